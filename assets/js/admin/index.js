@@ -19,10 +19,13 @@ import { slotManager } from '../features/slots/slot-manager.js';
 import { inventoryManager } from '../features/inventory/inventory-manager.js';
 import { techManager } from '../features/techs/tech-manager.js';
 import { techStockManager } from '../features/techs/tech-stock-manager.js';
+import { scheduleManager } from '../features/schedules/schedule-manager.js';
+import { customerDetail } from '../features/customers/customer-detail.js';
+import { invoiceEditor } from '../features/invoices/invoice-editor.js';
 import { initMiniMap } from '../features/dashboard/mini-map.js';
 
 // Export for direct usage
-export { Bootloader, kanbanBoard, slotManager, inventoryManager, techManager, techStockManager, initMiniMap };
+export { Bootloader, kanbanBoard, slotManager, inventoryManager, techManager, techStockManager, scheduleManager, customerDetail, invoiceEditor, initMiniMap };
 
 // Register components
 function registerComponents() {
@@ -37,6 +40,9 @@ function registerComponents() {
     window.Alpine.data('inventoryManager', inventoryManager);
     window.Alpine.data('techManager', techManager);
     window.Alpine.data('techStockManager', techStockManager);
+    window.Alpine.data('scheduleManager', scheduleManager);
+    window.Alpine.data('customerDetail', customerDetail);
+    window.Alpine.data('invoiceEditor', invoiceEditor);
 
     // Also expose globally for compatibility with existing templates
     window.kanbanBoard = kanbanBoard;
@@ -44,6 +50,9 @@ function registerComponents() {
     window.inventoryManager = inventoryManager;
     window.techManager = techManager;
     window.techStockManager = techStockManager;
+    window.scheduleManager = scheduleManager;
+    window.customerDetail = customerDetail;
+    window.invoiceEditor = invoiceEditor;
 
     // Initialize Mini Map (if element exists)
     initMiniMap();
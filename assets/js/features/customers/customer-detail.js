@@ -0,0 +1,73 @@
+/**
+ * Customer Detail Component - Alpine.js data component
+ * Edit profile/notes and merge duplicate customers
+ * @module features/customers/customer-detail
+ */
+
+import { apiClient } from '../../core/api-client.js';
+import { toast } from '../../core/toast.js';
+
+/**
+ * Define the Customer Detail Alpine.js component
+ * @param {string} customerId - Primary customer ID
+ * @returns {Object} Alpine.js component
+ */
+export function customerDetail(customerId) {
+    return {
+        customerId,
+        saving: false,
+        query: '',
+        results: [],
+
+        async save(form) {
+            this.saving = true;
+            try {
+                const response = await apiClient.post(`/admin/api/customers/${this.customerId}`, new FormData(form));
+                if (!response.ok) throw new Error();
+                toast.success('Đã lưu thông tin khách hàng');
+            } catch (e) {
+                toast.error('Không thể lưu thông tin');
+            } finally {
+                this.saving = false;
+            }
+        },
+
+        async search() {
+            if (this.query.trim().length < 2) {
+                this.results = [];
+                return;
+            }
+            try {
+                const data = await apiClient.get(`/admin/api/customers/search?q=${encodeURIComponent(this.query)}`);
+                this.results = data.items || [];
+            } catch (e) {
+                this.results = [];
+            }
+        },
+
+        async merge(duplicateId, phone) {
+            if (window.Swal) {
+                const res = await Swal.fire({
+                    title: 'Gộp khách hàng?',
+                    text: `Toàn bộ đơn của ${phone} sẽ chuyển về khách này. Không thể hoàn tác.`,
+                    icon: 'warning',
+                    showCancelButton: true,
+                    confirmButtonText: 'Gộp',
+                    cancelButtonText: 'Hủy',
+                });
+                if (!res.isConfirmed) return;
+            }
+
+            const response = await apiClient.post(`/admin/api/customers/${this.customerId}/merge`, { duplicate_id: duplicateId });
+            const result = await response.json();
+            if (!response.ok) {
+                toast.error(result.error || 'Không thể gộp khách hàng');
+                return;
+            }
+            toast.success('Đã gộp khách hàng');
+            window.location.reload();
+        },
+    };
+}
+
+export default customerDetail;
@@ -0,0 +1,18 @@
+/**
+ * Customers Module Entry Point
+ * @module features/customers
+ */
+
+import { customerDetail } from './customer-detail.js';
+
+/**
+ * Initialize customer features
+ */
+export function init() {
+    if (typeof Alpine !== 'undefined') {
+        Alpine.data('customerDetail', customerDetail);
+        console.log('[Customers] Alpine component registered');
+    }
+}
+
+export { customerDetail };
@@ -16,6 +16,7 @@ export function inventoryManager(initialItems = []) {
     return {
         items: Array.isArray(initialItems) ? initialItems : [],
         alerts: [],
+        ledgerIssues: [],
         showImportModal: false,
         showAddModal: false,
         searchQuery: '',
@@ -29,9 +30,11 @@ export function inventoryManager(initialItems = []) {
             price: '',
             stock_quantity: 0,
             unit: 'cái',
+            vat_rate: '',
+            tracking: '',
             description: ''
         },
-        importData: { product_id: '', quantity: 1, note: '' },
+        importData: { product_id: '', quantity: 1, unit_cost: '', lots: '', note: '' },
         loading: false,
         message: '',
         success: false,
@@ -52,6 +55,7 @@ export function inventoryManager(initialItems = []) {
         init() {
             console.log('[InventoryManager] Init with', this.items.length, 'items');
             this.loadAlerts();
+            this.loadLedgerCheck();
         },
 
         async loadAlerts() {
@@ -66,6 +70,82 @@ export function inventoryManager(initialItems = []) {
             }
         },
 
+        async loadLedgerCheck() {
+            try {
+                const res = await fetch('/admin/tools/inventory/ledger');
+                if (res.ok) {
+                    const data = await res.json();
+                    this.ledgerIssues = data.discrepancies || [];
+                }
+            } catch (e) {
+                console.error('[InventoryManager] Error checking ledger:', e);
+            }
+        },
+
+        async draftPurchaseOrders() {
+            this.loading = true;
+            try {
+                const res = await fetch('/admin/tools/purchasing/draft-from-alerts', { method: 'POST' });
+                const data = await res.json();
+                if (res.ok && data.success) {
+                    toast.success(data.message);
+                    if (data.orders && data.orders.length > 0) {
+                        setTimeout(() => window.location.href = '/admin/tools/purchasing?status=draft', 800);
+                    }
+                } else {
+                    toast.error(data.error || 'Không thể tạo PO nháp');
+                }
+            } catch (e) {
+                toast.error('Lỗi kết nối');
+            } finally {
+                this.loading = false;
+            }
+        },
+
+        async rebuildFromLedger() {
+            if (!confirm('Ghi đè số tồn theo sổ kho cho ' + this.ledgerIssues.length + ' số dư lệch?')) return;
+            try {
+                const res = await fetch('/admin/tools/inventory/ledger/rebuild', { method: 'POST' });
+                const data = await res.json();
+                if (res.ok && data.success) {
+                    toast.success(data.message);
+                    setTimeout(() => window.location.reload(), 800);
+                } else {
+                    toast.error(data.error || 'Không thể đồng bộ sổ kho');
+                }
+            } catch (e) {
+                toast.error('Lỗi kết nối');
+            }
+        },
+
+        // Serial / lot tracking of the item picked for import
+        get importTracking() {
+            const item = this.items.find(i => i.id === this.importData.product_id);
+            return item ? item.tracking || '' : '';
+        },
+
+        async submitImport() {
+            this.loading = true;
+            try {
+                const res = await fetch('/admin/tools/inventory/import', {
+                    method: 'POST',
+                    body: new URLSearchParams(this.importData)
+                });
+                const data = await res.json();
+                if (res.ok) {
+                    toast.success('Đã nhập kho');
+                    this.showImportModal = false;
+                    setTimeout(() => window.location.reload(), 800);
+                } else {
+                    toast.error(data.error || 'Không thể nhập kho');
+                }
+            } catch (e) {
+                toast.error('Lỗi kết nối');
+            } finally {
+                this.loading = false;
+            }
+        },
+
         async saveItem() {
             if (!this.newItem.name || !this.newItem.price) {
                 this.showMessage('Vui lòng điền tên và giá', false);
@@ -99,6 +179,8 @@ export function inventoryManager(initialItems = []) {
                 fd.append('price', this.newItem.price);
                 fd.append('stock_quantity', this.newItem.stock_quantity);
                 fd.append('unit', this.newItem.unit);
+                fd.append('vat_rate', this.newItem.vat_rate);
+                fd.append('tracking', this.newItem.tracking);
                 fd.append('description', this.newItem.description);
 
                 const response = await fetch(url, { method: 'POST', body: fd });
@@ -139,6 +221,8 @@ export function inventoryManager(initialItems = []) {
                 price: item.price,
                 stock_quantity: item.stock_quantity,
                 unit: item.unit || 'cái',
+                vat_rate: item.vat_rate || '',
+                tracking: item.tracking || '',
                 description: item.description || ''
             };
             this.showAddModal = true;
@@ -178,6 +262,8 @@ export function inventoryManager(initialItems = []) {
                 price: '',
                 stock_quantity: 0,
                 unit: 'cái',
+                vat_rate: '',
+                tracking: '',
                 description: ''
             };
         },
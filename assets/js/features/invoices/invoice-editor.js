@@ -0,0 +1,90 @@
+/**
+ * Invoice Editor Component - Alpine.js data component
+ * Invoice/line discounts and extra labor hours on an unpaid invoice
+ * @module features/invoices/invoice-editor
+ */
+
+import { apiClient } from '../../core/api-client.js';
+import { toast } from '../../core/toast.js';
+
+/**
+ * Define the Invoice Editor Alpine.js component
+ * @param {string} invoiceId - Invoice ID
+ * @param {boolean} locked - Paid invoices are read-only
+ * @param {Object} initial - Saved adjustments (invoices.adjustments)
+ * @returns {Object} Alpine.js component
+ */
+export function invoiceEditor(invoiceId, locked, initial = {}) {
+    return {
+        invoiceId,
+        locked,
+        saving: false,
+        discount: initial.discount || { type: '', value: 0, reason: '' },
+        lineDiscounts: initial.line_discounts || {},
+        labor: initial.extra_labor || [],
+        lineNames: {},
+
+        formatMoney(value) {
+            return new Intl.NumberFormat('vi-VN').format(value || 0) + ' đ';
+        },
+
+        async editLine(key) {
+            if (!window.Swal) return;
+            const name = this.lineNames[key] || key;
+            const current = this.lineDiscounts[key] || { type: 'percent', value: '', reason: '' };
+            const res = await Swal.fire({
+                title: `Giảm giá: ${name}`,
+                html: `
+                    <select id="ld-type" class="swal2-select">
+                        <option value="percent" ${current.type === 'percent' ? 'selected' : ''}>%</option>
+                        <option value="fixed" ${current.type === 'fixed' ? 'selected' : ''}>Số tiền</option>
+                    </select>
+                    <input id="ld-value" type="number" min="0" class="swal2-input" placeholder="Giá trị" value="${current.value}">
+                    <input id="ld-reason" class="swal2-input" placeholder="Lý do">`,
+                showCancelButton: true,
+                confirmButtonText: 'Áp dụng',
+                cancelButtonText: 'Hủy',
+                didOpen: () => { document.getElementById('ld-reason').value = current.reason || ''; },
+                preConfirm: () => ({
+                    type: document.getElementById('ld-type').value,
+                    value: parseFloat(document.getElementById('ld-value').value) || 0,
+                    reason: document.getElementById('ld-reason').value.trim(),
+                }),
+            });
+            if (!res.isConfirmed) return;
+            this.lineDiscounts = { ...this.lineDiscounts, [key]: res.value };
+        },
+
+        addLabor() {
+            this.labor.push({ description: '', hours: 1, rate: 0, source: 'admin' });
+        },
+
+        async save() {
+            const adjustments = {
+                discount: this.discount.type ? this.discount : null,
+                line_discounts: this.lineDiscounts,
+                extra_labor: this.labor.filter(l => l.source !== 'tech'),
+            };
+
+            this.saving = true;
+            try {
+                const response = await apiClient.post(`/admin/api/invoices/${this.invoiceId}/adjustments`, {
+                    adjustments_json: JSON.stringify(adjustments),
+                });
+                const result = await response.json();
+                if (!response.ok) {
+                    toast.error(result.error || 'Không thể lưu điều chỉnh');
+                    return;
+                }
+                toast.success(`Đã tính lại: ${this.formatMoney(result.total_amount)}`);
+                window.location.reload();
+            } catch (e) {
+                toast.error('Không thể lưu điều chỉnh');
+            } finally {
+                this.saving = false;
+            }
+        },
+    };
+}
+
+export default invoiceEditor;
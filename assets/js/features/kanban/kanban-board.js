@@ -43,6 +43,8 @@ export function kanbanBoard(initialActive = [], initialCompleted = []) {
         completedJobs: [],
         editingJob: {},
         selectedJob: null,
+        timeline: [], // [NEW] Booking event history for the view modal
+        timelineLoading: false,
         searchQuery: '',
         showMapModal: false,
         // Heavy objects stored on instance (non-reactive)
@@ -62,6 +64,7 @@ export function kanbanBoard(initialActive = [], initialCompleted = []) {
         // mapMarkers: {}, // Heavy object
         assignTechId: '',
         busyTechs: [], // [NEW] Track busy technicians for assignment modal
+        dispatchSuggestions: [], // [NEW] Ranked technicians from dispatch engine
 
         // === Lifecycle ===
         init() {
@@ -222,6 +225,19 @@ export function kanbanBoard(initialActive = [], initialCompleted = []) {
                     this.handleNewBooking(event.data);
                     break;
 
+                case 'contract.reminder':
+                    // Contract visit soon, customer has no email: call them
+                    toast.warning(`📞 Gọi nhắc lịch bảo trì: ${event.data.customer_name} (${event.data.phone}) - ${event.data.booking_time}`);
+                    break;
+
+                case 'quote.decided':
+                    if (event.data.status === 'approved') {
+                        toast.success(`✍️ Khách đã duyệt báo giá đơn #${event.data.booking_id}`);
+                    } else {
+                        toast.warning(`Khách từ chối báo giá đơn #${event.data.booking_id}: ${event.data.reject_reason || ''}`);
+                    }
+                    break;
+
                 case 'tech.status_changed':
                     // [NEW] Update tech status in real-time
                     const tech = this.techs.find(t => t.id === event.data.id);
@@ -408,6 +424,10 @@ export function kanbanBoard(initialActive = [], initialCompleted = []) {
                 if (response.ok) {
                     this.moveJobLocally(jobId, targetCol);
                     toast.success('Đã cập nhật trạng thái');
+                } else if (response.status === 409) {
+                    // Rejected by the booking lifecycle
+                    const data = await response.json().catch(() => ({}));
+                    toast.error(data.error || 'Không thể chuyển sang trạng thái này');
                 } else {
                     toast.error('Không thể cập nhật trạng thái');
                 }
@@ -421,6 +441,42 @@ export function kanbanBoard(initialActive = [], initialCompleted = []) {
             this.selectedJob = job;
             document.getElementById('modal-view-job')?.showModal?.() ||
                 (document.getElementById('modal-view-job').checked = true);
+            this.loadTimeline(job.id);
+        },
+
+        // [NEW] Persistent booking timeline (who did what, when)
+        async loadTimeline(jobId) {
+            this.timeline = [];
+            this.timelineLoading = true;
+            try {
+                this.timeline = await apiClient.get(`/admin/api/bookings/${jobId}/timeline`);
+            } catch (err) {
+                console.error('[Kanban] Failed to load timeline', err);
+            } finally {
+                this.timelineLoading = false;
+            }
+        },
+
+        timelineLabel(ev) {
+            switch (ev.type) {
+                case 'assigned': return `Giao cho ${ev.data?.technician_name || 'thợ'}`;
+                case 'reassigned': return `Chuyển giao cho ${ev.data?.technician_name || 'thợ khác'}`;
+                case 'recalled': return 'Thu hồi về chờ xử lý';
+                case 'rescheduled': return `Đổi lịch: ${ev.data?.from_time || '?'} → ${ev.data?.to_time || '?'}`;
+                case 'cancelled': return `Hủy đơn${ev.note ? ': ' + ev.note : ''}`;
+                default: return `${getStatusLabel(ev.from_status)} → ${getStatusLabel(ev.to_status)}`;
+            }
+        },
+
+        timelineTime(created) {
+            const d = new Date((created || '').replace(' ', 'T'));
+            return isNaN(d.getTime()) ? created : d.toLocaleString('vi-VN', { hour: '2-digit', minute: '2-digit', day: '2-digit', month: '2-digit' });
+        },
+
+        timelineActor(ev) {
+            const roles = { admin: 'Admin', tech: 'Thợ', customer: 'Khách hàng', system: 'Hệ thống' };
+            const who = ev.actor?.name || roles[ev.actor?.type] || '';
+            return ev.actor?.source ? `${who} (${ev.actor.source})` : who;
         },
 
         openEdit(job) {
@@ -448,6 +504,10 @@ export function kanbanBoard(initialActive = [], initialCompleted = []) {
         async openAssignModal(job) {
             this.selectedJob = job;
             this.assignTechId = '';
+            this.dispatchSuggestions = [];
+            apiClient.get(`/admin/api/bookings/${job.id}/dispatch`)
+                .then(list => { this.dispatchSuggestions = (list || []).filter(c => c.eligible).slice(0, 3); })
+                .catch(err => console.error('[Kanban] Dispatch suggestions failed', err));
 
             // 1. Fetch latest data
             const allJobs = await this.refreshBookings();
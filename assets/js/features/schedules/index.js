@@ -0,0 +1,18 @@
+/**
+ * Schedules Module Entry Point
+ * @module features/schedules
+ */
+
+import { scheduleManager } from './schedule-manager.js';
+
+/**
+ * Initialize technician schedule features
+ */
+export function init() {
+    if (typeof Alpine !== 'undefined') {
+        Alpine.data('scheduleManager', scheduleManager);
+        console.log('[Schedules] Alpine component registered');
+    }
+}
+
+export { scheduleManager };
@@ -0,0 +1,134 @@
+/**
+ * Schedule Manager Component - Alpine.js data component
+ * Weekly working hours per technician, leave approval and holidays
+ * @module features/schedules/schedule-manager
+ */
+
+import { apiClient } from '../../core/api-client.js';
+import { toast } from '../../core/toast.js';
+import { formatDate } from '../../core/utils.js';
+
+export const WEEKDAYS = ['Chủ nhật', 'Thứ 2', 'Thứ 3', 'Thứ 4', 'Thứ 5', 'Thứ 6', 'Thứ 7'];
+
+export const TIME_OFF_KINDS = {
+    leave: 'Nghỉ phép',
+    sick: 'Nghỉ ốm',
+    holiday: 'Nghỉ lễ',
+};
+
+/**
+ * Define the Schedule Manager Alpine.js component
+ * @returns {Object} Alpine.js component
+ */
+export function scheduleManager() {
+    return {
+        weekdays: WEEKDAYS,
+        kinds: TIME_OFF_KINDS,
+        techId: '',
+        hours: [],
+        timeOff: [],
+        loading: false,
+        saving: false,
+        form: { technician_id: '', kind: 'leave', date_from: '', date_to: '', start_time: '', end_time: '', reason: '' },
+
+        async selectTech(id) {
+            this.techId = id;
+            this.form.technician_id = id;
+            if (!id) {
+                this.hours = [];
+                this.timeOff = [];
+                return;
+            }
+
+            this.loading = true;
+            try {
+                const data = await apiClient.get(`/admin/api/techs/${id}/schedule`);
+                this.hours = data.hours || [];
+                this.timeOff = data.time_off || [];
+            } catch (e) {
+                toast.error('Không tải được lịch làm việc');
+            } finally {
+                this.loading = false;
+            }
+        },
+
+        addShift(weekday) {
+            this.hours.push({ weekday, start_time: '08:00', end_time: '17:00' });
+        },
+
+        removeShift(index) {
+            this.hours.splice(index, 1);
+        },
+
+        shiftsOf(weekday) {
+            return this.hours
+                .map((h, index) => ({ ...h, index }))
+                .filter(h => h.weekday === weekday);
+        },
+
+        // Copy Monday shifts to Tuesday..Saturday
+        copyWeekdays() {
+            const monday = this.hours.filter(h => h.weekday === 1);
+            this.hours = this.hours.filter(h => h.weekday === 0 || h.weekday === 1);
+            for (let d = 2; d <= 6; d++) {
+                monday.forEach(h => this.hours.push({ weekday: d, start_time: h.start_time, end_time: h.end_time }));
+            }
+        },
+
+        async saveHours() {
+            this.saving = true;
+            try {
+                const hours = this.hours.map(h => ({ weekday: Number(h.weekday), start_time: h.start_time, end_time: h.end_time }));
+                await apiClient.postJSON(`/admin/api/techs/${this.techId}/working-hours`, { hours });
+                toast.success('Đã lưu giờ làm việc');
+            } catch (e) {
+                toast.error('Giờ làm việc không hợp lệ');
+            } finally {
+                this.saving = false;
+            }
+        },
+
+        async createTimeOff() {
+            const response = await apiClient.post('/admin/api/time-off', this.form);
+            const result = await response.json();
+            if (!response.ok) {
+                toast.error(result.error || 'Không thể lưu ngày nghỉ');
+                return;
+            }
+            toast.success('Đã thêm ngày nghỉ');
+            this.form = { ...this.form, date_from: '', date_to: '', start_time: '', end_time: '', reason: '' };
+            if (this.techId) this.selectTech(this.techId);
+        },
+
+        async review(id, action) {
+            let note = '';
+            if (action === 'reject' && window.Swal) {
+                const res = await Swal.fire({ title: 'Lý do từ chối', input: 'text', showCancelButton: true });
+                if (!res.isConfirmed) return;
+                note = res.value || '';
+            }
+
+            const response = await apiClient.post(`/admin/api/time-off/${id}/review`, { action, note });
+            const result = await response.json();
+            if (!response.ok) {
+                toast.error(result.error || 'Không thể xử lý yêu cầu');
+                return;
+            }
+            toast.success(action === 'approve' ? 'Đã duyệt nghỉ phép' : 'Đã từ chối');
+            document.getElementById(`timeoff-${id}`)?.remove();
+        },
+
+        kindLabel(kind) {
+            return this.kinds[kind] || kind;
+        },
+
+        rangeLabel(off) {
+            let label = formatDate(off.date_from);
+            if (off.date_to && off.date_to !== off.date_from) label += ' → ' + formatDate(off.date_to);
+            if (off.start_time && off.end_time) label += ` (${off.start_time} - ${off.end_time})`;
+            return label;
+        },
+    };
+}
+
+export default scheduleManager;
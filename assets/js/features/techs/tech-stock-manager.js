@@ -24,12 +24,14 @@ export function techStockManager(initData = { techs: [], items: [] }) {
         transfer: {
             techId: '',
             itemId: '',
-            quantity: 1
+            quantity: 1,
+            lots: '' // Serials / lots of tracked items, one per line
         },
 
         selectedItem: null,
         selectedTech: null,
         techItems: [],
+        techLots: {}, // Serials / lots on the truck by item
 
         init() {
             console.log('[TechStockManager] Initialized');
@@ -56,6 +58,7 @@ export function techStockManager(initData = { techs: [], items: [] }) {
             this.transfer.techId = techId;
             this.transfer.itemId = '';
             this.transfer.quantity = 1;
+            this.transfer.lots = '';
             this.selectedItem = null;
             this.showTransferModal = true;
         },
@@ -67,9 +70,11 @@ export function techStockManager(initData = { techs: [], items: [] }) {
             try {
                 const data = await apiClient.get(`/admin/tools/tech-stock/${techId}`);
                 this.techItems = data.items || [];
+                this.techLots = data.lots || {};
             } catch (e) {
                 console.error('Error loading tech inventory:', e);
                 this.techItems = [];
+                this.techLots = {};
                 toast.error('Không thể tải danh sách vật tư của thợ');
             }
         },
@@ -87,6 +92,7 @@ export function techStockManager(initData = { techs: [], items: [] }) {
                 fd.append('technician_id', this.transfer.techId);
                 fd.append('item_id', this.transfer.itemId);
                 fd.append('quantity', this.transfer.quantity);
+                fd.append('lots', this.transfer.lots);
 
                 const res = await fetch('/admin/tools/tech-stock/transfer', {
                     method: 'POST',
@@ -111,7 +117,7 @@ export function techStockManager(initData = { techs: [], items: [] }) {
 
                     this.showMessage(data.message || 'Cấp hàng thành công!', true);
                     this.showTransferModal = false;
-                    this.transfer = { techId: '', itemId: '', quantity: 1 };
+                    this.transfer = { techId: '', itemId: '', quantity: 1, lots: '' };
                 } else {
                     this.showMessage(data.error || 'Lỗi không xác định', false);
                 }
@@ -1,6 +1,6 @@
 // assets/js/public.js
 
-console.log('✅ Public JS Loaded (v14) - Simplified GPS');
+console.log('✅ Public JS Loaded (v15) - Simplified GPS');
 
 /**
  * 1. BOOKING WIZARD CONTROLLER
@@ -633,4 +633,248 @@ window.pageController = function () {
             }));
         }
     };
-};
\ No newline at end of file
+};
+/**
+ * 3. CUSTOMER PORTAL CONTROLLER
+ * Trang /b/{token}: theo dõi trạng thái, vị trí thợ, đổi lịch / hủy lịch
+ */
+window.bookingPortal = function (initial) {
+    return {
+        token: initial.token,
+        status: initial.status,
+        statusLabel: initial.statusLabel,
+        bookingTime: initial.bookingTime,
+        canModify: initial.canModify,
+        customer: initial.customer,
+        map: null,
+        techMarker: null,
+        stream: null,
+
+        // Reschedule
+        showReschedule: false,
+        selectedDate: '',
+        loadingSlots: false,
+        availableSlots: [],
+        slotId: '',
+
+        // Cancel
+        showCancel: false,
+        cancelReason: '',
+        cancelNote: '',
+        submitting: false,
+
+        labels: {
+            pending: 'Đang tìm thợ phù hợp',
+            assigned: 'Đã có thợ nhận lịch',
+            accepted: 'Thợ đã xác nhận',
+            moving: 'Thợ đang trên đường đến',
+            arrived: 'Thợ đã đến nơi',
+            working: 'Đang sửa chữa',
+            quoting: 'Đang báo giá',
+            completed: 'Hoàn thành',
+            cancelled: 'Đã hủy'
+        },
+
+        init() {
+            this.connect();
+            if (this.status === 'moving' || this.status === 'arrived') {
+                this.$nextTick(() => this.initMap());
+            }
+        },
+
+        connect() {
+            this.stream = new EventSource(`/b/${this.token}/stream`);
+            this.stream.onmessage = (msg) => {
+                let event;
+                try { event = JSON.parse(msg.data); } catch (_) { return; }
+                const data = event.data || {};
+
+                switch (event.type) {
+                    case 'location.updated':
+                        this.updateTech(data.latitude, data.longitude);
+                        break;
+                    case 'job.status_changed':
+                        this.setStatus(data.status);
+                        break;
+                    case 'job.rescheduled':
+                        if (data.new_time) this.bookingTime = data.new_time;
+                        break;
+                }
+            };
+        },
+
+        setStatus(status) {
+            this.status = status;
+            this.statusLabel = this.labels[status] || status;
+            this.canModify = ['pending', 'assigned', 'accepted'].includes(status);
+            if (status === 'moving' || status === 'arrived') {
+                this.$nextTick(() => this.initMap());
+            }
+            if (status === 'completed' || status === 'cancelled') {
+                this.stream && this.stream.close();
+            }
+        },
+
+        async initMap() {
+            if (this.map || typeof L === 'undefined' || !this.$refs.map) return;
+            const center = this.customer.lat ? [this.customer.lat, this.customer.long] : [21.0285, 105.8542];
+            this.map = L.map(this.$refs.map).setView(center, 14);
+            L.tileLayer('https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png', {
+                attribution: '&copy; OpenStreetMap'
+            }).addTo(this.map);
+            if (this.customer.lat) {
+                L.marker(center).addTo(this.map).bindPopup('Địa chỉ của bạn');
+            }
+
+            try {
+                const res = await fetch(`/b/${this.token}/location`);
+                if (res.ok) {
+                    const loc = await res.json();
+                    this.updateTech(loc.latitude, loc.longitude);
+                }
+            } catch (_) { /* chưa có vị trí */ }
+        },
+
+        updateTech(lat, lng) {
+            if (!this.map || !lat || !lng) return;
+            if (!this.techMarker) {
+                this.techMarker = L.circleMarker([lat, lng], { radius: 9, color: '#2563eb', fillOpacity: 0.9 })
+                    .addTo(this.map).bindPopup('Kỹ thuật viên');
+            } else {
+                this.techMarker.setLatLng([lat, lng]);
+            }
+        },
+
+        async fetchSlots() {
+            if (!this.selectedDate) return;
+            this.loadingSlots = true;
+            this.availableSlots = [];
+            this.slotId = '';
+            try {
+                const res = await fetch(`/b/${this.token}/slots?date=${this.selectedDate}`);
+                if (res.ok) this.availableSlots = await res.json();
+            } catch (error) {
+                Swal.fire('Lỗi', 'Không thể tải lịch trống. Vui lòng thử lại sau.', 'error');
+            } finally {
+                this.loadingSlots = false;
+            }
+        },
+
+        async post(path, body) {
+            this.submitting = true;
+            try {
+                const res = await fetch(`/b/${this.token}/${path}`, { method: 'POST', body: body });
+                const data = await res.json();
+                if (!res.ok) throw new Error(data.error || 'Có lỗi xảy ra');
+                return data;
+            } finally {
+                this.submitting = false;
+            }
+        },
+
+        async reschedule() {
+            if (!this.slotId) {
+                Swal.fire('Chưa chọn giờ', 'Vui lòng chọn một khung giờ phù hợp.', 'warning');
+                return;
+            }
+            const body = new FormData();
+            body.append('slot_id', this.slotId);
+            try {
+                const data = await this.post('reschedule', body);
+                this.bookingTime = data.booking_time;
+                this.showReschedule = false;
+                Swal.fire('Thành công', data.message, 'success');
+            } catch (err) {
+                Swal.fire('Không thể đổi lịch', err.message, 'error');
+                this.fetchSlots();
+            }
+        },
+
+        async cancel() {
+            if (!this.cancelReason) {
+                Swal.fire('Thiếu lý do', 'Vui lòng chọn lý do hủy.', 'warning');
+                return;
+            }
+            const body = new FormData();
+            body.append('reason', this.cancelReason);
+            body.append('note', this.cancelNote);
+            try {
+                const data = await this.post('cancel', body);
+                this.showCancel = false;
+                this.setStatus('cancelled');
+                Swal.fire('Đã hủy', data.message, 'success');
+            } catch (err) {
+                Swal.fire('Không thể hủy', err.message, 'error');
+            }
+        }
+    };
+};
+
+/**
+ * 4. QUOTE APPROVAL
+ * Trang /quote/{hash}: khách ký duyệt hoặc từ chối báo giá tại chỗ
+ */
+window.quoteApproval = function (hash, status) {
+    return {
+        hash: hash,
+        status: status,
+        reason: '',
+        submitting: false,
+        pad: null,
+
+        init() {
+            this.$nextTick(() => {
+                const canvas = this.$refs.canvas;
+                if (!canvas || typeof SignaturePad === 'undefined') return;
+                const ratio = Math.max(window.devicePixelRatio || 1, 1);
+                canvas.width = canvas.offsetWidth * ratio;
+                canvas.height = canvas.offsetHeight * ratio;
+                canvas.getContext('2d').scale(ratio, ratio);
+                this.pad = new SignaturePad(canvas, { penColor: 'rgb(0, 0, 0)' });
+            });
+        },
+
+        clear() {
+            if (this.pad) this.pad.clear();
+        },
+
+        signatureBlob() {
+            const parts = this.pad.toDataURL('image/png').split(',');
+            const bytes = atob(parts[1]);
+            const buffer = new Uint8Array(bytes.length);
+            for (let i = 0; i < bytes.length; i++) {
+                buffer[i] = bytes.charCodeAt(i);
+            }
+            return new Blob([buffer], { type: 'image/png' });
+        },
+
+        async submit(decision) {
+            if (!this.pad || this.pad.isEmpty()) {
+                Swal.fire('Thiếu chữ ký', 'Vui lòng ký vào ô bên trên.', 'warning');
+                return;
+            }
+            if (decision === 'reject' && !this.reason.trim()) {
+                Swal.fire('Thiếu lý do', 'Vui lòng cho biết lý do từ chối.', 'warning');
+                return;
+            }
+
+            const body = new FormData();
+            body.append('decision', decision);
+            body.append('reason', this.reason);
+            body.append('signature', this.signatureBlob(), 'signature.png');
+
+            this.submitting = true;
+            try {
+                const res = await fetch(`/quote/${this.hash}/decision`, { method: 'POST', body });
+                const data = await res.json();
+                if (!res.ok) throw new Error(data.error || 'Có lỗi xảy ra');
+                this.status = data.status;
+                Swal.fire('Thành công', data.message, 'success');
+            } catch (err) {
+                Swal.fire('Không thể xác nhận', err.message, 'error');
+            } finally {
+                this.submitting = false;
+            }
+        }
+    };
+};
@@ -0,0 +1,96 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"hvac-system/internal/core"
+)
+
+func sampleInvoice() *core.EInvoice {
+	return &core.EInvoice{
+		InvoiceCode:   "HD-2026-000012",
+		Template:      core.EInvoiceTemplate,
+		Series:        "C26TAA",
+		IssuedAt:      time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC),
+		Currency:      "VND",
+		PaymentMethod: "TM",
+		Seller:        core.EInvoiceParty{Name: "Công ty TNHH Điện Lạnh", TaxCode: "0312345678"},
+		Buyer:         core.EInvoiceParty{Name: "Nguyễn Văn A", Address: "12 Lê Lợi & Q1"},
+		Lines: []core.EInvoiceLine{
+			{No: 1, Nature: core.EInvoiceLineGoods, Name: "Gas R32", Unit: "kg", Quantity: 1.5, UnitPrice: 200000, Discount: 30000, Amount: 270000, VATCode: "10", Tax: 27000},
+			{No: 2, Nature: core.EInvoiceLineGoods, Name: "Giờ công", Quantity: 1, UnitPrice: 100000, Amount: 100000, VATCode: core.VATExempt},
+		},
+		Discount:     30000,
+		Amount:       370000,
+		Tax:          27000,
+		Total:        397000,
+		TotalInWords: core.VNDInWords(397000),
+	}
+}
+
+func TestBuildXML(t *testing.T) {
+	data, err := BuildXML(sampleInvoice())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc xmlInvoice
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	g := doc.Data.General
+	if g.Version != XMLVersion || g.Series != "C26TAA" || g.Date != "2026-03-11" || g.Related != nil {
+		t.Errorf("TTChung = %+v", g)
+	}
+	lines := doc.Data.Content.Lines
+	if len(lines) != 2 || lines[0].Quantity != "1.5" || lines[0].Discount != "30000" || lines[1].VATRate != "KCT" {
+		t.Errorf("HHDVu = %+v", lines)
+	}
+	totals := doc.Data.Content.Totals
+	if len(totals.Groups) != 2 || totals.Total != "397000" || totals.InWords != "Ba trăm chín mươi bảy nghìn đồng" {
+		t.Errorf("TToan = %+v", totals)
+	}
+	if doc.Data.Content.Buyer.Address != "12 Lê Lợi & Q1" {
+		t.Errorf("buyer address = %q", doc.Data.Content.Buyer.Address)
+	}
+}
+
+func TestFileProviderLifecycle(t *testing.T) {
+	p := NewFileProvider(t.TempDir())
+
+	first, err := p.Submit(sampleInvoice())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Number != "1" || len(first.LookupCode) != 10 {
+		t.Errorf("receipt = %+v", first)
+	}
+	ref := core.EInvoiceRef{Template: "1", Series: "C26TAA", Number: first.Number, IssuedAt: first.IssuedAt}
+
+	replacement := sampleInvoice()
+	replacement.Replaces = &ref
+	replacement.Reason = "Sai tên người mua"
+	second, err := p.Replace(ref, replacement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Number != "2" {
+		t.Errorf("replacement number = %s; want 2", second.Number)
+	}
+	data, err := p.Download(core.EInvoiceRef{Series: "C26TAA", Number: "2"})
+	if err != nil || !strings.Contains(string(data), "<SHDCLQuan>1</SHDCLQuan>") {
+		t.Errorf("replacement does not reference invoice 1: %v", err)
+	}
+
+	if err := p.Cancel(ref, "Hủy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Cancel(ref, "Hủy"); err != ErrAlreadyCancelled {
+		t.Errorf("second cancel: err = %v", err)
+	}
+	if err := p.Cancel(core.EInvoiceRef{Series: "C26TAA", Number: "9"}, "Hủy"); err != ErrUnknownInvoice {
+		t.Errorf("unknown invoice: err = %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package einvoice
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hvac-system/internal/core"
+)
+
+var (
+	ErrUnknownInvoice   = errors.New("e-invoice not found at the provider")
+	ErrAlreadyCancelled = errors.New("e-invoice already cancelled at the provider")
+)
+
+// FileProvider is a fake provider for local testing: each e-invoice is
+// numbered per series and written to <dir>/<series>/<number>.xml, and a
+// cancellation leaves <number>.cancelled.json next to it.
+type FileProvider struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) Name() string {
+	return "file"
+}
+
+func (p *FileProvider) Submit(inv *core.EInvoice) (*core.EInvoiceReceipt, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seriesDir := filepath.Join(p.dir, inv.Series)
+	if err := os.MkdirAll(seriesDir, 0o755); err != nil {
+		return nil, err
+	}
+	issued, err := filepath.Glob(filepath.Join(seriesDir, "*.xml"))
+	if err != nil {
+		return nil, err
+	}
+	inv.Number = strconv.Itoa(len(issued) + 1)
+
+	data, err := BuildXML(inv)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(p.path(inv.Series, inv.Number, ".xml"), data, 0o644); err != nil {
+		return nil, err
+	}
+
+	code := make([]byte, 5)
+	if _, err := rand.Read(code); err != nil {
+		return nil, err
+	}
+	return &core.EInvoiceReceipt{
+		Number:        inv.Number,
+		LookupCode:    strings.ToUpper(hex.EncodeToString(code)),
+		TransactionID: inv.Series + "-" + inv.Number,
+		IssuedAt:      inv.IssuedAt,
+	}, nil
+}
+
+func (p *FileProvider) Replace(original core.EInvoiceRef, inv *core.EInvoice) (*core.EInvoiceReceipt, error) {
+	if _, err := os.Stat(p.path(original.Series, original.Number, ".xml")); err != nil {
+		return nil, ErrUnknownInvoice
+	}
+	return p.Submit(inv)
+}
+
+func (p *FileProvider) Cancel(ref core.EInvoiceRef, reason string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := os.Stat(p.path(ref.Series, ref.Number, ".xml")); err != nil {
+		return ErrUnknownInvoice
+	}
+	notice := p.path(ref.Series, ref.Number, ".cancelled.json")
+	if _, err := os.Stat(notice); err == nil {
+		return ErrAlreadyCancelled
+	}
+	data, err := json.MarshalIndent(map[string]string{
+		"series":       ref.Series,
+		"number":       ref.Number,
+		"reason":       reason,
+		"cancelled_at": time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(notice, data, 0o644)
+}
+
+func (p *FileProvider) Download(ref core.EInvoiceRef) ([]byte, error) {
+	data, err := os.ReadFile(p.path(ref.Series, ref.Number, ".xml"))
+	if err != nil {
+		return nil, ErrUnknownInvoice
+	}
+	return data, nil
+}
+
+func (p *FileProvider) path(series, number, ext string) string {
+	return filepath.Join(p.dir, series, fmt.Sprintf("%s%s", number, ext))
+}
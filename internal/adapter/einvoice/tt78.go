@@ -0,0 +1,220 @@
+// Package einvoice holds the e-invoice provider adapters and the XML format
+// of Circular 78/2021/TT-BTC (Decision 1450/QĐ-TCT, version 2.0.1).
+package einvoice
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"hvac-system/internal/core"
+)
+
+// XMLVersion is the PBan of the generated documents
+const XMLVersion = "2.0.1"
+
+// Replacement relation (TTHDLQuan.TCHDon) and kind of the related invoice
+// (LHDCLQuan: 1 = e-invoice under Decree 123)
+const (
+	relationReplace     = 1
+	relatedInvoiceKind  = 1
+	dateLayout          = "2006-01-02"
+	internalNumberField = "SoHoaDonNoiBo"
+)
+
+type xmlInvoice struct {
+	XMLName xml.Name    `xml:"HDon"`
+	Data    xmlData     `xml:"DLHDon"`
+	TaxCode string      `xml:"MCCQT"` // Filled by the tax authority
+	Signs   xmlSignList `xml:"DSCKS"`
+}
+
+type xmlData struct {
+	ID      string     `xml:"Id,attr"`
+	General xmlGeneral `xml:"TTChung"`
+	Content xmlContent `xml:"NDHDon"`
+}
+
+type xmlGeneral struct {
+	Version       string      `xml:"PBan"`
+	Title         string      `xml:"THDon"`
+	Template      string      `xml:"KHMSHDon"`
+	Series        string      `xml:"KHHDon"`
+	Number        string      `xml:"SHDon"`
+	Date          string      `xml:"NLap"`
+	Currency      string      `xml:"DVTTe"`
+	Rate          int         `xml:"TGia"`
+	PaymentMethod string      `xml:"HTTToan"`
+	Related       *xmlRelated `xml:"TTHDLQuan,omitempty"`
+	Other         xmlOther    `xml:"TTKhac"`
+}
+
+type xmlRelated struct {
+	Relation int    `xml:"TCHDon"`
+	Kind     int    `xml:"LHDCLQuan"`
+	Template string `xml:"KHMSHDCLQuan"`
+	Series   string `xml:"KHHDCLQuan"`
+	Number   string `xml:"SHDCLQuan"`
+	Date     string `xml:"NLHDCLQuan"`
+	Note     string `xml:"GChu,omitempty"`
+}
+
+type xmlOther struct {
+	Fields []xmlField `xml:"TTin"`
+}
+
+type xmlField struct {
+	Name  string `xml:"TTruong"`
+	Type  string `xml:"KDLieu"`
+	Value string `xml:"DLieu"`
+}
+
+type xmlContent struct {
+	Seller xmlSeller `xml:"NBan"`
+	Buyer  xmlBuyer  `xml:"NMua"`
+	Lines  []xmlLine `xml:"DSHHDVu>HHDVu"`
+	Totals xmlTotals `xml:"TToan"`
+}
+
+type xmlSeller struct {
+	Name        string `xml:"Ten"`
+	TaxCode     string `xml:"MST"`
+	Address     string `xml:"DChi"`
+	Phone       string `xml:"SDThoai,omitempty"`
+	Email       string `xml:"DCTDTu,omitempty"`
+	BankAccount string `xml:"STKNHang,omitempty"`
+	BankName    string `xml:"TNHang,omitempty"`
+}
+
+type xmlBuyer struct {
+	Name    string `xml:"Ten"`
+	TaxCode string `xml:"MST,omitempty"`
+	Address string `xml:"DChi"`
+	Phone   string `xml:"SDThoai,omitempty"`
+	Email   string `xml:"DCTDTu,omitempty"`
+	Contact string `xml:"HVTNMHang,omitempty"`
+}
+
+type xmlLine struct {
+	Nature    int    `xml:"TChat"`
+	No        int    `xml:"STT"`
+	Name      string `xml:"THHDVu"`
+	Unit      string `xml:"DVTinh,omitempty"`
+	Quantity  string `xml:"SLuong"`
+	UnitPrice string `xml:"DGia"`
+	Discount  string `xml:"STCKhau,omitempty"`
+	Amount    string `xml:"ThTien"`
+	VATRate   string `xml:"TSuat"`
+}
+
+type xmlTotals struct {
+	Groups   []xmlVATGroup `xml:"THTTLTSuat>LTSuat"`
+	Amount   string        `xml:"TgTCThue"`
+	Tax      string        `xml:"TgTThue"`
+	Discount string        `xml:"TTCKTMai"`
+	Total    string        `xml:"TgTTTBSo"`
+	InWords  string        `xml:"TgTTTBChu"`
+}
+
+type xmlVATGroup struct {
+	VATRate string `xml:"TSuat"`
+	Amount  string `xml:"ThTien"`
+	Tax     string `xml:"TThue"`
+}
+
+type xmlSignList struct {
+	Seller string `xml:"NBan"` // Signed by the provider with the seller's certificate
+}
+
+// BuildXML renders an e-invoice as the XML document sent to the provider
+func BuildXML(inv *core.EInvoice) ([]byte, error) {
+	doc := xmlInvoice{
+		Data: xmlData{
+			ID: "data",
+			General: xmlGeneral{
+				Version:       XMLVersion,
+				Title:         "Hóa đơn giá trị gia tăng",
+				Template:      inv.Template,
+				Series:        inv.Series,
+				Number:        inv.Number,
+				Date:          inv.LocalIssuedAt().Format(dateLayout),
+				Currency:      inv.Currency,
+				Rate:          1,
+				PaymentMethod: inv.PaymentMethod,
+				Other: xmlOther{Fields: []xmlField{
+					{Name: internalNumberField, Type: "string", Value: inv.InvoiceCode},
+				}},
+			},
+			Content: xmlContent{
+				Seller: xmlSeller{
+					Name:        inv.Seller.Name,
+					TaxCode:     inv.Seller.TaxCode,
+					Address:     inv.Seller.Address,
+					Phone:       inv.Seller.Phone,
+					Email:       inv.Seller.Email,
+					BankAccount: inv.Seller.BankAccount,
+					BankName:    inv.Seller.BankName,
+				},
+				Buyer: xmlBuyer{
+					Name:    inv.Buyer.Name,
+					TaxCode: inv.Buyer.TaxCode,
+					Address: inv.Buyer.Address,
+					Phone:   inv.Buyer.Phone,
+					Email:   inv.Buyer.Email,
+					Contact: inv.Buyer.Contact,
+				},
+				Totals: xmlTotals{
+					Amount:   amount(inv.Amount),
+					Tax:      amount(inv.Tax),
+					Discount: amount(inv.Discount),
+					Total:    amount(inv.Total),
+					InWords:  inv.TotalInWords,
+				},
+			},
+		},
+	}
+	if r := inv.Replaces; r != nil {
+		doc.Data.General.Related = &xmlRelated{
+			Relation: relationReplace,
+			Kind:     relatedInvoiceKind,
+			Template: r.Template,
+			Series:   r.Series,
+			Number:   r.Number,
+			Date:     r.LocalIssuedAt().Format(dateLayout),
+			Note:     inv.Reason,
+		}
+	}
+	for _, l := range inv.Lines {
+		line := xmlLine{
+			Nature:    l.Nature,
+			No:        l.No,
+			Name:      l.Name,
+			Unit:      l.Unit,
+			Quantity:  strconv.FormatFloat(l.Quantity, 'f', -1, 64),
+			UnitPrice: amount(l.UnitPrice),
+			Amount:    amount(l.Amount),
+			VATRate:   core.VATLabel(l.VATCode),
+		}
+		if l.Discount > 0 {
+			line.Discount = amount(l.Discount)
+		}
+		doc.Data.Content.Lines = append(doc.Data.Content.Lines, line)
+	}
+	for _, g := range inv.VATGroups() {
+		doc.Data.Content.Totals.Groups = append(doc.Data.Content.Totals.Groups, xmlVATGroup{
+			VATRate: core.VATLabel(g.VATCode),
+			Amount:  amount(g.Amount),
+			Tax:     amount(g.Tax),
+		})
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// amount formats whole dong without separators
+func amount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 0, 64)
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+)
+
+type PBAccountingRepo struct {
+	app pbCore.App
+}
+
+func NewAccountingRepo(app pbCore.App) core.AccountingRepository {
+	return &PBAccountingRepo{app: app}
+}
+
+func (r *PBAccountingRepo) Invoices(from, to time.Time) ([]*core.AccountingInvoice, error) {
+	var rows []struct {
+		ID             string  `db:"id"`
+		Code           string  `db:"invoice_code"`
+		BookingID      string  `db:"booking_id"`
+		IssuedAt       string  `db:"issued_at"`
+		PaymentMethod  string  `db:"payment_method"`
+		Total          float64 `db:"total_amount"`
+		Tax            float64 `db:"tax_total"`
+		Commission     float64 `db:"tech_commission"`
+		CustomerName   string  `db:"customer_name"`
+		TechnicianName string  `db:"technician_name"`
+	}
+	err := r.app.DB().Select(
+		"i.id as id",
+		"i.invoice_code as invoice_code",
+		"i.booking_id as booking_id",
+		"i.issued_at as issued_at",
+		"i.payment_method as payment_method",
+		"i.total_amount as total_amount",
+		"i.tax_total as tax_total",
+		"i.tech_commission as tech_commission",
+		"COALESCE(b.customer_name, '') as customer_name",
+		"COALESCE(t.name, '') as technician_name",
+	).
+		From("invoices i").
+		LeftJoin("bookings b", dbx.NewExp("b.id = i.booking_id")).
+		LeftJoin("technicians t", dbx.NewExp("t.id = b.technician_id")).
+		Where(dbx.And(
+			dbx.NewExp("i.issued_at >= {:from} AND i.issued_at < {:to}", timeRange(from, to)),
+			dbx.NewExp("i.invoice_code != ''"),
+			dbx.Not(dbx.HashExp{"i.status": core.InvoiceCancelled}),
+		)).
+		OrderBy("i.issued_at").
+		All(&rows)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	invoices := make([]*core.AccountingInvoice, 0, len(rows))
+	byID := make(map[string]*core.AccountingInvoice, len(rows))
+	byBooking := make(map[string][]*core.AccountingInvoice, len(rows))
+	ids := make([]interface{}, 0, len(rows))
+	bookingIDs := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		issuedAt, _ := time.Parse(core.DateTimeLayout, row.IssuedAt)
+		inv := &core.AccountingInvoice{
+			ID:             row.ID,
+			Code:           row.Code,
+			IssuedAt:       issuedAt,
+			CustomerName:   row.CustomerName,
+			TechnicianName: row.TechnicianName,
+			PaymentMethod:  row.PaymentMethod,
+			Tax:            row.Tax,
+			Total:          row.Total,
+			Commission:     row.Commission,
+		}
+		invoices = append(invoices, inv)
+		byID[inv.ID] = inv
+		ids = append(ids, inv.ID)
+		if row.BookingID != "" {
+			if _, seen := byBooking[row.BookingID]; !seen {
+				bookingIDs = append(bookingIDs, row.BookingID)
+			}
+			byBooking[row.BookingID] = append(byBooking[row.BookingID], inv)
+		}
+	}
+
+	items, err := r.app.FindAllRecords("invoice_items", dbx.In("invoice_id", ids...))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		inv := byID[item.GetString("invoice_id")]
+		if inv == nil {
+			continue
+		}
+		line := core.InvoiceLine{
+			Kind:     item.GetString("kind"),
+			Name:     item.GetString("item_name"),
+			VATCode:  item.GetString("vat_rate"),
+			Subtotal: item.GetFloat("subtotal"),
+			Discount: item.GetFloat("discount"),
+			Tax:      item.GetFloat("tax"),
+			Total:    item.GetFloat("total"),
+		}
+		if line.Subtotal == 0 && line.Total > 0 {
+			// Lines priced before VAT and discounts were stored per line
+			line.Subtotal = line.Total
+		}
+		inv.Lines = append(inv.Lines, line)
+	}
+
+	if len(bookingIDs) == 0 {
+		return invoices, nil
+	}
+	// Cost of parts at import price, from the job reports of each booking
+	var costs []struct {
+		BookingID string  `db:"booking_id"`
+		Cost      float64 `db:"cost"`
+	}
+	err = r.app.DB().Select(
+		"jr.booking_id as booking_id",
+		"COALESCE(SUM(CASE WHEN jp.cost_total > 0 THEN jp.cost_total ELSE jp.quantity * ii.price_import END), 0) as cost",
+	).
+		From("job_parts jp").
+		InnerJoin("job_reports jr", dbx.NewExp("jr.id = jp.job_report_id")).
+		InnerJoin("inventory_items ii", dbx.NewExp("ii.id = jp.item_id")).
+		Where(dbx.In("jr.booking_id", bookingIDs...)).
+		GroupBy("jr.booking_id").
+		All(&costs)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range costs {
+		// Parts are costed once per booking, on its first invoice of the range
+		if list := byBooking[c.BookingID]; len(list) > 0 {
+			list[0].Cost = c.Cost
+		}
+	}
+	return invoices, nil
+}
+
+func (r *PBAccountingRepo) Payments(from, to time.Time) ([]*core.AccountingPayment, error) {
+	var rows []struct {
+		ID           string  `db:"id"`
+		InvoiceCode  string  `db:"invoice_code"`
+		CustomerName string  `db:"customer_name"`
+		Kind         string  `db:"kind"`
+		Method       string  `db:"method"`
+		Reference    string  `db:"reference"`
+		Amount       float64 `db:"amount"`
+		PaidAt       string  `db:"paid_at"`
+	}
+	err := r.app.DB().Select(
+		"p.id as id",
+		"COALESCE(i.invoice_code, '') as invoice_code",
+		"COALESCE(b.customer_name, '') as customer_name",
+		"p.kind as kind",
+		"p.method as method",
+		"p.reference as reference",
+		"p.amount as amount",
+		"p.paid_at as paid_at",
+	).
+		From("payments p").
+		LeftJoin("invoices i", dbx.NewExp("i.id = p.invoice_id")).
+		LeftJoin("bookings b", dbx.NewExp("b.id = i.booking_id")).
+		Where(dbx.And(
+			dbx.NewExp("p.paid_at >= {:from} AND p.paid_at < {:to}", timeRange(from, to)),
+			dbx.In("p.status", core.PaymentPosted, core.PaymentVoidPending),
+		)).
+		OrderBy("p.paid_at").
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]*core.AccountingPayment, 0, len(rows))
+	for _, row := range rows {
+		paidAt, _ := time.Parse(core.DateTimeLayout, row.PaidAt)
+		payments = append(payments, &core.AccountingPayment{
+			ID:           row.ID,
+			InvoiceCode:  row.InvoiceCode,
+			CustomerName: row.CustomerName,
+			Kind:         row.Kind,
+			Method:       row.Method,
+			Reference:    row.Reference,
+			Amount:       row.Amount,
+			PaidAt:       paidAt,
+		})
+	}
+	return payments, nil
+}
+
+func timeRange(from, to time.Time) dbx.Params {
+	return dbx.Params{
+		"from": from.UTC().Format(core.DateTimeLayout),
+		"to":   to.UTC().Format(core.DateTimeLayout),
+	}
+}
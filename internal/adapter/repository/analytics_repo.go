@@ -30,7 +30,7 @@ func (r *PBAnalyticsRepo) GetDailyRevenue(start, end string) ([]core.RevenueStat
 		"SUM(total_amount) as total",
 	).
 		From("invoices").
-		Where(dbx.NewExp("status = 'paid' AND created >= {:start} AND created <= {:end}", dbx.Params{
+		Where(dbx.NewExp("status IN ('paid', 'overpaid') AND created >= {:start} AND created <= {:end}", dbx.Params{
 			"start": start,
 			"end":   end,
 		})).
@@ -82,7 +82,7 @@ func (r *PBAnalyticsRepo) GetTotalRevenue() (float64, error) {
 	}
 	err := r.app.DB().Select("SUM(total_amount) as total").
 		From("invoices").
-		Where(dbx.HashExp{"status": "paid"}).
+		Where(dbx.In("status", "paid", "overpaid")).
 		One(&result)
 
 	if err != nil {
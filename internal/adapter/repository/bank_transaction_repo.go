@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type PBBankTransactionRepo struct {
+	app pbCore.App
+}
+
+func NewBankTransactionRepo(app pbCore.App) core.BankTransactionRepository {
+	return &PBBankTransactionRepo{app: app}
+}
+
+func (r *PBBankTransactionRepo) toDomain(record *pbCore.Record) *core.BankTransaction {
+	return &core.BankTransaction{
+		ID:                 record.Id,
+		Source:             record.GetString("source"),
+		ExternalID:         record.GetString("external_id"),
+		AccountNumber:      record.GetString("account_number"),
+		Amount:             record.GetFloat("amount"),
+		Description:        record.GetString("description"),
+		TransactedAt:       record.GetDateTime("transacted_at").Time(),
+		Status:             record.GetString("status"),
+		InvoiceID:          record.GetString("invoice_id"),
+		SuggestedInvoiceID: record.GetString("suggested_invoice_id"),
+		MatchNote:          record.GetString("match_note"),
+		ReviewedBy:         record.GetString("reviewed_by"),
+		Created:            record.GetString("created"),
+	}
+}
+
+func (r *PBBankTransactionRepo) setFields(record *pbCore.Record, tx *core.BankTransaction) {
+	record.Set("source", tx.Source)
+	record.Set("external_id", tx.ExternalID)
+	record.Set("account_number", tx.AccountNumber)
+	record.Set("amount", tx.Amount)
+	record.Set("description", tx.Description)
+	if dt, err := types.ParseDateTime(tx.TransactedAt); err == nil {
+		record.Set("transacted_at", dt)
+	}
+	record.Set("status", tx.Status)
+	record.Set("invoice_id", tx.InvoiceID)
+	record.Set("suggested_invoice_id", tx.SuggestedInvoiceID)
+	record.Set("match_note", tx.MatchNote)
+	record.Set("reviewed_by", tx.ReviewedBy)
+}
+
+func (r *PBBankTransactionRepo) GetByID(id string) (*core.BankTransaction, error) {
+	record, err := r.app.FindRecordById("bank_transactions", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(record), nil
+}
+
+func (r *PBBankTransactionRepo) ListByStatus(status string, limit int) ([]*core.BankTransaction, error) {
+	filter, params := "", dbx.Params{}
+	if status != "" {
+		filter, params = "status = {:status}", dbx.Params{"status": status}
+	}
+	records, err := r.app.FindRecordsByFilter("bank_transactions", filter, "-transacted_at,-created", limit, 0, params)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*core.BankTransaction, 0, len(records))
+	for _, rec := range records {
+		txs = append(txs, r.toDomain(rec))
+	}
+	return txs, nil
+}
+
+func (r *PBBankTransactionRepo) Create(tx *core.BankTransaction) error {
+	existing, err := r.app.FindFirstRecordByFilter("bank_transactions",
+		"source = {:source} && external_id = {:ref}", dbx.Params{"source": tx.Source, "ref": tx.ExternalID})
+	if err == nil && existing != nil {
+		return core.ErrDuplicateTransaction
+	}
+
+	collection, err := r.app.FindCollectionByNameOrId("bank_transactions")
+	if err != nil {
+		return err
+	}
+	record := pbCore.NewRecord(collection)
+	r.setFields(record, tx)
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+	tx.ID = record.Id
+	tx.Created = record.GetString("created")
+	return nil
+}
+
+func (r *PBBankTransactionRepo) Update(tx *core.BankTransaction) error {
+	record, err := r.app.FindRecordById("bank_transactions", tx.ID)
+	if err != nil {
+		return err
+	}
+	r.setFields(record, tx)
+	return r.app.Save(record)
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+)
+
+type PBBookingEventRepo struct {
+	app pbCore.App
+}
+
+func NewBookingEventRepo(app pbCore.App) core.BookingEventRepository {
+	return &PBBookingEventRepo{app: app}
+}
+
+func (r *PBBookingEventRepo) toDomain(record *pbCore.Record) *core.BookingEvent {
+	var data map[string]interface{}
+	_ = record.UnmarshalJSONField("data", &data)
+
+	return &core.BookingEvent{
+		ID:         record.Id,
+		BookingID:  record.GetString("booking_id"),
+		Type:       record.GetString("type"),
+		FromStatus: record.GetString("from_status"),
+		ToStatus:   record.GetString("to_status"),
+		Actor: core.Actor{
+			Type:   record.GetString("actor_type"),
+			ID:     record.GetString("actor_id"),
+			Name:   record.GetString("actor_name"),
+			Source: record.GetString("source"),
+		},
+		Note:    record.GetString("note"),
+		Data:    data,
+		Created: record.GetString("created"),
+	}
+}
+
+// Append writes a new timeline entry
+func (r *PBBookingEventRepo) Append(event *core.BookingEvent) error {
+	collection, err := r.app.FindCollectionByNameOrId("booking_events")
+	if err != nil {
+		return err
+	}
+
+	actorType := event.Actor.Type
+	if actorType == "" {
+		actorType = core.ActorSystem
+	}
+
+	record := pbCore.NewRecord(collection)
+	record.Set("booking_id", event.BookingID)
+	record.Set("type", event.Type)
+	record.Set("from_status", event.FromStatus)
+	record.Set("to_status", event.ToStatus)
+	record.Set("actor_type", actorType)
+	record.Set("actor_id", event.Actor.ID)
+	record.Set("actor_name", event.Actor.Name)
+	record.Set("source", event.Actor.Source)
+	record.Set("note", event.Note)
+	if len(event.Data) > 0 {
+		record.Set("data", event.Data)
+	}
+
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+
+	event.ID = record.Id
+	event.Created = record.GetString("created")
+	return nil
+}
+
+// ListByBooking returns the timeline oldest first
+func (r *PBBookingEventRepo) ListByBooking(bookingID string) ([]*core.BookingEvent, error) {
+	records, err := r.app.FindRecordsByFilter(
+		"booking_events",
+		"booking_id = {:id}",
+		"created",
+		0, 0,
+		dbx.Params{"id": bookingID},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*core.BookingEvent, 0, len(records))
+	for _, rec := range records {
+		events = append(events, r.toDomain(rec))
+	}
+	return events, nil
+}
+
+// ListByActor returns the events of one type triggered by an actor in [from, to), oldest first
+func (r *PBBookingEventRepo) ListByActor(actorID, eventType string, from, to time.Time) ([]*core.BookingEvent, error) {
+	records, err := r.app.FindRecordsByFilter(
+		"booking_events",
+		"actor_id = {:actor} && type = {:type} && created >= {:from} && created < {:to}",
+		"created",
+		0, 0,
+		dbx.Params{
+			"actor": actorID,
+			"type":  eventType,
+			"from":  from.UTC().Format(core.DateTimeLayout),
+			"to":    to.UTC().Format(core.DateTimeLayout),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*core.BookingEvent, 0, len(records))
+	for _, rec := range records {
+		events = append(events, r.toDomain(rec))
+	}
+	return events, nil
+}
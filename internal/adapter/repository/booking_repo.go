@@ -1,8 +1,8 @@
 package repository
 
 import (
+	"errors"
 	"hvac-system/internal/core"
-	"time"
 
 	"github.com/pocketbase/dbx"
 	pbCore "github.com/pocketbase/pocketbase/core"
@@ -19,7 +19,10 @@ func NewBookingRepo(app pbCore.App) core.BookingRepository {
 
 // Mapping helper: Record -> Domain Model
 func (r *PBBookingRepo) toDomain(record *pbCore.Record) *core.Booking {
-	slotID := record.GetString("slot_id")
+	slotID := record.GetString("time_slot_id")
+	if slotID == "" {
+		slotID = record.GetString("slot_id") // Legacy field name
+	}
 	var slotIDPtr *string
 	if slotID != "" {
 		slotIDPtr = &slotID
@@ -37,12 +40,21 @@ func (r *PBBookingRepo) toDomain(record *pbCore.Record) *core.Booking {
 		Brand:            record.GetString("brand"),
 		JobStatus:        record.GetString("job_status"),
 		BookingTime:      record.GetString("booking_time"),
+		CustomerID:       record.GetString("customer_id"),
+		ContractID:       record.GetString("contract_id"),
 		TechnicianID:     record.GetString("technician_id"),
 		SlotID:           slotIDPtr,
 		Created:          record.GetString("created"),
 		Updated:          record.GetString("updated"),
 		Lat:              record.GetFloat("lat"),
 		Long:             record.GetFloat("long"),
+		MovingStartAt:    record.GetString("moving_start_at"),
+		ArrivedAt:        record.GetString("arrived_at"),
+		StartedAt:        record.GetString("started_at"),
+		CompletedAt:      record.GetString("completed_at"),
+		AccessToken:      record.GetString("access_token"),
+		CancelReason:     record.GetString("cancel_reason"),
+		TechNotes:        record.GetString("tech_notes"),
 	}
 }
 
@@ -55,6 +67,18 @@ func (r *PBBookingRepo) GetByID(id string) (*core.Booking, error) {
 	return r.toDomain(record), nil
 }
 
+// GetByAccessToken finds the booking behind a customer portal link
+func (r *PBBookingRepo) GetByAccessToken(token string) (*core.Booking, error) {
+	if token == "" {
+		return nil, errors.New("empty access token")
+	}
+	record, err := r.app.FindFirstRecordByFilter("bookings", "access_token = {:token}", dbx.Params{"token": token})
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(record), nil
+}
+
 // Create persists a new booking
 func (r *PBBookingRepo) Create(b *core.Booking, files []*filesystem.File) error {
 	collection, err := r.app.FindCollectionByNameOrId("bookings")
@@ -74,6 +98,13 @@ func (r *PBBookingRepo) Create(b *core.Booking, files []*filesystem.File) error
 	record.Set("device_type", b.DeviceType)
 	record.Set("brand", b.Brand)
 	record.Set("job_status", b.JobStatus)
+	record.Set("access_token", b.AccessToken)
+	if b.CustomerID != "" {
+		record.Set("customer_id", b.CustomerID)
+	}
+	if b.ContractID != "" {
+		record.Set("contract_id", b.ContractID)
+	}
 
 	if b.BookingTime != "" {
 		record.Set("booking_time", b.BookingTime)
@@ -119,26 +150,38 @@ func (r *PBBookingRepo) Update(b *core.Booking) error {
 	// Update fields
 	record.Set("technician_id", b.TechnicianID)
 	record.Set("job_status", b.JobStatus)
+	record.Set("cancel_reason", b.CancelReason)
 
 	if b.SlotID != nil {
-		record.Set("slot_id", *b.SlotID)
+		record.Set("time_slot_id", *b.SlotID)
 	} else {
-		record.Set("slot_id", nil)
+		record.Set("time_slot_id", nil)
 	}
 
-	// Fields that might change during update
-	// Note: Be careful not to overwrite with empty values if partial update is intended
-	// Logic here assumes 'b' is a complete object.
+	if b.BookingTime != "" {
+		record.Set("booking_time", b.BookingTime)
+	}
+	if b.Lat != 0 {
+		record.Set("lat", b.Lat)
+		record.Set("long", b.Long)
+	}
 
-	// ... (Map other fields as necessary, for now we focus on critical ones for State changes)
+	// Lifecycle timestamps: empty means cleared (e.g. recall to pending)
+	for field, value := range map[string]string{
+		"moving_start_at": b.MovingStartAt,
+		"arrived_at":      b.ArrivedAt,
+		"started_at":      b.StartedAt,
+		"completed_at":    b.CompletedAt,
+	} {
+		if value == "" {
+			record.Set(field, nil)
+		} else {
+			record.Set(field, value)
+		}
+	}
 
-	// Reset specific fields if needed (like technician removal)
 	if b.TechnicianID == "" {
 		record.Set("technician_id", nil)
-		// Assuming we also reset related timestamps as per recall logic
-		record.Set("moving_start_at", nil)
-		record.Set("working_start_at", nil)
-		record.Set("completed_at", nil)
 	}
 
 	if err := r.app.Save(record); err != nil {
@@ -203,30 +246,6 @@ func (r *PBBookingRepo) FindScheduledByTechnician(techID string) ([]*core.Bookin
 	return bookings, nil
 }
 
-// UpdateStatus updates only the job_status field of a booking
-func (r *PBBookingRepo) UpdateStatus(bookingID string, status string) error {
-	record, err := r.app.FindRecordById("bookings", bookingID)
-	if err != nil {
-		return err
-	}
-
-	record.Set("job_status", status)
-
-	// Set status-specific timestamps
-	switch status {
-	case "moving":
-		record.Set("moving_start_at", time.Now())
-	case "arrived":
-		record.Set("arrived_at", time.Now())
-	case "working":
-		record.Set("working_start_at", time.Now())
-	case "completed":
-		record.Set("completed_at", time.Now())
-	}
-
-	return r.app.Save(record)
-}
-
 // UpdateLocation updates the latitude and longitude of a booking
 func (r *PBBookingRepo) UpdateLocation(bookingID string, lat float64, lng float64) error {
 	record, err := r.app.FindRecordById("bookings", bookingID)
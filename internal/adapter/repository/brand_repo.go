@@ -77,8 +77,8 @@ func (r *BrandRepo) recordToModel(record *core.Record) *domain.Brand {
 		Logo:        record.GetString("logo"),
 		Icon:        record.GetString("logo"), // Fallback to Logo
 		Hotline:     record.GetString("hotline"),
-		Address:     "", // Settings doesn't have address yet
-		Email:       "", // Settings doesn't have email yet
+		Address:     record.GetString("address"),
+		Email:       record.GetString("email"),
 
 		SeoTitle:       record.GetString("seo_title"),
 		SeoDescription: record.GetString("seo_description"),
@@ -96,6 +96,22 @@ func (r *BrandRepo) recordToModel(record *core.Record) *domain.Brand {
 		BankOwner:   record.GetString("bank_owner"),
 		QrTemplate:  record.GetString("qr_template"),
 
+		AutoAssignEnabled:      record.GetBool("auto_assign_enabled"),
+		AutoAssignAfterMinutes: record.GetInt("auto_assign_after_minutes"),
+		TravelBufferMinutes:    record.GetInt("travel_buffer_minutes"),
+
+		DefaultVAT:    record.GetString("default_vat"),
+		LaborHourRate: record.GetFloat("labor_hour_rate"),
+
+		BankWebhookSecret: record.GetString("bank_webhook_secret"),
+
+		PayrollNoShowPenalty: record.GetFloat("payroll_no_show_penalty"),
+
+		TaxCode:        record.GetString("tax_code"),
+		EInvoiceSeries: record.GetString("einvoice_series"),
+
+		InventoryCosting: record.GetString("inventory_costing"),
+
 		Created: record.GetString("created"),
 		Updated: record.GetString("updated"),
 	}
@@ -105,7 +121,8 @@ func (r *BrandRepo) recordToModel(record *core.Record) *domain.Brand {
 func (r *BrandRepo) modelToRecord(brand *domain.Brand, record *core.Record) {
 	record.Set("company_name", brand.CompanyName)
 	record.Set("hotline", brand.Hotline)
-	// Address/Email not in settings yet
+	record.Set("address", brand.Address)
+	record.Set("email", brand.Email)
 
 	record.Set("seo_title", brand.SeoTitle)
 	record.Set("seo_description", brand.SeoDescription)
@@ -123,4 +140,16 @@ func (r *BrandRepo) modelToRecord(brand *domain.Brand, record *core.Record) {
 	record.Set("bank_account", brand.BankAccount)
 	record.Set("bank_owner", brand.BankOwner)
 	record.Set("qr_template", brand.QrTemplate)
+
+	record.Set("auto_assign_enabled", brand.AutoAssignEnabled)
+	record.Set("auto_assign_after_minutes", brand.AutoAssignAfterMinutes)
+	record.Set("travel_buffer_minutes", brand.TravelBufferMinutes)
+
+	record.Set("default_vat", brand.DefaultVAT)
+	record.Set("labor_hour_rate", brand.LaborHourRate)
+	record.Set("bank_webhook_secret", brand.BankWebhookSecret)
+	record.Set("payroll_no_show_penalty", brand.PayrollNoShowPenalty)
+	record.Set("tax_code", brand.TaxCode)
+	record.Set("einvoice_series", brand.EInvoiceSeries)
+	record.Set("inventory_costing", brand.InventoryCosting)
 }
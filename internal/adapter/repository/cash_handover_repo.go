@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type PBCashHandoverRepo struct {
+	app pbCore.App
+}
+
+func NewCashHandoverRepo(app pbCore.App) core.CashHandoverRepository {
+	return &PBCashHandoverRepo{app: app}
+}
+
+func (r *PBCashHandoverRepo) toDomain(record *pbCore.Record) *core.CashHandover {
+	return &core.CashHandover{
+		ID:             record.Id,
+		TechnicianID:   record.GetString("technician_id"),
+		TechnicianName: record.GetString("technician_name"),
+		Expected:       record.GetFloat("expected"),
+		Declared:       record.GetFloat("declared"),
+		Received:       record.GetFloat("received"),
+		Discrepancy:    record.GetFloat("discrepancy"),
+		Status:         record.GetString("status"),
+		Note:           record.GetString("note"),
+		SubmittedAt:    record.GetDateTime("submitted_at").Time(),
+		ConfirmedBy:    record.GetString("confirmed_by"),
+		ConfirmedAt:    record.GetDateTime("confirmed_at").Time(),
+		AdminNote:      record.GetString("admin_note"),
+		Created:        record.GetString("created"),
+	}
+}
+
+func (r *PBCashHandoverRepo) setFields(record *pbCore.Record, h *core.CashHandover) {
+	record.Set("technician_id", h.TechnicianID)
+	record.Set("technician_name", h.TechnicianName)
+	record.Set("expected", h.Expected)
+	record.Set("declared", h.Declared)
+	record.Set("received", h.Received)
+	record.Set("discrepancy", h.Discrepancy)
+	record.Set("status", h.Status)
+	record.Set("note", h.Note)
+	if dt, err := types.ParseDateTime(h.SubmittedAt); err == nil {
+		record.Set("submitted_at", dt)
+	}
+	record.Set("confirmed_by", h.ConfirmedBy)
+	if !h.ConfirmedAt.IsZero() {
+		if dt, err := types.ParseDateTime(h.ConfirmedAt); err == nil {
+			record.Set("confirmed_at", dt)
+		}
+	}
+	record.Set("admin_note", h.AdminNote)
+}
+
+func (r *PBCashHandoverRepo) GetByID(id string) (*core.CashHandover, error) {
+	record, err := r.app.FindRecordById("cash_handovers", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(record), nil
+}
+
+func (r *PBCashHandoverRepo) ListByTechnician(techID string) ([]*core.CashHandover, error) {
+	records, err := r.app.FindRecordsByFilter("cash_handovers", "technician_id = {:tech}", "-submitted_at", 0, 0,
+		dbx.Params{"tech": techID})
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomainList(records), nil
+}
+
+func (r *PBCashHandoverRepo) ListByStatus(status string) ([]*core.CashHandover, error) {
+	records, err := r.app.FindRecordsByFilter("cash_handovers", "status = {:status}", "submitted_at", 0, 0,
+		dbx.Params{"status": status})
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomainList(records), nil
+}
+
+func (r *PBCashHandoverRepo) Create(h *core.CashHandover) error {
+	collection, err := r.app.FindCollectionByNameOrId("cash_handovers")
+	if err != nil {
+		return err
+	}
+	record := pbCore.NewRecord(collection)
+	r.setFields(record, h)
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+	h.ID = record.Id
+	h.Created = record.GetString("created")
+	return nil
+}
+
+func (r *PBCashHandoverRepo) Update(h *core.CashHandover) error {
+	record, err := r.app.FindRecordById("cash_handovers", h.ID)
+	if err != nil {
+		return err
+	}
+	r.setFields(record, h)
+	return r.app.Save(record)
+}
+
+func (r *PBCashHandoverRepo) toDomainList(records []*pbCore.Record) []*core.CashHandover {
+	handovers := make([]*core.CashHandover, 0, len(records))
+	for _, rec := range records {
+		handovers = append(handovers, r.toDomain(rec))
+	}
+	return handovers
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+)
+
+type PBContractRepo struct {
+	app pbCore.App
+}
+
+func NewContractRepo(app pbCore.App) core.ContractRepository {
+	return &PBContractRepo{app: app}
+}
+
+func (r *PBContractRepo) toDomain(record *pbCore.Record) *core.MaintenanceContract {
+	return &core.MaintenanceContract{
+		ID:              record.Id,
+		CustomerID:      record.GetString("customer_id"),
+		EquipmentIDs:    record.GetStringSlice("equipment_ids"),
+		ServiceID:       record.GetString("service_id"),
+		IntervalMonths:  record.GetInt("interval_months"),
+		Price:           record.GetFloat("price"),
+		StartDate:       record.GetString("start_date"),
+		EndDate:         record.GetString("end_date"),
+		NextServiceDate: record.GetString("next_service_date"),
+		Status:          record.GetString("status"),
+		LastBookingID:   record.GetString("last_booking_id"),
+		ReminderSentAt:  record.GetString("reminder_sent_at"),
+		Notes:           record.GetString("notes"),
+		Created:         record.GetString("created"),
+	}
+}
+
+func (r *PBContractRepo) setFields(record *pbCore.Record, c *core.MaintenanceContract) {
+	record.Set("customer_id", c.CustomerID)
+	record.Set("equipment_ids", c.EquipmentIDs)
+	record.Set("service_id", c.ServiceID)
+	record.Set("interval_months", c.IntervalMonths)
+	record.Set("price", c.Price)
+	record.Set("start_date", c.StartDate)
+	record.Set("end_date", c.EndDate)
+	record.Set("next_service_date", c.NextServiceDate)
+	record.Set("status", c.Status)
+	record.Set("last_booking_id", c.LastBookingID)
+	record.Set("reminder_sent_at", c.ReminderSentAt)
+	record.Set("notes", c.Notes)
+}
+
+func (r *PBContractRepo) list(records []*pbCore.Record) []*core.MaintenanceContract {
+	contracts := make([]*core.MaintenanceContract, 0, len(records))
+	for _, rec := range records {
+		contracts = append(contracts, r.toDomain(rec))
+	}
+	r.fillDetails(contracts)
+	return contracts
+}
+
+// fillDetails expands customer/service names and counts generated visits
+func (r *PBContractRepo) fillDetails(contracts []*core.MaintenanceContract) {
+	if len(contracts) == 0 {
+		return
+	}
+	byID := make(map[string]*core.MaintenanceContract, len(contracts))
+	ids := make([]interface{}, 0, len(contracts))
+	for _, c := range contracts {
+		byID[c.ID] = c
+		ids = append(ids, c.ID)
+	}
+
+	var rows []struct {
+		ID            string `db:"id"`
+		CustomerName  string `db:"customer_name"`
+		CustomerPhone string `db:"customer_phone"`
+		ServiceName   string `db:"service_name"`
+		VisitCount    int    `db:"visit_count"`
+	}
+	err := r.app.DB().Select(
+		"c.id as id",
+		"COALESCE(cu.name, '') as customer_name",
+		"COALESCE(cu.phone, '') as customer_phone",
+		"COALESCE(s.name, '') as service_name",
+		"(SELECT COUNT(*) FROM bookings b WHERE b.contract_id = c.id AND b.job_status != 'cancelled') as visit_count",
+	).
+		From("maintenance_contracts c").
+		LeftJoin("customers cu", dbx.NewExp("cu.id = c.customer_id")).
+		LeftJoin("services s", dbx.NewExp("s.id = c.service_id")).
+		Where(dbx.In("c.id", ids...)).
+		All(&rows)
+	if err != nil {
+		return
+	}
+	for _, row := range rows {
+		if c := byID[row.ID]; c != nil {
+			c.CustomerName = row.CustomerName
+			c.CustomerPhone = row.CustomerPhone
+			c.ServiceName = row.ServiceName
+			c.VisitCount = row.VisitCount
+		}
+	}
+}
+
+func (r *PBContractRepo) GetByID(id string) (*core.MaintenanceContract, error) {
+	record, err := r.app.FindRecordById("maintenance_contracts", id)
+	if err != nil {
+		return nil, err
+	}
+	c := r.toDomain(record)
+	r.fillDetails([]*core.MaintenanceContract{c})
+	return c, nil
+}
+
+func (r *PBContractRepo) List(status string) ([]*core.MaintenanceContract, error) {
+	filter := ""
+	params := dbx.Params{}
+	if status != "" {
+		filter = "status = {:status}"
+		params["status"] = status
+	}
+	records, err := r.app.FindRecordsByFilter("maintenance_contracts", filter, "next_service_date", 0, 0, params)
+	if err != nil {
+		return nil, err
+	}
+	return r.list(records), nil
+}
+
+func (r *PBContractRepo) ListByCustomer(customerID string) ([]*core.MaintenanceContract, error) {
+	records, err := r.app.FindRecordsByFilter("maintenance_contracts", "customer_id = {:customer}", "-created", 0, 0, dbx.Params{"customer": customerID})
+	if err != nil {
+		return nil, err
+	}
+	return r.list(records), nil
+}
+
+func (r *PBContractRepo) ListEndingBetween(from, to string) ([]*core.MaintenanceContract, error) {
+	records, err := r.app.FindRecordsByFilter(
+		"maintenance_contracts",
+		"(status = 'active' || status = 'expired') && end_date >= {:from} && end_date <= {:to}",
+		"end_date",
+		0, 0,
+		dbx.Params{"from": from, "to": to},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.list(records), nil
+}
+
+func (r *PBContractRepo) Create(c *core.MaintenanceContract) error {
+	collection, err := r.app.FindCollectionByNameOrId("maintenance_contracts")
+	if err != nil {
+		return err
+	}
+
+	record := pbCore.NewRecord(collection)
+	r.setFields(record, c)
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+
+	c.ID = record.Id
+	c.Created = record.GetString("created")
+	return nil
+}
+
+func (r *PBContractRepo) Update(c *core.MaintenanceContract) error {
+	record, err := r.app.FindRecordById("maintenance_contracts", c.ID)
+	if err != nil {
+		return err
+	}
+	r.setFields(record, c)
+	return r.app.Save(record)
+}
+
+// BookVisit runs in one transaction so a visit is never booked twice: either
+// the booking exists and the contract has moved to its next visit, or neither
+func (r *PBContractRepo) BookVisit(c *core.MaintenanceContract, booking *core.Booking) error {
+	return r.app.RunInTransaction(func(txApp pbCore.App) error {
+		if err := (&PBBookingRepo{app: txApp}).Create(booking, nil); err != nil {
+			return err
+		}
+		c.LastBookingID = booking.ID
+		return (&PBContractRepo{app: txApp}).Update(c)
+	})
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+)
+
+type PBCustomerRepo struct {
+	app pbCore.App
+}
+
+func NewCustomerRepo(app pbCore.App) core.CustomerRepository {
+	return &PBCustomerRepo{app: app}
+}
+
+func (r *PBCustomerRepo) toDomain(record *pbCore.Record) *core.Customer {
+	c := &core.Customer{
+		ID:      record.Id,
+		Phone:   record.GetString("phone"),
+		Name:    record.GetString("name"),
+		Email:   record.GetString("email"),
+		Notes:   record.GetString("notes"),
+		Created: record.GetString("created"),
+	}
+	_ = record.UnmarshalJSONField("addresses", &c.Addresses)
+	return c
+}
+
+func (r *PBCustomerRepo) setFields(record *pbCore.Record, c *core.Customer) {
+	record.Set("phone", c.Phone)
+	record.Set("name", c.Name)
+	record.Set("email", c.Email)
+	record.Set("notes", c.Notes)
+	if c.Addresses == nil {
+		c.Addresses = []core.CustomerAddress{}
+	}
+	record.Set("addresses", c.Addresses)
+}
+
+func (r *PBCustomerRepo) list(records []*pbCore.Record) []*core.Customer {
+	customers := make([]*core.Customer, 0, len(records))
+	for _, rec := range records {
+		customers = append(customers, r.toDomain(rec))
+	}
+	r.fillStats(customers)
+	return customers
+}
+
+// fillStats computes booking count, lifetime value and last service in two grouped queries
+func (r *PBCustomerRepo) fillStats(customers []*core.Customer) {
+	if len(customers) == 0 {
+		return
+	}
+	byID := make(map[string]*core.Customer, len(customers))
+	ids := make([]interface{}, 0, len(customers))
+	for _, c := range customers {
+		byID[c.ID] = c
+		ids = append(ids, c.ID)
+	}
+
+	var bookingStats []struct {
+		CustomerID      string `db:"customer_id"`
+		BookingCount    int    `db:"booking_count"`
+		CompletedCount  int    `db:"completed_count"`
+		LastServiceAt   string `db:"last_service_at"`
+		LastServiceName string `db:"last_service_name"`
+	}
+	err := r.app.DB().Select(
+		"b.customer_id as customer_id",
+		"COUNT(b.id) as booking_count",
+		"SUM(CASE WHEN b.job_status = 'completed' THEN 1 ELSE 0 END) as completed_count",
+		"COALESCE(MAX(CASE WHEN b.job_status = 'completed' THEN COALESCE(NULLIF(b.completed_at, ''), b.created) END), '') as last_service_at",
+		`COALESCE((SELECT s.name FROM bookings lb LEFT JOIN services s ON s.id = lb.service_id
+			WHERE lb.customer_id = b.customer_id AND lb.job_status = 'completed'
+			ORDER BY COALESCE(NULLIF(lb.completed_at, ''), lb.created) DESC LIMIT 1), '') as last_service_name`,
+	).
+		From("bookings b").
+		Where(dbx.In("b.customer_id", ids...)).
+		GroupBy("b.customer_id").
+		All(&bookingStats)
+	if err == nil {
+		for _, st := range bookingStats {
+			if c := byID[st.CustomerID]; c != nil {
+				c.BookingCount = st.BookingCount
+				c.CompletedCount = st.CompletedCount
+				c.LastServiceAt = st.LastServiceAt
+				c.LastServiceName = st.LastServiceName
+			}
+		}
+	}
+
+	var revenue []struct {
+		CustomerID string  `db:"customer_id"`
+		Total      float64 `db:"total"`
+	}
+	err = r.app.DB().Select(
+		"b.customer_id as customer_id",
+		"COALESCE(SUM(i.total_amount), 0) as total",
+	).
+		From("invoices i").
+		InnerJoin("bookings b", dbx.NewExp("b.id = i.booking_id")).
+		Where(dbx.And(dbx.In("b.customer_id", ids...), dbx.In("i.status", "paid", "overpaid"))).
+		GroupBy("b.customer_id").
+		All(&revenue)
+	if err == nil {
+		for _, rv := range revenue {
+			if c := byID[rv.CustomerID]; c != nil {
+				c.LifetimeValue = rv.Total
+			}
+		}
+	}
+}
+
+func (r *PBCustomerRepo) GetByID(id string) (*core.Customer, error) {
+	record, err := r.app.FindRecordById("customers", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.list([]*pbCore.Record{record})[0], nil
+}
+
+func (r *PBCustomerRepo) GetByPhone(phone string) (*core.Customer, error) {
+	record, err := r.app.FindFirstRecordByData("customers", "phone", phone)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(record), nil
+}
+
+func (r *PBCustomerRepo) Create(c *core.Customer) error {
+	collection, err := r.app.FindCollectionByNameOrId("customers")
+	if err != nil {
+		return err
+	}
+
+	record := pbCore.NewRecord(collection)
+	r.setFields(record, c)
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+
+	c.ID = record.Id
+	c.Created = record.GetString("created")
+	return nil
+}
+
+func (r *PBCustomerRepo) Update(c *core.Customer) error {
+	record, err := r.app.FindRecordById("customers", c.ID)
+	if err != nil {
+		return err
+	}
+	r.setFields(record, c)
+	return r.app.Save(record)
+}
+
+// Search matches name or phone; a phone-like query is normalized first
+func (r *PBCustomerRepo) Search(query string, limit, offset int) ([]*core.Customer, int, error) {
+	filter := "id != ''"
+	params := dbx.Params{}
+	if query = strings.TrimSpace(query); query != "" {
+		filter = "name ~ {:q} || phone ~ {:q} || email ~ {:q}"
+		params["q"] = query
+		if phone, err := core.NormalizePhoneVN(query); err == nil {
+			filter += " || phone = {:phone}"
+			params["phone"] = phone
+		}
+	}
+
+	records, err := r.app.FindRecordsByFilter("customers", filter, "-created", limit, offset, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.app.CountRecords("customers", dbx.NewExp(r.countExpr(query), params))
+	if err != nil {
+		return nil, 0, err
+	}
+	return r.list(records), int(total), nil
+}
+
+// countExpr mirrors the Search filter in plain SQL
+func (r *PBCustomerRepo) countExpr(query string) string {
+	if query == "" {
+		return "1 = 1"
+	}
+	expr := "(name LIKE '%' || {:q} || '%' OR phone LIKE '%' || {:q} || '%' OR email LIKE '%' || {:q} || '%')"
+	if _, err := core.NormalizePhoneVN(query); err == nil {
+		expr = "(" + expr + " OR phone = {:phone})"
+	}
+	return expr
+}
+
+// FindSimilar returns other customers with the same name or sharing a saved address
+func (r *PBCustomerRepo) FindSimilar(c *core.Customer, limit int) ([]*core.Customer, error) {
+	conditions := []dbx.Expression{
+		dbx.NewExp("LOWER(TRIM(name)) = LOWER(TRIM({:name}))", dbx.Params{"name": c.Name}),
+	}
+	for i, addr := range c.Addresses {
+		if strings.TrimSpace(addr.Address) == "" {
+			continue
+		}
+		key := fmt.Sprintf("addr%d", i)
+		conditions = append(conditions, dbx.NewExp("addresses LIKE {:"+key+"}", dbx.Params{key: "%" + addr.Address + "%"}))
+	}
+
+	var records []*pbCore.Record
+	err := r.app.RecordQuery("customers").
+		AndWhere(dbx.Not(dbx.HashExp{"id": c.ID})).
+		AndWhere(dbx.Or(conditions...)).
+		OrderBy("created DESC").
+		Limit(int64(limit)).
+		All(&records)
+	if err != nil {
+		return nil, err
+	}
+	return r.list(records), nil
+}
+
+func (r *PBCustomerRepo) ListBookings(customerID string) ([]*core.Booking, error) {
+	records, err := r.app.FindRecordsByFilter("bookings", "customer_id = {:id}", "-created", 0, 0, dbx.Params{"id": customerID})
+	if err != nil {
+		return nil, err
+	}
+
+	mapper := &PBBookingRepo{app: r.app}
+	bookings := make([]*core.Booking, 0, len(records))
+	for _, rec := range records {
+		bookings = append(bookings, mapper.toDomain(rec))
+	}
+	return bookings, nil
+}
+
+// Merge runs in one transaction so a failure never leaves orphaned bookings
+func (r *PBCustomerRepo) Merge(primary *core.Customer, duplicateID string) error {
+	return r.app.RunInTransaction(func(txApp pbCore.App) error {
+		duplicate, err := txApp.FindRecordById("customers", duplicateID)
+		if err != nil {
+			return err
+		}
+
+		// Raw update: re-linking must not trigger booking hooks/notifications
+		if _, err := txApp.DB().Update("bookings",
+			dbx.Params{"customer_id": primary.ID},
+			dbx.HashExp{"customer_id": duplicateID},
+		).Execute(); err != nil {
+			return err
+		}
+		// Units and contracts follow the customer (both cascade-delete otherwise)
+		for _, table := range []string{"equipment", "maintenance_contracts"} {
+			if _, err := txApp.DB().Update(table,
+				dbx.Params{"customer_id": primary.ID},
+				dbx.HashExp{"customer_id": duplicateID},
+			).Execute(); err != nil {
+				return err
+			}
+		}
+
+		if err := txApp.Delete(duplicate); err != nil {
+			return err
+		}
+
+		record, err := txApp.FindRecordById("customers", primary.ID)
+		if err != nil {
+			return err
+		}
+		r.setFields(record, primary)
+		return txApp.Save(record)
+	})
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+)
+
+type PBEInvoiceRepo struct {
+	app pbCore.App
+}
+
+func NewEInvoiceRepo(app pbCore.App) core.EInvoiceRepository {
+	return &PBEInvoiceRepo{app: app}
+}
+
+func (r *PBEInvoiceRepo) Source(invoiceID string) (*core.EInvoiceSource, error) {
+	invoice, err := r.app.FindRecordById("invoices", invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	src := &core.EInvoiceSource{
+		InvoiceID:     invoice.Id,
+		InvoiceCode:   invoice.GetString("invoice_code"),
+		InvoiceStatus: invoice.GetString("status"),
+		PaymentMethod: invoice.GetString("payment_method"),
+	}
+	_ = invoice.UnmarshalJSONField("einvoice", &src.State)
+	src.State.Status = invoice.GetString("einvoice_status")
+
+	items, err := r.app.FindRecordsByFilter("invoice_items", "invoice_id = {:id}", "created", 0, 0,
+		dbx.Params{"id": invoice.Id})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		line := core.InvoiceLine{
+			Key:       item.GetString("line_key"),
+			Kind:      item.GetString("kind"),
+			Name:      item.GetString("item_name"),
+			Unit:      item.GetString("unit"),
+			Quantity:  item.GetFloat("quantity"),
+			UnitPrice: item.GetFloat("unit_price"),
+			VATCode:   item.GetString("vat_rate"),
+			Subtotal:  item.GetFloat("subtotal"),
+			Discount:  item.GetFloat("discount"),
+			Tax:       item.GetFloat("tax"),
+			Total:     item.GetFloat("total"),
+		}
+		if line.Subtotal == 0 && line.Total > 0 {
+			// Lines priced before VAT and discounts were stored per line
+			line.Subtotal = line.Total
+		}
+		src.Lines = append(src.Lines, line)
+	}
+
+	if booking, err := r.app.FindRecordById("bookings", invoice.GetString("booking_id")); err == nil {
+		src.Customer = core.EInvoiceParty{
+			Name:    booking.GetString("customer_name"),
+			Phone:   booking.GetString("customer_phone"),
+			Address: strings.TrimSpace(booking.GetString("address") + " " + booking.GetString("address_details")),
+		}
+		if customerID := booking.GetString("customer_id"); customerID != "" {
+			if customer, err := r.app.FindRecordById("customers", customerID); err == nil {
+				src.Customer.Email = customer.GetString("email")
+			}
+		}
+	}
+	return src, nil
+}
+
+func (r *PBEInvoiceRepo) SaveState(invoiceID string, state *core.EInvoiceState) error {
+	invoice, err := r.app.FindRecordById("invoices", invoiceID)
+	if err != nil {
+		return err
+	}
+	lookup := ""
+	if state.Current != nil && state.Status == core.EInvoiceIssued {
+		lookup = state.Current.LookupCode
+	}
+	invoice.Set("einvoice", state)
+	invoice.Set("einvoice_status", state.Status)
+	invoice.Set("einvoice_lookup_code", lookup)
+	return r.app.Save(invoice)
+}
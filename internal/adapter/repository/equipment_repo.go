@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+)
+
+// reportEquipmentIDs expands job_reports.equipment_ids (JSON array) for json_each
+const reportEquipmentIDs = "json_each(CASE WHEN json_valid(r.equipment_ids) THEN r.equipment_ids ELSE '[]' END) je"
+
+type PBEquipmentRepo struct {
+	app pbCore.App
+}
+
+func NewEquipmentRepo(app pbCore.App) core.EquipmentRepository {
+	return &PBEquipmentRepo{app: app}
+}
+
+func (r *PBEquipmentRepo) toDomain(record *pbCore.Record) *core.Equipment {
+	return &core.Equipment{
+		ID:          record.Id,
+		CustomerID:  record.GetString("customer_id"),
+		UnitType:    record.GetString("unit_type"),
+		Brand:       record.GetString("brand"),
+		Model:       record.GetString("model"),
+		Serial:      record.GetString("serial"),
+		CapacityBTU: record.GetInt("capacity_btu"),
+		CapacityHP:  record.GetFloat("capacity_hp"),
+		Refrigerant: record.GetString("refrigerant"),
+		InstallDate: record.GetString("install_date"),
+		Location:    record.GetString("location"),
+		Notes:       record.GetString("notes"),
+		Created:     record.GetString("created"),
+	}
+}
+
+func (r *PBEquipmentRepo) setFields(record *pbCore.Record, eq *core.Equipment) {
+	record.Set("customer_id", eq.CustomerID)
+	record.Set("unit_type", eq.UnitType)
+	record.Set("brand", eq.Brand)
+	record.Set("model", eq.Model)
+	record.Set("serial", eq.Serial)
+	record.Set("capacity_btu", eq.CapacityBTU)
+	record.Set("capacity_hp", eq.CapacityHP)
+	record.Set("refrigerant", eq.Refrigerant)
+	record.Set("install_date", eq.InstallDate)
+	record.Set("location", eq.Location)
+	record.Set("notes", eq.Notes)
+}
+
+func (r *PBEquipmentRepo) GetByID(id string) (*core.Equipment, error) {
+	record, err := r.app.FindRecordById("equipment", id)
+	if err != nil {
+		return nil, err
+	}
+	eq := r.toDomain(record)
+	r.fillStats([]*core.Equipment{eq})
+	return eq, nil
+}
+
+func (r *PBEquipmentRepo) ListByCustomer(customerID string) ([]*core.Equipment, error) {
+	records, err := r.app.FindRecordsByFilter("equipment", "customer_id = {:customer}", "location,created", 0, 0, dbx.Params{"customer": customerID})
+	if err != nil {
+		return nil, err
+	}
+
+	units := make([]*core.Equipment, 0, len(records))
+	for _, rec := range records {
+		units = append(units, r.toDomain(rec))
+	}
+	r.fillStats(units)
+	return units, nil
+}
+
+// fillStats counts the job reports of each unit
+func (r *PBEquipmentRepo) fillStats(units []*core.Equipment) {
+	if len(units) == 0 {
+		return
+	}
+	byID := make(map[string]*core.Equipment, len(units))
+	ids := make([]interface{}, 0, len(units))
+	for _, eq := range units {
+		byID[eq.ID] = eq
+		ids = append(ids, eq.ID)
+	}
+
+	var stats []struct {
+		EquipmentID   string `db:"equipment_id"`
+		ServiceCount  int    `db:"service_count"`
+		LastServiceAt string `db:"last_service_at"`
+	}
+	err := r.app.DB().Select(
+		"je.value as equipment_id",
+		"COUNT(r.id) as service_count",
+		"MAX(r.created) as last_service_at",
+	).
+		From("job_reports r", reportEquipmentIDs).
+		Where(dbx.In("je.value", ids...)).
+		GroupBy("je.value").
+		All(&stats)
+	if err != nil {
+		return
+	}
+	for _, st := range stats {
+		if eq := byID[st.EquipmentID]; eq != nil {
+			eq.ServiceCount = st.ServiceCount
+			eq.LastServiceAt = st.LastServiceAt
+		}
+	}
+}
+
+func (r *PBEquipmentRepo) Create(eq *core.Equipment) error {
+	collection, err := r.app.FindCollectionByNameOrId("equipment")
+	if err != nil {
+		return err
+	}
+
+	record := pbCore.NewRecord(collection)
+	r.setFields(record, eq)
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+
+	eq.ID = record.Id
+	eq.Created = record.GetString("created")
+	return nil
+}
+
+func (r *PBEquipmentRepo) Update(eq *core.Equipment) error {
+	record, err := r.app.FindRecordById("equipment", eq.ID)
+	if err != nil {
+		return err
+	}
+	r.setFields(record, eq)
+	return r.app.Save(record)
+}
+
+// ServiceHistory lists every job report linked to the unit, newest first
+func (r *PBEquipmentRepo) ServiceHistory(equipmentID string) ([]*core.EquipmentServiceRecord, error) {
+	history := []*core.EquipmentServiceRecord{}
+	err := r.app.DB().Select(
+		"r.id as report_id",
+		"r.booking_id as booking_id",
+		"COALESCE(NULLIF(b.completed_at, ''), r.created) as date",
+		"COALESCE(b.job_status, '') as job_status",
+		"COALESCE(t.name, '') as tech_name",
+		"COALESCE(b.issue_description, '') as issue",
+		"COALESCE(NULLIF(r.photo_notes, ''), r.technician_notes, '') as notes",
+	).
+		From("job_reports r", reportEquipmentIDs).
+		LeftJoin("bookings b", dbx.NewExp("b.id = r.booking_id")).
+		LeftJoin("technicians t", dbx.NewExp("t.id = b.technician_id")).
+		Where(dbx.HashExp{"je.value": equipmentID}).
+		OrderBy("date DESC").
+		All(&history)
+	return history, err
+}
+
+// LinkReport adds the units to the report (keeps units linked earlier)
+func (r *PBEquipmentRepo) LinkReport(reportID string, equipmentIDs []string) error {
+	report, err := r.app.FindRecordById("job_reports", reportID)
+	if err != nil {
+		return err
+	}
+
+	linked := report.GetStringSlice("equipment_ids")
+	seen := make(map[string]bool, len(linked))
+	for _, id := range linked {
+		seen[id] = true
+	}
+	for _, id := range equipmentIDs {
+		if id != "" && !seen[id] {
+			linked = append(linked, id)
+			seen[id] = true
+		}
+	}
+	report.Set("equipment_ids", linked)
+	return r.app.Save(report)
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+
+	pbCore "github.com/pocketbase/pocketbase/core"
+)
+
+type PBPartRepo struct {
+	app pbCore.App
+}
+
+func NewPartRepo(app pbCore.App) core.PartRepository {
+	return &PBPartRepo{app: app}
+}
+
+func (r *PBPartRepo) toDomain(record *pbCore.Record) *core.Part {
+	return &core.Part{
+		ID:       record.Id,
+		Name:     record.GetString("name"),
+		SKU:      record.GetString("sku"),
+		Category: record.GetString("category"),
+		Unit:     record.GetString("unit"),
+		Price:    record.GetFloat("price"),
+	}
+}
+
+func (r *PBPartRepo) GetByID(id string) (*core.Part, error) {
+	record, err := r.app.FindRecordById("inventory_items", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(record), nil
+}
+
+func (r *PBPartRepo) ListActive() ([]*core.Part, error) {
+	records, err := r.app.FindRecordsByFilter("inventory_items", "is_active = true", "category,name", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]*core.Part, 0, len(records))
+	for _, rec := range records {
+		parts = append(parts, r.toDomain(rec))
+	}
+	return parts, nil
+}
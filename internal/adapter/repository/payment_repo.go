@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type PBPaymentRepo struct {
+	app pbCore.App
+}
+
+func NewPaymentRepo(app pbCore.App) core.PaymentRepository {
+	return &PBPaymentRepo{app: app}
+}
+
+func (r *PBPaymentRepo) toDomain(record *pbCore.Record) *core.Payment {
+	return &core.Payment{
+		ID:              record.Id,
+		InvoiceID:       record.GetString("invoice_id"),
+		BookingID:       record.GetString("booking_id"),
+		Kind:            record.GetString("kind"),
+		Amount:          record.GetFloat("amount"),
+		Method:          record.GetString("method"),
+		Reference:       record.GetString("reference"),
+		IdempotencyKey:  record.GetString("idempotency_key"),
+		CollectedBy:     record.GetString("collected_by"),
+		CollectedByName: record.GetString("collected_by_name"),
+		PaidAt:          record.GetDateTime("paid_at").Time(),
+		Status:          record.GetString("status"),
+		Note:            record.GetString("note"),
+		RequestedBy:     record.GetString("requested_by"),
+		RequestNote:     record.GetString("request_note"),
+		ApprovedBy:      record.GetString("approved_by"),
+		ApprovedAt:      record.GetString("approved_at"),
+		Created:         record.GetString("created"),
+	}
+}
+
+func (r *PBPaymentRepo) setFields(record *pbCore.Record, p *core.Payment) {
+	record.Set("invoice_id", p.InvoiceID)
+	record.Set("booking_id", p.BookingID)
+	record.Set("kind", p.Kind)
+	record.Set("amount", p.Amount)
+	record.Set("method", p.Method)
+	record.Set("reference", p.Reference)
+	record.Set("idempotency_key", p.IdempotencyKey)
+	record.Set("collected_by", p.CollectedBy)
+	record.Set("collected_by_name", p.CollectedByName)
+	if dt, err := types.ParseDateTime(p.PaidAt); err == nil {
+		record.Set("paid_at", dt)
+	}
+	record.Set("status", p.Status)
+	record.Set("note", p.Note)
+	record.Set("requested_by", p.RequestedBy)
+	record.Set("request_note", p.RequestNote)
+	record.Set("approved_by", p.ApprovedBy)
+	record.Set("approved_at", p.ApprovedAt)
+}
+
+func (r *PBPaymentRepo) GetByID(id string) (*core.Payment, error) {
+	record, err := r.app.FindRecordById("payments", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(record), nil
+}
+
+func (r *PBPaymentRepo) ListByInvoice(invoiceID string) ([]*core.Payment, error) {
+	records, err := r.app.FindRecordsByFilter("payments", "invoice_id = {:invoice}", "paid_at,created", 0, 0,
+		dbx.Params{"invoice": invoiceID})
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomainList(records), nil
+}
+
+func (r *PBPaymentRepo) ListByStatus(statuses ...string) ([]*core.Payment, error) {
+	conds := make([]string, 0, len(statuses))
+	params := dbx.Params{}
+	for i, s := range statuses {
+		key := fmt.Sprintf("s%d", i)
+		conds = append(conds, fmt.Sprintf("status = {:%s}", key))
+		params[key] = s
+	}
+	records, err := r.app.FindRecordsByFilter("payments", strings.Join(conds, " || "), "-created", 0, 0, params)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomainList(records), nil
+}
+
+func (r *PBPaymentRepo) ListCashCollectedBy(collectorID string) ([]*core.Payment, error) {
+	records, err := r.app.FindRecordsByFilter("payments",
+		"collected_by = {:collector} && method = 'cash' && kind = 'payment'", "paid_at,created", 0, 0,
+		dbx.Params{"collector": collectorID})
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomainList(records), nil
+}
+
+// Create checks the idempotency key and saves the entry in one transaction,
+// so two submits of the same payment cannot both be recorded
+func (r *PBPaymentRepo) Create(p *core.Payment) error {
+	collection, err := r.app.FindCollectionByNameOrId("payments")
+	if err != nil {
+		return err
+	}
+	record := pbCore.NewRecord(collection)
+	r.setFields(record, p)
+	err = r.app.RunInTransaction(func(txApp pbCore.App) error {
+		if p.IdempotencyKey != "" {
+			existing, err := txApp.FindFirstRecordByFilter("payments",
+				"idempotency_key = {:key}", dbx.Params{"key": p.IdempotencyKey})
+			if err == nil && existing != nil {
+				return core.ErrDuplicatePayment
+			}
+		}
+		return txApp.Save(record)
+	})
+	if err != nil {
+		return err
+	}
+	p.ID = record.Id
+	p.Created = record.GetString("created")
+	return nil
+}
+
+func (r *PBPaymentRepo) Update(p *core.Payment) error {
+	record, err := r.app.FindRecordById("payments", p.ID)
+	if err != nil {
+		return err
+	}
+	r.setFields(record, p)
+	return r.app.Save(record)
+}
+
+func (r *PBPaymentRepo) toDomainList(records []*pbCore.Record) []*core.Payment {
+	payments := make([]*core.Payment, 0, len(records))
+	for _, rec := range records {
+		payments = append(payments, r.toDomain(rec))
+	}
+	return payments
+}
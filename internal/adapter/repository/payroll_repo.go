@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"hvac-system/internal/core"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+)
+
+type PBPayrollRepo struct {
+	app pbCore.App
+}
+
+func NewPayrollRepo(app pbCore.App) core.PayrollRepository {
+	return &PBPayrollRepo{app: app}
+}
+
+func (r *PBPayrollRepo) statementToDomain(record *pbCore.Record) *core.PayrollStatement {
+	var lines []core.PayrollLine
+	_ = record.UnmarshalJSONField("lines", &lines)
+
+	return &core.PayrollStatement{
+		ID:             record.Id,
+		TechnicianID:   record.GetString("technician_id"),
+		TechnicianName: record.GetString("technician_name"),
+		Period:         record.GetString("period"),
+		BaseSalary:     record.GetFloat("base_salary"),
+		Commission:     record.GetFloat("commission"),
+		Bonus:          record.GetFloat("bonus"),
+		Penalty:        record.GetFloat("penalty"),
+		Advance:        record.GetFloat("advance"),
+		Shortage:       record.GetFloat("shortage"),
+		NetPay:         record.GetFloat("net_pay"),
+		Lines:          lines,
+		Status:         record.GetString("status"),
+		ApprovedBy:     record.GetString("approved_by"),
+		ApprovedAt:     record.GetString("approved_at"),
+		Updated:        record.GetString("updated"),
+	}
+}
+
+func (r *PBPayrollRepo) adjustmentToDomain(record *pbCore.Record) *core.PayrollAdjustment {
+	return &core.PayrollAdjustment{
+		ID:           record.Id,
+		TechnicianID: record.GetString("technician_id"),
+		Period:       record.GetString("period"),
+		Kind:         record.GetString("kind"),
+		Amount:       record.GetFloat("amount"),
+		Description:  record.GetString("description"),
+		CreatedBy:    record.GetString("created_by"),
+		Created:      record.GetString("created"),
+	}
+}
+
+func (r *PBPayrollRepo) GetStatement(id string) (*core.PayrollStatement, error) {
+	record, err := r.app.FindRecordById("payroll_statements", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.statementToDomain(record), nil
+}
+
+func (r *PBPayrollRepo) FindStatement(techID, period string) (*core.PayrollStatement, error) {
+	record, err := r.app.FindFirstRecordByFilter("payroll_statements",
+		"technician_id = {:tech} && period = {:period}",
+		dbx.Params{"tech": techID, "period": period})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.statementToDomain(record), nil
+}
+
+func (r *PBPayrollRepo) ListStatements(period string) ([]*core.PayrollStatement, error) {
+	records, err := r.app.FindRecordsByFilter("payroll_statements", "period = {:period}", "technician_name", 0, 0,
+		dbx.Params{"period": period})
+	if err != nil {
+		return nil, err
+	}
+	return r.statementList(records), nil
+}
+
+func (r *PBPayrollRepo) ListStatementsByTechnician(techID string) ([]*core.PayrollStatement, error) {
+	records, err := r.app.FindRecordsByFilter("payroll_statements", "technician_id = {:tech}", "-period", 0, 0,
+		dbx.Params{"tech": techID})
+	if err != nil {
+		return nil, err
+	}
+	return r.statementList(records), nil
+}
+
+func (r *PBPayrollRepo) SaveStatement(s *core.PayrollStatement) error {
+	var record *pbCore.Record
+	if s.ID != "" {
+		existing, err := r.app.FindRecordById("payroll_statements", s.ID)
+		if err != nil {
+			return err
+		}
+		record = existing
+	} else {
+		collection, err := r.app.FindCollectionByNameOrId("payroll_statements")
+		if err != nil {
+			return err
+		}
+		record = pbCore.NewRecord(collection)
+	}
+
+	record.Set("technician_id", s.TechnicianID)
+	record.Set("technician_name", s.TechnicianName)
+	record.Set("period", s.Period)
+	record.Set("base_salary", s.BaseSalary)
+	record.Set("commission", s.Commission)
+	record.Set("bonus", s.Bonus)
+	record.Set("penalty", s.Penalty)
+	record.Set("advance", s.Advance)
+	record.Set("shortage", s.Shortage)
+	record.Set("net_pay", s.NetPay)
+	record.Set("lines", s.Lines)
+	record.Set("status", s.Status)
+	record.Set("approved_by", s.ApprovedBy)
+	record.Set("approved_at", s.ApprovedAt)
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+	s.ID = record.Id
+	s.Updated = record.GetString("updated")
+	return nil
+}
+
+func (r *PBPayrollRepo) GetAdjustment(id string) (*core.PayrollAdjustment, error) {
+	record, err := r.app.FindRecordById("payroll_adjustments", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.adjustmentToDomain(record), nil
+}
+
+func (r *PBPayrollRepo) ListAdjustments(techID, period string) ([]*core.PayrollAdjustment, error) {
+	records, err := r.app.FindRecordsByFilter("payroll_adjustments",
+		"technician_id = {:tech} && period = {:period}", "created", 0, 0,
+		dbx.Params{"tech": techID, "period": period})
+	if err != nil {
+		return nil, err
+	}
+	adjustments := make([]*core.PayrollAdjustment, 0, len(records))
+	for _, rec := range records {
+		adjustments = append(adjustments, r.adjustmentToDomain(rec))
+	}
+	return adjustments, nil
+}
+
+func (r *PBPayrollRepo) CreateAdjustment(a *core.PayrollAdjustment) error {
+	collection, err := r.app.FindCollectionByNameOrId("payroll_adjustments")
+	if err != nil {
+		return err
+	}
+	record := pbCore.NewRecord(collection)
+	record.Set("technician_id", a.TechnicianID)
+	record.Set("period", a.Period)
+	record.Set("kind", a.Kind)
+	record.Set("amount", a.Amount)
+	record.Set("description", a.Description)
+	record.Set("created_by", a.CreatedBy)
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+	a.ID = record.Id
+	a.Created = record.GetString("created")
+	return nil
+}
+
+func (r *PBPayrollRepo) DeleteAdjustment(id string) error {
+	record, err := r.app.FindRecordById("payroll_adjustments", id)
+	if err != nil {
+		return err
+	}
+	return r.app.Delete(record)
+}
+
+// SettledCommissions takes the tech's paid invoices and keeps those that
+// first became fully paid in [from, to), so each commission is paid once even
+// when another payment comes in later
+func (r *PBPayrollRepo) SettledCommissions(techID string, from, to time.Time) ([]*core.CommissionItem, error) {
+	var rows []struct {
+		ID         string  `db:"id"`
+		Code       string  `db:"invoice_code"`
+		BookingID  string  `db:"booking_id"`
+		Commission float64 `db:"tech_commission"`
+		Total      float64 `db:"total_amount"`
+		PaidAt     string  `db:"paid_at"`
+		Amount     float64 `db:"amount"`
+	}
+	err := r.app.DB().Select(
+		"i.id as id",
+		"i.invoice_code as invoice_code",
+		"i.booking_id as booking_id",
+		"i.tech_commission as tech_commission",
+		"i.total_amount as total_amount",
+		"p.paid_at as paid_at",
+		"p.amount as amount",
+	).
+		From("invoices i").
+		InnerJoin("bookings b", dbx.NewExp("b.id = i.booking_id")).
+		InnerJoin("payments p", dbx.NewExp("p.invoice_id = i.id")).
+		Where(dbx.And(
+			dbx.HashExp{"b.technician_id": techID},
+			dbx.In("i.status", core.InvoicePaid, core.InvoiceOverpaid),
+			dbx.HashExp{"p.kind": core.PaymentIn},
+			dbx.In("p.status", core.PaymentPosted, core.PaymentVoidPending),
+			dbx.NewExp("p.paid_at < {:to}", dbx.Params{"to": to.UTC().Format(core.DateTimeLayout)}),
+		)).
+		OrderBy("i.id", "p.paid_at").
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*core.CommissionItem
+	var payments []core.SettlingPayment
+	for i, row := range rows {
+		paidAt, _ := time.Parse(core.DateTimeLayout, row.PaidAt)
+		payments = append(payments, core.SettlingPayment{PaidAt: paidAt, Amount: row.Amount})
+		if i+1 < len(rows) && rows[i+1].ID == row.ID {
+			continue
+		}
+		settledAt := core.FirstSettled(row.Total, payments)
+		payments = nil
+		if settledAt.IsZero() || settledAt.Before(from) {
+			continue
+		}
+		items = append(items, &core.CommissionItem{
+			InvoiceID:   row.ID,
+			InvoiceCode: row.Code,
+			BookingID:   row.BookingID,
+			SettledAt:   settledAt,
+			Amount:      row.Commission,
+		})
+	}
+	return items, nil
+}
+
+func (r *PBPayrollRepo) statementList(records []*pbCore.Record) []*core.PayrollStatement {
+	statements := make([]*core.PayrollStatement, 0, len(records))
+	for _, rec := range records {
+		statements = append(statements, r.statementToDomain(rec))
+	}
+	return statements
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+type PBQuoteRepo struct {
+	app pbCore.App
+}
+
+func NewQuoteRepo(app pbCore.App) core.QuoteRepository {
+	return &PBQuoteRepo{app: app}
+}
+
+func (r *PBQuoteRepo) toDomain(record *pbCore.Record) *core.Quote {
+	q := &core.Quote{
+		ID:           record.Id,
+		BookingID:    record.GetString("booking_id"),
+		TechnicianID: record.GetString("technician_id"),
+		Status:       record.GetString("status"),
+		LaborTotal:   record.GetFloat("labor_total"),
+		PartsTotal:   record.GetFloat("parts_total"),
+		Total:        record.GetFloat("total"),
+		Notes:        record.GetString("notes"),
+		PublicHash:   record.GetString("public_hash"),
+		Signature:    record.GetString("customer_signature"),
+		DecidedAt:    record.GetString("decided_at"),
+		RejectReason: record.GetString("reject_reason"),
+		Created:      record.GetString("created"),
+	}
+	_ = record.UnmarshalJSONField("items", &q.Items)
+	return q
+}
+
+func (r *PBQuoteRepo) setFields(record *pbCore.Record, q *core.Quote) {
+	record.Set("booking_id", q.BookingID)
+	record.Set("technician_id", q.TechnicianID)
+	record.Set("status", q.Status)
+	record.Set("items", q.Items)
+	record.Set("labor_total", q.LaborTotal)
+	record.Set("parts_total", q.PartsTotal)
+	record.Set("total", q.Total)
+	record.Set("notes", q.Notes)
+	record.Set("public_hash", q.PublicHash)
+	record.Set("decided_at", q.DecidedAt)
+	record.Set("reject_reason", q.RejectReason)
+}
+
+func (r *PBQuoteRepo) GetByID(id string) (*core.Quote, error) {
+	record, err := r.app.FindRecordById("quotes", id)
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(record), nil
+}
+
+func (r *PBQuoteRepo) GetByHash(hash string) (*core.Quote, error) {
+	record, err := r.app.FindFirstRecordByFilter("quotes", "public_hash = {:hash}", dbx.Params{"hash": hash})
+	if err != nil {
+		return nil, err
+	}
+	return r.toDomain(record), nil
+}
+
+func (r *PBQuoteRepo) ListByBooking(bookingID string) ([]*core.Quote, error) {
+	records, err := r.app.FindRecordsByFilter("quotes", "booking_id = {:booking}", "-created", 0, 0, dbx.Params{"booking": bookingID})
+	if err != nil {
+		return nil, err
+	}
+	quotes := make([]*core.Quote, 0, len(records))
+	for _, rec := range records {
+		quotes = append(quotes, r.toDomain(rec))
+	}
+	return quotes, nil
+}
+
+func (r *PBQuoteRepo) Create(q *core.Quote) error {
+	collection, err := r.app.FindCollectionByNameOrId("quotes")
+	if err != nil {
+		return err
+	}
+
+	record := pbCore.NewRecord(collection)
+	r.setFields(record, q)
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+
+	q.ID = record.Id
+	q.Created = record.GetString("created")
+	return nil
+}
+
+func (r *PBQuoteRepo) Update(q *core.Quote, signature *filesystem.File) error {
+	record, err := r.app.FindRecordById("quotes", q.ID)
+	if err != nil {
+		return err
+	}
+	r.setFields(record, q)
+	if signature != nil {
+		record.Set("customer_signature", signature)
+	}
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+	q.Signature = record.GetString("customer_signature")
+	return nil
+}
+
+// SaveDecision runs in one transaction so an approved quote never stands
+// next to the approved quote it replaces
+func (r *PBQuoteRepo) SaveDecision(q *core.Quote, signature *filesystem.File, superseded []*core.Quote) error {
+	return r.app.RunInTransaction(func(txApp pbCore.App) error {
+		tx := &PBQuoteRepo{app: txApp}
+		for _, old := range superseded {
+			if err := tx.Update(old, nil); err != nil {
+				return err
+			}
+		}
+		return tx.Update(q, signature)
+	})
+}
+
+// CreatePending supersedes the pending quotes, saves the new one and the
+// booking moved to quoting (nil when it already is) in one transaction, so only
+// one quote ever waits for a signature
+func (r *PBQuoteRepo) CreatePending(q *core.Quote, superseded []*core.Quote, booking *core.Booking) error {
+	return r.app.RunInTransaction(func(txApp pbCore.App) error {
+		tx := &PBQuoteRepo{app: txApp}
+		for _, old := range superseded {
+			if err := tx.Update(old, nil); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(q); err != nil {
+			return err
+		}
+		if booking == nil {
+			return nil
+		}
+		return (&PBBookingRepo{app: txApp}).Update(booking)
+	})
+}
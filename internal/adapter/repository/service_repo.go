@@ -22,7 +22,13 @@ func (r *PBServiceRepo) GetByID(id string) (*core.Service, error) {
 
 	return &core.Service{
 		ID:              record.Id,
+		CategoryID:      record.GetString("category_id"),
 		Name:            record.GetString("name"),
+		BasePrice:       record.GetFloat("price"),
 		DurationMinutes: record.GetInt("duration_minutes"),
+		WarrantyMonths:  record.GetInt("warranty_months"),
+		CommissionRate:  record.GetFloat("commission_rate"),
+		RequiredSkill:   record.GetString("required_skill"),
+		IsActive:        record.GetBool("active"),
 	}, nil
 }
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+)
+
+type PBTechScheduleRepo struct {
+	app pbCore.App
+}
+
+func NewTechScheduleRepo(app pbCore.App) core.TechScheduleRepository {
+	return &PBTechScheduleRepo{app: app}
+}
+
+func (r *PBTechScheduleRepo) hoursToDomain(record *pbCore.Record) *core.WorkingHours {
+	return &core.WorkingHours{
+		ID:           record.Id,
+		TechnicianID: record.GetString("technician_id"),
+		Weekday:      record.GetInt("weekday"),
+		StartTime:    record.GetString("start_time"),
+		EndTime:      record.GetString("end_time"),
+	}
+}
+
+func (r *PBTechScheduleRepo) timeOffToDomain(record *pbCore.Record) *core.TimeOff {
+	off := &core.TimeOff{
+		ID:           record.Id,
+		TechnicianID: record.GetString("technician_id"),
+		Kind:         record.GetString("kind"),
+		DateFrom:     record.GetString("date_from"),
+		DateTo:       record.GetString("date_to"),
+		StartTime:    record.GetString("start_time"),
+		EndTime:      record.GetString("end_time"),
+		Reason:       record.GetString("reason"),
+		Status:       record.GetString("status"),
+		RequestedBy:  record.GetString("requested_by"),
+		ReviewedBy:   record.GetString("reviewed_by"),
+		ReviewNote:   record.GetString("review_note"),
+		ReviewedAt:   record.GetString("reviewed_at"),
+		Created:      record.GetString("created"),
+	}
+	if tech := record.ExpandedOne("technician_id"); tech != nil {
+		off.TechnicianName = tech.GetString("name")
+	}
+	return off
+}
+
+func (r *PBTechScheduleRepo) timeOffList(filter string, sort string, params dbx.Params) ([]*core.TimeOff, error) {
+	records, err := r.app.FindRecordsByFilter("tech_time_off", filter, sort, 0, 0, params)
+	if err != nil {
+		return nil, err
+	}
+	r.app.ExpandRecords(records, []string{"technician_id"}, nil)
+
+	offs := make([]*core.TimeOff, 0, len(records))
+	for _, rec := range records {
+		offs = append(offs, r.timeOffToDomain(rec))
+	}
+	return offs, nil
+}
+
+// GetWorkingHours returns the weekly shifts of a technician
+func (r *PBTechScheduleRepo) GetWorkingHours(techID string) ([]*core.WorkingHours, error) {
+	records, err := r.app.FindRecordsByFilter(
+		"tech_working_hours",
+		"technician_id = {:tech}",
+		"weekday,start_time",
+		0, 0,
+		dbx.Params{"tech": techID},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	hours := make([]*core.WorkingHours, 0, len(records))
+	for _, rec := range records {
+		hours = append(hours, r.hoursToDomain(rec))
+	}
+	return hours, nil
+}
+
+// ReplaceWorkingHours swaps the whole weekly schedule in one transaction
+func (r *PBTechScheduleRepo) ReplaceWorkingHours(techID string, hours []*core.WorkingHours) error {
+	return r.app.RunInTransaction(func(txApp pbCore.App) error {
+		collection, err := txApp.FindCollectionByNameOrId("tech_working_hours")
+		if err != nil {
+			return err
+		}
+
+		existing, err := txApp.FindRecordsByFilter(collection, "technician_id = {:tech}", "", 0, 0, dbx.Params{"tech": techID})
+		if err != nil {
+			return err
+		}
+		for _, rec := range existing {
+			if err := txApp.Delete(rec); err != nil {
+				return err
+			}
+		}
+
+		for _, h := range hours {
+			record := pbCore.NewRecord(collection)
+			record.Set("technician_id", techID)
+			record.Set("weekday", h.Weekday)
+			record.Set("start_time", h.StartTime)
+			record.Set("end_time", h.EndTime)
+			if err := txApp.Save(record); err != nil {
+				return err
+			}
+			h.ID = record.Id
+			h.TechnicianID = techID
+		}
+		return nil
+	})
+}
+
+func (r *PBTechScheduleRepo) GetTimeOff(id string) (*core.TimeOff, error) {
+	record, err := r.app.FindRecordById("tech_time_off", id)
+	if err != nil {
+		return nil, err
+	}
+	r.app.ExpandRecord(record, []string{"technician_id"}, nil)
+	return r.timeOffToDomain(record), nil
+}
+
+func (r *PBTechScheduleRepo) CreateTimeOff(off *core.TimeOff) error {
+	collection, err := r.app.FindCollectionByNameOrId("tech_time_off")
+	if err != nil {
+		return err
+	}
+
+	record := pbCore.NewRecord(collection)
+	r.setTimeOff(record, off)
+	if err := r.app.Save(record); err != nil {
+		return err
+	}
+
+	off.ID = record.Id
+	off.Created = record.GetString("created")
+	return nil
+}
+
+func (r *PBTechScheduleRepo) UpdateTimeOff(off *core.TimeOff) error {
+	record, err := r.app.FindRecordById("tech_time_off", off.ID)
+	if err != nil {
+		return err
+	}
+	r.setTimeOff(record, off)
+	return r.app.Save(record)
+}
+
+func (r *PBTechScheduleRepo) setTimeOff(record *pbCore.Record, off *core.TimeOff) {
+	record.Set("technician_id", off.TechnicianID)
+	record.Set("kind", off.Kind)
+	record.Set("date_from", off.DateFrom)
+	record.Set("date_to", off.DateTo)
+	record.Set("start_time", off.StartTime)
+	record.Set("end_time", off.EndTime)
+	record.Set("reason", off.Reason)
+	record.Set("status", off.Status)
+	record.Set("requested_by", off.RequestedBy)
+	record.Set("reviewed_by", off.ReviewedBy)
+	record.Set("review_note", off.ReviewNote)
+	if off.ReviewedAt != "" {
+		record.Set("reviewed_at", off.ReviewedAt)
+	}
+}
+
+// ListTimeOffInRange returns every entry of the technician (and company-wide
+// holidays) overlapping [dateFrom, dateTo]; callers filter by status.
+func (r *PBTechScheduleRepo) ListTimeOffInRange(techID, dateFrom, dateTo string) ([]*core.TimeOff, error) {
+	return r.timeOffList(
+		"(technician_id = {:tech} || technician_id = '') && date_from <= {:to} && date_to >= {:from}",
+		"date_from",
+		dbx.Params{"tech": techID, "from": dateFrom, "to": dateTo},
+	)
+}
+
+func (r *PBTechScheduleRepo) ListTimeOffByStatus(status string) ([]*core.TimeOff, error) {
+	return r.timeOffList("status = {:status}", "date_from", dbx.Params{"status": status})
+}
+
+func (r *PBTechScheduleRepo) ListTimeOffByTechnician(techID string) ([]*core.TimeOff, error) {
+	return r.timeOffList("technician_id = {:tech}", "-date_from", dbx.Params{"tech": techID})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"html/template"
 
+	"hvac-system/internal/adapter/einvoice"
 	"hvac-system/internal/adapter/repository"
 	domain "hvac-system/internal/core"
 	"hvac-system/internal/handler"
@@ -37,19 +38,48 @@ type Container struct {
 	SlotRepo      domain.TimeSlotRepository
 	ServiceRepo   domain.ServiceRepository
 	AnalyticsRepo domain.AnalyticsRepository
-	SettingsRepo  *repository.SettingsRepo // Concrete type for handler compatibility
-	BrandRepo     domain.BrandRepository   // [NEW] SaaS Brand Management
+	SettingsRepo  *repository.SettingsRepo         // Concrete type for handler compatibility
+	BrandRepo     domain.BrandRepository           // [NEW] SaaS Brand Management
+	EventRepo     domain.BookingEventRepository    // [NEW] Booking timeline
+	ScheduleRepo  domain.TechScheduleRepository    // [NEW] Working hours & time off
+	CustomerRepo  domain.CustomerRepository        // [NEW] Customers deduped by phone
+	EquipmentRepo domain.EquipmentRepository       // [NEW] Installed units per customer
+	ContractRepo  domain.ContractRepository        // [NEW] Maintenance contracts
+	PartRepo      domain.PartRepository            // [NEW] Material catalog (inventory_items)
+	QuoteRepo     domain.QuoteRepository           // [NEW] On-site quotes
+	BankTxRepo    domain.BankTransactionRepository // [NEW] Incoming bank transfers
+	PaymentRepo   domain.PaymentRepository         // [NEW] Invoice payments ledger
+	CashRepo      domain.CashHandoverRepository    // [NEW] Tech cash handovers
+	PayrollRepo   domain.PayrollRepository         // [NEW] Payroll statements & adjustments
+	EInvoiceRepo  domain.EInvoiceRepository        // [NEW] E-invoice state on invoices
+	JournalRepo   domain.AccountingRepository      // [NEW] Journal sources (invoices, payments, cost of parts)
 
 	// Domain Services (Business Logic)
 	BookingService   domain.BookingService
 	SlotService      domain.TimeSlotControl // Interface for slot operations
 	AnalyticsService domain.AnalyticsService
+	DispatchService  domain.DispatchService     // [NEW] Technician ranking + auto-assign
+	ScheduleService  domain.TechScheduleService // [NEW] Tech working hours & leave
+	CustomerService  domain.CustomerService     // [NEW] Customer dedup, LTV, merge
+	EquipmentService domain.EquipmentService    // [NEW] Equipment registry
+	ContractService  domain.ContractService     // [NEW] Maintenance contracts + cron
+	QuoteService     domain.QuoteService        // [NEW] Quotation workflow
 	TechService      *services.TechManagementService
 	InventoryService *services.InventoryService
 	InvoiceService   *services.InvoiceService
+	PurchaseService  *services.PurchaseService    // [NEW] Suppliers, POs, goods receipts, payables
+	StocktakeService *services.StocktakeService   // [NEW] Warehouse & truck stock counts
+	PDFService       *services.PDFService         // [NEW] Invoice / job report PDFs
+	PaymentService   domain.PaymentService        // [NEW] Deposits, refunds, approvals
+	ReconcileService domain.ReconciliationService // [NEW] Bank transfer matching
+	CashService      domain.CashService           // [NEW] Tech cash on hand & handovers
+	PayrollService   domain.PayrollService        // [NEW] Monthly tech statements
+	EInvoiceService  domain.EInvoiceService       // [NEW] E-invoice submit / cancel / replace
+	JournalService   domain.AccountingService     // [NEW] Accounting journal export
 
 	// External Services (New package locations)
 	FCMService    *notification.FCMService
+	MailService   *notification.MailService // [NEW] Customer emails (SMTP settings)
 	LocationCache *cache.LocationCache
 
 	// Handlers (internal package)
@@ -85,6 +115,19 @@ func NewContainer(pb *pocketbase.PocketBase) (*Container, error) {
 	c.AnalyticsRepo = repository.NewAnalyticsRepo(pb)
 	c.SettingsRepo = repository.NewSettingsRepo(pb)
 	c.BrandRepo = repository.NewBrandRepo(pb)
+	c.EventRepo = repository.NewBookingEventRepo(pb)
+	c.ScheduleRepo = repository.NewTechScheduleRepo(pb)
+	c.CustomerRepo = repository.NewCustomerRepo(pb)
+	c.EquipmentRepo = repository.NewEquipmentRepo(pb)
+	c.ContractRepo = repository.NewContractRepo(pb)
+	c.PartRepo = repository.NewPartRepo(pb)
+	c.QuoteRepo = repository.NewQuoteRepo(pb)
+	c.BankTxRepo = repository.NewBankTransactionRepo(pb)
+	c.PaymentRepo = repository.NewPaymentRepo(pb)
+	c.CashRepo = repository.NewCashHandoverRepo(pb)
+	c.PayrollRepo = repository.NewPayrollRepo(pb)
+	c.EInvoiceRepo = repository.NewEInvoiceRepo(pb)
+	c.JournalRepo = repository.NewAccountingRepo(pb)
 
 	// 4. External Services (from new packages)
 	c.LocationCache = cache.NewLocationCache()
@@ -97,9 +140,13 @@ func NewContainer(pb *pocketbase.PocketBase) (*Container, error) {
 		fmt.Println("✅ FCM Service Initialized")
 	}
 	c.FCMService = fcmService
+	c.MailService = notification.NewMailService(pb)
 
 	// 5. Domain Services (inject repos + external services)
-	c.SlotService = service.NewTimeSlotService(c.SlotRepo, c.BookingRepo, c.ServiceRepo)
+	c.ScheduleService = service.NewTechScheduleService(c.ScheduleRepo, c.Broker)
+	c.CustomerService = service.NewCustomerService(c.CustomerRepo)
+	c.EquipmentService = service.NewEquipmentService(c.EquipmentRepo, c.BookingRepo)
+	c.SlotService = service.NewTimeSlotService(c.SlotRepo, c.BookingRepo, c.ServiceRepo, c.BrandRepo, c.ScheduleService)
 	c.AnalyticsService = service.NewAnalyticsService(c.AnalyticsRepo)
 	c.BookingService = service.NewBookingService(
 		c.BookingRepo,
@@ -109,15 +156,53 @@ func NewContainer(pb *pocketbase.PocketBase) (*Container, error) {
 		c.FCMService,
 		c.SettingsRepo,
 		c.Broker,
+		c.EventRepo,
+		c.CustomerService,
+	)
+	c.ContractService = service.NewContractService(
+		c.ContractRepo,
+		c.BookingService,
+		c.BookingRepo,
+		c.CustomerService,
+		c.EquipmentService,
+		c.MailService,
+		c.Broker,
+	)
+	c.QuoteService = service.NewQuoteService(
+		c.QuoteRepo,
+		c.BookingRepo,
+		c.BookingService,
+		c.ServiceRepo,
+		c.PartRepo,
+		c.Broker,
+	)
+	c.DispatchService = service.NewDispatchService(
+		c.BookingRepo,
+		c.TechRepo,
+		c.ServiceRepo,
+		c.SlotService,
+		c.BrandRepo,
+		c.BookingService,
+		c.LocationCache,
 	)
 
 	// 6. pkg/services (legacy, will be migrated in future phases)
 	c.TechService = services.NewTechManagementService(c.TechRepo)
 	c.InventoryService = services.NewInventoryService(pb)
 	c.InvoiceService = services.NewInvoiceService(pb)
+	c.PurchaseService = services.NewPurchaseService(pb)
+	c.StocktakeService = services.NewStocktakeService(pb)
+	c.PDFService = services.NewPDFService(pb, "assets/fonts")
+	c.PaymentService = service.NewPaymentService(c.PaymentRepo, c.InvoiceService, c.Broker)
+	c.ReconcileService = service.NewReconciliationService(c.BankTxRepo, c.InvoiceService, c.PaymentService, c.Broker)
+	c.CashService = service.NewCashService(c.PaymentRepo, c.CashRepo, c.TechRepo, c.InvoiceService, c.Broker)
+	c.PayrollService = service.NewPayrollService(c.PayrollRepo, c.TechRepo, c.EventRepo, c.CashRepo, c.BrandRepo)
+	// Only the file-based provider exists for now; swap in a VNPT / Viettel / MISA adapter here
+	c.EInvoiceService = service.NewEInvoiceService(c.EInvoiceRepo, c.BrandRepo, einvoice.NewFileProvider("pb_data/einvoices"))
+	c.JournalService = service.NewAccountingService(c.JournalRepo)
 
 	// 7. Internal Handlers
-	c.LocationHandler = handler.NewLocationHandler(c.LocationCache, c.BookingRepo, c.TechRepo, c.Broker)
+	c.LocationHandler = handler.NewLocationHandler(c.LocationCache, c.BookingRepo, c.BookingService, c.TechRepo, c.Broker)
 	c.LocationSSEHandler = handler.NewLocationSSEHandler(c.Broker)
 
 	// 8. UI Components
@@ -0,0 +1,245 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Journal entry kinds
+const (
+	JournalRevenue    = "revenue"
+	JournalVAT        = "vat"
+	JournalCost       = "cost"
+	JournalCommission = "commission"
+	JournalReceipt    = "receipt"
+	JournalRefund     = "refund"
+)
+
+var ErrInvalidAccountingRange = errors.New("accounting range needs from <= to (YYYY-MM-DD)")
+
+// ChartOfAccounts maps our postings to the accounts of Circular 200
+type ChartOfAccounts struct {
+	Receivable     string // Phải thu khách hàng
+	Cash           string // Tiền mặt
+	Bank           string // Tiền gửi ngân hàng (transfers and cards)
+	ServiceRevenue string // Doanh thu cung cấp dịch vụ (services, labor)
+	GoodsRevenue   string // Doanh thu bán hàng hóa (parts)
+	OutputVAT      string // Thuế GTGT đầu ra
+	COGS           string // Giá vốn hàng bán
+	Inventory      string // Hàng hóa
+	Commission     string // Chi phí bán hàng
+	PayrollPayable string // Phải trả người lao động
+}
+
+// DefaultChartOfAccounts is what MISA SME suggests for a service company
+var DefaultChartOfAccounts = ChartOfAccounts{
+	Receivable:     "131",
+	Cash:           "1111",
+	Bank:           "1121",
+	ServiceRevenue: "5113",
+	GoodsRevenue:   "5111",
+	OutputVAT:      "33311",
+	COGS:           "632",
+	Inventory:      "1561",
+	Commission:     "6421",
+	PayrollPayable: "334",
+}
+
+// AccountingRange parses inclusive "YYYY-MM-DD" dates into [from, to) in Vietnam time
+func AccountingRange(from, to string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02", from, fiscalZone)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidAccountingRange
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, fiscalZone)
+	if err != nil || end.Before(start) {
+		return time.Time{}, time.Time{}, ErrInvalidAccountingRange
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+// AccountingInvoice is an invoice as seen by the accountant
+type AccountingInvoice struct {
+	ID             string
+	Code           string
+	IssuedAt       time.Time
+	CustomerName   string
+	TechnicianName string
+	PaymentMethod  string
+	Lines          []InvoiceLine
+	Tax            float64 // invoices.tax_total
+	Total          float64 // invoices.total_amount
+	Cost           float64 // job_parts.cost_total, or x inventory_items.price_import
+	Commission     float64 // invoices.tech_commission
+}
+
+// Revenue splits the taxable amount between services (with labor) and parts.
+// Invoices without lines are booked as services for their total before VAT.
+func (a *AccountingInvoice) Revenue() (services, goods float64) {
+	if len(a.Lines) == 0 {
+		return a.Total - a.Tax, 0
+	}
+	for i := range a.Lines {
+		if a.Lines[i].Kind == InvoiceLinePart {
+			goods += a.Lines[i].Taxable()
+		} else {
+			services += a.Lines[i].Taxable()
+		}
+	}
+	return services, goods
+}
+
+// AccountingPayment is a counted ledger entry with its invoice and customer
+type AccountingPayment struct {
+	ID           string
+	InvoiceCode  string
+	CustomerName string
+	Kind         string // payment | refund
+	Method       string
+	Reference    string
+	Amount       float64
+	PaidAt       time.Time
+}
+
+// JournalEntry is one debit/credit pair, the row layout accounting software imports
+type JournalEntry struct {
+	Date        time.Time
+	Voucher     string // Số chứng từ
+	Kind        string
+	Description string
+	Debit       string
+	Credit      string
+	Amount      float64
+	Partner     string // Đối tượng: customer or technician
+	InvoiceCode string
+	Method      string
+}
+
+// LocalDate is the posting date in Vietnam time
+func (j JournalEntry) LocalDate() time.Time {
+	return j.Date.In(fiscalZone)
+}
+
+// JournalTotals sums a journal per kind, for the export summary
+type JournalTotals struct {
+	Revenue    float64
+	VAT        float64
+	Cost       float64
+	Commission float64
+	Receipts   float64
+	Refunds    float64
+}
+
+// Add sums the entries per kind
+func (t *JournalTotals) Add(entries []JournalEntry) {
+	for _, e := range entries {
+		switch e.Kind {
+		case JournalRevenue:
+			t.Revenue += e.Amount
+		case JournalVAT:
+			t.VAT += e.Amount
+		case JournalCost:
+			t.Cost += e.Amount
+		case JournalCommission:
+			t.Commission += e.Amount
+		case JournalReceipt:
+			t.Receipts += e.Amount
+		case JournalRefund:
+			t.Refunds += e.Amount
+		}
+	}
+}
+
+// BuildJournal posts revenue, VAT, cost of parts and commission of each
+// invoice on its issue date, and money in/out on the payment date. Amounts
+// are rounded to whole dong; zero postings are skipped.
+func BuildJournal(invoices []*AccountingInvoice, payments []*AccountingPayment, chart ChartOfAccounts) []JournalEntry {
+	var entries []JournalEntry
+	add := func(e JournalEntry) {
+		e.Amount = math.Round(e.Amount)
+		if e.Amount > 0 {
+			entries = append(entries, e)
+		}
+	}
+
+	for _, inv := range invoices {
+		base := JournalEntry{Date: inv.IssuedAt, Voucher: inv.Code, InvoiceCode: inv.Code, Partner: inv.CustomerName, Method: inv.PaymentMethod}
+		services, goods := inv.Revenue()
+
+		e := base
+		e.Kind, e.Debit, e.Credit, e.Amount = JournalRevenue, chart.Receivable, chart.ServiceRevenue, services
+		e.Description = "Doanh thu dịch vụ " + inv.Code
+		add(e)
+
+		e = base
+		e.Kind, e.Debit, e.Credit, e.Amount = JournalRevenue, chart.Receivable, chart.GoodsRevenue, goods
+		e.Description = "Doanh thu vật tư " + inv.Code
+		add(e)
+
+		e = base
+		e.Kind, e.Debit, e.Credit, e.Amount = JournalVAT, chart.Receivable, chart.OutputVAT, inv.Tax
+		e.Description = "Thuế GTGT đầu ra " + inv.Code
+		add(e)
+
+		e = base
+		e.Kind, e.Debit, e.Credit, e.Amount = JournalCost, chart.COGS, chart.Inventory, inv.Cost
+		e.Description, e.Partner = "Giá vốn vật tư "+inv.Code, ""
+		add(e)
+
+		e = base
+		e.Kind, e.Debit, e.Credit, e.Amount = JournalCommission, chart.Commission, chart.PayrollPayable, inv.Commission
+		e.Description, e.Partner = "Hoa hồng thợ "+inv.Code, inv.TechnicianName
+		add(e)
+	}
+
+	for _, p := range payments {
+		money, prefix := chart.Bank, "BC" // Báo có
+		if p.Method == MethodCash {
+			money, prefix = chart.Cash, "PT" // Phiếu thu
+		}
+		e := JournalEntry{
+			Date: p.PaidAt, Kind: JournalReceipt, Debit: money, Credit: chart.Receivable, Amount: p.Amount,
+			Partner: p.CustomerName, InvoiceCode: p.InvoiceCode, Method: p.Method,
+			Description: "Thu tiền " + p.InvoiceCode,
+		}
+		if p.Kind == PaymentRefund {
+			prefix = "UNC" // Ủy nhiệm chi
+			if p.Method == MethodCash {
+				prefix = "PC" // Phiếu chi
+			}
+			e.Kind, e.Debit, e.Credit = JournalRefund, chart.Receivable, money
+			e.Description = "Hoàn tiền " + p.InvoiceCode
+		}
+		e.Voucher = fmt.Sprintf("%s-%s", prefix, p.ID)
+		if p.Reference != "" {
+			e.Description += " (" + p.Reference + ")"
+		}
+		add(e)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Date.Before(entries[j].Date) })
+	return entries
+}
+
+// JournalKindLabel is the Vietnamese label of an entry kind
+func JournalKindLabel(kind string) string {
+	switch kind {
+	case JournalRevenue:
+		return "Doanh thu"
+	case JournalVAT:
+		return "Thuế GTGT"
+	case JournalCost:
+		return "Giá vốn"
+	case JournalCommission:
+		return "Hoa hồng"
+	case JournalReceipt:
+		return "Thu tiền"
+	case JournalRefund:
+		return "Hoàn tiền"
+	default:
+		return fmt.Sprintf("Khác (%s)", kind)
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccountingRange(t *testing.T) {
+	from, to, err := AccountingRange("2026-03-01", "2026-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := from.UTC().Format(time.RFC3339); got != "2026-02-28T17:00:00Z" {
+		t.Errorf("from = %s; want midnight ICT", got)
+	}
+	if got := to.UTC().Format(time.RFC3339); got != "2026-03-31T17:00:00Z" {
+		t.Errorf("to = %s; want the day after the last one", got)
+	}
+	if _, _, err := AccountingRange("2026-03-31", "2026-03-01"); err != ErrInvalidAccountingRange {
+		t.Errorf("reversed range: err = %v", err)
+	}
+	if _, _, err := AccountingRange("01/03/2026", "2026-03-31"); err != ErrInvalidAccountingRange {
+		t.Errorf("bad date: err = %v", err)
+	}
+}
+
+func TestBuildJournal(t *testing.T) {
+	day := time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)
+	invoices := []*AccountingInvoice{
+		{
+			Code: "HD-2026-000012", IssuedAt: day, CustomerName: "Trần Thị B", TechnicianName: "Nguyễn Văn A",
+			Lines: []InvoiceLine{
+				{Kind: InvoiceLineService, Subtotal: 500000, Discount: 50000, Tax: 45000},
+				{Kind: InvoiceLineLabor, Subtotal: 200000, Tax: 20000},
+				{Kind: InvoiceLinePart, Subtotal: 300000, Tax: 30000},
+			},
+			Tax: 95000, Total: 1045000, Cost: 180000.4, Commission: 100000,
+		},
+		{Code: "HD-2026-000001", IssuedAt: day.Add(-48 * time.Hour), Total: 400000},
+	}
+	payments := []*AccountingPayment{
+		{ID: "p1", InvoiceCode: "HD-2026-000012", Kind: PaymentIn, Method: MethodCash, Amount: 1045000, PaidAt: day.Add(time.Hour)},
+		{ID: "p2", InvoiceCode: "HD-2026-000001", Kind: PaymentRefund, Method: MethodTransfer, Amount: 100000, PaidAt: day.Add(2 * time.Hour), Reference: "FT123"},
+	}
+
+	entries := BuildJournal(invoices, payments, DefaultChartOfAccounts)
+	// 5 postings for the first invoice, service revenue only for the legacy one, 2 payments
+	if len(entries) != 8 {
+		t.Fatalf("entries = %d; want 8", len(entries))
+	}
+	if entries[0].InvoiceCode != "HD-2026-000001" || entries[0].Credit != "5113" || entries[0].Amount != 400000 {
+		t.Errorf("legacy invoice not first as service revenue: %+v", entries[0])
+	}
+
+	var totals JournalTotals
+	totals.Add(entries)
+	if totals.Revenue != 1350000 || totals.VAT != 95000 {
+		t.Errorf("revenue/VAT = %v/%v", totals.Revenue, totals.VAT)
+	}
+	if totals.Cost != 180000 || totals.Commission != 100000 {
+		t.Errorf("cost/commission = %v/%v", totals.Cost, totals.Commission)
+	}
+	if totals.Receipts != 1045000 || totals.Refunds != 100000 {
+		t.Errorf("receipts/refunds = %v/%v", totals.Receipts, totals.Refunds)
+	}
+
+	for _, e := range entries {
+		switch e.Kind {
+		case JournalCommission:
+			if e.Debit != "6421" || e.Credit != "334" || e.Partner != "Nguyễn Văn A" {
+				t.Errorf("commission posting: %+v", e)
+			}
+		case JournalReceipt:
+			if e.Debit != "1111" || e.Credit != "131" || e.Voucher != "PT-p1" {
+				t.Errorf("cash receipt posting: %+v", e)
+			}
+		case JournalRefund:
+			if e.Debit != "131" || e.Credit != "1121" || e.Voucher != "UNC-p2" {
+				t.Errorf("transfer refund posting: %+v", e)
+			}
+		}
+	}
+}
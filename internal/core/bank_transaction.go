@@ -0,0 +1,424 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bank transaction sources (bank_transactions.source)
+const (
+	BankSourceCasso     = "casso"
+	BankSourceSePay     = "sepay"
+	BankSourceLocal     = "local"     // Admin stand-in for testing without a bank feed
+	BankSourceStatement = "statement" // CSV/Excel statement import
+)
+
+// Bank transaction statuses: unmatched ones form the admin review queue
+const (
+	BankTxMatched   = "matched"
+	BankTxUnmatched = "unmatched"
+	BankTxIgnored   = "ignored"
+)
+
+var (
+	ErrDuplicateTransaction = errors.New("bank transaction already recorded")
+	ErrInvalidBankPayload   = errors.New("invalid bank transaction payload")
+	ErrStatementColumns     = errors.New("statement has no date/amount/description header")
+	ErrTransactionResolved  = errors.New("bank transaction already matched")
+	ErrInvoiceNotPayable    = errors.New("invoice not found or already paid")
+)
+
+// BankTransaction is one incoming transfer reported by the bank feed or a statement
+type BankTransaction struct {
+	ID            string    `json:"id"`
+	Source        string    `json:"source"`
+	ExternalID    string    `json:"external_id"` // Bank/provider reference, unique per source
+	AccountNumber string    `json:"account_number"`
+	Amount        float64   `json:"amount"`
+	Description   string    `json:"description"` // Transfer memo
+	TransactedAt  time.Time `json:"transacted_at"`
+
+	Status             string `json:"status"`
+	InvoiceID          string `json:"invoice_id"`
+	SuggestedInvoiceID string `json:"suggested_invoice_id"` // Best guess shown in the review queue
+	MatchNote          string `json:"match_note"`
+	ReviewedBy         string `json:"reviewed_by"`
+	Created            string `json:"created"`
+
+	// Display only (review queue)
+	InvoiceCode          string `json:"invoice_code,omitempty"`
+	SuggestedInvoiceCode string `json:"suggested_invoice_code,omitempty"`
+}
+
+// LocalTime is the transaction time in Vietnam time, as printed on statements
+func (t *BankTransaction) LocalTime() time.Time {
+	return t.TransactedAt.In(fiscalZone)
+}
+
+// PayableInvoice is an invoice as seen by the payments ledger
+type PayableInvoice struct {
+	ID        string  `json:"id"`
+	BookingID string  `json:"booking_id"`
+	Code      string  `json:"code"`   // Empty until issued
+	Amount    float64 `json:"amount"` // Amount due
+	Total     float64 `json:"total"`
+	Status    string  `json:"status"`
+}
+
+// BankMatch is the outcome of matching one transaction against the open invoices
+type BankMatch struct {
+	InvoiceID   string // Set when the transfer settles the invoice automatically
+	SuggestedID string // Candidate for the review queue
+	Note        string
+}
+
+var invoiceCodeInMemo = regexp.MustCompile(`(?i)` + InvoicePrefix + `[\s\-_.]*(\d{4})[\s\-_.]*(\d{6})`)
+
+// FindInvoiceCodes extracts invoice numbers from a transfer memo. Banks often
+// drop the dashes (HD2026000123) or replace them with spaces.
+func FindInvoiceCodes(memo string) []string {
+	var codes []string
+	for _, m := range invoiceCodeInMemo.FindAllStringSubmatch(memo, -1) {
+		year, _ := strconv.Atoi(m[1])
+		seq, _ := strconv.Atoi(m[2])
+		if seq > 0 {
+			codes = append(codes, FormatInvoiceCode(year, seq))
+		}
+	}
+	return codes
+}
+
+// MatchBankTransaction settles an invoice only when the memo names it AND the
+// amount is exactly what is due; anything weaker goes to the review queue
+// with a suggestion.
+func MatchBankTransaction(tx *BankTransaction, open []PayableInvoice) BankMatch {
+	if tx.Amount <= 0 {
+		return BankMatch{Note: "Không phải giao dịch tiền vào"}
+	}
+
+	byCode := make(map[string]PayableInvoice, len(open))
+	for _, inv := range open {
+		if inv.Code != "" {
+			byCode[inv.Code] = inv
+		}
+	}
+
+	codes := FindInvoiceCodes(tx.Description)
+	for _, code := range codes {
+		inv, ok := byCode[code]
+		if !ok {
+			continue
+		}
+		if sameVND(inv.Amount, tx.Amount) {
+			return BankMatch{InvoiceID: inv.ID, Note: "Khớp mã hóa đơn và số tiền"}
+		}
+		return BankMatch{SuggestedID: inv.ID,
+			Note: fmt.Sprintf("Số tiền không khớp hóa đơn %s (cần %.0f)", code, inv.Amount)}
+	}
+
+	var sameAmount []PayableInvoice
+	for _, inv := range open {
+		if sameVND(inv.Amount, tx.Amount) {
+			sameAmount = append(sameAmount, inv)
+		}
+	}
+
+	var note string
+	switch {
+	case len(codes) > 0:
+		note = "Mã " + strings.Join(codes, ", ") + " không có hoặc đã thanh toán"
+	case len(sameAmount) == 1:
+		note = "Không có mã hóa đơn, chỉ khớp số tiền"
+	case len(sameAmount) > 1:
+		note = fmt.Sprintf("Không có mã hóa đơn, %d hóa đơn cùng số tiền", len(sameAmount))
+	default:
+		note = "Không tìm thấy hóa đơn phù hợp"
+	}
+	match := BankMatch{Note: note}
+	if len(sameAmount) == 1 {
+		match.SuggestedID = sameAmount[0].ID
+	}
+	return match
+}
+
+func sameVND(a, b float64) bool {
+	return math.Abs(a-b) < 0.5
+}
+
+// --- Provider webhooks ---
+
+// cassoWebhook is the Casso v2 webhook body
+type cassoWebhook struct {
+	Error int `json:"error"`
+	Data  []struct {
+		ID          json.Number `json:"id"`
+		Tid         string      `json:"tid"`
+		Description string      `json:"description"`
+		Amount      float64     `json:"amount"`
+		When        string      `json:"when"`
+		SubAccount  string      `json:"bank_sub_acc_id"`
+	} `json:"data"`
+}
+
+// sepayWebhook is the SePay webhook body (one transaction per call)
+type sepayWebhook struct {
+	ID              json.Number `json:"id"`
+	TransactionDate string      `json:"transactionDate"`
+	AccountNumber   string      `json:"accountNumber"`
+	Content         string      `json:"content"`
+	TransferType    string      `json:"transferType"` // in | out
+	TransferAmount  float64     `json:"transferAmount"`
+	ReferenceCode   string      `json:"referenceCode"`
+}
+
+// localWebhook is the stand-in format used by the admin simulator and tests
+type localWebhook struct {
+	Reference     string  `json:"reference"`
+	AccountNumber string  `json:"account_number"`
+	Amount        float64 `json:"amount"`
+	Description   string  `json:"description"`
+	When          string  `json:"when"`
+}
+
+// ParseBankWebhook converts a provider webhook body into transactions.
+// Outgoing transfers are dropped.
+func ParseBankWebhook(source string, body []byte) ([]*BankTransaction, error) {
+	switch source {
+	case BankSourceCasso:
+		var payload cassoWebhook
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBankPayload, err)
+		}
+		txs := make([]*BankTransaction, 0, len(payload.Data))
+		for _, d := range payload.Data {
+			if d.Amount <= 0 {
+				continue
+			}
+			ref := d.Tid
+			if ref == "" {
+				ref = d.ID.String()
+			}
+			txs = append(txs, &BankTransaction{
+				Source: source, ExternalID: ref, AccountNumber: d.SubAccount,
+				Amount: d.Amount, Description: d.Description, TransactedAt: ParseBankTime(d.When),
+			})
+		}
+		return txs, nil
+
+	case BankSourceSePay:
+		var d sepayWebhook
+		if err := json.Unmarshal(body, &d); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBankPayload, err)
+		}
+		if d.TransferType == "out" || d.TransferAmount <= 0 {
+			return nil, nil
+		}
+		ref := d.ReferenceCode
+		if ref == "" {
+			ref = d.ID.String()
+		}
+		return []*BankTransaction{{
+			Source: source, ExternalID: ref, AccountNumber: d.AccountNumber,
+			Amount: d.TransferAmount, Description: d.Content, TransactedAt: ParseBankTime(d.TransactionDate),
+		}}, nil
+
+	case BankSourceLocal:
+		var d localWebhook
+		if err := json.Unmarshal(body, &d); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBankPayload, err)
+		}
+		if d.Amount <= 0 {
+			return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidBankPayload)
+		}
+		tx := &BankTransaction{
+			Source: source, ExternalID: d.Reference, AccountNumber: d.AccountNumber,
+			Amount: d.Amount, Description: d.Description, TransactedAt: ParseBankTime(d.When),
+		}
+		if tx.ExternalID == "" {
+			tx.ExternalID = tx.Fingerprint()
+		}
+		return []*BankTransaction{tx}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown source %q", ErrInvalidBankPayload, source)
+}
+
+// Fingerprint identifies a transaction without a bank reference, so that
+// importing the same statement twice does not create duplicates.
+func (t *BankTransaction) Fingerprint() string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%.0f|%s", t.TransactedAt.UTC().Format(time.RFC3339), t.Amount, strings.TrimSpace(t.Description))))
+	return "fp-" + hex.EncodeToString(sum[:8])
+}
+
+// --- Statement import ---
+
+// Header keywords (lowercase, matched as substrings) of the usual Vietnamese bank exports
+var statementColumns = map[string][]string{
+	"date":        {"ngày giao dịch", "ngày gd", "ngày hiệu lực", "thời gian", "ngày", "date"},
+	"credit":      {"ghi có", "số tiền có", "tiền vào", "credit", "có"},
+	"amount":      {"số tiền", "amount"},
+	"description": {"nội dung", "diễn giải", "mô tả", "description", "remark"},
+	"reference":   {"số tham chiếu", "mã giao dịch", "số bút toán", "reference", "ref", "số ct"},
+	"account":     {"số tài khoản", "tài khoản", "account"},
+}
+
+// ParseStatementRows reads a bank statement already split into cells (CSV or
+// the first Excel sheet). The header row is detected from its labels; only
+// credit lines (money in) are returned.
+func ParseStatementRows(rows [][]string) ([]*BankTransaction, error) {
+	header, cols := -1, map[string]int{}
+	for i, row := range rows {
+		if found := statementHeader(row); found["date"] >= 0 && found["description"] >= 0 &&
+			(found["credit"] >= 0 || found["amount"] >= 0) {
+			header, cols = i, found
+			break
+		}
+	}
+	if header < 0 {
+		return nil, ErrStatementColumns
+	}
+	amountCol := cols["credit"]
+	if amountCol < 0 {
+		amountCol = cols["amount"]
+	}
+
+	cell := func(row []string, col int) string {
+		if col < 0 || col >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[col])
+	}
+
+	var txs []*BankTransaction
+	for _, row := range rows[header+1:] {
+		amount := ParseVNDAmount(cell(row, amountCol))
+		when := ParseBankTime(cell(row, cols["date"]))
+		if amount <= 0 || when.IsZero() {
+			continue // Debit line, blank line or footer total
+		}
+		tx := &BankTransaction{
+			Source:        BankSourceStatement,
+			ExternalID:    cell(row, cols["reference"]),
+			AccountNumber: cell(row, cols["account"]),
+			Amount:        amount,
+			Description:   cell(row, cols["description"]),
+			TransactedAt:  when,
+		}
+		if tx.ExternalID == "" {
+			tx.ExternalID = tx.Fingerprint()
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+func statementHeader(row []string) map[string]int {
+	found := map[string]int{}
+	for key := range statementColumns {
+		found[key] = -1
+	}
+	for i, raw := range row {
+		label := strings.ToLower(strings.TrimSpace(raw))
+		if label == "" {
+			continue
+		}
+		// "Số tiền ghi có" must land on credit, not amount: most specific key first
+		for _, key := range []string{"credit", "reference", "account", "date", "description", "amount"} {
+			if found[key] >= 0 {
+				continue
+			}
+			if matchesLabel(label, statementColumns[key]) {
+				found[key] = i
+				break
+			}
+		}
+	}
+	return found
+}
+
+func matchesLabel(label string, keywords []string) bool {
+	for _, kw := range keywords {
+		if label == kw || (len([]rune(kw)) > 2 && strings.Contains(label, kw)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseVNDAmount reads amounts such as "1.500.000", "1,500,000", "1500000.00"
+// or "+1.500.000 VND". Debits ("-200.000", "(200.000)") are negative.
+func ParseVNDAmount(s string) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	negative := strings.HasPrefix(s, "-") || strings.HasPrefix(s, "(")
+	// Drop a 1-2 digit decimal part: VND has no minor unit
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 {
+		if tail := strings.TrimRight(s[i+1:], " )VNDvndđ"); len(tail) > 0 && len(tail) <= 2 && isDigits(tail) {
+			s = s[:i]
+		}
+	}
+	var digits strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	value, err := strconv.ParseFloat(digits.String(), 64)
+	if err != nil {
+		return 0
+	}
+	if negative {
+		return -value
+	}
+	return value
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var bankTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+	"02-01-2006 15:04:05",
+	"02-01-2006",
+}
+
+// ParseBankTime reads the timestamps of bank feeds and statements; values
+// without a zone are Vietnam time. Zero when unparseable.
+func ParseBankTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range bankTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, fiscalZone); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ReconcileResult summarizes one webhook call or statement import
+type ReconcileResult struct {
+	Received   int `json:"received"`
+	Duplicates int `json:"duplicates"`
+	Matched    int `json:"matched"`
+	Unmatched  int `json:"unmatched"`
+	Ignored    int `json:"ignored"`
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindInvoiceCodes(t *testing.T) {
+	cases := map[string][]string{
+		"THANH TOAN HD-2026-000123":           {"HD-2026-000123"},
+		"MBVCB.123 thanh toan hd2026000123 ":  {"HD-2026-000123"},
+		"TT HD 2026 000045 va HD-2026-000046": {"HD-2026-000045", "HD-2026-000046"},
+		"chuyen tien":                         nil,
+		"HD-2026-000000":                      nil,
+	}
+	for memo, want := range cases {
+		if got := FindInvoiceCodes(memo); !reflect.DeepEqual(got, want) {
+			t.Errorf("FindInvoiceCodes(%q) = %v; want %v", memo, got, want)
+		}
+	}
+}
+
+func TestMatchBankTransaction(t *testing.T) {
+	open := []PayableInvoice{
+		{ID: "inv1", Code: "HD-2026-000001", Amount: 540000},
+		{ID: "inv2", Code: "HD-2026-000002", Amount: 1200000},
+		{ID: "inv3", Code: "", Amount: 1200000},
+		{ID: "inv4", Code: "HD-2026-000004", Amount: 350000},
+	}
+	cases := []struct {
+		name          string
+		tx            BankTransaction
+		matched, hint string
+	}{
+		{"code and amount", BankTransaction{Amount: 540000, Description: "THANH TOAN HD2026000001"}, "inv1", ""},
+		{"code, wrong amount", BankTransaction{Amount: 500000, Description: "HD-2026-000001"}, "", "inv1"},
+		{"amount only, unique", BankTransaction{Amount: 350000, Description: "chuyen khoan"}, "", "inv4"},
+		{"amount only, ambiguous", BankTransaction{Amount: 1200000, Description: "ck"}, "", ""},
+		{"unknown code", BankTransaction{Amount: 540000, Description: "HD-2025-000001"}, "", "inv1"},
+		{"outgoing", BankTransaction{Amount: -540000, Description: "HD-2026-000001"}, "", ""},
+	}
+	for _, c := range cases {
+		got := MatchBankTransaction(&c.tx, open)
+		if got.InvoiceID != c.matched || got.SuggestedID != c.hint {
+			t.Errorf("%s: got invoice=%q suggested=%q (%s); want %q/%q", c.name, got.InvoiceID, got.SuggestedID, got.Note, c.matched, c.hint)
+		}
+		if got.Note == "" {
+			t.Errorf("%s: match note must explain the outcome", c.name)
+		}
+	}
+}
+
+func TestParseBankWebhook(t *testing.T) {
+	casso := `{"error":0,"data":[
+		{"id":11,"tid":"FT123","description":"THANH TOAN HD-2026-000001","amount":540000,"when":"2026-03-01 10:20:00","bank_sub_acc_id":"0123456789"},
+		{"id":12,"tid":"FT124","description":"phi","amount":-11000,"when":"2026-03-01 10:21:00"}]}`
+	txs, err := ParseBankWebhook(BankSourceCasso, []byte(casso))
+	if err != nil || len(txs) != 1 {
+		t.Fatalf("casso: %v, %d transactions", err, len(txs))
+	}
+	if tx := txs[0]; tx.ExternalID != "FT123" || tx.Amount != 540000 || tx.TransactedAt.IsZero() || tx.AccountNumber != "0123456789" {
+		t.Errorf("casso transaction = %+v", tx)
+	}
+
+	sepay := `{"id":92704,"gateway":"Vietcombank","transactionDate":"2026-03-25 14:02:37","accountNumber":"0123499999",
+		"content":"HD2026000002","transferType":"in","transferAmount":1200000,"referenceCode":"MBVCB.3278907687"}`
+	txs, err = ParseBankWebhook(BankSourceSePay, []byte(sepay))
+	if err != nil || len(txs) != 1 || txs[0].ExternalID != "MBVCB.3278907687" || txs[0].Amount != 1200000 {
+		t.Fatalf("sepay: %v %+v", err, txs)
+	}
+	txs, _ = ParseBankWebhook(BankSourceSePay, []byte(`{"id":1,"transferType":"out","transferAmount":5000}`))
+	if len(txs) != 0 {
+		t.Errorf("sepay outgoing transfer must be dropped")
+	}
+
+	txs, err = ParseBankWebhook(BankSourceLocal, []byte(`{"amount":350000,"description":"test"}`))
+	if err != nil || len(txs) != 1 || txs[0].ExternalID == "" {
+		t.Fatalf("local: %v %+v", err, txs)
+	}
+	if _, err := ParseBankWebhook("unknown", []byte(`{}`)); err == nil {
+		t.Error("unknown source must fail")
+	}
+}
+
+func TestParseStatementRows(t *testing.T) {
+	rows := [][]string{
+		{"SAO KÊ TÀI KHOẢN"},
+		{"Số tài khoản: 0123456789"},
+		{"STT", "Ngày giao dịch", "Số tham chiếu", "Số tiền ghi nợ", "Số tiền ghi có", "Nội dung"},
+		{"1", "01/03/2026 09:15:00", "FT001", "", "540.000", "THANH TOAN HD-2026-000001"},
+		{"2", "01/03/2026", "FT002", "11.000", "", "Phi SMS"},
+		{"3", "02/03/2026", "", "", "1,200,000.00", "ck tien may lanh"},
+		{"", "Tổng cộng", "", "11.000", "1.740.000", ""},
+	}
+	txs, err := ParseStatementRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("got %d transactions; want 2 credits", len(txs))
+	}
+	if txs[0].ExternalID != "FT001" || txs[0].Amount != 540000 || txs[0].Description != "THANH TOAN HD-2026-000001" {
+		t.Errorf("first = %+v", txs[0])
+	}
+	if txs[1].Amount != 1200000 || txs[1].ExternalID == "" {
+		t.Errorf("second = %+v (needs a fingerprint reference)", txs[1])
+	}
+	again, _ := ParseStatementRows(rows)
+	if again[1].ExternalID != txs[1].ExternalID {
+		t.Error("fingerprint must be stable across imports")
+	}
+
+	if _, err := ParseStatementRows([][]string{{"a", "b"}, {"1", "2"}}); err != ErrStatementColumns {
+		t.Errorf("missing header: err = %v", err)
+	}
+}
+
+func TestParseVNDAmount(t *testing.T) {
+	cases := map[string]float64{
+		"1.500.000":    1500000,
+		"1,500,000":    1500000,
+		"1500000.00":   1500000,
+		"1.500.000,00": 1500000,
+		"+540.000 VND": 540000,
+		"-200.000":     -200000,
+		"(200,000)":    -200000,
+		"":             0,
+		"abc":          0,
+		"12.5":         12,
+		"1 200 000 đ":  1200000,
+	}
+	for in, want := range cases {
+		if got := ParseVNDAmount(in); got != want {
+			t.Errorf("ParseVNDAmount(%q) = %v; want %v", in, got, want)
+		}
+	}
+}
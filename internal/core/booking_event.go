@@ -0,0 +1,45 @@
+package core
+
+// Actor types recorded on booking events
+const (
+	ActorAdmin    = "admin"
+	ActorTech     = "tech"
+	ActorCustomer = "customer"
+	ActorSystem   = "system"
+)
+
+// Actor identifies who triggered a booking change
+type Actor struct {
+	Type   string `json:"type"`   // admin | tech | customer | system
+	ID     string `json:"id"`     // Auth record ID (empty for system/customer)
+	Name   string `json:"name"`   // Display name at the time of the change
+	Source string `json:"source"` // Optional channel: kanban, tech_app, geofence, payment, portal...
+}
+
+// SystemActor is used for automated changes (cron, geofence...)
+func SystemActor(source string) Actor {
+	return Actor{Type: ActorSystem, Name: "Hệ thống", Source: source}
+}
+
+// Booking event types (booking_events.type)
+const (
+	EventStatusChanged = "status_changed"
+	EventAssigned      = "assigned"
+	EventReassigned    = "reassigned"
+	EventRecalled      = "recalled"
+	EventRescheduled   = "rescheduled"
+	EventCancelled     = "cancelled"
+)
+
+// BookingEvent is one persisted entry of a booking's timeline
+type BookingEvent struct {
+	ID         string                 `json:"id"`
+	BookingID  string                 `json:"booking_id"`
+	Type       string                 `json:"type"`
+	FromStatus string                 `json:"from_status"`
+	ToStatus   string                 `json:"to_status"`
+	Actor      Actor                  `json:"actor"`
+	Note       string                 `json:"note"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Created    string                 `json:"created"`
+}
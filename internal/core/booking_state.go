@@ -0,0 +1,218 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Booking lifecycle states (stored in bookings.job_status)
+const (
+	StatusPending   = "pending"
+	StatusAssigned  = "assigned"
+	StatusAccepted  = "accepted"
+	StatusMoving    = "moving"
+	StatusArrived   = "arrived"
+	StatusWorking   = "working"
+	StatusQuoting   = "quoting"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+// DateTimeLayout is the datetime format PocketBase stores and returns
+const DateTimeLayout = "2006-01-02 15:04:05.000Z"
+
+// ErrInvalidTransition is wrapped by every TransitionError (use errors.Is)
+var ErrInvalidTransition = errors.New("invalid booking status transition")
+
+// TransitionError is returned when a booking cannot move from its current status to the requested one
+type TransitionError struct {
+	BookingID string
+	From      string
+	To        string
+	Reason    string // Guard failure detail (optional)
+}
+
+func (e *TransitionError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("booking %s: cannot change status %s -> %s: %s", e.BookingID, e.From, e.To, e.Reason)
+	}
+	return fmt.Sprintf("booking %s: cannot change status %s -> %s", e.BookingID, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error { return ErrInvalidTransition }
+
+// Message returns a user-facing (Vietnamese) explanation for the UI
+func (e *TransitionError) Message() string {
+	switch {
+	case e.From == StatusCancelled:
+		return "Đơn hàng này đã bị hủy hoặc thay đổi trạng thái bởi Admin."
+	case e.From == StatusCompleted:
+		return "Đơn hàng đã hoàn thành, không thể thay đổi trạng thái."
+	case e.Reason != "":
+		return e.Reason
+	}
+	return fmt.Sprintf("Trạng thái không hợp lệ (Hiện tại: %s -> %s)", e.From, e.To)
+}
+
+// SideEffect is an infrastructure action the service layer runs after a transition
+type SideEffect int
+
+const (
+	EffectReleaseSlot    SideEffect = iota + 1 // Free the reserved time slot
+	EffectNotifyCustomer                       // Push status to the customer tracking channel
+)
+
+// Transition is one allowed edge of the booking lifecycle
+type Transition struct {
+	From    string
+	To      string
+	Guard   func(b *Booking) error // Optional precondition, error text is shown to the user
+	Effects []SideEffect
+}
+
+// Has reports whether the transition carries the given side effect
+func (t Transition) Has(effect SideEffect) bool {
+	for _, e := range t.Effects {
+		if e == effect {
+			return true
+		}
+	}
+	return false
+}
+
+// BookingStateMachine is the single source of truth for job_status changes.
+// Admin, tech and service code paths must all go through Apply.
+type BookingStateMachine struct {
+	edges map[string]map[string]Transition
+}
+
+func NewBookingStateMachine(transitions ...Transition) *BookingStateMachine {
+	m := &BookingStateMachine{edges: make(map[string]map[string]Transition)}
+	for _, t := range transitions {
+		if m.edges[t.From] == nil {
+			m.edges[t.From] = make(map[string]Transition)
+		}
+		m.edges[t.From][t.To] = t
+	}
+	return m
+}
+
+// Can reports whether from -> to is an allowed edge (guards are not evaluated)
+func (m *BookingStateMachine) Can(from, to string) bool {
+	_, ok := m.edges[from][to]
+	return ok
+}
+
+// Next lists the statuses reachable from the given one
+func (m *BookingStateMachine) Next(from string) []string {
+	var next []string
+	for _, s := range bookingStatusOrder {
+		if m.Can(from, s) {
+			next = append(next, s)
+		}
+	}
+	return next
+}
+
+// Apply validates the transition, mutates the booking (status + timestamps)
+// and returns the edge so the caller can run its side effects.
+func (m *BookingStateMachine) Apply(b *Booking, to string, now time.Time) (Transition, error) {
+	t, ok := m.edges[b.JobStatus][to]
+	if !ok {
+		return Transition{}, &TransitionError{BookingID: b.ID, From: b.JobStatus, To: to}
+	}
+	if t.Guard != nil {
+		if err := t.Guard(b); err != nil {
+			return Transition{}, &TransitionError{BookingID: b.ID, From: b.JobStatus, To: to, Reason: err.Error()}
+		}
+	}
+
+	stamp := now.UTC().Format(DateTimeLayout)
+	switch to {
+	case StatusPending:
+		// Recall: job goes back to the pool without progress
+		b.TechnicianID = ""
+		b.MovingStartAt = ""
+		b.ArrivedAt = ""
+		b.StartedAt = ""
+		b.CompletedAt = ""
+	case StatusMoving:
+		b.MovingStartAt = stamp
+	case StatusArrived:
+		b.ArrivedAt = stamp
+	case StatusWorking:
+		if b.StartedAt == "" { // Keep first start when coming back from quoting
+			b.StartedAt = stamp
+		}
+	case StatusCompleted:
+		b.CompletedAt = stamp
+	}
+
+	b.JobStatus = to
+	return t, nil
+}
+
+// CustomerCanModify reports whether the customer may still reschedule or
+// cancel from the self-service portal (until the technician is on the way)
+func CustomerCanModify(status string) bool {
+	switch status {
+	case StatusPending, StatusAssigned, StatusAccepted:
+		return true
+	}
+	return false
+}
+
+// bookingStatusOrder keeps Next() deterministic
+var bookingStatusOrder = []string{
+	StatusPending, StatusAssigned, StatusAccepted, StatusMoving, StatusArrived,
+	StatusWorking, StatusQuoting, StatusCompleted, StatusCancelled,
+}
+
+func requireTechnician(b *Booking) error {
+	if b.TechnicianID == "" {
+		return errors.New("Đơn hàng chưa được giao cho thợ")
+	}
+	return nil
+}
+
+// BookingLifecycle is the default booking state machine.
+//
+//	pending -> assigned -> accepted -> moving -> arrived -> working <-> quoting -> completed
+//
+// Recall to pending is allowed until the tech arrives; cancel until completion.
+var BookingLifecycle = NewBookingStateMachine(bookingTransitions()...)
+
+func bookingTransitions() []Transition {
+	release := []SideEffect{EffectReleaseSlot, EffectNotifyCustomer}
+	notify := []SideEffect{EffectNotifyCustomer}
+
+	t := []Transition{
+		{From: StatusPending, To: StatusAssigned, Guard: requireTechnician},
+		{From: StatusAssigned, To: StatusAssigned, Guard: requireTechnician}, // Reassign
+		{From: StatusAssigned, To: StatusAccepted, Guard: requireTechnician},
+
+		{From: StatusAssigned, To: StatusMoving, Guard: requireTechnician, Effects: notify},
+		{From: StatusAccepted, To: StatusMoving, Guard: requireTechnician, Effects: notify},
+		{From: StatusMoving, To: StatusArrived, Effects: notify},
+
+		// Admin Kanban collapses moving/arrived into "working"
+		{From: StatusAssigned, To: StatusWorking, Guard: requireTechnician, Effects: notify},
+		{From: StatusAccepted, To: StatusWorking, Guard: requireTechnician, Effects: notify},
+		{From: StatusMoving, To: StatusWorking, Effects: notify},
+		{From: StatusArrived, To: StatusWorking, Effects: notify},
+
+		{From: StatusWorking, To: StatusQuoting, Effects: notify},
+		{From: StatusQuoting, To: StatusWorking, Effects: notify},
+		{From: StatusWorking, To: StatusCompleted, Effects: notify},
+		{From: StatusQuoting, To: StatusCompleted, Effects: notify},
+	}
+
+	for _, from := range []string{StatusAssigned, StatusAccepted, StatusMoving} {
+		t = append(t, Transition{From: from, To: StatusPending, Effects: release})
+	}
+	for _, from := range []string{StatusPending, StatusAssigned, StatusAccepted, StatusMoving, StatusArrived, StatusWorking, StatusQuoting} {
+		t = append(t, Transition{From: from, To: StatusCancelled, Effects: release})
+	}
+	return t
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBookingLifecycle_HappyPath(t *testing.T) {
+	b := &Booking{ID: "b1", JobStatus: StatusPending, TechnicianID: "t1"}
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	for _, to := range []string{StatusAssigned, StatusAccepted, StatusMoving, StatusArrived, StatusWorking, StatusCompleted} {
+		if _, err := BookingLifecycle.Apply(b, to, now); err != nil {
+			t.Fatalf("Apply(%s): %v", to, err)
+		}
+	}
+
+	if b.JobStatus != StatusCompleted {
+		t.Errorf("Expected completed, got %s", b.JobStatus)
+	}
+	if b.MovingStartAt == "" || b.ArrivedAt == "" || b.StartedAt == "" || b.CompletedAt == "" {
+		t.Errorf("Expected all timestamps to be stamped, got %+v", b)
+	}
+}
+
+func TestBookingLifecycle_InvalidTransition(t *testing.T) {
+	b := &Booking{ID: "b1", JobStatus: StatusCancelled}
+
+	_, err := BookingLifecycle.Apply(b, StatusWorking, time.Now())
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Expected ErrInvalidTransition, got %v", err)
+	}
+
+	var te *TransitionError
+	if !errors.As(err, &te) || te.From != StatusCancelled || te.To != StatusWorking {
+		t.Errorf("Unexpected error detail: %#v", err)
+	}
+	if b.JobStatus != StatusCancelled {
+		t.Errorf("Status must not change on failure, got %s", b.JobStatus)
+	}
+}
+
+func TestBookingLifecycle_AssignRequiresTechnician(t *testing.T) {
+	b := &Booking{ID: "b1", JobStatus: StatusPending}
+
+	_, err := BookingLifecycle.Apply(b, StatusAssigned, time.Now())
+	var te *TransitionError
+	if !errors.As(err, &te) || te.Reason == "" {
+		t.Fatalf("Expected guard failure, got %v", err)
+	}
+}
+
+func TestBookingLifecycle_RecallClearsProgress(t *testing.T) {
+	b := &Booking{ID: "b1", JobStatus: StatusMoving, TechnicianID: "t1", MovingStartAt: "2026-03-01 08:00:00.000Z"}
+
+	tr, err := BookingLifecycle.Apply(b, StatusPending, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Has(EffectReleaseSlot) {
+		t.Error("Recall must release the slot")
+	}
+	if b.TechnicianID != "" || b.MovingStartAt != "" {
+		t.Errorf("Recall must clear technician and timestamps, got %+v", b)
+	}
+}
@@ -1,5 +1,13 @@
 package core
 
+import "time"
+
+// Scheduling defaults used when the brand or service does not set its own value
+const (
+	DefaultTravelBufferMinutes = 30
+	DefaultJobDurationMinutes  = 60
+)
+
 // Brand represents a business entity/tenant in the system
 type Brand struct {
 	Id          string `json:"id" db:"id"`
@@ -36,7 +44,39 @@ type Brand struct {
 	BankOwner   string `json:"bank_owner" db:"bank_owner"`
 	QrTemplate  string `json:"qr_template" db:"qr_template"`
 
+	// [NEW] Dispatch: auto-assign pending bookings after N minutes without admin action
+	AutoAssignEnabled      bool `json:"auto_assign_enabled" db:"auto_assign_enabled"`
+	AutoAssignAfterMinutes int  `json:"auto_assign_after_minutes" db:"auto_assign_after_minutes"`
+
+	// [NEW] Scheduling: gap kept between two jobs of the same tech (0 = default)
+	TravelBufferMinutes int `json:"travel_buffer_minutes" db:"travel_buffer_minutes"`
+
+	// [NEW] Invoicing: VAT code for services/items without their own rate, hourly rate of extra labor
+	DefaultVAT    string  `json:"default_vat" db:"default_vat"`
+	LaborHourRate float64 `json:"labor_hour_rate" db:"labor_hour_rate"`
+
+	// [NEW] Reconciliation: token the bank feed (Casso/SePay) sends with each webhook
+	BankWebhookSecret string `json:"-" db:"bank_webhook_secret"`
+
+	// [NEW] Payroll: deducted per "customer not home" cancellation (0 = none)
+	PayrollNoShowPenalty float64 `json:"payroll_no_show_penalty" db:"payroll_no_show_penalty"`
+
+	// [NEW] E-invoicing: seller tax code (MST) and the two letters ending the series (C26T + AA)
+	TaxCode        string `json:"tax_code" db:"tax_code"`
+	EInvoiceSeries string `json:"einvoice_series" db:"einvoice_series"`
+
+	// [NEW] Inventory: how stock is costed, CostingAverage or CostingFIFO
+	InventoryCosting string `json:"inventory_costing" db:"inventory_costing"`
+
 	// Meta
 	Created string `json:"created" db:"created"`
 	Updated string `json:"updated" db:"updated"`
 }
+
+// TravelBuffer returns the brand's travel buffer (default when unset or brand is nil)
+func (b *Brand) TravelBuffer() time.Duration {
+	if b == nil || b.TravelBufferMinutes <= 0 {
+		return DefaultTravelBufferMinutes * time.Minute
+	}
+	return time.Duration(b.TravelBufferMinutes) * time.Minute
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Cash handover statuses (cash_handovers.status)
+const (
+	HandoverSubmitted = "submitted" // Tech says the cash was handed in, admin has to count it
+	HandoverConfirmed = "confirmed"
+	HandoverRejected  = "rejected" // Nothing received, the cash stays with the tech
+)
+
+var (
+	ErrInvalidHandover    = errors.New("handover amount must be positive")
+	ErrHandoverPending    = errors.New("a cash handover is already awaiting confirmation")
+	ErrHandoverNotPending = errors.New("cash handover is not awaiting confirmation")
+)
+
+// CashHandover is the cash a technician hands in to the office, usually at
+// the end of the day. Expected is frozen at submission so that cash collected
+// while the admin counts is not part of the discrepancy.
+type CashHandover struct {
+	ID             string    `json:"id"`
+	TechnicianID   string    `json:"technician_id"`
+	TechnicianName string    `json:"technician_name"`
+	Expected       float64   `json:"expected"`    // Cash on hand according to the ledger
+	Declared       float64   `json:"declared"`    // Amount the tech says they hand in
+	Received       float64   `json:"received"`    // Amount counted by the admin
+	Discrepancy    float64   `json:"discrepancy"` // Received - Expected (negative = short)
+	Status         string    `json:"status"`
+	Note           string    `json:"note"`
+	SubmittedAt    time.Time `json:"submitted_at"`
+	ConfirmedBy    string    `json:"confirmed_by"`
+	ConfirmedAt    time.Time `json:"confirmed_at"`
+	AdminNote      string    `json:"admin_note"`
+	Created        string    `json:"created"`
+}
+
+// LocalTime is the submission time in Vietnam time
+func (h *CashHandover) LocalTime() time.Time {
+	return h.SubmittedAt.In(fiscalZone)
+}
+
+// Confirm records the amount counted by the admin and the discrepancy
+func (h *CashHandover) Confirm(received float64, by string, at time.Time) {
+	h.Status = HandoverConfirmed
+	h.Received = received
+	h.Discrepancy = received - h.Expected
+	if sameVND(received, h.Expected) {
+		h.Discrepancy = 0
+	}
+	h.ConfirmedBy = by
+	h.ConfirmedAt = at
+}
+
+// CashPosition is the cash a technician is carrying
+type CashPosition struct {
+	TechnicianID   string        `json:"technician_id"`
+	TechnicianName string        `json:"technician_name"`
+	Collected      float64       `json:"collected"`   // Cash payments collected, all time
+	HandedIn       float64       `json:"handed_in"`   // Received on confirmed handovers
+	Outstanding    float64       `json:"outstanding"` // Still to hand in
+	Shortage       float64       `json:"shortage"`    // Sum of negative discrepancies, deducted from payroll
+	Pending        *CashHandover `json:"pending,omitempty"`
+	LastHandover   *CashHandover `json:"last_handover,omitempty"`
+}
+
+// CashEntry is one line of a technician's cash ledger
+type CashEntry struct {
+	At        time.Time `json:"at"`
+	Kind      string    `json:"kind"` // "collected", "handover" or "shortage"
+	Amount    float64   `json:"amount"`
+	InvoiceID string    `json:"invoice_id,omitempty"`
+	Reference string    `json:"reference"` // Invoice code or handover note
+	Balance   float64   `json:"balance"`   // Cash on hand after the entry
+}
+
+// LocalTime is the entry time in Vietnam time
+func (c CashEntry) LocalTime() time.Time {
+	return c.At.In(fiscalZone)
+}
+
+// IsCashCollection reports whether the payment put cash in the collector's pocket
+func IsCashCollection(p *Payment) bool {
+	return p.Kind == PaymentIn && p.Method == MethodCash && p.Counted()
+}
+
+// BuildCashLedger merges cash collections and handovers of one technician,
+// oldest first, with the running cash on hand. Only counted cash payments and
+// confirmed handovers move the balance. A shortage on a handover leaves the
+// cash ledger: it is deducted from the technician's payroll instead.
+func BuildCashLedger(payments []*Payment, handovers []*CashHandover) ([]CashEntry, CashPosition) {
+	var pos CashPosition
+	entries := make([]CashEntry, 0, len(payments)+len(handovers))
+	for _, p := range payments {
+		if !IsCashCollection(p) {
+			continue
+		}
+		entries = append(entries, CashEntry{At: p.PaidAt, Kind: "collected", Amount: p.Amount, InvoiceID: p.InvoiceID, Reference: p.Reference})
+		pos.Collected += p.Amount
+	}
+	for _, h := range handovers {
+		switch h.Status {
+		case HandoverConfirmed:
+			entries = append(entries, CashEntry{At: h.ConfirmedAt, Kind: "handover", Amount: -h.Received, Reference: h.Note})
+			pos.HandedIn += h.Received
+			if h.Discrepancy < 0 {
+				entries = append(entries, CashEntry{At: h.ConfirmedAt, Kind: "shortage", Amount: h.Discrepancy, Reference: "Trừ lương"})
+				pos.Shortage -= h.Discrepancy
+			}
+			if pos.LastHandover == nil || h.ConfirmedAt.After(pos.LastHandover.ConfirmedAt) {
+				pos.LastHandover = h
+			}
+		case HandoverSubmitted:
+			pos.Pending = h
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+	balance := 0.0
+	for i := range entries {
+		balance += entries[i].Amount
+		entries[i].Balance = balance
+	}
+	pos.Outstanding = pos.Collected - pos.HandedIn - pos.Shortage
+	return entries, pos
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildCashLedger(t *testing.T) {
+	day := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	payments := []*Payment{
+		{Kind: PaymentIn, Method: MethodCash, Amount: 800000, Status: PaymentPosted, PaidAt: day.Add(time.Hour)},
+		{Kind: PaymentIn, Method: MethodCash, Amount: 1200000, Status: PaymentVoidPending, PaidAt: day.Add(3 * time.Hour)},
+		{Kind: PaymentIn, Method: MethodCash, Amount: 500000, Status: PaymentVoided, PaidAt: day.Add(4 * time.Hour)},
+		{Kind: PaymentIn, Method: MethodTransfer, Amount: 3000000, Status: PaymentPosted, PaidAt: day.Add(5 * time.Hour)},
+		{Kind: PaymentIn, Method: MethodCash, Amount: 400000, Status: PaymentPosted, PaidAt: day.Add(26 * time.Hour)},
+	}
+	handovers := []*CashHandover{
+		{Status: HandoverConfirmed, Expected: 2000000, Received: 1900000, Discrepancy: -100000, ConfirmedAt: day.Add(10 * time.Hour)},
+		{Status: HandoverSubmitted, Expected: 500000, Declared: 500000},
+		{Status: HandoverRejected, Expected: 300000, Declared: 300000},
+	}
+
+	entries, pos := BuildCashLedger(payments, handovers)
+	if len(entries) != 5 {
+		t.Fatalf("entries = %d; want 3 cash collections, 1 handover and its shortage", len(entries))
+	}
+	if entries[2].Kind != "handover" || entries[2].Balance != 100000 {
+		t.Errorf("after handover: kind = %q, balance = %v", entries[2].Kind, entries[2].Balance)
+	}
+	if entries[3].Kind != "shortage" || entries[3].Balance != 0 {
+		t.Errorf("shortage moved to payroll: kind = %q, balance = %v", entries[3].Kind, entries[3].Balance)
+	}
+	if entries[4].Balance != 400000 {
+		t.Errorf("running balance = %v; want 400000", entries[4].Balance)
+	}
+	if pos.Collected != 2400000 || pos.HandedIn != 1900000 || pos.Outstanding != 400000 {
+		t.Errorf("collected/handed in/outstanding = %v/%v/%v", pos.Collected, pos.HandedIn, pos.Outstanding)
+	}
+	if pos.Shortage != 100000 {
+		t.Errorf("shortage = %v; want 100000", pos.Shortage)
+	}
+	if pos.Pending == nil || pos.Pending.Declared != 500000 {
+		t.Errorf("pending handover not reported")
+	}
+}
+
+func TestCashHandoverConfirm(t *testing.T) {
+	h := &CashHandover{Status: HandoverSubmitted, Expected: 1500000}
+	h.Confirm(1400000, "admin@example.com", time.Now())
+	if h.Status != HandoverConfirmed || h.Discrepancy != -100000 {
+		t.Errorf("status = %q, discrepancy = %v", h.Status, h.Discrepancy)
+	}
+
+	h = &CashHandover{Status: HandoverSubmitted, Expected: 1500000}
+	h.Confirm(1500000.3, "admin@example.com", time.Now())
+	if h.Discrepancy != 0 {
+		t.Errorf("rounding difference recorded as discrepancy: %v", h.Discrepancy)
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"errors"
+	"time"
+)
+
+// Maintenance contract statuses (maintenance_contracts.status)
+const (
+	ContractActive    = "active"
+	ContractPaused    = "paused"    // No bookings generated until resumed
+	ContractExpired   = "expired"   // End date passed, waiting for renewal
+	ContractCancelled = "cancelled" // Terminated by the customer
+)
+
+const (
+	ContractLeadDays      = 7  // Booking is created this many days before the visit
+	ContractReminderDays  = 2  // Customer is reminded this many days before the visit
+	ContractRenewalWindow = 30 // Dashboard lists contracts ending within N days
+	ContractVisitTime     = "08:00"
+)
+
+var (
+	ErrInvalidContract  = errors.New("invalid maintenance contract")
+	ErrContractStatus   = errors.New("contract status does not allow this action")
+	ErrNoContactChannel = errors.New("customer has no contact channel")
+)
+
+// MaintenanceContract is a periodic service plan (vệ sinh định kỳ 3/6 tháng)
+type MaintenanceContract struct {
+	ID              string   `json:"id"`
+	CustomerID      string   `json:"customer_id"`
+	EquipmentIDs    []string `json:"equipment_ids"`
+	ServiceID       string   `json:"service_id"`
+	IntervalMonths  int      `json:"interval_months"`
+	Price           float64  `json:"price"`      // Contract value for the whole term
+	StartDate       string   `json:"start_date"` // YYYY-MM-DD, first visit
+	EndDate         string   `json:"end_date"`   // YYYY-MM-DD (inclusive)
+	NextServiceDate string   `json:"next_service_date"`
+	Status          string   `json:"status"`
+	LastBookingID   string   `json:"last_booking_id"`  // Latest generated booking
+	ReminderSentAt  string   `json:"reminder_sent_at"` // Reminder for LastBookingID
+	Notes           string   `json:"notes"`
+	Created         string   `json:"created"`
+
+	// Expanded for admin views
+	CustomerName  string `json:"customer_name"`
+	CustomerPhone string `json:"customer_phone"`
+	ServiceName   string `json:"service_name"`
+	VisitCount    int    `json:"visit_count"`
+}
+
+// AddMonths adds n months keeping the day, clamped to the end of the month
+// (31/01 + 1 month = 28/02 instead of 03/03).
+func AddMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// Validate checks the plan and sets the first visit on new contracts
+func (c *MaintenanceContract) Validate() error {
+	if c.CustomerID == "" || c.ServiceID == "" || c.Price < 0 {
+		return ErrInvalidContract
+	}
+	if c.IntervalMonths < 1 || c.IntervalMonths > 24 {
+		return ErrInvalidContract
+	}
+	start, err := time.Parse("2006-01-02", c.StartDate)
+	if err != nil {
+		return ErrInvalidContract
+	}
+	end, err := time.Parse("2006-01-02", c.EndDate)
+	if err != nil || end.Before(start) {
+		return ErrInvalidContract
+	}
+	if c.NextServiceDate == "" {
+		c.NextServiceDate = c.StartDate
+	}
+	if c.Status == "" {
+		c.Status = ContractActive
+	}
+	return nil
+}
+
+// DueForBooking reports whether the next visit needs a booking now
+func (c *MaintenanceContract) DueForBooking(now time.Time) bool {
+	if c.Status != ContractActive || c.NextServiceDate == "" || c.NextServiceDate > c.EndDate {
+		return false
+	}
+	horizon := now.AddDate(0, 0, ContractLeadDays).Format("2006-01-02")
+	return c.NextServiceDate <= horizon
+}
+
+// Advance moves NextServiceDate one interval forward (after a booking is generated)
+func (c *MaintenanceContract) Advance() {
+	next, err := time.Parse("2006-01-02", c.NextServiceDate)
+	if err != nil {
+		return
+	}
+	c.NextServiceDate = AddMonths(next, c.IntervalMonths).Format("2006-01-02")
+}
+
+// Ended reports whether the term is over on the given day
+func (c *MaintenanceContract) Ended(now time.Time) bool {
+	return c.EndDate < now.Format("2006-01-02")
+}
+
+// Renew extends the term by n months and reactivates the contract
+func (c *MaintenanceContract) Renew(months int) error {
+	if months < 1 || c.Status == ContractCancelled {
+		return ErrContractStatus
+	}
+	end, err := time.Parse("2006-01-02", c.EndDate)
+	if err != nil {
+		return ErrInvalidContract
+	}
+	c.EndDate = AddMonths(end, months).Format("2006-01-02")
+	c.Status = ContractActive
+	return nil
+}
+
+// DaysLeft is the number of days until EndDate (negative once expired)
+func (c *MaintenanceContract) DaysLeft(now time.Time) int {
+	end, err := time.Parse("2006-01-02", c.EndDate)
+	if err != nil {
+		return 0
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(today).Hours() / 24)
+}
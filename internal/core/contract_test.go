@@ -0,0 +1,78 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddMonthsClampsToMonthEnd(t *testing.T) {
+	cases := map[string]string{
+		"2026-01-31": "2026-02-28",
+		"2024-01-31": "2024-02-29",
+		"2026-03-15": "2026-04-15",
+		"2026-11-30": "2026-12-30",
+	}
+	for from, want := range cases {
+		d, _ := time.Parse("2006-01-02", from)
+		if got := AddMonths(d, 1).Format("2006-01-02"); got != want {
+			t.Errorf("AddMonths(%s, 1) = %s; want %s", from, got, want)
+		}
+	}
+	d, _ := time.Parse("2006-01-02", "2026-08-31")
+	if got := AddMonths(d, 6).Format("2006-01-02"); got != "2027-02-28" {
+		t.Errorf("AddMonths across year = %s", got)
+	}
+}
+
+func TestContractSchedule(t *testing.T) {
+	c := &MaintenanceContract{CustomerID: "c1", ServiceID: "s1", IntervalMonths: 6, StartDate: "2026-03-10", EndDate: "2027-03-09"}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if c.NextServiceDate != "2026-03-10" || c.Status != ContractActive {
+		t.Fatalf("Validate should schedule the first visit, got %+v", c)
+	}
+
+	now, _ := time.Parse("2006-01-02", "2026-02-20")
+	if c.DueForBooking(now) {
+		t.Error("Visit 18 days ahead is outside the lead window")
+	}
+	now = now.AddDate(0, 0, 12) // 2026-03-04
+	if !c.DueForBooking(now) {
+		t.Error("Visit 6 days ahead should be booked")
+	}
+
+	c.Advance()
+	if c.NextServiceDate != "2026-09-10" {
+		t.Errorf("Advance = %s", c.NextServiceDate)
+	}
+	c.Advance()
+	later, _ := time.Parse("2006-01-02", "2027-03-08")
+	if c.DueForBooking(later) {
+		t.Error("Visit after the end date must not be booked")
+	}
+
+	c.Status = ContractPaused
+	if c.DueForBooking(later) {
+		t.Error("Paused contract must not be booked")
+	}
+	if err := c.Renew(12); err != nil || c.EndDate != "2028-03-09" || c.Status != ContractActive {
+		t.Errorf("Renew = %v, %+v", err, c)
+	}
+	if !c.DueForBooking(later) {
+		t.Error("Renewed contract should book the pending visit")
+	}
+}
+
+func TestContractValidate(t *testing.T) {
+	for _, bad := range []*MaintenanceContract{
+		{ServiceID: "s1", IntervalMonths: 3, StartDate: "2026-01-01", EndDate: "2026-12-31"},
+		{CustomerID: "c1", ServiceID: "s1", IntervalMonths: 0, StartDate: "2026-01-01", EndDate: "2026-12-31"},
+		{CustomerID: "c1", ServiceID: "s1", IntervalMonths: 3, StartDate: "2026-12-31", EndDate: "2026-01-01"},
+		{CustomerID: "c1", ServiceID: "s1", IntervalMonths: 3, StartDate: "01/01/2026", EndDate: "2026-12-31"},
+	} {
+		if err := bad.Validate(); err != ErrInvalidContract {
+			t.Errorf("Validate(%+v) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Costing methods (settings.inventory_costing)
+const (
+	CostingAverage = "average" // Moving weighted average per location
+	CostingFIFO    = "fifo"    // Oldest receipt consumed first
+)
+
+var ErrInvalidValuationDate = errors.New("valuation date must be YYYY-MM-DD")
+
+// NormalizeCostingMethod falls back to the weighted average
+func NormalizeCostingMethod(method string) string {
+	if method == CostingFIFO {
+		return CostingFIFO
+	}
+	return CostingAverage
+}
+
+// CostLayer is a quantity that entered the stock at one unit cost and is
+// (partly) still at a location (stock_cost_layers). Every receipt opens one;
+// a transfer moves layers from one location to another.
+type CostLayer struct {
+	ID         string  `json:"id"`
+	ItemID     string  `json:"item_id"`
+	Location   string  `json:"location"` // StockMain or a technician ID
+	Quantity   float64 `json:"quantity"`
+	Remaining  float64 `json:"remaining"`
+	UnitCost   float64 `json:"unit_cost"`
+	ReceivedAt string  `json:"received_at"` // When it entered the company; transfers keep it
+}
+
+// LayerDraw is a quantity taken out of a layer. Layer is nil for the part
+// no layer covered (stock that went negative or predates the layers).
+type LayerDraw struct {
+	Layer      *CostLayer
+	Quantity   float64
+	UnitCost   float64
+	ReceivedAt string
+}
+
+// DrawLayers takes qty out of the layers of one item at one location and
+// returns where it came from. FIFO empties the oldest layers first; the
+// weighted average takes the same share of every layer, so the average cost
+// of what stays is unchanged. Layers are updated in place. What the layers
+// cannot cover is costed at the current average, or fallbackCost when the
+// location has nothing left.
+func DrawLayers(layers []*CostLayer, qty float64, method string, fallbackCost float64) []LayerDraw {
+	var draws []LayerDraw
+	available, value := 0.0, 0.0
+	for _, l := range layers {
+		available += l.Remaining
+		value += l.Remaining * l.UnitCost
+	}
+	take := math.Min(qty, available)
+
+	if take > StockEpsilon {
+		if NormalizeCostingMethod(method) == CostingFIFO {
+			ordered := append([]*CostLayer(nil), layers...)
+			sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].ReceivedAt < ordered[j].ReceivedAt })
+			left := take
+			for _, l := range ordered {
+				if left <= StockEpsilon {
+					break
+				}
+				part := math.Min(left, l.Remaining)
+				if part <= 0 {
+					continue
+				}
+				l.Remaining = settle(l.Remaining - part)
+				left -= part
+				draws = append(draws, LayerDraw{Layer: l, Quantity: part, UnitCost: l.UnitCost, ReceivedAt: l.ReceivedAt})
+			}
+		} else {
+			share := take / available
+			for _, l := range layers {
+				part := l.Remaining * share
+				if part <= 0 {
+					continue
+				}
+				l.Remaining = settle(l.Remaining - part)
+				draws = append(draws, LayerDraw{Layer: l, Quantity: part, UnitCost: l.UnitCost, ReceivedAt: l.ReceivedAt})
+			}
+		}
+	}
+
+	if uncovered := qty - take; uncovered > StockEpsilon {
+		cost := fallbackCost
+		if available > StockEpsilon {
+			cost = value / available
+		}
+		draws = append(draws, LayerDraw{Quantity: uncovered, UnitCost: cost})
+	}
+	return draws
+}
+
+// AverageCost is the average unit cost of what the layers hold, or
+// fallbackCost when they hold nothing
+func AverageCost(layers []*CostLayer, fallbackCost float64) float64 {
+	qty, value := 0.0, 0.0
+	for _, l := range layers {
+		qty += l.Remaining
+		value += l.Remaining * l.UnitCost
+	}
+	if qty <= StockEpsilon {
+		return fallbackCost
+	}
+	return value / qty
+}
+
+// settle rounds float leftovers of a layer to zero
+func settle(qty float64) float64 {
+	if qty < StockEpsilon {
+		return 0
+	}
+	return qty
+}
+
+// DrawCost is the total cost of the draws
+func DrawCost(draws []LayerDraw) float64 {
+	total := 0.0
+	for _, d := range draws {
+		total += d.Quantity * d.UnitCost
+	}
+	return total
+}
+
+// TransferLayers are the layers a transfer opens at the receiving location.
+// FIFO keeps each layer's cost and age; the weighted average arrives as one
+// layer at the average cost of what was taken.
+func TransferLayers(draws []LayerDraw, method string, now string) []CostLayer {
+	if NormalizeCostingMethod(method) == CostingAverage {
+		qty := 0.0
+		for _, d := range draws {
+			qty += d.Quantity
+		}
+		if qty <= StockEpsilon {
+			return nil
+		}
+		return []CostLayer{{Quantity: qty, Remaining: qty, UnitCost: DrawCost(draws) / qty, ReceivedAt: now}}
+	}
+
+	layers := make([]CostLayer, 0, len(draws))
+	for _, d := range draws {
+		received := d.ReceivedAt
+		if received == "" {
+			received = now
+		}
+		layers = append(layers, CostLayer{Quantity: d.Quantity, Remaining: d.Quantity, UnitCost: d.UnitCost, ReceivedAt: received})
+	}
+	return layers
+}
+
+// StockValue is the quantity and cost of an item at a location
+type StockValue struct {
+	ItemID       string  `json:"item_id"`
+	ItemName     string  `json:"item_name"`
+	Unit         string  `json:"unit"`
+	Location     string  `json:"location"`
+	LocationName string  `json:"location_name"`
+	Quantity     float64 `json:"quantity"`
+	Value        float64 `json:"value"`
+}
+
+// UnitCost is the average cost of the quantity on hand
+func (v StockValue) UnitCost() float64 {
+	if math.Abs(v.Quantity) <= StockEpsilon {
+		return 0
+	}
+	return v.Value / v.Quantity
+}
+
+// ValueStock replays the ledger into quantities and values per item and
+// location. Every movement carries the unit cost it moved at, so the value
+// of a location is what came in minus what went out, at cost. Pass only the
+// movements up to the valuation date. Sorted main warehouse first, then by
+// location and item.
+func ValueStock(movements []*StockMovement) []StockValue {
+	index := map[StockKey]int{}
+	var out []StockValue
+	for _, m := range movements {
+		for _, d := range m.Deltas() {
+			i, ok := index[d.Key]
+			if !ok {
+				i = len(out)
+				index[d.Key] = i
+				out = append(out, StockValue{ItemID: d.Key.ItemID, Location: d.Key.Location})
+			}
+			out[i].Quantity += d.Quantity
+			out[i].Value += d.Quantity * m.UnitCost
+		}
+	}
+
+	kept := out[:0]
+	for _, v := range out {
+		if math.Abs(v.Quantity) > StockEpsilon || math.Abs(v.Value) > 0.5 {
+			kept = append(kept, v)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if (kept[i].Location == StockMain) != (kept[j].Location == StockMain) {
+			return kept[i].Location == StockMain
+		}
+		if kept[i].Location != kept[j].Location {
+			return kept[i].Location < kept[j].Location
+		}
+		return kept[i].ItemID < kept[j].ItemID
+	})
+	return kept
+}
+
+// LocationValue totals the stock value of one warehouse
+type LocationValue struct {
+	Location string  `json:"location"`
+	Name     string  `json:"name"`
+	Items    int     `json:"items"`
+	Value    float64 `json:"value"`
+}
+
+// ValueByLocation totals ValueStock lines per warehouse, in the same order
+func ValueByLocation(lines []StockValue) []LocationValue {
+	var out []LocationValue
+	for _, l := range lines {
+		if n := len(out); n == 0 || out[n-1].Location != l.Location {
+			out = append(out, LocationValue{Location: l.Location, Name: l.LocationName})
+		}
+		out[len(out)-1].Items++
+		out[len(out)-1].Value += l.Value
+	}
+	return out
+}
+
+// ValuationCutoff is the end of the given day (YYYY-MM-DD) in Vietnam time:
+// the valuation includes every movement before it
+func ValuationCutoff(date string) (time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, fiscalZone)
+	if err != nil {
+		return time.Time{}, ErrInvalidValuationDate
+	}
+	return day.AddDate(0, 0, 1), nil
+}
+
+// ValuationDate is the day of now in Vietnam time, the default valuation date
+func ValuationDate(now time.Time) string {
+	return now.In(fiscalZone).Format("2006-01-02")
+}
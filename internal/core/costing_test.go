@@ -0,0 +1,115 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func pipeLayers() []*CostLayer {
+	return []*CostLayer{
+		{ID: "new", Remaining: 10, UnitCost: 50000, ReceivedAt: "2026-03-10 08:00:00.000Z"},
+		{ID: "old", Remaining: 10, UnitCost: 40000, ReceivedAt: "2026-03-01 08:00:00.000Z"},
+	}
+}
+
+func TestDrawLayersFIFO(t *testing.T) {
+	layers := pipeLayers()
+	draws := DrawLayers(layers, 12, CostingFIFO, 0)
+
+	if len(draws) != 2 || draws[0].Layer.ID != "old" || draws[0].Quantity != 10 || draws[1].Quantity != 2 {
+		t.Fatalf("draws = %+v", draws)
+	}
+	if got := DrawCost(draws); got != 10*40000+2*50000 {
+		t.Errorf("cost = %v", got)
+	}
+	if layers[1].Remaining != 0 || layers[0].Remaining != 8 {
+		t.Errorf("remaining = %v / %v", layers[1].Remaining, layers[0].Remaining)
+	}
+
+	moved := TransferLayers(draws, CostingFIFO, "now")
+	if len(moved) != 2 || moved[0].UnitCost != 40000 || moved[0].ReceivedAt != "2026-03-01 08:00:00.000Z" {
+		t.Errorf("transferred layers = %+v", moved)
+	}
+}
+
+func TestDrawLayersAverage(t *testing.T) {
+	layers := pipeLayers()
+	draws := DrawLayers(layers, 5, CostingAverage, 0)
+
+	if got := DrawCost(draws) / 5; !near(got, 45000) {
+		t.Errorf("unit cost = %v; want 45000", got)
+	}
+	if !near(layers[0].Remaining, 7.5) || !near(layers[1].Remaining, 7.5) {
+		t.Errorf("layers not drawn evenly: %v / %v", layers[0].Remaining, layers[1].Remaining)
+	}
+
+	moved := TransferLayers(draws, CostingAverage, "now")
+	if len(moved) != 1 || moved[0].Quantity != 5 || !near(moved[0].UnitCost, 45000) || moved[0].ReceivedAt != "now" {
+		t.Errorf("transferred layers = %+v", moved)
+	}
+}
+
+func TestDrawLayersUncovered(t *testing.T) {
+	layers := pipeLayers()
+	draws := DrawLayers(layers, 25, CostingFIFO, 60000)
+	last := draws[len(draws)-1]
+	if last.Layer != nil || last.Quantity != 5 || last.UnitCost != 45000 {
+		t.Errorf("uncovered draw = %+v; want 5 at the average 45000", last)
+	}
+
+	draws = DrawLayers(nil, 2, CostingAverage, 60000)
+	if len(draws) != 1 || draws[0].UnitCost != 60000 {
+		t.Errorf("draw from empty location = %+v; want fallback cost", draws)
+	}
+}
+
+func TestAverageCost(t *testing.T) {
+	if got := AverageCost(pipeLayers(), 60000); got != 45000 {
+		t.Errorf("average = %v; want 45000", got)
+	}
+	if got := AverageCost(nil, 60000); got != 60000 {
+		t.Errorf("empty location = %v; want fallback", got)
+	}
+}
+
+func TestValueStock(t *testing.T) {
+	values := ValueStock([]*StockMovement{
+		{Type: MoveImport, ToID: StockMain, ItemID: "pipe", Quantity: 10, UnitCost: 40000},
+		{Type: MoveImport, ToID: StockMain, ItemID: "pipe", Quantity: 10, UnitCost: 50000},
+		{Type: MoveMainToTech, FromID: StockMain, ToID: "t1", ItemID: "pipe", Quantity: 12, UnitCost: 41666.666666666664},
+		{Type: MoveTechToJob, FromID: "t1", ItemID: "pipe", Quantity: 12, UnitCost: 41666.666666666664},
+		{Type: MoveAdjustment, ToID: StockMain, ItemID: "gas", Quantity: 2, UnitCost: 1200000},
+		{Type: MoveAdjustment, ToID: StockMain, ItemID: "gas", Quantity: -0.5, UnitCost: 1200000},
+	})
+
+	if len(values) != 2 {
+		t.Fatalf("values = %+v; the emptied truck line should be dropped", values)
+	}
+	if v := values[0]; v.ItemID != "gas" || v.Quantity != 1.5 || v.Value != 1800000 {
+		t.Errorf("gas = %+v", v)
+	}
+	if v := values[1]; v.ItemID != "pipe" || v.Quantity != 8 || !near(v.Value, 900000-500000) {
+		t.Errorf("pipe = %+v", v)
+	}
+
+	byLocation := ValueByLocation(values)
+	if len(byLocation) != 1 || byLocation[0].Items != 2 || !near(byLocation[0].Value, 2200000) {
+		t.Errorf("by location = %+v", byLocation)
+	}
+}
+
+func TestValuationCutoff(t *testing.T) {
+	cutoff, err := ValuationCutoff("2026-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 31, 17, 0, 0, 0, time.UTC); !cutoff.Equal(want) {
+		t.Errorf("cutoff = %v; want %v", cutoff.UTC(), want)
+	}
+	if _, err := ValuationCutoff("31/03/2026"); err != ErrInvalidValuationDate {
+		t.Errorf("bad date: err = %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidPhone = errors.New("invalid vietnamese phone number")
+
+// CustomerPageSize is the admin customer list page size
+const CustomerPageSize = 30
+
+// CustomerAddress is one saved service location of a customer
+type CustomerAddress struct {
+	Label   string  `json:"label"` // Nhà, Công ty, ...
+	Address string  `json:"address"`
+	Lat     float64 `json:"lat"`
+	Long    float64 `json:"long"`
+}
+
+// Customer is identified by the normalized phone number (0xxxxxxxxx)
+type Customer struct {
+	ID        string            `json:"id"`
+	Phone     string            `json:"phone"`
+	Name      string            `json:"name"`
+	Email     string            `json:"email"`
+	Notes     string            `json:"notes"`
+	Addresses []CustomerAddress `json:"addresses"`
+	Created   string            `json:"created"`
+
+	// Aggregates (computed by the repository, not stored)
+	BookingCount    int     `json:"booking_count"`
+	CompletedCount  int     `json:"completed_count"`
+	LifetimeValue   float64 `json:"lifetime_value"` // Sum of paid invoices
+	LastServiceAt   string  `json:"last_service_at"`
+	LastServiceName string  `json:"last_service_name"`
+}
+
+// Mobile prefixes renamed in 2018 (11 digits -> 10 digits)
+var legacyMobilePrefixes = map[string]string{
+	"0162": "032", "0163": "033", "0164": "034", "0165": "035", "0166": "036",
+	"0167": "037", "0168": "038", "0169": "039",
+	"0120": "070", "0121": "079", "0122": "077", "0126": "076", "0128": "078",
+	"0123": "083", "0124": "084", "0125": "085", "0127": "081", "0129": "082",
+	"0186": "056", "0188": "058", "0199": "059",
+}
+
+// NormalizePhoneVN converts "+84 912.345.678", "84912345678", "0912-345-678"
+// to the canonical "0912345678" form used as the customer key.
+func NormalizePhoneVN(raw string) (string, error) {
+	var b strings.Builder
+	for _, r := range raw {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+
+	switch {
+	case strings.HasPrefix(digits, "0084"):
+		digits = "0" + digits[4:]
+	case strings.HasPrefix(digits, "84") && len(digits) >= 11:
+		digits = "0" + digits[2:]
+	case len(digits) == 9 && digits[0] != '0':
+		digits = "0" + digits // Leading zero dropped (e.g. pasted from Excel)
+	}
+
+	if len(digits) == 11 {
+		if prefix, ok := legacyMobilePrefixes[digits[:4]]; ok {
+			digits = prefix + digits[4:]
+		}
+	}
+
+	switch {
+	case len(digits) == 10 && digits[0] == '0' && strings.ContainsRune("35789", rune(digits[1])):
+		return digits, nil // Mobile
+	case len(digits) == 11 && strings.HasPrefix(digits, "02"):
+		return digits, nil // Landline (02x + 8 digits)
+	}
+	return "", ErrInvalidPhone
+}
+
+// AddAddress saves a new location; returns false when it is already known
+func (c *Customer) AddAddress(addr CustomerAddress) bool {
+	key := normalizeAddress(addr.Address)
+	if key == "" {
+		return false
+	}
+	for i, existing := range c.Addresses {
+		if normalizeAddress(existing.Address) == key {
+			// Same place: keep coordinates fresh
+			if addr.Lat != 0 && addr.Long != 0 {
+				c.Addresses[i].Lat = addr.Lat
+				c.Addresses[i].Long = addr.Long
+			}
+			return false
+		}
+	}
+	c.Addresses = append(c.Addresses, addr)
+	return true
+}
+
+func normalizeAddress(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,45 @@
+package core
+
+import "testing"
+
+func TestNormalizePhoneVN(t *testing.T) {
+	cases := map[string]string{
+		"0912345678":      "0912345678",
+		"0912 345 678":    "0912345678",
+		"091.234.5678":    "0912345678",
+		"+84 912 345 678": "0912345678",
+		"84912345678":     "0912345678",
+		"0084912345678":   "0912345678",
+		"912345678":       "0912345678",
+		"01662345678":     "0362345678",  // Pre-2018 11-digit mobile
+		"024 3826 1234":   "02438261234", // Hanoi landline
+	}
+	for raw, want := range cases {
+		got, err := NormalizePhoneVN(raw)
+		if err != nil || got != want {
+			t.Errorf("NormalizePhoneVN(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+
+	for _, raw := range []string{"", "12345", "0112345678", "09123456789"} {
+		if _, err := NormalizePhoneVN(raw); err != ErrInvalidPhone {
+			t.Errorf("NormalizePhoneVN(%q) should be invalid", raw)
+		}
+	}
+}
+
+func TestCustomerAddAddress(t *testing.T) {
+	c := &Customer{}
+	if !c.AddAddress(CustomerAddress{Address: "12 Lê Lợi, Q1"}) {
+		t.Fatal("First address should be added")
+	}
+	if c.AddAddress(CustomerAddress{Address: "  12 lê lợi,   Q1 ", Lat: 10.77, Long: 106.7}) {
+		t.Error("Same address with different spacing/case must not be duplicated")
+	}
+	if len(c.Addresses) != 1 || c.Addresses[0].Lat != 10.77 {
+		t.Errorf("Expected coordinates refreshed on the saved address, got %+v", c.Addresses)
+	}
+	if c.AddAddress(CustomerAddress{Address: ""}) {
+		t.Error("Empty address must be ignored")
+	}
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Dispatch scoring weights (max 100)
+const (
+	dispatchWeightSkill    = 30.0
+	dispatchWeightZone     = 20.0
+	dispatchWeightDistance = 25.0
+	dispatchWeightLoad     = 15.0
+	dispatchWeightRating   = 10.0
+
+	dispatchMaxDistanceKm = 20.0 // Beyond this the distance score is 0
+	dispatchMaxActiveJobs = 3    // Load score reaches 0 at this many open jobs
+)
+
+// DispatchInput is what a technician is scored on for a booking. The
+// dispatch service gathers it from the repositories, live positions and the
+// schedule.
+type DispatchInput struct {
+	Booking    *Booking
+	Service    *Service // nil when the booking has no known service
+	Technician *Technician
+	DistanceKm float64 // From the live position, -1 when unknown
+	ActiveJobs int     // Open jobs other than this booking
+	Conflict   error   // Schedule conflict at the booking time, nil when free
+}
+
+// ScoreTechnician scores a technician for a booking. A missing required skill
+// or a schedule conflict makes the technician ineligible.
+func ScoreTechnician(in DispatchInput) *DispatchCandidate {
+	tech, svc := in.Technician, in.Service
+	c := &DispatchCandidate{
+		TechnicianID:   tech.ID,
+		TechnicianName: tech.Name,
+		Eligible:       true,
+		DistanceKm:     -1,
+		ActiveJobs:     in.ActiveJobs,
+	}
+
+	// 1. Skill (hard constraint when the service requires one)
+	switch {
+	case svc == nil || svc.RequiredSkill == "":
+		c.Score += dispatchWeightSkill / 2
+		c.Reasons = append(c.Reasons, "Dịch vụ không yêu cầu kỹ năng riêng")
+	case hasSkill(tech.Skills, svc.RequiredSkill):
+		c.Score += dispatchWeightSkill
+		c.Reasons = append(c.Reasons, "Có kỹ năng "+svc.RequiredSkill)
+	default:
+		c.Eligible = false
+		c.Reasons = append(c.Reasons, "Thiếu kỹ năng "+svc.RequiredSkill)
+	}
+
+	// 2. Zone
+	zoneScore, zoneReason := ZoneMatch(tech.ServiceZones, in.Booking.Address+", "+in.Booking.AddressDetails)
+	c.Score += zoneScore
+	c.Reasons = append(c.Reasons, zoneReason)
+
+	// 3. Distance from live position
+	if in.DistanceKm >= 0 {
+		c.DistanceKm = in.DistanceKm
+		ratio := math.Max(0, 1-c.DistanceKm/dispatchMaxDistanceKm)
+		c.Score += dispatchWeightDistance * ratio
+		c.Reasons = append(c.Reasons, fmt.Sprintf("Cách khách %.1f km", c.DistanceKm))
+	} else {
+		c.Reasons = append(c.Reasons, "Chưa có vị trí trực tuyến")
+	}
+
+	// 4. Current load
+	load := math.Max(0, 1-float64(c.ActiveJobs)/dispatchMaxActiveJobs)
+	c.Score += dispatchWeightLoad * load
+	c.Reasons = append(c.Reasons, fmt.Sprintf("Đang có %d việc", c.ActiveJobs))
+
+	// 5. Rating (unrated techs get a neutral score), small bonus for level
+	rating := tech.Rating
+	if rating <= 0 {
+		rating = 2.5
+	}
+	c.Score += dispatchWeightRating * math.Min(rating, 5) / 5
+	switch strings.ToLower(tech.Level) {
+	case "master":
+		c.Score += 3
+	case "senior":
+		c.Score += 1.5
+	}
+	if tech.Rating > 0 {
+		c.Reasons = append(c.Reasons, fmt.Sprintf("Đánh giá %.1f★", tech.Rating))
+	}
+
+	// 6. Schedule (hard constraint)
+	if in.Conflict != nil {
+		c.Eligible = false
+		c.Reasons = append(c.Reasons, "Trùng lịch: "+in.Conflict.Error())
+	}
+
+	c.Score = math.Round(c.Score*10) / 10
+	return c
+}
+
+// RankCandidates puts eligible candidates first, by score; on a tie the one
+// with fewer open jobs goes first
+func RankCandidates(candidates []*DispatchCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.ActiveJobs < b.ActiveJobs
+	})
+}
+
+// ZoneMatch scores how well a tech's zones ("Xã, Huyện, Tỉnh") cover an address
+func ZoneMatch(zones []string, address string) (float64, string) {
+	if len(zones) == 0 {
+		return dispatchWeightZone / 2, "Không giới hạn khu vực"
+	}
+
+	address = strings.ToLower(address)
+	best, reason := 0.0, "Ngoài khu vực phụ trách"
+	for _, zone := range zones {
+		parts := strings.Split(zone, ", ")
+		levels := []struct {
+			ratio float64
+			label string
+		}{{1, "Cùng xã/phường"}, {0.75, "Cùng quận/huyện"}, {0.3, "Cùng tỉnh/thành"}}
+
+		for i, part := range parts {
+			if i >= len(levels) || strings.TrimSpace(part) == "" {
+				break
+			}
+			if strings.Contains(address, strings.ToLower(strings.TrimSpace(part))) {
+				if score := dispatchWeightZone * levels[i].ratio; score > best {
+					best, reason = score, levels[i].label
+				}
+				break
+			}
+		}
+	}
+	return best, reason
+}
+
+func hasSkill(skills []string, skill string) bool {
+	for _, s := range skills {
+		if s == skill {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestZoneMatch(t *testing.T) {
+	zones := []string{"Phường Bến Nghé, Quận 1, TP Hồ Chí Minh"}
+	cases := []struct {
+		name    string
+		zones   []string
+		address string
+		score   float64
+		reason  string
+	}{
+		{"no zones", nil, "Hà Nội", 10, "Không giới hạn khu vực"},
+		{"same ward", zones, "12 Lê Lợi, phường bến nghé, Quận 1, TP Hồ Chí Minh", 20, "Cùng xã/phường"},
+		{"same district", zones, "5 Đinh Tiên Hoàng, Phường Đa Kao, Quận 1, TP Hồ Chí Minh", 15, "Cùng quận/huyện"},
+		{"same province", zones, "Phường Võ Thị Sáu, Quận 3, TP Hồ Chí Minh", 6, "Cùng tỉnh/thành"},
+		{"miss", zones, "Phường Hàng Bạc, Quận Hoàn Kiếm, Hà Nội", 0, "Ngoài khu vực phụ trách"},
+		{"best zone wins", append([]string{"Phường 1, Quận 3, TP Hồ Chí Minh"}, zones...), "Phường Bến Nghé, Quận 1, TP Hồ Chí Minh", 20, "Cùng xã/phường"},
+	}
+	for _, c := range cases {
+		score, reason := ZoneMatch(c.zones, c.address)
+		if score != c.score || reason != c.reason {
+			t.Errorf("%s: ZoneMatch = %v, %q; want %v, %q", c.name, score, reason, c.score, c.reason)
+		}
+	}
+}
+
+func TestScoreTechnician(t *testing.T) {
+	booking := &Booking{ID: "b1", Address: "Quận 1", AddressDetails: "TP Hồ Chí Minh"}
+	inverter := &Service{ID: "s1", RequiredSkill: "inverter"}
+	cases := []struct {
+		name     string
+		in       DispatchInput
+		eligible bool
+		score    float64
+	}{
+		// Skill 15, zone 10, no position 0, no jobs 15, unrated 5
+		{"no skill required", DispatchInput{Technician: &Technician{ID: "t1"}, DistanceKm: -1}, true, 45},
+		{"has skill", DispatchInput{Service: inverter, Technician: &Technician{ID: "t1", Skills: []string{"inverter"}}, DistanceKm: -1}, true, 60},
+		{"missing skill", DispatchInput{Service: inverter, Technician: &Technician{ID: "t1", Skills: []string{"cleaning"}}, DistanceKm: -1}, false, 30},
+		{"busy", DispatchInput{Technician: &Technician{ID: "t1"}, DistanceKm: -1, Conflict: errors.New("busy")}, false, 45},
+		{"full load", DispatchInput{Technician: &Technician{ID: "t1"}, DistanceKm: -1, ActiveJobs: 3}, true, 30},
+		{"close and rated", DispatchInput{Technician: &Technician{ID: "t1", Rating: 5, Level: "Senior"}, DistanceKm: 10}, true, 64},
+	}
+	for _, c := range cases {
+		c.in.Booking = booking
+		got := ScoreTechnician(c.in)
+		if got.Eligible != c.eligible || got.Score != c.score {
+			t.Errorf("%s: eligible %v, score %v; want %v, %v (%v)", c.name, got.Eligible, got.Score, c.eligible, c.score, got.Reasons)
+		}
+	}
+}
+
+func TestRankCandidates(t *testing.T) {
+	candidates := []*DispatchCandidate{
+		{TechnicianID: "busy", Score: 90},
+		{TechnicianID: "loaded", Score: 50, Eligible: true, ActiveJobs: 2},
+		{TechnicianID: "free", Score: 50, Eligible: true},
+		{TechnicianID: "best", Score: 70, Eligible: true, ActiveJobs: 1},
+	}
+	RankCandidates(candidates)
+	want := []string{"best", "free", "loaded", "busy"}
+	for i, c := range candidates {
+		if c.TechnicianID != want[i] {
+			t.Fatalf("rank %d = %s; want %v", i, c.TechnicianID, want)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// E-invoice statuses (invoices.einvoice_status)
+const (
+	EInvoiceNone      = ""          // Not sent to the provider yet
+	EInvoiceIssued    = "issued"    // Signed and registered with the tax authority
+	EInvoiceCancelled = "cancelled" // Cancelled, a new e-invoice may be issued
+)
+
+// EInvoiceTemplate is the invoice form symbol (KHMSHDon): 1 = VAT invoice
+const EInvoiceTemplate = "1"
+
+// Line natures (TChat) of Circular 78
+const (
+	EInvoiceLineGoods    = 1 // Hàng hóa, dịch vụ
+	EInvoiceLineDiscount = 3 // Chiết khấu thương mại
+)
+
+var (
+	ErrEInvoiceNotReady  = errors.New("invoice must be issued before its e-invoice")
+	ErrEInvoiceIssued    = errors.New("e-invoice already issued")
+	ErrEInvoiceNotIssued = errors.New("no e-invoice issued for this invoice")
+	ErrEInvoiceReason    = errors.New("a reason is required to cancel or replace an e-invoice")
+	ErrEInvoiceSeller    = errors.New("seller name and tax code are required")
+	ErrEInvoiceBuyer     = errors.New("buyer name is required")
+	ErrInvalidTaxCode    = errors.New("tax code must be 10 digits, 10-3 digits or 12 digits")
+)
+
+var (
+	taxCodePattern      = regexp.MustCompile(`^(\d{10}(-\d{3})?|\d{12})$`)
+	seriesSuffixPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// ValidTaxCode checks a Vietnamese tax code (MST): 10 digits, a branch
+// code "0123456789-001", or a 12-digit personal ID
+func ValidTaxCode(code string) bool {
+	return taxCodePattern.MatchString(code)
+}
+
+// EInvoiceSeries builds the invoice series symbol (KHHDon): C = coded by the
+// tax authority, two digits of the year, T = registered by the business, and
+// a two-letter suffix chosen by the business (AA by default)
+func EInvoiceSeries(suffix string, at time.Time) string {
+	suffix = strings.ToUpper(strings.TrimSpace(suffix))
+	if !seriesSuffixPattern.MatchString(suffix) {
+		suffix = "AA"
+	}
+	return fmt.Sprintf("C%02dT%s", at.In(fiscalZone).Year()%100, suffix)
+}
+
+// EInvoiceParty is the seller or buyer printed on the e-invoice
+type EInvoiceParty struct {
+	Name        string `json:"name"`     // Company (or person) name
+	TaxCode     string `json:"tax_code"` // Optional for private buyers
+	Address     string `json:"address"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	BankAccount string `json:"bank_account,omitempty"`
+	BankName    string `json:"bank_name,omitempty"`
+	Contact     string `json:"contact,omitempty"` // Person buying for a company (HVTNMHang)
+}
+
+// Validate checks a buyer: a name, and a well-formed tax code if any
+func (p *EInvoiceParty) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return ErrEInvoiceBuyer
+	}
+	if p.TaxCode != "" && !ValidTaxCode(p.TaxCode) {
+		return ErrInvalidTaxCode
+	}
+	return nil
+}
+
+// EInvoiceLine is one row of the e-invoice (HHDVu)
+type EInvoiceLine struct {
+	No        int     `json:"no"`
+	Nature    int     `json:"nature"`
+	Name      string  `json:"name"`
+	Unit      string  `json:"unit"`
+	Quantity  float64 `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Discount  float64 `json:"discount"`
+	Amount    float64 `json:"amount"` // Before tax, after discount
+	VATCode   string  `json:"vat_code"`
+	Tax       float64 `json:"tax"`
+}
+
+// EInvoiceVATGroup totals the lines of one VAT rate (LTSuat)
+type EInvoiceVATGroup struct {
+	VATCode string
+	Amount  float64
+	Tax     float64
+}
+
+// EInvoiceRef identifies an e-invoice already issued by the provider
+type EInvoiceRef struct {
+	Template      string    `json:"template"`
+	Series        string    `json:"series"`
+	Number        string    `json:"number"`
+	IssuedAt      time.Time `json:"issued_at"`
+	LookupCode    string    `json:"lookup_code"`    // Given to the customer to look the invoice up
+	TransactionID string    `json:"transaction_id"` // Provider side ID
+}
+
+// EInvoice is the provider-agnostic e-invoice built from one of our invoices
+type EInvoice struct {
+	InvoiceID     string
+	InvoiceCode   string // Our own number (HD-2026-000012)
+	Template      string
+	Series        string
+	Number        string // Assigned by the provider
+	IssuedAt      time.Time
+	Currency      string
+	PaymentMethod string // HTTToan: TM, CK or TM/CK
+	Seller        EInvoiceParty
+	Buyer         EInvoiceParty
+	Lines         []EInvoiceLine
+	Subtotal      float64 // Before discount and tax
+	Discount      float64
+	Amount        float64 // Taxable: Subtotal - Discount
+	Tax           float64
+	Total         float64
+	TotalInWords  string
+	Replaces      *EInvoiceRef // Set on a replacement invoice
+	Reason        string       // Why the previous e-invoice is replaced
+}
+
+// VATGroups totals the lines per VAT rate, in VATCodes order
+func (e *EInvoice) VATGroups() []EInvoiceVATGroup {
+	byCode := map[string]*EInvoiceVATGroup{}
+	for _, l := range e.Lines {
+		g, ok := byCode[l.VATCode]
+		if !ok {
+			g = &EInvoiceVATGroup{VATCode: l.VATCode}
+			byCode[l.VATCode] = g
+		}
+		g.Amount += l.Amount
+		g.Tax += l.Tax
+	}
+	groups := make([]EInvoiceVATGroup, 0, len(byCode))
+	for _, code := range VATCodes {
+		if g, ok := byCode[code]; ok {
+			groups = append(groups, *g)
+		}
+	}
+	return groups
+}
+
+// EInvoiceSource is what an invoice contributes to its e-invoice
+type EInvoiceSource struct {
+	InvoiceID     string
+	InvoiceCode   string
+	InvoiceStatus string
+	PaymentMethod string
+	Lines         []InvoiceLine // Priced lines (invoice_items)
+	Customer      EInvoiceParty // From the booking / customer record
+	State         EInvoiceState
+}
+
+// EInvoiceState is kept on the invoice (invoices.einvoice)
+type EInvoiceState struct {
+	Provider    string        `json:"provider"`
+	Status      string        `json:"status"`
+	Current     *EInvoiceRef  `json:"current,omitempty"`
+	Buyer       EInvoiceParty `json:"buyer"`
+	Reason      string        `json:"reason,omitempty"` // Last cancel / replace reason
+	UpdatedBy   string        `json:"updated_by,omitempty"`
+	Superseded  []EInvoiceRef `json:"superseded,omitempty"` // Cancelled or replaced e-invoices, oldest first
+	CancelledAt *time.Time    `json:"cancelled_at,omitempty"`
+}
+
+// EInvoiceReceipt is the provider's answer to a submission
+type EInvoiceReceipt struct {
+	Number        string
+	LookupCode    string
+	TransactionID string
+	IssuedAt      time.Time
+}
+
+// EInvoicePaymentMethod maps our payment method to HTTToan
+func EInvoicePaymentMethod(method string) string {
+	switch method {
+	case MethodCash:
+		return "TM"
+	case MethodTransfer, MethodCard:
+		return "CK"
+	default:
+		return "TM/CK"
+	}
+}
+
+// BuildEInvoice turns an issued invoice into an e-invoice for buyer, signed
+// as seller under series. The number is left to the provider.
+func BuildEInvoice(src *EInvoiceSource, seller, buyer EInvoiceParty, series string, at time.Time) (*EInvoice, error) {
+	if src.InvoiceCode == "" || src.InvoiceStatus == InvoiceCancelled {
+		return nil, ErrEInvoiceNotReady
+	}
+	if strings.TrimSpace(seller.Name) == "" || !ValidTaxCode(seller.TaxCode) {
+		return nil, ErrEInvoiceSeller
+	}
+	if err := buyer.Validate(); err != nil {
+		return nil, err
+	}
+
+	e := &EInvoice{
+		InvoiceID:     src.InvoiceID,
+		InvoiceCode:   src.InvoiceCode,
+		Template:      EInvoiceTemplate,
+		Series:        series,
+		IssuedAt:      at,
+		Currency:      "VND",
+		PaymentMethod: EInvoicePaymentMethod(src.PaymentMethod),
+		Seller:        seller,
+		Buyer:         buyer,
+	}
+	for i, l := range src.Lines {
+		line := EInvoiceLine{
+			No:        i + 1,
+			Nature:    EInvoiceLineGoods,
+			Name:      l.Name,
+			Unit:      l.Unit,
+			Quantity:  l.Quantity,
+			UnitPrice: l.UnitPrice,
+			Discount:  l.Discount,
+			Amount:    l.Taxable(),
+			VATCode:   ResolveVAT(l.VATCode, ""),
+			Tax:       l.Tax,
+		}
+		e.Lines = append(e.Lines, line)
+		e.Subtotal += l.Subtotal
+		e.Discount += l.Discount
+		e.Amount += line.Amount
+		e.Tax += l.Tax
+	}
+	e.Total = e.Amount + e.Tax
+	e.TotalInWords = VNDInWords(e.Total)
+	return e, nil
+}
+
+// LocalIssuedAt is the issue date in Vietnam time (NLap)
+func (e *EInvoice) LocalIssuedAt() time.Time {
+	return e.IssuedAt.In(fiscalZone)
+}
+
+// LocalIssuedAt is the issue date in Vietnam time
+func (r EInvoiceRef) LocalIssuedAt() time.Time {
+	return r.IssuedAt.In(fiscalZone)
+}
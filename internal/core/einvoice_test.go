@@ -0,0 +1,98 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func einvoiceSource() *EInvoiceSource {
+	lines := []InvoiceLine{
+		{Kind: InvoiceLineService, Name: "Vệ sinh máy lạnh", Unit: "bộ", Quantity: 2, UnitPrice: 250000, VATCode: "8"},
+		{Kind: InvoiceLinePart, Name: "Gas R32", Unit: "kg", Quantity: 1.5, UnitPrice: 200000, VATCode: "10"},
+		{Kind: InvoiceLineLabor, Name: "Giờ công", Unit: "giờ", Quantity: 1, UnitPrice: 100000, VATCode: VATExempt},
+	}
+	_, _ = PriceInvoice(lines, InvoiceAdjustments{
+		Discount: &Discount{Type: DiscountFixed, Value: 90000, Reason: "Khách quen", ApprovedBy: "admin"},
+	}, DefaultVATCode)
+	return &EInvoiceSource{
+		InvoiceID:     "inv1",
+		InvoiceCode:   "HD-2026-000012",
+		InvoiceStatus: InvoicePaid,
+		PaymentMethod: MethodTransfer,
+		Lines:         lines,
+	}
+}
+
+func TestBuildEInvoice(t *testing.T) {
+	seller := EInvoiceParty{Name: "Công ty TNHH Điện Lạnh", TaxCode: "0312345678"}
+	buyer := EInvoiceParty{Name: "Nguyễn Văn A"}
+	at := time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)
+
+	e, err := BuildEInvoice(einvoiceSource(), seller, buyer, EInvoiceSeries("", at), at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Series != "C26TAA" || e.Template != "1" || e.PaymentMethod != "CK" {
+		t.Errorf("series/template/method = %s/%s/%s", e.Series, e.Template, e.PaymentMethod)
+	}
+	if e.Subtotal != 900000 || e.Discount != 90000 || e.Amount != 810000 {
+		t.Errorf("subtotal/discount/amount = %v/%v/%v", e.Subtotal, e.Discount, e.Amount)
+	}
+	if e.Total != e.Amount+e.Tax {
+		t.Errorf("total %v != amount %v + tax %v", e.Total, e.Amount, e.Tax)
+	}
+	if e.TotalInWords != VNDInWords(e.Total) {
+		t.Errorf("total in words = %q", e.TotalInWords)
+	}
+
+	groups := e.VATGroups()
+	if len(groups) != 3 || groups[0].VATCode != "10" || groups[2].VATCode != VATExempt {
+		t.Fatalf("VAT groups = %+v", groups)
+	}
+	sum := 0.0
+	for _, g := range groups {
+		sum += g.Amount
+	}
+	if sum != e.Amount {
+		t.Errorf("VAT groups sum to %v; amount is %v", sum, e.Amount)
+	}
+}
+
+func TestBuildEInvoiceRejects(t *testing.T) {
+	seller := EInvoiceParty{Name: "Công ty", TaxCode: "0312345678"}
+	buyer := EInvoiceParty{Name: "Khách"}
+	at := time.Now()
+
+	unissued := einvoiceSource()
+	unissued.InvoiceCode = ""
+	if _, err := BuildEInvoice(unissued, seller, buyer, "C26TAA", at); err != ErrEInvoiceNotReady {
+		t.Errorf("unissued invoice: err = %v", err)
+	}
+	if _, err := BuildEInvoice(einvoiceSource(), EInvoiceParty{Name: "Công ty"}, buyer, "C26TAA", at); err != ErrEInvoiceSeller {
+		t.Errorf("seller without tax code: err = %v", err)
+	}
+	if _, err := BuildEInvoice(einvoiceSource(), seller, EInvoiceParty{Name: "Cty B", TaxCode: "123"}, "C26TAA", at); err != ErrInvalidTaxCode {
+		t.Errorf("bad buyer tax code: err = %v", err)
+	}
+}
+
+func TestEInvoiceSeriesAndTaxCode(t *testing.T) {
+	at := time.Date(2026, 12, 31, 18, 0, 0, 0, time.UTC) // Already 2027 in Vietnam
+	if got := EInvoiceSeries("ab", at); got != "C27TAB" {
+		t.Errorf("series = %s; want C27TAB", got)
+	}
+	if got := EInvoiceSeries("../", at); got != "C27TAA" {
+		t.Errorf("invalid suffix kept: %s", got)
+	}
+	for code, want := range map[string]bool{
+		"0312345678":     true,
+		"0312345678-001": true,
+		"079123456789":   true,
+		"031234567":      false,
+		"0312345678-1":   false,
+	} {
+		if ValidTaxCode(code) != want {
+			t.Errorf("ValidTaxCode(%q) = %v", code, !want)
+		}
+	}
+}
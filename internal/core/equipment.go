@@ -0,0 +1,99 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	ErrInvalidEquipment = errors.New("invalid equipment")
+	// Legacy booking with an invalid phone number is not linked to a customer
+	ErrNoCustomer = errors.New("booking has no customer")
+)
+
+// BTUPerHP is the usual VN market conversion (1 HP ~ 9000 BTU/h)
+const BTUPerHP = 9000
+
+// Refrigerants offered in the equipment form
+var Refrigerants = []string{"R32", "R410A", "R22", "R290", "R134A"}
+
+// Equipment is an installed unit (AC, fridge...) at a customer's place
+type Equipment struct {
+	ID          string  `json:"id"`
+	CustomerID  string  `json:"customer_id"`
+	UnitType    string  `json:"unit_type"` // Same values as booking device_type (ac_split, ac_cassette...)
+	Brand       string  `json:"brand"`
+	Model       string  `json:"model"`
+	Serial      string  `json:"serial"`
+	CapacityBTU int     `json:"capacity_btu"`
+	CapacityHP  float64 `json:"capacity_hp"`
+	Refrigerant string  `json:"refrigerant"`
+	InstallDate string  `json:"install_date"` // YYYY-MM-DD
+	Location    string  `json:"location"`     // Phòng khách, Phòng ngủ 1...
+	Notes       string  `json:"notes"`
+	Created     string  `json:"created"`
+
+	// Aggregates (computed by the repository)
+	ServiceCount  int    `json:"service_count"`
+	LastServiceAt string `json:"last_service_at"`
+}
+
+// EquipmentServiceRecord is one job report that touched the unit
+type EquipmentServiceRecord struct {
+	ReportID  string `json:"report_id" db:"report_id"`
+	BookingID string `json:"booking_id" db:"booking_id"`
+	Date      string `json:"date" db:"date"`
+	JobStatus string `json:"job_status" db:"job_status"`
+	TechName  string `json:"tech_name" db:"tech_name"`
+	Issue     string `json:"issue" db:"issue"`
+	Notes     string `json:"notes" db:"notes"`
+}
+
+// Normalize cleans user input and fills the missing capacity unit
+func (e *Equipment) Normalize() error {
+	e.UnitType = strings.TrimSpace(e.UnitType)
+	e.Brand = strings.TrimSpace(e.Brand)
+	e.Model = strings.TrimSpace(e.Model)
+	e.Serial = strings.ToUpper(strings.TrimSpace(e.Serial))
+	e.Location = strings.TrimSpace(e.Location)
+	e.Refrigerant = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(e.Refrigerant), "-", ""))
+
+	if e.CustomerID == "" || (e.UnitType == "" && e.Brand == "" && e.Model == "") {
+		return ErrInvalidEquipment
+	}
+	if e.CapacityBTU < 0 || e.CapacityHP < 0 {
+		return ErrInvalidEquipment
+	}
+
+	switch {
+	case e.CapacityHP == 0 && e.CapacityBTU > 0:
+		// Round to the nearest half HP (9000 -> 1, 12000 -> 1.5, 18000 -> 2)
+		e.CapacityHP = math.Round(float64(e.CapacityBTU)/BTUPerHP*2) / 2
+	case e.CapacityBTU == 0 && e.CapacityHP > 0:
+		e.CapacityBTU = int(e.CapacityHP * BTUPerHP)
+	}
+	return nil
+}
+
+// Label is the short name shown to techs: "Daikin FTKC35 1.5HP - Phòng khách"
+func (e *Equipment) Label() string {
+	parts := []string{}
+	for _, p := range []string{e.Brand, e.Model} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, e.UnitType)
+	}
+	if e.CapacityHP > 0 {
+		parts = append(parts, fmt.Sprintf("%gHP", e.CapacityHP))
+	}
+	label := strings.Join(parts, " ")
+	if e.Location != "" {
+		label += " - " + e.Location
+	}
+	return label
+}
@@ -0,0 +1,38 @@
+package core
+
+import "testing"
+
+func TestEquipmentNormalizeCapacity(t *testing.T) {
+	eq := &Equipment{CustomerID: "c1", Brand: " Daikin ", CapacityBTU: 12000, Serial: " e123x ", Refrigerant: "r-410a"}
+	if err := eq.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if eq.CapacityHP != 1.5 || eq.Brand != "Daikin" || eq.Serial != "E123X" || eq.Refrigerant != "R410A" {
+		t.Errorf("Unexpected normalized unit %+v", eq)
+	}
+
+	eq = &Equipment{CustomerID: "c1", UnitType: "ac_split", CapacityHP: 2}
+	if err := eq.Normalize(); err != nil || eq.CapacityBTU != 18000 {
+		t.Errorf("Expected 18000 BTU from 2HP, got %d (%v)", eq.CapacityBTU, err)
+	}
+
+	for _, bad := range []*Equipment{
+		{Brand: "Daikin"}, // No customer
+		{CustomerID: "c1", Location: "Phòng khách"},      // Nothing identifies the unit
+		{CustomerID: "c1", Brand: "LG", CapacityBTU: -1}, // Negative capacity
+	} {
+		if err := bad.Normalize(); err != ErrInvalidEquipment {
+			t.Errorf("Normalize(%+v) should be invalid", bad)
+		}
+	}
+}
+
+func TestEquipmentLabel(t *testing.T) {
+	eq := &Equipment{Brand: "Daikin", Model: "FTKC35", CapacityHP: 1.5, Location: "Phòng khách"}
+	if got := eq.Label(); got != "Daikin FTKC35 1.5HP - Phòng khách" {
+		t.Errorf("Label() = %q", got)
+	}
+	if got := (&Equipment{UnitType: "ac_split"}).Label(); got != "ac_split" {
+		t.Errorf("Label() = %q", got)
+	}
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InvoicePrefix starts every invoice number: HD-<year>-<6 digit sequence>
+const InvoicePrefix = "HD"
+
+// fiscalZone is Vietnam time; an invoice finalized on Dec 31 at 23:30 local
+// time belongs to that year even though it is already Jan 1 in UTC.
+var fiscalZone = time.FixedZone("ICT", 7*60*60)
+
+// FiscalYear is the accounting year an invoice finalized at t belongs to
+func FiscalYear(t time.Time) int {
+	return t.In(fiscalZone).Year()
+}
+
+// FormatInvoiceCode builds the printed invoice number, e.g. HD-2026-000123
+func FormatInvoiceCode(year, seq int) string {
+	return fmt.Sprintf("%s-%d-%06d", InvoicePrefix, year, seq)
+}
+
+// ParseInvoiceCode is the reverse of FormatInvoiceCode
+func ParseInvoiceCode(code string) (year, seq int, ok bool) {
+	parts := strings.Split(code, "-")
+	if len(parts) != 3 || parts[0] != InvoicePrefix {
+		return 0, 0, false
+	}
+	year, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err = strconv.Atoi(parts[2])
+	if err != nil || seq <= 0 {
+		return 0, 0, false
+	}
+	return year, seq, true
+}
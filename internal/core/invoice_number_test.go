@@ -0,0 +1,32 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFiscalYearUsesVietnamTime(t *testing.T) {
+	newYearsEve := time.Date(2026, 12, 31, 16, 30, 0, 0, time.UTC) // 23:30 in Hanoi
+	if got := FiscalYear(newYearsEve); got != 2026 {
+		t.Errorf("FiscalYear = %d; want 2026", got)
+	}
+	if got := FiscalYear(newYearsEve.Add(time.Hour)); got != 2027 {
+		t.Errorf("FiscalYear after local midnight = %d; want 2027", got)
+	}
+}
+
+func TestInvoiceCodeRoundTrip(t *testing.T) {
+	code := FormatInvoiceCode(2026, 123)
+	if code != "HD-2026-000123" {
+		t.Fatalf("FormatInvoiceCode = %q", code)
+	}
+	year, seq, ok := ParseInvoiceCode(code)
+	if !ok || year != 2026 || seq != 123 {
+		t.Errorf("ParseInvoiceCode(%q) = %d, %d, %v", code, year, seq, ok)
+	}
+	for _, bad := range []string{"", "HD-2026", "INV-2026-000001", "HD-x-000001", "HD-2026-000000"} {
+		if _, _, ok := ParseInvoiceCode(bad); ok {
+			t.Errorf("ParseInvoiceCode(%q) must fail", bad)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// VAT codes (services.vat_rate, inventory_items.vat_rate, settings.default_vat).
+// An empty code on a service/item means "use the brand default".
+const (
+	VATExempt      = "exempt" // Không chịu thuế (KCT)
+	DefaultVATCode = "10"
+)
+
+// VATCodes lists the selectable rates, highest first
+var VATCodes = []string{"10", "8", "5", "0", VATExempt}
+
+// Discount kinds
+const (
+	DiscountPercent = "percent"
+	DiscountFixed   = "fixed"
+)
+
+// Invoice line kinds (invoice_items.kind)
+const (
+	InvoiceLineService = "service"
+	InvoiceLinePart    = "part"
+	InvoiceLineLabor   = "labor" // Additional hours
+)
+
+// Labor line sources: the tech's extra hours are replaced on each completion submit
+const (
+	LaborFromTech  = "tech"
+	LaborFromAdmin = "admin"
+)
+
+var (
+	ErrInvalidDiscount    = errors.New("invalid discount")
+	ErrDiscountApproval   = errors.New("discount reason and approver required")
+	ErrInvalidLaborLine   = errors.New("invalid labor line")
+	ErrInvalidInvoiceLine = errors.New("invalid invoice line")
+	ErrInvoiceLocked      = errors.New("invoice already paid or e-invoiced")
+)
+
+// InvoiceEditable reports whether an invoice may still be re-priced: open for
+// payment and without an e-invoice in force. An issued e-invoice is cancelled
+// or replaced first (see EInvoiceService).
+func InvoiceEditable(status, einvoiceStatus string) bool {
+	return InvoiceOpen(status) && einvoiceStatus != EInvoiceIssued
+}
+
+// ResolveVAT returns a valid VAT code: the line's own, else the fallback, else DefaultVATCode
+func ResolveVAT(code, fallback string) string {
+	for _, c := range []string{code, fallback} {
+		for _, valid := range VATCodes {
+			if c == valid {
+				return c
+			}
+		}
+	}
+	return DefaultVATCode
+}
+
+// VATPercent is the tax rate of a code (exempt and unknown codes are 0)
+func VATPercent(code string) float64 {
+	if code == VATExempt {
+		return 0
+	}
+	rate, err := strconv.ParseFloat(code, 64)
+	if err != nil || rate < 0 {
+		return 0
+	}
+	return rate
+}
+
+// VATLabel is the code as printed on invoices
+func VATLabel(code string) string {
+	if code == VATExempt {
+		return "KCT"
+	}
+	return code + "%"
+}
+
+// Discount is a percent or fixed reduction, always justified and approved
+type Discount struct {
+	Type       string  `json:"type"`
+	Value      float64 `json:"value"`
+	Reason     string  `json:"reason"`
+	ApprovedBy string  `json:"approved_by"`
+	ApprovedAt string  `json:"approved_at"`
+}
+
+func (d *Discount) Validate() error {
+	switch d.Type {
+	case DiscountPercent:
+		if d.Value <= 0 || d.Value > 100 {
+			return ErrInvalidDiscount
+		}
+	case DiscountFixed:
+		if d.Value <= 0 {
+			return ErrInvalidDiscount
+		}
+	default:
+		return ErrInvalidDiscount
+	}
+	if strings.TrimSpace(d.Reason) == "" || strings.TrimSpace(d.ApprovedBy) == "" {
+		return ErrDiscountApproval
+	}
+	return nil
+}
+
+// Amount is the rounded reduction on base, never more than base
+func (d *Discount) Amount(base float64) float64 {
+	if d == nil || base <= 0 {
+		return 0
+	}
+	amount := d.Value
+	if d.Type == DiscountPercent {
+		amount = base * d.Value / 100
+	}
+	return math.Min(math.Round(amount), base)
+}
+
+// LaborLine is an additional labor charge billed by the hour
+type LaborLine struct {
+	Description string  `json:"description"`
+	Hours       float64 `json:"hours"`
+	Rate        float64 `json:"rate"` // Per hour
+	Source      string  `json:"source"`
+}
+
+// InvoiceAdjustments are the manual pricing decisions kept on the invoice
+// (invoices.adjustments) so they survive regeneration from the job report.
+type InvoiceAdjustments struct {
+	Discount      *Discount           `json:"discount,omitempty"`
+	LineDiscounts map[string]Discount `json:"line_discounts,omitempty"` // By InvoiceLine.Key
+	ExtraLabor    []LaborLine         `json:"extra_labor,omitempty"`
+}
+
+func (a *InvoiceAdjustments) Validate() error {
+	if a.Discount != nil {
+		if err := a.Discount.Validate(); err != nil {
+			return err
+		}
+	}
+	for key, d := range a.LineDiscounts {
+		if key == "" {
+			return ErrInvalidDiscount
+		}
+		if err := d.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, l := range a.ExtraLabor {
+		if l.Hours <= 0 || l.Rate < 0 || strings.TrimSpace(l.Description) == "" {
+			return ErrInvalidLaborLine
+		}
+	}
+	return nil
+}
+
+// SetTechHours replaces the hours reported by the tech (0 removes them)
+func (a *InvoiceAdjustments) SetTechHours(hours, rate float64, note string) {
+	kept := a.ExtraLabor[:0]
+	for _, l := range a.ExtraLabor {
+		if l.Source != LaborFromTech {
+			kept = append(kept, l)
+		}
+	}
+	a.ExtraLabor = kept
+	if hours > 0 {
+		if strings.TrimSpace(note) == "" {
+			note = "Giờ công phát sinh"
+		}
+		a.ExtraLabor = append(a.ExtraLabor, LaborLine{Description: note, Hours: hours, Rate: rate, Source: LaborFromTech})
+	}
+}
+
+// LaborLines turns the extra hours into invoice lines (keys labor:0, labor:1...)
+func (a *InvoiceAdjustments) LaborLines(vatCode string) []InvoiceLine {
+	lines := make([]InvoiceLine, 0, len(a.ExtraLabor))
+	for i, l := range a.ExtraLabor {
+		lines = append(lines, InvoiceLine{
+			Key:       InvoiceLineLabor + ":" + strconv.Itoa(i),
+			Kind:      InvoiceLineLabor,
+			Name:      l.Description,
+			Unit:      "giờ",
+			Quantity:  l.Hours,
+			UnitPrice: l.Rate,
+			VATCode:   vatCode,
+		})
+	}
+	return lines
+}
+
+// InvoiceLine is one priced row of an invoice (invoice_items)
+type InvoiceLine struct {
+	Key       string  `json:"key"` // Stable across regeneration: service:<id>, part:<id>, labor:<n>
+	Kind      string  `json:"kind"`
+	Name      string  `json:"name"`
+	Unit      string  `json:"unit"`
+	Quantity  float64 `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	VATCode   string  `json:"vat_rate"`
+
+	// Computed by PriceInvoice
+	Subtotal float64 `json:"subtotal"` // Quantity x UnitPrice
+	Discount float64 `json:"discount"` // Line discount + share of the invoice discount
+	Tax      float64 `json:"tax"`
+	Total    float64 `json:"total"` // Subtotal - Discount + Tax
+}
+
+// Taxable is the amount VAT applies to
+func (l *InvoiceLine) Taxable() float64 {
+	return l.Subtotal - l.Discount
+}
+
+// InvoiceTotals are the amounts stored on the invoice
+type InvoiceTotals struct {
+	LaborTotal float64 // Service + labor subtotals, before discounts
+	PartsTotal float64
+	Subtotal   float64
+	Discount   float64
+	Tax        float64
+	Total      float64
+	LaborNet   float64 // Labor after discounts, before tax (commission base)
+}
+
+// PriceInvoice applies line discounts, spreads the invoice discount over the
+// lines pro rata (so VAT is charged on the discounted price) and computes VAT
+// per line. Amounts are rounded to whole dong.
+func PriceInvoice(lines []InvoiceLine, adj InvoiceAdjustments, defaultVAT string) (InvoiceTotals, error) {
+	var totals InvoiceTotals
+	if err := adj.Validate(); err != nil {
+		return totals, err
+	}
+
+	net := 0.0
+	for i := range lines {
+		l := &lines[i]
+		if l.Quantity <= 0 || l.UnitPrice < 0 {
+			return totals, ErrInvalidInvoiceLine
+		}
+		l.VATCode = ResolveVAT(l.VATCode, defaultVAT)
+		l.Subtotal = math.Round(l.Quantity * l.UnitPrice)
+		l.Discount = 0
+		if d, ok := adj.LineDiscounts[l.Key]; ok {
+			l.Discount = d.Amount(l.Subtotal)
+		}
+		net += l.Taxable()
+	}
+
+	// Invoice discount: pro rata by net amount, rounding remainder on the last line
+	remaining := adj.Discount.Amount(net)
+	last := -1
+	for i := range lines {
+		if lines[i].Taxable() > 0 {
+			last = i
+		}
+	}
+	if remaining > 0 && last >= 0 {
+		total := remaining
+		for i := range lines {
+			l := &lines[i]
+			base := l.Taxable()
+			if base <= 0 {
+				continue
+			}
+			share := math.Min(math.Round(total*base/net), remaining)
+			if i == last {
+				share = remaining
+			}
+			l.Discount += share
+			remaining -= share
+		}
+	}
+
+	for i := range lines {
+		l := &lines[i]
+		l.Tax = math.Round(l.Taxable() * VATPercent(l.VATCode) / 100)
+		l.Total = l.Taxable() + l.Tax
+
+		if l.Kind == InvoiceLinePart {
+			totals.PartsTotal += l.Subtotal
+		} else {
+			totals.LaborTotal += l.Subtotal
+			totals.LaborNet += l.Taxable()
+		}
+		totals.Subtotal += l.Subtotal
+		totals.Discount += l.Discount
+		totals.Tax += l.Tax
+		totals.Total += l.Total
+	}
+	return totals, nil
+}
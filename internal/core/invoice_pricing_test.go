@@ -0,0 +1,125 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func approved(kind string, value float64) *Discount {
+	return &Discount{Type: kind, Value: value, Reason: "Khách quen", ApprovedBy: "admin"}
+}
+
+func TestResolveVAT(t *testing.T) {
+	cases := []struct{ code, fallback, want string }{
+		{"8", "10", "8"},
+		{"", "5", "5"},
+		{"", "", DefaultVATCode},
+		{"7", "bogus", DefaultVATCode},
+		{VATExempt, "10", VATExempt},
+	}
+	for _, c := range cases {
+		if got := ResolveVAT(c.code, c.fallback); got != c.want {
+			t.Errorf("ResolveVAT(%q, %q) = %q; want %q", c.code, c.fallback, got, c.want)
+		}
+	}
+	if VATPercent(VATExempt) != 0 || VATPercent("8") != 8 {
+		t.Error("VATPercent mismatch")
+	}
+}
+
+func TestInvoiceEditable(t *testing.T) {
+	cases := []struct {
+		status, einvoice string
+		want             bool
+	}{
+		{InvoiceUnpaid, EInvoiceNone, true},
+		{InvoicePartiallyPaid, EInvoiceCancelled, true},
+		{InvoiceUnpaid, EInvoiceIssued, false},
+		{InvoicePaid, EInvoiceNone, false},
+	}
+	for _, c := range cases {
+		if got := InvoiceEditable(c.status, c.einvoice); got != c.want {
+			t.Errorf("InvoiceEditable(%q, %q) = %v; want %v", c.status, c.einvoice, got, c.want)
+		}
+	}
+}
+
+func TestDiscountValidate(t *testing.T) {
+	if err := approved(DiscountPercent, 120).Validate(); !errors.Is(err, ErrInvalidDiscount) {
+		t.Errorf("Percent over 100 must fail, got %v", err)
+	}
+	if err := (&Discount{Type: DiscountFixed, Value: 50000}).Validate(); !errors.Is(err, ErrDiscountApproval) {
+		t.Errorf("Discount without reason/approver must fail, got %v", err)
+	}
+	if got := approved(DiscountFixed, 900000).Amount(500000); got != 500000 {
+		t.Errorf("Fixed discount must be clamped to the base, got %v", got)
+	}
+}
+
+func TestPriceInvoiceVATAndDiscounts(t *testing.T) {
+	lines := []InvoiceLine{
+		{Key: "service:s1", Kind: InvoiceLineService, Quantity: 1, UnitPrice: 300000},               // default 10%
+		{Key: "part:p1", Kind: InvoiceLinePart, Quantity: 2, UnitPrice: 100000, VATCode: "8"},       // 8%
+		{Key: "part:p2", Kind: InvoiceLinePart, Quantity: 1, UnitPrice: 100000, VATCode: VATExempt}, // KCT
+	}
+	adj := InvoiceAdjustments{
+		LineDiscounts: map[string]Discount{"part:p1": *approved(DiscountPercent, 50)},
+		Discount:      approved(DiscountFixed, 50000),
+	}
+
+	totals, err := PriceInvoice(lines, adj, "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Net before invoice discount: 300000 + 100000 + 100000 = 500000, 50000 spread 30/10/10
+	if lines[0].Discount != 30000 || lines[1].Discount != 110000 || lines[2].Discount != 10000 {
+		t.Errorf("Discounts = %v / %v / %v", lines[0].Discount, lines[1].Discount, lines[2].Discount)
+	}
+	if lines[0].Tax != 27000 || lines[1].Tax != 7200 || lines[2].Tax != 0 {
+		t.Errorf("Taxes = %v / %v / %v", lines[0].Tax, lines[1].Tax, lines[2].Tax)
+	}
+	if totals.Subtotal != 600000 || totals.Discount != 150000 || totals.Tax != 34200 || totals.Total != 484200 {
+		t.Errorf("Totals = %+v", totals)
+	}
+	if totals.LaborTotal != 300000 || totals.PartsTotal != 300000 || totals.LaborNet != 270000 {
+		t.Errorf("Labor/parts = %+v", totals)
+	}
+}
+
+func TestPriceInvoiceDiscountRemainder(t *testing.T) {
+	lines := []InvoiceLine{
+		{Key: "a", Kind: InvoiceLineService, Quantity: 1, UnitPrice: 100000, VATCode: "0"},
+		{Key: "b", Kind: InvoiceLineService, Quantity: 1, UnitPrice: 100000, VATCode: "0"},
+		{Key: "c", Kind: InvoiceLineService, Quantity: 1, UnitPrice: 100000, VATCode: "0"},
+	}
+	totals, err := PriceInvoice(lines, InvoiceAdjustments{Discount: approved(DiscountFixed, 100000)}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totals.Discount != 100000 || totals.Total != 200000 {
+		t.Errorf("Rounded shares must add up to the discount, got %+v", totals)
+	}
+}
+
+func TestTechHoursReplaced(t *testing.T) {
+	adj := InvoiceAdjustments{ExtraLabor: []LaborLine{{Description: "Đi dây thêm", Hours: 1, Rate: 150000, Source: LaborFromAdmin}}}
+	adj.SetTechHours(2, 100000, "")
+	adj.SetTechHours(1.5, 100000, "Vệ sinh dàn nóng")
+	if len(adj.ExtraLabor) != 2 || adj.ExtraLabor[1].Hours != 1.5 {
+		t.Fatalf("Tech hours must replace the previous entry, got %+v", adj.ExtraLabor)
+	}
+
+	lines := adj.LaborLines("")
+	totals, err := PriceInvoice(lines, adj, "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines[1].Key != "labor:1" || totals.Subtotal != 300000 || totals.Total != 330000 {
+		t.Errorf("Labor lines = %+v, totals = %+v", lines, totals)
+	}
+
+	adj.SetTechHours(0, 0, "")
+	if len(adj.ExtraLabor) != 1 {
+		t.Errorf("Zero hours must remove the tech entry, got %+v", adj.ExtraLabor)
+	}
+}
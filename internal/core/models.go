@@ -15,6 +15,8 @@ type Booking struct {
 	BookingTime      string `json:"booking_time"` // Raw string from DB for now, ideally time.Time
 
 	// Assignments
+	CustomerID   string  `json:"customer_id"` // [NEW] Deduplicated customer
+	ContractID   string  `json:"contract_id"` // [NEW] Maintenance contract visit
 	TechnicianID string  `json:"technician_id"`
 	SlotID       *string `json:"slot_id"` // Pointer to allow null
 
@@ -26,8 +28,14 @@ type Booking struct {
 	Lat  float64 `json:"lat"`
 	Long float64 `json:"long"`
 
-	// Status timestamps
-	ArrivedAt string `json:"arrived_at"`
+	// Status timestamps (stamped by BookingLifecycle.Apply)
+	MovingStartAt string `json:"moving_start_at"`
+	ArrivedAt     string `json:"arrived_at"`
+	StartedAt     string `json:"started_at"`
+	CompletedAt   string `json:"completed_at"`
+
+	// Customer self-service portal link (/b/{token})
+	AccessToken string `json:"-"`
 
 	// Exception Handling
 	CancelReason string `json:"cancel_reason"`
@@ -96,6 +104,17 @@ type Service struct {
 	IsActive       bool    `json:"is_active"`
 }
 
+// DispatchCandidate is a technician scored for a booking by the dispatch engine
+type DispatchCandidate struct {
+	TechnicianID   string   `json:"technician_id"`
+	TechnicianName string   `json:"technician_name"`
+	Score          float64  `json:"score"`       // 0-100, higher is better
+	Eligible       bool     `json:"eligible"`    // False = hard constraint failed (skill, schedule...)
+	DistanceKm     float64  `json:"distance_km"` // -1 when no live position
+	ActiveJobs     int      `json:"active_jobs"`
+	Reasons        []string `json:"reasons"` // Human readable scoring breakdown
+}
+
 // Analytics Models
 type RevenueStat struct {
 	Date   string  `json:"date"`
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"errors"
+	"time"
+)
+
+// Invoice statuses (invoices.status), derived from the payments ledger.
+// Cancelled is the only status set by hand.
+const (
+	InvoiceUnpaid        = "unpaid"
+	InvoicePartiallyPaid = "partially_paid"
+	InvoicePaid          = "paid"
+	InvoiceOverpaid      = "overpaid"
+	InvoiceRefunded      = "refunded"
+	InvoiceCancelled     = "cancelled"
+)
+
+// Payment kinds (payments.kind)
+const (
+	PaymentIn     = "payment" // Deposit, balance or full payment from the customer
+	PaymentRefund = "refund"  // Money returned to the customer
+)
+
+// Payment entry statuses (payments.status). Refunds start pending and voiding
+// a payment is requested first: both wait for an admin.
+const (
+	PaymentPosted      = "posted"
+	PaymentPending     = "pending"      // Refund awaiting approval
+	PaymentVoidPending = "void_pending" // Void requested, still counted until approved
+	PaymentVoided      = "voided"
+	PaymentRejected    = "rejected"
+)
+
+// Payment methods (payments.method, same values as invoices.payment_method)
+const (
+	MethodCash     = "cash"
+	MethodTransfer = "transfer"
+	MethodCard     = "card"
+)
+
+var (
+	ErrInvalidPayment     = errors.New("payment amount must be positive with a valid method")
+	ErrRefundExceedsPaid  = errors.New("refund exceeds the amount paid")
+	ErrPaymentNotPending  = errors.New("payment has no pending request")
+	ErrPaymentNotVoidable = errors.New("only posted payments can be voided")
+	ErrInvoiceClosed      = errors.New("invoice is cancelled")
+	ErrDuplicatePayment   = errors.New("payment already recorded")
+)
+
+// Payment is one entry of an invoice's payments ledger
+type Payment struct {
+	ID              string    `json:"id"`
+	InvoiceID       string    `json:"invoice_id"`
+	BookingID       string    `json:"booking_id"`
+	Kind            string    `json:"kind"`
+	Amount          float64   `json:"amount"` // Always positive; Kind gives the direction
+	Method          string    `json:"method"`
+	Reference       string    `json:"reference"`                 // Bank reference, receipt number...
+	IdempotencyKey  string    `json:"idempotency_key,omitempty"` // At most one entry per key; empty = no guard
+	CollectedBy     string    `json:"collected_by"`
+	CollectedByName string    `json:"collected_by_name"`
+	PaidAt          time.Time `json:"paid_at"`
+	Status          string    `json:"status"`
+	Note            string    `json:"note"`
+	RequestedBy     string    `json:"requested_by"`
+	RequestNote     string    `json:"request_note"`
+	ApprovedBy      string    `json:"approved_by"`
+	ApprovedAt      string    `json:"approved_at"`
+	Created         string    `json:"created"`
+
+	// Display only (approval queue)
+	InvoiceCode string `json:"invoice_code,omitempty"`
+}
+
+// Counted reports whether the entry is part of the balance. A payment being
+// voided still counts until the void is approved.
+func (p *Payment) Counted() bool {
+	return p.Status == PaymentPosted || p.Status == PaymentVoidPending
+}
+
+// LocalTime is the payment time in Vietnam time
+func (p *Payment) LocalTime() time.Time {
+	return p.PaidAt.In(fiscalZone)
+}
+
+// ValidMethod reports whether m is a known payment method
+func ValidMethod(m string) bool {
+	return m == MethodCash || m == MethodTransfer || m == MethodCard
+}
+
+// InvoiceBalance is the state of an invoice computed from its ledger
+type InvoiceBalance struct {
+	Total    float64 `json:"total"`
+	Paid     float64 `json:"paid"`
+	Refunded float64 `json:"refunded"`
+	Net      float64 `json:"net"` // Paid - Refunded
+	Due      float64 `json:"due"` // Still owed by the customer (never negative)
+	Status   string  `json:"status"`
+	Method   string  `json:"method"` // Method of the latest counted payment
+}
+
+// ComputeBalance sums the counted entries of the ledger against the invoice total
+func ComputeBalance(total float64, payments []*Payment) InvoiceBalance {
+	b := InvoiceBalance{Total: total}
+	var latest time.Time
+	for _, p := range payments {
+		if !p.Counted() {
+			continue
+		}
+		switch p.Kind {
+		case PaymentIn:
+			b.Paid += p.Amount
+			if b.Method == "" || !p.PaidAt.Before(latest) {
+				b.Method, latest = p.Method, p.PaidAt
+			}
+		case PaymentRefund:
+			b.Refunded += p.Amount
+		}
+	}
+	b.Net = b.Paid - b.Refunded
+	if b.Net < total && !sameVND(b.Net, total) {
+		b.Due = total - b.Net
+	}
+	b.Status = DeriveInvoiceStatus(total, b.Paid, b.Refunded)
+	return b
+}
+
+// DeriveInvoiceStatus maps what was paid and refunded to an invoice status
+func DeriveInvoiceStatus(total, paid, refunded float64) string {
+	net := paid - refunded
+	switch {
+	case refunded > 0 && (net < 0 || sameVND(net, 0)):
+		return InvoiceRefunded
+	case total <= 0 && refunded == 0:
+		return InvoicePaid // Nothing to collect (warranty, fully discounted)
+	case net < 0 || sameVND(net, 0):
+		return InvoiceUnpaid
+	case sameVND(net, total):
+		return InvoicePaid
+	case net < total:
+		return InvoicePartiallyPaid
+	default:
+		return InvoiceOverpaid
+	}
+}
+
+// InvoiceOpen reports whether an invoice with this status still expects money
+func InvoiceOpen(status string) bool {
+	return status == "" || status == InvoiceUnpaid || status == InvoicePartiallyPaid
+}
+
+// InvoiceSettled reports whether the customer paid at least the total
+func InvoiceSettled(status string) bool {
+	return status == InvoicePaid || status == InvoiceOverpaid
+}
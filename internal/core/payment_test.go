@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeriveInvoiceStatus(t *testing.T) {
+	cases := []struct {
+		total, paid, refunded float64
+		want                  string
+	}{
+		{1000000, 0, 0, InvoiceUnpaid},
+		{1000000, 300000, 0, InvoicePartiallyPaid},
+		{1000000, 1000000, 0, InvoicePaid},
+		{1000000, 1000000.4, 0, InvoicePaid},
+		{1000000, 1200000, 0, InvoiceOverpaid},
+		{1000000, 1200000, 200000, InvoicePaid},
+		{1000000, 1000000, 400000, InvoicePartiallyPaid},
+		{1000000, 1000000, 1000000, InvoiceRefunded},
+		{1000000, 300000, 300000, InvoiceRefunded},
+		{0, 0, 0, InvoicePaid},
+	}
+	for _, c := range cases {
+		if got := DeriveInvoiceStatus(c.total, c.paid, c.refunded); got != c.want {
+			t.Errorf("DeriveInvoiceStatus(%v, %v, %v) = %q; want %q", c.total, c.paid, c.refunded, got, c.want)
+		}
+	}
+}
+
+func TestComputeBalance(t *testing.T) {
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	ledger := []*Payment{
+		{Kind: PaymentIn, Amount: 5000000, Method: MethodTransfer, Status: PaymentPosted, PaidAt: day},
+		{Kind: PaymentIn, Amount: 2000000, Method: MethodCash, Status: PaymentVoidPending, PaidAt: day.Add(48 * time.Hour)},
+		{Kind: PaymentIn, Amount: 9000000, Method: MethodCash, Status: PaymentVoided, PaidAt: day.Add(72 * time.Hour)},
+		{Kind: PaymentRefund, Amount: 500000, Method: MethodCash, Status: PaymentPending},
+		{Kind: PaymentRefund, Amount: 1000000, Method: MethodTransfer, Status: PaymentPosted},
+	}
+	b := ComputeBalance(12000000, ledger)
+	if b.Paid != 7000000 || b.Refunded != 1000000 || b.Net != 6000000 {
+		t.Fatalf("paid/refunded/net = %v/%v/%v", b.Paid, b.Refunded, b.Net)
+	}
+	if b.Due != 6000000 || b.Status != InvoicePartiallyPaid {
+		t.Errorf("due = %v, status = %q", b.Due, b.Status)
+	}
+	if b.Method != MethodCash {
+		t.Errorf("method = %q; want the latest counted payment", b.Method)
+	}
+
+	over := ComputeBalance(1000000, []*Payment{{Kind: PaymentIn, Amount: 1500000, Status: PaymentPosted}})
+	if over.Due != 0 || over.Status != InvoiceOverpaid {
+		t.Errorf("overpaid: due = %v, status = %q", over.Due, over.Status)
+	}
+}
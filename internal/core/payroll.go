@@ -0,0 +1,257 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Payroll statement statuses (payroll_statements.status)
+const (
+	PayrollDraft    = "draft"    // Recomputed on every generation
+	PayrollApproved = "approved" // Locked: no regeneration, no adjustment
+)
+
+// Payroll line kinds. Earnings are positive, deductions negative.
+const (
+	PayLineBaseSalary = "base_salary"
+	PayLineCommission = "commission"
+	PayLineBonus      = "bonus"
+	PayLinePenalty    = "penalty"
+	PayLineAdvance    = "advance"
+	PayLineShortage   = "shortage"
+)
+
+// CancelReasonNotHome is the cancel reason a tech picks when the customer is
+// not home (booking_events.data.reason); it can be penalized on payroll
+const CancelReasonNotHome = "customer_not_home"
+
+var (
+	ErrInvalidPeriod     = errors.New("payroll period must be YYYY-MM")
+	ErrInvalidAdjustment = errors.New("adjustment needs a kind (bonus, penalty, advance) and a positive amount")
+	ErrPayrollLocked     = errors.New("payroll statement is approved")
+)
+
+// PayrollPeriod returns the bounds [from, to) of a "YYYY-MM" period in Vietnam time
+func PayrollPeriod(period string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01", period, fiscalZone)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidPeriod
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// CurrentPayrollPeriod is the "YYYY-MM" period containing t
+func CurrentPayrollPeriod(t time.Time) string {
+	return t.In(fiscalZone).Format("2006-01")
+}
+
+// PayrollLine is one line of a statement
+type PayrollLine struct {
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	Reference   string    `json:"reference,omitempty"` // Invoice code, booking ID...
+	Date        time.Time `json:"date,omitempty"`
+	Amount      float64   `json:"amount"` // Signed: earnings > 0, deductions < 0
+}
+
+// LocalDate is the line date in Vietnam time
+func (l PayrollLine) LocalDate() time.Time {
+	return l.Date.In(fiscalZone)
+}
+
+// PayrollStatement is the monthly pay of one technician
+type PayrollStatement struct {
+	ID             string        `json:"id"`
+	TechnicianID   string        `json:"technician_id"`
+	TechnicianName string        `json:"technician_name"`
+	Period         string        `json:"period"` // YYYY-MM
+	BaseSalary     float64       `json:"base_salary"`
+	Commission     float64       `json:"commission"`
+	Bonus          float64       `json:"bonus"`
+	Penalty        float64       `json:"penalty"`
+	Advance        float64       `json:"advance"`
+	Shortage       float64       `json:"shortage"`
+	NetPay         float64       `json:"net_pay"`
+	Lines          []PayrollLine `json:"lines"`
+	Status         string        `json:"status"`
+	ApprovedBy     string        `json:"approved_by"`
+	ApprovedAt     string        `json:"approved_at"`
+	Updated        string        `json:"updated"`
+}
+
+// Gross is what the technician earned before deductions
+func (s *PayrollStatement) Gross() float64 {
+	return s.BaseSalary + s.Commission + s.Bonus
+}
+
+// Deductions is the total withheld from the gross pay
+func (s *PayrollStatement) Deductions() float64 {
+	return s.Penalty + s.Advance + s.Shortage
+}
+
+// Locked reports whether the statement can no longer change
+func (s *PayrollStatement) Locked() bool {
+	return s.Status == PayrollApproved
+}
+
+// PayrollAdjustment is a bonus, penalty or salary advance entered by an admin
+type PayrollAdjustment struct {
+	ID           string  `json:"id"`
+	TechnicianID string  `json:"technician_id"`
+	Period       string  `json:"period"`
+	Kind         string  `json:"kind"`   // bonus | penalty | advance
+	Amount       float64 `json:"amount"` // Always positive; Kind gives the sign
+	Description  string  `json:"description"`
+	CreatedBy    string  `json:"created_by"`
+	Created      string  `json:"created"`
+}
+
+// Validate checks the kind, amount and period of an adjustment
+func (a *PayrollAdjustment) Validate() error {
+	if _, _, err := PayrollPeriod(a.Period); err != nil {
+		return err
+	}
+	if a.Amount <= 0 || (a.Kind != PayLineBonus && a.Kind != PayLinePenalty && a.Kind != PayLineAdvance) {
+		return ErrInvalidAdjustment
+	}
+	return nil
+}
+
+// CommissionItem is the commission of an invoice settled in the period
+type CommissionItem struct {
+	InvoiceID   string    `json:"invoice_id"`
+	InvoiceCode string    `json:"invoice_code"`
+	BookingID   string    `json:"booking_id"`
+	SettledAt   time.Time `json:"settled_at"` // Payment that first settled the invoice
+	Amount      float64   `json:"amount"`     // invoices.tech_commission
+}
+
+// SettlingPayment is an incoming payment counted towards an invoice
+type SettlingPayment struct {
+	PaidAt time.Time
+	Amount float64
+}
+
+// FirstSettled is when an invoice first became fully paid: the date of the
+// payment, in date order, that brought the sum up to the total. A later
+// payment does not move it, so the commission falls in one period only.
+// Zero when the payments never cover the total.
+func FirstSettled(total float64, payments []SettlingPayment) time.Time {
+	sort.SliceStable(payments, func(i, j int) bool { return payments[i].PaidAt.Before(payments[j].PaidAt) })
+	paid := 0.0
+	for _, p := range payments {
+		paid += p.Amount
+		if paid > total || sameVND(paid, total) {
+			return p.PaidAt
+		}
+	}
+	return time.Time{}
+}
+
+// PayrollInput gathers everything a statement is computed from
+type PayrollInput struct {
+	Technician    *Technician
+	Period        string
+	Commissions   []*CommissionItem
+	NoShows       []*BookingEvent // "Customer not home" cancellations by the tech
+	NoShowPenalty float64         // Per cancellation, 0 = no automatic penalty
+	Handovers     []*CashHandover // Handovers confirmed in the period
+	Adjustments   []*PayrollAdjustment
+}
+
+// BuildStatement computes a draft statement: base salary, commissions,
+// bonuses, penalties, advances and cash shortages
+func BuildStatement(in PayrollInput) *PayrollStatement {
+	s := &PayrollStatement{
+		TechnicianID:   in.Technician.ID,
+		TechnicianName: in.Technician.Name,
+		Period:         in.Period,
+		Status:         PayrollDraft,
+	}
+	from, _, _ := PayrollPeriod(in.Period)
+
+	if in.Technician.BaseSalary > 0 {
+		s.BaseSalary = in.Technician.BaseSalary
+		s.Lines = append(s.Lines, PayrollLine{Kind: PayLineBaseSalary, Description: "Lương cơ bản", Date: from, Amount: s.BaseSalary})
+	}
+
+	commissions := append([]*CommissionItem(nil), in.Commissions...)
+	sort.SliceStable(commissions, func(i, j int) bool { return commissions[i].SettledAt.Before(commissions[j].SettledAt) })
+	for _, c := range commissions {
+		if c.Amount == 0 {
+			continue
+		}
+		s.Commission += c.Amount
+		s.Lines = append(s.Lines, PayrollLine{
+			Kind: PayLineCommission, Description: "Hoa hồng hóa đơn", Reference: c.InvoiceCode, Date: c.SettledAt, Amount: c.Amount,
+		})
+	}
+
+	if in.NoShowPenalty > 0 {
+		for _, ev := range in.NoShows {
+			s.Penalty += in.NoShowPenalty
+			at, _ := time.Parse(DateTimeLayout, ev.Created)
+			s.Lines = append(s.Lines, PayrollLine{
+				Kind: PayLinePenalty, Description: "Hủy việc - khách không có nhà", Reference: ev.BookingID, Date: at, Amount: -in.NoShowPenalty,
+			})
+		}
+	}
+
+	for _, h := range in.Handovers {
+		if h.Status != HandoverConfirmed || h.Discrepancy >= 0 {
+			continue
+		}
+		s.Shortage -= h.Discrepancy
+		s.Lines = append(s.Lines, PayrollLine{
+			Kind: PayLineShortage, Description: "Thiếu tiền mặt khi nộp", Date: h.ConfirmedAt, Amount: h.Discrepancy,
+		})
+	}
+
+	for _, a := range in.Adjustments {
+		line := PayrollLine{Kind: a.Kind, Description: a.Description, Amount: a.Amount}
+		if at, err := time.Parse(DateTimeLayout, a.Created); err == nil {
+			line.Date = at
+		}
+		switch a.Kind {
+		case PayLineBonus:
+			s.Bonus += a.Amount
+		case PayLinePenalty:
+			s.Penalty += a.Amount
+			line.Amount = -a.Amount
+		case PayLineAdvance:
+			s.Advance += a.Amount
+			line.Amount = -a.Amount
+		default:
+			continue
+		}
+		if line.Description == "" {
+			line.Description = PayLineLabel(a.Kind)
+		}
+		s.Lines = append(s.Lines, line)
+	}
+
+	s.NetPay = s.Gross() - s.Deductions()
+	return s
+}
+
+// PayLineLabel is the Vietnamese label of a line kind
+func PayLineLabel(kind string) string {
+	switch kind {
+	case PayLineBaseSalary:
+		return "Lương cơ bản"
+	case PayLineCommission:
+		return "Hoa hồng"
+	case PayLineBonus:
+		return "Thưởng"
+	case PayLinePenalty:
+		return "Phạt"
+	case PayLineAdvance:
+		return "Tạm ứng"
+	case PayLineShortage:
+		return "Thiếu tiền mặt"
+	default:
+		return fmt.Sprintf("Khác (%s)", kind)
+	}
+}
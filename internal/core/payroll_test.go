@@ -0,0 +1,110 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPayrollPeriod(t *testing.T) {
+	from, to, err := PayrollPeriod("2026-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := from.UTC().Format(time.RFC3339); got != "2026-02-28T17:00:00Z" {
+		t.Errorf("from = %s; want midnight ICT", got)
+	}
+	if !to.Equal(from.AddDate(0, 1, 0)) {
+		t.Errorf("to = %s", to)
+	}
+	if _, _, err := PayrollPeriod("2026-13"); err != ErrInvalidPeriod {
+		t.Errorf("err = %v; want ErrInvalidPeriod", err)
+	}
+	if got := CurrentPayrollPeriod(time.Date(2026, 3, 31, 18, 0, 0, 0, time.UTC)); got != "2026-04" {
+		t.Errorf("CurrentPayrollPeriod = %s; want 2026-04 (already April in Vietnam)", got)
+	}
+}
+
+func TestBuildStatement(t *testing.T) {
+	day := time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)
+	s := BuildStatement(PayrollInput{
+		Technician: &Technician{ID: "t1", Name: "Nguyễn Văn A", BaseSalary: 7000000},
+		Period:     "2026-03",
+		Commissions: []*CommissionItem{
+			{InvoiceCode: "HD-2026-000012", SettledAt: day.Add(48 * time.Hour), Amount: 150000},
+			{InvoiceCode: "HD-2026-000009", SettledAt: day, Amount: 250000},
+			{InvoiceCode: "HD-2026-000015", SettledAt: day, Amount: 0},
+		},
+		NoShows:       []*BookingEvent{{BookingID: "b1", Created: "2026-03-12 02:00:00.000Z"}},
+		NoShowPenalty: 50000,
+		Handovers: []*CashHandover{
+			{Status: HandoverConfirmed, Discrepancy: -20000, ConfirmedAt: day},
+			{Status: HandoverConfirmed, Discrepancy: 10000, ConfirmedAt: day},
+			{Status: HandoverRejected, Discrepancy: -900000},
+		},
+		Adjustments: []*PayrollAdjustment{
+			{Kind: PayLineBonus, Amount: 300000, Description: "Thưởng đánh giá 5 sao"},
+			{Kind: PayLineAdvance, Amount: 2000000},
+			{Kind: PayLinePenalty, Amount: 100000, Description: "Đi trễ"},
+		},
+	})
+
+	if s.BaseSalary != 7000000 || s.Commission != 400000 || s.Bonus != 300000 {
+		t.Errorf("base/commission/bonus = %v/%v/%v", s.BaseSalary, s.Commission, s.Bonus)
+	}
+	if s.Penalty != 150000 || s.Advance != 2000000 || s.Shortage != 20000 {
+		t.Errorf("penalty/advance/shortage = %v/%v/%v", s.Penalty, s.Advance, s.Shortage)
+	}
+	if want := 7700000.0 - 2170000.0; s.NetPay != want {
+		t.Errorf("net pay = %v; want %v", s.NetPay, want)
+	}
+
+	// base + 2 commissions + no-show + shortage + 3 adjustments
+	if len(s.Lines) != 8 {
+		t.Fatalf("lines = %d; want 8", len(s.Lines))
+	}
+	if s.Lines[1].Reference != "HD-2026-000009" {
+		t.Errorf("commissions not in settlement order: %q first", s.Lines[1].Reference)
+	}
+	sum := 0.0
+	for _, l := range s.Lines {
+		sum += l.Amount
+	}
+	if sum != s.NetPay {
+		t.Errorf("lines sum to %v; net pay is %v", sum, s.NetPay)
+	}
+	if s.Lines[6].Description != "Tạm ứng" {
+		t.Errorf("advance without description labelled %q", s.Lines[6].Description)
+	}
+}
+
+func TestFirstSettled(t *testing.T) {
+	march := time.Date(2026, 3, 20, 3, 0, 0, 0, time.UTC)
+	april := march.AddDate(0, 1, 0)
+	payments := []SettlingPayment{
+		{PaidAt: april, Amount: 50000}, // Overpayment after the invoice was settled
+		{PaidAt: march, Amount: 700000},
+		{PaidAt: march.AddDate(0, 0, -5), Amount: 300000},
+	}
+	if got := FirstSettled(1000000, payments); !got.Equal(march) {
+		t.Errorf("FirstSettled = %s; want %s", got, march)
+	}
+	if got := FirstSettled(2000000, payments); !got.IsZero() {
+		t.Errorf("FirstSettled of an unpaid invoice = %s; want zero", got)
+	}
+}
+
+func TestPayrollAdjustmentValidate(t *testing.T) {
+	ok := &PayrollAdjustment{Period: "2026-03", Kind: PayLineAdvance, Amount: 1000000}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("valid adjustment rejected: %v", err)
+	}
+	for _, a := range []*PayrollAdjustment{
+		{Period: "2026-03", Kind: PayLineCommission, Amount: 1000},
+		{Period: "2026-03", Kind: PayLineBonus, Amount: 0},
+		{Period: "03/2026", Kind: PayLineBonus, Amount: 1000},
+	} {
+		if err := a.Validate(); err == nil {
+			t.Errorf("%+v accepted", a)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"time"
 
 	"github.com/pocketbase/pocketbase/tools/filesystem"
 )
@@ -9,6 +10,7 @@ import (
 // BookingRepository defines data access methods for Bookings
 type BookingRepository interface {
 	GetByID(id string) (*Booking, error)
+	GetByAccessToken(token string) (*Booking, error) // [NEW] Customer portal link
 	Create(booking *Booking, files []*filesystem.File) error
 	Update(booking *Booking) error
 
@@ -18,11 +20,17 @@ type BookingRepository interface {
 	FindScheduledByTechnician(techID string) ([]*Booking, error) // Scheduled = Not Cancelled (includes completed)
 	FindAllByDate(date string) ([]*Booking, error)               // [NEW] For Dynamic Availability
 
-	// Location and Status Updates
-	UpdateStatus(bookingID string, status string) error
+	// Location Updates (status changes go through BookingService / BookingLifecycle)
 	UpdateLocation(bookingID string, lat float64, lng float64) error
 }
 
+// BookingEventRepository persists the booking timeline (append-only)
+type BookingEventRepository interface {
+	Append(event *BookingEvent) error
+	ListByBooking(bookingID string) ([]*BookingEvent, error)
+	ListByActor(actorID, eventType string, from, to time.Time) ([]*BookingEvent, error)
+}
+
 // TechnicianRepository defines data access for Technicians
 type TechnicianRepository interface {
 	GetByID(id string) (*Technician, error)
@@ -44,6 +52,152 @@ type TechnicianRepository interface {
 	CountActive() (int, error)
 }
 
+// TechScheduleRepository stores weekly working hours and time off (leave, sick, holiday)
+type TechScheduleRepository interface {
+	GetWorkingHours(techID string) ([]*WorkingHours, error)
+	ReplaceWorkingHours(techID string, hours []*WorkingHours) error
+
+	GetTimeOff(id string) (*TimeOff, error)
+	CreateTimeOff(off *TimeOff) error
+	UpdateTimeOff(off *TimeOff) error
+	ListTimeOffInRange(techID, dateFrom, dateTo string) ([]*TimeOff, error) // Tech's entries + company holidays
+	ListTimeOffByStatus(status string) ([]*TimeOff, error)
+	ListTimeOffByTechnician(techID string) ([]*TimeOff, error)
+}
+
+// CustomerRepository stores customers keyed by normalized phone
+type CustomerRepository interface {
+	GetByID(id string) (*Customer, error) // Includes aggregates
+	GetByPhone(phone string) (*Customer, error)
+	Create(customer *Customer) error
+	Update(customer *Customer) error
+	Search(query string, limit, offset int) ([]*Customer, int, error) // Name / phone, with aggregates
+	FindSimilar(customer *Customer, limit int) ([]*Customer, error)   // Merge candidates
+	ListBookings(customerID string) ([]*Booking, error)
+
+	// Merge moves every booking of duplicateID to primary, saves primary and deletes the duplicate
+	Merge(primary *Customer, duplicateID string) error
+}
+
+// EquipmentRepository stores installed units; service history comes from job_reports
+type EquipmentRepository interface {
+	GetByID(id string) (*Equipment, error)
+	ListByCustomer(customerID string) ([]*Equipment, error) // Includes aggregates
+	Create(eq *Equipment) error
+	Update(eq *Equipment) error
+	ServiceHistory(equipmentID string) ([]*EquipmentServiceRecord, error)
+	LinkReport(reportID string, equipmentIDs []string) error
+}
+
+// ContractRepository stores maintenance contracts (with customer/service names expanded)
+type ContractRepository interface {
+	GetByID(id string) (*MaintenanceContract, error)
+	List(status string) ([]*MaintenanceContract, error) // Empty status = all
+	ListByCustomer(customerID string) ([]*MaintenanceContract, error)
+	ListEndingBetween(from, to string) ([]*MaintenanceContract, error) // Active/expired, by end_date
+	Create(c *MaintenanceContract) error
+	Update(c *MaintenanceContract) error
+	// BookVisit saves the visit's booking and the contract pointing to it in one transaction
+	BookVisit(c *MaintenanceContract, booking *Booking) error
+}
+
+// PartRepository reads the material catalog (inventory_items)
+type PartRepository interface {
+	GetByID(id string) (*Part, error)
+	ListActive() ([]*Part, error)
+}
+
+// QuoteRepository stores on-site quotes; the signature is saved as a file field
+type QuoteRepository interface {
+	GetByID(id string) (*Quote, error)
+	GetByHash(hash string) (*Quote, error)
+	ListByBooking(bookingID string) ([]*Quote, error) // Newest first
+	Create(q *Quote) error
+	Update(q *Quote, signature *filesystem.File) error // signature may be nil
+	// SaveDecision saves a signed answer and the quotes it supersedes in one transaction
+	SaveDecision(q *Quote, signature *filesystem.File, superseded []*Quote) error
+	// CreatePending saves a new quote, the pending quotes it supersedes and the
+	// booking moved to quoting (nil when unchanged) in one transaction
+	CreatePending(q *Quote, superseded []*Quote, booking *Booking) error
+}
+
+// BankTransactionRepository stores incoming transfers; Create returns
+// ErrDuplicateTransaction when source + external_id was already recorded
+type BankTransactionRepository interface {
+	GetByID(id string) (*BankTransaction, error)
+	ListByStatus(status string, limit int) ([]*BankTransaction, error) // Newest first, "" = all
+	Create(tx *BankTransaction) error
+	Update(tx *BankTransaction) error
+}
+
+// PaymentRepository stores the payments ledger of invoices; Create returns
+// ErrDuplicatePayment when an entry with the same idempotency key exists
+type PaymentRepository interface {
+	GetByID(id string) (*Payment, error)
+	ListByInvoice(invoiceID string) ([]*Payment, error) // Oldest first
+	ListByStatus(statuses ...string) ([]*Payment, error)
+	ListCashCollectedBy(collectorID string) ([]*Payment, error) // Cash payments, oldest first
+	Create(p *Payment) error
+	Update(p *Payment) error
+}
+
+type PayrollRepository interface {
+	GetStatement(id string) (*PayrollStatement, error)
+	FindStatement(techID, period string) (*PayrollStatement, error) // nil, nil when none
+	ListStatements(period string) ([]*PayrollStatement, error)
+	ListStatementsByTechnician(techID string) ([]*PayrollStatement, error) // Latest period first
+	SaveStatement(s *PayrollStatement) error                               // Creates when ID is empty
+	GetAdjustment(id string) (*PayrollAdjustment, error)
+	ListAdjustments(techID, period string) ([]*PayrollAdjustment, error)
+	CreateAdjustment(a *PayrollAdjustment) error
+	DeleteAdjustment(id string) error
+	// Commission of the tech's invoices whose last payment falls in [from, to)
+	SettledCommissions(techID string, from, to time.Time) ([]*CommissionItem, error)
+}
+
+type CashHandoverRepository interface {
+	GetByID(id string) (*CashHandover, error)
+	ListByTechnician(techID string) ([]*CashHandover, error) // Newest first
+	ListByStatus(status string) ([]*CashHandover, error)
+	Create(h *CashHandover) error
+	Update(h *CashHandover) error
+}
+
+// InvoiceLedger is the invoice side of the payments ledger
+// (implemented by the invoice service)
+type InvoiceLedger interface {
+	OpenInvoices() ([]PayableInvoice, error) // Unpaid / partially paid invoices with their amount due
+	GetPayable(invoiceID string) (*PayableInvoice, error)
+	// ApplyBalance stores the status and amount paid derived from the ledger
+	ApplyBalance(invoiceID string, balance InvoiceBalance) (*PayableInvoice, error)
+}
+
+// EInvoiceRepository reads invoices for e-invoicing and keeps their e-invoice state
+type EInvoiceRepository interface {
+	Source(invoiceID string) (*EInvoiceSource, error)
+	// SaveState stores the state with its status and lookup code on the invoice
+	SaveState(invoiceID string, state *EInvoiceState) error
+}
+
+// AccountingRepository reads what the accounting journal is built from
+type AccountingRepository interface {
+	// Invoices issued in [from, to), cancelled ones excluded, with their lines and cost of parts
+	Invoices(from, to time.Time) ([]*AccountingInvoice, error)
+	// Counted payments and refunds paid in [from, to)
+	Payments(from, to time.Time) ([]*AccountingPayment, error)
+}
+
+// EInvoiceProvider is an e-invoice service provider (VNPT, Viettel, MISA
+// meInvoice...). It numbers, signs and registers the e-invoice with the tax
+// authority.
+type EInvoiceProvider interface {
+	Name() string
+	Submit(inv *EInvoice) (*EInvoiceReceipt, error)
+	Replace(original EInvoiceRef, inv *EInvoice) (*EInvoiceReceipt, error) // inv.Replaces is set
+	Cancel(ref EInvoiceRef, reason string) error
+	Download(ref EInvoiceRef) ([]byte, error) // XML of the issued e-invoice
+}
+
 type TimeSlotRepository interface {
 	GetByID(id string) (*TimeSlot, error)
 	Update(slot *TimeSlot) error
@@ -78,7 +232,9 @@ type BrandRepository interface {
 type TimeSlotControl interface {
 	ReleaseSlot(slotID string) error
 	BookSlot(slotID, bookingID string) error
-	CheckConflict(techID, date, timeStr string, durationMin int, newSlotID string) error
+	CheckConflict(techID, date, timeStr string, durationMin int, newSlotID, excludeBookingID string) error
+	// CheckReschedule checks the booking's technician is free at a new time (the booking itself aside)
+	CheckReschedule(booking *Booking, date, timeStr, newSlotID string) error
 }
 
 type AnalyticsRepository interface {
@@ -102,17 +258,162 @@ type NotificationService interface {
 	NotifyBookingCancelled(ctx context.Context, bookingID, customerName, reason, note string) error                                    // [NEW]
 	NotifyAdminsBookingCancelled(ctx context.Context, tokens []string, bookingID, customerName, reason, note string) ([]string, error) // [UPDATED] Return failed tokens
 	NotifyJobStatusChange(ctx context.Context, techToken string, jobID string, status string) error                                    // [NEW]
+	NotifyAdminsBookingRescheduled(ctx context.Context, tokens []string, bookingID, customerName, newTime string) ([]string, error)    // [NEW]
 }
 
 // BookingService defines business logic methods
 type BookingService interface {
 	CreateBooking(req *BookingRequest) (*Booking, error)
-	AssignTechnician(bookingID, technicianID string) error
-	RecallToPending(bookingID string) error
-	UpdateStatus(bookingID, status string) error
-	TechCheckIn(bookingID string, techLat, techLong float64) error
-	CancelBooking(bookingID, reason, note string) error
-	RescheduleBooking(bookingID, newTime string) error
+	// NewBooking and AnnounceBooking are CreateBooking without the save, for
+	// callers that save the booking with other records in one transaction
+	NewBooking(req *BookingRequest) (*Booking, error)
+	AnnounceBooking(booking *Booking)
+	AssignTechnician(bookingID, technicianID string, actor Actor) error
+	RecallToPending(bookingID string, actor Actor) error
+	UpdateStatus(bookingID, status string, actor Actor) error
+	// StageStatus and AnnounceStatus are UpdateStatus without the save, for
+	// callers that save the booking with other records in one transaction
+	StageStatus(booking *Booking, status string) (Transition, error)
+	AnnounceStatus(booking *Booking, t Transition, actor Actor)
+	TechCheckIn(bookingID string, techLat, techLong float64, actor Actor) error
+	CancelBooking(bookingID, reason, note string, actor Actor) error
+	RescheduleBooking(bookingID, newTime, newSlotID string, actor Actor) error // newSlotID optional
+}
+
+// TechScheduleService resolves when a technician can work and manages leave requests
+type TechScheduleService interface {
+	DaySchedule(techID, date string) (*DaySchedule, error)
+	GetWorkingHours(techID string) ([]*WorkingHours, error)
+	SetWorkingHours(techID string, hours []*WorkingHours) error
+
+	// Tech requests are pending until reviewed; admin entries are approved directly
+	RequestTimeOff(off *TimeOff, actor Actor) error
+	ReviewTimeOff(id string, approve bool, note string, actor Actor) error
+	PendingTimeOff() ([]*TimeOff, error)
+	TimeOffForTechnician(techID string) ([]*TimeOff, error)
+}
+
+// CustomerService dedups customers by phone and keeps their saved addresses
+type CustomerService interface {
+	// Resolve finds or creates the customer of a booking (ErrInvalidPhone on bad input)
+	Resolve(name, phone string, addr CustomerAddress) (*Customer, error)
+	Get(id string) (*Customer, error)
+	Search(query string, page int) ([]*Customer, int, error)
+	Update(id, name, email, notes string) (*Customer, error)
+	Bookings(id string) ([]*Booking, error)
+	Duplicates(id string) ([]*Customer, error)
+	Merge(primaryID, duplicateID string, actor Actor) (*Customer, error)
+}
+
+// EquipmentService manages the per-customer equipment registry
+type EquipmentService interface {
+	Get(id string) (*Equipment, error)
+	ForCustomer(customerID string) ([]*Equipment, error)
+	ForBooking(bookingID string) ([]*Equipment, error) // Units of the booking's customer
+	Register(eq *Equipment) error
+	RegisterForBooking(bookingID string, eq *Equipment) error
+	Update(eq *Equipment) error
+	History(id string) ([]*EquipmentServiceRecord, error)
+
+	// RecordService links a job report to the units serviced on the job
+	RecordService(bookingID, reportID string, equipmentIDs []string) error
+}
+
+// ContractService runs maintenance contracts and the bookings they generate
+type ContractService interface {
+	Get(id string) (*MaintenanceContract, error)
+	List(status string) ([]*MaintenanceContract, error)
+	ForCustomer(customerID string) ([]*MaintenanceContract, error)
+	Create(c *MaintenanceContract, actor Actor) error
+	SetStatus(id, status string, actor Actor) error
+	Renew(id string, months int, actor Actor) error
+	Alerts(now time.Time) ([]*MaintenanceContract, error) // Ending soon or expired recently
+
+	// Cron jobs (return the number of contracts handled)
+	GenerateDueBookings(now time.Time) (int, error)
+	SendReminders(now time.Time) (int, error)
+	ExpireEnded(now time.Time) (int, error)
+}
+
+// QuoteService builds quotes from the catalog and records the customer's decision
+type QuoteService interface {
+	Create(bookingID string, lines []QuoteLine, notes string, actor Actor) (*Quote, error)
+	Get(id string) (*Quote, error)
+	GetByHash(hash string) (*Quote, error)
+	ForBooking(bookingID string) ([]*Quote, error)
+	Approved(bookingID string) (*Quote, error) // Latest approved quote, nil if none
+	Decide(hash string, approve bool, reason string, signature *filesystem.File) (*Quote, error)
+}
+
+// ReconciliationService matches bank transfers to invoices and settles them.
+// Transactions it cannot match wait in the review queue (status unmatched).
+type ReconciliationService interface {
+	Ingest(txs []*BankTransaction) (*ReconcileResult, error)
+	List(status string) ([]*BankTransaction, error)
+	Resolve(txID, invoiceRef string, actor Actor) error // invoiceRef: invoice code or ID
+	Ignore(txID, note string, actor Actor) error
+}
+
+// PaymentService keeps the payments ledger of invoices. Payments are posted
+// at once; refunds and voids wait for an admin approval.
+type PaymentService interface {
+	Record(p *Payment, actor Actor) (*InvoiceBalance, error)
+	RequestRefund(refund *Payment, actor Actor) (*Payment, error)
+	RequestVoid(paymentID, note string, actor Actor) error
+	Approve(paymentID string, actor Actor) (*InvoiceBalance, error)
+	Reject(paymentID, note string, actor Actor) error
+	Ledger(invoiceID string) ([]*Payment, *InvoiceBalance, error)
+	Pending() ([]*Payment, error) // Refunds and voids awaiting approval
+}
+
+// PayrollService produces the monthly statements of technicians
+type PayrollService interface {
+	Generate(period, techID string) ([]*PayrollStatement, error) // techID "" = every technician
+	List(period string) ([]*PayrollStatement, error)
+	Get(id string) (*PayrollStatement, error)
+	ForTechnician(techID string) ([]*PayrollStatement, error)
+	Approve(id string, actor Actor) error
+	Adjustments(techID, period string) ([]*PayrollAdjustment, error)
+	AddAdjustment(a *PayrollAdjustment, actor Actor) (*PayrollStatement, error)
+	RemoveAdjustment(id string, actor Actor) (*PayrollStatement, error)
+}
+
+// EInvoiceService issues, cancels and replaces the e-invoice of an invoice
+type EInvoiceService interface {
+	State(invoiceID string) (*EInvoiceSource, error)
+	Submit(invoiceID string, buyer EInvoiceParty, actor Actor) (*EInvoiceState, error)
+	Cancel(invoiceID, reason string, actor Actor) (*EInvoiceState, error)
+	Replace(invoiceID string, buyer EInvoiceParty, reason string, actor Actor) (*EInvoiceState, error)
+	Download(invoiceID string) ([]byte, error) // XML of the current e-invoice
+}
+
+// AccountingService builds the journal entries handed to the accountant
+type AccountingService interface {
+	Journal(from, to time.Time) ([]JournalEntry, error)
+}
+
+// CashService tracks the cash technicians collect and hand in to the office
+type CashService interface {
+	Position(techID string) (*CashPosition, error)
+	Ledger(techID string, limit int) ([]CashEntry, *CashPosition, error) // Latest entries first
+	Positions() ([]*CashPosition, error)                                 // Every tech with cash or a pending handover
+	History(techID string) ([]*CashHandover, error)
+	PendingHandovers() ([]*CashHandover, error)
+	SubmitHandover(declared float64, note string, actor Actor) (*CashHandover, error)
+	ConfirmHandover(id string, received float64, note string, actor Actor) (*CashHandover, error)
+	RejectHandover(id, note string, actor Actor) error
+}
+
+// CustomerNotifier reaches customers outside the app (email for now).
+// Returns ErrNoContactChannel when the customer cannot be reached.
+type CustomerNotifier interface {
+	SendMaintenanceReminder(customer *Customer, booking *Booking) error
+}
+
+// DispatchService ranks technicians for a booking and auto-assigns stale pending jobs
+type DispatchService interface {
+	RankTechnicians(bookingID string) ([]*DispatchCandidate, error)
+	AutoAssignPending() (int, error) // Returns number of bookings assigned
 }
 
 // DTOs for Service Layer
@@ -130,4 +431,5 @@ type BookingRequest struct {
 	Lat            float64
 	Long           float64
 	Files          []*filesystem.File
+	ContractID     string // [NEW] Generated by a maintenance contract
 }
@@ -0,0 +1,373 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Purchase order statuses (purchase_orders.status)
+const (
+	PODraft     = "draft"              // Being prepared, not sent to the supplier
+	POOrdered   = "ordered"            // Sent, nothing received yet
+	POPartial   = "partially_received" // Some lines delivered
+	POReceived  = "received"           // Every line delivered in full
+	POCancelled = "cancelled"          // Remainder will not be delivered
+)
+
+// Document prefixes: PO-<year>-<seq> orders, PNK-<year>-<seq> goods receipts
+// (phiếu nhập kho)
+const (
+	PurchasePrefix = "PO"
+	ReceiptPrefix  = "PNK"
+)
+
+// ReorderFactor: a draft PO refills an item to this many times its minimum
+const ReorderFactor = 2
+
+var (
+	ErrInvalidSupplier        = errors.New("supplier name is required")
+	ErrInvalidPurchaseOrder   = errors.New("purchase order needs a supplier and lines with positive quantities")
+	ErrPurchaseOrderState     = errors.New("purchase order cannot do this in its current status")
+	ErrInvalidReceipt         = errors.New("receipt lines must be on the order with positive quantities")
+	ErrOverReceipt            = errors.New("received quantity exceeds what is still on order")
+	ErrInvalidSupplierInvoice = errors.New("supplier invoice needs a number and a positive amount")
+	ErrSupplierOverpayment    = errors.New("payment exceeds the invoice balance")
+)
+
+// FormatPurchaseCode builds a purchasing document number, e.g. PO-2026-00042
+func FormatPurchaseCode(prefix string, year, seq int) string {
+	return fmt.Sprintf("%s-%d-%05d", prefix, year, seq)
+}
+
+// Supplier is an entry of the supplier directory (suppliers)
+type Supplier struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	TaxCode      string `json:"tax_code"` // Mã số thuế
+	ContactName  string `json:"contact_name"`
+	Phone        string `json:"phone"`
+	Email        string `json:"email"`
+	Address      string `json:"address"`
+	PaymentTerms int    `json:"payment_terms"` // Days until a supplier invoice is due
+	Note         string `json:"note"`
+	Active       bool   `json:"active"`
+}
+
+// Validate checks the required fields
+func (s *Supplier) Validate() error {
+	if s.Name == "" || s.PaymentTerms < 0 {
+		return ErrInvalidSupplier
+	}
+	return nil
+}
+
+// PurchaseLine is one item of a purchase order
+type PurchaseLine struct {
+	ItemID    string  `json:"item_id"`
+	ItemName  string  `json:"item_name"`
+	Unit      string  `json:"unit"`
+	Quantity  float64 `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"` // Expected import price, before VAT
+	Received  float64 `json:"received"`
+}
+
+// Amount is the expected line value
+func (l *PurchaseLine) Amount() float64 {
+	return l.Quantity * l.UnitPrice
+}
+
+// Remaining is what the supplier still has to deliver
+func (l *PurchaseLine) Remaining() float64 {
+	return math.Max(0, l.Quantity-l.Received)
+}
+
+// PurchaseOrder is an order to a supplier (purchase_orders)
+type PurchaseOrder struct {
+	ID           string         `json:"id"`
+	Code         string         `json:"code"`
+	SupplierID   string         `json:"supplier_id"`
+	SupplierName string         `json:"supplier_name"`
+	Status       string         `json:"status"`
+	ExpectedDate string         `json:"expected_date"` // YYYY-MM-DD
+	Note         string         `json:"note"`
+	Lines        []PurchaseLine `json:"lines"`
+	CreatedBy    string         `json:"created_by"`
+	OrderedAt    string         `json:"ordered_at"`
+	Created      string         `json:"created"`
+}
+
+// Total is the expected order value
+func (po *PurchaseOrder) Total() float64 {
+	total := 0.0
+	for i := range po.Lines {
+		total += po.Lines[i].Amount()
+	}
+	return total
+}
+
+// Open reports whether goods can still arrive on the order
+func (po *PurchaseOrder) Open() bool {
+	return po.Status == POOrdered || po.Status == POPartial
+}
+
+// Validate checks the supplier and the lines: each item once, quantities
+// positive, prices not negative
+func (po *PurchaseOrder) Validate() error {
+	if po.SupplierID == "" || len(po.Lines) == 0 {
+		return ErrInvalidPurchaseOrder
+	}
+	seen := make(map[string]bool, len(po.Lines))
+	for _, l := range po.Lines {
+		if l.ItemID == "" || seen[l.ItemID] || l.Quantity <= 0 || l.UnitPrice < 0 {
+			return ErrInvalidPurchaseOrder
+		}
+		seen[l.ItemID] = true
+	}
+	return nil
+}
+
+// MarkOrdered sends a draft to the supplier
+func (po *PurchaseOrder) MarkOrdered(at time.Time) error {
+	if po.Status != PODraft {
+		return ErrPurchaseOrderState
+	}
+	po.Status = POOrdered
+	po.OrderedAt = at.UTC().Format(time.RFC3339)
+	return nil
+}
+
+// Cancel closes the order; goods already received stay in stock
+func (po *PurchaseOrder) Cancel() error {
+	if po.Status == POReceived || po.Status == POCancelled {
+		return ErrPurchaseOrderState
+	}
+	po.Status = POCancelled
+	return nil
+}
+
+// Receive books delivered quantities against the lines. Deliveries may be
+// partial but never more than is still on order; the status follows.
+func (po *PurchaseOrder) Receive(lines []ReceiptLine) error {
+	if !po.Open() {
+		return ErrPurchaseOrderState
+	}
+	if len(lines) == 0 {
+		return ErrInvalidReceipt
+	}
+
+	index := make(map[string]int, len(po.Lines))
+	for i, l := range po.Lines {
+		index[l.ItemID] = i
+	}
+	received := make(map[string]float64, len(lines))
+	for _, r := range lines {
+		i, ok := index[r.ItemID]
+		if !ok || r.Quantity <= 0 || r.UnitPrice < 0 {
+			return ErrInvalidReceipt
+		}
+		received[r.ItemID] += r.Quantity
+		if received[r.ItemID] > po.Lines[i].Remaining()+StockEpsilon {
+			return ErrOverReceipt
+		}
+	}
+
+	complete := true
+	for i := range po.Lines {
+		po.Lines[i].Received += received[po.Lines[i].ItemID]
+		if po.Lines[i].Remaining() > StockEpsilon {
+			complete = false
+		}
+	}
+	po.Status = POPartial
+	if complete {
+		po.Status = POReceived
+	}
+	return nil
+}
+
+// ReceiptLine is a quantity delivered at its actual price
+type ReceiptLine struct {
+	ItemID    string  `json:"item_id"`
+	ItemName  string  `json:"item_name"`
+	Unit      string  `json:"unit"`
+	Quantity  float64 `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"` // Actual import price, before VAT
+
+	Lots []LotAllocation `json:"lots,omitempty"` // Serials / lots received, for tracked items
+}
+
+// GoodsReceipt is one delivery posted into the main warehouse (goods_receipts)
+type GoodsReceipt struct {
+	ID         string        `json:"id"`
+	Code       string        `json:"code"`
+	POID       string        `json:"po_id"`
+	POCode     string        `json:"po_code"`
+	SupplierID string        `json:"supplier_id"`
+	Lines      []ReceiptLine `json:"lines"`
+	Note       string        `json:"note"`
+	ReceivedBy string        `json:"received_by"`
+	Created    string        `json:"created"`
+}
+
+// Total is the value of the delivery
+func (r *GoodsReceipt) Total() float64 {
+	total := 0.0
+	for _, l := range r.Lines {
+		total += l.Quantity * l.UnitPrice
+	}
+	return total
+}
+
+// SupplierInvoice is a bill received from a supplier (supplier_invoices)
+type SupplierInvoice struct {
+	ID           string  `json:"id"`
+	SupplierID   string  `json:"supplier_id"`
+	SupplierName string  `json:"supplier_name"`
+	POID         string  `json:"po_id"`
+	InvoiceNo    string  `json:"invoice_no"`
+	InvoiceDate  string  `json:"invoice_date"` // YYYY-MM-DD
+	DueDate      string  `json:"due_date"`     // YYYY-MM-DD
+	Amount       float64 `json:"amount"`       // VAT included
+	Paid         float64 `json:"paid"`
+	Status       string  `json:"status"` // InvoiceUnpaid, InvoicePartiallyPaid or InvoicePaid
+	Note         string  `json:"note"`
+	CreatedBy    string  `json:"created_by"`
+	Created      string  `json:"created"`
+}
+
+// Validate checks the invoice number and amount
+func (inv *SupplierInvoice) Validate() error {
+	if inv.SupplierID == "" || inv.InvoiceNo == "" || inv.Amount <= 0 {
+		return ErrInvalidSupplierInvoice
+	}
+	return nil
+}
+
+// Balance is what is still owed on the invoice
+func (inv *SupplierInvoice) Balance() float64 {
+	return math.Max(0, inv.Amount-inv.Paid)
+}
+
+// Overdue reports whether the due date has passed with money still owed
+func (inv *SupplierInvoice) Overdue(now time.Time) bool {
+	return inv.DueDate != "" && inv.Balance() > 0 && inv.DueDate < now.In(fiscalZone).Format("2006-01-02")
+}
+
+// Pay records a payment and updates the status
+func (inv *SupplierInvoice) Pay(amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidPayment
+	}
+	if amount > inv.Balance()+0.5 {
+		return ErrSupplierOverpayment
+	}
+	inv.Paid += amount
+	inv.Status = PayableStatus(inv.Amount, inv.Paid)
+	return nil
+}
+
+// PayableStatus derives a supplier invoice status from what has been paid
+func PayableStatus(amount, paid float64) string {
+	switch {
+	case paid <= 0:
+		return InvoiceUnpaid
+	case paid < amount-0.5:
+		return InvoicePartiallyPaid
+	default:
+		return InvoicePaid
+	}
+}
+
+// DueDate adds the supplier's payment terms to the invoice date; without a
+// valid date or terms there is no due date
+func DueDate(invoiceDate string, terms int) string {
+	d, err := time.Parse("2006-01-02", invoiceDate)
+	if err != nil || terms <= 0 {
+		return ""
+	}
+	return d.AddDate(0, 0, terms).Format("2006-01-02")
+}
+
+// Payable is what is owed to one supplier
+type Payable struct {
+	SupplierID   string  `json:"supplier_id"`
+	SupplierName string  `json:"supplier_name"`
+	Invoiced     float64 `json:"invoiced"`
+	Paid         float64 `json:"paid"`
+	Balance      float64 `json:"balance"`
+	Overdue      float64 `json:"overdue"` // Part of Balance past its due date
+	OpenInvoices int     `json:"open_invoices"`
+}
+
+// SummarizePayables totals the invoices per supplier, largest balance first
+func SummarizePayables(invoices []*SupplierInvoice, now time.Time) []*Payable {
+	bySupplier := map[string]*Payable{}
+	var out []*Payable
+	for _, inv := range invoices {
+		p := bySupplier[inv.SupplierID]
+		if p == nil {
+			p = &Payable{SupplierID: inv.SupplierID, SupplierName: inv.SupplierName}
+			bySupplier[inv.SupplierID] = p
+			out = append(out, p)
+		}
+		p.Invoiced += inv.Amount
+		p.Paid += inv.Paid
+		p.Balance += inv.Balance()
+		if inv.Balance() > 0 {
+			p.OpenInvoices++
+		}
+		if inv.Overdue(now) {
+			p.Overdue += inv.Balance()
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Balance > out[j].Balance })
+	return out
+}
+
+// ReorderCandidate is a low-stock item considered for a draft PO
+type ReorderCandidate struct {
+	ItemID       string  `json:"item_id"`
+	ItemName     string  `json:"item_name"`
+	Unit         string  `json:"unit"`
+	SupplierID   string  `json:"supplier_id"` // Preferred supplier, may be empty
+	Stock        float64 `json:"stock"`
+	MinThreshold float64 `json:"min_threshold"`
+	OnOrder      float64 `json:"on_order"` // Still to arrive on open POs
+	UnitPrice    float64 `json:"unit_price"`
+}
+
+// ReorderQuantity refills the item to ReorderFactor times its minimum,
+// counting what is already on order, in whole units
+func (c *ReorderCandidate) ReorderQuantity() float64 {
+	return math.Max(0, math.Ceil(c.MinThreshold*ReorderFactor-c.Stock-c.OnOrder-StockEpsilon))
+}
+
+// DraftPurchaseOrders groups the candidates into one draft per preferred
+// supplier. Items without a preferred supplier are returned as unassigned;
+// items already covered by open orders are left out.
+func DraftPurchaseOrders(candidates []ReorderCandidate) (orders []*PurchaseOrder, unassigned []ReorderCandidate) {
+	bySupplier := map[string]*PurchaseOrder{}
+	for _, c := range candidates {
+		qty := c.ReorderQuantity()
+		if qty <= 0 {
+			continue
+		}
+		if c.SupplierID == "" {
+			unassigned = append(unassigned, c)
+			continue
+		}
+		po := bySupplier[c.SupplierID]
+		if po == nil {
+			po = &PurchaseOrder{SupplierID: c.SupplierID, Status: PODraft}
+			bySupplier[c.SupplierID] = po
+			orders = append(orders, po)
+		}
+		po.Lines = append(po.Lines, PurchaseLine{
+			ItemID: c.ItemID, ItemName: c.ItemName, Unit: c.Unit, Quantity: qty, UnitPrice: c.UnitPrice,
+		})
+	}
+	return orders, unassigned
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPurchaseOrderReceive(t *testing.T) {
+	po := &PurchaseOrder{SupplierID: "s1", Status: PODraft, Lines: []PurchaseLine{
+		{ItemID: "pipe", Quantity: 50, UnitPrice: 40000},
+		{ItemID: "gas", Quantity: 2, UnitPrice: 1200000},
+	}}
+	if err := po.Validate(); err != nil {
+		t.Fatalf("valid order rejected: %v", err)
+	}
+	if got := po.Total(); got != 4400000 {
+		t.Errorf("total = %v; want 4400000", got)
+	}
+	if err := po.Receive([]ReceiptLine{{ItemID: "pipe", Quantity: 1}}); err != ErrPurchaseOrderState {
+		t.Errorf("draft accepted a receipt: %v", err)
+	}
+	if err := po.MarkOrdered(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := po.Receive([]ReceiptLine{{ItemID: "pipe", Quantity: 30, UnitPrice: 41000}}); err != nil {
+		t.Fatal(err)
+	}
+	if po.Status != POPartial || po.Lines[0].Remaining() != 20 {
+		t.Errorf("after first delivery: status %s, pipe remaining %v", po.Status, po.Lines[0].Remaining())
+	}
+
+	for _, bad := range [][]ReceiptLine{
+		{{ItemID: "pipe", Quantity: 21}},
+		{{ItemID: "pipe", Quantity: 15}, {ItemID: "pipe", Quantity: 6}},
+	} {
+		if err := po.Receive(bad); err != ErrOverReceipt {
+			t.Errorf("over-receipt %+v: err = %v", bad, err)
+		}
+	}
+	if err := po.Receive([]ReceiptLine{{ItemID: "coil", Quantity: 1}}); err != ErrInvalidReceipt {
+		t.Errorf("item not on order: err = %v", err)
+	}
+	if po.Lines[0].Received != 30 {
+		t.Errorf("rejected receipts changed the order: received %v", po.Lines[0].Received)
+	}
+
+	if err := po.Receive([]ReceiptLine{{ItemID: "pipe", Quantity: 20}, {ItemID: "gas", Quantity: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if po.Status != POReceived {
+		t.Errorf("status = %s; want received", po.Status)
+	}
+	if err := po.Cancel(); err != ErrPurchaseOrderState {
+		t.Errorf("received order cancelled: %v", err)
+	}
+}
+
+func TestPurchaseOrderValidate(t *testing.T) {
+	invalid := []*PurchaseOrder{
+		{Lines: []PurchaseLine{{ItemID: "pipe", Quantity: 1}}},
+		{SupplierID: "s1"},
+		{SupplierID: "s1", Lines: []PurchaseLine{{ItemID: "pipe", Quantity: 0}}},
+		{SupplierID: "s1", Lines: []PurchaseLine{{ItemID: "pipe", Quantity: 1}, {ItemID: "pipe", Quantity: 2}}},
+		{SupplierID: "s1", Lines: []PurchaseLine{{ItemID: "pipe", Quantity: 1, UnitPrice: -5}}},
+	}
+	for _, po := range invalid {
+		if err := po.Validate(); err != ErrInvalidPurchaseOrder {
+			t.Errorf("%+v accepted", po)
+		}
+	}
+}
+
+func TestSupplierPayables(t *testing.T) {
+	now := time.Date(2026, 3, 20, 10, 0, 0, 0, time.UTC)
+	a := &SupplierInvoice{SupplierID: "s1", SupplierName: "Điện lạnh A", InvoiceNo: "0001", Amount: 10000000, DueDate: "2026-03-10"}
+	b := &SupplierInvoice{SupplierID: "s1", SupplierName: "Điện lạnh A", InvoiceNo: "0002", Amount: 4000000, DueDate: "2026-04-10"}
+	c := &SupplierInvoice{SupplierID: "s2", SupplierName: "Ống đồng B", InvoiceNo: "77", Amount: 2000000}
+
+	if err := a.Pay(3000000); err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != InvoicePartiallyPaid || a.Balance() != 7000000 {
+		t.Errorf("after partial payment: %s, balance %v", a.Status, a.Balance())
+	}
+	if err := a.Pay(8000000); err != ErrSupplierOverpayment {
+		t.Errorf("overpayment: err = %v", err)
+	}
+	if err := c.Pay(2000000); err != nil || c.Status != InvoicePaid {
+		t.Errorf("full payment: %v, status %s", err, c.Status)
+	}
+
+	payables := SummarizePayables([]*SupplierInvoice{a, b, c}, now)
+	if len(payables) != 2 {
+		t.Fatalf("payables = %+v", payables)
+	}
+	s1 := payables[0]
+	if s1.SupplierID != "s1" || s1.Balance != 11000000 || s1.Overdue != 7000000 || s1.OpenInvoices != 2 {
+		t.Errorf("s1 payable = %+v", s1)
+	}
+	if payables[1].Balance != 0 || payables[1].OpenInvoices != 0 {
+		t.Errorf("s2 payable = %+v", payables[1])
+	}
+
+	if got := DueDate("2026-03-01", 30); got != "2026-03-31" {
+		t.Errorf("due date = %s", got)
+	}
+	if got := DueDate("2026-03-01", 0); got != "" {
+		t.Errorf("due date without terms = %s", got)
+	}
+}
+
+func TestDraftPurchaseOrders(t *testing.T) {
+	orders, unassigned := DraftPurchaseOrders([]ReorderCandidate{
+		{ItemID: "pipe", SupplierID: "s1", Stock: 3, MinThreshold: 10, UnitPrice: 40000},
+		{ItemID: "gas", SupplierID: "s2", Stock: 0.5, MinThreshold: 2},
+		{ItemID: "tape", SupplierID: "s1", Stock: 1, MinThreshold: 5, OnOrder: 4},
+		{ItemID: "coil", SupplierID: "s1", Stock: 0, MinThreshold: 3, OnOrder: 6},
+		{ItemID: "bolt", Stock: 0, MinThreshold: 100},
+	})
+
+	if len(orders) != 2 || orders[0].SupplierID != "s1" || orders[1].SupplierID != "s2" {
+		t.Fatalf("orders = %+v", orders)
+	}
+	s1 := orders[0].Lines
+	if len(s1) != 2 || s1[0].ItemID != "pipe" || s1[0].Quantity != 17 || s1[1].ItemID != "tape" || s1[1].Quantity != 5 {
+		t.Errorf("s1 lines = %+v", s1)
+	}
+	if q := orders[1].Lines[0].Quantity; q != 4 {
+		t.Errorf("gas quantity = %v; want 4", q)
+	}
+	if orders[0].Status != PODraft {
+		t.Errorf("status = %s", orders[0].Status)
+	}
+	if len(unassigned) != 1 || unassigned[0].ItemID != "bolt" {
+		t.Errorf("unassigned = %+v", unassigned)
+	}
+}
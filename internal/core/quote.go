@@ -0,0 +1,113 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Quote statuses (quotes.status)
+const (
+	QuotePending    = "pending" // Waiting for the customer's signature
+	QuoteApproved   = "approved"
+	QuoteRejected   = "rejected"
+	QuoteSuperseded = "superseded" // Replaced by a newer quote of the same job
+)
+
+// Quote line kinds
+const (
+	QuoteItemService = "service" // Labor, priced from services.price
+	QuoteItemPart    = "part"    // Material, priced from inventory_items.price
+)
+
+var (
+	ErrInvalidQuote      = errors.New("invalid quote")
+	ErrQuoteDecided      = errors.New("quote already approved or rejected")
+	ErrSignatureRequired = errors.New("customer signature required")
+)
+
+// Part is a sellable material from the inventory catalog (inventory_items)
+type Part struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	SKU      string  `json:"sku"`
+	Category string  `json:"category"`
+	Unit     string  `json:"unit"`
+	Price    float64 `json:"price"` // Selling price
+}
+
+// QuoteLine is what the tech picks; prices are filled from the catalog
+type QuoteLine struct {
+	Kind     string  `json:"kind"`
+	RefID    string  `json:"ref_id"`
+	Quantity float64 `json:"qty"`
+}
+
+// QuoteItem is a priced line frozen in the quote
+type QuoteItem struct {
+	Kind      string  `json:"kind"`
+	RefID     string  `json:"ref_id"`
+	Name      string  `json:"name"`
+	Unit      string  `json:"unit"`
+	Quantity  float64 `json:"qty"`
+	UnitPrice float64 `json:"unit_price"`
+	Total     float64 `json:"total"`
+}
+
+// Quote is an on-site price proposal the customer signs before work continues
+type Quote struct {
+	ID           string      `json:"id"`
+	BookingID    string      `json:"booking_id"`
+	TechnicianID string      `json:"technician_id"`
+	Status       string      `json:"status"`
+	Items        []QuoteItem `json:"items"`
+	LaborTotal   float64     `json:"labor_total"`
+	PartsTotal   float64     `json:"parts_total"`
+	Total        float64     `json:"total"`
+	Notes        string      `json:"notes"`
+	PublicHash   string      `json:"-"`
+	Signature    string      `json:"signature"` // File name of the customer signature
+	DecidedAt    string      `json:"decided_at"`
+	RejectReason string      `json:"reject_reason"`
+	Created      string      `json:"created"`
+}
+
+// Recalculate validates the lines and computes line and quote totals
+func (q *Quote) Recalculate() error {
+	if len(q.Items) == 0 {
+		return ErrInvalidQuote
+	}
+	q.LaborTotal, q.PartsTotal = 0, 0
+	for i := range q.Items {
+		it := &q.Items[i]
+		if it.Quantity <= 0 || it.UnitPrice < 0 {
+			return ErrInvalidQuote
+		}
+		it.Total = math.Round(it.Quantity * it.UnitPrice)
+		switch it.Kind {
+		case QuoteItemService:
+			q.LaborTotal += it.Total
+		case QuoteItemPart:
+			q.PartsTotal += it.Total
+		default:
+			return ErrInvalidQuote
+		}
+	}
+	q.Total = q.LaborTotal + q.PartsTotal
+	return nil
+}
+
+// Decide records the customer's answer; only pending quotes can be decided
+func (q *Quote) Decide(approve bool, reason string, at time.Time) error {
+	if q.Status != QuotePending {
+		return ErrQuoteDecided
+	}
+	q.Status = QuoteRejected
+	q.RejectReason = reason
+	if approve {
+		q.Status = QuoteApproved
+		q.RejectReason = ""
+	}
+	q.DecidedAt = at.UTC().Format(DateTimeLayout)
+	return nil
+}
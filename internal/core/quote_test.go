@@ -0,0 +1,64 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuoteRecalculate(t *testing.T) {
+	q := &Quote{Items: []QuoteItem{
+		{Kind: QuoteItemService, Quantity: 1, UnitPrice: 250000},
+		{Kind: QuoteItemPart, Quantity: 2.5, UnitPrice: 120000},
+		{Kind: QuoteItemPart, Quantity: 1, UnitPrice: 33333.4},
+	}}
+	if err := q.Recalculate(); err != nil {
+		t.Fatal(err)
+	}
+	if q.Items[1].Total != 300000 || q.Items[2].Total != 33333 {
+		t.Errorf("Line totals = %v, %v", q.Items[1].Total, q.Items[2].Total)
+	}
+	if q.LaborTotal != 250000 || q.PartsTotal != 333333 || q.Total != 583333 {
+		t.Errorf("Totals = %v + %v = %v", q.LaborTotal, q.PartsTotal, q.Total)
+	}
+
+	invalid := []*Quote{
+		{},
+		{Items: []QuoteItem{{Kind: QuoteItemPart, Quantity: 0, UnitPrice: 1000}}},
+		{Items: []QuoteItem{{Kind: QuoteItemPart, Quantity: 1, UnitPrice: -1}}},
+		{Items: []QuoteItem{{Kind: "discount", Quantity: 1, UnitPrice: 1000}}},
+	}
+	for i, q := range invalid {
+		if err := q.Recalculate(); !errors.Is(err, ErrInvalidQuote) {
+			t.Errorf("case %d: expected ErrInvalidQuote, got %v", i, err)
+		}
+	}
+}
+
+func TestQuoteDecide(t *testing.T) {
+	at := time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC)
+
+	q := &Quote{Status: QuotePending, RejectReason: "stale"}
+	if err := q.Decide(true, "ignored", at); err != nil {
+		t.Fatal(err)
+	}
+	if q.Status != QuoteApproved || q.RejectReason != "" || q.DecidedAt == "" {
+		t.Errorf("Approve result = %+v", q)
+	}
+	if err := q.Decide(false, "too expensive", at); !errors.Is(err, ErrQuoteDecided) {
+		t.Errorf("Decided quote must not change, got %v", err)
+	}
+
+	q = &Quote{Status: QuotePending}
+	if err := q.Decide(false, "too expensive", at); err != nil {
+		t.Fatal(err)
+	}
+	if q.Status != QuoteRejected || q.RejectReason != "too expensive" {
+		t.Errorf("Reject result = %+v", q)
+	}
+
+	q = &Quote{Status: QuoteSuperseded}
+	if err := q.Decide(true, "", at); !errors.Is(err, ErrQuoteDecided) {
+		t.Errorf("Superseded quote must not be decided, got %v", err)
+	}
+}
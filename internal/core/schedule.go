@@ -0,0 +1,216 @@
+package core
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Time-off kinds (tech_time_off.kind)
+const (
+	TimeOffLeave   = "leave"   // Nghỉ phép
+	TimeOffSick    = "sick"    // Nghỉ ốm
+	TimeOffHoliday = "holiday" // Nghỉ lễ (technician_id empty = whole company)
+)
+
+// Time-off request statuses (tech_time_off.status)
+const (
+	TimeOffPending  = "pending"
+	TimeOffApproved = "approved"
+	TimeOffRejected = "rejected"
+)
+
+var (
+	ErrInvalidTimeOff   = errors.New("invalid time off request")
+	ErrTimeOffReviewed  = errors.New("time off request already reviewed")
+	ErrInvalidWorkHours = errors.New("invalid working hours")
+	ErrScheduleConflict = errors.New("schedule conflict")
+)
+
+// WorkingHours is one weekly shift of a technician (several per weekday allowed)
+type WorkingHours struct {
+	ID           string `json:"id"`
+	TechnicianID string `json:"technician_id"`
+	Weekday      int    `json:"weekday"`    // 0 = Sunday ... 6 = Saturday (time.Weekday)
+	StartTime    string `json:"start_time"` // HH:MM
+	EndTime      string `json:"end_time"`   // HH:MM
+}
+
+// TimeOff is an exception to the weekly schedule: leave, sick day or holiday.
+// Empty StartTime/EndTime means the whole day on every date of the range.
+type TimeOff struct {
+	ID           string `json:"id"`
+	TechnicianID string `json:"technician_id"` // Empty = applies to every technician
+	Kind         string `json:"kind"`
+	DateFrom     string `json:"date_from"` // YYYY-MM-DD
+	DateTo       string `json:"date_to"`   // YYYY-MM-DD (inclusive)
+	StartTime    string `json:"start_time"`
+	EndTime      string `json:"end_time"`
+	Reason       string `json:"reason"`
+	Status       string `json:"status"`
+	RequestedBy  string `json:"requested_by"` // Actor type: tech/admin
+	ReviewedBy   string `json:"reviewed_by"`
+	ReviewNote   string `json:"review_note"`
+	ReviewedAt   string `json:"reviewed_at"`
+	Created      string `json:"created"`
+
+	TechnicianName string `json:"technician_name"` // Expanded for admin views
+}
+
+// Covers reports whether the time off range includes the given date (YYYY-MM-DD)
+func (t *TimeOff) Covers(date string) bool {
+	return t.DateFrom <= date && date <= t.DateTo
+}
+
+// FullDay reports whether the time off blocks whole days
+func (t *TimeOff) FullDay() bool {
+	return t.StartTime == "" || t.EndTime == ""
+}
+
+// Validate checks dates and the optional partial-day window
+func (t *TimeOff) Validate() error {
+	from, err := time.Parse("2006-01-02", t.DateFrom)
+	if err != nil {
+		return ErrInvalidTimeOff
+	}
+	to, err := time.Parse("2006-01-02", t.DateTo)
+	if err != nil || to.Before(from) {
+		return ErrInvalidTimeOff
+	}
+	switch t.Kind {
+	case TimeOffLeave, TimeOffSick, TimeOffHoliday:
+	default:
+		return ErrInvalidTimeOff
+	}
+	if !t.FullDay() && !validClockRange(t.StartTime, t.EndTime) {
+		return ErrInvalidTimeOff
+	}
+	return nil
+}
+
+// ValidateWorkingHours checks weekday and HH:MM range of each shift
+func ValidateWorkingHours(hours []*WorkingHours) error {
+	for _, h := range hours {
+		if h.Weekday < 0 || h.Weekday > 6 || !validClockRange(h.StartTime, h.EndTime) {
+			return ErrInvalidWorkHours
+		}
+	}
+	return nil
+}
+
+// TimeWindow is a continuous working period
+type TimeWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// DaySchedule is the resolved working time of one technician on one date
+type DaySchedule struct {
+	Date    string       `json:"date"`
+	Windows []TimeWindow `json:"windows"`
+	OffKind string       `json:"off_kind,omitempty"` // Set when a full-day time off applies
+}
+
+// Covers reports whether [start, end] lies inside one working window
+func (d *DaySchedule) Covers(start, end time.Time) bool {
+	for _, w := range d.Windows {
+		if !start.Before(w.Start) && !end.After(w.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// Off reports whether the technician does not work at all that day
+func (d *DaySchedule) Off() bool {
+	return len(d.Windows) == 0
+}
+
+// ResolveDaySchedule combines weekly hours and approved time off for a date.
+// A technician without any weekly hours configured works the whole day
+// (legacy behaviour: the slot grid limits the bookable hours).
+func ResolveDaySchedule(date string, hours []*WorkingHours, offs []*TimeOff) (*DaySchedule, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
+	}
+	schedule := &DaySchedule{Date: date}
+
+	if len(hours) == 0 {
+		schedule.Windows = []TimeWindow{{Start: day, End: day.Add(24 * time.Hour)}}
+	} else {
+		for _, h := range hours {
+			if time.Weekday(h.Weekday) != day.Weekday() {
+				continue
+			}
+			start, err1 := time.Parse("2006-01-02 15:04", date+" "+h.StartTime)
+			end, err2 := time.Parse("2006-01-02 15:04", date+" "+h.EndTime)
+			if err1 != nil || err2 != nil || !end.After(start) {
+				continue
+			}
+			schedule.Windows = append(schedule.Windows, TimeWindow{Start: start, End: end})
+		}
+		schedule.Windows = mergeWindows(schedule.Windows)
+	}
+
+	for _, off := range offs {
+		if off.Status != TimeOffApproved || !off.Covers(date) {
+			continue
+		}
+		if off.FullDay() {
+			schedule.Windows = nil
+			schedule.OffKind = off.Kind
+			break
+		}
+		start, err1 := time.Parse("2006-01-02 15:04", date+" "+off.StartTime)
+		end, err2 := time.Parse("2006-01-02 15:04", date+" "+off.EndTime)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		schedule.Windows = subtractWindow(schedule.Windows, TimeWindow{Start: start, End: end})
+	}
+
+	return schedule, nil
+}
+
+func mergeWindows(windows []TimeWindow) []TimeWindow {
+	if len(windows) < 2 {
+		return windows
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+	merged := []TimeWindow{windows[0]}
+	for _, w := range windows[1:] {
+		last := &merged[len(merged)-1]
+		if !w.Start.After(last.End) {
+			if w.End.After(last.End) {
+				last.End = w.End
+			}
+			continue
+		}
+		merged = append(merged, w)
+	}
+	return merged
+}
+
+func subtractWindow(windows []TimeWindow, cut TimeWindow) []TimeWindow {
+	var out []TimeWindow
+	for _, w := range windows {
+		if !cut.Start.Before(w.End) || !cut.End.After(w.Start) {
+			out = append(out, w) // No overlap
+			continue
+		}
+		if w.Start.Before(cut.Start) {
+			out = append(out, TimeWindow{Start: w.Start, End: cut.Start})
+		}
+		if cut.End.Before(w.End) {
+			out = append(out, TimeWindow{Start: cut.End, End: w.End})
+		}
+	}
+	return out
+}
+
+func validClockRange(start, end string) bool {
+	s, err1 := time.Parse("15:04", start)
+	e, err2 := time.Parse("15:04", end)
+	return err1 == nil && err2 == nil && e.After(s)
+}
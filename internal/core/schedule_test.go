@@ -0,0 +1,84 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func at(date, clock string) time.Time {
+	t, _ := time.Parse("2006-01-02 15:04", date+" "+clock)
+	return t
+}
+
+// 2026-03-02 is a Monday
+func TestResolveDaySchedule_WeeklyHours(t *testing.T) {
+	hours := []*WorkingHours{
+		{Weekday: 1, StartTime: "08:00", EndTime: "12:00"},
+		{Weekday: 1, StartTime: "13:00", EndTime: "17:00"},
+	}
+
+	day, err := ResolveDaySchedule("2026-03-02", hours, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !day.Covers(at("2026-03-02", "09:00"), at("2026-03-02", "11:00")) {
+		t.Error("Expected morning job to fit")
+	}
+	if day.Covers(at("2026-03-02", "11:00"), at("2026-03-02", "14:00")) {
+		t.Error("Job spanning the lunch break must not fit")
+	}
+
+	sunday, _ := ResolveDaySchedule("2026-03-01", hours, nil)
+	if !sunday.Off() {
+		t.Errorf("Expected day off on Sunday, got %+v", sunday.Windows)
+	}
+}
+
+func TestResolveDaySchedule_NoHoursMeansAllDay(t *testing.T) {
+	day, _ := ResolveDaySchedule("2026-03-01", nil, nil)
+	if !day.Covers(at("2026-03-01", "19:00"), at("2026-03-01", "21:00")) {
+		t.Error("Tech without weekly hours should be available all day")
+	}
+}
+
+func TestResolveDaySchedule_TimeOff(t *testing.T) {
+	hours := []*WorkingHours{{Weekday: 1, StartTime: "08:00", EndTime: "17:00"}}
+
+	pending := &TimeOff{Kind: TimeOffLeave, DateFrom: "2026-03-01", DateTo: "2026-03-03", Status: TimeOffPending}
+	day, _ := ResolveDaySchedule("2026-03-02", hours, []*TimeOff{pending})
+	if day.Off() {
+		t.Error("Pending leave must not block the schedule")
+	}
+
+	approved := &TimeOff{Kind: TimeOffSick, DateFrom: "2026-03-01", DateTo: "2026-03-03", Status: TimeOffApproved}
+	day, _ = ResolveDaySchedule("2026-03-02", hours, []*TimeOff{approved})
+	if !day.Off() || day.OffKind != TimeOffSick {
+		t.Errorf("Expected sick day, got %+v", day)
+	}
+
+	halfDay := &TimeOff{Kind: TimeOffLeave, DateFrom: "2026-03-02", DateTo: "2026-03-02", StartTime: "12:00", EndTime: "17:00", Status: TimeOffApproved}
+	day, _ = ResolveDaySchedule("2026-03-02", hours, []*TimeOff{halfDay})
+	if !day.Covers(at("2026-03-02", "08:00"), at("2026-03-02", "12:00")) {
+		t.Error("Morning should stay bookable")
+	}
+	if day.Covers(at("2026-03-02", "11:00"), at("2026-03-02", "13:00")) {
+		t.Error("Afternoon leave must block overlapping jobs")
+	}
+}
+
+func TestTimeOffValidate(t *testing.T) {
+	cases := []struct {
+		off   TimeOff
+		valid bool
+	}{
+		{TimeOff{Kind: TimeOffLeave, DateFrom: "2026-03-02", DateTo: "2026-03-02"}, true},
+		{TimeOff{Kind: TimeOffLeave, DateFrom: "2026-03-03", DateTo: "2026-03-02"}, false},
+		{TimeOff{Kind: "vacation", DateFrom: "2026-03-02", DateTo: "2026-03-02"}, false},
+		{TimeOff{Kind: TimeOffSick, DateFrom: "2026-03-02", DateTo: "2026-03-02", StartTime: "14:00", EndTime: "10:00"}, false},
+	}
+	for i, c := range cases {
+		if err := c.off.Validate(); (err == nil) != c.valid {
+			t.Errorf("case %d: valid=%v, err=%v", i, c.valid, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// StockMain is the main warehouse in stock_transfers.from_id / to_id. Any
+// other location is a technician ID (truck stock, tech_inventory).
+const StockMain = "main"
+
+// Stock movement types (stock_transfers.transfer_type). Every movement takes
+// Quantity out of FromID and puts it into ToID; an empty side is outside the
+// stock (supplier, customer job).
+const (
+	MoveImport     = "import"       // Supplier -> main
+	MoveMainToTech = "main_to_tech" // Main -> truck
+	MoveTechToMain = "tech_to_main" // Truck -> main
+	MoveTechToJob  = "tech_to_job"  // Truck -> customer job
+	MoveMainToJob  = "main_to_job"  // Main -> customer job
+	MoveOpening    = "opening"      // Balance when the ledger started, signed, into ToID
+	MoveAdjustment = "adjustment"   // Count correction, signed, into ToID
+)
+
+// StockEpsilon absorbs float rounding on fractional quantities (meters, kg)
+const StockEpsilon = 1e-6
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidMovement   = errors.New("invalid stock movement")
+)
+
+// StockMovement is one entry of the stock ledger (stock_transfers)
+type StockMovement struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"transfer_type"`
+	FromID    string          `json:"from_id"`
+	ToID      string          `json:"to_id"`
+	ItemID    string          `json:"item_id"`
+	Quantity  float64         `json:"quantity"`
+	UnitCost  float64         `json:"unit_cost"`      // Cost per unit it moved at (see DrawLayers)
+	Lots      []LotAllocation `json:"lots,omitempty"` // Serial / lot numbers moved (see NormalizeLots)
+	Note      string          `json:"note"`
+	CreatedBy string          `json:"created_by"`
+	JobID     string          `json:"job_id"`
+	Created   string          `json:"created"`
+}
+
+// Validate checks that the movement has an item, a location and a quantity
+// of the right sign: only openings and adjustments may be negative
+func (m *StockMovement) Validate() error {
+	if m.ItemID == "" || (m.FromID == "" && m.ToID == "") || m.FromID == m.ToID {
+		return ErrInvalidMovement
+	}
+	switch m.Type {
+	case MoveImport, MoveMainToTech, MoveTechToMain, MoveTechToJob, MoveMainToJob:
+		if m.Quantity <= 0 {
+			return ErrInvalidMovement
+		}
+	case MoveOpening, MoveAdjustment:
+		if m.Quantity == 0 || m.FromID != "" {
+			return ErrInvalidMovement
+		}
+	default:
+		return ErrInvalidMovement
+	}
+	return nil
+}
+
+// StockKey identifies the balance of an item at a location
+type StockKey struct {
+	ItemID   string
+	Location string
+}
+
+// StockDelta is the change a movement makes to one balance
+type StockDelta struct {
+	Key      StockKey
+	Quantity float64
+}
+
+// Deltas lists the balances the movement changes, the outgoing side first
+func (m *StockMovement) Deltas() []StockDelta {
+	var deltas []StockDelta
+	if m.FromID != "" {
+		deltas = append(deltas, StockDelta{Key: StockKey{m.ItemID, m.FromID}, Quantity: -m.Quantity})
+	}
+	if m.ToID != "" {
+		deltas = append(deltas, StockDelta{Key: StockKey{m.ItemID, m.ToID}, Quantity: m.Quantity})
+	}
+	return deltas
+}
+
+// StockBalances replays the ledger into the balance of every item and location
+func StockBalances(movements []*StockMovement) map[StockKey]float64 {
+	balances := make(map[StockKey]float64)
+	for _, m := range movements {
+		for _, d := range m.Deltas() {
+			balances[d.Key] += d.Quantity
+		}
+	}
+	return balances
+}
+
+// StockDiscrepancy is a stored balance that differs from the ledger
+type StockDiscrepancy struct {
+	ItemID   string  `json:"item_id"`
+	ItemName string  `json:"item_name"`
+	Location string  `json:"location"` // StockMain or a technician ID
+	Recorded float64 `json:"recorded"` // inventory_items.stock_quantity / tech_inventory.quantity
+	Ledger   float64 `json:"ledger"`
+}
+
+// Difference is what the stored balance has too much (negative: too little)
+func (d StockDiscrepancy) Difference() float64 {
+	return d.Recorded - d.Ledger
+}
+
+// CompareStock lists the balances where the stored quantity and the ledger
+// disagree, by item then location
+func CompareStock(recorded, ledger map[StockKey]float64) []StockDiscrepancy {
+	keys := make(map[StockKey]bool, len(recorded)+len(ledger))
+	for k := range recorded {
+		keys[k] = true
+	}
+	for k := range ledger {
+		keys[k] = true
+	}
+
+	var out []StockDiscrepancy
+	for k := range keys {
+		if math.Abs(recorded[k]-ledger[k]) > StockEpsilon {
+			out = append(out, StockDiscrepancy{ItemID: k.ItemID, Location: k.Location, Recorded: recorded[k], Ledger: ledger[k]})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ItemID != out[j].ItemID {
+			return out[i].ItemID < out[j].ItemID
+		}
+		return out[i].Location < out[j].Location
+	})
+	return out
+}
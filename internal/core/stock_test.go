@@ -0,0 +1,71 @@
+package core
+
+import "testing"
+
+func TestStockMovementValidate(t *testing.T) {
+	valid := []*StockMovement{
+		{Type: MoveImport, ToID: StockMain, ItemID: "i1", Quantity: 10},
+		{Type: MoveMainToTech, FromID: StockMain, ToID: "t1", ItemID: "i1", Quantity: 2},
+		{Type: MoveTechToJob, FromID: "t1", ItemID: "i1", Quantity: 0.5},
+		{Type: MoveAdjustment, ToID: "t1", ItemID: "i1", Quantity: -1},
+	}
+	for _, m := range valid {
+		if err := m.Validate(); err != nil {
+			t.Errorf("%s rejected: %v", m.Type, err)
+		}
+	}
+
+	invalid := []*StockMovement{
+		{Type: MoveImport, ToID: StockMain, ItemID: "i1", Quantity: -3},
+		{Type: MoveMainToTech, FromID: StockMain, ToID: StockMain, ItemID: "i1", Quantity: 1},
+		{Type: MoveTechToJob, ItemID: "i1", Quantity: 1},
+		{Type: MoveAdjustment, ToID: "t1", ItemID: "i1", Quantity: 0},
+		{Type: MoveOpening, FromID: StockMain, ToID: "t1", ItemID: "i1", Quantity: 1},
+		{Type: "gift", ToID: "t1", ItemID: "i1", Quantity: 1},
+		{Type: MoveImport, ToID: StockMain, Quantity: 1},
+	}
+	for _, m := range invalid {
+		if err := m.Validate(); err != ErrInvalidMovement {
+			t.Errorf("%+v accepted", m)
+		}
+	}
+}
+
+func TestStockBalances(t *testing.T) {
+	ledger := StockBalances([]*StockMovement{
+		{Type: MoveOpening, ToID: StockMain, ItemID: "pipe", Quantity: 20},
+		{Type: MoveImport, ToID: StockMain, ItemID: "pipe", Quantity: 30},
+		{Type: MoveMainToTech, FromID: StockMain, ToID: "t1", ItemID: "pipe", Quantity: 12},
+		{Type: MoveTechToJob, FromID: "t1", ItemID: "pipe", Quantity: 3.5},
+		{Type: MoveTechToMain, FromID: "t1", ToID: StockMain, ItemID: "pipe", Quantity: 2},
+		{Type: MoveMainToJob, FromID: StockMain, ItemID: "gas", Quantity: 1},
+		{Type: MoveAdjustment, ToID: "t1", ItemID: "pipe", Quantity: -0.5},
+	})
+
+	if got := ledger[StockKey{"pipe", StockMain}]; got != 40 {
+		t.Errorf("main pipe = %v; want 40", got)
+	}
+	if got := ledger[StockKey{"pipe", "t1"}]; got != 6 {
+		t.Errorf("truck pipe = %v; want 6", got)
+	}
+	if got := ledger[StockKey{"gas", StockMain}]; got != -1 {
+		t.Errorf("main gas = %v; want -1", got)
+	}
+
+	recorded := map[StockKey]float64{
+		{"pipe", StockMain}: 40,
+		{"pipe", "t1"}:      7,
+		{"gas", StockMain}:  -1 + 1e-9,
+		{"coil", "t2"}:      1,
+	}
+	diffs := CompareStock(recorded, ledger)
+	if len(diffs) != 2 {
+		t.Fatalf("discrepancies = %+v; want truck pipe and coil", diffs)
+	}
+	if diffs[0].ItemID != "coil" || diffs[0].Difference() != 1 {
+		t.Errorf("first discrepancy = %+v", diffs[0])
+	}
+	if diffs[1].Location != "t1" || diffs[1].Difference() != 1 {
+		t.Errorf("second discrepancy = %+v", diffs[1])
+	}
+}
@@ -0,0 +1,302 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Stocktake statuses (stocktakes.status)
+const (
+	StocktakeCounting  = "counting"  // Snapshot taken, counts being entered
+	StocktakeSubmitted = "submitted" // Every line counted, waiting for approval
+	StocktakeApproved  = "approved"  // Variances posted to the ledger
+	StocktakeCancelled = "cancelled"
+)
+
+// StocktakePrefix numbers stocktakes KK-<year>-<seq> (phiếu kiểm kê)
+const StocktakePrefix = "KK"
+
+var (
+	ErrInvalidStocktake    = errors.New("stocktake needs a location and at least one item")
+	ErrStocktakeOpen       = errors.New("a stocktake is already in progress at this location")
+	ErrStocktakeState      = errors.New("stocktake cannot do this in its current status")
+	ErrInvalidCount        = errors.New("counted quantity must be zero or more, for an item on the stocktake")
+	ErrStocktakeIncomplete = errors.New("every item must be counted before submitting")
+)
+
+// StocktakeLine is one item of a stocktake. Expected and UnitCost are frozen
+// at the snapshot; on approval Expected is moved forward by what the ledger
+// moved since (see Rebase), so the count is measured against the book stock.
+type StocktakeLine struct {
+	ItemID    string  `json:"item_id"`
+	ItemName  string  `json:"item_name"`
+	Unit      string  `json:"unit"`
+	Expected  float64 `json:"expected"` // Balance at the snapshot, at approval once rebased
+	Counted   float64 `json:"counted"`
+	IsCounted bool    `json:"is_counted"`
+	UnitCost  float64 `json:"unit_cost"` // Average cost at the location at the snapshot
+	Tracking  string  `json:"tracking,omitempty"`
+	// Serial / lot numbers of the variance of a tracked item: the missing ones
+	// for a shortage, the ones found for a surplus
+	Lots []LotAllocation `json:"lots,omitempty"`
+}
+
+// Variance is what was found too much (negative: missing); 0 until counted
+func (l StocktakeLine) Variance() float64 {
+	if !l.IsCounted || math.Abs(l.Counted-l.Expected) <= StockEpsilon {
+		return 0
+	}
+	return l.Counted - l.Expected
+}
+
+// VarianceValue is the variance at cost
+func (l StocktakeLine) VarianceValue() float64 {
+	return l.Variance() * l.UnitCost
+}
+
+// Stocktake is a count of the main warehouse or one truck (stocktakes)
+type Stocktake struct {
+	ID           string          `json:"id"`
+	Code         string          `json:"code"`
+	Location     string          `json:"location"` // StockMain or a technician ID
+	LocationName string          `json:"location_name"`
+	Status       string          `json:"status"`
+	Note         string          `json:"note"`
+	Lines        []StocktakeLine `json:"lines"`
+	CreatedBy    string          `json:"created_by"`
+	CountedBy    string          `json:"counted_by"`
+	SubmittedAt  string          `json:"submitted_at"`
+	ApprovedBy   string          `json:"approved_by"`
+	ApprovedAt   string          `json:"approved_at"`
+	Created      string          `json:"created"` // Snapshot time
+}
+
+// Open reports whether counts can still change
+func (s *Stocktake) Open() bool {
+	return s.Status == StocktakeCounting || s.Status == StocktakeSubmitted
+}
+
+// Validate checks the location and that each item is listed once
+func (s *Stocktake) Validate() error {
+	if s.Location == "" || len(s.Lines) == 0 {
+		return ErrInvalidStocktake
+	}
+	seen := make(map[string]bool, len(s.Lines))
+	for _, l := range s.Lines {
+		if l.ItemID == "" || seen[l.ItemID] {
+			return ErrInvalidStocktake
+		}
+		seen[l.ItemID] = true
+	}
+	return nil
+}
+
+// Count records the counted quantity of an item on the stocktake. Counts can
+// be corrected until the stocktake is approved.
+func (s *Stocktake) Count(itemID string, qty float64, by string) error {
+	if !s.Open() {
+		return ErrStocktakeState
+	}
+	if qty < 0 {
+		return ErrInvalidCount
+	}
+	for i := range s.Lines {
+		if s.Lines[i].ItemID == itemID {
+			s.Lines[i].Counted = qty
+			s.Lines[i].IsCounted = true
+			s.CountedBy = by
+			return nil
+		}
+	}
+	return ErrInvalidCount
+}
+
+// SetLots records the serial / lot numbers of an item's variance. They can be
+// corrected until the stocktake is approved.
+func (s *Stocktake) SetLots(itemID string, lots []LotAllocation) error {
+	if !s.Open() {
+		return ErrStocktakeState
+	}
+	for i := range s.Lines {
+		if s.Lines[i].ItemID == itemID {
+			s.Lines[i].Lots = lots
+			return nil
+		}
+	}
+	return ErrInvalidCount
+}
+
+// AddLine adds an item found during the count that the snapshot did not list
+func (s *Stocktake) AddLine(l StocktakeLine) error {
+	if !s.Open() {
+		return ErrStocktakeState
+	}
+	for _, existing := range s.Lines {
+		if existing.ItemID == l.ItemID {
+			return ErrInvalidStocktake
+		}
+	}
+	s.Lines = append(s.Lines, l)
+	return nil
+}
+
+// Submit hands the counts in for approval
+func (s *Stocktake) Submit(at string) error {
+	if s.Status != StocktakeCounting {
+		return ErrStocktakeState
+	}
+	if s.CountedLines() < len(s.Lines) {
+		return ErrStocktakeIncomplete
+	}
+	s.Status = StocktakeSubmitted
+	s.SubmittedAt = at
+	return nil
+}
+
+// Approve closes a submitted stocktake; post Adjustments with it
+func (s *Stocktake) Approve(by, at string) error {
+	if s.Status != StocktakeSubmitted {
+		return ErrStocktakeState
+	}
+	s.Status = StocktakeApproved
+	s.ApprovedBy = by
+	s.ApprovedAt = at
+	return nil
+}
+
+// Cancel drops a stocktake that was not approved
+func (s *Stocktake) Cancel() error {
+	if !s.Open() {
+		return ErrStocktakeState
+	}
+	s.Status = StocktakeCancelled
+	return nil
+}
+
+// CountedLines is the number of items counted so far
+func (s *Stocktake) CountedLines() int {
+	n := 0
+	for _, l := range s.Lines {
+		if l.IsCounted {
+			n++
+		}
+	}
+	return n
+}
+
+// VarianceLines is the number of items whose count differs from the snapshot
+func (s *Stocktake) VarianceLines() int {
+	n := 0
+	for _, l := range s.Lines {
+		if l.Variance() != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Shortage is the value of what is missing, as a positive amount
+func (s *Stocktake) Shortage() float64 {
+	total := 0.0
+	for _, l := range s.Lines {
+		if v := l.VarianceValue(); v < 0 {
+			total -= v
+		}
+	}
+	return total
+}
+
+// Surplus is the value of what was found too much
+func (s *Stocktake) Surplus() float64 {
+	total := 0.0
+	for _, l := range s.Lines {
+		if v := l.VarianceValue(); v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+// Rebase adds the net ledger movement of each item at the location since the
+// snapshot to its expected balance. Stock used or transferred during the count
+// is already in the ledger and must not be adjusted a second time.
+func (s *Stocktake) Rebase(moved map[string]float64) {
+	for i := range s.Lines {
+		s.Lines[i].Expected += moved[s.Lines[i].ItemID]
+	}
+}
+
+// CheckTracking makes sure every tracked item with a variance names its serial
+// or lot numbers, so the adjustment moves them with the quantity
+func (s *Stocktake) CheckTracking() error {
+	for _, l := range s.Lines {
+		if NormalizeTrackingMode(l.Tracking) != "" && l.Variance() != 0 && len(l.Lots) == 0 {
+			return fmt.Errorf("%w: %s", ErrTrackingRequired, l.ItemName)
+		}
+	}
+	return nil
+}
+
+// Adjustments are the ledger movements that bring the stock to the count
+func (s *Stocktake) Adjustments(by string) []*StockMovement {
+	var out []*StockMovement
+	for _, l := range s.Lines {
+		if v := l.Variance(); v != 0 {
+			out = append(out, &StockMovement{
+				Type: MoveAdjustment, ToID: s.Location, ItemID: l.ItemID, Quantity: v, Lots: l.Lots,
+				UnitCost: l.UnitCost, Note: "Kiểm kê " + s.Code, CreatedBy: by,
+			})
+		}
+	}
+	return out
+}
+
+// StocktakeHistory sums the approved stocktakes of one location
+type StocktakeHistory struct {
+	Location     string  `json:"location"`
+	LocationName string  `json:"location_name"`
+	Stocktakes   int     `json:"stocktakes"`
+	Shortage     float64 `json:"shortage"`
+	Surplus      float64 `json:"surplus"`
+	LastCode     string  `json:"last_code"`
+	LastAt       string  `json:"last_at"` // Approval time of the latest stocktake
+}
+
+// Net is the overall variance value (negative: stock lost)
+func (h StocktakeHistory) Net() float64 {
+	return h.Surplus - h.Shortage
+}
+
+// SummarizeStocktakes totals the approved stocktakes per location: the main
+// warehouse first, then the trucks that lost the most
+func SummarizeStocktakes(takes []*Stocktake) []StocktakeHistory {
+	index := map[string]int{}
+	var out []StocktakeHistory
+	for _, s := range takes {
+		if s.Status != StocktakeApproved {
+			continue
+		}
+		i, ok := index[s.Location]
+		if !ok {
+			i = len(out)
+			index[s.Location] = i
+			out = append(out, StocktakeHistory{Location: s.Location, LocationName: s.LocationName})
+		}
+		h := &out[i]
+		h.Stocktakes++
+		h.Shortage += s.Shortage()
+		h.Surplus += s.Surplus()
+		if s.ApprovedAt > h.LastAt {
+			h.LastAt, h.LastCode = s.ApprovedAt, s.Code
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if (out[i].Location == StockMain) != (out[j].Location == StockMain) {
+			return out[i].Location == StockMain
+		}
+		return out[i].Net() < out[j].Net()
+	})
+	return out
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func truckStocktake() *Stocktake {
+	return &Stocktake{Code: "KK-2026-00001", Location: "t1", Status: StocktakeCounting, Lines: []StocktakeLine{
+		{ItemID: "pipe", Expected: 10, UnitCost: 45000},
+		{ItemID: "gas", Expected: 2, UnitCost: 1200000},
+		{ItemID: "tape", Expected: 5, UnitCost: 8000},
+	}}
+}
+
+func TestStocktakeCountAndApprove(t *testing.T) {
+	s := truckStocktake()
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Count("pipe", -1, "t1"); err != ErrInvalidCount {
+		t.Errorf("negative count: err = %v", err)
+	}
+	if err := s.Count("coil", 1, "t1"); err != ErrInvalidCount {
+		t.Errorf("item not on stocktake: err = %v", err)
+	}
+
+	s.Count("pipe", 8.5, "t1")
+	s.Count("gas", 2, "t1")
+	if err := s.Submit("now"); err != ErrStocktakeIncomplete {
+		t.Errorf("submit with tape uncounted: err = %v", err)
+	}
+	if err := s.AddLine(StocktakeLine{ItemID: "valve", UnitCost: 150000}); err != nil {
+		t.Fatal(err)
+	}
+	s.Count("tape", 6, "t1")
+	s.Count("valve", 1, "t1")
+	if err := s.Submit("now"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.VarianceLines(); got != 3 {
+		t.Errorf("variance lines = %d; want 3", got)
+	}
+	if got := s.Shortage(); got != 1.5*45000 {
+		t.Errorf("shortage = %v", got)
+	}
+	if got := s.Surplus(); got != 8000+150000 {
+		t.Errorf("surplus = %v", got)
+	}
+
+	moves := s.Adjustments("admin")
+	if len(moves) != 3 {
+		t.Fatalf("adjustments = %+v", moves)
+	}
+	for _, m := range moves {
+		if err := m.Validate(); err != nil {
+			t.Errorf("adjustment %+v invalid: %v", m, err)
+		}
+	}
+	if m := moves[0]; m.ItemID != "pipe" || m.Quantity != -1.5 || m.ToID != "t1" || m.Type != MoveAdjustment {
+		t.Errorf("pipe adjustment = %+v", m)
+	}
+
+	if err := s.Approve("admin", "now"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Count("pipe", 10, "t1"); err != ErrStocktakeState {
+		t.Errorf("count after approval: err = %v", err)
+	}
+	if err := s.Cancel(); err != ErrStocktakeState {
+		t.Errorf("cancel after approval: err = %v", err)
+	}
+}
+
+func TestStocktakeRebase(t *testing.T) {
+	s := truckStocktake()
+	s.Count("pipe", 7, "t1")
+	s.Count("gas", 2, "t1")
+	s.Count("tape", 5, "t1")
+	s.Status = StocktakeSubmitted
+
+	// 3m of pipe used on a job and a cylinder of gas added after the snapshot
+	s.Rebase(map[string]float64{"pipe": -3, "gas": 1, "coil": 4})
+	moves := s.Adjustments("admin")
+	if len(moves) != 1 || moves[0].ItemID != "gas" || moves[0].Quantity != -1 {
+		t.Errorf("adjustments after rebase = %+v", moves)
+	}
+	if got := s.Lines[0].Expected; got != 7 {
+		t.Errorf("pipe expected = %v; want 7", got)
+	}
+}
+
+func TestStocktakeTracking(t *testing.T) {
+	s := truckStocktake()
+	s.Lines[1].Tracking = TrackSerial
+	s.Count("pipe", 10, "t1")
+	s.Count("gas", 1, "t1")
+	s.Count("tape", 5, "t1")
+	if err := s.CheckTracking(); !errors.Is(err, ErrTrackingRequired) {
+		t.Errorf("missing gas cylinder without its serial: err = %v", err)
+	}
+
+	lots := []LotAllocation{{Number: "GAS-002", Quantity: 1}}
+	if err := s.SetLots("gas", lots); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckTracking(); err != nil {
+		t.Errorf("with the missing serial: err = %v", err)
+	}
+	moves := s.Adjustments("admin")
+	if len(moves) != 1 || len(moves[0].Lots) != 1 || moves[0].Lots[0].Number != "GAS-002" {
+		t.Errorf("adjustments = %+v", moves)
+	}
+
+	// A tracked item that matches the book needs no numbers
+	s.Count("gas", 2, "t1")
+	s.SetLots("gas", nil)
+	if err := s.CheckTracking(); err != nil {
+		t.Errorf("no variance: err = %v", err)
+	}
+}
+
+func TestSummarizeStocktakes(t *testing.T) {
+	lossy := truckStocktake()
+	for _, l := range lossy.Lines {
+		lossy.Count(l.ItemID, l.Expected, "t1")
+	}
+	lossy.Count("gas", 1, "t1")
+	lossy.Status, lossy.ApprovedAt = StocktakeApproved, "2026-03-31"
+
+	main := &Stocktake{Code: "KK-2026-00002", Location: StockMain, Status: StocktakeApproved, ApprovedAt: "2026-03-30",
+		Lines: []StocktakeLine{{ItemID: "pipe", Expected: 100, Counted: 101, IsCounted: true, UnitCost: 45000}}}
+	other := &Stocktake{Location: "t2", Status: StocktakeApproved, ApprovedAt: "2026-03-29",
+		Lines: []StocktakeLine{{ItemID: "tape", Expected: 3, Counted: 2, IsCounted: true, UnitCost: 8000}}}
+	cancelled := truckStocktake()
+	cancelled.Count("gas", 0, "t1")
+	cancelled.Status = StocktakeCancelled
+
+	history := SummarizeStocktakes([]*Stocktake{other, lossy, cancelled, main})
+	if len(history) != 3 {
+		t.Fatalf("history = %+v", history)
+	}
+	if history[0].Location != StockMain || history[1].Location != "t1" || history[2].Location != "t2" {
+		t.Errorf("order = %s, %s, %s", history[0].Location, history[1].Location, history[2].Location)
+	}
+	if h := history[1]; h.Stocktakes != 1 || h.Shortage != 1200000 || h.Net() != -1200000 || h.LastCode != "KK-2026-00001" {
+		t.Errorf("t1 history = %+v", h)
+	}
+}
@@ -0,0 +1,85 @@
+package core
+
+import "time"
+
+// Slot statuses offered when booking (TimeSlot status)
+const (
+	SlotAvailable = "available" // Two or more technicians free for the whole job
+	SlotLimited   = "limited"   // The last free technician
+	SlotWaitlist  = "waitlist"  // Nobody free, overbooking accepted
+	SlotFull      = "full"
+)
+
+// SlotOverbooking is how many bookings a slot takes on the waitlist beyond
+// the technicians working that day
+const SlotOverbooking = 2
+
+// TimeBlock is a period a technician is busy, travel included
+type TimeBlock struct {
+	Start time.Time
+	End   time.Time
+}
+
+// BusyBlock is the period a job starting at start keeps its technician busy:
+// the job itself with the travel buffer on both sides
+func BusyBlock(start time.Time, duration, buffer time.Duration) TimeBlock {
+	return TimeBlock{Start: start.Add(-buffer), End: start.Add(duration + buffer)}
+}
+
+// Overlaps reports whether [start, end) intersects the block; touching ends
+// do not overlap
+func (b TimeBlock) Overlaps(start, end time.Time) bool {
+	return start.Before(b.End) && end.After(b.Start)
+}
+
+// OverlapsAny reports whether [start, end) intersects one of the blocks
+func OverlapsAny(start, end time.Time, blocks []TimeBlock) bool {
+	for _, block := range blocks {
+		if block.Overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// FreeCapacity is how many technicians are left for a job in [start, end):
+// the free ones, less one per unassigned booking overlapping it (each will
+// take a technician), never below zero
+func FreeCapacity(freeTechs int, start, end time.Time, unassigned []TimeBlock) int {
+	for _, block := range unassigned {
+		if block.Overlaps(start, end) {
+			freeTechs--
+		}
+	}
+	if freeTechs < 0 {
+		return 0
+	}
+	return freeTechs
+}
+
+// ClassifySlot gives the status of a slot from the technicians free for the
+// whole job. With nobody free a job that fits in the day goes on the waitlist
+// while the slot has fewer than capacity + SlotOverbooking bookings.
+func ClassifySlot(free, booked, capacity int, fitsDay bool) (status string, available bool) {
+	switch {
+	case free >= 2:
+		return SlotAvailable, true
+	case free == 1:
+		return SlotLimited, true
+	case fitsDay && booked < capacity+SlotOverbooking:
+		return SlotWaitlist, true
+	default:
+		return SlotFull, false
+	}
+}
+
+// ParseBookingTime accepts the booking_time formats found in existing data
+func ParseBookingTime(raw string) (time.Time, bool) {
+	formats := []string{"2006-01-02 15:04", "2006-01-02 15:04:05", DateTimeLayout, time.RFC3339}
+	for _, f := range formats {
+		if t, err := time.Parse(f, raw); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+const slotDay = "2026-03-10"
+
+func TestBusyBlockOverlap(t *testing.T) {
+	// 09:00 job of 60 minutes with a 30 minute buffer: busy 08:30-10:30
+	block := BusyBlock(at(slotDay, "09:00"), time.Hour, 30*time.Minute)
+	cases := []struct {
+		start, end string
+		want       bool
+	}{
+		{"07:00", "08:30", false}, // Ends as the buffer starts
+		{"07:30", "08:45", true},
+		{"10:00", "11:00", true},
+		{"10:30", "11:30", false}, // Starts as the buffer ends
+		{"08:00", "12:00", true},  // Covers the whole job
+	}
+	for _, c := range cases {
+		if got := block.Overlaps(at(slotDay, c.start), at(slotDay, c.end)); got != c.want {
+			t.Errorf("Overlaps(%s-%s) = %v; want %v", c.start, c.end, got, c.want)
+		}
+	}
+	if OverlapsAny(at(slotDay, "11:00"), at(slotDay, "12:00"), []TimeBlock{block}) {
+		t.Error("11:00-12:00 must be free")
+	}
+}
+
+func TestFreeCapacity(t *testing.T) {
+	unassigned := []TimeBlock{
+		BusyBlock(at(slotDay, "09:00"), time.Hour, 0),
+		BusyBlock(at(slotDay, "09:30"), time.Hour, 0),
+	}
+	cases := []struct {
+		free       int
+		start, end string
+		want       int
+	}{
+		{3, "08:00", "09:00", 3}, // Before both
+		{3, "09:00", "10:00", 1}, // Both unassigned jobs take a tech
+		{3, "10:00", "11:00", 2},
+		{1, "09:00", "10:00", 0}, // Never below zero
+	}
+	for _, c := range cases {
+		if got := FreeCapacity(c.free, at(slotDay, c.start), at(slotDay, c.end), unassigned); got != c.want {
+			t.Errorf("FreeCapacity(%d, %s-%s) = %d; want %d", c.free, c.start, c.end, got, c.want)
+		}
+	}
+}
+
+func TestClassifySlot(t *testing.T) {
+	cases := []struct {
+		free, booked, capacity int
+		fitsDay                bool
+		status                 string
+		available              bool
+	}{
+		{3, 0, 3, true, SlotAvailable, true},
+		{1, 2, 3, true, SlotLimited, true},
+		{0, 4, 3, true, SlotWaitlist, true},
+		{0, 5, 3, true, SlotFull, false},  // Capacity + overbooking reached
+		{0, 0, 3, false, SlotFull, false}, // Job runs past the day
+	}
+	for _, c := range cases {
+		status, available := ClassifySlot(c.free, c.booked, c.capacity, c.fitsDay)
+		if status != c.status || available != c.available {
+			t.Errorf("ClassifySlot(%d, %d, %d, %v) = %s, %v; want %s, %v",
+				c.free, c.booked, c.capacity, c.fitsDay, status, available, c.status, c.available)
+		}
+	}
+}
+
+func TestParseBookingTime(t *testing.T) {
+	for _, raw := range []string{"2026-03-10 09:00", "2026-03-10 09:00:00", "2026-03-10 09:00:00.000Z", "2026-03-10T09:00:00Z"} {
+		if got, ok := ParseBookingTime(raw); !ok || !got.Equal(at(slotDay, "09:00")) {
+			t.Errorf("ParseBookingTime(%q) = %s, %v", raw, got, ok)
+		}
+	}
+	if _, ok := ParseBookingTime("10/03/2026"); ok {
+		t.Error("unknown format must not parse")
+	}
+}
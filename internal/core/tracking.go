@@ -0,0 +1,152 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tracking modes (inventory_items.tracking); empty means untracked
+const (
+	TrackSerial = "serial" // One number per unit: AC units, compressors under warranty
+	TrackLot    = "lot"    // Batch number shared by many units: refrigerant cylinders
+)
+
+// Tracked lot statuses (stock_lots.status)
+const (
+	LotInStock   = "in_stock"
+	LotInstalled = "installed" // Serial fitted at a customer's place
+	LotLost      = "lost"      // Serial missing at a stocktake
+)
+
+var (
+	ErrTrackingRequired = errors.New("serial or lot numbers are required for this item")
+	ErrTrackingMismatch = errors.New("serial or lot numbers do not add up to the quantity")
+	ErrUntrackedItem    = errors.New("item is not tracked by serial or lot")
+	ErrDuplicateSerial  = errors.New("serial number is already in stock")
+	ErrLotNotInStock    = errors.New("serial or lot number is not in stock at this location")
+)
+
+// NormalizeTrackingMode keeps the known modes, anything else is untracked
+func NormalizeTrackingMode(mode string) string {
+	switch mode {
+	case TrackSerial, TrackLot:
+		return mode
+	}
+	return ""
+}
+
+// LotAllocation is the part of a movement that carries one serial or lot
+// number. A serial always stands for one unit.
+type LotAllocation struct {
+	Number   string  `json:"number"`
+	Quantity float64 `json:"quantity"`
+}
+
+// RequiresTracking reports whether the movement must name its serials or
+// lots: receipts, transfers and consumption do; openings and count
+// adjustments may.
+func (m *StockMovement) RequiresTracking() bool {
+	return m.Type != MoveOpening && m.Type != MoveAdjustment
+}
+
+// NormalizeLots checks the numbers given for qty units of an item tracked by
+// mode and returns them cleaned: upper-cased, serials at one unit each and
+// repeated lot numbers merged. Untracked items take no numbers.
+func NormalizeLots(mode string, qty float64, lots []LotAllocation) ([]LotAllocation, error) {
+	qty = math.Abs(qty)
+	if NormalizeTrackingMode(mode) == "" {
+		if len(lots) > 0 {
+			return nil, ErrUntrackedItem
+		}
+		return nil, nil
+	}
+	if len(lots) == 0 {
+		return nil, ErrTrackingRequired
+	}
+
+	var out []LotAllocation
+	index := map[string]int{}
+	total := 0.0
+	for _, l := range lots {
+		number := strings.ToUpper(strings.TrimSpace(l.Number))
+		if number == "" {
+			return nil, ErrTrackingRequired
+		}
+		if mode == TrackSerial {
+			if _, dup := index[number]; dup {
+				return nil, ErrTrackingMismatch
+			}
+			l.Quantity = 1
+		} else if l.Quantity <= 0 {
+			return nil, ErrTrackingMismatch
+		}
+		total += l.Quantity
+		if i, ok := index[number]; ok {
+			out[i].Quantity += l.Quantity
+			continue
+		}
+		index[number] = len(out)
+		out = append(out, LotAllocation{Number: number, Quantity: l.Quantity})
+	}
+	if math.Abs(total-qty) > StockEpsilon {
+		return nil, ErrTrackingMismatch
+	}
+	return out, nil
+}
+
+// ParseLots reads numbers typed in a form, one per line or separated by
+// commas or semicolons. "NUMBER:QTY" gives the quantity of a lot; without it
+// the quantity is 0, to be filled by FillLotQuantity.
+func ParseLots(text string) []LotAllocation {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == ',' || r == ';'
+	})
+	var lots []LotAllocation
+	for _, f := range fields {
+		number, qty := strings.TrimSpace(f), 0.0
+		if i := strings.LastIndex(number, ":"); i > 0 {
+			if n, err := strconv.ParseFloat(strings.TrimSpace(number[i+1:]), 64); err == nil {
+				number, qty = strings.TrimSpace(number[:i]), n
+			}
+		}
+		if number != "" {
+			lots = append(lots, LotAllocation{Number: number, Quantity: qty})
+		}
+	}
+	return lots
+}
+
+// FillLotQuantity gives a single lot typed without a quantity the whole
+// quantity of the movement
+func FillLotQuantity(lots []LotAllocation, qty float64) []LotAllocation {
+	if len(lots) == 1 && lots[0].Quantity == 0 {
+		lots[0].Quantity = qty
+	}
+	return lots
+}
+
+// TrackedLot is a serial or lot number of an item and where it is
+// (stock_lots). A lot split over several locations has one entry per
+// location; an installed serial has left the stock.
+type TrackedLot struct {
+	ID           string  `json:"id"`
+	ItemID       string  `json:"item_id"`
+	ItemName     string  `json:"item_name"`
+	Tracking     string  `json:"tracking"`
+	Number       string  `json:"number"`
+	Location     string  `json:"location"` // StockMain or a technician ID; empty once installed
+	LocationName string  `json:"location_name"`
+	Quantity     float64 `json:"quantity"`
+	Status       string  `json:"status"`
+	ReceivedAt   string  `json:"received_at"`
+	JobID        string  `json:"job_id"`
+	JobReportID  string  `json:"job_report_id"`
+	EquipmentID  string  `json:"equipment_id"` // Customer unit the serial went into
+	InstalledAt  string  `json:"installed_at"`
+
+	EquipmentName string `json:"equipment_name"`
+	CustomerID    string `json:"customer_id"`
+	CustomerName  string `json:"customer_name"`
+}
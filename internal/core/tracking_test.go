@@ -0,0 +1,62 @@
+package core
+
+import "testing"
+
+func TestNormalizeLots(t *testing.T) {
+	lots, err := NormalizeLots(TrackSerial, 2, []LotAllocation{{Number: " sn-001 "}, {Number: "SN-002", Quantity: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lots[0].Number != "SN-001" || lots[0].Quantity != 1 || lots[1].Quantity != 1 {
+		t.Errorf("serials = %+v", lots)
+	}
+	if _, err := NormalizeLots(TrackSerial, 3, lots); err != ErrTrackingMismatch {
+		t.Errorf("2 serials for 3 units: err = %v", err)
+	}
+	if _, err := NormalizeLots(TrackSerial, 2, []LotAllocation{{Number: "A"}, {Number: "a"}}); err != ErrTrackingMismatch {
+		t.Errorf("repeated serial: err = %v", err)
+	}
+	if _, err := NormalizeLots(TrackSerial, 1, nil); err != ErrTrackingRequired {
+		t.Errorf("no serial: err = %v", err)
+	}
+
+	lots, err = NormalizeLots(TrackLot, -3.5, []LotAllocation{{Number: "L1", Quantity: 2}, {Number: "l1", Quantity: 1}, {Number: "L2", Quantity: 0.5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lots) != 2 || lots[0].Quantity != 3 || lots[1].Number != "L2" {
+		t.Errorf("lots = %+v", lots)
+	}
+	if _, err := NormalizeLots(TrackLot, 2, []LotAllocation{{Number: "L1", Quantity: 0}}); err != ErrTrackingMismatch {
+		t.Errorf("lot without quantity: err = %v", err)
+	}
+
+	if lots, err := NormalizeLots("", 4, nil); err != nil || lots != nil {
+		t.Errorf("untracked = %+v, %v", lots, err)
+	}
+	if _, err := NormalizeLots("", 1, []LotAllocation{{Number: "X", Quantity: 1}}); err != ErrUntrackedItem {
+		t.Errorf("numbers on untracked item: err = %v", err)
+	}
+}
+
+func TestParseLots(t *testing.T) {
+	lots := ParseLots("SN1, SN2\nL-2026:2.5;\r\n ")
+	if len(lots) != 3 || lots[1].Number != "SN2" || lots[2].Number != "L-2026" || lots[2].Quantity != 2.5 {
+		t.Errorf("lots = %+v", lots)
+	}
+	if lots := FillLotQuantity(ParseLots("R32-0425"), 4); lots[0].Quantity != 4 {
+		t.Errorf("single lot = %+v", lots)
+	}
+	if lots := ParseLots("A:B"); len(lots) != 1 || lots[0].Number != "A:B" {
+		t.Errorf("colon in number = %+v", lots)
+	}
+}
+
+func TestMovementRequiresTracking(t *testing.T) {
+	for typ, want := range map[string]bool{MoveImport: true, MoveMainToTech: true, MoveTechToJob: true, MoveAdjustment: false, MoveOpening: false} {
+		m := &StockMovement{Type: typ}
+		if got := m.RequiresTracking(); got != want {
+			t.Errorf("%s requires tracking = %v", typ, got)
+		}
+	}
+}
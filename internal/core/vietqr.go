@@ -0,0 +1,108 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// VietQR (NAPAS 247) constants from the EMVCo merchant-presented QR spec
+const (
+	vietQRGUID        = "A000000727" // NAPAS application ID
+	vietQRToAccount   = "QRIBFTTA"   // Transfer to a bank account (QRIBFTTC = to a card)
+	vietQRCurrencyVND = "704"
+	vietQRCountry     = "VN"
+	vietQRMemoMax     = 25 // Longest memo every bank app keeps intact
+)
+
+var ErrVietQRAccount = errors.New("vietqr: bank BIN and account number are required")
+
+// VietQR is a bank transfer request scanned by Vietnamese banking apps
+type VietQR struct {
+	BankBin string  // 6-digit NAPAS BIN of the receiving bank
+	Account string  // Receiving account number
+	Amount  float64 // VND, 0 lets the payer type the amount
+	Memo    string  // Transfer content, shown on the bank statement
+}
+
+// InvoiceTransferMemo is the transfer content identifying an invoice payment
+func InvoiceTransferMemo(invoiceCode string) string {
+	return "THANH TOAN " + invoiceCode
+}
+
+// Payload builds the EMVCo string encoded in the QR image (CRC included)
+func (q VietQR) Payload() (string, error) {
+	bin := strings.TrimSpace(q.BankBin)
+	account := strings.ReplaceAll(strings.TrimSpace(q.Account), " ", "")
+	if bin == "" || account == "" {
+		return "", ErrVietQRAccount
+	}
+
+	beneficiary := emvField("00", bin) + emvField("01", account)
+	merchant := emvField("00", vietQRGUID) + emvField("01", beneficiary) + emvField("02", vietQRToAccount)
+
+	initiation := "11" // Static: the payer enters the amount
+	if q.Amount > 0 {
+		initiation = "12"
+	}
+
+	var b strings.Builder
+	b.WriteString(emvField("00", "01"))
+	b.WriteString(emvField("01", initiation))
+	b.WriteString(emvField("38", merchant))
+	b.WriteString(emvField("53", vietQRCurrencyVND))
+	if q.Amount > 0 {
+		b.WriteString(emvField("54", strconv.FormatInt(int64(q.Amount+0.5), 10)))
+	}
+	b.WriteString(emvField("58", vietQRCountry))
+	if memo := VietQRMemo(q.Memo); memo != "" {
+		b.WriteString(emvField("62", emvField("08", memo)))
+	}
+	b.WriteString("6304")
+	return b.String() + fmt.Sprintf("%04X", crc16CCITT(b.String())), nil
+}
+
+// VietQRMemo keeps what every bank accepts in the transfer content:
+// uppercase ASCII letters, digits, space and '-', at most 25 characters.
+func VietQRMemo(memo string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(memo) {
+		switch {
+		case r == 'đ' || r == 'Đ':
+			b.WriteRune('D')
+		case unicode.Is(unicode.Mn, r):
+			// Drop the combining diacritics left by NFD
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '-'):
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	out := strings.Join(strings.Fields(b.String()), " ")
+	if len(out) > vietQRMemoMax {
+		out = strings.TrimSpace(out[:vietQRMemoMax])
+	}
+	return out
+}
+
+func emvField(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// crc16CCITT is CRC-16/CCITT-FALSE (poly 0x1021, init 0xFFFF) required by EMVCo
+func crc16CCITT(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
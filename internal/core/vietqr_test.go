@@ -0,0 +1,59 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCRC16CCITT(t *testing.T) {
+	// Standard check value of CRC-16/CCITT-FALSE
+	if got := crc16CCITT("123456789"); got != 0x29B1 {
+		t.Errorf("crc16CCITT = %04X; want 29B1", got)
+	}
+}
+
+func TestVietQRPayload(t *testing.T) {
+	payload, err := VietQR{BankBin: "970436", Account: "0123 456789", Amount: 540000, Memo: "THANH TOAN HD-2026-000001"}.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPrefix := "000201" + "010212" +
+		"3854" + "0010A000000727" + "0124" + "0006970436" + "01100123456789" + "0208QRIBFTTA" +
+		"5303704" + "5406540000" + "5802VN" +
+		"6229" + "0825THANH TOAN HD-2026-000001" + "6304"
+	if !strings.HasPrefix(payload, wantPrefix) {
+		t.Fatalf("payload = %s\nwant prefix %s", payload, wantPrefix)
+	}
+	if len(payload) != len(wantPrefix)+4 {
+		t.Fatalf("payload must end with a 4 digit CRC: %s", payload)
+	}
+	if crc := payload[len(payload)-4:]; crc != strings.ToUpper(crc) {
+		t.Errorf("CRC must be uppercase hex: %s", crc)
+	}
+}
+
+func TestVietQRPayloadWithoutAmount(t *testing.T) {
+	payload, err := VietQR{BankBin: "970436", Account: "0123456789"}.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(payload, "010211") || strings.Contains(payload, "5406") || strings.Contains(payload, "6229") {
+		t.Errorf("static QR must have no amount or memo: %s", payload)
+	}
+	if _, err := (VietQR{BankBin: "970436"}).Payload(); err != ErrVietQRAccount {
+		t.Errorf("missing account: err = %v", err)
+	}
+}
+
+func TestVietQRMemo(t *testing.T) {
+	cases := map[string]string{
+		"Thanh toán HĐ-2026-000001":    "THANH TOAN HD-2026-000001",
+		"  điện  lạnh #12! ":           "DIEN LANH 12",
+		"THANH TOAN HD-2026-000001 XX": "THANH TOAN HD-2026-000001",
+	}
+	for in, want := range cases {
+		if got := VietQRMemo(in); got != want {
+			t.Errorf("VietQRMemo(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
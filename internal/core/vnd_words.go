@@ -0,0 +1,90 @@
+package core
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var vnDigits = []string{"không", "một", "hai", "ba", "bốn", "năm", "sáu", "bảy", "tám", "chín"}
+
+// VNDInWords spells an amount the way it is printed on invoices
+// ("Một triệu hai trăm linh năm nghìn đồng"). Amounts are rounded to whole dong.
+func VNDInWords(amount float64) string {
+	words := "không"
+	if n := int64(math.Round(math.Abs(amount))); n > 0 {
+		words = readVND(n, false)
+	}
+	if math.Round(amount) < 0 {
+		words = "âm " + words
+	}
+	r, size := utf8.DecodeRuneInString(words)
+	return string(unicode.ToUpper(r)) + words[size:] + " đồng"
+}
+
+// readVND reads n > 0 by groups of three digits; above a billion the
+// number of billions is read on its own ("một nghìn năm trăm tỷ")
+func readVND(n int64, full bool) string {
+	const billion = 1000000000
+	if n >= billion {
+		words := readVND(n/billion, full) + " tỷ"
+		if low := n % billion; low > 0 {
+			words += " " + readVND(low, true)
+		}
+		return words
+	}
+
+	var parts []string
+	scales := []string{"triệu", "nghìn", ""}
+	for i, div := range []int64{1000000, 1000, 1} {
+		group := n / div % 1000
+		if group == 0 {
+			continue
+		}
+		parts = append(parts, readTriple(group, full || len(parts) > 0))
+		if scales[i] != "" {
+			parts = append(parts, scales[i])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// readTriple reads 0-999; full adds "không trăm" / "linh" inside a longer number
+func readTriple(n int64, full bool) string {
+	h, t, u := n/100, n/10%10, n%10
+	var parts []string
+	if full || h > 0 {
+		parts = append(parts, vnDigits[h], "trăm")
+	}
+	switch {
+	case t == 0:
+		if u > 0 && len(parts) > 0 {
+			parts = append(parts, "linh")
+		}
+		if u > 0 {
+			parts = append(parts, vnDigits[u])
+		}
+	case t == 1:
+		parts = append(parts, "mười")
+		if u == 5 {
+			parts = append(parts, "lăm")
+		} else if u > 0 {
+			parts = append(parts, vnDigits[u])
+		}
+	default:
+		parts = append(parts, vnDigits[t], "mươi")
+		switch u {
+		case 0:
+		case 1:
+			parts = append(parts, "mốt")
+		case 4:
+			parts = append(parts, "tư")
+		case 5:
+			parts = append(parts, "lăm")
+		default:
+			parts = append(parts, vnDigits[u])
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,23 @@
+package core
+
+import "testing"
+
+func TestVNDInWords(t *testing.T) {
+	cases := map[float64]string{
+		0:             "Không đồng",
+		15:            "Mười lăm đồng",
+		21:            "Hai mươi mốt đồng",
+		105:           "Một trăm linh năm đồng",
+		1205000:       "Một triệu hai trăm linh năm nghìn đồng",
+		2354000:       "Hai triệu ba trăm năm mươi tư nghìn đồng",
+		1000005:       "Một triệu không trăm linh năm đồng",
+		3000000000:    "Ba tỷ đồng",
+		1500000000000: "Một nghìn năm trăm tỷ đồng",
+		-50000:        "Âm năm mươi nghìn đồng",
+	}
+	for amount, want := range cases {
+		if got := VNDInWords(amount); got != want {
+			t.Errorf("VNDInWords(%.0f) = %q; want %q", amount, got, want)
+		}
+	}
+}
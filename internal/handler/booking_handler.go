@@ -24,7 +24,11 @@ func (h *BookingHandler) AssignJob(e *pbCore.RequestEvent) error {
 		return e.JSON(400, map[string]string{"error": "Missing booking ID or technician ID"})
 	}
 
-	if err := h.service.AssignTechnician(bookingID, techID); err != nil {
+	if err := h.service.AssignTechnician(bookingID, techID, adminActor(e)); err != nil {
+		var te *domain.TransitionError
+		if errors.As(err, &te) {
+			return e.JSON(409, map[string]string{"error": te.Message(), "current_status": te.From})
+		}
 		return e.JSON(500, map[string]string{"error": err.Error()})
 	}
 
@@ -40,19 +44,23 @@ func (h *BookingHandler) UpdateStatus(e *pbCore.RequestEvent) error {
 		return e.JSON(400, map[string]string{"error": "Missing information"})
 	}
 
-	if status == "pending" {
-		if err := h.service.RecallToPending(id); err != nil {
-			return e.JSON(500, map[string]string{"error": err.Error()})
-		}
-		return e.JSON(200, map[string]string{"message": "Recalled to pending"})
-	}
-
-	if err := h.service.UpdateStatus(id, status); err != nil {
-		if errors.Is(err, errors.New("invalid status")) { // Simplified error check
-			return e.JSON(400, map[string]string{"error": err.Error()})
+	if err := h.service.UpdateStatus(id, status, adminActor(e)); err != nil {
+		var te *domain.TransitionError
+		if errors.As(err, &te) {
+			return e.JSON(409, map[string]string{"error": te.Message(), "current_status": te.From})
 		}
 		return e.JSON(500, map[string]string{"error": err.Error()})
 	}
 
 	return e.JSON(200, map[string]string{"message": "Status updated"})
 }
+
+// adminActor identifies the logged-in admin for the booking timeline
+func adminActor(e *pbCore.RequestEvent) domain.Actor {
+	actor := domain.Actor{Type: domain.ActorAdmin, Source: "api"}
+	if e.Auth != nil {
+		actor.ID = e.Auth.Id
+		actor.Name = e.Auth.GetString("email")
+	}
+	return actor
+}
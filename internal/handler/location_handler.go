@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"hvac-system/internal/core"
 	"hvac-system/pkg/broker"
 	"hvac-system/pkg/cache"
@@ -14,6 +15,7 @@ import (
 type LocationHandler struct {
 	locationCache  *cache.LocationCache
 	bookingRepo    core.BookingRepository
+	bookingService core.BookingService // Status changes go through the lifecycle
 	techRepo       core.TechnicianRepository
 	broker         *broker.SegmentedBroker
 	geofenceRadius float64 // Default 100 meters
@@ -22,12 +24,14 @@ type LocationHandler struct {
 func NewLocationHandler(
 	locationCache *cache.LocationCache,
 	bookingRepo core.BookingRepository,
+	bookingService core.BookingService,
 	techRepo core.TechnicianRepository,
 	broker *broker.SegmentedBroker,
 ) *LocationHandler {
 	return &LocationHandler{
 		locationCache:  locationCache,
 		bookingRepo:    bookingRepo,
+		bookingService: bookingService,
 		techRepo:       techRepo,
 		broker:         broker,
 		geofenceRadius: 100.0, // 100 meters default
@@ -196,12 +200,12 @@ func (h *LocationHandler) UpdateLocation(e *pbCore.RequestEvent) error {
 	}
 
 	// ============ GEOFENCE CHECK: ARRIVED DETECTION ============
-	if arrived && booking != nil && booking.JobStatus == "moving" {
+	if arrived && booking != nil && booking.JobStatus == core.StatusMoving {
 		log.Printf("✅ [GEOFENCE] Tech %s has ARRIVED at booking %s (distance: %.2f m)",
 			req.TechnicianID, req.BookingID, distance)
 
 		// Update booking status to "arrived"
-		if err := h.bookingRepo.UpdateStatus(req.BookingID, "arrived"); err != nil {
+		if err := h.bookingService.UpdateStatus(req.BookingID, core.StatusArrived, core.SystemActor("geofence")); err != nil {
 			log.Printf("❌ Failed to update booking status: %v", err)
 		}
 
@@ -292,7 +296,11 @@ func (h *LocationHandler) StartTracking(e *pbCore.RequestEvent) error {
 	h.locationCache.UpdateTechStatus(techID, "moving")
 
 	// Update booking status
-	if err := h.bookingRepo.UpdateStatus(bookingID, "moving"); err != nil {
+	if err := h.bookingService.UpdateStatus(bookingID, core.StatusMoving, core.Actor{Type: core.ActorTech, ID: techID, Source: "tracking"}); err != nil {
+		var te *core.TransitionError
+		if errors.As(err, &te) {
+			return e.JSON(409, map[string]interface{}{"error": te.Message(), "current_status": te.From})
+		}
 		return e.JSON(500, map[string]string{"error": err.Error()})
 	}
 
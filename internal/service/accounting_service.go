@@ -0,0 +1,34 @@
+package service
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+	"time"
+)
+
+// AccountingService turns invoices and the payments ledger into journal
+// entries, so the accountant imports them instead of re-typing the dashboard
+type AccountingService struct {
+	repo  core.AccountingRepository
+	chart core.ChartOfAccounts
+}
+
+func NewAccountingService(repo core.AccountingRepository) core.AccountingService {
+	return &AccountingService{repo: repo, chart: core.DefaultChartOfAccounts}
+}
+
+// Journal posts everything issued or paid in [from, to)
+func (s *AccountingService) Journal(from, to time.Time) ([]core.JournalEntry, error) {
+	if !from.Before(to) {
+		return nil, core.ErrInvalidAccountingRange
+	}
+	invoices, err := s.repo.Invoices(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch invoices: %w", err)
+	}
+	payments, err := s.repo.Payments(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch payments: %w", err)
+	}
+	return core.BuildJournal(invoices, payments, s.chart), nil
+}
@@ -18,6 +18,9 @@ type BookingService struct {
 	notifications core.NotificationService
 	settingsRepo  core.SettingsRepository // [NEW]
 	broker        *broker.SegmentedBroker
+	lifecycle     *core.BookingStateMachine   // Single source of truth for job_status
+	eventRepo     core.BookingEventRepository // [NEW] Persistent timeline
+	customers     core.CustomerService        // [NEW] Dedup by phone
 }
 
 func NewBookingService(
@@ -28,6 +31,8 @@ func NewBookingService(
 	notifications core.NotificationService,
 	settingsRepo core.SettingsRepository, // [NEW]
 	eventBroker *broker.SegmentedBroker,
+	eventRepo core.BookingEventRepository, // [NEW]
+	customers core.CustomerService, // [NEW]
 ) core.BookingService {
 	return &BookingService{
 		bookingRepo:   bookingRepo,
@@ -37,10 +42,27 @@ func NewBookingService(
 		notifications: notifications,
 		settingsRepo:  settingsRepo,
 		broker:        eventBroker,
+		lifecycle:     core.BookingLifecycle,
+		eventRepo:     eventRepo,
+		customers:     customers,
 	}
 }
 
 func (s *BookingService) CreateBooking(req *core.BookingRequest) (*core.Booking, error) {
+	booking, err := s.NewBooking(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.bookingRepo.Create(booking, req.Files); err != nil {
+		return nil, err
+	}
+	s.AnnounceBooking(booking)
+	return booking, nil
+}
+
+// NewBooking builds the pending booking of a request, linked to its customer,
+// without saving it
+func (s *BookingService) NewBooking(req *core.BookingRequest) (*core.Booking, error) {
 	booking := &core.Booking{
 		ServiceID:        req.ServiceID,
 		CustomerName:     req.CustomerName,
@@ -50,9 +72,29 @@ func (s *BookingService) CreateBooking(req *core.BookingRequest) (*core.Booking,
 		IssueDescription: req.IssueDesc,
 		DeviceType:       req.DeviceType,
 		Brand:            req.Brand,
-		JobStatus:        "pending",
+		JobStatus:        core.StatusPending,
 		Lat:              req.Lat,
 		Long:             req.Long,
+		AccessToken:      newAccessToken(),
+		ContractID:       req.ContractID,
+	}
+
+	// [NEW] Link to the customer (one per normalized phone number)
+	if s.customers != nil {
+		address := req.AddressDetails
+		if address == "" {
+			address = req.Address
+		}
+		customer, err := s.customers.Resolve(req.CustomerName, req.Phone, core.CustomerAddress{
+			Address: address,
+			Lat:     req.Lat,
+			Long:    req.Long,
+		})
+		if err != nil {
+			return nil, err
+		}
+		booking.CustomerID = customer.ID
+		booking.CustomerPhone = customer.Phone
 	}
 
 	if req.SlotID != "" {
@@ -76,11 +118,11 @@ func (s *BookingService) CreateBooking(req *core.BookingRequest) (*core.Booking,
 	} else if req.BookingTime != "" {
 		booking.BookingTime = req.BookingTime
 	}
+	return booking, nil
+}
 
-	if err := s.bookingRepo.Create(booking, req.Files); err != nil {
-		return nil, err
-	}
-
+// AnnounceBooking tells the office about a new booking once it is saved
+func (s *BookingService) AnnounceBooking(booking *core.Booking) {
 	// [CENTRALIZED NOTIFICATION]
 	// 1. SSE to Admin
 	if s.broker != nil {
@@ -140,19 +182,17 @@ func (s *BookingService) CreateBooking(req *core.BookingRequest) (*core.Booking,
 			}
 		}()
 	}
-
-	return booking, nil
 }
 
-func (s *BookingService) AssignTechnician(bookingID, technicianID string) error {
+func (s *BookingService) AssignTechnician(bookingID, technicianID string, actor core.Actor) error {
 	booking, err := s.bookingRepo.GetByID(bookingID)
 	if err != nil {
 		return fmt.Errorf("booking not found: %w", err)
 	}
 
-	// Validation
-	if booking.JobStatus != "pending" && booking.JobStatus != "assigned" {
-		return fmt.Errorf("cannot assign technician: job is already %s", booking.JobStatus)
+	// Fail fast before looking up the technician
+	if !s.lifecycle.Can(booking.JobStatus, core.StatusAssigned) {
+		return &core.TransitionError{BookingID: bookingID, From: booking.JobStatus, To: core.StatusAssigned}
 	}
 
 	tech, err := s.techRepo.GetByID(technicianID)
@@ -163,13 +203,14 @@ func (s *BookingService) AssignTechnician(bookingID, technicianID string) error
 		return fmt.Errorf("technician is not active")
 	}
 
-	// Update Domain Model
+	previousTechID := booking.TechnicianID
 	booking.TechnicianID = technicianID
-	booking.JobStatus = "assigned"
-
-	// Persist
-	if err := s.bookingRepo.Update(booking); err != nil {
-		return fmt.Errorf("failed to save assignment: %w", err)
+	if _, err := s.transition(booking, core.StatusAssigned, actor, "", map[string]interface{}{
+		"technician_id":          technicianID,
+		"technician_name":        tech.Name,
+		"previous_technician_id": previousTechID,
+	}); err != nil {
+		return err
 	}
 
 	// [CENTRALIZED NOTIFICATION]
@@ -249,30 +290,20 @@ func (s *BookingService) AssignTechnician(bookingID, technicianID string) error
 	return nil
 }
 
-func (s *BookingService) RecallToPending(bookingID string) error {
+func (s *BookingService) RecallToPending(bookingID string, actor core.Actor) error {
 	booking, err := s.bookingRepo.GetByID(bookingID)
 	if err != nil {
 		return fmt.Errorf("booking not found: %w", err)
 	}
 
-	// Release slot
-	if booking.SlotID != nil && *booking.SlotID != "" {
-		if s.slotControl != nil {
-			// Ignore error for now, just log if posssible
-			_ = s.slotControl.ReleaseSlot(*booking.SlotID)
-		}
-		booking.SlotID = nil
-	}
-
 	// Capture old tech ID for notification
 	oldTechID := booking.TechnicianID
 
-	// Clear Assignments
-	booking.TechnicianID = ""
-	booking.JobStatus = "pending"
-
-	if err := s.bookingRepo.Update(booking); err != nil {
-		return fmt.Errorf("failed to recall booking: %w", err)
+	// Lifecycle clears the technician and progress timestamps, slot is released
+	if _, err := s.transition(booking, core.StatusPending, actor, "", map[string]interface{}{
+		"previous_technician_id": oldTechID,
+	}); err != nil {
+		return err
 	}
 
 	// [CENTRALIZED NOTIFICATION]
@@ -325,20 +356,130 @@ func (s *BookingService) RecallToPending(bookingID string) error {
 	return nil
 }
 
-func (s *BookingService) UpdateStatus(bookingID, status string) error {
+// UpdateStatus moves a booking along the lifecycle. Recall and cancel are
+// routed to their dedicated flows so their side effects always run.
+func (s *BookingService) UpdateStatus(bookingID, status string, actor core.Actor) error {
+	switch status {
+	case core.StatusPending:
+		return s.RecallToPending(bookingID, actor)
+	case core.StatusCancelled:
+		return s.CancelBooking(bookingID, "", "", actor)
+	}
+
 	booking, err := s.bookingRepo.GetByID(bookingID)
 	if err != nil {
 		return fmt.Errorf("booking not found: %w", err)
 	}
 
-	// Validation logic for transitions could be here
+	// Idempotent: retried taps from the tech app should not fail
+	if booking.JobStatus == status {
+		return nil
+	}
 
-	booking.JobStatus = status
-	if err := s.bookingRepo.Update(booking); err != nil {
+	if _, err := s.transition(booking, status, actor, "", nil); err != nil {
 		return err
 	}
 
-	// [CENTRALIZED NOTIFICATION]
+	s.publishStatusChange(booking)
+	return nil
+}
+
+// transition applies a lifecycle change through the state machine, runs its
+// side effects (slot release, customer channel), persists the booking and
+// appends the change to the booking timeline.
+func (s *BookingService) transition(booking *core.Booking, to string, actor core.Actor, note string, data map[string]interface{}) (core.Transition, error) {
+	t, err := s.StageStatus(booking, to)
+	if err != nil {
+		return t, err
+	}
+	if err := s.bookingRepo.Update(booking); err != nil {
+		return t, fmt.Errorf("failed to save booking status: %w", err)
+	}
+	s.announceTransition(booking, t, actor, note, data)
+	return t, nil
+}
+
+// StageStatus applies a lifecycle change to the booking without saving it,
+// for callers that save it with other records in one transaction; call
+// AnnounceStatus once saved
+func (s *BookingService) StageStatus(booking *core.Booking, to string) (core.Transition, error) {
+	t, err := s.lifecycle.Apply(booking, to, time.Now())
+	if err != nil {
+		return t, err
+	}
+
+	if t.Has(core.EffectReleaseSlot) && booking.SlotID != nil {
+		if *booking.SlotID != "" && s.slotControl != nil {
+			if err := s.slotControl.ReleaseSlot(*booking.SlotID); err != nil {
+				log.Printf("⚠️ [BOOKING_SERVICE] Failed to release slot %s: %v", *booking.SlotID, err)
+			}
+		}
+		booking.SlotID = nil
+	}
+	return t, nil
+}
+
+// AnnounceStatus records a staged change on the timeline and tells the
+// customer, tech and office, as UpdateStatus does
+func (s *BookingService) AnnounceStatus(booking *core.Booking, t core.Transition, actor core.Actor) {
+	s.announceTransition(booking, t, actor, "", nil)
+	s.publishStatusChange(booking)
+}
+
+// announceTransition appends a saved change to the timeline and pushes it to
+// the customer tracking channel when the transition asks for it
+func (s *BookingService) announceTransition(booking *core.Booking, t core.Transition, actor core.Actor, note string, data map[string]interface{}) {
+	s.recordEvent(&core.BookingEvent{
+		BookingID:  booking.ID,
+		Type:       eventTypeFor(t.From, t.To),
+		FromStatus: t.From,
+		ToStatus:   t.To,
+		Actor:      actor,
+		Note:       note,
+		Data:       data,
+	})
+
+	if t.Has(core.EffectNotifyCustomer) && s.broker != nil {
+		s.broker.Publish(broker.ChannelCustomer, booking.ID, broker.Event{
+			Type:      "job.status_changed",
+			Timestamp: time.Now().Unix(),
+			Data: map[string]interface{}{
+				"status":  t.To,
+				"tech_id": booking.TechnicianID,
+			},
+		})
+	}
+}
+
+// recordEvent appends to the timeline; failures never block the booking flow
+func (s *BookingService) recordEvent(event *core.BookingEvent) {
+	if s.eventRepo == nil {
+		return
+	}
+	if err := s.eventRepo.Append(event); err != nil {
+		log.Printf("⚠️ [BOOKING_SERVICE] Failed to record %s event for booking %s: %v", event.Type, event.BookingID, err)
+	}
+}
+
+// eventTypeFor names a lifecycle change for the timeline
+func eventTypeFor(from, to string) string {
+	switch {
+	case to == core.StatusAssigned && from == core.StatusAssigned:
+		return core.EventReassigned
+	case to == core.StatusAssigned:
+		return core.EventAssigned
+	case to == core.StatusPending:
+		return core.EventRecalled
+	case to == core.StatusCancelled:
+		return core.EventCancelled
+	}
+	return core.EventStatusChanged
+}
+
+// publishStatusChange notifies tech and admin (SSE) and the tech device (FCM)
+func (s *BookingService) publishStatusChange(booking *core.Booking) {
+	bookingID, status := booking.ID, booking.JobStatus
+
 	if s.broker != nil {
 		// Notify Tech (SSE)
 		if booking.TechnicianID != "" {
@@ -379,8 +520,6 @@ func (s *BookingService) UpdateStatus(bookingID, status string) error {
 			}
 		}()
 	}
-
-	return nil
 }
 
 // Haversine calculates distance in km between two coordinate points
@@ -398,12 +537,20 @@ func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
 }
 
 // TechCheckIn verifies technician location and updates status
-func (s *BookingService) TechCheckIn(bookingID string, techLat, techLong float64) error {
+func (s *BookingService) TechCheckIn(bookingID string, techLat, techLong float64, actor core.Actor) error {
 	booking, err := s.bookingRepo.GetByID(bookingID)
 	if err != nil {
 		return fmt.Errorf("booking not found: %w", err)
 	}
 
+	// Already arrived (e.g. geofence got there first)
+	if booking.JobStatus == core.StatusArrived {
+		return nil
+	}
+	if !s.lifecycle.Can(booking.JobStatus, core.StatusArrived) {
+		return &core.TransitionError{BookingID: bookingID, From: booking.JobStatus, To: core.StatusArrived}
+	}
+
 	// Check distance (allow 0.5km error)
 	// [FIX] If booking has no coordinates (Lat/Long = 0), we assume the technician is at the correct location.
 	// We Update the booking's location to the technician's current location to "fix" the data.
@@ -421,40 +568,35 @@ func (s *BookingService) TechCheckIn(bookingID string, techLat, techLong float64
 		}
 	}
 
-	// Update DB
-	booking.ArrivedAt = time.Now().Format("2006-01-02 15:04:05.000Z") // ISO/PB format
-	booking.JobStatus = "arrived"
-
-	if err := s.bookingRepo.Update(booking); err != nil {
-		return fmt.Errorf("failed to update booking: %w", err)
+	// Update DB (arrived_at is stamped by the lifecycle)
+	if _, err := s.transition(booking, core.StatusArrived, actor, "", map[string]interface{}{
+		"lat":  techLat,
+		"long": techLong,
+	}); err != nil {
+		return err
 	}
 
+	s.publishStatusChange(booking)
 	return nil
 }
 
 // CancelBooking updates status to cancelled with a reason
-func (s *BookingService) CancelBooking(bookingID, reason, note string) error {
+func (s *BookingService) CancelBooking(bookingID, reason, note string, actor core.Actor) error {
 	booking, err := s.bookingRepo.GetByID(bookingID)
 	if err != nil {
 		return fmt.Errorf("booking not found: %w", err)
 	}
 
-	booking.JobStatus = "cancelled"
 	booking.CancelReason = reason
 	if note != "" {
 		booking.CancelReason += " - Note: " + note
 	}
 
-	// Release slot if applicable
-	if booking.SlotID != nil && *booking.SlotID != "" {
-		if s.slotControl != nil {
-			_ = s.slotControl.ReleaseSlot(*booking.SlotID)
-		}
-		booking.SlotID = nil
-	}
-
-	if err := s.bookingRepo.Update(booking); err != nil {
-		return fmt.Errorf("failed to cancel booking: %w", err)
+	// Slot release is a side effect of the cancel transition
+	if _, err := s.transition(booking, core.StatusCancelled, actor, booking.CancelReason, map[string]interface{}{
+		"reason": reason,
+	}); err != nil {
+		return err
 	}
 
 	// [CENTRALIZED NOTIFICATION]
@@ -529,19 +671,113 @@ func (s *BookingService) CancelBooking(bookingID, reason, note string) error {
 	return nil
 }
 
-// RescheduleBooking updates the booking time
-func (s *BookingService) RescheduleBooking(bookingID, newTime string) error {
+// RescheduleBooking moves the booking to a new time (and optionally a new slot).
+// The old slot is released, the assigned tech keeps the job and is notified;
+// a time the tech is not free at fails with ErrScheduleConflict.
+func (s *BookingService) RescheduleBooking(bookingID, newTime, newSlotID string, actor core.Actor) error {
 	booking, err := s.bookingRepo.GetByID(bookingID)
 	if err != nil {
 		return fmt.Errorf("booking not found: %w", err)
 	}
+	if booking.JobStatus == core.StatusCompleted || booking.JobStatus == core.StatusCancelled {
+		return &core.TransitionError{BookingID: booking.ID, From: booking.JobStatus, To: booking.JobStatus}
+	}
+
+	oldTime := booking.BookingTime
+	oldSlotID := ""
+	if booking.SlotID != nil {
+		oldSlotID = *booking.SlotID
+	}
+
+	// The assigned technician must be free at the new time
+	if booking.TechnicianID != "" && s.slotControl != nil {
+		if date, clock, ok := splitBookingTime(newTime); ok {
+			if err := s.slotControl.CheckReschedule(booking, date, clock, newSlotID); err != nil {
+				return err
+			}
+		}
+	}
 
 	booking.BookingTime = newTime
-	// Reset status if it was completed/cancelled? Assuming active job.
-	// We might want to keep it assigned.
+	if newSlotID != oldSlotID {
+		if newSlotID != "" {
+			booking.SlotID = &newSlotID
+			if s.slotControl != nil {
+				// Counter only, availability was checked by the caller
+				if err := s.slotControl.BookSlot(newSlotID, bookingID); err != nil {
+					log.Printf("⚠️ [BOOKING_SERVICE] BookSlot %s on reschedule: %v", newSlotID, err)
+				}
+			}
+		} else {
+			booking.SlotID = nil
+		}
+		if oldSlotID != "" && s.slotControl != nil {
+			if err := s.slotControl.ReleaseSlot(oldSlotID); err != nil {
+				log.Printf("⚠️ [BOOKING_SERVICE] Failed to release slot %s: %v", oldSlotID, err)
+			}
+		}
+	}
 
 	if err := s.bookingRepo.Update(booking); err != nil {
 		return fmt.Errorf("failed to reschedule booking: %w", err)
 	}
+
+	s.recordEvent(&core.BookingEvent{
+		BookingID:  bookingID,
+		Type:       core.EventRescheduled,
+		FromStatus: booking.JobStatus,
+		ToStatus:   booking.JobStatus,
+		Actor:      actor,
+		Data: map[string]interface{}{
+			"from_time": oldTime,
+			"to_time":   newTime,
+		},
+	})
+
+	// [CENTRALIZED NOTIFICATION]
+	displayTime := formatTimeForSSE(booking, s.slotRepo)
+	if s.broker != nil {
+		payload := map[string]interface{}{
+			"id":         bookingID,
+			"booking_id": bookingID,
+			"old_time":   oldTime,
+			"new_time":   newTime,
+			"time":       displayTime,
+			"by":         actor.Type,
+		}
+		s.broker.Publish(broker.ChannelAdmin, "", broker.Event{Type: "booking.rescheduled", Timestamp: time.Now().Unix(), Data: payload})
+		s.broker.Publish(broker.ChannelCustomer, bookingID, broker.Event{Type: "job.rescheduled", Timestamp: time.Now().Unix(), Data: payload})
+		if booking.TechnicianID != "" && actor.Type != core.ActorTech {
+			s.broker.Publish(broker.ChannelTech, booking.TechnicianID, broker.Event{Type: "job.rescheduled", Timestamp: time.Now().Unix(), Data: payload})
+		}
+	}
+
+	if s.notifications != nil {
+		go func() {
+			if actor.Type != core.ActorAdmin {
+				settings, err := s.settingsRepo.GetSettings()
+				if err == nil && len(settings.AdminFCMTokens) > 0 {
+					failedTokens, err := s.notifications.NotifyAdminsBookingRescheduled(context.Background(), settings.AdminFCMTokens, bookingID, booking.CustomerName, displayTime)
+					if err != nil {
+						log.Printf("❌ [BOOKING_SERVICE] Failed to notify admins of reschedule: %v", err)
+					}
+					for _, t := range failedTokens {
+						_ = s.settingsRepo.RemoveAdminToken(t)
+					}
+				}
+			}
+
+			if booking.TechnicianID != "" && actor.Type != core.ActorTech {
+				tech, err := s.techRepo.GetByID(booking.TechnicianID)
+				if err == nil && tech.FCMToken != "" {
+					err := s.notifications.NotifyJobStatusChange(context.Background(), tech.FCMToken, bookingID, "rescheduled")
+					if err != nil && err.Error() == "token_invalid" {
+						tech.FCMToken = ""
+						_ = s.techRepo.Update(tech)
+					}
+				}
+			}
+		}()
+	}
 	return nil
 }
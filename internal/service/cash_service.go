@@ -0,0 +1,211 @@
+package service
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+	"hvac-system/pkg/broker"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CashService keeps the cash ledger of technicians: cash payments they collect
+// (from the payments ledger) minus the handovers an admin has confirmed.
+type CashService struct {
+	payments  core.PaymentRepository
+	handovers core.CashHandoverRepository
+	techs     core.TechnicianRepository
+	invoices  core.InvoiceLedger
+	broker    *broker.SegmentedBroker
+}
+
+func NewCashService(
+	payments core.PaymentRepository,
+	handovers core.CashHandoverRepository,
+	techs core.TechnicianRepository,
+	invoices core.InvoiceLedger,
+	eventBroker *broker.SegmentedBroker,
+) core.CashService {
+	return &CashService{
+		payments:  payments,
+		handovers: handovers,
+		techs:     techs,
+		invoices:  invoices,
+		broker:    eventBroker,
+	}
+}
+
+// Position returns the cash a technician still has to hand in
+func (s *CashService) Position(techID string) (*core.CashPosition, error) {
+	_, pos, err := s.build(techID)
+	return pos, err
+}
+
+// Ledger returns the latest entries of a technician's cash ledger (limit <= 0 = all)
+func (s *CashService) Ledger(techID string, limit int) ([]core.CashEntry, *core.CashPosition, error) {
+	entries, pos, err := s.build(techID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	latest := make([]core.CashEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0 && (limit <= 0 || len(latest) < limit); i-- {
+		entry := entries[i]
+		if entry.InvoiceID != "" {
+			if invoice, err := s.invoices.GetPayable(entry.InvoiceID); err == nil && invoice.Code != "" {
+				entry.Reference = invoice.Code
+			}
+		}
+		latest = append(latest, entry)
+	}
+	return latest, pos, nil
+}
+
+// Positions lists active technicians and anyone still holding cash or
+// waiting for a handover to be confirmed, largest amount first
+func (s *CashService) Positions() ([]*core.CashPosition, error) {
+	techs, err := s.techs.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch technicians: %w", err)
+	}
+
+	positions := make([]*core.CashPosition, 0, len(techs))
+	for _, tech := range techs {
+		_, pos, err := s.build(tech.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !tech.Active && pos.Outstanding == 0 && pos.Pending == nil {
+			continue
+		}
+		pos.TechnicianName = tech.Name
+		positions = append(positions, pos)
+	}
+	sort.SliceStable(positions, func(i, j int) bool { return positions[i].Outstanding > positions[j].Outstanding })
+	return positions, nil
+}
+
+func (s *CashService) History(techID string) ([]*core.CashHandover, error) {
+	return s.handovers.ListByTechnician(techID)
+}
+
+func (s *CashService) PendingHandovers() ([]*core.CashHandover, error) {
+	return s.handovers.ListByStatus(core.HandoverSubmitted)
+}
+
+// SubmitHandover records the cash a technician hands in. The expected amount
+// is the ledger balance at this moment.
+func (s *CashService) SubmitHandover(declared float64, note string, actor core.Actor) (*core.CashHandover, error) {
+	if declared <= 0 {
+		return nil, core.ErrInvalidHandover
+	}
+	pos, err := s.Position(actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pos.Pending != nil {
+		return nil, core.ErrHandoverPending
+	}
+
+	h := &core.CashHandover{
+		TechnicianID:   actor.ID,
+		TechnicianName: actor.Name,
+		Expected:       pos.Outstanding,
+		Declared:       declared,
+		Status:         core.HandoverSubmitted,
+		Note:           strings.TrimSpace(note),
+		SubmittedAt:    time.Now(),
+	}
+	if err := s.handovers.Create(h); err != nil {
+		return nil, err
+	}
+	log.Printf("💰 [CASH] %s hands in %.0f (ledger %.0f)", actor.Name, declared, pos.Outstanding)
+
+	s.publish(broker.ChannelAdmin, "", "cash.handover_submitted", h)
+	return h, nil
+}
+
+// ConfirmHandover records the amount the admin counted. A difference with the
+// expected amount is kept as the discrepancy; a shortage goes to payroll.
+func (s *CashService) ConfirmHandover(id string, received float64, note string, actor core.Actor) (*core.CashHandover, error) {
+	if received <= 0 {
+		return nil, core.ErrInvalidHandover
+	}
+	h, err := s.handovers.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("handover not found: %w", err)
+	}
+	if h.Status != core.HandoverSubmitted {
+		return nil, core.ErrHandoverNotPending
+	}
+
+	h.Confirm(received, actor.Name, time.Now())
+	h.AdminNote = strings.TrimSpace(note)
+	if err := s.handovers.Update(h); err != nil {
+		return nil, err
+	}
+	if h.Discrepancy != 0 {
+		log.Printf("⚠️ [CASH] Handover of %s off by %.0f (expected %.0f, received %.0f)",
+			h.TechnicianName, h.Discrepancy, h.Expected, h.Received)
+	}
+
+	s.publish(broker.ChannelTech, h.TechnicianID, "cash.handover_confirmed", h)
+	return h, nil
+}
+
+// RejectHandover is used when nothing was received: the cash stays with the tech
+func (s *CashService) RejectHandover(id, note string, actor core.Actor) error {
+	h, err := s.handovers.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("handover not found: %w", err)
+	}
+	if h.Status != core.HandoverSubmitted {
+		return core.ErrHandoverNotPending
+	}
+
+	h.Status = core.HandoverRejected
+	h.ConfirmedBy = actor.Name
+	h.ConfirmedAt = time.Now()
+	h.AdminNote = strings.TrimSpace(note)
+	if err := s.handovers.Update(h); err != nil {
+		return err
+	}
+
+	s.publish(broker.ChannelTech, h.TechnicianID, "cash.handover_rejected", h)
+	return nil
+}
+
+func (s *CashService) build(techID string) ([]core.CashEntry, *core.CashPosition, error) {
+	payments, err := s.payments.ListCashCollectedBy(techID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch cash payments: %w", err)
+	}
+	handovers, err := s.handovers.ListByTechnician(techID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch handovers: %w", err)
+	}
+	entries, pos := core.BuildCashLedger(payments, handovers)
+	pos.TechnicianID = techID
+	return entries, &pos, nil
+}
+
+func (s *CashService) publish(channel broker.Channel, id, eventType string, h *core.CashHandover) {
+	if s.broker == nil {
+		return
+	}
+	s.broker.Publish(channel, id, broker.Event{
+		Type:      eventType,
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"handover_id":     h.ID,
+			"technician_id":   h.TechnicianID,
+			"technician_name": h.TechnicianName,
+			"expected":        h.Expected,
+			"declared":        h.Declared,
+			"received":        h.Received,
+			"discrepancy":     h.Discrepancy,
+			"status":          h.Status,
+		},
+	})
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hvac-system/internal/core"
+	"hvac-system/pkg/broker"
+	"log"
+	"strings"
+	"time"
+)
+
+// ContractService turns maintenance contracts into bookings and reminders
+type ContractService struct {
+	repo        core.ContractRepository
+	bookings    core.BookingService
+	bookingRepo core.BookingRepository
+	customers   core.CustomerService
+	equipment   core.EquipmentService
+	notifier    core.CustomerNotifier
+	broker      *broker.SegmentedBroker
+}
+
+func NewContractService(
+	repo core.ContractRepository,
+	bookings core.BookingService,
+	bookingRepo core.BookingRepository,
+	customers core.CustomerService,
+	equipment core.EquipmentService,
+	notifier core.CustomerNotifier,
+	eventBroker *broker.SegmentedBroker,
+) core.ContractService {
+	return &ContractService{
+		repo:        repo,
+		bookings:    bookings,
+		bookingRepo: bookingRepo,
+		customers:   customers,
+		equipment:   equipment,
+		notifier:    notifier,
+		broker:      eventBroker,
+	}
+}
+
+func (s *ContractService) Get(id string) (*core.MaintenanceContract, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *ContractService) List(status string) ([]*core.MaintenanceContract, error) {
+	return s.repo.List(status)
+}
+
+func (s *ContractService) ForCustomer(customerID string) ([]*core.MaintenanceContract, error) {
+	return s.repo.ListByCustomer(customerID)
+}
+
+// Create validates the plan; equipment not owned by the customer is dropped
+func (s *ContractService) Create(c *core.MaintenanceContract, actor core.Actor) error {
+	c.Status = core.ContractActive
+	c.NextServiceDate = ""
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if len(c.EquipmentIDs) > 0 {
+		units, err := s.equipment.ForCustomer(c.CustomerID)
+		if err != nil {
+			return err
+		}
+		owned := make(map[string]bool, len(units))
+		for _, eq := range units {
+			owned[eq.ID] = true
+		}
+		valid := make([]string, 0, len(c.EquipmentIDs))
+		for _, id := range c.EquipmentIDs {
+			if owned[id] {
+				valid = append(valid, id)
+			}
+		}
+		c.EquipmentIDs = valid
+	}
+
+	if err := s.repo.Create(c); err != nil {
+		return fmt.Errorf("failed to save contract: %w", err)
+	}
+	log.Printf("📄 [CONTRACT] %s created for customer %s by %s", c.ID, c.CustomerID, actor.Name)
+	return nil
+}
+
+// SetStatus pauses, resumes or cancels a contract
+func (s *ContractService) SetStatus(id, status string, actor core.Actor) error {
+	c, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	switch status {
+	case core.ContractPaused:
+		allowed = c.Status == core.ContractActive
+	case core.ContractActive:
+		allowed = c.Status == core.ContractPaused && !c.Ended(time.Now())
+	case core.ContractCancelled:
+		allowed = c.Status != core.ContractCancelled
+	}
+	if !allowed {
+		return core.ErrContractStatus
+	}
+
+	c.Status = status
+	if err := s.repo.Update(c); err != nil {
+		return err
+	}
+	log.Printf("📄 [CONTRACT] %s -> %s by %s", c.ID, status, actor.Name)
+	return nil
+}
+
+// Renew extends the term; visits continue from the pending next service date
+func (s *ContractService) Renew(id string, months int, actor core.Actor) error {
+	c, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := c.Renew(months); err != nil {
+		return err
+	}
+	if err := s.repo.Update(c); err != nil {
+		return err
+	}
+	log.Printf("📄 [CONTRACT] %s renewed until %s by %s", c.ID, c.EndDate, actor.Name)
+	return nil
+}
+
+// Alerts lists contracts to renew: ending within the window or expired within it
+func (s *ContractService) Alerts(now time.Time) ([]*core.MaintenanceContract, error) {
+	return s.repo.ListEndingBetween(
+		now.AddDate(0, 0, -core.ContractRenewalWindow).Format("2006-01-02"),
+		now.AddDate(0, 0, core.ContractRenewalWindow).Format("2006-01-02"),
+	)
+}
+
+// GenerateDueBookings creates the booking of every visit entering the lead window.
+// A missed visit (server down, contract resumed late) is booked for today. The
+// booking and the contract's next visit are saved together; a contract that
+// fails is reported and the others are still booked.
+func (s *ContractService) GenerateDueBookings(now time.Time) (int, error) {
+	contracts, err := s.repo.List(core.ContractActive)
+	if err != nil {
+		return 0, err
+	}
+
+	today := now.Format("2006-01-02")
+	created := 0
+	var errs []error
+	for _, c := range contracts {
+		if !c.DueForBooking(now) {
+			continue
+		}
+		date := c.NextServiceDate
+		if date < today {
+			date = today
+		}
+
+		booking, err := s.newVisit(c, date)
+		if err == nil {
+			c.ReminderSentAt = ""
+			c.Advance()
+			err = s.repo.BookVisit(c, booking)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("contract %s: %w", c.ID, err))
+			continue
+		}
+		s.bookings.AnnounceBooking(booking)
+		created++
+	}
+	return created, errors.Join(errs...)
+}
+
+// newVisit builds the booking of a contract visit, not saved yet
+func (s *ContractService) newVisit(c *core.MaintenanceContract, date string) (*core.Booking, error) {
+	customer, err := s.customers.Get(c.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("customer not found: %w", err)
+	}
+
+	req := &core.BookingRequest{
+		ServiceID:    c.ServiceID,
+		CustomerName: customer.Name,
+		Phone:        customer.Phone,
+		BookingTime:  date + " " + core.ContractVisitTime,
+		ContractID:   c.ID,
+	}
+	if len(customer.Addresses) > 0 {
+		req.AddressDetails = customer.Addresses[0].Address
+		req.Lat = customer.Addresses[0].Lat
+		req.Long = customer.Addresses[0].Long
+	}
+
+	// Describe the covered units so the tech knows what to service
+	labels := []string{}
+	if len(c.EquipmentIDs) > 0 {
+		units, _ := s.equipment.ForCustomer(c.CustomerID)
+		covered := make(map[string]bool, len(c.EquipmentIDs))
+		for _, id := range c.EquipmentIDs {
+			covered[id] = true
+		}
+		for _, eq := range units {
+			if !covered[eq.ID] {
+				continue
+			}
+			labels = append(labels, eq.Label())
+			if req.DeviceType == "" {
+				req.DeviceType = eq.UnitType
+				req.Brand = eq.Brand
+			}
+		}
+	}
+	req.IssueDesc = "Bảo trì định kỳ theo hợp đồng"
+	if len(labels) > 0 {
+		req.IssueDesc += ": " + strings.Join(labels, "; ")
+	}
+
+	return s.bookings.NewBooking(req)
+}
+
+// SendReminders notifies customers whose contract visit is within ContractReminderDays.
+// Customers without a channel are flagged to the admin for a phone call.
+func (s *ContractService) SendReminders(now time.Time) (int, error) {
+	contracts, err := s.repo.List(core.ContractActive)
+	if err != nil {
+		return 0, err
+	}
+
+	horizon := now.AddDate(0, 0, core.ContractReminderDays).Format("2006-01-02")
+	sent := 0
+	for _, c := range contracts {
+		if c.LastBookingID == "" || c.ReminderSentAt != "" {
+			continue
+		}
+		booking, err := s.bookingRepo.GetByID(c.LastBookingID)
+		if err != nil {
+			continue
+		}
+		if booking.JobStatus == core.StatusCancelled || booking.JobStatus == core.StatusCompleted {
+			continue
+		}
+		if len(booking.BookingTime) < 10 || booking.BookingTime[:10] > horizon {
+			continue
+		}
+
+		customer, err := s.customers.Get(c.CustomerID)
+		if err != nil {
+			continue
+		}
+
+		err = core.ErrNoContactChannel
+		if s.notifier != nil {
+			err = s.notifier.SendMaintenanceReminder(customer, booking)
+		}
+		if errors.Is(err, core.ErrNoContactChannel) {
+			s.publishCallReminder(c, booking)
+		} else if err != nil {
+			log.Printf("⚠️ [CONTRACT] Reminder for %s failed, retrying next run: %v", booking.ID, err)
+			continue
+		}
+
+		c.ReminderSentAt = now.UTC().Format(core.DateTimeLayout)
+		if err := s.repo.Update(c); err != nil {
+			log.Printf("⚠️ [CONTRACT] Failed to mark reminder on %s: %v", c.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// publishCallReminder asks the dispatcher to call the customer instead
+func (s *ContractService) publishCallReminder(c *core.MaintenanceContract, booking *core.Booking) {
+	if s.broker == nil {
+		return
+	}
+	s.broker.Publish(broker.ChannelAdmin, "", broker.Event{
+		Type:      "contract.reminder",
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"contract_id":   c.ID,
+			"booking_id":    booking.ID,
+			"customer_name": booking.CustomerName,
+			"phone":         booking.CustomerPhone,
+			"booking_time":  booking.BookingTime,
+		},
+	})
+}
+
+// ExpireEnded marks contracts whose term is over as expired (waiting for renewal)
+func (s *ContractService) ExpireEnded(now time.Time) (int, error) {
+	contracts, err := s.repo.List("")
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, c := range contracts {
+		if (c.Status != core.ContractActive && c.Status != core.ContractPaused) || !c.Ended(now) {
+			continue
+		}
+		c.Status = core.ContractExpired
+		if err := s.repo.Update(c); err != nil {
+			log.Printf("⚠️ [CONTRACT] Failed to expire %s: %v", c.ID, err)
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
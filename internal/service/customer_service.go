@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hvac-system/internal/core"
+	"log"
+	"strings"
+)
+
+// CustomerService keeps one customer per normalized phone number
+type CustomerService struct {
+	repo core.CustomerRepository
+}
+
+func NewCustomerService(repo core.CustomerRepository) core.CustomerService {
+	return &CustomerService{repo: repo}
+}
+
+// Resolve finds the customer by phone (creating it on first booking) and
+// remembers the booking address.
+func (s *CustomerService) Resolve(name, phone string, addr core.CustomerAddress) (*core.Customer, error) {
+	normalized, err := core.NormalizePhoneVN(phone)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+
+	customer, err := s.repo.GetByPhone(normalized)
+	if err != nil {
+		customer = &core.Customer{Phone: normalized, Name: name}
+		customer.AddAddress(addr)
+		if err := s.repo.Create(customer); err != nil {
+			return nil, fmt.Errorf("failed to create customer: %w", err)
+		}
+		return customer, nil
+	}
+
+	changed := customer.AddAddress(addr)
+	if customer.Name == "" && name != "" {
+		customer.Name = name
+		changed = true
+	}
+	if changed {
+		if err := s.repo.Update(customer); err != nil {
+			// Booking must not fail because of an address book update
+			log.Printf("⚠️ [CUSTOMER] Failed to update %s: %v", customer.ID, err)
+		}
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) Get(id string) (*core.Customer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *CustomerService) Search(query string, page int) ([]*core.Customer, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	return s.repo.Search(query, core.CustomerPageSize, (page-1)*core.CustomerPageSize)
+}
+
+func (s *CustomerService) Update(id, name, email, notes string) (*core.Customer, error) {
+	customer, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		customer.Name = name
+	}
+	customer.Email = strings.TrimSpace(email)
+	customer.Notes = notes
+	if err := s.repo.Update(customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) Bookings(id string) ([]*core.Booking, error) {
+	return s.repo.ListBookings(id)
+}
+
+// Duplicates lists likely duplicates (same name or shared address)
+func (s *CustomerService) Duplicates(id string) ([]*core.Customer, error) {
+	customer, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindSimilar(customer, 10)
+}
+
+// Merge folds the duplicate into the primary customer: bookings are re-linked,
+// addresses and notes combined, then the duplicate is deleted.
+func (s *CustomerService) Merge(primaryID, duplicateID string, actor core.Actor) (*core.Customer, error) {
+	if primaryID == duplicateID {
+		return nil, errors.New("cannot merge a customer into itself")
+	}
+	primary, err := s.repo.GetByID(primaryID)
+	if err != nil {
+		return nil, err
+	}
+	duplicate, err := s.repo.GetByID(duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addr := range duplicate.Addresses {
+		primary.AddAddress(addr)
+	}
+	if primary.Name == "" {
+		primary.Name = duplicate.Name
+	}
+	if primary.Email == "" {
+		primary.Email = duplicate.Email
+	}
+
+	// Keep the other phone number visible after the merge
+	note := fmt.Sprintf("[Gộp từ %s - %s]", duplicate.Name, duplicate.Phone)
+	if duplicate.Notes != "" {
+		note += " " + duplicate.Notes
+	}
+	primary.Notes = strings.TrimSpace(primary.Notes + "\n" + note)
+
+	if err := s.repo.Merge(primary, duplicate.ID); err != nil {
+		return nil, fmt.Errorf("failed to merge customers: %w", err)
+	}
+	log.Printf("👥 [CUSTOMER] Merged %s (%s) into %s (%s) by %s", duplicate.ID, duplicate.Phone, primary.ID, primary.Phone, actor.Name)
+
+	return s.repo.GetByID(primary.ID)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+	"hvac-system/pkg/cache"
+	"log"
+	"time"
+)
+
+type DispatchService struct {
+	bookingRepo    core.BookingRepository
+	techRepo       core.TechnicianRepository
+	serviceRepo    core.ServiceRepository
+	slotControl    core.TimeSlotControl
+	brandRepo      core.BrandRepository
+	bookingService core.BookingService
+	locations      *cache.LocationCache
+}
+
+func NewDispatchService(
+	bookingRepo core.BookingRepository,
+	techRepo core.TechnicianRepository,
+	serviceRepo core.ServiceRepository,
+	slotControl core.TimeSlotControl,
+	brandRepo core.BrandRepository,
+	bookingService core.BookingService,
+	locations *cache.LocationCache,
+) core.DispatchService {
+	return &DispatchService{
+		bookingRepo:    bookingRepo,
+		techRepo:       techRepo,
+		serviceRepo:    serviceRepo,
+		slotControl:    slotControl,
+		brandRepo:      brandRepo,
+		bookingService: bookingService,
+		locations:      locations,
+	}
+}
+
+// RankTechnicians scores every active technician for the booking.
+// Eligible candidates come first, ordered by score.
+func (s *DispatchService) RankTechnicians(bookingID string) ([]*core.DispatchCandidate, error) {
+	booking, err := s.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found: %w", err)
+	}
+
+	techs, err := s.techRepo.GetAvailable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load technicians: %w", err)
+	}
+
+	var svc *core.Service
+	if booking.ServiceID != "" {
+		svc, _ = s.serviceRepo.GetByID(booking.ServiceID)
+	}
+
+	candidates := make([]*core.DispatchCandidate, 0, len(techs))
+	for _, tech := range techs {
+		candidates = append(candidates, core.ScoreTechnician(s.dispatchInput(booking, svc, tech)))
+	}
+	core.RankCandidates(candidates)
+	return candidates, nil
+}
+
+// dispatchInput gathers what the technician is scored on: live distance,
+// open jobs and the schedule at the booking time
+func (s *DispatchService) dispatchInput(booking *core.Booking, svc *core.Service, tech *core.Technician) core.DispatchInput {
+	in := core.DispatchInput{Booking: booking, Service: svc, Technician: tech, DistanceKm: -1}
+
+	if s.locations != nil && booking.Lat != 0 && booking.Long != 0 {
+		if loc := s.locations.GetTechLocation(tech.ID); loc != nil && loc.Latitude != 0 {
+			in.DistanceKm = Haversine(loc.Latitude, loc.Longitude, booking.Lat, booking.Long)
+		}
+	}
+
+	if active, err := s.bookingRepo.FindActiveByTechnician(tech.ID); err == nil {
+		for _, job := range active {
+			if job.ID != booking.ID {
+				in.ActiveJobs++
+			}
+		}
+	}
+
+	if s.slotControl != nil {
+		if date, clock, ok := splitBookingTime(booking.BookingTime); ok {
+			duration := core.DefaultJobDurationMinutes
+			if svc != nil && svc.DurationMinutes > 0 {
+				duration = svc.DurationMinutes
+			}
+			slotID := ""
+			if booking.SlotID != nil {
+				slotID = *booking.SlotID
+			}
+			in.Conflict = s.slotControl.CheckConflict(tech.ID, date, clock, duration, slotID, booking.ID)
+		}
+	}
+	return in
+}
+
+// AutoAssignPending assigns the best eligible technician to bookings that
+// stayed pending longer than the brand's auto_assign_after_minutes.
+func (s *DispatchService) AutoAssignPending() (int, error) {
+	brand, err := s.brandRepo.GetDefault()
+	if err != nil {
+		return 0, nil // No brand configured yet
+	}
+	if !brand.AutoAssignEnabled || brand.AutoAssignAfterMinutes <= 0 {
+		return 0, nil
+	}
+
+	pending, err := s.bookingRepo.FindPending()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-time.Duration(brand.AutoAssignAfterMinutes) * time.Minute)
+	assigned := 0
+	for _, booking := range pending {
+		created, err := time.Parse(core.DateTimeLayout, booking.Created)
+		if err != nil || created.After(cutoff) {
+			continue
+		}
+
+		ranked, err := s.RankTechnicians(booking.ID)
+		if err != nil || len(ranked) == 0 || !ranked[0].Eligible {
+			continue
+		}
+
+		best := ranked[0]
+		actor := core.SystemActor("auto_dispatch")
+		if err := s.bookingService.AssignTechnician(booking.ID, best.TechnicianID, actor); err != nil {
+			log.Printf("⚠️ [DISPATCH] Auto-assign %s -> %s failed: %v", booking.ID, best.TechnicianID, err)
+			continue
+		}
+		log.Printf("🤖 [DISPATCH] Auto-assigned booking %s to %s (score %.1f)", booking.ID, best.TechnicianName, best.Score)
+		assigned++
+	}
+
+	return assigned, nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+	"log"
+	"strings"
+	"time"
+)
+
+// EInvoiceService sends issued invoices to the e-invoice provider and keeps
+// the lifecycle (issued, cancelled, replaced) on the invoice. A cancelled
+// e-invoice may be issued again; a replacement points to the one it replaces.
+type EInvoiceService struct {
+	repo     core.EInvoiceRepository
+	brands   core.BrandRepository
+	provider core.EInvoiceProvider
+}
+
+func NewEInvoiceService(
+	repo core.EInvoiceRepository,
+	brands core.BrandRepository,
+	provider core.EInvoiceProvider,
+) core.EInvoiceService {
+	return &EInvoiceService{
+		repo:     repo,
+		brands:   brands,
+		provider: provider,
+	}
+}
+
+func (s *EInvoiceService) State(invoiceID string) (*core.EInvoiceSource, error) {
+	return s.repo.Source(invoiceID)
+}
+
+// Submit issues the e-invoice of an invoice. An empty buyer name bills the
+// customer of the booking.
+func (s *EInvoiceService) Submit(invoiceID string, buyer core.EInvoiceParty, actor core.Actor) (*core.EInvoiceState, error) {
+	src, err := s.repo.Source(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found: %w", err)
+	}
+	state := src.State
+	if state.Status == core.EInvoiceIssued {
+		return nil, core.ErrEInvoiceIssued
+	}
+
+	inv, err := s.build(src, withDefaults(buyer, src.Customer))
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := s.provider.Submit(inv)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.provider.Name(), err)
+	}
+
+	if state.Current != nil {
+		state.Superseded = append(state.Superseded, *state.Current)
+	}
+	state.Current = refOf(inv, receipt)
+	state.Status = core.EInvoiceIssued
+	state.Provider = s.provider.Name()
+	state.Buyer = inv.Buyer
+	state.Reason = ""
+	state.UpdatedBy = actor.Name
+	state.CancelledAt = nil
+	if err := s.save(invoiceID, &state); err != nil {
+		return nil, err
+	}
+	log.Printf("🧾 [EINVOICE] %s issued as %s/%s by %s (lookup %s)", src.InvoiceCode, inv.Series, inv.Number, actor.Name, receipt.LookupCode)
+	return &state, nil
+}
+
+// Cancel cancels the current e-invoice at the provider
+func (s *EInvoiceService) Cancel(invoiceID, reason string, actor core.Actor) (*core.EInvoiceState, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, core.ErrEInvoiceReason
+	}
+	src, err := s.repo.Source(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found: %w", err)
+	}
+	state := src.State
+	if state.Status != core.EInvoiceIssued || state.Current == nil {
+		return nil, core.ErrEInvoiceNotIssued
+	}
+
+	if err := s.provider.Cancel(*state.Current, reason); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.provider.Name(), err)
+	}
+
+	now := time.Now().UTC()
+	state.Status = core.EInvoiceCancelled
+	state.Reason = reason
+	state.UpdatedBy = actor.Name
+	state.CancelledAt = &now
+	if err := s.save(invoiceID, &state); err != nil {
+		return nil, err
+	}
+	log.Printf("🧾 [EINVOICE] %s/%s of %s cancelled by %s: %s", state.Current.Series, state.Current.Number, src.InvoiceCode, actor.Name, reason)
+	return &state, nil
+}
+
+// Replace issues a new e-invoice replacing the current one, e.g. to correct
+// the buyer. An empty buyer name keeps the previous buyer.
+func (s *EInvoiceService) Replace(invoiceID string, buyer core.EInvoiceParty, reason string, actor core.Actor) (*core.EInvoiceState, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, core.ErrEInvoiceReason
+	}
+	src, err := s.repo.Source(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found: %w", err)
+	}
+	state := src.State
+	if state.Status != core.EInvoiceIssued || state.Current == nil {
+		return nil, core.ErrEInvoiceNotIssued
+	}
+
+	inv, err := s.build(src, withDefaults(buyer, state.Buyer))
+	if err != nil {
+		return nil, err
+	}
+	original := *state.Current
+	inv.Replaces = &original
+	inv.Reason = reason
+	receipt, err := s.provider.Replace(original, inv)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.provider.Name(), err)
+	}
+
+	state.Superseded = append(state.Superseded, original)
+	state.Current = refOf(inv, receipt)
+	state.Buyer = inv.Buyer
+	state.Reason = reason
+	state.UpdatedBy = actor.Name
+	if err := s.save(invoiceID, &state); err != nil {
+		return nil, err
+	}
+	log.Printf("🧾 [EINVOICE] %s/%s replaced by %s/%s for %s by %s: %s", original.Series, original.Number, inv.Series, inv.Number, src.InvoiceCode, actor.Name, reason)
+	return &state, nil
+}
+
+// Download returns the XML of the current e-invoice from the provider
+func (s *EInvoiceService) Download(invoiceID string) ([]byte, error) {
+	src, err := s.repo.Source(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found: %w", err)
+	}
+	if src.State.Current == nil {
+		return nil, core.ErrEInvoiceNotIssued
+	}
+	return s.provider.Download(*src.State.Current)
+}
+
+func (s *EInvoiceService) build(src *core.EInvoiceSource, buyer core.EInvoiceParty) (*core.EInvoice, error) {
+	brand, err := s.brands.GetDefault()
+	if err != nil || brand == nil {
+		return nil, core.ErrEInvoiceSeller
+	}
+	seller := core.EInvoiceParty{
+		Name:        brand.CompanyName,
+		TaxCode:     strings.TrimSpace(brand.TaxCode),
+		Address:     brand.Address,
+		Phone:       brand.Hotline,
+		Email:       brand.Email,
+		BankAccount: brand.BankAccount,
+	}
+	now := time.Now()
+	return core.BuildEInvoice(src, seller, buyer, core.EInvoiceSeries(brand.EInvoiceSeries, now), now)
+}
+
+// save stores the new state. The provider has already accepted the change,
+// so a failure is logged with what is needed to fix the invoice by hand.
+func (s *EInvoiceService) save(invoiceID string, state *core.EInvoiceState) error {
+	if err := s.repo.SaveState(invoiceID, state); err != nil {
+		log.Printf("❌ [EINVOICE] Provider accepted %s for invoice %s but saving failed: %v (state %+v)", state.Status, invoiceID, err, state.Current)
+		return err
+	}
+	return nil
+}
+
+// withDefaults bills fallback when no buyer name was entered
+func withDefaults(buyer, fallback core.EInvoiceParty) core.EInvoiceParty {
+	buyer.Name = strings.TrimSpace(buyer.Name)
+	buyer.TaxCode = strings.TrimSpace(buyer.TaxCode)
+	if buyer.Name != "" {
+		return buyer
+	}
+	return fallback
+}
+
+func refOf(inv *core.EInvoice, receipt *core.EInvoiceReceipt) *core.EInvoiceRef {
+	issuedAt := receipt.IssuedAt
+	if issuedAt.IsZero() {
+		issuedAt = inv.IssuedAt
+	}
+	return &core.EInvoiceRef{
+		Template:      inv.Template,
+		Series:        inv.Series,
+		Number:        receipt.Number,
+		IssuedAt:      issuedAt,
+		LookupCode:    receipt.LookupCode,
+		TransactionID: receipt.TransactionID,
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+)
+
+// EquipmentService keeps the installed units of each customer
+type EquipmentService struct {
+	repo        core.EquipmentRepository
+	bookingRepo core.BookingRepository
+}
+
+func NewEquipmentService(repo core.EquipmentRepository, bookingRepo core.BookingRepository) core.EquipmentService {
+	return &EquipmentService{
+		repo:        repo,
+		bookingRepo: bookingRepo,
+	}
+}
+
+func (s *EquipmentService) Get(id string) (*core.Equipment, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *EquipmentService) ForCustomer(customerID string) ([]*core.Equipment, error) {
+	return s.repo.ListByCustomer(customerID)
+}
+
+func (s *EquipmentService) ForBooking(bookingID string) ([]*core.Equipment, error) {
+	booking, err := s.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.CustomerID == "" {
+		return []*core.Equipment{}, nil
+	}
+	return s.repo.ListByCustomer(booking.CustomerID)
+}
+
+func (s *EquipmentService) Register(eq *core.Equipment) error {
+	if err := eq.Normalize(); err != nil {
+		return err
+	}
+	if err := s.repo.Create(eq); err != nil {
+		return fmt.Errorf("failed to save equipment: %w", err)
+	}
+	return nil
+}
+
+// RegisterForBooking creates a unit for the customer of the booking (tech on site)
+func (s *EquipmentService) RegisterForBooking(bookingID string, eq *core.Equipment) error {
+	booking, err := s.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return err
+	}
+	if booking.CustomerID == "" {
+		return core.ErrNoCustomer
+	}
+	eq.CustomerID = booking.CustomerID
+	if eq.UnitType == "" {
+		eq.UnitType = booking.DeviceType
+	}
+	if eq.Brand == "" {
+		eq.Brand = booking.Brand
+	}
+	return s.Register(eq)
+}
+
+func (s *EquipmentService) Update(eq *core.Equipment) error {
+	existing, err := s.repo.GetByID(eq.ID)
+	if err != nil {
+		return err
+	}
+	eq.CustomerID = existing.CustomerID // Units never move between customers here
+	if err := eq.Normalize(); err != nil {
+		return err
+	}
+	return s.repo.Update(eq)
+}
+
+func (s *EquipmentService) History(id string) ([]*core.EquipmentServiceRecord, error) {
+	return s.repo.ServiceHistory(id)
+}
+
+// RecordService only accepts units belonging to the booking's customer
+func (s *EquipmentService) RecordService(bookingID, reportID string, equipmentIDs []string) error {
+	if len(equipmentIDs) == 0 {
+		return nil
+	}
+	units, err := s.ForBooking(bookingID)
+	if err != nil {
+		return err
+	}
+	owned := make(map[string]bool, len(units))
+	for _, eq := range units {
+		owned[eq.ID] = true
+	}
+
+	valid := make([]string, 0, len(equipmentIDs))
+	for _, id := range equipmentIDs {
+		if owned[id] {
+			valid = append(valid, id)
+		}
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+	return s.repo.LinkReport(reportID, valid)
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"hvac-system/internal/core"
 	"time"
@@ -44,3 +46,25 @@ func formatTimeForSSE(booking *core.Booking, slotRepo core.TimeSlotRepository) s
 
 	return rawTime
 }
+
+// splitBookingTime returns date ("2006-01-02") and clock ("15:04") of a booking_time
+// stored either as "YYYY-MM-DD HH:MM" or PocketBase datetime.
+func splitBookingTime(raw string) (date, clock string, ok bool) {
+	if len(raw) < 16 {
+		return "", "", false
+	}
+	t, err := time.Parse("2006-01-02 15:04", raw[:16])
+	if err != nil {
+		return "", "", false
+	}
+	return t.Format("2006-01-02"), t.Format("15:04"), true
+}
+
+// newAccessToken returns an unguessable token for customer portal links
+func newAccessToken() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hvac-system/internal/core"
+	"hvac-system/pkg/broker"
+	"log"
+	"strings"
+	"time"
+)
+
+// PaymentService keeps the payments ledger of invoices. The invoice status is
+// recomputed from the ledger after every change that affects the balance.
+type PaymentService struct {
+	repo     core.PaymentRepository
+	invoices core.InvoiceLedger
+	broker   *broker.SegmentedBroker
+}
+
+func NewPaymentService(
+	repo core.PaymentRepository,
+	invoices core.InvoiceLedger,
+	eventBroker *broker.SegmentedBroker,
+) core.PaymentService {
+	return &PaymentService{repo: repo, invoices: invoices, broker: eventBroker}
+}
+
+// Record posts a payment from the customer (deposit, balance or full)
+func (s *PaymentService) Record(p *core.Payment, actor core.Actor) (*core.InvoiceBalance, error) {
+	if p.Amount <= 0 || !core.ValidMethod(p.Method) {
+		return nil, core.ErrInvalidPayment
+	}
+	invoice, err := s.invoices.GetPayable(p.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status == core.InvoiceCancelled {
+		return nil, core.ErrInvoiceClosed
+	}
+
+	p.Kind = core.PaymentIn
+	p.Status = core.PaymentPosted
+	p.BookingID = invoice.BookingID
+	if p.PaidAt.IsZero() {
+		p.PaidAt = time.Now()
+	}
+	if p.CollectedBy == "" && p.CollectedByName == "" {
+		p.CollectedBy, p.CollectedByName = actor.ID, actor.Name
+	}
+	if err := s.repo.Create(p); err != nil {
+		if errors.Is(err, core.ErrDuplicatePayment) {
+			// The caller may have saved a stale invoice on the way here: put the
+			// balance of the entry already recorded back
+			if _, rerr := s.rebalance(p.InvoiceID); rerr != nil {
+				log.Printf("⚠️ [PAYMENT] Rebalance of invoice %s failed: %v", p.InvoiceID, rerr)
+			}
+		}
+		return nil, err
+	}
+	log.Printf("💵 [PAYMENT] %s %.0f (%s) on invoice %s by %s", p.Kind, p.Amount, p.Method, p.InvoiceID, actor.Name)
+	return s.rebalance(p.InvoiceID)
+}
+
+// RequestRefund queues a refund for approval. The amount cannot exceed what
+// the customer has paid, net of approved and pending refunds.
+func (s *PaymentService) RequestRefund(refund *core.Payment, actor core.Actor) (*core.Payment, error) {
+	if refund.Amount <= 0 || !core.ValidMethod(refund.Method) {
+		return nil, core.ErrInvalidPayment
+	}
+	ledger, balance, err := s.Ledger(refund.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	refundable := balance.Net
+	for _, p := range ledger {
+		if p.Kind == core.PaymentRefund && p.Status == core.PaymentPending {
+			refundable -= p.Amount
+		}
+	}
+	if refund.Amount > refundable+0.5 {
+		return nil, core.ErrRefundExceedsPaid
+	}
+
+	invoice, err := s.invoices.GetPayable(refund.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	refund.Kind = core.PaymentRefund
+	refund.Status = core.PaymentPending
+	refund.BookingID = invoice.BookingID
+	refund.RequestedBy = actor.Name
+	refund.RequestNote = strings.TrimSpace(refund.RequestNote)
+	if refund.PaidAt.IsZero() {
+		refund.PaidAt = time.Now()
+	}
+	if err := s.repo.Create(refund); err != nil {
+		return nil, err
+	}
+	s.publishRequest(refund, invoice)
+	return refund, nil
+}
+
+// RequestVoid flags a posted entry (typed in error, duplicate...) for voiding.
+// It keeps counting in the balance until an admin approves.
+func (s *PaymentService) RequestVoid(paymentID, note string, actor core.Actor) error {
+	p, err := s.repo.GetByID(paymentID)
+	if err != nil {
+		return fmt.Errorf("payment not found: %w", err)
+	}
+	if p.Status != core.PaymentPosted {
+		return core.ErrPaymentNotVoidable
+	}
+	p.Status = core.PaymentVoidPending
+	p.RequestedBy = actor.Name
+	p.RequestNote = strings.TrimSpace(note)
+	if err := s.repo.Update(p); err != nil {
+		return err
+	}
+	if invoice, err := s.invoices.GetPayable(p.InvoiceID); err == nil {
+		s.publishRequest(p, invoice)
+	}
+	return nil
+}
+
+// Approve posts a pending refund or voids a payment
+func (s *PaymentService) Approve(paymentID string, actor core.Actor) (*core.InvoiceBalance, error) {
+	p, err := s.repo.GetByID(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found: %w", err)
+	}
+	switch p.Status {
+	case core.PaymentPending:
+		p.Status = core.PaymentPosted
+		p.PaidAt = time.Now()
+	case core.PaymentVoidPending:
+		p.Status = core.PaymentVoided
+	default:
+		return nil, core.ErrPaymentNotPending
+	}
+	p.ApprovedBy = actor.Name
+	p.ApprovedAt = time.Now().UTC().Format(core.DateTimeLayout)
+	if err := s.repo.Update(p); err != nil {
+		return nil, err
+	}
+	log.Printf("✅ [PAYMENT] %s %s (%.0f) approved by %s", p.Kind, p.Status, p.Amount, actor.Name)
+	return s.rebalance(p.InvoiceID)
+}
+
+// Reject drops a refund request or keeps a payment whose void was requested
+func (s *PaymentService) Reject(paymentID, note string, actor core.Actor) error {
+	p, err := s.repo.GetByID(paymentID)
+	if err != nil {
+		return fmt.Errorf("payment not found: %w", err)
+	}
+	switch p.Status {
+	case core.PaymentPending:
+		p.Status = core.PaymentRejected
+	case core.PaymentVoidPending:
+		p.Status = core.PaymentPosted
+	default:
+		return core.ErrPaymentNotPending
+	}
+	p.ApprovedBy = actor.Name
+	p.ApprovedAt = time.Now().UTC().Format(core.DateTimeLayout)
+	if note = strings.TrimSpace(note); note != "" {
+		p.Note = note
+	}
+	return s.repo.Update(p)
+}
+
+// Ledger returns the entries of an invoice with the balance they give
+func (s *PaymentService) Ledger(invoiceID string) ([]*core.Payment, *core.InvoiceBalance, error) {
+	invoice, err := s.invoices.GetPayable(invoiceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	payments, err := s.repo.ListByInvoice(invoiceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	balance := core.ComputeBalance(invoice.Total, payments)
+	return payments, &balance, nil
+}
+
+// Pending lists the refunds and voids awaiting approval
+func (s *PaymentService) Pending() ([]*core.Payment, error) {
+	payments, err := s.repo.ListByStatus(core.PaymentPending, core.PaymentVoidPending)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range payments {
+		if invoice, err := s.invoices.GetPayable(p.InvoiceID); err == nil {
+			p.InvoiceCode = invoice.Code
+		}
+	}
+	return payments, nil
+}
+
+func (s *PaymentService) rebalance(invoiceID string) (*core.InvoiceBalance, error) {
+	_, balance, err := s.Ledger(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.invoices.ApplyBalance(invoiceID, *balance); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (s *PaymentService) publishRequest(p *core.Payment, invoice *core.PayableInvoice) {
+	if s.broker == nil {
+		return
+	}
+	s.broker.Publish(broker.ChannelAdmin, "", broker.Event{
+		Type:      "payment.approval_requested",
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"payment_id":   p.ID,
+			"kind":         p.Kind,
+			"status":       p.Status,
+			"amount":       p.Amount,
+			"invoice_id":   invoice.ID,
+			"invoice_code": invoice.Code,
+			"booking_id":   invoice.BookingID,
+			"requested_by": p.RequestedBy,
+		},
+	})
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+	"log"
+	"strings"
+	"time"
+)
+
+// PayrollService builds monthly technician statements from settled invoices,
+// "customer not home" cancellations, cash handover shortages and the bonuses,
+// penalties and advances entered by admins. Drafts are recomputed on every
+// generation; approved statements are locked.
+type PayrollService struct {
+	repo      core.PayrollRepository
+	techs     core.TechnicianRepository
+	events    core.BookingEventRepository
+	handovers core.CashHandoverRepository
+	brands    core.BrandRepository
+}
+
+func NewPayrollService(
+	repo core.PayrollRepository,
+	techs core.TechnicianRepository,
+	events core.BookingEventRepository,
+	handovers core.CashHandoverRepository,
+	brands core.BrandRepository,
+) core.PayrollService {
+	return &PayrollService{
+		repo:      repo,
+		techs:     techs,
+		events:    events,
+		handovers: handovers,
+		brands:    brands,
+	}
+}
+
+// Generate (re)computes the draft statements of a period. Inactive
+// technicians only get one when something happened in the period.
+func (s *PayrollService) Generate(period, techID string) ([]*core.PayrollStatement, error) {
+	from, to, err := core.PayrollPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	var techs []*core.Technician
+	if techID != "" {
+		tech, err := s.techs.GetByID(techID)
+		if err != nil {
+			return nil, fmt.Errorf("technician not found: %w", err)
+		}
+		techs = []*core.Technician{tech}
+	} else if techs, err = s.techs.GetAll(); err != nil {
+		return nil, fmt.Errorf("failed to fetch technicians: %w", err)
+	}
+
+	penalty := 0.0
+	if brand, err := s.brands.GetDefault(); err == nil && brand != nil {
+		penalty = brand.PayrollNoShowPenalty
+	}
+
+	statements := make([]*core.PayrollStatement, 0, len(techs))
+	for _, tech := range techs {
+		existing, err := s.repo.FindStatement(tech.ID, period)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.Locked() {
+			statements = append(statements, existing)
+			continue
+		}
+
+		input, err := s.input(tech, period, from, to, penalty)
+		if err != nil {
+			return nil, err
+		}
+		statement := core.BuildStatement(input)
+		if existing == nil && !tech.Active && techID == "" && statement.Commission+statement.Bonus+statement.Deductions() == 0 {
+			continue
+		}
+		if existing != nil {
+			statement.ID = existing.ID
+		}
+		if err := s.repo.SaveStatement(statement); err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+func (s *PayrollService) List(period string) ([]*core.PayrollStatement, error) {
+	if _, _, err := core.PayrollPeriod(period); err != nil {
+		return nil, err
+	}
+	return s.repo.ListStatements(period)
+}
+
+func (s *PayrollService) Get(id string) (*core.PayrollStatement, error) {
+	return s.repo.GetStatement(id)
+}
+
+func (s *PayrollService) ForTechnician(techID string) ([]*core.PayrollStatement, error) {
+	return s.repo.ListStatementsByTechnician(techID)
+}
+
+// Approve locks a statement after recomputing it one last time
+func (s *PayrollService) Approve(id string, actor core.Actor) error {
+	statement, err := s.repo.GetStatement(id)
+	if err != nil {
+		return fmt.Errorf("statement not found: %w", err)
+	}
+	if statement.Locked() {
+		return core.ErrPayrollLocked
+	}
+
+	fresh, err := s.Generate(statement.Period, statement.TechnicianID)
+	if err != nil {
+		return err
+	}
+	statement = fresh[0]
+	statement.Status = core.PayrollApproved
+	statement.ApprovedBy = actor.Name
+	statement.ApprovedAt = time.Now().UTC().Format(core.DateTimeLayout)
+	if err := s.repo.SaveStatement(statement); err != nil {
+		return err
+	}
+	log.Printf("🧾 [PAYROLL] %s %s approved by %s: %.0f", statement.TechnicianName, statement.Period, actor.Name, statement.NetPay)
+	return nil
+}
+
+func (s *PayrollService) Adjustments(techID, period string) ([]*core.PayrollAdjustment, error) {
+	return s.repo.ListAdjustments(techID, period)
+}
+
+// AddAdjustment records a bonus, penalty or advance and refreshes the draft
+func (s *PayrollService) AddAdjustment(a *core.PayrollAdjustment, actor core.Actor) (*core.PayrollStatement, error) {
+	a.Description = strings.TrimSpace(a.Description)
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.ensureOpen(a.TechnicianID, a.Period); err != nil {
+		return nil, err
+	}
+	a.CreatedBy = actor.Name
+	if err := s.repo.CreateAdjustment(a); err != nil {
+		return nil, err
+	}
+	return s.regenerate(a.TechnicianID, a.Period)
+}
+
+// RemoveAdjustment deletes an adjustment of a period that is not approved yet
+func (s *PayrollService) RemoveAdjustment(id string, actor core.Actor) (*core.PayrollStatement, error) {
+	a, err := s.repo.GetAdjustment(id)
+	if err != nil {
+		return nil, fmt.Errorf("adjustment not found: %w", err)
+	}
+	if err := s.ensureOpen(a.TechnicianID, a.Period); err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteAdjustment(id); err != nil {
+		return nil, err
+	}
+	log.Printf("🧾 [PAYROLL] %s removed %s %.0f from %s", actor.Name, a.Kind, a.Amount, a.Period)
+	return s.regenerate(a.TechnicianID, a.Period)
+}
+
+func (s *PayrollService) ensureOpen(techID, period string) error {
+	statement, err := s.repo.FindStatement(techID, period)
+	if err != nil {
+		return err
+	}
+	if statement != nil && statement.Locked() {
+		return core.ErrPayrollLocked
+	}
+	return nil
+}
+
+func (s *PayrollService) regenerate(techID, period string) (*core.PayrollStatement, error) {
+	statements, err := s.Generate(period, techID)
+	if err != nil {
+		return nil, err
+	}
+	return statements[0], nil
+}
+
+func (s *PayrollService) input(tech *core.Technician, period string, from, to time.Time, penalty float64) (core.PayrollInput, error) {
+	in := core.PayrollInput{Technician: tech, Period: period, NoShowPenalty: penalty}
+
+	commissions, err := s.repo.SettledCommissions(tech.ID, from, to)
+	if err != nil {
+		return in, fmt.Errorf("failed to fetch commissions: %w", err)
+	}
+	in.Commissions = commissions
+
+	cancellations, err := s.events.ListByActor(tech.ID, core.EventCancelled, from, to)
+	if err != nil {
+		return in, fmt.Errorf("failed to fetch cancellations: %w", err)
+	}
+	for _, ev := range cancellations {
+		if reason, _ := ev.Data["reason"].(string); reason == core.CancelReasonNotHome {
+			in.NoShows = append(in.NoShows, ev)
+		}
+	}
+
+	handovers, err := s.handovers.ListByTechnician(tech.ID)
+	if err != nil {
+		return in, fmt.Errorf("failed to fetch cash handovers: %w", err)
+	}
+	for _, h := range handovers {
+		if h.Status == core.HandoverConfirmed && !h.ConfirmedAt.Before(from) && h.ConfirmedAt.Before(to) {
+			in.Handovers = append(in.Handovers, h)
+		}
+	}
+
+	if in.Adjustments, err = s.repo.ListAdjustments(tech.ID, period); err != nil {
+		return in, fmt.Errorf("failed to fetch adjustments: %w", err)
+	}
+	return in, nil
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+	"hvac-system/pkg/broker"
+	"log"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+// QuoteService prices on-site quotes from the catalog and moves the booking
+// working -> quoting -> working around the customer's signature.
+type QuoteService struct {
+	repo        core.QuoteRepository
+	bookingRepo core.BookingRepository
+	bookings    core.BookingService
+	serviceRepo core.ServiceRepository
+	partRepo    core.PartRepository
+	broker      *broker.SegmentedBroker
+}
+
+func NewQuoteService(
+	repo core.QuoteRepository,
+	bookingRepo core.BookingRepository,
+	bookings core.BookingService,
+	serviceRepo core.ServiceRepository,
+	partRepo core.PartRepository,
+	eventBroker *broker.SegmentedBroker,
+) core.QuoteService {
+	return &QuoteService{
+		repo:        repo,
+		bookingRepo: bookingRepo,
+		bookings:    bookings,
+		serviceRepo: serviceRepo,
+		partRepo:    partRepo,
+		broker:      eventBroker,
+	}
+}
+
+// Create prices the lines from the catalog, supersedes the pending quote of the
+// job and puts the booking in "quoting" until the customer decides.
+func (s *QuoteService) Create(bookingID string, lines []core.QuoteLine, notes string, actor core.Actor) (*core.Quote, error) {
+	booking, err := s.bookingRepo.GetByID(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found: %w", err)
+	}
+	if actor.Type == core.ActorTech && booking.TechnicianID != actor.ID {
+		return nil, fmt.Errorf("booking %s is not assigned to this technician", bookingID)
+	}
+	if booking.JobStatus != core.StatusWorking && booking.JobStatus != core.StatusQuoting {
+		return nil, &core.TransitionError{BookingID: bookingID, From: booking.JobStatus, To: core.StatusQuoting,
+			Reason: "Chỉ lập báo giá khi đang làm việc tại nhà khách"}
+	}
+
+	quote := &core.Quote{
+		BookingID:    bookingID,
+		TechnicianID: booking.TechnicianID,
+		Status:       core.QuotePending,
+		Notes:        notes,
+		PublicHash:   newAccessToken(),
+	}
+	for _, line := range lines {
+		item, err := s.priceLine(line)
+		if err != nil {
+			return nil, err
+		}
+		quote.Items = append(quote.Items, *item)
+	}
+	if err := quote.Recalculate(); err != nil {
+		return nil, err
+	}
+
+	// Only one quote waits for a signature at a time
+	previous, err := s.repo.ListByBooking(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load quotes: %w", err)
+	}
+	var superseded []*core.Quote
+	for _, p := range previous {
+		if p.Status == core.QuotePending {
+			p.Status = core.QuoteSuperseded
+			superseded = append(superseded, p)
+		}
+	}
+
+	// A revised quote leaves the booking in quoting
+	var moved *core.Booking
+	var t core.Transition
+	if booking.JobStatus != core.StatusQuoting {
+		if t, err = s.bookings.StageStatus(booking, core.StatusQuoting); err != nil {
+			return nil, err
+		}
+		moved = booking
+	}
+	if err := s.repo.CreatePending(quote, superseded, moved); err != nil {
+		return nil, fmt.Errorf("failed to save quote: %w", err)
+	}
+	if moved != nil {
+		s.bookings.AnnounceStatus(moved, t, actor)
+	}
+	return quote, nil
+}
+
+// priceLine freezes the catalog price and name of a line
+func (s *QuoteService) priceLine(line core.QuoteLine) (*core.QuoteItem, error) {
+	item := &core.QuoteItem{Kind: line.Kind, RefID: line.RefID, Quantity: line.Quantity}
+	switch line.Kind {
+	case core.QuoteItemService:
+		svc, err := s.serviceRepo.GetByID(line.RefID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: service %s not found", core.ErrInvalidQuote, line.RefID)
+		}
+		item.Name = svc.Name
+		item.UnitPrice = svc.BasePrice
+	case core.QuoteItemPart:
+		part, err := s.partRepo.GetByID(line.RefID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: part %s not found", core.ErrInvalidQuote, line.RefID)
+		}
+		item.Name = part.Name
+		item.Unit = part.Unit
+		item.UnitPrice = part.Price
+	default:
+		return nil, core.ErrInvalidQuote
+	}
+	return item, nil
+}
+
+func (s *QuoteService) Get(id string) (*core.Quote, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *QuoteService) GetByHash(hash string) (*core.Quote, error) {
+	if hash == "" {
+		return nil, fmt.Errorf("quote not found")
+	}
+	return s.repo.GetByHash(hash)
+}
+
+func (s *QuoteService) ForBooking(bookingID string) ([]*core.Quote, error) {
+	return s.repo.ListByBooking(bookingID)
+}
+
+func (s *QuoteService) Approved(bookingID string) (*core.Quote, error) {
+	quotes, err := s.repo.ListByBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range quotes {
+		if q.Status == core.QuoteApproved {
+			return q, nil
+		}
+	}
+	return nil, nil
+}
+
+// Decide stores the signed answer and sends the booking back to "working"
+func (s *QuoteService) Decide(hash string, approve bool, reason string, signature *filesystem.File) (*core.Quote, error) {
+	quote, err := s.GetByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if signature == nil {
+		return nil, core.ErrSignatureRequired
+	}
+	if err := quote.Decide(approve, reason, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// A newly approved quote replaces the previously approved one (extra work)
+	var superseded []*core.Quote
+	if approve {
+		quotes, err := s.repo.ListByBooking(quote.BookingID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load quotes: %w", err)
+		}
+		for _, q := range quotes {
+			if q.ID != quote.ID && q.Status == core.QuoteApproved {
+				q.Status = core.QuoteSuperseded
+				superseded = append(superseded, q)
+			}
+		}
+	}
+	if err := s.repo.SaveDecision(quote, signature, superseded); err != nil {
+		return nil, fmt.Errorf("failed to save decision: %w", err)
+	}
+
+	actor := core.Actor{Type: core.ActorCustomer, Name: "Khách hàng", Source: "quote"}
+	if booking, err := s.bookingRepo.GetByID(quote.BookingID); err == nil && booking.JobStatus == core.StatusQuoting {
+		if err := s.bookings.UpdateStatus(booking.ID, core.StatusWorking, actor); err != nil {
+			log.Printf("⚠️ [QUOTE] Booking %s not moved back to working: %v", booking.ID, err)
+		}
+	}
+	s.publishDecision(quote)
+	return quote, nil
+}
+
+func (s *QuoteService) publishDecision(q *core.Quote) {
+	if s.broker == nil {
+		return
+	}
+	event := broker.Event{
+		Type:      "quote.decided",
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"quote_id":      q.ID,
+			"booking_id":    q.BookingID,
+			"job_id":        q.BookingID,
+			"status":        q.Status,
+			"total":         q.Total,
+			"reject_reason": q.RejectReason,
+		},
+	}
+	s.broker.Publish(broker.ChannelTech, q.TechnicianID, event)
+	s.broker.Publish(broker.ChannelAdmin, "", event)
+}
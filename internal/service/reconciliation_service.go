@@ -0,0 +1,222 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hvac-system/internal/core"
+	"hvac-system/pkg/broker"
+	"log"
+	"strings"
+	"time"
+)
+
+// ReconciliationService records bank transfers (webhook, statement, simulator)
+// and posts them to the payments ledger of the invoice they pay. Only a
+// transfer naming the invoice code with the exact amount due is posted
+// automatically; the rest waits for an admin.
+type ReconciliationService struct {
+	repo     core.BankTransactionRepository
+	invoices core.InvoiceLedger
+	payments core.PaymentService
+	broker   *broker.SegmentedBroker
+}
+
+func NewReconciliationService(
+	repo core.BankTransactionRepository,
+	invoices core.InvoiceLedger,
+	payments core.PaymentService,
+	eventBroker *broker.SegmentedBroker,
+) core.ReconciliationService {
+	return &ReconciliationService{repo: repo, invoices: invoices, payments: payments, broker: eventBroker}
+}
+
+// Ingest stores new transactions, skipping the ones already recorded, and
+// settles the invoices they match.
+func (s *ReconciliationService) Ingest(txs []*core.BankTransaction) (*core.ReconcileResult, error) {
+	result := &core.ReconcileResult{Received: len(txs)}
+	open, err := s.invoices.OpenInvoices()
+	if err != nil {
+		return nil, fmt.Errorf("load open invoices: %w", err)
+	}
+
+	for _, tx := range txs {
+		if tx.TransactedAt.IsZero() {
+			tx.TransactedAt = time.Now()
+		}
+		match := core.MatchBankTransaction(tx, open)
+		tx.MatchNote = match.Note
+		tx.SuggestedInvoiceID = match.SuggestedID
+		switch {
+		case tx.Amount <= 0:
+			tx.Status = core.BankTxIgnored
+		case match.InvoiceID != "":
+			tx.Status = core.BankTxMatched
+			tx.InvoiceID = match.InvoiceID
+		default:
+			tx.Status = core.BankTxUnmatched
+		}
+
+		// Recorded before settling: a webhook retried by the provider hits the
+		// unique reference and never pays an invoice twice
+		if err := s.repo.Create(tx); err != nil {
+			if errors.Is(err, core.ErrDuplicateTransaction) {
+				result.Duplicates++
+				continue
+			}
+			return result, err
+		}
+
+		if tx.Status == core.BankTxMatched {
+			if err := s.settle(tx); err != nil {
+				// Paid meanwhile (cash, another transfer): an admin has to look at it
+				log.Printf("⚠️ [RECONCILE] %s matched invoice %s but was not settled: %v", tx.ExternalID, tx.InvoiceID, err)
+				tx.Status = core.BankTxUnmatched
+				tx.SuggestedInvoiceID = tx.InvoiceID
+				tx.InvoiceID = ""
+				tx.MatchNote = "Không ghi nhận được: " + err.Error()
+				if err := s.repo.Update(tx); err != nil {
+					return result, err
+				}
+			} else {
+				open = withoutInvoice(open, tx.InvoiceID)
+			}
+		}
+
+		switch tx.Status {
+		case core.BankTxMatched:
+			result.Matched++
+		case core.BankTxUnmatched:
+			result.Unmatched++
+		default:
+			result.Ignored++
+		}
+	}
+	return result, nil
+}
+
+// List returns the transactions of a status ("" = all), newest first
+func (s *ReconciliationService) List(status string) ([]*core.BankTransaction, error) {
+	txs, err := s.repo.ListByStatus(status, 200)
+	if err != nil {
+		return nil, err
+	}
+	// Review queue shows invoice codes rather than record IDs
+	open, _ := s.invoices.OpenInvoices()
+	codes := make(map[string]string, len(open))
+	for _, inv := range open {
+		codes[inv.ID] = inv.Code
+	}
+	for _, tx := range txs {
+		tx.SuggestedInvoiceCode = codes[tx.SuggestedInvoiceID]
+	}
+	return txs, nil
+}
+
+// Resolve settles an invoice with a queued transaction. invoiceRef is the
+// invoice code typed by the admin (or the suggested invoice ID).
+func (s *ReconciliationService) Resolve(txID, invoiceRef string, actor core.Actor) error {
+	tx, err := s.repo.GetByID(txID)
+	if err != nil {
+		return fmt.Errorf("bank transaction not found: %w", err)
+	}
+	if tx.Status == core.BankTxMatched {
+		return core.ErrTransactionResolved
+	}
+
+	open, err := s.invoices.OpenInvoices()
+	if err != nil {
+		return err
+	}
+	invoiceRef = strings.ToUpper(strings.TrimSpace(invoiceRef))
+	if codes := core.FindInvoiceCodes(invoiceRef); len(codes) > 0 {
+		invoiceRef = codes[0]
+	}
+	var invoice *core.PayableInvoice
+	for i := range open {
+		if strings.EqualFold(open[i].ID, invoiceRef) || (open[i].Code != "" && open[i].Code == invoiceRef) {
+			invoice = &open[i]
+			break
+		}
+	}
+	if invoice == nil {
+		return core.ErrInvoiceNotPayable
+	}
+
+	tx.InvoiceID = invoice.ID
+	if err := s.settle(tx); err != nil {
+		return err
+	}
+	tx.Status = core.BankTxMatched
+	tx.ReviewedBy = actor.Name
+	tx.MatchNote = "Đối soát thủ công với " + invoice.Code
+	return s.repo.Update(tx)
+}
+
+// Ignore removes a transaction from the review queue (refund, unrelated income...)
+func (s *ReconciliationService) Ignore(txID, note string, actor core.Actor) error {
+	tx, err := s.repo.GetByID(txID)
+	if err != nil {
+		return fmt.Errorf("bank transaction not found: %w", err)
+	}
+	if tx.Status == core.BankTxMatched {
+		return core.ErrTransactionResolved
+	}
+	tx.Status = core.BankTxIgnored
+	tx.ReviewedBy = actor.Name
+	if note = strings.TrimSpace(note); note != "" {
+		tx.MatchNote = note
+	}
+	return s.repo.Update(tx)
+}
+
+func (s *ReconciliationService) settle(tx *core.BankTransaction) error {
+	invoice, err := s.invoices.GetPayable(tx.InvoiceID)
+	if err != nil {
+		return err
+	}
+	if !core.InvoiceOpen(invoice.Status) {
+		return core.ErrInvoiceNotPayable
+	}
+	balance, err := s.payments.Record(&core.Payment{
+		InvoiceID:       tx.InvoiceID,
+		Amount:          tx.Amount,
+		Method:          core.MethodTransfer,
+		Reference:       tx.Source + ":" + tx.ExternalID,
+		CollectedByName: "Ngân hàng",
+		PaidAt:          tx.TransactedAt,
+		Note:            tx.Description,
+	}, core.SystemActor("bank_reconciliation"))
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ [RECONCILE] %s %.0f posted to invoice %s (%s)", tx.ExternalID, tx.Amount, invoice.Code, balance.Status)
+
+	if s.broker != nil {
+		data := map[string]interface{}{
+			"booking_id":   invoice.BookingID,
+			"invoice_id":   invoice.ID,
+			"invoice_code": invoice.Code,
+			"amount":       tx.Amount,
+			"method":       core.MethodTransfer,
+			"status":       balance.Status,
+			"due":          balance.Due,
+		}
+		s.broker.Publish(broker.ChannelCustomer, invoice.BookingID, broker.Event{
+			Type: "payment.processed", Timestamp: time.Now().Unix(), Data: data,
+		})
+		s.broker.Publish(broker.ChannelAdmin, "", broker.Event{
+			Type: "payment.reconciled", Timestamp: time.Now().Unix(), Data: data,
+		})
+	}
+	return nil
+}
+
+func withoutInvoice(open []core.PayableInvoice, id string) []core.PayableInvoice {
+	out := open[:0:0]
+	for _, inv := range open {
+		if inv.ID != id {
+			out = append(out, inv)
+		}
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"hvac-system/internal/core"
+	"hvac-system/pkg/broker"
+	"log"
+	"time"
+)
+
+// TechScheduleService combines weekly working hours with approved time off
+type TechScheduleService struct {
+	repo   core.TechScheduleRepository
+	broker *broker.SegmentedBroker
+}
+
+func NewTechScheduleService(repo core.TechScheduleRepository, eventBroker *broker.SegmentedBroker) core.TechScheduleService {
+	return &TechScheduleService{
+		repo:   repo,
+		broker: eventBroker,
+	}
+}
+
+// DaySchedule returns the working windows of a technician on a date (YYYY-MM-DD)
+func (s *TechScheduleService) DaySchedule(techID, date string) (*core.DaySchedule, error) {
+	hours, err := s.repo.GetWorkingHours(techID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch working hours: %w", err)
+	}
+	offs, err := s.repo.ListTimeOffInRange(techID, date, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch time off: %w", err)
+	}
+	return core.ResolveDaySchedule(date, hours, offs)
+}
+
+func (s *TechScheduleService) GetWorkingHours(techID string) ([]*core.WorkingHours, error) {
+	return s.repo.GetWorkingHours(techID)
+}
+
+// SetWorkingHours replaces the weekly schedule (empty = works every day, all day)
+func (s *TechScheduleService) SetWorkingHours(techID string, hours []*core.WorkingHours) error {
+	if err := core.ValidateWorkingHours(hours); err != nil {
+		return err
+	}
+	return s.repo.ReplaceWorkingHours(techID, hours)
+}
+
+// RequestTimeOff records a leave request (tech) or an approved exception (admin)
+func (s *TechScheduleService) RequestTimeOff(off *core.TimeOff, actor core.Actor) error {
+	if off.DateTo == "" {
+		off.DateTo = off.DateFrom
+	}
+	if err := off.Validate(); err != nil {
+		return err
+	}
+
+	off.RequestedBy = actor.Type
+	switch actor.Type {
+	case core.ActorTech:
+		// Techs can only request for themselves, holidays are set by admin
+		if off.Kind == core.TimeOffHoliday {
+			return core.ErrInvalidTimeOff
+		}
+		off.TechnicianID = actor.ID
+		off.Status = core.TimeOffPending
+	default:
+		if off.TechnicianID == "" && off.Kind != core.TimeOffHoliday {
+			return core.ErrInvalidTimeOff
+		}
+		off.Status = core.TimeOffApproved
+		off.ReviewedBy = actor.Name
+		off.ReviewedAt = time.Now().UTC().Format(core.DateTimeLayout)
+	}
+
+	if err := s.repo.CreateTimeOff(off); err != nil {
+		return fmt.Errorf("failed to save time off: %w", err)
+	}
+
+	if off.Status == core.TimeOffPending && s.broker != nil {
+		s.broker.Publish(broker.ChannelAdmin, "", broker.Event{
+			Type:      "timeoff.requested",
+			Timestamp: time.Now().Unix(),
+			Data: map[string]interface{}{
+				"id":        off.ID,
+				"tech_id":   off.TechnicianID,
+				"tech_name": actor.Name,
+				"kind":      off.Kind,
+				"date_from": off.DateFrom,
+				"date_to":   off.DateTo,
+				"reason":    off.Reason,
+			},
+		})
+	}
+	return nil
+}
+
+// ReviewTimeOff approves or rejects a pending request and notifies the tech
+func (s *TechScheduleService) ReviewTimeOff(id string, approve bool, note string, actor core.Actor) error {
+	off, err := s.repo.GetTimeOff(id)
+	if err != nil {
+		return fmt.Errorf("time off not found: %w", err)
+	}
+	if off.Status != core.TimeOffPending {
+		return core.ErrTimeOffReviewed
+	}
+
+	off.Status = core.TimeOffRejected
+	if approve {
+		off.Status = core.TimeOffApproved
+	}
+	off.ReviewedBy = actor.Name
+	off.ReviewNote = note
+	off.ReviewedAt = time.Now().UTC().Format(core.DateTimeLayout)
+
+	if err := s.repo.UpdateTimeOff(off); err != nil {
+		return fmt.Errorf("failed to update time off: %w", err)
+	}
+	log.Printf("🗓️ [SCHEDULE] Time off %s for tech %s -> %s by %s", off.ID, off.TechnicianID, off.Status, actor.Name)
+
+	if s.broker != nil && off.TechnicianID != "" {
+		s.broker.Publish(broker.ChannelTech, off.TechnicianID, broker.Event{
+			Type:      "timeoff.reviewed",
+			Timestamp: time.Now().Unix(),
+			Data: map[string]interface{}{
+				"id":        off.ID,
+				"status":    off.Status,
+				"date_from": off.DateFrom,
+				"date_to":   off.DateTo,
+				"note":      note,
+			},
+		})
+	}
+	return nil
+}
+
+func (s *TechScheduleService) PendingTimeOff() ([]*core.TimeOff, error) {
+	return s.repo.ListTimeOffByStatus(core.TimeOffPending)
+}
+
+func (s *TechScheduleService) TimeOffForTechnician(techID string) ([]*core.TimeOff, error) {
+	return s.repo.ListTimeOffByTechnician(techID)
+}
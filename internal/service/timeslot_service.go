@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 	"hvac-system/internal/core"
+	"log"
 	"time"
 )
 
@@ -10,17 +11,23 @@ type TimeSlotService struct {
 	slotRepo    core.TimeSlotRepository
 	bookingRepo core.BookingRepository
 	serviceRepo core.ServiceRepository
+	brandRepo   core.BrandRepository     // [NEW] Travel buffer per brand
+	schedule    core.TechScheduleService // [NEW] Working hours & time off
 }
 
 func NewTimeSlotService(
 	slotRepo core.TimeSlotRepository,
 	bookingRepo core.BookingRepository,
 	serviceRepo core.ServiceRepository,
+	brandRepo core.BrandRepository,
+	schedule core.TechScheduleService,
 ) core.TimeSlotControl {
 	return &TimeSlotService{
 		slotRepo:    slotRepo,
 		bookingRepo: bookingRepo,
 		serviceRepo: serviceRepo,
+		brandRepo:   brandRepo,
+		schedule:    schedule,
 	}
 }
 
@@ -56,8 +63,15 @@ func (s *TimeSlotService) BookSlot(slotID, bookingID string) error {
 	return s.slotRepo.Update(slot)
 }
 
-func (s *TimeSlotService) CheckConflict(techID, date, timeStr string, durationMin int, newSlotID string) error {
-	const TravelBufferMinutes = 30
+// CheckConflict fails with ErrScheduleConflict when the technician is off duty
+// or busy (travel buffer included) at the time; excludeBookingID is the job
+// being moved, which does not conflict with itself
+func (s *TimeSlotService) CheckConflict(techID, date, timeStr string, durationMin int, newSlotID, excludeBookingID string) error {
+	var brand *core.Brand
+	if s.brandRepo != nil {
+		brand, _ = s.brandRepo.GetDefault()
+	}
+	buffer := brand.TravelBuffer()
 
 	// 1. Calculate New Job Times
 	newStart, err := time.Parse("2006-01-02 15:04", date+" "+timeStr)
@@ -66,6 +80,17 @@ func (s *TimeSlotService) CheckConflict(techID, date, timeStr string, durationMi
 	}
 	newEnd := newStart.Add(time.Duration(durationMin) * time.Minute)
 
+	// [NEW] Job must fit inside the tech's working hours (leave, days off)
+	if s.schedule != nil {
+		day, err := s.schedule.DaySchedule(techID, date)
+		if err != nil {
+			return fmt.Errorf("failed to fetch technician schedule: %w", err)
+		}
+		if !day.Covers(newStart, newEnd) {
+			return fmt.Errorf("%w: Technician is off duty from %s to %s", core.ErrScheduleConflict, newStart.Format("15:04"), newEnd.Format("15:04"))
+		}
+	}
+
 	// 2. Fetch existing jobs for technician
 	bookings, err := s.bookingRepo.FindScheduledByTechnician(techID)
 	if err != nil {
@@ -74,6 +99,9 @@ func (s *TimeSlotService) CheckConflict(techID, date, timeStr string, durationMi
 
 	// 3. Iterate and check overlaps
 	for _, job := range bookings {
+		if job.ID == excludeBookingID {
+			continue
+		}
 		// [NEW] Check Slot overlap if both have slot IDs
 		if newSlotID != "" && job.SlotID != nil {
 			if *job.SlotID == newSlotID {
@@ -81,17 +109,13 @@ func (s *TimeSlotService) CheckConflict(techID, date, timeStr string, durationMi
 				// Assuming Slot ID is unique record ID from time_slots collection, checks are safe.
 				// But we should double check date just in case.
 				// With current architecture, Slot ID is unique globally.
-				return fmt.Errorf("Slot conflict: Technician already assigned to this slot")
+				return fmt.Errorf("%w: Technician already assigned to this slot", core.ErrScheduleConflict)
 			}
 		}
 
-		// Parse job time (using booking_time field which format is expected to be "YYYY-MM-DD HH:MM")
-		if len(job.BookingTime) < 16 {
-			continue
-		}
-
-		jobStart, err := time.Parse("2006-01-02 15:04", job.BookingTime)
-		if err != nil {
+		jobStart, ok := core.ParseBookingTime(job.BookingTime)
+		if !ok {
+			log.Printf("⚠️ [TIMESLOT] Booking %s has an unreadable booking_time %q, not checked on %s", job.ID, job.BookingTime, date)
 			continue
 		}
 
@@ -100,30 +124,37 @@ func (s *TimeSlotService) CheckConflict(techID, date, timeStr string, durationMi
 			continue
 		}
 
-		// Determine duration
-		existingDuration := 60
-		if job.ServiceID != "" {
-			svc, err := s.serviceRepo.GetByID(job.ServiceID)
-			if err == nil && svc.DurationMinutes > 0 {
-				existingDuration = svc.DurationMinutes
-			}
-		}
+		existingDuration := s.jobDuration(job.ServiceID)
 
 		jobEnd := jobStart.Add(time.Duration(existingDuration) * time.Minute)
 
 		// Check overlap with buffers
-		bufferedStart := jobStart.Add(-time.Duration(TravelBufferMinutes) * time.Minute)
-		bufferedEnd := jobEnd.Add(time.Duration(TravelBufferMinutes) * time.Minute)
-
-		if newStart.Before(bufferedEnd) && newEnd.After(bufferedStart) {
+		if core.BusyBlock(jobStart, jobEnd.Sub(jobStart), buffer).Overlaps(newStart, newEnd) {
 			return fmt.Errorf(
-				"Conflict: Technician busy from %s to %s (Service: %dm + 30m buffer)",
+				"%w: Technician busy from %s to %s (Service: %dm + %dm buffer)",
+				core.ErrScheduleConflict,
 				jobStart.Format("15:04"),
 				jobEnd.Format("15:04"),
 				existingDuration,
+				int(buffer.Minutes()),
 			)
 		}
 	}
 
 	return nil
 }
+
+// CheckReschedule checks the booking's technician can do it at the new time
+func (s *TimeSlotService) CheckReschedule(booking *core.Booking, date, timeStr, newSlotID string) error {
+	return s.CheckConflict(booking.TechnicianID, date, timeStr, s.jobDuration(booking.ServiceID), newSlotID, booking.ID)
+}
+
+// jobDuration is the service's duration, or the default for unknown services
+func (s *TimeSlotService) jobDuration(serviceID string) int {
+	if serviceID != "" {
+		if svc, err := s.serviceRepo.GetByID(serviceID); err == nil && svc.DurationMinutes > 0 {
+			return svc.DurationMinutes
+		}
+	}
+	return core.DefaultJobDurationMinutes
+}
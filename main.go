@@ -29,6 +29,12 @@ func main() {
 	// 3. Register Routes (passes only the Container)
 	app.RegisterRoutes(pb, container)
 
+	// 4. Background Jobs (cron)
+	app.RegisterJobs(pb, container)
+
+	// 5. CLI commands (export accounting ...)
+	app.RegisterCommands(pb, container)
+
 	if err := pb.Start(); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Lifecycle timestamps written by BookingLifecycle (arrived_at, started_at,
+// completed_at already exist in the snapshot).
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return err
+		}
+
+		if collection.Fields.GetByName("moving_start_at") != nil {
+			return nil // Already added manually
+		}
+
+		collection.Fields.Add(&core.DateField{Name: "moving_start_at"})
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("moving_start_at")
+		return app.Save(collection)
+	})
+}
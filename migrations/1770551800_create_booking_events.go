@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// booking_events: append-only timeline of every booking change (who/when/what)
+func init() {
+	m.Register(func(app core.App) error {
+		if _, err := app.FindCollectionByNameOrId("booking_events"); err == nil {
+			return nil // Already exists
+		}
+
+		bookings, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return err
+		}
+
+		collection := core.NewBaseCollection("booking_events")
+		collection.Fields.Add(
+			&core.RelationField{Name: "booking_id", CollectionId: bookings.Id, MaxSelect: 1, Required: true, CascadeDelete: true},
+			&core.TextField{Name: "type", Required: true},
+			&core.TextField{Name: "from_status"},
+			&core.TextField{Name: "to_status"},
+			&core.SelectField{Name: "actor_type", MaxSelect: 1, Values: []string{"admin", "tech", "customer", "system"}},
+			&core.TextField{Name: "actor_id"},
+			&core.TextField{Name: "actor_name"},
+			&core.TextField{Name: "source"},
+			&core.TextField{Name: "note"},
+			&core.JSONField{Name: "data"},
+			&core.AutodateField{Name: "created", OnCreate: true},
+			&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+		)
+		collection.AddIndex("idx_booking_events_booking", false, "booking_id, created", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("booking_events")
+		if err != nil {
+			return nil
+		}
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Per-brand dispatch settings (settings record = brand in single tenant mode)
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("settings")
+		if err != nil {
+			return err
+		}
+
+		if collection.Fields.GetByName("auto_assign_enabled") == nil {
+			collection.Fields.Add(&core.BoolField{Name: "auto_assign_enabled"})
+		}
+		if collection.Fields.GetByName("auto_assign_after_minutes") == nil {
+			collection.Fields.Add(&core.NumberField{Name: "auto_assign_after_minutes", OnlyInt: true})
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("settings")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("auto_assign_enabled")
+		collection.Fields.RemoveByName("auto_assign_after_minutes")
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Per-brand travel buffer between two jobs of the same technician
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("settings")
+		if err != nil {
+			return err
+		}
+
+		if collection.Fields.GetByName("travel_buffer_minutes") == nil {
+			collection.Fields.Add(&core.NumberField{Name: "travel_buffer_minutes", OnlyInt: true})
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		// Keep the previous hard-coded 30 minutes for existing brands
+		records, err := app.FindAllRecords("settings")
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if record.GetInt("travel_buffer_minutes") > 0 {
+				continue
+			}
+			record.Set("travel_buffer_minutes", 30)
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("settings")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("travel_buffer_minutes")
+		return app.Save(collection)
+	})
+}
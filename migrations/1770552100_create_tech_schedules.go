@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// tech_working_hours: weekly shifts per technician
+// tech_time_off: leave / sick days / holidays (empty technician = whole company)
+func init() {
+	m.Register(func(app core.App) error {
+		techs, err := app.FindCollectionByNameOrId("technicians")
+		if err != nil {
+			return err
+		}
+
+		if _, err := app.FindCollectionByNameOrId("tech_working_hours"); err != nil {
+			hours := core.NewBaseCollection("tech_working_hours")
+			hours.Fields.Add(
+				&core.RelationField{Name: "technician_id", CollectionId: techs.Id, MaxSelect: 1, Required: true, CascadeDelete: true},
+				&core.NumberField{Name: "weekday", OnlyInt: true},
+				&core.TextField{Name: "start_time", Required: true, Pattern: `^\d{2}:\d{2}$`},
+				&core.TextField{Name: "end_time", Required: true, Pattern: `^\d{2}:\d{2}$`},
+				&core.AutodateField{Name: "created", OnCreate: true},
+				&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			hours.AddIndex("idx_tech_working_hours_tech", false, "technician_id, weekday", "")
+			if err := app.Save(hours); err != nil {
+				return err
+			}
+		}
+
+		if _, err := app.FindCollectionByNameOrId("tech_time_off"); err != nil {
+			off := core.NewBaseCollection("tech_time_off")
+			off.Fields.Add(
+				&core.RelationField{Name: "technician_id", CollectionId: techs.Id, MaxSelect: 1, CascadeDelete: true},
+				&core.SelectField{Name: "kind", MaxSelect: 1, Required: true, Values: []string{"leave", "sick", "holiday"}},
+				&core.TextField{Name: "date_from", Required: true},
+				&core.TextField{Name: "date_to", Required: true},
+				&core.TextField{Name: "start_time"},
+				&core.TextField{Name: "end_time"},
+				&core.TextField{Name: "reason"},
+				&core.SelectField{Name: "status", MaxSelect: 1, Required: true, Values: []string{"pending", "approved", "rejected"}},
+				&core.TextField{Name: "requested_by"},
+				&core.TextField{Name: "reviewed_by"},
+				&core.TextField{Name: "review_note"},
+				&core.DateField{Name: "reviewed_at"},
+				&core.AutodateField{Name: "created", OnCreate: true},
+				&core.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			off.AddIndex("idx_tech_time_off_range", false, "technician_id, date_from, date_to", "")
+			off.AddIndex("idx_tech_time_off_status", false, "status", "")
+			if err := app.Save(off); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error {
+		for _, name := range []string{"tech_time_off", "tech_working_hours"} {
+			if collection, err := app.FindCollectionByNameOrId(name); err == nil {
+				if err := app.Delete(collection); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Secret token of the customer self-service link (/b/{token})
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return err
+		}
+
+		if collection.Fields.GetByName("access_token") == nil {
+			collection.Fields.Add(&core.TextField{Name: "access_token", Hidden: true})
+			collection.AddIndex("idx_bookings_access_token", true, "access_token", "access_token != ''")
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		// Backfill existing bookings (raw update: no booking hooks/notifications)
+		var ids []string
+		if err := app.DB().Select("id").From("bookings").
+			Where(dbx.NewExp("access_token = '' OR access_token IS NULL")).
+			Column(&ids); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			buf := make([]byte, 24)
+			if _, err := rand.Read(buf); err != nil {
+				return err
+			}
+			if _, err := app.DB().Update("bookings",
+				dbx.Params{"access_token": hex.EncodeToString(buf)},
+				dbx.HashExp{"id": id},
+			).Execute(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return err
+		}
+
+		collection.RemoveIndex("idx_bookings_access_token")
+		collection.Fields.RemoveByName("access_token")
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,140 @@
+package migrations
+
+import (
+	"hvac-system/internal/core"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// customers: one record per normalized phone, bookings.customer_id links to it.
+// Existing bookings are grouped by phone and linked.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		customers, err := app.FindCollectionByNameOrId("customers")
+		if err != nil {
+			customers = pbCore.NewBaseCollection("customers")
+			customers.Fields.Add(
+				&pbCore.TextField{Name: "phone", Required: true, Pattern: `^0\d{9,10}$`},
+				&pbCore.TextField{Name: "name"},
+				&pbCore.EmailField{Name: "email"},
+				&pbCore.TextField{Name: "notes"},
+				&pbCore.JSONField{Name: "addresses"},
+				&pbCore.AutodateField{Name: "created", OnCreate: true},
+				&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			customers.AddIndex("idx_customers_phone", true, "phone", "")
+			customers.AddIndex("idx_customers_name", false, "name", "")
+			if err := app.Save(customers); err != nil {
+				return err
+			}
+		}
+
+		bookings, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return err
+		}
+		if bookings.Fields.GetByName("customer_id") == nil {
+			bookings.Fields.Add(&pbCore.RelationField{Name: "customer_id", CollectionId: customers.Id, MaxSelect: 1})
+			bookings.AddIndex("idx_bookings_customer", false, "customer_id", "")
+			if err := app.Save(bookings); err != nil {
+				return err
+			}
+		}
+
+		return backfillCustomers(app, customers)
+	}, func(app pbCore.App) error {
+		if bookings, err := app.FindCollectionByNameOrId("bookings"); err == nil {
+			bookings.RemoveIndex("idx_bookings_customer")
+			bookings.Fields.RemoveByName("customer_id")
+			if err := app.Save(bookings); err != nil {
+				return err
+			}
+		}
+		if customers, err := app.FindCollectionByNameOrId("customers"); err == nil {
+			return app.Delete(customers)
+		}
+		return nil
+	})
+}
+
+func backfillCustomers(app pbCore.App, collection *pbCore.Collection) error {
+	var rows []struct {
+		ID             string  `db:"id"`
+		CustomerName   string  `db:"customer_name"`
+		CustomerPhone  string  `db:"customer_phone"`
+		Address        string  `db:"address"`
+		AddressDetails string  `db:"address_details"`
+		Lat            float64 `db:"lat"`
+		Long           float64 `db:"long"`
+	}
+	err := app.DB().Select("id", "customer_name", "customer_phone", "address", "address_details", "lat", "long").
+		From("bookings").
+		Where(dbx.NewExp("customer_id = '' OR customer_id IS NULL")).
+		OrderBy("created ASC").
+		All(&rows)
+	if err != nil {
+		return err
+	}
+
+	byPhone := map[string]*core.Customer{}
+	bookingIDs := map[string][]interface{}{}
+	for _, row := range rows {
+		phone, err := core.NormalizePhoneVN(row.CustomerPhone)
+		if err != nil {
+			continue // Left unlinked, visible in booking history only
+		}
+		c := byPhone[phone]
+		if c == nil {
+			c = &core.Customer{Phone: phone}
+			if existing, err := app.FindFirstRecordByData(collection, "phone", phone); err == nil {
+				c.ID = existing.Id
+				_ = existing.UnmarshalJSONField("addresses", &c.Addresses)
+			}
+			byPhone[phone] = c
+		}
+		if name := strings.TrimSpace(row.CustomerName); name != "" {
+			c.Name = name // Latest booking wins
+		}
+		address := row.AddressDetails
+		if address == "" {
+			address = row.Address
+		}
+		c.AddAddress(core.CustomerAddress{Address: address, Lat: row.Lat, Long: row.Long})
+		bookingIDs[phone] = append(bookingIDs[phone], row.ID)
+	}
+
+	for phone, c := range byPhone {
+		var record *pbCore.Record
+		if c.ID != "" {
+			record, err = app.FindRecordById(collection, c.ID)
+			if err != nil {
+				return err
+			}
+		} else {
+			record = pbCore.NewRecord(collection)
+			record.Set("phone", phone)
+		}
+		if record.GetString("name") == "" {
+			record.Set("name", c.Name)
+		}
+		if c.Addresses == nil {
+			c.Addresses = []core.CustomerAddress{}
+		}
+		record.Set("addresses", c.Addresses)
+		if err := app.Save(record); err != nil {
+			return err
+		}
+
+		// Raw update: no booking hooks/notifications during migration
+		if _, err := app.DB().Update("bookings",
+			dbx.Params{"customer_id": record.Id},
+			dbx.In("id", bookingIDs[phone]...),
+		).Execute(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// equipment: installed units per customer, job_reports.equipment_ids links
+// the units serviced in a job.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		customers, err := app.FindCollectionByNameOrId("customers")
+		if err != nil {
+			return err
+		}
+
+		equipment, err := app.FindCollectionByNameOrId("equipment")
+		if err != nil {
+			equipment = pbCore.NewBaseCollection("equipment")
+			equipment.Fields.Add(
+				&pbCore.RelationField{Name: "customer_id", CollectionId: customers.Id, Required: true, MaxSelect: 1, CascadeDelete: true},
+				&pbCore.TextField{Name: "unit_type"},
+				&pbCore.TextField{Name: "brand"},
+				&pbCore.TextField{Name: "model"},
+				&pbCore.TextField{Name: "serial"},
+				&pbCore.NumberField{Name: "capacity_btu", OnlyInt: true},
+				&pbCore.NumberField{Name: "capacity_hp"},
+				&pbCore.TextField{Name: "refrigerant"},
+				&pbCore.TextField{Name: "install_date"},
+				&pbCore.TextField{Name: "location"},
+				&pbCore.TextField{Name: "notes"},
+				&pbCore.AutodateField{Name: "created", OnCreate: true},
+				&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			equipment.AddIndex("idx_equipment_customer", false, "customer_id", "")
+			equipment.AddIndex("idx_equipment_serial", false, "serial", "")
+			if err := app.Save(equipment); err != nil {
+				return err
+			}
+		}
+
+		reports, err := app.FindCollectionByNameOrId("job_reports")
+		if err != nil {
+			return err
+		}
+		if reports.Fields.GetByName("equipment_ids") == nil {
+			reports.Fields.Add(&pbCore.RelationField{Name: "equipment_ids", CollectionId: equipment.Id, MaxSelect: 50})
+			return app.Save(reports)
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if reports, err := app.FindCollectionByNameOrId("job_reports"); err == nil {
+			reports.Fields.RemoveByName("equipment_ids")
+			if err := app.Save(reports); err != nil {
+				return err
+			}
+		}
+		if equipment, err := app.FindCollectionByNameOrId("equipment"); err == nil {
+			return app.Delete(equipment)
+		}
+		return nil
+	})
+}
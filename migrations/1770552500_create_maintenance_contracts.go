@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// maintenance_contracts: periodic service plans; bookings.contract_id marks
+// the visits generated by the daily cron.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		customers, err := app.FindCollectionByNameOrId("customers")
+		if err != nil {
+			return err
+		}
+		equipment, err := app.FindCollectionByNameOrId("equipment")
+		if err != nil {
+			return err
+		}
+		services, err := app.FindCollectionByNameOrId("services")
+		if err != nil {
+			return err
+		}
+
+		contracts, err := app.FindCollectionByNameOrId("maintenance_contracts")
+		if err != nil {
+			contracts = pbCore.NewBaseCollection("maintenance_contracts")
+			contracts.Fields.Add(
+				&pbCore.RelationField{Name: "customer_id", CollectionId: customers.Id, Required: true, MaxSelect: 1, CascadeDelete: true},
+				&pbCore.RelationField{Name: "equipment_ids", CollectionId: equipment.Id, MaxSelect: 50},
+				&pbCore.RelationField{Name: "service_id", CollectionId: services.Id, Required: true, MaxSelect: 1},
+				&pbCore.NumberField{Name: "interval_months", OnlyInt: true},
+				&pbCore.NumberField{Name: "price"},
+				&pbCore.TextField{Name: "start_date", Required: true},
+				&pbCore.TextField{Name: "end_date", Required: true},
+				&pbCore.TextField{Name: "next_service_date"},
+				&pbCore.SelectField{Name: "status", MaxSelect: 1, Values: []string{"active", "paused", "expired", "cancelled"}},
+				&pbCore.TextField{Name: "last_booking_id"},
+				&pbCore.TextField{Name: "reminder_sent_at"},
+				&pbCore.TextField{Name: "notes"},
+				&pbCore.AutodateField{Name: "created", OnCreate: true},
+				&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			contracts.AddIndex("idx_contracts_customer", false, "customer_id", "")
+			contracts.AddIndex("idx_contracts_status_next", false, "status, next_service_date", "")
+			if err := app.Save(contracts); err != nil {
+				return err
+			}
+		}
+
+		bookings, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return err
+		}
+		if bookings.Fields.GetByName("contract_id") == nil {
+			bookings.Fields.Add(&pbCore.RelationField{Name: "contract_id", CollectionId: contracts.Id, MaxSelect: 1})
+			bookings.AddIndex("idx_bookings_contract", false, "contract_id", "")
+			return app.Save(bookings)
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if bookings, err := app.FindCollectionByNameOrId("bookings"); err == nil {
+			bookings.RemoveIndex("idx_bookings_contract")
+			bookings.Fields.RemoveByName("contract_id")
+			if err := app.Save(bookings); err != nil {
+				return err
+			}
+		}
+		if contracts, err := app.FindCollectionByNameOrId("maintenance_contracts"); err == nil {
+			return app.Delete(contracts)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// quotes: on-site quotation signed by the customer via /quote/{hash};
+// invoices.quote_id records which approved quote priced the invoice.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		bookings, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return err
+		}
+		technicians, err := app.FindCollectionByNameOrId("technicians")
+		if err != nil {
+			return err
+		}
+
+		quotes, err := app.FindCollectionByNameOrId("quotes")
+		if err != nil {
+			quotes = pbCore.NewBaseCollection("quotes")
+			quotes.Fields.Add(
+				&pbCore.RelationField{Name: "booking_id", CollectionId: bookings.Id, Required: true, MaxSelect: 1, CascadeDelete: true},
+				&pbCore.RelationField{Name: "technician_id", CollectionId: technicians.Id, MaxSelect: 1},
+				&pbCore.SelectField{Name: "status", MaxSelect: 1, Values: []string{"pending", "approved", "rejected", "superseded"}},
+				&pbCore.JSONField{Name: "items"},
+				&pbCore.NumberField{Name: "labor_total"},
+				&pbCore.NumberField{Name: "parts_total"},
+				&pbCore.NumberField{Name: "total"},
+				&pbCore.TextField{Name: "notes"},
+				&pbCore.TextField{Name: "public_hash", Required: true, Hidden: true},
+				&pbCore.FileField{Name: "customer_signature", MaxSelect: 1, MaxSize: 2 << 20, MimeTypes: []string{"image/png", "image/jpeg"}},
+				&pbCore.TextField{Name: "decided_at"},
+				&pbCore.TextField{Name: "reject_reason"},
+				&pbCore.AutodateField{Name: "created", OnCreate: true},
+				&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			quotes.AddIndex("idx_quotes_booking", false, "booking_id", "")
+			quotes.AddIndex("idx_quotes_hash", true, "public_hash", "")
+			if err := app.Save(quotes); err != nil {
+				return err
+			}
+		}
+
+		invoices, err := app.FindCollectionByNameOrId("invoices")
+		if err != nil {
+			return err
+		}
+		if invoices.Fields.GetByName("quote_id") == nil {
+			invoices.Fields.Add(&pbCore.RelationField{Name: "quote_id", CollectionId: quotes.Id, MaxSelect: 1})
+			return app.Save(invoices)
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if invoices, err := app.FindCollectionByNameOrId("invoices"); err == nil {
+			invoices.Fields.RemoveByName("quote_id")
+			if err := app.Save(invoices); err != nil {
+				return err
+			}
+		}
+		if quotes, err := app.FindCollectionByNameOrId("quotes"); err == nil {
+			return app.Delete(quotes)
+		}
+		return nil
+	})
+}
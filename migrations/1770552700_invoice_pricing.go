@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Invoice pricing: VAT rates on services/items/brand, discounts and extra
+// labor kept on invoices.adjustments, subtotal/discount/tax stored per line.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		vatValues := []string{"10", "8", "5", "0", "exempt"} // core.VATCodes
+		additions := map[string][]pbCore.Field{
+			"settings": {
+				&pbCore.SelectField{Name: "default_vat", MaxSelect: 1, Values: vatValues},
+				&pbCore.NumberField{Name: "labor_hour_rate"},
+			},
+			"services": {
+				&pbCore.SelectField{Name: "vat_rate", MaxSelect: 1, Values: vatValues},
+			},
+			"inventory_items": {
+				&pbCore.SelectField{Name: "vat_rate", MaxSelect: 1, Values: vatValues},
+			},
+			"job_reports": {
+				&pbCore.NumberField{Name: "extra_hours"},
+				&pbCore.TextField{Name: "extra_hours_note"},
+			},
+			"invoices": {
+				&pbCore.NumberField{Name: "labor_total"},
+				&pbCore.NumberField{Name: "parts_total"},
+				&pbCore.NumberField{Name: "subtotal"},
+				&pbCore.NumberField{Name: "tax_total"},
+				&pbCore.NumberField{Name: "tech_commission"},
+				&pbCore.JSONField{Name: "adjustments"},
+			},
+			"invoice_items": {
+				&pbCore.SelectField{Name: "kind", MaxSelect: 1, Values: []string{"service", "part", "labor"}},
+				&pbCore.TextField{Name: "line_key"},
+				&pbCore.TextField{Name: "unit"},
+				&pbCore.TextField{Name: "vat_rate"},
+				&pbCore.NumberField{Name: "subtotal"},
+				&pbCore.NumberField{Name: "discount"},
+				&pbCore.NumberField{Name: "tax"},
+			},
+		}
+
+		for name, fields := range additions {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			changed := false
+			for _, f := range fields {
+				if collection.Fields.GetByName(f.GetName()) == nil {
+					collection.Fields.Add(f)
+					changed = true
+				}
+			}
+			if changed {
+				if err := app.Save(collection); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		removals := map[string][]string{
+			"settings":        {"default_vat", "labor_hour_rate"},
+			"services":        {"vat_rate"},
+			"inventory_items": {"vat_rate"},
+			"job_reports":     {"extra_hours", "extra_hours_note"},
+			"invoices":        {"subtotal", "tax_total", "adjustments"},
+			"invoice_items":   {"kind", "line_key", "unit", "vat_rate", "subtotal", "discount", "tax"},
+		}
+		for name, fields := range removals {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				continue
+			}
+			for _, f := range fields {
+				collection.Fields.RemoveByName(f)
+			}
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
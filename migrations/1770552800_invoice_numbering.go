@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// invoice_sequences: last invoice number handed out per brand and fiscal year.
+// invoices.invoice_code is assigned from it once, when the invoice is issued.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		sequences, err := app.FindCollectionByNameOrId("invoice_sequences")
+		if err != nil {
+			sequences = pbCore.NewBaseCollection("invoice_sequences")
+			sequences.Fields.Add(
+				&pbCore.TextField{Name: "brand_id", Required: true},
+				&pbCore.NumberField{Name: "year", Required: true, OnlyInt: true},
+				&pbCore.NumberField{Name: "last_number", OnlyInt: true},
+				&pbCore.AutodateField{Name: "created", OnCreate: true},
+				&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			sequences.AddIndex("idx_invoice_sequences_brand_year", true, "brand_id, year", "")
+			if err := app.Save(sequences); err != nil {
+				return err
+			}
+		}
+
+		invoices, err := app.FindCollectionByNameOrId("invoices")
+		if err != nil {
+			return err
+		}
+		if invoices.Fields.GetByName("issued_at") == nil {
+			invoices.Fields.Add(&pbCore.TextField{Name: "issued_at"})
+		}
+		// Safety net behind the sequence: a number can never be used twice
+		invoices.AddIndex("idx_invoices_code", true, "invoice_code", "invoice_code != ''")
+		return app.Save(invoices)
+	}, func(app pbCore.App) error {
+		if invoices, err := app.FindCollectionByNameOrId("invoices"); err == nil {
+			invoices.RemoveIndex("idx_invoices_code")
+			invoices.Fields.RemoveByName("issued_at")
+			if err := app.Save(invoices); err != nil {
+				return err
+			}
+		}
+		if sequences, err := app.FindCollectionByNameOrId("invoice_sequences"); err == nil {
+			return app.Delete(sequences)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// bank_transactions: incoming transfers from the bank webhook (Casso/SePay),
+// statement imports and the local simulator, matched to invoices.
+// settings.bank_webhook_secret authenticates the webhook calls.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		transactions, err := app.FindCollectionByNameOrId("bank_transactions")
+		if err != nil {
+			transactions = pbCore.NewBaseCollection("bank_transactions")
+			transactions.Fields.Add(
+				&pbCore.SelectField{Name: "source", Required: true, MaxSelect: 1, Values: []string{"casso", "sepay", "local", "statement"}},
+				&pbCore.TextField{Name: "external_id", Required: true},
+				&pbCore.TextField{Name: "account_number"},
+				&pbCore.NumberField{Name: "amount"},
+				&pbCore.TextField{Name: "description"},
+				&pbCore.DateField{Name: "transacted_at"},
+				&pbCore.SelectField{Name: "status", Required: true, MaxSelect: 1, Values: []string{"matched", "unmatched", "ignored"}},
+				&pbCore.TextField{Name: "invoice_id"},
+				&pbCore.TextField{Name: "suggested_invoice_id"},
+				&pbCore.TextField{Name: "match_note"},
+				&pbCore.TextField{Name: "reviewed_by"},
+				&pbCore.AutodateField{Name: "created", OnCreate: true},
+				&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			// A webhook retried by the provider or a statement imported twice is recorded once
+			transactions.AddIndex("idx_bank_transactions_ref", true, "source, external_id", "")
+			transactions.AddIndex("idx_bank_transactions_status", false, "status", "")
+			if err := app.Save(transactions); err != nil {
+				return err
+			}
+		}
+
+		settings, err := app.FindCollectionByNameOrId("settings")
+		if err != nil {
+			return err
+		}
+		if settings.Fields.GetByName("bank_webhook_secret") == nil {
+			settings.Fields.Add(&pbCore.TextField{Name: "bank_webhook_secret", Hidden: true})
+			return app.Save(settings)
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if settings, err := app.FindCollectionByNameOrId("settings"); err == nil {
+			settings.Fields.RemoveByName("bank_webhook_secret")
+			if err := app.Save(settings); err != nil {
+				return err
+			}
+		}
+		if transactions, err := app.FindCollectionByNameOrId("bank_transactions"); err == nil {
+			return app.Delete(transactions)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// payments: ledger of deposits, payments and refunds per invoice.
+// invoices.status is derived from it (unpaid, partially_paid, paid, overpaid,
+// refunded) and invoices.amount_paid keeps the net amount received.
+// Invoices already paid get one posted entry for their total.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		payments, err := app.FindCollectionByNameOrId("payments")
+		if err != nil {
+			payments = pbCore.NewBaseCollection("payments")
+			payments.Fields.Add(
+				&pbCore.TextField{Name: "invoice_id", Required: true},
+				&pbCore.TextField{Name: "booking_id"},
+				&pbCore.SelectField{Name: "kind", Required: true, MaxSelect: 1, Values: []string{"payment", "refund"}},
+				&pbCore.NumberField{Name: "amount"},
+				&pbCore.SelectField{Name: "method", Required: true, MaxSelect: 1, Values: []string{"cash", "transfer", "card"}},
+				&pbCore.TextField{Name: "reference"},
+				&pbCore.TextField{Name: "collected_by"}, // technicians / users ID, empty for the bank
+				&pbCore.TextField{Name: "collected_by_name"},
+				&pbCore.DateField{Name: "paid_at"},
+				&pbCore.SelectField{Name: "status", Required: true, MaxSelect: 1, Values: []string{"posted", "pending", "void_pending", "voided", "rejected"}},
+				&pbCore.TextField{Name: "note"},
+				&pbCore.TextField{Name: "requested_by"},
+				&pbCore.TextField{Name: "request_note"},
+				&pbCore.TextField{Name: "approved_by"},
+				&pbCore.TextField{Name: "approved_at"},
+				&pbCore.AutodateField{Name: "created", OnCreate: true},
+				&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			payments.AddIndex("idx_payments_invoice", false, "invoice_id", "")
+			payments.AddIndex("idx_payments_status", false, "status", "")
+			payments.AddIndex("idx_payments_collected_by", false, "collected_by, paid_at", "")
+			if err := app.Save(payments); err != nil {
+				return err
+			}
+		}
+
+		invoices, err := app.FindCollectionByNameOrId("invoices")
+		if err != nil {
+			return err
+		}
+		if status, ok := invoices.Fields.GetByName("status").(*pbCore.SelectField); ok {
+			status.Values = []string{"unpaid", "partially_paid", "paid", "overpaid", "refunded", "cancelled"}
+		}
+		if invoices.Fields.GetByName("amount_paid") == nil {
+			invoices.Fields.Add(&pbCore.NumberField{Name: "amount_paid"})
+		}
+		if err := app.Save(invoices); err != nil {
+			return err
+		}
+
+		paid, err := app.FindRecordsByFilter("invoices", "status = 'paid'", "", 0, 0)
+		if err != nil {
+			return err
+		}
+		for _, invoice := range paid {
+			if existing, _ := app.FindFirstRecordByData("payments", "invoice_id", invoice.Id); existing != nil {
+				continue
+			}
+			method := invoice.GetString("payment_method")
+			if method == "" {
+				method = "cash"
+			}
+			entry := pbCore.NewRecord(payments)
+			entry.Set("invoice_id", invoice.Id)
+			entry.Set("booking_id", invoice.GetString("booking_id"))
+			entry.Set("kind", "payment")
+			entry.Set("amount", invoice.GetFloat("total_amount"))
+			entry.Set("method", method)
+			entry.Set("paid_at", invoice.GetDateTime("updated"))
+			entry.Set("status", "posted")
+			entry.Set("note", "Chuyển từ hóa đơn đã thanh toán")
+			if booking, err := app.FindRecordById("bookings", invoice.GetString("booking_id")); err == nil {
+				entry.Set("collected_by", booking.GetString("technician_id"))
+			}
+			if err := app.Save(entry); err != nil {
+				return err
+			}
+			invoice.Set("amount_paid", invoice.GetFloat("total_amount"))
+			if err := app.Save(invoice); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if invoices, err := app.FindCollectionByNameOrId("invoices"); err == nil {
+			invoices.Fields.RemoveByName("amount_paid")
+			if err := app.Save(invoices); err != nil {
+				return err
+			}
+		}
+		if payments, err := app.FindCollectionByNameOrId("payments"); err == nil {
+			return app.Delete(payments)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// cash_handovers: cash technicians hand in to the office. The cash on hand is
+// the cash payments they collected (payments.collected_by) minus the
+// handovers an admin confirmed.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		if _, err := app.FindCollectionByNameOrId("cash_handovers"); err == nil {
+			return nil
+		}
+		handovers := pbCore.NewBaseCollection("cash_handovers")
+		handovers.Fields.Add(
+			&pbCore.TextField{Name: "technician_id", Required: true},
+			&pbCore.TextField{Name: "technician_name"},
+			&pbCore.NumberField{Name: "expected"},
+			&pbCore.NumberField{Name: "declared"},
+			&pbCore.NumberField{Name: "received"},
+			&pbCore.NumberField{Name: "discrepancy"},
+			&pbCore.SelectField{Name: "status", Required: true, MaxSelect: 1, Values: []string{"submitted", "confirmed", "rejected"}},
+			&pbCore.TextField{Name: "note"},
+			&pbCore.DateField{Name: "submitted_at"},
+			&pbCore.TextField{Name: "confirmed_by"},
+			&pbCore.DateField{Name: "confirmed_at"},
+			&pbCore.TextField{Name: "admin_note"},
+			&pbCore.AutodateField{Name: "created", OnCreate: true},
+			&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+		)
+		handovers.AddIndex("idx_cash_handovers_tech", false, "technician_id, submitted_at", "")
+		handovers.AddIndex("idx_cash_handovers_status", false, "status", "")
+		return app.Save(handovers)
+	}, func(app pbCore.App) error {
+		if handovers, err := app.FindCollectionByNameOrId("cash_handovers"); err == nil {
+			return app.Delete(handovers)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// payroll_statements: monthly pay per technician (lines kept as JSON, locked
+// once approved). payroll_adjustments: bonuses, penalties and advances entered
+// by admins. settings.payroll_no_show_penalty is deducted per "customer not
+// home" cancellation.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		if _, err := app.FindCollectionByNameOrId("payroll_statements"); err != nil {
+			statements := pbCore.NewBaseCollection("payroll_statements")
+			statements.Fields.Add(
+				&pbCore.TextField{Name: "technician_id", Required: true},
+				&pbCore.TextField{Name: "technician_name"},
+				&pbCore.TextField{Name: "period", Required: true}, // YYYY-MM
+				&pbCore.NumberField{Name: "base_salary"},
+				&pbCore.NumberField{Name: "commission"},
+				&pbCore.NumberField{Name: "bonus"},
+				&pbCore.NumberField{Name: "penalty"},
+				&pbCore.NumberField{Name: "advance"},
+				&pbCore.NumberField{Name: "shortage"},
+				&pbCore.NumberField{Name: "net_pay"},
+				&pbCore.JSONField{Name: "lines"},
+				&pbCore.SelectField{Name: "status", Required: true, MaxSelect: 1, Values: []string{"draft", "approved"}},
+				&pbCore.TextField{Name: "approved_by"},
+				&pbCore.TextField{Name: "approved_at"},
+				&pbCore.AutodateField{Name: "created", OnCreate: true},
+				&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			statements.AddIndex("idx_payroll_statements_tech_period", true, "technician_id, period", "")
+			statements.AddIndex("idx_payroll_statements_period", false, "period", "")
+			if err := app.Save(statements); err != nil {
+				return err
+			}
+		}
+
+		if _, err := app.FindCollectionByNameOrId("payroll_adjustments"); err != nil {
+			adjustments := pbCore.NewBaseCollection("payroll_adjustments")
+			adjustments.Fields.Add(
+				&pbCore.TextField{Name: "technician_id", Required: true},
+				&pbCore.TextField{Name: "period", Required: true},
+				&pbCore.SelectField{Name: "kind", Required: true, MaxSelect: 1, Values: []string{"bonus", "penalty", "advance"}},
+				&pbCore.NumberField{Name: "amount"},
+				&pbCore.TextField{Name: "description"},
+				&pbCore.TextField{Name: "created_by"},
+				&pbCore.AutodateField{Name: "created", OnCreate: true},
+				&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+			)
+			adjustments.AddIndex("idx_payroll_adjustments_tech_period", false, "technician_id, period", "")
+			if err := app.Save(adjustments); err != nil {
+				return err
+			}
+		}
+
+		settings, err := app.FindCollectionByNameOrId("settings")
+		if err != nil {
+			return err
+		}
+		if settings.Fields.GetByName("payroll_no_show_penalty") == nil {
+			settings.Fields.Add(&pbCore.NumberField{Name: "payroll_no_show_penalty"})
+			return app.Save(settings)
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if settings, err := app.FindCollectionByNameOrId("settings"); err == nil {
+			settings.Fields.RemoveByName("payroll_no_show_penalty")
+			if err := app.Save(settings); err != nil {
+				return err
+			}
+		}
+		for _, name := range []string{"payroll_adjustments", "payroll_statements"} {
+			if collection, err := app.FindCollectionByNameOrId(name); err == nil {
+				if err := app.Delete(collection); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
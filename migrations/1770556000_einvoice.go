@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// E-invoicing: seller details on settings (tax code, address, email, series
+// suffix) and the e-invoice state on invoices. einvoice keeps the current and
+// superseded e-invoices as JSON; status and lookup code are copied to their
+// own columns for filtering and customer lookups.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		additions := map[string][]pbCore.Field{
+			"settings": {
+				&pbCore.TextField{Name: "tax_code"},
+				&pbCore.TextField{Name: "address"},
+				&pbCore.TextField{Name: "email"},
+				&pbCore.TextField{Name: "einvoice_series"},
+			},
+			"invoices": {
+				&pbCore.JSONField{Name: "einvoice"},
+				&pbCore.SelectField{Name: "einvoice_status", MaxSelect: 1, Values: []string{"issued", "cancelled"}},
+				&pbCore.TextField{Name: "einvoice_lookup_code"},
+			},
+		}
+		for name, fields := range additions {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			for _, f := range fields {
+				if collection.Fields.GetByName(f.GetName()) == nil {
+					collection.Fields.Add(f)
+				}
+			}
+			if name == "invoices" {
+				collection.AddIndex("idx_invoices_einvoice_lookup", false, "einvoice_lookup_code", "einvoice_lookup_code != ''")
+			}
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		removals := map[string][]string{
+			"settings": {"tax_code", "address", "email", "einvoice_series"},
+			"invoices": {"einvoice", "einvoice_status", "einvoice_lookup_code"},
+		}
+		for name, fields := range removals {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				continue
+			}
+			collection.RemoveIndex("idx_invoices_einvoice_lookup")
+			for _, f := range fields {
+				collection.Fields.RemoveByName(f)
+			}
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
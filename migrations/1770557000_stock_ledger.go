@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"hvac-system/internal/core"
+
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// stock_transfers becomes the stock ledger: movements get a creation date,
+// legacy rows name the main warehouse on both sides, and an "opening"
+// movement per balance makes the ledger agree with the stock on hand today.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		transfers, err := app.FindCollectionByNameOrId("stock_transfers")
+		if err != nil {
+			return err
+		}
+		if transfers.Fields.GetByName("created") != nil {
+			return nil
+		}
+		transfers.Fields.Add(
+			&pbCore.AutodateField{Name: "created", OnCreate: true},
+		)
+		transfers.AddIndex("idx_transfers_item_created", false, "item_id, created", "")
+		transfers.AddIndex("idx_transfers_from", false, "from_id", "")
+		if err := app.Save(transfers); err != nil {
+			return err
+		}
+
+		// Legacy rows left the main warehouse side empty
+		if _, err := app.DB().NewQuery(
+			"UPDATE stock_transfers SET from_id = 'main' WHERE transfer_type = 'main_to_tech' AND from_id = ''",
+		).Execute(); err != nil {
+			return err
+		}
+		if _, err := app.DB().NewQuery(
+			"UPDATE stock_transfers SET to_id = 'main' WHERE transfer_type = 'tech_to_main' AND to_id = ''",
+		).Execute(); err != nil {
+			return err
+		}
+
+		rows, err := app.FindAllRecords("stock_transfers")
+		if err != nil {
+			return err
+		}
+		movements := make([]*core.StockMovement, 0, len(rows))
+		for _, r := range rows {
+			movements = append(movements, &core.StockMovement{
+				FromID:   r.GetString("from_id"),
+				ToID:     r.GetString("to_id"),
+				ItemID:   r.GetString("item_id"),
+				Quantity: r.GetFloat("quantity"),
+			})
+		}
+
+		recorded := map[core.StockKey]float64{}
+		items, err := app.FindAllRecords("inventory_items")
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			recorded[core.StockKey{ItemID: item.Id, Location: core.StockMain}] = item.GetFloat("stock_quantity")
+		}
+		trucks, err := app.FindAllRecords("tech_inventory")
+		if err != nil {
+			return err
+		}
+		for _, r := range trucks {
+			recorded[core.StockKey{ItemID: r.GetString("item_id"), Location: r.GetString("technician_id")}] = r.GetFloat("quantity")
+		}
+
+		for _, d := range core.CompareStock(recorded, core.StockBalances(movements)) {
+			opening := pbCore.NewRecord(transfers)
+			opening.Set("transfer_type", core.MoveOpening)
+			opening.Set("to_id", d.Location)
+			opening.Set("item_id", d.ItemID)
+			opening.Set("quantity", d.Difference())
+			opening.Set("note", "Số dư đầu khi bật sổ kho")
+			opening.Set("created_by", "system")
+			if err := app.Save(opening); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if _, err := app.DB().NewQuery("DELETE FROM stock_transfers WHERE transfer_type = 'opening'").Execute(); err != nil {
+			return err
+		}
+		transfers, err := app.FindCollectionByNameOrId("stock_transfers")
+		if err != nil {
+			return nil
+		}
+		transfers.RemoveIndex("idx_transfers_item_created")
+		transfers.RemoveIndex("idx_transfers_from")
+		transfers.Fields.RemoveByName("created")
+		return app.Save(transfers)
+	})
+}
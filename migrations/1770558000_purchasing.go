@@ -0,0 +1,132 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Purchasing: supplier directory, purchase orders (lines as JSON with the
+// quantity received so far), goods receipts posted into the main warehouse,
+// supplier invoices with their payments. inventory_items gets a preferred
+// supplier used to draft POs from low-stock alerts.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		collections := map[string]func(c *pbCore.Collection){
+			"suppliers": func(c *pbCore.Collection) {
+				c.Fields.Add(
+					&pbCore.TextField{Name: "name", Required: true},
+					&pbCore.TextField{Name: "tax_code"},
+					&pbCore.TextField{Name: "contact_name"},
+					&pbCore.TextField{Name: "phone"},
+					&pbCore.TextField{Name: "email"},
+					&pbCore.TextField{Name: "address"},
+					&pbCore.NumberField{Name: "payment_terms", OnlyInt: true}, // days
+					&pbCore.TextField{Name: "note"},
+					&pbCore.BoolField{Name: "active"},
+					&pbCore.AutodateField{Name: "created", OnCreate: true},
+					&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+				)
+				c.AddIndex("idx_suppliers_name", false, "name", "")
+			},
+			"purchase_orders": func(c *pbCore.Collection) {
+				c.Fields.Add(
+					&pbCore.TextField{Name: "code", Required: true},
+					&pbCore.TextField{Name: "supplier_id", Required: true},
+					&pbCore.SelectField{Name: "status", Required: true, MaxSelect: 1,
+						Values: []string{"draft", "ordered", "partially_received", "received", "cancelled"}},
+					&pbCore.TextField{Name: "expected_date"},
+					&pbCore.TextField{Name: "note"},
+					&pbCore.JSONField{Name: "lines"},
+					&pbCore.NumberField{Name: "total"},
+					&pbCore.TextField{Name: "created_by"},
+					&pbCore.TextField{Name: "ordered_at"},
+					&pbCore.AutodateField{Name: "created", OnCreate: true},
+					&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+				)
+				c.AddIndex("idx_purchase_orders_code", true, "code", "")
+				c.AddIndex("idx_purchase_orders_supplier", false, "supplier_id, status", "")
+			},
+			"goods_receipts": func(c *pbCore.Collection) {
+				c.Fields.Add(
+					&pbCore.TextField{Name: "code", Required: true},
+					&pbCore.TextField{Name: "po_id", Required: true},
+					&pbCore.TextField{Name: "supplier_id"},
+					&pbCore.JSONField{Name: "lines"},
+					&pbCore.NumberField{Name: "total"},
+					&pbCore.TextField{Name: "note"},
+					&pbCore.TextField{Name: "received_by"},
+					&pbCore.AutodateField{Name: "created", OnCreate: true},
+				)
+				c.AddIndex("idx_goods_receipts_code", true, "code", "")
+				c.AddIndex("idx_goods_receipts_po", false, "po_id", "")
+			},
+			"supplier_invoices": func(c *pbCore.Collection) {
+				c.Fields.Add(
+					&pbCore.TextField{Name: "supplier_id", Required: true},
+					&pbCore.TextField{Name: "po_id"},
+					&pbCore.TextField{Name: "invoice_no", Required: true},
+					&pbCore.TextField{Name: "invoice_date"},
+					&pbCore.TextField{Name: "due_date"},
+					&pbCore.NumberField{Name: "amount"},
+					&pbCore.NumberField{Name: "paid"},
+					&pbCore.SelectField{Name: "status", Required: true, MaxSelect: 1,
+						Values: []string{"unpaid", "partially_paid", "paid"}},
+					&pbCore.TextField{Name: "note"},
+					&pbCore.TextField{Name: "created_by"},
+					&pbCore.AutodateField{Name: "created", OnCreate: true},
+					&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+				)
+				c.AddIndex("idx_supplier_invoices_no", true, "supplier_id, invoice_no", "")
+				c.AddIndex("idx_supplier_invoices_status", false, "status, due_date", "")
+			},
+			"supplier_payments": func(c *pbCore.Collection) {
+				c.Fields.Add(
+					&pbCore.TextField{Name: "invoice_id", Required: true},
+					&pbCore.TextField{Name: "supplier_id"},
+					&pbCore.NumberField{Name: "amount"},
+					&pbCore.SelectField{Name: "method", MaxSelect: 1, Values: []string{"cash", "transfer", "card"}},
+					&pbCore.TextField{Name: "reference"},
+					&pbCore.TextField{Name: "note"},
+					&pbCore.TextField{Name: "created_by"},
+					&pbCore.AutodateField{Name: "created", OnCreate: true},
+				)
+				c.AddIndex("idx_supplier_payments_invoice", false, "invoice_id", "")
+			},
+		}
+		for _, name := range []string{"suppliers", "purchase_orders", "goods_receipts", "supplier_invoices", "supplier_payments"} {
+			if _, err := app.FindCollectionByNameOrId(name); err == nil {
+				continue
+			}
+			collection := pbCore.NewBaseCollection(name)
+			collections[name](collection)
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		items, err := app.FindCollectionByNameOrId("inventory_items")
+		if err != nil {
+			return err
+		}
+		if items.Fields.GetByName("preferred_supplier_id") == nil {
+			items.Fields.Add(&pbCore.TextField{Name: "preferred_supplier_id"})
+			return app.Save(items)
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if items, err := app.FindCollectionByNameOrId("inventory_items"); err == nil {
+			items.Fields.RemoveByName("preferred_supplier_id")
+			if err := app.Save(items); err != nil {
+				return err
+			}
+		}
+		for _, name := range []string{"supplier_payments", "supplier_invoices", "goods_receipts", "purchase_orders", "suppliers"} {
+			if collection, err := app.FindCollectionByNameOrId(name); err == nil {
+				if err := app.Delete(collection); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,141 @@
+package migrations
+
+import (
+	"hvac-system/internal/core"
+
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Inventory costing: stock_cost_layers hold what each receipt cost per
+// location, stock_transfers record the unit cost every movement moved at and
+// job_parts the actual cost of the parts used. settings.inventory_costing
+// picks FIFO or the weighted average. Existing movements, parts and stock on
+// hand are costed at today's purchase price.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		if _, err := app.FindCollectionByNameOrId("stock_cost_layers"); err == nil {
+			return nil
+		}
+		layers := pbCore.NewBaseCollection("stock_cost_layers")
+		layers.Fields.Add(
+			&pbCore.TextField{Name: "item_id", Required: true},
+			&pbCore.TextField{Name: "location", Required: true},
+			&pbCore.NumberField{Name: "quantity"},
+			&pbCore.NumberField{Name: "remaining"},
+			&pbCore.NumberField{Name: "unit_cost"},
+			&pbCore.TextField{Name: "received_at"},
+			&pbCore.AutodateField{Name: "created", OnCreate: true},
+		)
+		layers.AddIndex("idx_cost_layers_item_location", false, "item_id, location, received_at", "")
+		if err := app.Save(layers); err != nil {
+			return err
+		}
+
+		transfers, err := app.FindCollectionByNameOrId("stock_transfers")
+		if err != nil {
+			return err
+		}
+		if transfers.Fields.GetByName("unit_cost") == nil {
+			transfers.Fields.Add(&pbCore.NumberField{Name: "unit_cost"})
+			if err := app.Save(transfers); err != nil {
+				return err
+			}
+		}
+		parts, err := app.FindCollectionByNameOrId("job_parts")
+		if err != nil {
+			return err
+		}
+		if parts.Fields.GetByName("unit_cost") == nil {
+			parts.Fields.Add(
+				&pbCore.NumberField{Name: "unit_cost"},
+				&pbCore.NumberField{Name: "cost_total"},
+			)
+			if err := app.Save(parts); err != nil {
+				return err
+			}
+		}
+		settings, err := app.FindCollectionByNameOrId("settings")
+		if err != nil {
+			return err
+		}
+		if settings.Fields.GetByName("inventory_costing") == nil {
+			settings.Fields.Add(&pbCore.SelectField{Name: "inventory_costing", MaxSelect: 1,
+				Values: []string{core.CostingAverage, core.CostingFIFO}})
+			if err := app.Save(settings); err != nil {
+				return err
+			}
+		}
+
+		// History has no cost: take today's purchase price
+		if _, err := app.DB().NewQuery(
+			"UPDATE stock_transfers SET unit_cost = COALESCE((SELECT price_import FROM inventory_items WHERE inventory_items.id = stock_transfers.item_id), 0)",
+		).Execute(); err != nil {
+			return err
+		}
+		if _, err := app.DB().NewQuery(
+			"UPDATE job_parts SET unit_cost = COALESCE((SELECT price_import FROM inventory_items WHERE inventory_items.id = job_parts.item_id), 0), " +
+				"cost_total = quantity * COALESCE((SELECT price_import FROM inventory_items WHERE inventory_items.id = job_parts.item_id), 0)",
+		).Execute(); err != nil {
+			return err
+		}
+
+		// One opening layer per balance on hand
+		items, err := app.FindAllRecords("inventory_items")
+		if err != nil {
+			return err
+		}
+		cost := map[string]float64{}
+		opening := map[core.StockKey]float64{}
+		for _, item := range items {
+			cost[item.Id] = item.GetFloat("price_import")
+			opening[core.StockKey{ItemID: item.Id, Location: core.StockMain}] = item.GetFloat("stock_quantity")
+		}
+		trucks, err := app.FindAllRecords("tech_inventory")
+		if err != nil {
+			return err
+		}
+		for _, r := range trucks {
+			opening[core.StockKey{ItemID: r.GetString("item_id"), Location: r.GetString("technician_id")}] = r.GetFloat("quantity")
+		}
+		for key, qty := range opening {
+			if qty <= core.StockEpsilon {
+				continue
+			}
+			layer := pbCore.NewRecord(layers)
+			layer.Set("item_id", key.ItemID)
+			layer.Set("location", key.Location)
+			layer.Set("quantity", qty)
+			layer.Set("remaining", qty)
+			layer.Set("unit_cost", cost[key.ItemID])
+			if err := app.Save(layer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if settings, err := app.FindCollectionByNameOrId("settings"); err == nil {
+			settings.Fields.RemoveByName("inventory_costing")
+			if err := app.Save(settings); err != nil {
+				return err
+			}
+		}
+		if parts, err := app.FindCollectionByNameOrId("job_parts"); err == nil {
+			parts.Fields.RemoveByName("unit_cost")
+			parts.Fields.RemoveByName("cost_total")
+			if err := app.Save(parts); err != nil {
+				return err
+			}
+		}
+		if transfers, err := app.FindCollectionByNameOrId("stock_transfers"); err == nil {
+			transfers.Fields.RemoveByName("unit_cost")
+			if err := app.Save(transfers); err != nil {
+				return err
+			}
+		}
+		if layers, err := app.FindCollectionByNameOrId("stock_cost_layers"); err == nil {
+			return app.Delete(layers)
+		}
+		return nil
+	})
+}
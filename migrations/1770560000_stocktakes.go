@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Stocktakes (kiểm kê) of the main warehouse and the trucks: the snapshot of
+// balances and counts as JSON lines, with the variance value kept per
+// stocktake for the history of each technician.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		if _, err := app.FindCollectionByNameOrId("stocktakes"); err == nil {
+			return nil
+		}
+		collection := pbCore.NewBaseCollection("stocktakes")
+		collection.Fields.Add(
+			&pbCore.TextField{Name: "code", Required: true},
+			&pbCore.TextField{Name: "location", Required: true}, // "main" or a technician ID
+			&pbCore.SelectField{Name: "status", Required: true, MaxSelect: 1,
+				Values: []string{"counting", "submitted", "approved", "cancelled"}},
+			&pbCore.TextField{Name: "note"},
+			&pbCore.JSONField{Name: "lines"},
+			&pbCore.NumberField{Name: "shortage"},
+			&pbCore.NumberField{Name: "surplus"},
+			&pbCore.TextField{Name: "created_by"},
+			&pbCore.TextField{Name: "counted_by"},
+			&pbCore.TextField{Name: "submitted_at"},
+			&pbCore.TextField{Name: "approved_by"},
+			&pbCore.TextField{Name: "approved_at"},
+			&pbCore.AutodateField{Name: "created", OnCreate: true},
+			&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+		)
+		collection.AddIndex("idx_stocktakes_code", true, "code", "")
+		collection.AddIndex("idx_stocktakes_location", false, "location, status", "")
+		return app.Save(collection)
+	}, func(app pbCore.App) error {
+		if collection, err := app.FindCollectionByNameOrId("stocktakes"); err == nil {
+			return app.Delete(collection)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"hvac-system/internal/core"
+
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Serial and lot tracking: inventory_items.tracking marks the items tracked,
+// stock_lots holds where each serial or lot number is, and the ledger and
+// job_parts keep the numbers moved. An installed serial is linked to the
+// customer equipment it went into.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		if _, err := app.FindCollectionByNameOrId("stock_lots"); err == nil {
+			return nil
+		}
+		lots := pbCore.NewBaseCollection("stock_lots")
+		lots.Fields.Add(
+			&pbCore.TextField{Name: "item_id", Required: true},
+			&pbCore.TextField{Name: "number", Required: true},
+			&pbCore.TextField{Name: "location"}, // "main", a technician ID, empty once installed
+			&pbCore.NumberField{Name: "quantity"},
+			&pbCore.SelectField{Name: "status", Required: true, MaxSelect: 1,
+				Values: []string{core.LotInStock, core.LotInstalled}},
+			&pbCore.TextField{Name: "received_at"},
+			&pbCore.TextField{Name: "job_id"},
+			&pbCore.TextField{Name: "job_report_id"},
+			&pbCore.TextField{Name: "job_part_id"},
+			&pbCore.TextField{Name: "equipment_id"},
+			&pbCore.TextField{Name: "installed_at"},
+			&pbCore.AutodateField{Name: "created", OnCreate: true},
+			&pbCore.AutodateField{Name: "updated", OnCreate: true, OnUpdate: true},
+		)
+		lots.AddIndex("idx_stock_lots_item_number", false, "item_id, number, location", "")
+		lots.AddIndex("idx_stock_lots_number", false, "number", "")
+		lots.AddIndex("idx_stock_lots_equipment", false, "equipment_id", "")
+		if err := app.Save(lots); err != nil {
+			return err
+		}
+
+		items, err := app.FindCollectionByNameOrId("inventory_items")
+		if err != nil {
+			return err
+		}
+		if items.Fields.GetByName("tracking") == nil {
+			items.Fields.Add(&pbCore.SelectField{Name: "tracking", MaxSelect: 1,
+				Values: []string{core.TrackSerial, core.TrackLot}})
+			if err := app.Save(items); err != nil {
+				return err
+			}
+		}
+		transfers, err := app.FindCollectionByNameOrId("stock_transfers")
+		if err != nil {
+			return err
+		}
+		if transfers.Fields.GetByName("lots") == nil {
+			transfers.Fields.Add(&pbCore.JSONField{Name: "lots"})
+			if err := app.Save(transfers); err != nil {
+				return err
+			}
+		}
+		parts, err := app.FindCollectionByNameOrId("job_parts")
+		if err != nil {
+			return err
+		}
+		if parts.Fields.GetByName("lots") == nil {
+			parts.Fields.Add(
+				&pbCore.JSONField{Name: "lots"},
+				&pbCore.TextField{Name: "equipment_id"},
+			)
+			return app.Save(parts)
+		}
+		return nil
+	}, func(app pbCore.App) error {
+		if parts, err := app.FindCollectionByNameOrId("job_parts"); err == nil {
+			parts.Fields.RemoveByName("lots")
+			parts.Fields.RemoveByName("equipment_id")
+			if err := app.Save(parts); err != nil {
+				return err
+			}
+		}
+		if transfers, err := app.FindCollectionByNameOrId("stock_transfers"); err == nil {
+			transfers.Fields.RemoveByName("lots")
+			if err := app.Save(transfers); err != nil {
+				return err
+			}
+		}
+		if items, err := app.FindCollectionByNameOrId("inventory_items"); err == nil {
+			items.Fields.RemoveByName("tracking")
+			if err := app.Save(items); err != nil {
+				return err
+			}
+		}
+		if lots, err := app.FindCollectionByNameOrId("stock_lots"); err == nil {
+			return app.Delete(lots)
+		}
+		return nil
+	})
+}
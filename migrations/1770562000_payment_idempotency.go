@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// payments.idempotency_key: a payment that must be recorded once (the cash
+// collected when a job is completed) carries a key, unique when set, so a
+// double submit cannot post it twice.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		payments, err := app.FindCollectionByNameOrId("payments")
+		if err != nil {
+			return err
+		}
+		if payments.Fields.GetByName("idempotency_key") != nil {
+			return nil
+		}
+		payments.Fields.Add(&pbCore.TextField{Name: "idempotency_key"})
+		payments.AddIndex("idx_payments_idempotency_key", true, "idempotency_key", "idempotency_key != ''")
+		return app.Save(payments)
+	}, func(app pbCore.App) error {
+		payments, err := app.FindCollectionByNameOrId("payments")
+		if err != nil {
+			return nil
+		}
+		payments.RemoveIndex("idx_payments_idempotency_key")
+		payments.Fields.RemoveByName("idempotency_key")
+		return app.Save(payments)
+	})
+}
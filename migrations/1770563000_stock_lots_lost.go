@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"hvac-system/internal/core"
+
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// stock_lots.status "lost": a serial found missing at a stocktake leaves the
+// stock with its last location kept.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		lots, err := app.FindCollectionByNameOrId("stock_lots")
+		if err != nil {
+			return err
+		}
+		if status, ok := lots.Fields.GetByName("status").(*pbCore.SelectField); ok {
+			status.Values = []string{core.LotInStock, core.LotInstalled, core.LotLost}
+		}
+		return app.Save(lots)
+	}, func(app pbCore.App) error {
+		lots, err := app.FindCollectionByNameOrId("stock_lots")
+		if err != nil {
+			return nil
+		}
+		if status, ok := lots.Fields.GetByName("status").(*pbCore.SelectField); ok {
+			status.Values = []string{core.LotInStock, core.LotInstalled}
+		}
+		return app.Save(lots)
+	})
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"hvac-system/internal/core"
+	"slices"
+
+	pbCore "github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// bookings.job_status "quoting": the job waits for the customer to sign an
+// on-site quote, then goes back to working.
+func init() {
+	m.Register(func(app pbCore.App) error {
+		bookings, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return err
+		}
+		status, ok := bookings.Fields.GetByName("job_status").(*pbCore.SelectField)
+		if !ok || slices.Contains(status.Values, core.StatusQuoting) {
+			return nil
+		}
+		i := slices.Index(status.Values, core.StatusWorking) + 1
+		status.Values = slices.Insert(status.Values, i, core.StatusQuoting)
+		return app.Save(bookings)
+	}, func(app pbCore.App) error {
+		bookings, err := app.FindCollectionByNameOrId("bookings")
+		if err != nil {
+			return nil
+		}
+		if status, ok := bookings.Fields.GetByName("job_status").(*pbCore.SelectField); ok {
+			status.Values = slices.DeleteFunc(status.Values, func(v string) bool { return v == core.StatusQuoting })
+		}
+		return app.Save(bookings)
+	})
+}
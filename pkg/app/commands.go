@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	internalApp "hvac-system/internal/app"
+	domain "hvac-system/internal/core"
+	"hvac-system/pkg/services"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/spf13/cobra"
+)
+
+// RegisterCommands adds the CLI commands next to serve / migrate
+func RegisterCommands(pb *pocketbase.PocketBase, c *internalApp.Container) {
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export data for external tools",
+	}
+	export.AddCommand(accountingCommand(c))
+	pb.RootCmd.AddCommand(export)
+}
+
+// ./hvac-system export accounting --from 2026-03-01 --to 2026-03-31 [--format misa|csv] [--out file]
+func accountingCommand(c *internalApp.Container) *cobra.Command {
+	var fromStr, toStr, format, out string
+	cmd := &cobra.Command{
+		Use:   "accounting",
+		Short: "Export the accounting journal (MISA Excel or generic CSV)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, to, err := domain.AccountingRange(fromStr, toStr)
+			if err != nil {
+				return err
+			}
+			entries, err := c.JournalService.Journal(from, to)
+			if err != nil {
+				return err
+			}
+
+			var data []byte
+			switch format {
+			case "misa":
+				data, err = services.AccountingXLSX(entries, fromStr, toStr)
+			case "csv":
+				data, err = services.AccountingCSV(entries)
+			default:
+				return fmt.Errorf("unknown format %q (misa, csv)", format)
+			}
+			if err != nil {
+				return err
+			}
+
+			if out == "" {
+				ext := "xlsx"
+				if format == "csv" {
+					ext = "csv"
+				}
+				out = fmt.Sprintf("so-nhat-ky-%s_%s.%s", fromStr, toStr, ext)
+			}
+			if err := os.WriteFile(out, data, 0o644); err != nil {
+				return err
+			}
+			fmt.Printf("✅ %d journal entries written to %s\n", len(entries), out)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&fromStr, "from", "", "first day, YYYY-MM-DD (required)")
+	cmd.Flags().StringVar(&toStr, "to", "", "last day included, YYYY-MM-DD (required)")
+	cmd.Flags().StringVar(&format, "format", "misa", "misa (Excel import layout) or csv")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "output file (default so-nhat-ky-<from>_<to>.<ext>)")
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"log"
+	"time"
+
+	internalApp "hvac-system/internal/app"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+// RegisterJobs schedules background jobs on the PocketBase cron
+func RegisterJobs(pb *pocketbase.PocketBase, c *internalApp.Container) {
+	// Auto-dispatch: no-op unless enabled in brand settings
+	pb.Cron().MustAdd("auto_dispatch", "* * * * *", func() {
+		n, err := c.DispatchService.AutoAssignPending()
+		if err != nil {
+			log.Printf("⚠️ [CRON] auto_dispatch failed: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("✅ [CRON] auto_dispatch assigned %d booking(s)", n)
+		}
+	})
+
+	// Maintenance contracts: expire, book upcoming visits, remind customers (07:00 daily)
+	pb.Cron().MustAdd("maintenance_contracts", "0 7 * * *", func() {
+		now := time.Now()
+		if n, err := c.ContractService.ExpireEnded(now); err != nil {
+			log.Printf("⚠️ [CRON] contract expiry failed: %v", err)
+		} else if n > 0 {
+			log.Printf("✅ [CRON] %d contract(s) expired", n)
+		}
+		// Some contracts may fail while the others are booked
+		n, err := c.ContractService.GenerateDueBookings(now)
+		if err != nil {
+			log.Printf("⚠️ [CRON] contract bookings failed: %v", err)
+		}
+		if n > 0 {
+			log.Printf("✅ [CRON] %d contract booking(s) created", n)
+		}
+		if n, err := c.ContractService.SendReminders(now); err != nil {
+			log.Printf("⚠️ [CRON] contract reminders failed: %v", err)
+		} else if n > 0 {
+			log.Printf("✅ [CRON] %d contract reminder(s) sent", n)
+		}
+	})
+
+	// Stock ledger check: stored balances must match the replay (02:00 daily)
+	pb.Cron().MustAdd("stock_ledger_check", "0 2 * * *", func() {
+		diffs, err := c.InventoryService.CheckStockLedger()
+		if err != nil {
+			log.Printf("⚠️ [CRON] stock ledger check failed: %v", err)
+			return
+		}
+		for _, d := range diffs {
+			log.Printf("⚠️ [CRON] stock mismatch %s (%s) at %s: stored %.2f, ledger %.2f",
+				d.ItemName, d.ItemID, d.Location, d.Recorded, d.Ledger)
+		}
+	})
+}
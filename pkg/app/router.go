@@ -58,7 +58,7 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		// 2. SERVICES FROM CONTAINER (No more local initialization)
 		// ---------------------------------------------------------
 		// Legacy pkg/services that still need PocketBase directly
-		slotService := services.NewTimeSlotService(pb, c.TechRepo, c.BookingRepo)
+		slotService := services.NewTimeSlotService(pb, c.TechRepo, c.BookingRepo, c.ServiceRepo, c.BrandRepo, c.ScheduleService)
 
 		// Register Global Middleware for Settings Injection & License Check
 		se.Router.BindFunc(middleware.SettingsMiddleware(c.SettingsRepo))
@@ -78,19 +78,45 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 			SettingsRepo:     c.SettingsRepo,
 			BrandRepo:        c.BrandRepo,
 			FCMService:       c.FCMService,
+			EventRepo:        c.EventRepo,
+			DispatchService:  c.DispatchService,
+			ScheduleService:  c.ScheduleService,
+			CustomerService:  c.CustomerService,
+			EquipmentService: c.EquipmentService,
+			ContractService:  c.ContractService,
+			InvoiceService:   c.InvoiceService,
+			PDFService:       c.PDFService,
+			ReconcileService: c.ReconcileService,
+			PaymentService:   c.PaymentService,
+			CashService:      c.CashService,
+			PayrollService:   c.PayrollService,
+			EInvoiceService:  c.EInvoiceService,
+			JournalService:   c.JournalService,
+			InventoryService: c.InventoryService,
 		}
 
 		tech := &handlers.TechHandler{
-			App:            pb,
-			Templates:      c.Templates,
-			Broker:         c.Broker,
-			Inventory:      c.InventoryService,
-			InvoiceService: c.InvoiceService,
-			BookingService: c.BookingService,
-			SettingsRepo:   c.SettingsRepo,
-			FCMService:     c.FCMService,
-			TechRepo:       c.TechRepo,
-			BookingRepo:    c.BookingRepo,
+			App:              pb,
+			Templates:        c.Templates,
+			Broker:           c.Broker,
+			Inventory:        c.InventoryService,
+			InvoiceService:   c.InvoiceService,
+			BookingService:   c.BookingService,
+			SettingsRepo:     c.SettingsRepo,
+			FCMService:       c.FCMService,
+			TechRepo:         c.TechRepo,
+			BookingRepo:      c.BookingRepo,
+			ScheduleService:  c.ScheduleService,
+			EquipmentService: c.EquipmentService,
+			QuoteService:     c.QuoteService,
+			PartRepo:         c.PartRepo,
+			PDFService:       c.PDFService,
+			MailService:      c.MailService,
+			CustomerRepo:     c.CustomerRepo,
+			PaymentService:   c.PaymentService,
+			CashService:      c.CashService,
+			PayrollService:   c.PayrollService,
+			Stocktakes:       c.StocktakeService,
 		}
 
 		slot := &handlers.SlotHandler{
@@ -103,6 +129,8 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 			Templates:        c.Templates,
 			SlotService:      slotService,
 			InventoryService: c.InventoryService,
+			PurchaseService:  c.PurchaseService,
+			StocktakeService: c.StocktakeService,
 			Broker:           c.Broker,
 		}
 
@@ -114,6 +142,9 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 			FCMService:     c.FCMService,
 			BookingService: c.BookingService,
 			SlotService:    slotService,
+			BookingRepo:    c.BookingRepo,
+			TechRepo:       c.TechRepo,
+			LocationCache:  c.LocationCache,
 		}
 
 		fcm := &handlers.FCMHandler{
@@ -124,10 +155,14 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		}
 
 		public := &handlers.PublicHandler{
-			App:            pb,
-			Templates:      c.Templates,
-			InvoiceService: c.InvoiceService,
-			BrandRepo:      c.BrandRepo,
+			App:              pb,
+			Templates:        c.Templates,
+			InvoiceService:   c.InvoiceService,
+			BrandRepo:        c.BrandRepo,
+			QuoteService:     c.QuoteService,
+			BookingRepo:      c.BookingRepo,
+			PDFService:       c.PDFService,
+			ReconcileService: c.ReconcileService,
 		}
 
 		// Location handlers from Container
@@ -147,9 +182,25 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		se.Router.GET("/api/public/geocode", public.Geocode)
 
 		// Super Invoice Public Routes
-		se.Router.GET("/invoice/{hash}", public.ShowInvoice)
+		se.Router.GET("/invoice/{hash}", public.ShowInvoice) // also /invoice/{hash}.pdf
+		se.Router.GET("/invoice/{hash}/qr.png", public.InvoiceQR)
 		se.Router.POST("/api/invoice/{hash}/feedback", public.SubmitFeedback)
 
+		// [NEW] Bank feed webhook (Casso / SePay), authenticated by settings.bank_webhook_secret
+		se.Router.POST("/api/payments/webhook/{provider}", public.BankWebhook)
+
+		// [NEW] Báo giá tại chỗ: khách xem và ký duyệt
+		se.Router.GET("/quote/{hash}", public.ShowQuote)
+		se.Router.POST("/quote/{hash}/decision", public.DecideQuote)
+
+		// [NEW] Customer self-service portal (tokenized link sent after booking)
+		se.Router.GET("/b/{token}", web.ShowPortal)
+		se.Router.GET("/b/{token}/stream", web.CustomerTrackStream)
+		se.Router.GET("/b/{token}/slots", web.PortalSlots)
+		se.Router.GET("/b/{token}/location", web.PortalLocation)
+		se.Router.POST("/b/{token}/reschedule", web.PortalReschedule)
+		se.Router.POST("/b/{token}/cancel", web.PortalCancel)
+
 		// ----- LOCATION TRACKING - PUBLIC ROUTES -----
 		se.Router.GET("/api/health/location", locationHandler.HealthCheck)
 		se.Router.GET("/api/bookings/{id}/tech-location", locationHandler.GetBookingTechLocation)
@@ -195,6 +246,11 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		adminGroup.POST("/bookings/{id}/update", admin.UpdateBookingInfo)
 		adminGroup.POST("/bookings/create", admin.CreateBooking)
 		adminGroup.POST("/api/bookings/{id}/status", admin.UpdateBookingStatus)
+		adminGroup.GET("/api/bookings/{id}/timeline", admin.BookingTimeline)
+		adminGroup.GET("/api/bookings/{id}/dispatch", admin.DispatchSuggestions)
+		adminGroup.GET("/bookings/{id}/invoice", admin.InvoicePage)
+		adminGroup.GET("/bookings/{id}/report.pdf", admin.JobReportPDF)
+		adminGroup.POST("/api/invoices/{id}/adjustments", admin.SaveInvoiceAdjustments)
 		// [NEW] API for fetching active bookings for conflict check
 		adminGroup.GET("/api/bookings/active", admin.ActiveBookings)
 
@@ -205,6 +261,65 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		adminGroup.POST("/techs/{id}/password", admin.ResetTechPassword)
 		adminGroup.POST("/techs/{id}/toggle", admin.ToggleTechStatus)
 
+		// Tech Schedules (working hours, leave, holidays)
+		adminGroup.GET("/schedules", admin.SchedulesPage)
+		adminGroup.GET("/api/techs/{id}/schedule", admin.TechSchedule)
+		adminGroup.POST("/api/techs/{id}/working-hours", admin.SaveWorkingHours)
+		adminGroup.POST("/api/time-off", admin.CreateTimeOff)
+		adminGroup.POST("/api/time-off/{id}/review", admin.ReviewTimeOff)
+
+		// Customers (dedup by phone, lifetime value, merge)
+		adminGroup.GET("/customers", admin.CustomersPage)
+		adminGroup.GET("/customers/{id}", admin.CustomerDetail)
+		adminGroup.GET("/api/customers/search", admin.SearchCustomers)
+		adminGroup.POST("/api/customers/{id}", admin.UpdateCustomer)
+		adminGroup.POST("/api/customers/{id}/merge", admin.MergeCustomer)
+		adminGroup.POST("/customers/{id}/equipment", admin.CreateEquipment)
+		adminGroup.GET("/equipment/{id}", admin.EquipmentDetail)
+		adminGroup.POST("/equipment/{id}", admin.UpdateEquipment)
+		adminGroup.GET("/contracts", admin.ContractsPage)
+		adminGroup.POST("/customers/{id}/contracts", admin.CreateContract)
+		adminGroup.POST("/contracts/{id}/status", admin.UpdateContractStatus)
+		adminGroup.POST("/contracts/{id}/renew", admin.RenewContract)
+
+		// Đối soát chuyển khoản ngân hàng
+		adminGroup.GET("/payments/bank", admin.BankTransactionsPage)
+		adminGroup.POST("/payments/bank/import", admin.ImportBankStatement)
+		adminGroup.POST("/payments/bank/simulate", admin.SimulateBankTransfer)
+		adminGroup.POST("/payments/bank/{id}/resolve", admin.ResolveBankTransaction)
+		adminGroup.POST("/payments/bank/{id}/ignore", admin.IgnoreBankTransaction)
+
+		// Sổ thanh toán: đặt cọc, hoàn tiền, hủy (hoàn/hủy cần duyệt)
+		adminGroup.GET("/payments/approvals", admin.PaymentApprovalsPage)
+		adminGroup.POST("/invoices/{id}/payments", admin.RecordPayment)
+		adminGroup.POST("/invoices/{id}/refunds", admin.RequestRefund)
+		adminGroup.POST("/invoices/{id}/einvoice", admin.SubmitEInvoice)
+		adminGroup.POST("/invoices/{id}/einvoice/cancel", admin.CancelEInvoice)
+		adminGroup.POST("/invoices/{id}/einvoice/replace", admin.ReplaceEInvoice)
+		adminGroup.GET("/invoices/{id}/einvoice.xml", admin.DownloadEInvoice)
+		adminGroup.POST("/payments/{id}/void", admin.RequestPaymentVoid)
+		adminGroup.POST("/payments/{id}/approve", admin.ApprovePayment)
+		adminGroup.POST("/payments/{id}/reject", admin.RejectPayment)
+
+		// Tiền mặt thợ đang giữ & xác nhận nộp tiền
+		adminGroup.GET("/cash", admin.CashPage)
+		adminGroup.GET("/cash/{techId}", admin.TechCashPage)
+		adminGroup.POST("/cash/handovers/{id}/confirm", admin.ConfirmCashHandover)
+		adminGroup.POST("/cash/handovers/{id}/reject", admin.RejectCashHandover)
+
+		// Payroll
+		adminGroup.GET("/payroll", admin.PayrollPage)
+		adminGroup.GET("/payroll/export", admin.ExportPayroll)
+		adminGroup.POST("/payroll/generate", admin.GeneratePayroll)
+		adminGroup.POST("/payroll/adjustments", admin.AddPayrollAdjustment)
+		adminGroup.POST("/payroll/adjustments/{id}/delete", admin.RemovePayrollAdjustment)
+		adminGroup.GET("/payroll/{id}", admin.PayrollStatementPage)
+		adminGroup.POST("/payroll/{id}/approve", admin.ApprovePayroll)
+
+		// Accounting export
+		adminGroup.GET("/accounting", admin.AccountingPage)
+		adminGroup.GET("/accounting/export", admin.ExportAccounting)
+
 		// FCM Token
 		adminGroup.POST("/fcm/token", fcm.RegisterDeviceToken)
 		adminGroup.GET("/debug/fcm-tokens", admin.DebugAdminTokens)
@@ -219,6 +334,10 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		adminGroup.POST("/tools/inventory/{id}/delete", adminTools.DeleteInventoryItem)
 		adminGroup.POST("/tools/inventory/import", adminTools.ImportToMain)
 		adminGroup.GET("/tools/inventory/alerts", adminTools.GetLowStockAlerts)
+		adminGroup.GET("/tools/inventory/ledger", adminTools.CheckStockLedger)
+		adminGroup.POST("/tools/inventory/ledger/rebuild", adminTools.RebuildStockFromLedger)
+		adminGroup.GET("/tools/inventory/valuation", adminTools.StockValuation)
+		adminGroup.GET("/tools/inventory/serials", adminTools.StockLotsPage)
 
 		// Tech Stock (Kho Trên Xe) Routes
 		adminGroup.GET("/tools/tech-stock", adminTools.ShowTechStock)
@@ -226,6 +345,29 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		adminGroup.POST("/tools/tech-stock/transfer", adminTools.TransferStock)
 		adminGroup.POST("/tools/tech-stock/return", adminTools.ReturnStock)
 
+		// [NEW] Kiểm kê: stocktakes of the main warehouse and the trucks
+		adminGroup.GET("/tools/stocktakes", adminTools.StocktakesPage)
+		adminGroup.POST("/tools/stocktakes", adminTools.StartStocktake)
+		adminGroup.GET("/tools/stocktakes/{id}", adminTools.StocktakePage)
+		adminGroup.POST("/tools/stocktakes/{id}/count", adminTools.CountStocktake)
+		adminGroup.POST("/tools/stocktakes/{id}/items", adminTools.AddStocktakeItem)
+		adminGroup.POST("/tools/stocktakes/{id}/approve", adminTools.ApproveStocktake)
+		adminGroup.POST("/tools/stocktakes/{id}/cancel", adminTools.CancelStocktake)
+
+		// [NEW] Mua hàng: suppliers, purchase orders, goods receipts, payables
+		adminGroup.GET("/tools/purchasing", adminTools.PurchasingPage)
+		adminGroup.POST("/tools/purchasing/orders", adminTools.CreatePurchaseOrder)
+		adminGroup.GET("/tools/purchasing/orders/{id}", adminTools.PurchaseOrderPage)
+		adminGroup.POST("/tools/purchasing/orders/{id}/order", adminTools.OrderPurchaseOrder)
+		adminGroup.POST("/tools/purchasing/orders/{id}/cancel", adminTools.CancelPurchaseOrder)
+		adminGroup.POST("/tools/purchasing/orders/{id}/receive", adminTools.ReceivePurchaseOrder)
+		adminGroup.POST("/tools/purchasing/draft-from-alerts", adminTools.DraftPurchaseOrders)
+		adminGroup.POST("/tools/purchasing/suppliers", adminTools.SaveSupplier)
+		adminGroup.GET("/tools/purchasing/suppliers/{id}", adminTools.SupplierPage)
+		adminGroup.POST("/tools/purchasing/preferred", adminTools.SetPreferredSupplier)
+		adminGroup.POST("/tools/purchasing/invoices", adminTools.CreateSupplierInvoice)
+		adminGroup.POST("/tools/purchasing/invoices/{id}/pay", adminTools.PaySupplierInvoice)
+
 		adminGroup.GET("/api/slots", admin.GetSlots)
 
 		// Service Management
@@ -257,6 +399,13 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		techGroup.GET("/job/{id}", tech.JobDetail)
 		techGroup.GET("/history", tech.ShowHistory)
 		techGroup.GET("/profile", tech.ShowProfile)
+		techGroup.GET("/leave", tech.ShowLeave)
+		techGroup.POST("/leave", tech.SubmitLeave)
+		techGroup.GET("/cash", tech.ShowCash)
+		techGroup.POST("/cash/handover", tech.SubmitCashHandover)
+		techGroup.GET("/payroll", tech.ShowPayroll)
+		techGroup.GET("/stocktake", tech.ShowStocktake)
+		techGroup.POST("/stocktake", tech.SubmitStocktakeCounts)
 		techGroup.GET("/stream", tech.TechStream)
 
 		// Luồng hoàn thành công việc
@@ -264,6 +413,14 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		techGroup.POST("/job/{id}/complete", tech.SubmitCompleteJob)
 		techGroup.GET("/job/{id}/invoice-payment", tech.ShowInvoicePayment)
 
+		// Báo giá tại chỗ trước khi sửa chữa
+		techGroup.GET("/job/{id}/quote", tech.ShowQuote)
+		techGroup.POST("/job/{id}/quote", tech.SubmitQuote)
+
+		// Thiết bị của khách (máy lạnh đã lắp)
+		techGroup.POST("/job/{id}/equipment", tech.AddJobEquipment)
+		techGroup.GET("/equipment/{id}", tech.EquipmentHistory)
+
 		// ---------------------------------------------------------
 		// 8. TECH API ROUTES (Dành cho HTMX và Xử lý dữ liệu)
 		// ---------------------------------------------------------
@@ -291,6 +448,7 @@ func RegisterRoutes(pb *pocketbase.PocketBase, c *internalApp.Container) {
 		apiGroup.GET("/job/{id}/invoice", tech.GetJobInvoice)
 		apiGroup.POST("/job/{id}/evidence", tech.UploadJobEvidence)
 		apiGroup.POST("/job/{id}/payment", tech.ProcessPayment)
+		apiGroup.GET("/job/{id}/payment-status", tech.GetPaymentStatus)
 
 		return se.Next()
 	})
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
@@ -29,9 +30,24 @@ type AdminHandler struct {
 	TechService      *services.TechManagementService // NEW: Tech Management
 	AnalyticsService domain.AnalyticsService
 	UIComponents     *ui.Components
-	SettingsRepo     *repository.SettingsRepo // [NEW]
-	BrandRepo        domain.BrandRepository   // [NEW] SaaS Brand Management
-	FCMService       *notification.FCMService // [NEW] FCM Push Notifications
+	SettingsRepo     *repository.SettingsRepo      // [NEW]
+	BrandRepo        domain.BrandRepository        // [NEW] SaaS Brand Management
+	FCMService       *notification.FCMService      // [NEW] FCM Push Notifications
+	EventRepo        domain.BookingEventRepository // [NEW] Booking timeline
+	DispatchService  domain.DispatchService        // [NEW] Technician suggestions
+	ScheduleService  domain.TechScheduleService    // [NEW] Working hours & leave
+	CustomerService  domain.CustomerService        // [NEW] Customers, LTV, merge
+	EquipmentService domain.EquipmentService       // [NEW] Equipment registry
+	ContractService  domain.ContractService        // [NEW] Maintenance contracts
+	InvoiceService   *services.InvoiceService      // [NEW] Discounts & extra labor
+	PDFService       *services.PDFService          // [NEW] Job report PDF
+	ReconcileService domain.ReconciliationService  // [NEW] Bank transfer review queue
+	PaymentService   domain.PaymentService         // [NEW] Payments ledger & approvals
+	CashService      domain.CashService            // [NEW] Tech cash on hand & handovers
+	PayrollService   domain.PayrollService         // [NEW] Monthly tech payroll
+	EInvoiceService  domain.EInvoiceService        // [NEW] E-invoices (hóa đơn điện tử)
+	JournalService   domain.AccountingService      // [NEW] Accounting journal export (MISA / CSV)
+	InventoryService *services.InventoryService    // [NEW] Serials installed in customer equipment
 }
 
 func (h *AdminHandler) ShowLogin(e *core.RequestEvent) error {
@@ -377,6 +393,13 @@ func (h *AdminHandler) Dashboard(e *core.RequestEvent) error {
 	// Fetch Services for Dropdown
 	servicesList, _ := h.App.FindRecordsByFilter("services", "active=true", "-created", 100, 0, nil)
 
+	// [NEW] Contracts to renew (ending soon / just expired)
+	contractAlerts, err := h.ContractService.Alerts(time.Now())
+	if err != nil {
+		log.Printf("⚠️ [ADMIN_HANDLER] Failed to fetch contract alerts: %v", err)
+		contractAlerts = []*domain.MaintenanceContract{}
+	}
+
 	// [NEW] Get Firebase Config for Frontend
 	// Quick fix: Hardcode valid public config or fetch if available.
 	// Ideally we should use h.App.Settings() or a config service.
@@ -404,6 +427,8 @@ func (h *AdminHandler) Dashboard(e *core.RequestEvent) error {
 		"CompletionRate":    stats.CompletionRate,
 		"RevenueStats":      revenueStats,
 		"TopTechs":          topTechs,
+		"ContractAlerts":    contractAlerts, // [NEW]
+		"Now":               time.Now(),
 		"IsAdmin":           true,
 		"PageType":          "admin_dashboard",
 		"FirebaseConfig":    firebaseConfig,                                                                            // [NEW]
@@ -503,6 +528,9 @@ func (h *AdminHandler) CreateBooking(e *core.RequestEvent) error {
 	// Creates booking AND triggers notifications (SSE+FCM)
 	newBooking, err := h.BookingService.CreateBooking(req)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPhone) {
+			return e.String(400, "Số điện thoại không hợp lệ")
+		}
 		return e.String(500, "Lỗi service tạo đơn: "+err.Error())
 	}
 
@@ -548,18 +576,11 @@ func (h *AdminHandler) UpdateBookingStatus(e *core.RequestEvent) error {
 		return e.String(400, "Missing ID or Status")
 	}
 
-	// 1. Handle "recall to pending" efficiently via service
-	// 1. Handle "recall to pending" efficiently via service
-	if status == "pending" {
-		// [FIX] New BookingService.RecallToPending only takes ID
-		if err := h.BookingService.RecallToPending(id); err != nil {
-			return e.String(500, "Lỗi khi thu hồi về Pending: "+err.Error())
+	// Lifecycle validates the transition (recall/cancel are routed inside the service)
+	if err := h.BookingService.UpdateStatus(id, status, adminActor(e, "kanban")); err != nil {
+		if ok, rerr := renderTransitionError(e, err); ok {
+			return rerr
 		}
-		return e.JSON(200, map[string]string{"message": "Đã thu hồi về Pending"})
-	}
-
-	// 2. Normal status flow
-	if err := h.BookingService.UpdateStatus(id, status); err != nil {
 		return e.String(500, err.Error())
 	}
 
@@ -642,7 +663,11 @@ func (h *AdminHandler) AssignJob(e *core.RequestEvent) error {
 
 	// Use service layer for business logic
 	// [REFACTORED] Service now handles SSE (Admin+Tech) and FCM
-	if err := h.BookingService.AssignTechnician(bookingID, technicianID); err != nil {
+	if err := h.BookingService.AssignTechnician(bookingID, technicianID, adminActor(e, "dashboard")); err != nil {
+		var te *domain.TransitionError
+		if errors.As(err, &te) {
+			return e.String(409, fmt.Sprintf("Lỗi giao việc: %s", te.Message()))
+		}
 		return e.String(500, fmt.Sprintf("Lỗi giao việc: %s", err.Error()))
 	}
 	// Removed manual Broker.Publish and FCM calls
@@ -709,23 +734,15 @@ func (h *AdminHandler) RenderBookingRow(record *core.Record) (string, error) {
 // CancelBooking soft-deletes or cancels a booking
 func (h *AdminHandler) CancelBooking(e *core.RequestEvent) error {
 	id := e.Request.PathValue("id")
-	booking, err := h.App.FindRecordById("bookings", id)
-	if err != nil {
+	if _, err := h.App.FindRecordById("bookings", id); err != nil {
 		return e.String(404, "Booking not found")
 	}
 
-	// 1. Release Time Slot if exists
-	slotID := booking.GetString("time_slot_id")
-	if slotID != "" && h.SlotService != nil {
-		if err := h.SlotService.ReleaseSlot(slotID); err != nil {
-			fmt.Printf("Warning: Failed to release slot %s: %v\n", slotID, err)
-			// Continue cancelling booking even if slot release fails
+	// Cancel via Service (lifecycle releases the slot and handles notification)
+	if err := h.BookingService.CancelBooking(id, "Admin cancelled", "", adminActor(e, "dashboard")); err != nil {
+		if ok, rerr := renderTransitionError(e, err); ok {
+			return rerr
 		}
-	}
-
-	// 2. Cancel Booking via Service (Handles Notification)
-	// booking.Set("job_status", "cancelled") -- Handled by Service
-	if err := h.BookingService.CancelBooking(id, "Admin cancelled", ""); err != nil {
 		return e.String(500, "Failed to cancel booking: "+err.Error())
 	}
 
@@ -789,8 +806,18 @@ func (h *AdminHandler) ShowSettings(e *core.RequestEvent) error {
 		fmt.Println("Error fetching settings:", err)
 	}
 
+	// [NEW] Per-brand operational settings (dispatch...)
+	var brand *domain.Brand
+	if h.BrandRepo != nil {
+		brand, _ = h.BrandRepo.GetDefault()
+	}
+	if brand == nil {
+		brand = &domain.Brand{}
+	}
+
 	data := map[string]interface{}{
 		"Settings": settings,
+		"Brand":    brand,
 		"IsAdmin":  true,
 		"PageType": "settings",
 	}
@@ -843,10 +870,14 @@ func (h *AdminHandler) UpdateSettings(e *core.RequestEvent) error {
 		// ... existing logic ...
 		record.Set("company_name", e.Request.FormValue("company_name"))
 		record.Set("hotline", e.Request.FormValue("hotline"))
+		record.Set("address", strings.TrimSpace(e.Request.FormValue("address")))
+		record.Set("email", strings.TrimSpace(e.Request.FormValue("email")))
+		record.Set("tax_code", strings.TrimSpace(e.Request.FormValue("tax_code")))
 		record.Set("bank_bin", e.Request.FormValue("bank_bin"))
 		record.Set("bank_account", e.Request.FormValue("bank_account"))
 		record.Set("bank_owner", e.Request.FormValue("bank_owner"))
 		record.Set("qr_template", e.Request.FormValue("qr_template"))
+		record.Set("bank_webhook_secret", strings.TrimSpace(e.Request.FormValue("bank_webhook_secret")))
 
 		// [FIX] Map SEO Fields
 		record.Set("seo_title", e.Request.FormValue("seo_title"))
@@ -869,6 +900,24 @@ func (h *AdminHandler) UpdateSettings(e *core.RequestEvent) error {
 		if len(heroFiles) > 0 {
 			record.Set("hero_image", heroFiles[0])
 		}
+
+		// [NEW] Dispatch
+		record.Set("auto_assign_enabled", e.Request.FormValue("auto_assign_enabled") == "on")
+		record.Set("auto_assign_after_minutes", e.Request.FormValue("auto_assign_after_minutes"))
+		record.Set("travel_buffer_minutes", e.Request.FormValue("travel_buffer_minutes"))
+
+		// [NEW] Invoicing
+		record.Set("default_vat", domain.ResolveVAT(e.Request.FormValue("default_vat"), ""))
+		record.Set("labor_hour_rate", e.Request.FormValue("labor_hour_rate"))
+
+		// [NEW] Payroll
+		record.Set("payroll_no_show_penalty", e.Request.FormValue("payroll_no_show_penalty"))
+
+		// [NEW] E-invoicing
+		record.Set("einvoice_series", strings.ToUpper(strings.TrimSpace(e.Request.FormValue("einvoice_series"))))
+
+		// [NEW] Inventory
+		record.Set("inventory_costing", domain.NormalizeCostingMethod(e.Request.FormValue("inventory_costing")))
 	}
 
 	// 4. Save
@@ -946,6 +995,7 @@ func (h *AdminHandler) ServicesList(e *core.RequestEvent) error {
 	data := map[string]interface{}{
 		"ServiceGroups": groups,
 		"Categories":    categories,
+		"VATCodes":      domain.VATCodes,
 		"IsAdmin":       true,
 		"PageType":      "services",
 	}
@@ -986,6 +1036,7 @@ func (h *AdminHandler) ServiceSave(e *core.RequestEvent) error {
 	record.Set("warranty_months", e.Request.FormValue("warranty_months"))
 	record.Set("commission_rate", e.Request.FormValue("commission_rate"))
 	record.Set("required_skill", e.Request.FormValue("required_skill"))
+	record.Set("vat_rate", e.Request.FormValue("vat_rate")) // Empty = brand default
 
 	// Process YouTube URL
 	video := e.Request.FormValue("video_url")
@@ -1512,3 +1563,29 @@ func (h *AdminHandler) MyBrandSave(e *core.RequestEvent) error {
 
 	return e.Redirect(http.StatusSeeOther, "/admin/my-brand?success=true")
 }
+
+// BookingTimeline returns the persisted event history of a booking (oldest first)
+// GET /admin/api/bookings/{id}/timeline
+func (h *AdminHandler) BookingTimeline(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	if _, err := h.App.FindRecordById("bookings", id); err != nil {
+		return e.JSON(http.StatusNotFound, map[string]string{"error": "Booking not found"})
+	}
+
+	events, err := h.EventRepo.ListByBooking(id)
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load timeline"})
+	}
+
+	return e.JSON(http.StatusOK, events)
+}
+
+// DispatchSuggestions ranks technicians for a booking (skill, zone, distance, load, rating)
+// GET /admin/api/bookings/{id}/dispatch
+func (h *AdminHandler) DispatchSuggestions(e *core.RequestEvent) error {
+	candidates, err := h.DispatchService.RankTechnicians(e.Request.PathValue("id"))
+	if err != nil {
+		return e.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return e.JSON(http.StatusOK, candidates)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	domain "hvac-system/internal/core"
+	"hvac-system/pkg/services"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// accountingRangeParams reads ?from=YYYY-MM-DD&to=YYYY-MM-DD, defaulting to
+// the current month up to today
+func accountingRangeParams(e *core.RequestEvent) (string, string, time.Time, time.Time) {
+	q := e.Request.URL.Query()
+	fromStr, toStr := q.Get("from"), q.Get("to")
+	from, to, err := domain.AccountingRange(fromStr, toStr)
+	if err != nil {
+		month, _, _ := domain.PayrollPeriod(domain.CurrentPayrollPeriod(time.Now()))
+		fromStr, toStr = month.Format("2006-01-02"), time.Now().In(month.Location()).Format("2006-01-02")
+		from, to, _ = domain.AccountingRange(fromStr, toStr)
+	}
+	return fromStr, toStr, from, to
+}
+
+// GET /admin/accounting?from=&to= - journal preview and downloads
+func (h *AdminHandler) AccountingPage(e *core.RequestEvent) error {
+	fromStr, toStr, from, to := accountingRangeParams(e)
+	entries, err := h.JournalService.Journal(from, to)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	var totals domain.JournalTotals
+	totals.Add(entries)
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/accounting.html", map[string]interface{}{
+		"From":    fromStr,
+		"To":      toStr,
+		"Entries": entries,
+		"Totals":  &totals,
+	})
+}
+
+// GET /admin/accounting/export?from=&to=&format=misa|csv
+func (h *AdminHandler) ExportAccounting(e *core.RequestEvent) error {
+	fromStr, toStr, from, to := accountingRangeParams(e)
+	entries, err := h.JournalService.Journal(from, to)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	name := fmt.Sprintf("so-nhat-ky-%s_%s", fromStr, toStr)
+	if e.Request.URL.Query().Get("format") == "csv" {
+		data, err := services.AccountingCSV(entries)
+		if err != nil {
+			return e.String(500, err.Error())
+		}
+		return RenderDownload(e, name+".csv", "text/csv; charset=utf-8", data)
+	}
+
+	data, err := services.AccountingXLSX(entries, fromStr, toStr)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	return RenderDownload(e, name+".xlsx", services.XLSXContentType, data)
+}
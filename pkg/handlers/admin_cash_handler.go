@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	domain "hvac-system/internal/core"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// GET /admin/cash - cash held by each tech and handovers to confirm
+func (h *AdminHandler) CashPage(e *core.RequestEvent) error {
+	positions, err := h.CashService.Positions()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	pending, err := h.CashService.PendingHandovers()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	total := 0.0
+	for _, p := range positions {
+		total += p.Outstanding
+	}
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/cash.html", map[string]interface{}{
+		"Positions":   positions,
+		"Pending":     pending,
+		"Outstanding": total,
+		"Error":       e.Request.URL.Query().Get("error"),
+	})
+}
+
+// GET /admin/cash/{techId} - cash ledger and handovers of one tech
+func (h *AdminHandler) TechCashPage(e *core.RequestEvent) error {
+	techID := e.Request.PathValue("techId")
+	tech, err := h.App.FindRecordById("technicians", techID)
+	if err != nil {
+		return e.String(404, "Technician not found")
+	}
+	entries, pos, err := h.CashService.Ledger(techID, 100)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	history, err := h.CashService.History(techID)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/tech_cash.html", map[string]interface{}{
+		"Tech":      tech,
+		"Position":  pos,
+		"Entries":   entries,
+		"Handovers": history,
+		"Error":     e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/cash/handovers/{id}/confirm
+// Form: received (amount counted), note, back
+func (h *AdminHandler) ConfirmCashHandover(e *core.RequestEvent) error {
+	_, err := h.CashService.ConfirmHandover(
+		e.Request.PathValue("id"),
+		domain.ParseVNDAmount(e.Request.FormValue("received")),
+		e.Request.FormValue("note"),
+		adminActor(e, "cash"),
+	)
+	return h.cashRedirect(e, err)
+}
+
+// POST /admin/cash/handovers/{id}/reject
+// Form: note, back
+func (h *AdminHandler) RejectCashHandover(e *core.RequestEvent) error {
+	err := h.CashService.RejectHandover(e.Request.PathValue("id"), e.Request.FormValue("note"), adminActor(e, "cash"))
+	return h.cashRedirect(e, err)
+}
+
+func (h *AdminHandler) cashRedirect(e *core.RequestEvent, err error) error {
+	back := e.Request.FormValue("back")
+	if !strings.HasPrefix(back, "/admin/cash") {
+		back = "/admin/cash"
+	}
+	if err != nil {
+		back += "?error=" + url.QueryEscape(cashErrorMessage(err))
+	}
+	return e.Redirect(http.StatusSeeOther, back)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func contractErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidContract):
+		return "Hợp đồng không hợp lệ: cần dịch vụ, chu kỳ 1-24 tháng và ngày kết thúc sau ngày bắt đầu"
+	case errors.Is(err, domain.ErrContractStatus):
+		return "Trạng thái hợp đồng không cho phép thao tác này"
+	default:
+		return err.Error()
+	}
+}
+
+// GET /admin/contracts?status=
+func (h *AdminHandler) ContractsPage(e *core.RequestEvent) error {
+	status := e.Request.URL.Query().Get("status")
+
+	contracts, err := h.ContractService.List(status)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	alerts, _ := h.ContractService.Alerts(time.Now())
+
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/contracts.html", map[string]interface{}{
+		"Contracts": contracts,
+		"Alerts":    alerts,
+		"Status":    status,
+		"LeadDays":  domain.ContractLeadDays,
+		"Now":       time.Now(),
+		"Error":     e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/customers/{id}/contracts
+// Form: service_id, equipment_ids[], interval_months, price, start_date, end_date, notes
+func (h *AdminHandler) CreateContract(e *core.RequestEvent) error {
+	customerID := e.Request.PathValue("id")
+	if err := e.Request.ParseForm(); err != nil {
+		return e.String(400, "Invalid form")
+	}
+
+	interval, _ := strconv.Atoi(e.Request.FormValue("interval_months"))
+	price, _ := strconv.ParseFloat(e.Request.FormValue("price"), 64)
+	contract := &domain.MaintenanceContract{
+		CustomerID:     customerID,
+		ServiceID:      e.Request.FormValue("service_id"),
+		EquipmentIDs:   e.Request.Form["equipment_ids"],
+		IntervalMonths: interval,
+		Price:          price,
+		StartDate:      e.Request.FormValue("start_date"),
+		EndDate:        e.Request.FormValue("end_date"),
+		Notes:          strings.TrimSpace(e.Request.FormValue("notes")),
+	}
+	if err := h.ContractService.Create(contract, adminActor(e, "contracts")); err != nil {
+		return e.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/customers/%s?error=%s", customerID, url.QueryEscape(contractErrorMessage(err))))
+	}
+	return e.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/customers/%s#contracts", customerID))
+}
+
+// POST /admin/contracts/{id}/status
+// Form: status (active | paused | cancelled), back
+func (h *AdminHandler) UpdateContractStatus(e *core.RequestEvent) error {
+	err := h.ContractService.SetStatus(e.Request.PathValue("id"), e.Request.FormValue("status"), adminActor(e, "contracts"))
+	return h.contractRedirect(e, err)
+}
+
+// POST /admin/contracts/{id}/renew
+// Form: months, back
+func (h *AdminHandler) RenewContract(e *core.RequestEvent) error {
+	months, _ := strconv.Atoi(e.Request.FormValue("months"))
+	err := h.ContractService.Renew(e.Request.PathValue("id"), months, adminActor(e, "contracts"))
+	return h.contractRedirect(e, err)
+}
+
+// contractRedirect goes back to the page the action came from (admin pages only)
+func (h *AdminHandler) contractRedirect(e *core.RequestEvent, err error) error {
+	back := e.Request.FormValue("back")
+	if !strings.HasPrefix(back, "/admin/") {
+		back = "/admin/contracts"
+	}
+	if err != nil {
+		sep := "?"
+		if strings.Contains(back, "?") {
+			sep = "&"
+		}
+		back += sep + "error=" + url.QueryEscape(contractErrorMessage(err))
+	}
+	return e.Redirect(http.StatusSeeOther, back)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// GET /admin/customers?q=&page=
+// Customer list with lifetime value, booking count and last service
+func (h *AdminHandler) CustomersPage(e *core.RequestEvent) error {
+	query := e.Request.URL.Query().Get("q")
+	page, _ := strconv.Atoi(e.Request.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	customers, total, err := h.CustomerService.Search(query, page)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/customers.html", map[string]interface{}{
+		"Customers": customers,
+		"Total":     total,
+		"Query":     query,
+		"Page":      page,
+		"HasPrev":   page > 1,
+		"HasNext":   page*domain.CustomerPageSize < total,
+		"PrevPage":  page - 1,
+		"NextPage":  page + 1,
+	})
+}
+
+// GET /admin/customers/{id}
+func (h *AdminHandler) CustomerDetail(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+
+	customer, err := h.CustomerService.Get(id)
+	if err != nil {
+		return e.String(404, "Không tìm thấy khách hàng")
+	}
+	bookings, err := h.CustomerService.Bookings(id)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	duplicates, _ := h.CustomerService.Duplicates(id)
+	equipment, _ := h.EquipmentService.ForCustomer(id)
+	contracts, _ := h.ContractService.ForCustomer(id)
+	services, _ := h.App.FindRecordsByFilter("services", "active=true", "+name", 100, 0, nil)
+
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/customer_detail.html", map[string]interface{}{
+		"Customer":     customer,
+		"Bookings":     bookings,
+		"Duplicates":   duplicates,
+		"Equipment":    equipment,
+		"Refrigerants": domain.Refrigerants,
+		"Contracts":    contracts,
+		"Services":     services,
+		"Today":        time.Now().Format("2006-01-02"),
+		"NextYear":     time.Now().AddDate(1, 0, -1).Format("2006-01-02"),
+		"Now":          time.Now(),
+		"Error":        e.Request.URL.Query().Get("error"),
+	})
+}
+
+// GET /admin/api/customers/search?q=
+// Used by the merge picker
+func (h *AdminHandler) SearchCustomers(e *core.RequestEvent) error {
+	customers, total, err := h.CustomerService.Search(e.Request.URL.Query().Get("q"), 1)
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return e.JSON(200, map[string]interface{}{"items": customers, "total": total})
+}
+
+// POST /admin/api/customers/{id}
+// Form: name, email, notes
+func (h *AdminHandler) UpdateCustomer(e *core.RequestEvent) error {
+	customer, err := h.CustomerService.Update(
+		e.Request.PathValue("id"),
+		e.Request.FormValue("name"),
+		e.Request.FormValue("email"),
+		e.Request.FormValue("notes"),
+	)
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return e.JSON(200, customer)
+}
+
+// POST /admin/api/customers/{id}/merge
+// Form: duplicate_id - the duplicate is folded into {id} and deleted
+func (h *AdminHandler) MergeCustomer(e *core.RequestEvent) error {
+	duplicateID := e.Request.FormValue("duplicate_id")
+	if duplicateID == "" {
+		return e.JSON(400, map[string]string{"error": "Chưa chọn khách hàng cần gộp"})
+	}
+
+	customer, err := h.CustomerService.Merge(e.Request.PathValue("id"), duplicateID, adminActor(e, "customers"))
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return e.JSON(200, customer)
+}
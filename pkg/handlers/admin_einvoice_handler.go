@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	domain "hvac-system/internal/core"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// einvoiceBuyer reads the buyer fields of the e-invoice forms
+func einvoiceBuyer(e *core.RequestEvent) domain.EInvoiceParty {
+	return domain.EInvoiceParty{
+		Name:    strings.TrimSpace(e.Request.FormValue("buyer_name")),
+		TaxCode: strings.TrimSpace(e.Request.FormValue("buyer_tax_code")),
+		Address: strings.TrimSpace(e.Request.FormValue("buyer_address")),
+		Email:   strings.TrimSpace(e.Request.FormValue("buyer_email")),
+		Phone:   strings.TrimSpace(e.Request.FormValue("buyer_phone")),
+		Contact: strings.TrimSpace(e.Request.FormValue("buyer_contact")),
+	}
+}
+
+// POST /admin/invoices/{id}/einvoice
+// Form: buyer_* (empty name = the booking's customer), back
+func (h *AdminHandler) SubmitEInvoice(e *core.RequestEvent) error {
+	_, err := h.EInvoiceService.Submit(e.Request.PathValue("id"), einvoiceBuyer(e), adminActor(e, "einvoice"))
+	return h.einvoiceRedirect(e, err)
+}
+
+// POST /admin/invoices/{id}/einvoice/cancel
+// Form: reason, back
+func (h *AdminHandler) CancelEInvoice(e *core.RequestEvent) error {
+	_, err := h.EInvoiceService.Cancel(e.Request.PathValue("id"), e.Request.FormValue("reason"), adminActor(e, "einvoice"))
+	return h.einvoiceRedirect(e, err)
+}
+
+// POST /admin/invoices/{id}/einvoice/replace
+// Form: buyer_* (empty name = same buyer), reason, back
+func (h *AdminHandler) ReplaceEInvoice(e *core.RequestEvent) error {
+	_, err := h.EInvoiceService.Replace(e.Request.PathValue("id"), einvoiceBuyer(e), e.Request.FormValue("reason"), adminActor(e, "einvoice"))
+	return h.einvoiceRedirect(e, err)
+}
+
+// GET /admin/invoices/{id}/einvoice.xml
+func (h *AdminHandler) DownloadEInvoice(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	src, err := h.EInvoiceService.State(id)
+	if err != nil {
+		return e.String(404, "Không tìm thấy hóa đơn")
+	}
+	data, err := h.EInvoiceService.Download(id)
+	if err != nil {
+		return e.String(404, einvoiceErrorMessage(err))
+	}
+	ref := src.State.Current
+	return RenderDownload(e, fmt.Sprintf("%s-%s.xml", ref.Series, ref.Number), "application/xml", data)
+}
+
+func (h *AdminHandler) einvoiceRedirect(e *core.RequestEvent, err error) error {
+	back := e.Request.FormValue("back")
+	if !strings.HasPrefix(back, "/admin/") {
+		back = "/admin/"
+	}
+	if err != nil {
+		sep := "?"
+		if strings.Contains(back, "?") {
+			sep = "&"
+		}
+		back += sep + "error=" + url.QueryEscape(einvoiceErrorMessage(err))
+	}
+	return e.Redirect(http.StatusSeeOther, back)
+}
+
+func einvoiceErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrEInvoiceNotReady):
+		return "Hóa đơn chưa phát hành (chưa thu tiền) hoặc đã hủy, chưa thể xuất hóa đơn điện tử"
+	case errors.Is(err, domain.ErrEInvoiceIssued):
+		return "Hóa đơn điện tử đã được phát hành"
+	case errors.Is(err, domain.ErrEInvoiceNotIssued):
+		return "Chưa có hóa đơn điện tử đang hiệu lực"
+	case errors.Is(err, domain.ErrEInvoiceReason):
+		return "Vui lòng nhập lý do hủy / thay thế"
+	case errors.Is(err, domain.ErrEInvoiceSeller):
+		return "Thiếu tên công ty hoặc mã số thuế người bán (Cài đặt > Chung)"
+	case errors.Is(err, domain.ErrEInvoiceBuyer):
+		return "Thiếu tên người mua"
+	case errors.Is(err, domain.ErrInvalidTaxCode):
+		return "Mã số thuế người mua không hợp lệ (10 số, 10 số-3 số hoặc 12 số)"
+	default:
+		return "Nhà cung cấp hóa đơn điện tử báo lỗi: " + err.Error()
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// GET /admin/equipment/{id}
+func (h *AdminHandler) EquipmentDetail(e *core.RequestEvent) error {
+	eq, err := h.EquipmentService.Get(e.Request.PathValue("id"))
+	if err != nil {
+		return e.String(404, "Không tìm thấy thiết bị")
+	}
+	history, err := h.EquipmentService.History(eq.ID)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	customer, _ := h.CustomerService.Get(eq.CustomerID)
+	var serials []domain.TrackedLot
+	if h.InventoryService != nil {
+		serials, _ = h.InventoryService.EquipmentLots(eq.ID)
+	}
+
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/equipment_detail.html", map[string]interface{}{
+		"Equipment":    eq,
+		"Customer":     customer,
+		"History":      history,
+		"Serials":      serials,
+		"Refrigerants": domain.Refrigerants,
+		"Error":        e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/customers/{id}/equipment
+func (h *AdminHandler) CreateEquipment(e *core.RequestEvent) error {
+	customerID := e.Request.PathValue("id")
+
+	eq := equipmentFromForm(e)
+	eq.CustomerID = customerID
+	if err := h.EquipmentService.Register(eq); err != nil {
+		return e.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/customers/%s?error=%s", customerID, url.QueryEscape(equipmentErrorMessage(err))))
+	}
+	return e.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/customers/%s#equipment", customerID))
+}
+
+// POST /admin/equipment/{id}
+func (h *AdminHandler) UpdateEquipment(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+
+	eq := equipmentFromForm(e)
+	eq.ID = id
+	if err := h.EquipmentService.Update(eq); err != nil {
+		return e.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/equipment/%s?error=%s", id, url.QueryEscape(equipmentErrorMessage(err))))
+	}
+	return e.Redirect(http.StatusSeeOther, "/admin/equipment/"+id)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func invoiceErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrDiscountApproval):
+		return "Giảm giá phải có lý do"
+	case errors.Is(err, domain.ErrInvalidDiscount):
+		return "Giảm giá không hợp lệ: phần trăm 0-100 hoặc số tiền lớn hơn 0"
+	case errors.Is(err, domain.ErrInvalidLaborLine):
+		return "Giờ công phát sinh cần mô tả và số giờ lớn hơn 0"
+	case errors.Is(err, domain.ErrInvoiceLocked):
+		return "Hóa đơn đã thanh toán đủ, đã hoàn tiền hoặc đã xuất hóa đơn điện tử (hủy hoặc thay thế hóa đơn điện tử trước), không thể điều chỉnh"
+	default:
+		return err.Error()
+	}
+}
+
+// GET /admin/bookings/{id}/invoice
+func (h *AdminHandler) InvoicePage(e *core.RequestEvent) error {
+	bookingID := e.Request.PathValue("id")
+	booking, err := h.App.FindRecordById("bookings", bookingID)
+	if err != nil {
+		return e.String(404, "Không tìm thấy đơn hàng")
+	}
+
+	invoice, err := h.App.FindFirstRecordByFilter("invoices", "booking_id = {:booking}", dbx.Params{"booking": bookingID})
+	if err != nil {
+		return e.String(404, "Đơn hàng chưa có hóa đơn")
+	}
+	items, _ := h.App.FindRecordsByFilter("invoice_items", fmt.Sprintf("invoice_id='%s'", invoice.Id), "", 100, 0, nil)
+
+	var adj domain.InvoiceAdjustments
+	_ = invoice.UnmarshalJSONField("adjustments", &adj)
+	adjJSON, _ := json.Marshal(adj)
+
+	payments, balance, err := h.PaymentService.Ledger(invoice.Id)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	techs, _ := h.App.FindRecordsByFilter("technicians", "active=true", "name", 100, 0, nil)
+	einvoice, _ := h.EInvoiceService.State(invoice.Id)
+
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/invoice.html", map[string]interface{}{
+		"Booking":         booking,
+		"Invoice":         invoice,
+		"Items":           items,
+		"AdjustmentsJSON": template.JS(string(adjJSON)),
+		"Locked":          !domain.InvoiceEditable(invoice.GetString("status"), invoice.GetString("einvoice_status")),
+		"Payments":        payments,
+		"Balance":         balance,
+		"Techs":           techs,
+		"EInvoice":        einvoice,
+		"Back":            "/admin/bookings/" + bookingID + "/invoice",
+		"Error":           e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/api/invoices/{id}/adjustments
+// Form: adjustments_json - domain.InvoiceAdjustments; the admin approves the discounts
+func (h *AdminHandler) SaveInvoiceAdjustments(e *core.RequestEvent) error {
+	var adj domain.InvoiceAdjustments
+	if err := json.Unmarshal([]byte(e.Request.FormValue("adjustments_json")), &adj); err != nil {
+		return e.JSON(400, map[string]string{"error": "Dữ liệu điều chỉnh không hợp lệ"})
+	}
+
+	actor := adminActor(e, "invoice")
+	invoice, err := h.InvoiceService.ApplyAdjustments(e.Request.PathValue("id"), adj, actor.Name)
+	if err != nil {
+		return e.JSON(400, map[string]string{"error": invoiceErrorMessage(err)})
+	}
+	return e.JSON(200, map[string]any{
+		"subtotal":     invoice.GetFloat("subtotal"),
+		"discount":     invoice.GetFloat("discount"),
+		"tax_total":    invoice.GetFloat("tax_total"),
+		"total_amount": invoice.GetFloat("total_amount"),
+	})
+}
+
+// GET /admin/bookings/{id}/report.pdf
+func (h *AdminHandler) JobReportPDF(e *core.RequestEvent) error {
+	bookingID := e.Request.PathValue("id")
+	data, err := h.PDFService.JobReportPDF(bookingID)
+	if err != nil {
+		fmt.Printf("❌ Job report PDF %s: %v\n", bookingID, err)
+		return e.String(404, "Không thể tạo biên bản cho đơn hàng này")
+	}
+	return RenderPDF(e, "bien-ban-"+bookingID+".pdf", data)
+}
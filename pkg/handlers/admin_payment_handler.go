@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/xuri/excelize/v2"
+)
+
+// GET /admin/payments/bank?status=unmatched|matched|ignored
+func (h *AdminHandler) BankTransactionsPage(e *core.RequestEvent) error {
+	status := e.Request.URL.Query().Get("status")
+	if status == "" {
+		status = domain.BankTxUnmatched
+	}
+
+	txs, err := h.ReconcileService.List(status)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	brand, _ := h.BrandRepo.GetDefault()
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/bank_transactions.html", map[string]interface{}{
+		"Transactions": txs,
+		"Status":       status,
+		"WebhookReady": brand != nil && brand.BankWebhookSecret != "",
+		"Message":      e.Request.URL.Query().Get("message"),
+		"Error":        e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/payments/bank/{id}/resolve
+// Form: invoice_code
+func (h *AdminHandler) ResolveBankTransaction(e *core.RequestEvent) error {
+	err := h.ReconcileService.Resolve(e.Request.PathValue("id"), e.Request.FormValue("invoice_code"), adminActor(e, "reconciliation"))
+	return h.paymentRedirect(e, "", err)
+}
+
+// POST /admin/payments/bank/{id}/ignore
+// Form: note
+func (h *AdminHandler) IgnoreBankTransaction(e *core.RequestEvent) error {
+	err := h.ReconcileService.Ignore(e.Request.PathValue("id"), e.Request.FormValue("note"), adminActor(e, "reconciliation"))
+	return h.paymentRedirect(e, "", err)
+}
+
+// POST /admin/payments/bank/import (multipart: statement = .csv | .xlsx)
+func (h *AdminHandler) ImportBankStatement(e *core.RequestEvent) error {
+	file, header, err := e.Request.FormFile("statement")
+	if err != nil {
+		return h.paymentRedirect(e, "", fmt.Errorf("vui lòng chọn file sao kê"))
+	}
+	defer file.Close()
+
+	var rows [][]string
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		reader := csv.NewReader(io.LimitReader(file, 10<<20))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		rows, err = reader.ReadAll()
+	case ".xlsx":
+		var book *excelize.File
+		if book, err = excelize.OpenReader(file); err == nil {
+			rows, err = book.GetRows(book.GetSheetName(0))
+			book.Close()
+		}
+	default:
+		err = fmt.Errorf("chỉ hỗ trợ file .csv hoặc .xlsx")
+	}
+	if err != nil {
+		return h.paymentRedirect(e, "", err)
+	}
+
+	txs, err := domain.ParseStatementRows(rows)
+	if err != nil {
+		return h.paymentRedirect(e, "", err)
+	}
+	result, err := h.ReconcileService.Ingest(txs)
+	if err != nil {
+		return h.paymentRedirect(e, "", err)
+	}
+	return h.paymentRedirect(e, reconcileSummary(result), nil)
+}
+
+// POST /admin/payments/bank/simulate
+// Local stand-in for the bank webhook. Form: amount, description
+func (h *AdminHandler) SimulateBankTransfer(e *core.RequestEvent) error {
+	tx := &domain.BankTransaction{
+		Source:       domain.BankSourceLocal,
+		ExternalID:   fmt.Sprintf("SIM-%d", time.Now().UnixNano()),
+		Amount:       domain.ParseVNDAmount(e.Request.FormValue("amount")),
+		Description:  strings.TrimSpace(e.Request.FormValue("description")),
+		TransactedAt: time.Now(),
+	}
+	if tx.Amount <= 0 {
+		return h.paymentRedirect(e, "", domain.ErrInvalidBankPayload)
+	}
+	result, err := h.ReconcileService.Ingest([]*domain.BankTransaction{tx})
+	if err != nil {
+		return h.paymentRedirect(e, "", err)
+	}
+	return h.paymentRedirect(e, reconcileSummary(result), nil)
+}
+
+func reconcileSummary(r *domain.ReconcileResult) string {
+	return fmt.Sprintf("Đã nhận %d giao dịch: %d tự động đối soát, %d chờ xử lý, %d bỏ qua, %d trùng lặp",
+		r.Received, r.Matched, r.Unmatched, r.Ignored, r.Duplicates)
+}
+
+// paymentRedirect goes back to the review queue tab the action came from
+func (h *AdminHandler) paymentRedirect(e *core.RequestEvent, message string, err error) error {
+	back := "/admin/payments/bank?status=" + url.QueryEscape(e.Request.FormValue("status"))
+	if err != nil {
+		back += "&error=" + url.QueryEscape(reconcileErrorMessage(err))
+	} else if message != "" {
+		back += "&message=" + url.QueryEscape(message)
+	}
+	return e.Redirect(http.StatusSeeOther, back)
+}
+
+func paymentErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPayment):
+		return "Số tiền phải lớn hơn 0 và phương thức hợp lệ"
+	case errors.Is(err, domain.ErrRefundExceedsPaid):
+		return "Số tiền hoàn vượt quá số khách đã thanh toán"
+	case errors.Is(err, domain.ErrPaymentNotPending):
+		return "Yêu cầu này đã được xử lý"
+	case errors.Is(err, domain.ErrPaymentNotVoidable):
+		return "Chỉ hủy được khoản thanh toán đã ghi nhận"
+	case errors.Is(err, domain.ErrInvoiceClosed):
+		return "Hóa đơn đã hủy"
+	default:
+		return err.Error()
+	}
+}
+
+// GET /admin/payments/approvals - refunds and voids awaiting approval
+func (h *AdminHandler) PaymentApprovalsPage(e *core.RequestEvent) error {
+	pending, err := h.PaymentService.Pending()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/payment_approvals.html", map[string]interface{}{
+		"Payments": pending,
+		"Error":    e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/invoices/{id}/payments
+// Form: amount, method, reference, paid_at (YYYY-MM-DD), collected_by (technician ID), note, back
+func (h *AdminHandler) RecordPayment(e *core.RequestEvent) error {
+	p := &domain.Payment{
+		InvoiceID: e.Request.PathValue("id"),
+		Amount:    domain.ParseVNDAmount(e.Request.FormValue("amount")),
+		Method:    e.Request.FormValue("method"),
+		Reference: strings.TrimSpace(e.Request.FormValue("reference")),
+		Note:      strings.TrimSpace(e.Request.FormValue("note")),
+		PaidAt:    domain.ParseBankTime(e.Request.FormValue("paid_at")),
+	}
+	// Deposit collected by a tech counts in the tech's cash on hand
+	if techID := e.Request.FormValue("collected_by"); techID != "" {
+		if tech, err := h.App.FindRecordById("technicians", techID); err == nil {
+			p.CollectedBy, p.CollectedByName = tech.Id, tech.GetString("name")
+		}
+	}
+	_, err := h.PaymentService.Record(p, adminActor(e, "payments"))
+	return h.ledgerRedirect(e, err)
+}
+
+// POST /admin/invoices/{id}/refunds
+// Form: amount, method, reference, note, back
+func (h *AdminHandler) RequestRefund(e *core.RequestEvent) error {
+	_, err := h.PaymentService.RequestRefund(&domain.Payment{
+		InvoiceID:   e.Request.PathValue("id"),
+		Amount:      domain.ParseVNDAmount(e.Request.FormValue("amount")),
+		Method:      e.Request.FormValue("method"),
+		Reference:   strings.TrimSpace(e.Request.FormValue("reference")),
+		RequestNote: e.Request.FormValue("note"),
+	}, adminActor(e, "payments"))
+	return h.ledgerRedirect(e, err)
+}
+
+// POST /admin/payments/{id}/void
+// Form: note, back
+func (h *AdminHandler) RequestPaymentVoid(e *core.RequestEvent) error {
+	err := h.PaymentService.RequestVoid(e.Request.PathValue("id"), e.Request.FormValue("note"), adminActor(e, "payments"))
+	return h.ledgerRedirect(e, err)
+}
+
+// POST /admin/payments/{id}/approve
+// Form: back
+func (h *AdminHandler) ApprovePayment(e *core.RequestEvent) error {
+	_, err := h.PaymentService.Approve(e.Request.PathValue("id"), adminActor(e, "payments"))
+	return h.ledgerRedirect(e, err)
+}
+
+// POST /admin/payments/{id}/reject
+// Form: note, back
+func (h *AdminHandler) RejectPayment(e *core.RequestEvent) error {
+	err := h.PaymentService.Reject(e.Request.PathValue("id"), e.Request.FormValue("note"), adminActor(e, "payments"))
+	return h.ledgerRedirect(e, err)
+}
+
+// ledgerRedirect goes back to the page the action came from (admin pages only)
+func (h *AdminHandler) ledgerRedirect(e *core.RequestEvent, err error) error {
+	back := e.Request.FormValue("back")
+	if !strings.HasPrefix(back, "/admin/") {
+		back = "/admin/payments/approvals"
+	}
+	if err != nil {
+		sep := "?"
+		if strings.Contains(back, "?") {
+			sep = "&"
+		}
+		back += sep + "error=" + url.QueryEscape(paymentErrorMessage(err))
+	}
+	return e.Redirect(http.StatusSeeOther, back)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	domain "hvac-system/internal/core"
+	"hvac-system/pkg/services"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// payrollPeriodParam reads ?period=YYYY-MM, defaulting to the current month
+func payrollPeriodParam(e *core.RequestEvent) string {
+	period := e.Request.URL.Query().Get("period")
+	if _, _, err := domain.PayrollPeriod(period); err != nil {
+		period = domain.CurrentPayrollPeriod(time.Now())
+	}
+	return period
+}
+
+// GET /admin/payroll?period=YYYY-MM - statements of the month
+func (h *AdminHandler) PayrollPage(e *core.RequestEvent) error {
+	period := payrollPeriodParam(e)
+	statements, err := h.PayrollService.List(period)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	var totals domain.PayrollStatement
+	approved := 0
+	for _, s := range statements {
+		totals.BaseSalary += s.BaseSalary
+		totals.Commission += s.Commission
+		totals.Bonus += s.Bonus
+		totals.Penalty += s.Penalty
+		totals.Advance += s.Advance
+		totals.Shortage += s.Shortage
+		totals.NetPay += s.NetPay
+		if s.Locked() {
+			approved++
+		}
+	}
+
+	from, _, _ := domain.PayrollPeriod(period)
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/payroll.html", map[string]interface{}{
+		"Period":     period,
+		"PrevPeriod": from.AddDate(0, -1, 0).Format("2006-01"),
+		"NextPeriod": from.AddDate(0, 1, 0).Format("2006-01"),
+		"Statements": statements,
+		"Totals":     &totals,
+		"Approved":   approved,
+		"Error":      e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/payroll/generate - recompute the drafts of a period
+// Form: period, technician_id (optional)
+func (h *AdminHandler) GeneratePayroll(e *core.RequestEvent) error {
+	period := e.Request.FormValue("period")
+	_, err := h.PayrollService.Generate(period, e.Request.FormValue("technician_id"))
+	return h.payrollRedirect(e, "/admin/payroll?period="+url.QueryEscape(period), err)
+}
+
+// GET /admin/payroll/{id} - lines and adjustments of one statement
+func (h *AdminHandler) PayrollStatementPage(e *core.RequestEvent) error {
+	statement, err := h.PayrollService.Get(e.Request.PathValue("id"))
+	if err != nil {
+		return e.String(404, "Statement not found")
+	}
+	adjustments, err := h.PayrollService.Adjustments(statement.TechnicianID, statement.Period)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/payroll_statement.html", map[string]interface{}{
+		"Statement":   statement,
+		"Adjustments": adjustments,
+		"Back":        "/admin/payroll/" + statement.ID,
+		"Error":       e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/payroll/{id}/approve
+func (h *AdminHandler) ApprovePayroll(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	err := h.PayrollService.Approve(id, adminActor(e, "payroll"))
+	return h.payrollRedirect(e, "/admin/payroll/"+id, err)
+}
+
+// POST /admin/payroll/adjustments
+// Form: technician_id, period, kind (bonus|penalty|advance), amount, description, back
+func (h *AdminHandler) AddPayrollAdjustment(e *core.RequestEvent) error {
+	_, err := h.PayrollService.AddAdjustment(&domain.PayrollAdjustment{
+		TechnicianID: e.Request.FormValue("technician_id"),
+		Period:       e.Request.FormValue("period"),
+		Kind:         e.Request.FormValue("kind"),
+		Amount:       domain.ParseVNDAmount(e.Request.FormValue("amount")),
+		Description:  e.Request.FormValue("description"),
+	}, adminActor(e, "payroll"))
+	return h.payrollRedirect(e, e.Request.FormValue("back"), err)
+}
+
+// POST /admin/payroll/adjustments/{id}/delete
+// Form: back
+func (h *AdminHandler) RemovePayrollAdjustment(e *core.RequestEvent) error {
+	_, err := h.PayrollService.RemoveAdjustment(e.Request.PathValue("id"), adminActor(e, "payroll"))
+	return h.payrollRedirect(e, e.Request.FormValue("back"), err)
+}
+
+// GET /admin/payroll/export?period=YYYY-MM&format=csv|xlsx
+func (h *AdminHandler) ExportPayroll(e *core.RequestEvent) error {
+	period := payrollPeriodParam(e)
+	statements, err := h.PayrollService.List(period)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	if e.Request.URL.Query().Get("format") == "csv" {
+		data, err := services.PayrollCSV(statements)
+		if err != nil {
+			return e.String(500, err.Error())
+		}
+		return RenderDownload(e, fmt.Sprintf("bang-luong-%s.csv", period), "text/csv; charset=utf-8", data)
+	}
+
+	data, err := services.PayrollXLSX(statements)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	return RenderDownload(e, fmt.Sprintf("bang-luong-%s.xlsx", period), services.XLSXContentType, data)
+}
+
+func (h *AdminHandler) payrollRedirect(e *core.RequestEvent, back string, err error) error {
+	if !strings.HasPrefix(back, "/admin/payroll") {
+		back = "/admin/payroll"
+	}
+	if err != nil {
+		sep := "?"
+		if strings.Contains(back, "?") {
+			sep = "&"
+		}
+		back += sep + "error=" + url.QueryEscape(payrollErrorMessage(err))
+	}
+	return e.Redirect(http.StatusSeeOther, back)
+}
+
+func payrollErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPeriod):
+		return "Kỳ lương không hợp lệ (định dạng YYYY-MM)"
+	case errors.Is(err, domain.ErrInvalidAdjustment):
+		return "Chọn loại điều chỉnh và nhập số tiền lớn hơn 0"
+	case errors.Is(err, domain.ErrPayrollLocked):
+		return "Bảng lương đã duyệt, không thể thay đổi"
+	case strings.Contains(err.Error(), "not found"):
+		return "Không tìm thấy bảng lương hoặc thợ"
+	default:
+		return "Không thể xử lý, vui lòng thử lại"
+	}
+}
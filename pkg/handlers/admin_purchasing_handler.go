@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	domain "hvac-system/internal/core"
+	"hvac-system/pkg/services"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// GET /admin/tools/purchasing?status= - orders, payables and suppliers
+func (h *AdminToolsHandler) PurchasingPage(e *core.RequestEvent) error {
+	status := e.Request.URL.Query().Get("status")
+	orders, err := h.PurchaseService.PurchaseOrders(status)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	payables, err := h.PurchaseService.Payables()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	suppliers, err := h.PurchaseService.Suppliers(false)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	products, err := h.InventoryService.GetProducts()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	var owed, overdue float64
+	for _, p := range payables {
+		owed += p.Balance
+		overdue += p.Overdue
+	}
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/purchasing.html", map[string]interface{}{
+		"Status":    status,
+		"Orders":    orders,
+		"Payables":  payables,
+		"Owed":      owed,
+		"Overdue":   overdue,
+		"Suppliers": suppliers,
+		"Products":  products,
+		"Error":     e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/tools/purchasing/orders - new draft
+// Form: supplier_id, expected_date, note, item_id[], quantity[], unit_price[]
+func (h *AdminToolsHandler) CreatePurchaseOrder(e *core.RequestEvent) error {
+	if err := e.Request.ParseForm(); err != nil {
+		return h.purchasingRedirect(e, "", err)
+	}
+	po := &domain.PurchaseOrder{
+		SupplierID:   e.Request.FormValue("supplier_id"),
+		ExpectedDate: e.Request.FormValue("expected_date"),
+		Note:         e.Request.FormValue("note"),
+	}
+	items := e.Request.Form["item_id"]
+	quantities := e.Request.Form["quantity"]
+	prices := e.Request.Form["unit_price"]
+	for i, itemID := range items {
+		if itemID == "" {
+			continue
+		}
+		line := domain.PurchaseLine{ItemID: itemID}
+		if i < len(quantities) {
+			line.Quantity, _ = strconv.ParseFloat(quantities[i], 64)
+		}
+		if i < len(prices) {
+			line.UnitPrice = domain.ParseVNDAmount(prices[i])
+		}
+		po.Lines = append(po.Lines, line)
+	}
+
+	if err := h.PurchaseService.CreatePurchaseOrder(po, adminActor(e, "purchasing").ID); err != nil {
+		return h.purchasingRedirect(e, "", err)
+	}
+	return h.purchasingRedirect(e, "/admin/tools/purchasing/orders/"+po.ID, nil)
+}
+
+// GET /admin/tools/purchasing/orders/{id} - lines, receipts and invoices
+func (h *AdminToolsHandler) PurchaseOrderPage(e *core.RequestEvent) error {
+	po, err := h.PurchaseService.GetPurchaseOrder(e.Request.PathValue("id"))
+	if err != nil {
+		return e.String(404, "Purchase order not found")
+	}
+	receipts, err := h.PurchaseService.Receipts(po.ID)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	invoices, err := h.PurchaseService.SupplierInvoices("", po.ID)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	// Lines of tracked items ask for their serials / lots on receipt
+	tracking := map[string]string{}
+	for _, l := range po.Lines {
+		if item, err := h.App.FindRecordById("inventory_items", l.ItemID); err == nil {
+			tracking[l.ItemID] = item.GetString("tracking")
+		}
+	}
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/purchase_order.html", map[string]interface{}{
+		"Order":    po,
+		"Tracking": tracking,
+		"Receipts": receipts,
+		"Invoices": invoices,
+		"Back":     "/admin/tools/purchasing/orders/" + po.ID,
+		"Error":    e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/tools/purchasing/orders/{id}/order - draft sent to the supplier
+func (h *AdminToolsHandler) OrderPurchaseOrder(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	_, err := h.PurchaseService.MarkOrdered(id)
+	return h.purchasingRedirect(e, "/admin/tools/purchasing/orders/"+id, err)
+}
+
+// POST /admin/tools/purchasing/orders/{id}/cancel
+func (h *AdminToolsHandler) CancelPurchaseOrder(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	_, err := h.PurchaseService.CancelPurchaseOrder(id)
+	return h.purchasingRedirect(e, "/admin/tools/purchasing/orders/"+id, err)
+}
+
+// POST /admin/tools/purchasing/orders/{id}/receive - post a delivery into the main warehouse
+// Form: item_id[], quantity[], unit_price[], lots[] (serials / lots of tracked items), note
+func (h *AdminToolsHandler) ReceivePurchaseOrder(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	if err := e.Request.ParseForm(); err != nil {
+		return h.purchasingRedirect(e, "/admin/tools/purchasing/orders/"+id, err)
+	}
+	quantities := e.Request.Form["quantity"]
+	prices := e.Request.Form["unit_price"]
+	lots := e.Request.Form["lots"]
+	var lines []domain.ReceiptLine
+	for i, itemID := range e.Request.Form["item_id"] {
+		line := domain.ReceiptLine{ItemID: itemID}
+		if i < len(quantities) {
+			line.Quantity, _ = strconv.ParseFloat(quantities[i], 64)
+		}
+		if i < len(prices) {
+			line.UnitPrice = domain.ParseVNDAmount(prices[i])
+		}
+		if i < len(lots) {
+			line.Lots = formLots(lots[i], line.Quantity)
+		}
+		lines = append(lines, line)
+	}
+
+	_, err := h.PurchaseService.ReceiveGoods(id, lines, e.Request.FormValue("note"), adminActor(e, "purchasing").ID)
+	return h.purchasingRedirect(e, "/admin/tools/purchasing/orders/"+id, err)
+}
+
+// POST /admin/tools/purchasing/suppliers - create, or update when id is set
+// Form: id, name, tax_code, contact_name, phone, email, address, payment_terms, note, active, back
+func (h *AdminToolsHandler) SaveSupplier(e *core.RequestEvent) error {
+	terms, _ := strconv.Atoi(e.Request.FormValue("payment_terms"))
+	supplier := &domain.Supplier{
+		ID:           e.Request.FormValue("id"),
+		Name:         strings.TrimSpace(e.Request.FormValue("name")),
+		TaxCode:      strings.TrimSpace(e.Request.FormValue("tax_code")),
+		ContactName:  e.Request.FormValue("contact_name"),
+		Phone:        strings.TrimSpace(e.Request.FormValue("phone")),
+		Email:        strings.TrimSpace(e.Request.FormValue("email")),
+		Address:      e.Request.FormValue("address"),
+		PaymentTerms: terms,
+		Note:         e.Request.FormValue("note"),
+		Active:       supplierActive(e),
+	}
+	err := h.PurchaseService.SaveSupplier(supplier)
+	back := e.Request.FormValue("back")
+	if err == nil && back == "" {
+		back = "/admin/tools/purchasing/suppliers/" + supplier.ID
+	}
+	return h.purchasingRedirect(e, back, err)
+}
+
+// New suppliers are active; the edit form sends the checkbox
+func supplierActive(e *core.RequestEvent) bool {
+	if e.Request.FormValue("id") == "" {
+		return true
+	}
+	return e.Request.FormValue("active") == "on" || e.Request.FormValue("active") == "true"
+}
+
+// GET /admin/tools/purchasing/suppliers/{id} - details, invoices, preferred items
+func (h *AdminToolsHandler) SupplierPage(e *core.RequestEvent) error {
+	supplier, err := h.PurchaseService.GetSupplier(e.Request.PathValue("id"))
+	if err != nil {
+		return e.String(404, "Supplier not found")
+	}
+	invoices, err := h.PurchaseService.SupplierInvoices(supplier.ID, "")
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	products, err := h.InventoryService.GetProducts()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	var preferred []services.Product
+	for _, p := range products {
+		if p.PreferredSupplierID == supplier.ID {
+			preferred = append(preferred, p)
+		}
+	}
+	payable := &domain.Payable{SupplierID: supplier.ID, SupplierName: supplier.Name}
+	if totals := domain.SummarizePayables(invoices, time.Now()); len(totals) > 0 {
+		payable = totals[0]
+	}
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/supplier.html", map[string]interface{}{
+		"Supplier":  supplier,
+		"Invoices":  invoices,
+		"Payable":   payable,
+		"Preferred": preferred,
+		"Products":  products,
+		"Back":      "/admin/tools/purchasing/suppliers/" + supplier.ID,
+		"Error":     e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/tools/purchasing/preferred - preferred supplier of an item
+// Form: item_id, supplier_id (empty clears it), back
+func (h *AdminToolsHandler) SetPreferredSupplier(e *core.RequestEvent) error {
+	err := h.PurchaseService.SetPreferredSupplier(e.Request.FormValue("item_id"), e.Request.FormValue("supplier_id"))
+	return h.purchasingRedirect(e, e.Request.FormValue("back"), err)
+}
+
+// POST /admin/tools/purchasing/invoices - record a supplier invoice
+// Form: supplier_id, po_id, invoice_no, invoice_date, due_date, amount, note, back
+func (h *AdminToolsHandler) CreateSupplierInvoice(e *core.RequestEvent) error {
+	err := h.PurchaseService.CreateSupplierInvoice(&domain.SupplierInvoice{
+		SupplierID:  e.Request.FormValue("supplier_id"),
+		POID:        e.Request.FormValue("po_id"),
+		InvoiceNo:   strings.TrimSpace(e.Request.FormValue("invoice_no")),
+		InvoiceDate: e.Request.FormValue("invoice_date"),
+		DueDate:     e.Request.FormValue("due_date"),
+		Amount:      domain.ParseVNDAmount(e.Request.FormValue("amount")),
+		Note:        e.Request.FormValue("note"),
+	}, adminActor(e, "purchasing").ID)
+	return h.purchasingRedirect(e, e.Request.FormValue("back"), err)
+}
+
+// POST /admin/tools/purchasing/invoices/{id}/pay - payment to the supplier
+// Form: amount, method, reference, note, back
+func (h *AdminToolsHandler) PaySupplierInvoice(e *core.RequestEvent) error {
+	_, err := h.PurchaseService.PaySupplierInvoice(
+		e.Request.PathValue("id"),
+		domain.ParseVNDAmount(e.Request.FormValue("amount")),
+		e.Request.FormValue("method"),
+		e.Request.FormValue("reference"),
+		e.Request.FormValue("note"),
+		adminActor(e, "purchasing").ID,
+	)
+	return h.purchasingRedirect(e, e.Request.FormValue("back"), err)
+}
+
+// DraftPurchaseOrders turns the current low-stock alerts into draft POs
+// POST /admin/tools/purchasing/draft-from-alerts
+func (h *AdminToolsHandler) DraftPurchaseOrders(e *core.RequestEvent) error {
+	alerts, err := h.InventoryService.GetLowStockAlerts()
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	orders, unassigned, err := h.PurchaseService.DraftFromLowStock(alerts, adminActor(e, "purchasing").ID)
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": purchasingErrorMessage(err)})
+	}
+
+	message := fmt.Sprintf("Đã tạo %d PO nháp", len(orders))
+	if len(orders) == 0 {
+		message = "Không có vật tư nào cần đặt thêm"
+	}
+	if len(unassigned) > 0 {
+		message += fmt.Sprintf(", %d vật tư chưa có nhà cung cấp ưu tiên", len(unassigned))
+	}
+	return e.JSON(200, map[string]interface{}{
+		"success":    true,
+		"orders":     orders,
+		"unassigned": unassigned,
+		"message":    message,
+	})
+}
+
+func (h *AdminToolsHandler) purchasingRedirect(e *core.RequestEvent, back string, err error) error {
+	if !strings.HasPrefix(back, "/admin/tools/purchasing") {
+		back = "/admin/tools/purchasing"
+	}
+	if err != nil {
+		sep := "?"
+		if strings.Contains(back, "?") {
+			sep = "&"
+		}
+		back += sep + "error=" + url.QueryEscape(purchasingErrorMessage(err))
+	}
+	return e.Redirect(http.StatusSeeOther, back)
+}
+
+func purchasingErrorMessage(err error) string {
+	if msg := trackingErrorMessage(err); msg != "" {
+		return msg
+	}
+	var shortage *services.StockShortageError
+	switch {
+	case errors.Is(err, domain.ErrInvalidSupplier):
+		return "Nhập tên nhà cung cấp"
+	case errors.Is(err, domain.ErrInvalidPurchaseOrder):
+		return "Chọn nhà cung cấp và ít nhất một vật tư với số lượng lớn hơn 0 (mỗi vật tư một dòng)"
+	case errors.Is(err, domain.ErrPurchaseOrderState):
+		return "Trạng thái đơn hàng không cho phép thao tác này"
+	case errors.Is(err, domain.ErrInvalidReceipt):
+		return "Nhập số lượng nhận lớn hơn 0 cho vật tư có trong đơn"
+	case errors.Is(err, domain.ErrOverReceipt):
+		return "Số lượng nhận vượt quá số còn lại trên đơn"
+	case errors.Is(err, domain.ErrInvalidSupplierInvoice):
+		return "Nhập số hóa đơn và số tiền lớn hơn 0"
+	case errors.Is(err, domain.ErrInvalidPayment):
+		return "Số tiền thanh toán phải lớn hơn 0"
+	case errors.Is(err, domain.ErrSupplierOverpayment):
+		return "Số tiền vượt quá số còn nợ trên hóa đơn"
+	case errors.As(err, &shortage):
+		return shortage.Error()
+	case strings.Contains(err.Error(), "UNIQUE"):
+		return "Hóa đơn này đã được ghi nhận"
+	case strings.Contains(err.Error(), "no rows"):
+		return "Không tìm thấy dữ liệu"
+	default:
+		return "Không thể xử lý, vui lòng thử lại"
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	domain "hvac-system/internal/core"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// GET /admin/schedules
+// Working hours per tech, pending leave requests and company holidays
+func (h *AdminHandler) SchedulesPage(e *core.RequestEvent) error {
+	techs, err := h.TechService.GetAllTechs()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	pending, err := h.ScheduleService.PendingTimeOff()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/schedules.html", map[string]interface{}{
+		"Techs":   techs,
+		"Pending": pending,
+	})
+}
+
+// GET /admin/api/techs/{id}/schedule
+// Weekly hours + time off of the next 60 days (incl. company holidays)
+func (h *AdminHandler) TechSchedule(e *core.RequestEvent) error {
+	techID := e.Request.PathValue("id")
+
+	hours, err := h.ScheduleService.GetWorkingHours(techID)
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	offs, err := h.ScheduleService.TimeOffForTechnician(techID)
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	// Only upcoming entries are relevant for the editor
+	today := time.Now().Format("2006-01-02")
+	upcoming := make([]*domain.TimeOff, 0, len(offs))
+	for _, off := range offs {
+		if off.DateTo >= today {
+			upcoming = append(upcoming, off)
+		}
+	}
+
+	return e.JSON(200, map[string]interface{}{
+		"hours":    hours,
+		"time_off": upcoming,
+	})
+}
+
+// POST /admin/api/techs/{id}/working-hours
+// Body: {"hours": [{"weekday": 1, "start_time": "08:00", "end_time": "17:00"}]}
+func (h *AdminHandler) SaveWorkingHours(e *core.RequestEvent) error {
+	techID := e.Request.PathValue("id")
+
+	var req struct {
+		Hours []*domain.WorkingHours `json:"hours"`
+	}
+	if err := e.BindBody(&req); err != nil {
+		return e.JSON(400, map[string]string{"error": "Dữ liệu không hợp lệ"})
+	}
+
+	if err := h.ScheduleService.SetWorkingHours(techID, req.Hours); err != nil {
+		if errors.Is(err, domain.ErrInvalidWorkHours) {
+			return e.JSON(400, map[string]string{"error": "Giờ làm việc không hợp lệ (giờ kết thúc phải sau giờ bắt đầu)"})
+		}
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return e.JSON(200, map[string]interface{}{"success": true, "hours": req.Hours})
+}
+
+// POST /admin/api/time-off
+// Admin entries (leave, sick day, holiday) are approved immediately.
+// Empty technician_id + kind=holiday = company-wide holiday.
+func (h *AdminHandler) CreateTimeOff(e *core.RequestEvent) error {
+	off := &domain.TimeOff{
+		TechnicianID: e.Request.FormValue("technician_id"),
+		Kind:         e.Request.FormValue("kind"),
+		DateFrom:     e.Request.FormValue("date_from"),
+		DateTo:       e.Request.FormValue("date_to"),
+		StartTime:    e.Request.FormValue("start_time"),
+		EndTime:      e.Request.FormValue("end_time"),
+		Reason:       e.Request.FormValue("reason"),
+	}
+
+	if err := h.ScheduleService.RequestTimeOff(off, adminActor(e, "schedules")); err != nil {
+		if errors.Is(err, domain.ErrInvalidTimeOff) {
+			return e.JSON(400, map[string]string{"error": "Thông tin ngày nghỉ không hợp lệ"})
+		}
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return e.JSON(200, off)
+}
+
+// POST /admin/api/time-off/{id}/review
+// Form: action=approve|reject, note
+func (h *AdminHandler) ReviewTimeOff(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	approve := e.Request.FormValue("action") == "approve"
+	note := e.Request.FormValue("note")
+
+	if err := h.ScheduleService.ReviewTimeOff(id, approve, note, adminActor(e, "schedules")); err != nil {
+		if errors.Is(err, domain.ErrTimeOffReviewed) {
+			return e.JSON(409, map[string]string{"error": "Yêu cầu đã được xử lý trước đó"})
+		}
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return e.JSON(200, map[string]interface{}{"success": true})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	domain "hvac-system/internal/core"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// GET /admin/tools/inventory/serials?q= - where a serial or lot number is, or
+// which customer equipment it was installed in
+func (h *AdminToolsHandler) StockLotsPage(e *core.RequestEvent) error {
+	query := strings.TrimSpace(e.Request.URL.Query().Get("q"))
+	var lots []domain.TrackedLot
+	if query != "" {
+		var err error
+		if lots, err = h.InventoryService.TraceLots(strings.ToUpper(query)); err != nil {
+			return e.String(500, err.Error())
+		}
+	}
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/stock_lots.html", map[string]interface{}{
+		"Query": query,
+		"Lots":  lots,
+	})
+}
+
+// formLots reads serial / lot numbers typed in a form (see domain.ParseLots);
+// a single lot without a quantity covers the whole quantity
+func formLots(value string, qty float64) []domain.LotAllocation {
+	return domain.FillLotQuantity(domain.ParseLots(value), qty)
+}
+
+// trackingErrorMessage explains a serial / lot error, or returns "" for any
+// other error
+func trackingErrorMessage(err error) string {
+	number := ""
+	if i := strings.LastIndex(err.Error(), ": "); i >= 0 {
+		number = " (" + err.Error()[i+2:] + ")"
+	}
+	switch {
+	case errors.Is(err, domain.ErrTrackingRequired):
+		return "Vật tư này quản lý theo số serial / lô: cần nhập số serial hoặc số lô"
+	case errors.Is(err, domain.ErrTrackingMismatch):
+		return "Số serial / lô không khớp số lượng (mỗi đơn vị một serial, không trùng; tổng các lô bằng số lượng)"
+	case errors.Is(err, domain.ErrUntrackedItem):
+		return "Vật tư này không quản lý theo số serial / lô"
+	case errors.Is(err, domain.ErrDuplicateSerial):
+		return "Số serial đã có trong kho" + number
+	case errors.Is(err, domain.ErrLotNotInStock):
+		return "Số serial / lô không có trong kho xuất" + number
+	default:
+		return ""
+	}
+}
+
+// stockErrorMessage is the message of a failed stock movement shown by the
+// JSON endpoints
+func stockErrorMessage(err error) string {
+	if msg := trackingErrorMessage(err); msg != "" {
+		return msg
+	}
+	return err.Error()
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	domain "hvac-system/internal/core"
+	"hvac-system/pkg/services"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// GET /admin/tools/stocktakes?location= - stocktakes in progress, history
+// per location and the start form
+func (h *AdminToolsHandler) StocktakesPage(e *core.RequestEvent) error {
+	location := e.Request.URL.Query().Get("location")
+	takes, err := h.StocktakeService.Stocktakes(location, "")
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	history, err := h.StocktakeService.History()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	techs, _ := h.App.FindRecordsByFilter("technicians", "", "name", 0, 0, nil)
+	locations := []map[string]string{{"id": domain.StockMain, "name": "Kho chính"}}
+	for _, t := range techs {
+		locations = append(locations, map[string]string{"id": t.Id, "name": t.GetString("name")})
+	}
+
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/stocktakes.html", map[string]interface{}{
+		"Location":   location,
+		"Locations":  locations,
+		"Stocktakes": takes,
+		"History":    history,
+		"Error":      e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/tools/stocktakes - snapshot a location
+// Form: location, note
+func (h *AdminToolsHandler) StartStocktake(e *core.RequestEvent) error {
+	st, err := h.StocktakeService.StartStocktake(e.Request.FormValue("location"), e.Request.FormValue("note"),
+		adminActor(e, "stocktake").ID)
+	if err != nil {
+		return h.stocktakeRedirect(e, "", err)
+	}
+	return h.stocktakeRedirect(e, "/admin/tools/stocktakes/"+st.ID, nil)
+}
+
+// GET /admin/tools/stocktakes/{id} - counts and variances
+func (h *AdminToolsHandler) StocktakePage(e *core.RequestEvent) error {
+	st, err := h.StocktakeService.GetStocktake(e.Request.PathValue("id"))
+	if err != nil {
+		return e.String(404, "Stocktake not found")
+	}
+	products, err := h.InventoryService.GetProducts()
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	listed := make(map[string]bool, len(st.Lines))
+	for _, l := range st.Lines {
+		listed[l.ItemID] = true
+	}
+	var unlisted []services.Product
+	for _, p := range products {
+		if !listed[p.ID] {
+			unlisted = append(unlisted, p)
+		}
+	}
+
+	// Serial / lot numbers at the location, to name the ones missing or found
+	inStock, err := h.InventoryService.StockLots(st.Location)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/stocktake.html", map[string]interface{}{
+		"Stocktake": st,
+		"Unlisted":  unlisted,
+		"InStock":   inStock,
+		"Error":     e.Request.URL.Query().Get("error"),
+	})
+}
+
+// POST /admin/tools/stocktakes/{id}/count - save counts, then submit if asked
+// Form: item_id[], counted[] (empty = not counted yet), lots[] (serial / lot
+// numbers of the variance of tracked items), submit
+func (h *AdminToolsHandler) CountStocktake(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	counts, err := stocktakeCounts(e)
+	if err != nil {
+		return h.stocktakeRedirect(e, "/admin/tools/stocktakes/"+id, err)
+	}
+	st, err := h.StocktakeService.GetStocktake(id)
+	if err != nil {
+		return h.stocktakeRedirect(e, "/admin/tools/stocktakes/"+id, err)
+	}
+	by := adminActor(e, "stocktake").ID
+	if _, err := h.StocktakeService.Count(id, counts, stocktakeLots(e, st, counts), by); err != nil {
+		return h.stocktakeRedirect(e, "/admin/tools/stocktakes/"+id, err)
+	}
+	if e.Request.FormValue("submit") != "" {
+		_, err = h.StocktakeService.Submit(id, by)
+	}
+	return h.stocktakeRedirect(e, "/admin/tools/stocktakes/"+id, err)
+}
+
+// POST /admin/tools/stocktakes/{id}/items - list an item the snapshot missed
+// Form: item_id
+func (h *AdminToolsHandler) AddStocktakeItem(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	_, err := h.StocktakeService.AddItem(id, e.Request.FormValue("item_id"))
+	return h.stocktakeRedirect(e, "/admin/tools/stocktakes/"+id, err)
+}
+
+// POST /admin/tools/stocktakes/{id}/approve - post the variances to the ledger
+func (h *AdminToolsHandler) ApproveStocktake(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	_, err := h.StocktakeService.Approve(id, adminActor(e, "stocktake").ID)
+	return h.stocktakeRedirect(e, "/admin/tools/stocktakes/"+id, err)
+}
+
+// POST /admin/tools/stocktakes/{id}/cancel
+func (h *AdminToolsHandler) CancelStocktake(e *core.RequestEvent) error {
+	id := e.Request.PathValue("id")
+	_, err := h.StocktakeService.Cancel(id)
+	return h.stocktakeRedirect(e, "/admin/tools/stocktakes/"+id, err)
+}
+
+// stocktakeCounts reads item_id[] / counted[] pairs; an empty count is skipped
+func stocktakeCounts(e *core.RequestEvent) (map[string]float64, error) {
+	if err := e.Request.ParseForm(); err != nil {
+		return nil, err
+	}
+	counted := e.Request.Form["counted"]
+	counts := map[string]float64{}
+	for i, itemID := range e.Request.Form["item_id"] {
+		if i >= len(counted) || strings.TrimSpace(counted[i]) == "" {
+			continue
+		}
+		qty, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(counted[i]), ",", "."), 64)
+		if err != nil {
+			return nil, domain.ErrInvalidCount
+		}
+		counts[itemID] = qty
+	}
+	return counts, nil
+}
+
+// stocktakeLots reads the item_id[] / lots[] pairs of tracked lines. A single
+// lot typed without a quantity covers the whole variance.
+func stocktakeLots(e *core.RequestEvent, st *domain.Stocktake, counts map[string]float64) map[string][]domain.LotAllocation {
+	numbers := e.Request.Form["lots"]
+	lots := map[string][]domain.LotAllocation{}
+	for i, itemID := range e.Request.Form["item_id"] {
+		if i >= len(numbers) {
+			break
+		}
+		for _, l := range st.Lines {
+			if l.ItemID != itemID || l.Tracking == "" {
+				continue
+			}
+			if qty, ok := counts[itemID]; ok {
+				l.Counted, l.IsCounted = qty, true
+			}
+			lots[itemID] = formLots(numbers[i], math.Abs(l.Variance()))
+		}
+	}
+	return lots
+}
+
+func (h *AdminToolsHandler) stocktakeRedirect(e *core.RequestEvent, back string, err error) error {
+	if !strings.HasPrefix(back, "/admin/tools/stocktakes") {
+		back = "/admin/tools/stocktakes"
+	}
+	if err != nil {
+		back += "?error=" + url.QueryEscape(stocktakeErrorMessage(err))
+	}
+	return e.Redirect(http.StatusSeeOther, back)
+}
+
+func stocktakeErrorMessage(err error) string {
+	var shortage *services.StockShortageError
+	switch {
+	case errors.Is(err, domain.ErrInvalidStocktake):
+		return "Chọn kho có vật tư để kiểm kê (mỗi vật tư một dòng)"
+	case errors.Is(err, domain.ErrStocktakeOpen):
+		return "Kho này đang có phiếu kiểm kê chưa duyệt"
+	case errors.Is(err, domain.ErrStocktakeState):
+		return "Trạng thái phiếu kiểm kê không cho phép thao tác này"
+	case errors.Is(err, domain.ErrInvalidCount):
+		return "Số lượng đếm phải là số không âm"
+	case errors.Is(err, domain.ErrStocktakeIncomplete):
+		return "Cần nhập số đếm cho tất cả vật tư trước khi gửi duyệt"
+	case errors.Is(err, domain.ErrTrackingRequired):
+		msg := "Vật tư quản lý theo serial / lô bị lệch: nhập số serial / lô thiếu hoặc thừa"
+		if item, ok := strings.CutPrefix(err.Error(), domain.ErrTrackingRequired.Error()+": "); ok {
+			msg += " (" + item + ")"
+		}
+		return msg
+	case errors.As(err, &shortage):
+		return "Tồn kho đã thay đổi kể từ lúc chốt số, cần kiểm kê lại: " + shortage.Error()
+	case strings.Contains(err.Error(), "no rows"):
+		return "Không tìm thấy phiếu kiểm kê"
+	case trackingErrorMessage(err) != "":
+		return trackingErrorMessage(err)
+	default:
+		return "Không thể xử lý, vui lòng thử lại"
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json" // <-- Nhớ thêm import này
 	"fmt"
 	"html/template"
+	domain "hvac-system/internal/core"
 	"hvac-system/pkg/broker"
 	"hvac-system/pkg/services"
 	"strconv"
@@ -18,6 +19,8 @@ type AdminToolsHandler struct {
 	Templates        *template.Template
 	SlotService      *services.TimeSlotService
 	InventoryService *services.InventoryService
+	PurchaseService  *services.PurchaseService
+	StocktakeService *services.StocktakeService
 	Broker           *broker.SegmentedBroker
 }
 
@@ -102,6 +105,8 @@ func (h *AdminToolsHandler) ShowInventoryManager(e *core.RequestEvent) error {
 		Price         float64 `json:"price"`
 		StockQuantity float64 `json:"stock_quantity"` // Quan trọng: Khớp với item.stock_quantity ở JS
 		Unit          string  `json:"unit"`
+		VATRate       string  `json:"vat_rate"`
+		Tracking      string  `json:"tracking"` // Serial / lot tracking, empty = none
 	}
 
 	// 3. Chuyển đổi dữ liệu PocketBase Record -> Struct JSON
@@ -115,6 +120,8 @@ func (h *AdminToolsHandler) ShowInventoryManager(e *core.RequestEvent) error {
 			Price:         item.Price,
 			StockQuantity: float64(item.StockQuantity), // Lấy đúng trường từ DB
 			Unit:          item.Unit,
+			VATRate:       item.VATRate,
+			Tracking:      item.Tracking,
 		})
 	}
 
@@ -158,6 +165,8 @@ func (h *AdminToolsHandler) CreateInventoryItem(e *core.RequestEvent) error {
 	stockStr := e.Request.FormValue("stock_quantity")
 	unit := e.Request.FormValue("unit")
 	description := e.Request.FormValue("description")
+	vatRate := e.Request.FormValue("vat_rate") // Empty = brand default
+	tracking := domain.NormalizeTrackingMode(e.Request.FormValue("tracking"))
 
 	fmt.Printf("📝 Data: Name=%s, SKU=%s, Category=%s, Price=%s, Stock=%s\n", name, sku, category, priceStr, stockStr)
 
@@ -172,9 +181,10 @@ func (h *AdminToolsHandler) CreateInventoryItem(e *core.RequestEvent) error {
 	record.Set("sku", sku)
 	record.Set("category", category)
 	record.Set("price", price)
-	record.Set("stock_quantity", stock)
 	record.Set("unit", unit)
 	record.Set("description", description)
+	record.Set("vat_rate", vatRate)
+	record.Set("tracking", tracking)
 	record.Set("is_active", true)
 
 	if err := h.App.Save(record); err != nil {
@@ -182,6 +192,11 @@ func (h *AdminToolsHandler) CreateInventoryItem(e *core.RequestEvent) error {
 		return e.JSON(500, map[string]string{"error": err.Error()})
 	}
 
+	// Initial stock goes through the stock ledger
+	if err := h.InventoryService.SetMainStock(record.Id, stock, "Tồn kho ban đầu", adminActor(e, "inventory").ID); err != nil {
+		return e.JSON(400, map[string]string{"error": err.Error()})
+	}
+
 	fmt.Println("✅ Inventory Item Created:", record.Id)
 
 	// Construct the item object to return
@@ -193,6 +208,8 @@ func (h *AdminToolsHandler) CreateInventoryItem(e *core.RequestEvent) error {
 		"price":          price,
 		"stock_quantity": stock,
 		"unit":           unit,
+		"vat_rate":       vatRate,
+		"tracking":       tracking,
 	}
 
 	return e.JSON(200, map[string]interface{}{
@@ -220,17 +237,13 @@ func (h *AdminToolsHandler) UpdateInventoryStock(e *core.RequestEvent) error {
 
 	currentStock := item.GetFloat("stock_quantity")
 
-	var newStock float64
+	newStock := quantity
 	if operation == "add" {
 		newStock = currentStock + quantity
-	} else {
-		newStock = quantity
 	}
 
-	item.Set("stock_quantity", newStock)
-
-	if err := h.App.Save(item); err != nil {
-		return e.JSON(500, map[string]string{"error": err.Error()})
+	if err := h.InventoryService.SetMainStock(itemID, newStock, e.Request.FormValue("note"), adminActor(e, "inventory").ID); err != nil {
+		return e.JSON(400, map[string]string{"error": err.Error()})
 	}
 
 	// Check for low stock alert
@@ -270,14 +283,15 @@ func (h *AdminToolsHandler) ImportToMain(e *core.RequestEvent) error {
 		return e.JSON(400, map[string]string{"error": "Số lượng không hợp lệ"})
 	}
 
-	adminID := ""
-	if e.Auth != nil {
-		adminID = e.Auth.Id
-	}
+	// Optional unit cost of this receipt; empty takes the last purchase price
+	unitCost := domain.ParseVNDAmount(e.Request.FormValue("unit_cost"))
 
-	err = h.InventoryService.ImportToMain(productID, qty, note, adminID)
+	// Serials / lots received, required for tracked items
+	lots := formLots(e.Request.FormValue("lots"), qty)
+
+	err = h.InventoryService.ImportToMain(productID, qty, unitCost, lots, note, adminActor(e, "inventory").ID)
 	if err != nil {
-		return e.JSON(400, map[string]string{"error": err.Error()})
+		return e.JSON(400, map[string]string{"error": stockErrorMessage(err)})
 	}
 
 	return e.JSON(200, map[string]interface{}{
@@ -300,6 +314,76 @@ func (h *AdminToolsHandler) GetLowStockAlerts(e *core.RequestEvent) error {
 	})
 }
 
+// CheckStockLedger lists balances that disagree with the stock ledger
+// GET /admin/tools/inventory/ledger
+func (h *AdminToolsHandler) CheckStockLedger(e *core.RequestEvent) error {
+	diffs, err := h.InventoryService.CheckStockLedger()
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return e.JSON(200, map[string]interface{}{
+		"discrepancies": diffs,
+		"count":         len(diffs),
+	})
+}
+
+// RebuildStockFromLedger overwrites the balances with the ledger replay
+// POST /admin/tools/inventory/ledger/rebuild
+func (h *AdminToolsHandler) RebuildStockFromLedger(e *core.RequestEvent) error {
+	fixed, err := h.InventoryService.RebuildStockFromLedger()
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return e.JSON(200, map[string]interface{}{
+		"success": true,
+		"fixed":   fixed,
+		"message": fmt.Sprintf("Đã đồng bộ %d số dư theo sổ kho", fixed),
+	})
+}
+
+// StockValuation values every warehouse at cost as of the end of a day
+// GET /admin/tools/inventory/valuation?date=YYYY-MM-DD&format=csv|xlsx
+func (h *AdminToolsHandler) StockValuation(e *core.RequestEvent) error {
+	date := e.Request.URL.Query().Get("date")
+	if _, err := domain.ValuationCutoff(date); err != nil {
+		date = domain.ValuationDate(time.Now())
+	}
+	lines, err := h.InventoryService.StockValuation(date)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	switch e.Request.URL.Query().Get("format") {
+	case "csv":
+		data, err := services.StockValuationCSV(date, lines)
+		if err != nil {
+			return e.String(500, err.Error())
+		}
+		return RenderDownload(e, fmt.Sprintf("gia-tri-ton-kho-%s.csv", date), "text/csv; charset=utf-8", data)
+	case "xlsx":
+		data, err := services.StockValuationXLSX(date, lines)
+		if err != nil {
+			return e.String(500, err.Error())
+		}
+		return RenderDownload(e, fmt.Sprintf("gia-tri-ton-kho-%s.xlsx", date), services.XLSXContentType, data)
+	}
+
+	locations := domain.ValueByLocation(lines)
+	total := 0.0
+	for _, l := range locations {
+		total += l.Value
+	}
+	return RenderPage(h.Templates, e, "layouts/admin.html", "admin/stock_valuation.html", map[string]interface{}{
+		"Date":      date,
+		"Lines":     lines,
+		"Locations": locations,
+		"Total":     total,
+		"Method":    h.InventoryService.CostingMethod(),
+	})
+}
+
 // ============================================
 // TRUCK STOCK (Kho Trên Xe) - Admin Handlers
 // ============================================
@@ -355,6 +439,7 @@ func (h *AdminToolsHandler) ShowTechStock(e *core.RequestEvent) error {
 		Price         float64 `json:"price"`
 		StockQuantity float64 `json:"stock_quantity"`
 		Unit          string  `json:"unit"`
+		Tracking      string  `json:"tracking"`
 	}
 
 	var itemsList []InventoryItemJSON
@@ -367,6 +452,7 @@ func (h *AdminToolsHandler) ShowTechStock(e *core.RequestEvent) error {
 			Price:         item.Price,
 			StockQuantity: float64(item.StockQuantity),
 			Unit:          item.Unit,
+			Tracking:      item.Tracking,
 		})
 	}
 
@@ -393,6 +479,10 @@ func (h *AdminToolsHandler) ShowTechStockDetail(e *core.RequestEvent) error {
 	if err != nil {
 		return e.JSON(500, map[string]string{"error": err.Error()})
 	}
+	lots, err := h.InventoryService.StockLots(techID)
+	if err != nil {
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
 
 	return e.JSON(200, map[string]interface{}{
 		"technician": map[string]string{
@@ -401,6 +491,7 @@ func (h *AdminToolsHandler) ShowTechStockDetail(e *core.RequestEvent) error {
 			"phone": tech.GetString("phone"),
 		},
 		"items": items,
+		"lots":  lots, // Serials / lots on the truck by item
 	})
 }
 
@@ -410,7 +501,7 @@ func (h *AdminToolsHandler) TransferStock(e *core.RequestEvent) error {
 	techID := e.Request.FormValue("technician_id")
 	itemID := e.Request.FormValue("item_id")
 	qtyStr := e.Request.FormValue("quantity")
-	adminID := "admin" // TODO: Get from session
+	adminID := adminActor(e, "inventory").ID
 
 	fmt.Printf("📦 TransferStock: Tech=%s Item=%s Qty=%s\n", techID, itemID, qtyStr)
 
@@ -425,10 +516,10 @@ func (h *AdminToolsHandler) TransferStock(e *core.RequestEvent) error {
 		return e.JSON(400, map[string]string{"error": "Invalid quantity"})
 	}
 
-	err = h.InventoryService.TransferToTech(itemID, techID, qty, adminID)
+	err = h.InventoryService.TransferToTech(itemID, techID, qty, formLots(e.Request.FormValue("lots"), qty), adminID)
 	if err != nil {
 		fmt.Println("❌ TransferToTech Error:", err)
-		return e.JSON(400, map[string]string{"error": err.Error()})
+		return e.JSON(400, map[string]string{"error": stockErrorMessage(err)})
 	}
 
 	// Get item name for SSE notification
@@ -463,7 +554,7 @@ func (h *AdminToolsHandler) ReturnStock(e *core.RequestEvent) error {
 	techID := e.Request.FormValue("technician_id")
 	itemID := e.Request.FormValue("item_id")
 	qtyStr := e.Request.FormValue("quantity")
-	adminID := "admin"
+	adminID := adminActor(e, "inventory").ID
 
 	if techID == "" || itemID == "" || qtyStr == "" {
 		return e.JSON(400, map[string]string{"error": "Missing required fields"})
@@ -474,9 +565,9 @@ func (h *AdminToolsHandler) ReturnStock(e *core.RequestEvent) error {
 		return e.JSON(400, map[string]string{"error": "Invalid quantity"})
 	}
 
-	err = h.InventoryService.ReturnToMain(techID, itemID, qty, adminID)
+	err = h.InventoryService.ReturnToMain(techID, itemID, qty, formLots(e.Request.FormValue("lots"), qty), adminID)
 	if err != nil {
-		return e.JSON(400, map[string]string{"error": err.Error()})
+		return e.JSON(400, map[string]string{"error": stockErrorMessage(err)})
 	}
 
 	return e.JSON(200, map[string]interface{}{
@@ -503,11 +594,9 @@ func (h *AdminToolsHandler) UpdateInventoryItem(e *core.RequestEvent) error {
 	priceStr := e.Request.FormValue("price")
 	unit := e.Request.FormValue("unit")
 	description := e.Request.FormValue("description")
-	// stock_quantity is usually managed via stock updates but can be set here if needed
-	// For now let's allow updating everything EXCEPT stock if intended, or just everything.
-	// User request was "edit information", usually stock is separate, but "Manage Item" modal has stock field.
-	// Let's allow stock update too if provided, but typically stock operations should be logged.
-	// For simplicity in "Edit Info", we might accept it if the user changes it.
+	vatRate := e.Request.FormValue("vat_rate") // Empty = brand default
+	// The "Manage Item" modal also edits the stock: a change is posted to the
+	// stock ledger as an adjustment, never written directly
 	stockStr := e.Request.FormValue("stock_quantity")
 
 	if name == "" || priceStr == "" {
@@ -515,19 +604,29 @@ func (h *AdminToolsHandler) UpdateInventoryItem(e *core.RequestEvent) error {
 	}
 
 	price, _ := strconv.ParseFloat(priceStr, 64)
-	stock, _ := strconv.ParseFloat(stockStr, 64)
+	stock := record.GetFloat("stock_quantity")
+	if stockStr != "" {
+		stock, _ = strconv.ParseFloat(stockStr, 64)
+	}
 
 	record.Set("name", name)
 	record.Set("sku", sku)
 	record.Set("category", category)
 	record.Set("price", price)
-	record.Set("stock_quantity", stock)
 	record.Set("unit", unit)
 	record.Set("description", description)
+	record.Set("vat_rate", vatRate)
+	// Serial / lot tracking; numbers are asked from the next receipt or transfer on
+	if _, ok := e.Request.Form["tracking"]; ok {
+		record.Set("tracking", domain.NormalizeTrackingMode(e.Request.FormValue("tracking")))
+	}
 
 	if err := h.App.Save(record); err != nil {
 		return e.JSON(500, map[string]string{"error": err.Error()})
 	}
+	if err := h.InventoryService.SetMainStock(id, stock, "Sửa số tồn khi cập nhật vật tư", adminActor(e, "inventory").ID); err != nil {
+		return e.JSON(400, map[string]string{"error": err.Error()})
+	}
 
 	newItem := map[string]interface{}{
 		"id":             record.Id,
@@ -538,6 +637,8 @@ func (h *AdminToolsHandler) UpdateInventoryItem(e *core.RequestEvent) error {
 		"stock_quantity": stock,
 		"unit":           unit,
 		"description":    description,
+		"vat_rate":       vatRate,
+		"tracking":       record.GetString("tracking"),
 	}
 
 	return e.JSON(200, map[string]interface{}{
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// renderTransitionError writes an illegal lifecycle change as 409 JSON
+// ({error, current_status}) - the shape the tech app and Kanban already handle.
+// Returns false when err is not a *domain.TransitionError.
+func renderTransitionError(e *core.RequestEvent, err error) (bool, error) {
+	var te *domain.TransitionError
+	if !errors.As(err, &te) {
+		return false, nil
+	}
+	return true, e.JSON(409, map[string]interface{}{
+		"error":          te.Message(),
+		"current_status": te.From,
+	})
+}
+
+// adminActor identifies the logged-in admin for the booking timeline
+func adminActor(e *core.RequestEvent, source string) domain.Actor {
+	actor := domain.Actor{Type: domain.ActorAdmin, Source: source}
+	if e.Auth != nil {
+		actor.ID = e.Auth.Id
+		actor.Name = e.Auth.GetString("email")
+	}
+	return actor
+}
+
+// techActor identifies the logged-in technician for the booking timeline
+func techActor(e *core.RequestEvent, source string) domain.Actor {
+	actor := domain.Actor{Type: domain.ActorTech, Source: source}
+	if e.Auth != nil {
+		actor.ID = e.Auth.Id
+		actor.Name = e.Auth.GetString("name")
+	}
+	return actor
+}
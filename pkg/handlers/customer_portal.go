@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	domain "hvac-system/internal/core"
+	"hvac-system/pkg/services"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// portalStatusLabels are the customer-facing booking states
+var portalStatusLabels = map[string]string{
+	domain.StatusPending:   "Đang tìm thợ phù hợp",
+	domain.StatusAssigned:  "Đã có thợ nhận lịch",
+	domain.StatusAccepted:  "Thợ đã xác nhận",
+	domain.StatusMoving:    "Thợ đang trên đường đến",
+	domain.StatusArrived:   "Thợ đã đến nơi",
+	domain.StatusWorking:   "Đang sửa chữa",
+	domain.StatusQuoting:   "Đang báo giá",
+	domain.StatusCompleted: "Hoàn thành",
+	domain.StatusCancelled: "Đã hủy",
+}
+
+// portalCancelReasons are the reasons a customer can pick when cancelling
+var portalCancelReasons = []string{
+	"Tôi không còn nhu cầu",
+	"Tôi đã tự sửa được",
+	"Thời gian không phù hợp",
+	"Tôi đặt nhầm",
+	"Lý do khác",
+}
+
+// portalBooking resolves the booking behind the /b/{token} link
+func (h *WebHandler) portalBooking(e *core.RequestEvent) (*domain.Booking, error) {
+	return h.BookingRepo.GetByAccessToken(e.Request.PathValue("token"))
+}
+
+// portalActor identifies the customer on the booking timeline
+func portalActor(b *domain.Booking) domain.Actor {
+	return domain.Actor{Type: domain.ActorCustomer, Name: b.CustomerName, Source: "portal"}
+}
+
+// PortalLink builds the customer portal URL path of a booking
+func PortalLink(b *domain.Booking) string {
+	if b == nil || b.AccessToken == "" {
+		return ""
+	}
+	return "/b/" + b.AccessToken
+}
+
+// ShowPortal renders the customer self-service page
+// GET /b/{token}
+func (h *WebHandler) ShowPortal(e *core.RequestEvent) error {
+	booking, err := h.portalBooking(e)
+	if err != nil {
+		return e.String(404, "Liên kết không hợp lệ hoặc đã hết hạn")
+	}
+
+	serviceName := booking.DeviceType
+	if booking.ServiceID != "" {
+		if svc, err := h.App.FindRecordById("services", booking.ServiceID); err == nil {
+			serviceName = svc.GetString("name")
+		}
+	}
+
+	var tech *domain.Technician
+	if booking.TechnicianID != "" {
+		tech, _ = h.TechRepo.GetByID(booking.TechnicianID)
+	}
+
+	// Initial state for the Alpine component (JSON-encoded by html/template)
+	initial := map[string]interface{}{
+		"token":       booking.AccessToken,
+		"status":      booking.JobStatus,
+		"statusLabel": portalStatusLabels[booking.JobStatus],
+		"bookingTime": booking.BookingTime,
+		"canModify":   domain.CustomerCanModify(booking.JobStatus),
+		"customer":    map[string]float64{"lat": booking.Lat, "long": booking.Long},
+	}
+
+	return RenderPage(h.Templates, e, "layouts/base.html", "public/booking_portal.html", map[string]interface{}{
+		"Initial":       initial,
+		"Booking":       booking,
+		"ServiceName":   serviceName,
+		"Tech":          tech,
+		"CancelReasons": portalCancelReasons,
+		"MinDate":       time.Now().Format("2006-01-02"),
+	})
+}
+
+// PortalSlots lists slots the booking can be moved to
+// GET /b/{token}/slots?date=2026-01-28
+func (h *WebHandler) PortalSlots(e *core.RequestEvent) error {
+	booking, err := h.portalBooking(e)
+	if err != nil {
+		return e.JSON(404, map[string]string{"error": "Không tìm thấy đơn hàng"})
+	}
+
+	slots, err := h.SlotService.GetAvailableSlotsForBooking(e.Request.URL.Query().Get("date"), booking)
+	if err != nil {
+		return e.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	// Waitlist slots are not offered for self-service reschedule
+	open := make([]services.TimeSlot, 0, len(slots))
+	for _, s := range slots {
+		if s.Status == "available" || s.Status == "limited" {
+			open = append(open, s)
+		}
+	}
+	return e.JSON(200, open)
+}
+
+// PortalLocation returns the latest position of the assigned tech
+// GET /b/{token}/location
+func (h *WebHandler) PortalLocation(e *core.RequestEvent) error {
+	booking, err := h.portalBooking(e)
+	if err != nil {
+		return e.JSON(404, map[string]string{"error": "Không tìm thấy đơn hàng"})
+	}
+	if h.LocationCache == nil {
+		return e.JSON(404, map[string]string{"error": "Chưa có vị trí"})
+	}
+
+	techs := h.LocationCache.GetTechsByBooking(booking.ID)
+	if len(techs) == 0 {
+		return e.JSON(404, map[string]string{"error": "Chưa có vị trí"})
+	}
+	return e.JSON(200, map[string]interface{}{
+		"latitude":    techs[0].Latitude,
+		"longitude":   techs[0].Longitude,
+		"distance":    techs[0].Distance,
+		"last_update": techs[0].LastUpdate,
+		"customer":    map[string]float64{"latitude": booking.Lat, "longitude": booking.Long},
+	})
+}
+
+// PortalReschedule moves the booking into another available slot
+// POST /b/{token}/reschedule (slot_id)
+func (h *WebHandler) PortalReschedule(e *core.RequestEvent) error {
+	booking, err := h.portalBooking(e)
+	if err != nil {
+		return e.JSON(404, map[string]string{"error": "Không tìm thấy đơn hàng"})
+	}
+	if !domain.CustomerCanModify(booking.JobStatus) {
+		return e.JSON(409, map[string]string{"error": "Thợ đã lên đường, vui lòng gọi hotline để đổi lịch"})
+	}
+
+	slotID := e.Request.FormValue("slot_id")
+	slot, err := h.App.FindRecordById("time_slots", slotID)
+	if err != nil {
+		return e.JSON(400, map[string]string{"error": "Vui lòng chọn khung giờ"})
+	}
+	date := slot.GetString("date")
+
+	// Re-check availability server side (slot may be taken meanwhile)
+	slots, err := h.SlotService.GetAvailableSlotsForBooking(date, booking)
+	if err != nil {
+		return e.JSON(400, map[string]string{"error": err.Error()})
+	}
+	available := false
+	for _, s := range slots {
+		if s.ID == slotID && (s.Status == "available" || s.Status == "limited") {
+			available = true
+			break
+		}
+	}
+	if !available {
+		return e.JSON(409, map[string]string{"error": "Khung giờ này vừa hết chỗ, vui lòng chọn giờ khác"})
+	}
+
+	newTime := fmt.Sprintf("%s %s", date, slot.GetString("start_time"))
+	if err := h.BookingService.RescheduleBooking(booking.ID, newTime, slotID, portalActor(booking)); err != nil {
+		if handled, rErr := renderTransitionError(e, err); handled {
+			return rErr
+		}
+		if errors.Is(err, domain.ErrScheduleConflict) {
+			return e.JSON(409, map[string]string{"error": "Kỹ thuật viên đã có lịch vào giờ này, vui lòng chọn khung giờ khác"})
+		}
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return e.JSON(200, map[string]string{"message": "Đã đổi lịch hẹn", "booking_time": newTime})
+}
+
+// PortalCancel cancels the booking with the customer's reason
+// POST /b/{token}/cancel (reason, note)
+func (h *WebHandler) PortalCancel(e *core.RequestEvent) error {
+	booking, err := h.portalBooking(e)
+	if err != nil {
+		return e.JSON(404, map[string]string{"error": "Không tìm thấy đơn hàng"})
+	}
+	if !domain.CustomerCanModify(booking.JobStatus) {
+		return e.JSON(409, map[string]string{"error": "Thợ đã lên đường, vui lòng gọi hotline để hủy lịch"})
+	}
+
+	reason := e.Request.FormValue("reason")
+	if reason == "" {
+		return e.JSON(400, map[string]string{"error": "Vui lòng chọn lý do hủy"})
+	}
+
+	err = h.BookingService.CancelBooking(booking.ID, "Khách hủy: "+reason, e.Request.FormValue("note"), portalActor(booking))
+	if err != nil {
+		if handled, rErr := renderTransitionError(e, err); handled {
+			return rErr
+		}
+		return e.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return e.JSON(200, map[string]string{"message": "Đã hủy lịch hẹn"})
+}
@@ -12,22 +12,14 @@ import (
 )
 
 // CustomerTrackStream provides SSE endpoint for customer order tracking
-// Customer receives only events for their specific booking
+// Customer receives only events for their specific booking.
+// GET /b/{token}/stream - the access token is the only credential.
 func (h *WebHandler) CustomerTrackStream(e *core.RequestEvent) error {
-	bookingID := e.Request.PathValue("booking_id")
-	if bookingID == "" {
-		return e.String(400, "Missing booking_id")
-	}
-
-	// TODO: Verify tracking token in query params
-	// trackingToken := e.Request.URL.Query().Get("token")
-	// For now, allow public access (can add token verification later)
-
-	// Verify booking exists
-	_, err := h.App.FindRecordById("bookings", bookingID)
+	booking, err := h.portalBooking(e)
 	if err != nil {
 		return e.String(404, "Booking not found")
 	}
+	bookingID := booking.ID
 
 	// Set SSE headers
 	e.Response.Header().Set("Content-Type", "text/event-stream")
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"strings"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BankWebhook receives incoming transfers from the bank feed provider
+// POST /api/payments/webhook/{provider} (casso | sepay | local)
+// Auth: "Secure-Token: <secret>" (Casso) or "Authorization: Apikey <secret>" (SePay)
+func (h *PublicHandler) BankWebhook(e *core.RequestEvent) error {
+	brand, err := h.BrandRepo.GetDefault()
+	if err != nil || brand.BankWebhookSecret == "" {
+		// No secret configured means the webhook is disabled
+		return e.JSON(403, map[string]interface{}{"success": false, "error": "webhook disabled"})
+	}
+	if !validWebhookToken(e, brand.BankWebhookSecret) {
+		return e.JSON(401, map[string]interface{}{"success": false, "error": "invalid token"})
+	}
+
+	body, err := io.ReadAll(io.LimitReader(e.Request.Body, 1<<20))
+	if err != nil {
+		return e.JSON(400, map[string]interface{}{"success": false, "error": "cannot read body"})
+	}
+	txs, err := domain.ParseBankWebhook(e.Request.PathValue("provider"), body)
+	if err != nil {
+		return e.JSON(400, map[string]interface{}{"success": false, "error": err.Error()})
+	}
+
+	result, err := h.ReconcileService.Ingest(txs)
+	if err != nil {
+		// 5xx makes the provider retry; recorded transactions are skipped as duplicates
+		return e.JSON(500, map[string]interface{}{"success": false, "error": err.Error()})
+	}
+	// SePay expects {"success": true}, Casso only checks the status code
+	return e.JSON(200, map[string]interface{}{"success": true, "result": result})
+}
+
+func validWebhookToken(e *core.RequestEvent, secret string) bool {
+	token := e.Request.Header.Get("Secure-Token")
+	if token == "" {
+		auth := e.Request.Header.Get("Authorization")
+		for _, scheme := range []string{"Apikey ", "Bearer "} {
+			if rest, ok := strings.CutPrefix(auth, scheme); ok {
+				token = rest
+				break
+			}
+		}
+	}
+	token = strings.TrimSpace(token)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// reconcileErrorMessage maps reconciliation errors to admin-facing messages
+func reconcileErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrTransactionResolved):
+		return "Giao dịch này đã được đối soát"
+	case errors.Is(err, domain.ErrInvoiceNotPayable):
+		return "Không tìm thấy hóa đơn chưa thanh toán với mã này"
+	case errors.Is(err, domain.ErrStatementColumns):
+		return "Không nhận diện được sao kê: cần các cột ngày giao dịch, số tiền ghi có và nội dung"
+	case errors.Is(err, domain.ErrInvalidBankPayload):
+		return "Dữ liệu giao dịch không hợp lệ"
+	default:
+		return err.Error()
+	}
+}
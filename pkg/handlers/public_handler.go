@@ -6,19 +6,25 @@ import (
 	"html/template"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	domain "hvac-system/internal/core"
 	"hvac-system/pkg/services"
 
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 )
 
 type PublicHandler struct {
-	App            core.App
-	Templates      *template.Template
-	InvoiceService *services.InvoiceService
-	BrandRepo      domain.BrandRepository // [NEW] Link to Brand
+	App              core.App
+	Templates        *template.Template
+	InvoiceService   *services.InvoiceService
+	BrandRepo        domain.BrandRepository // [NEW] Link to Brand
+	QuoteService     domain.QuoteService    // [NEW] Customer quote approval
+	BookingRepo      domain.BookingRepository
+	PDFService       *services.PDFService         // [NEW] /invoice/{hash}.pdf
+	ReconcileService domain.ReconciliationService // [NEW] Bank webhook
 }
 
 // Index renders the homepage with dynamic data
@@ -49,6 +55,8 @@ func (h *PublicHandler) Index(e *core.RequestEvent) error {
 // GET /invoice/{hash}
 func (h *PublicHandler) ShowInvoice(e *core.RequestEvent) error {
 	hash := e.Request.PathValue("hash")
+	// The router cannot match "{hash}.pdf" inside one segment, the suffix is checked here
+	hash, asPDF := strings.CutSuffix(hash, ".pdf")
 	if hash == "" {
 		return e.String(404, "Invoice not found")
 	}
@@ -61,6 +69,19 @@ func (h *PublicHandler) ShowInvoice(e *core.RequestEvent) error {
 	invoice := invoices[0]
 	bookingID := invoice.GetString("booking_id")
 
+	if asPDF {
+		data, err := h.PDFService.InvoicePDF(invoice)
+		if err != nil {
+			fmt.Printf("❌ Invoice PDF %s: %v\n", invoice.Id, err)
+			return e.String(500, "Không thể tạo file PDF")
+		}
+		name := invoice.GetString("invoice_code")
+		if name == "" {
+			name = "hoa-don"
+		}
+		return RenderPDF(e, name+".pdf", data)
+	}
+
 	// 2. Fetch Booking
 	booking, err := h.App.FindRecordById("bookings", bookingID)
 	if err != nil {
@@ -98,11 +119,32 @@ func (h *PublicHandler) ShowInvoice(e *core.RequestEvent) error {
 		"Report":   report,
 		"Settings": brand, // Keep legacy key for layout compatibility
 		"Brand":    brand,
+
+		"TransferMemo": domain.VietQRMemo(domain.InvoiceTransferMemo(invoice.GetString("invoice_code"))),
+		"Open":         domain.InvoiceOpen(invoice.GetString("status")),
+		"Settled":      domain.InvoiceSettled(invoice.GetString("status")),
+		"AmountDue":    max(invoice.GetFloat("total_amount")-invoice.GetFloat("amount_paid"), 0),
 	}
 
 	return RenderPage(h.Templates, e, "invoice_view", "public/invoice_view.html", data)
 }
 
+// InvoiceQR serves the VietQR transfer code of an invoice, generated locally
+// GET /invoice/{hash}/qr.png
+func (h *PublicHandler) InvoiceQR(e *core.RequestEvent) error {
+	invoice, err := h.App.FindFirstRecordByFilter("invoices", "public_hash = {:hash}", dbx.Params{"hash": e.Request.PathValue("hash")})
+	if err != nil {
+		return e.String(404, "Invoice not found")
+	}
+	png, err := h.InvoiceService.PaymentQR(invoice, 512)
+	if err != nil {
+		return e.String(404, "Chưa cấu hình tài khoản ngân hàng")
+	}
+	// The amount and code can still change until the invoice is paid
+	e.Response.Header().Set("Cache-Control", "no-cache")
+	return e.Blob(200, "image/png", png)
+}
+
 // SubmitFeedback handles customer signature and rating
 // POST /api/invoice/{hash}/feedback
 func (h *PublicHandler) SubmitFeedback(e *core.RequestEvent) error {
@@ -112,8 +154,7 @@ func (h *PublicHandler) SubmitFeedback(e *core.RequestEvent) error {
 	if err != nil || len(invoices) == 0 {
 		return e.JSON(404, map[string]string{"error": "Invoice not found"})
 	}
-	invoice := invoices[0]
-	bookingID := invoice.GetString("booking_id")
+	bookingID := invoices[0].GetString("booking_id")
 
 	// Get form data
 	signatureBase64 := e.Request.FormValue("signature") // Data URL
@@ -132,9 +173,7 @@ func (h *PublicHandler) SubmitFeedback(e *core.RequestEvent) error {
 		h.App.Save(booking)
 	}
 
-	// Update Invoice status?
-	invoice.Set("status", "signed") // If we have such status
-	h.App.Save(invoice)
+	// The invoice status is derived from the payments ledger; signing does not change it
 
 	return e.JSON(200, map[string]string{"success": "true"})
 }
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ShowQuote renders the quote the customer reviews and signs on the tech's phone
+// or from the shared link
+// GET /quote/{hash}
+func (h *PublicHandler) ShowQuote(e *core.RequestEvent) error {
+	quote, err := h.QuoteService.GetByHash(e.Request.PathValue("hash"))
+	if err != nil {
+		return e.String(404, "Báo giá không tồn tại hoặc đã hết hạn")
+	}
+	booking, err := h.BookingRepo.GetByID(quote.BookingID)
+	if err != nil {
+		return e.String(404, "Báo giá không tồn tại hoặc đã hết hạn")
+	}
+
+	brand, _ := h.BrandRepo.GetDefault()
+	data := map[string]interface{}{
+		"Quote":    quote,
+		"Booking":  booking,
+		"Hash":     e.Request.PathValue("hash"),
+		"Settings": brand,
+		"Brand":    brand,
+	}
+	return RenderPage(h.Templates, e, "layouts/base.html", "public/quote_view.html", data)
+}
+
+// DecideQuote stores the customer's signed approval or rejection
+// POST /quote/{hash}/decision (decision=approve|reject, reason, signature file)
+func (h *PublicHandler) DecideQuote(e *core.RequestEvent) error {
+	decision := e.Request.FormValue("decision")
+	if decision != "approve" && decision != "reject" {
+		return e.JSON(400, map[string]string{"error": "Lựa chọn không hợp lệ"})
+	}
+
+	files, _ := e.FindUploadedFiles("signature")
+	if len(files) == 0 {
+		return e.JSON(400, map[string]string{"error": "Vui lòng ký xác nhận"})
+	}
+
+	quote, err := h.QuoteService.Decide(e.Request.PathValue("hash"), decision == "approve", e.Request.FormValue("reason"), files[0])
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrQuoteDecided):
+			return e.JSON(409, map[string]string{"error": "Báo giá này đã được xử lý"})
+		case errors.Is(err, domain.ErrSignatureRequired):
+			return e.JSON(400, map[string]string{"error": "Vui lòng ký xác nhận"})
+		}
+		return e.JSON(500, map[string]string{"error": "Không thể lưu xác nhận, vui lòng thử lại"})
+	}
+
+	message := "Đã duyệt báo giá, kỹ thuật viên sẽ tiến hành sửa chữa"
+	if quote.Status == domain.QuoteRejected {
+		message = "Đã từ chối báo giá"
+	}
+	return e.JSON(200, map[string]string{"status": quote.Status, "message": message})
+}
@@ -126,3 +126,15 @@ func RenderPartial(t *template.Template, e *core.RequestEvent, pagePath string,
 
 	return nil
 }
+
+// RenderPDF sends a generated PDF inline (the browser viewer opens it; saving keeps fileName)
+func RenderPDF(e *core.RequestEvent, fileName string, data []byte) error {
+	e.Response.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", fileName))
+	return e.Blob(200, "application/pdf", data)
+}
+
+// RenderDownload sends a generated file as an attachment (CSV / XLSX exports)
+func RenderDownload(e *core.RequestEvent, fileName, contentType string, data []byte) error {
+	e.Response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	return e.Blob(200, contentType, data)
+}
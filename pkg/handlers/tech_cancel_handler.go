@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
 
+	domain "hvac-system/internal/core"
+
 	"github.com/pocketbase/pocketbase/core"
 )
 
@@ -40,7 +43,10 @@ func (h *TechHandler) CancelBooking(e *core.RequestEvent) error {
 			return e.JSON(400, map[string]string{"error": "Vui lòng chọn thời gian mới"})
 		}
 		// Call Service Reschedule
-		err := h.BookingService.RescheduleBooking(bookingID, newTime)
+		err := h.BookingService.RescheduleBooking(bookingID, newTime, "", techActor(e, "tech_app"))
+		if errors.Is(err, domain.ErrScheduleConflict) {
+			return e.JSON(409, map[string]string{"error": "Bạn đã có lịch khác vào giờ này, vui lòng chọn giờ khác"})
+		}
 		if err != nil {
 			return e.JSON(500, map[string]string{"error": err.Error()})
 		}
@@ -88,8 +94,11 @@ func (h *TechHandler) CancelBooking(e *core.RequestEvent) error {
 	}
 
 	// Call Service Cancel
-	err := h.BookingService.CancelBooking(bookingID, reason, note)
+	err := h.BookingService.CancelBooking(bookingID, reason, note, techActor(e, "tech_app"))
 	if err != nil {
+		if ok, rerr := renderTransitionError(e, err); ok {
+			return rerr
+		}
 		return e.JSON(500, map[string]string{"error": err.Error()})
 	}
 
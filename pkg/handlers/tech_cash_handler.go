@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	domain "hvac-system/internal/core"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ShowCash shows the cash the tech is carrying, the ledger and the handover form
+// GET /tech/cash
+func (h *TechHandler) ShowCash(e *core.RequestEvent) error {
+	entries, pos, err := h.CashService.Ledger(e.Auth.Id, 30)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	history, err := h.CashService.History(e.Auth.Id)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	if len(history) > 10 {
+		history = history[:10]
+	}
+
+	data := h.getTechCommonData(e.Auth.Id)
+	data["Position"] = pos
+	data["Entries"] = entries
+	data["Handovers"] = history
+	data["PageType"] = "profile"
+	data["Success"] = e.Request.URL.Query().Get("success") != ""
+	data["Error"] = e.Request.URL.Query().Get("error")
+	return RenderPage(h.Templates, e, "layouts/tech.html", "tech/cash.html", data)
+}
+
+// SubmitCashHandover declares the cash handed in to the office
+// POST /tech/cash/handover (Form: amount, note)
+func (h *TechHandler) SubmitCashHandover(e *core.RequestEvent) error {
+	amount := domain.ParseVNDAmount(e.Request.FormValue("amount"))
+	if _, err := h.CashService.SubmitHandover(amount, e.Request.FormValue("note"), techActor(e, "tech_app")); err != nil {
+		return e.Redirect(http.StatusSeeOther, "/tech/cash?error="+url.QueryEscape(cashErrorMessage(err)))
+	}
+	return e.Redirect(http.StatusSeeOther, "/tech/cash?success=1")
+}
+
+func cashErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidHandover):
+		return "Số tiền nộp phải lớn hơn 0"
+	case errors.Is(err, domain.ErrHandoverPending):
+		return "Lần nộp trước đang chờ xác nhận"
+	case errors.Is(err, domain.ErrHandoverNotPending):
+		return "Lần nộp này đã được xử lý"
+	case strings.Contains(err.Error(), "not found"):
+		return "Không tìm thấy lần nộp tiền"
+	default:
+		return "Không thể xử lý, vui lòng thử lại"
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// equipmentFromForm reads the shared equipment form fields
+func equipmentFromForm(e *core.RequestEvent) *domain.Equipment {
+	btu, _ := strconv.Atoi(e.Request.FormValue("capacity_btu"))
+	hp, _ := strconv.ParseFloat(e.Request.FormValue("capacity_hp"), 64)
+	return &domain.Equipment{
+		UnitType:    e.Request.FormValue("unit_type"),
+		Brand:       e.Request.FormValue("brand"),
+		Model:       e.Request.FormValue("model"),
+		Serial:      e.Request.FormValue("serial"),
+		CapacityBTU: btu,
+		CapacityHP:  hp,
+		Refrigerant: e.Request.FormValue("refrigerant"),
+		InstallDate: e.Request.FormValue("install_date"),
+		Location:    e.Request.FormValue("location"),
+		Notes:       e.Request.FormValue("notes"),
+	}
+}
+
+// equipmentErrorMessage maps registry errors to a message for the form
+func equipmentErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrNoCustomer):
+		return "Đơn này chưa gắn với khách hàng (SĐT không hợp lệ), vui lòng báo Admin"
+	case errors.Is(err, domain.ErrInvalidEquipment):
+		return "Vui lòng nhập loại máy, hãng hoặc model"
+	}
+	return "Không thể lưu thiết bị: " + err.Error()
+}
+
+// POST /tech/job/{id}/equipment
+// Registers a unit found on site for the customer of the job
+func (h *TechHandler) AddJobEquipment(e *core.RequestEvent) error {
+	jobID := e.Request.PathValue("id")
+
+	job, err := h.BookingRepo.GetByID(jobID)
+	if err != nil || job.TechnicianID != e.Auth.Id {
+		return e.String(404, "Job không tồn tại")
+	}
+
+	eq := equipmentFromForm(e)
+	if err := h.EquipmentService.RegisterForBooking(jobID, eq); err != nil {
+		return e.Redirect(http.StatusSeeOther, fmt.Sprintf("/tech/job/%s?error=%s", jobID, url.QueryEscape(equipmentErrorMessage(err))))
+	}
+	return e.Redirect(http.StatusSeeOther, fmt.Sprintf("/tech/job/%s#equipment", jobID))
+}
+
+// GET /tech/equipment/{id}
+// Full service history of a unit (what was done last time, which parts...).
+// Only for units of a customer with a job assigned to the tech.
+func (h *TechHandler) EquipmentHistory(e *core.RequestEvent) error {
+	eq, err := h.EquipmentService.Get(e.Request.PathValue("id"))
+	if err != nil || eq.CustomerID == "" {
+		return e.String(404, "Thiết bị không tồn tại")
+	}
+	if _, err := h.App.FindFirstRecordByFilter("bookings", "technician_id = {:tech} && customer_id = {:customer}",
+		dbx.Params{"tech": e.Auth.Id, "customer": eq.CustomerID}); err != nil {
+		return e.String(404, "Thiết bị không tồn tại")
+	}
+	history, err := h.EquipmentService.History(eq.ID)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	back := e.Request.URL.Query().Get("back")
+	if !strings.HasPrefix(back, "/tech/") {
+		back = "/tech/jobs"
+	}
+
+	return RenderPage(h.Templates, e, "layouts/tech.html", "tech/equipment_history.html", map[string]interface{}{
+		"Equipment": eq,
+		"History":   history,
+		"BackURL":   back,
+		"IsTech":    true,
+		"PageType":  "job_detail",
+	})
+}
+
+// partEquipment is the customer unit the serials of a part went into: a unit
+// of the job, "new" for the unit registered with the report, or the only unit
+// serviced when none was picked
+func (h *TechHandler) partEquipment(jobID, picked, newUnitID string, serviced []string) string {
+	switch picked {
+	case "new":
+		return newUnitID
+	case "":
+		if len(serviced) == 1 {
+			return serviced[0]
+		}
+		return ""
+	}
+	if h.EquipmentService == nil {
+		return ""
+	}
+	units, _ := h.EquipmentService.ForBooking(jobID)
+	for _, u := range units {
+		if u.ID == picked {
+			return picked
+		}
+	}
+	return ""
+}
@@ -6,6 +6,7 @@ import (
 	"html/template"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"hvac-system/internal/adapter/repository" // [NEW]
@@ -14,21 +15,33 @@ import (
 	"hvac-system/pkg/notification"
 	"hvac-system/pkg/services"
 
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
 )
 
 type TechHandler struct {
-	App            *pocketbase.PocketBase
-	Templates      *template.Template
-	Broker         *broker.SegmentedBroker
-	Inventory      *services.InventoryService
-	InvoiceService *services.InvoiceService
-	BookingService domain.BookingService
-	SettingsRepo   *repository.SettingsRepo    // [NEW]
-	FCMService     *notification.FCMService    // [NEW]
-	TechRepo       domain.TechnicianRepository // [PHASE4] For migration
-	BookingRepo    domain.BookingRepository    // [PHASE4] For migration
+	App              *pocketbase.PocketBase
+	Templates        *template.Template
+	Broker           *broker.SegmentedBroker
+	Inventory        *services.InventoryService
+	InvoiceService   *services.InvoiceService
+	BookingService   domain.BookingService
+	SettingsRepo     *repository.SettingsRepo    // [NEW]
+	FCMService       *notification.FCMService    // [NEW]
+	TechRepo         domain.TechnicianRepository // [PHASE4] For migration
+	BookingRepo      domain.BookingRepository    // [PHASE4] For migration
+	ScheduleService  domain.TechScheduleService  // [NEW] Leave requests
+	EquipmentService domain.EquipmentService     // [NEW] Customer units on the job
+	QuoteService     domain.QuoteService         // [NEW] On-site quotation
+	PartRepo         domain.PartRepository       // [NEW] Catalog for quotes
+	PDFService       *services.PDFService        // [NEW] Invoice PDF for the customer email
+	MailService      *notification.MailService   // [NEW] Customer emails
+	CustomerRepo     domain.CustomerRepository   // [NEW] Customer email lookup
+	PaymentService   domain.PaymentService       // [NEW] Cash payments ledger
+	CashService      domain.CashService          // [NEW] Cash on hand & handovers
+	PayrollService   domain.PayrollService       // [NEW] Own payroll statements
+	Stocktakes       *services.StocktakeService  // [NEW] Truck stock counts
 }
 
 // --- Auth ---
@@ -100,7 +113,7 @@ func (h *TechHandler) getTechCommonData(techID string) map[string]interface{} {
 	// Ở đây dùng cách đơn giản là loop qua danh sách invoice của thợ
 	invoices, _ := h.App.FindRecordsByFilter(
 		"invoices",
-		fmt.Sprintf("booking_id.technician_id='%s' && status='paid'", techID),
+		fmt.Sprintf("booking_id.technician_id='%s' && (status='paid' || status='overpaid')", techID),
 		"", 0, 0, nil,
 	)
 
@@ -159,7 +172,19 @@ func (h *TechHandler) getTechCommonData(techID string) map[string]interface{} {
 	)
 	completedTotal := len(completedTotalRecords)
 
+	// D. Tiền mặt đang giữ, cần nộp về văn phòng
+	cashToHandIn := 0.0
+	cashHandoverPending := false
+	if h.CashService != nil {
+		if pos, err := h.CashService.Position(techID); err == nil {
+			cashToHandIn = pos.Outstanding
+			cashHandoverPending = pos.Pending != nil
+		}
+	}
+
 	return map[string]interface{}{
+		"CashToHandIn":        cashToHandIn,
+		"CashHandoverPending": cashHandoverPending,
 		"ActiveCount":         activeCount,
 		"CompletedTodayCount": completedTodayCount,
 		"TodayEarnings":       todayEarnings,
@@ -346,11 +371,20 @@ func (h *TechHandler) JobDetail(e *core.RequestEvent) error {
 	// [NEW] Fetch Settings (if not already in common data, but JobDetail doesn't use getTechCommonData yet)
 	// settings handled by middleware
 
+	// [NEW] Installed units of this customer
+	var equipment []*domain.Equipment
+	if h.EquipmentService != nil {
+		equipment, _ = h.EquipmentService.ForBooking(jobID)
+	}
+
 	data := map[string]interface{}{
 		"Job":             job,
 		"Report":          report,  // Dữ liệu báo cáo (Ảnh sau)
 		"Invoice":         invoice, // Dữ liệu hóa đơn (Tiền)
 		"ProgressPercent": progress,
+		"Equipment":       equipment,
+		"Refrigerants":    domain.Refrigerants,
+		"EquipmentError":  e.Request.URL.Query().Get("error"),
 		"IsTech":          true,
 		"PageType":        "job_detail", // Used to hide main nav
 	}
@@ -393,6 +427,10 @@ func (h *TechHandler) ShowCompleteJob(e *core.RequestEvent) error {
 	// [TRUCK STOCK] Get technician's truck inventory instead of main inventory
 	techInventory, _ := h.Inventory.GetTechInventory(techID)
 
+	// [NEW] Serials / lots on the truck, picked for tracked parts
+	truckLots, _ := h.Inventory.StockLots(techID)
+	truckLotsJSON, _ := json.Marshal(truckLots)
+
 	// Get base service price
 	serviceID := job.ServiceID
 	service, _ := h.App.FindRecordById("services", serviceID)
@@ -403,10 +441,34 @@ func (h *TechHandler) ShowCompleteJob(e *core.RequestEvent) error {
 
 	// settings handled by middleware
 
+	// [NEW] Units the tech can tick as serviced
+	var equipment []*domain.Equipment
+	if h.EquipmentService != nil {
+		equipment, _ = h.EquipmentService.ForBooking(jobID)
+	}
+
+	// [NEW] The invoice follows the signed quote when there is one
+	var approvedQuote *domain.Quote
+	if h.QuoteService != nil {
+		approvedQuote, _ = h.QuoteService.Approved(jobID)
+	}
+
+	// [NEW] Extra hours are billed at the brand's hourly rate
+	laborHourRate := 0.0
+	if h.SettingsRepo != nil {
+		if settings, err := h.SettingsRepo.GetSettingsRecord(); err == nil {
+			laborHourRate = settings.GetFloat("labor_hour_rate")
+		}
+	}
+
 	data := map[string]interface{}{
 		"Booking":       job,
+		"ApprovedQuote": approvedQuote,
 		"TechInventory": techInventory, // [TRUCK STOCK] Tech's own inventory
+		"TruckLotsJSON": template.JS(truckLotsJSON),
 		"LaborPrice":    laborPrice,
+		"LaborHourRate": laborHourRate,
+		"Equipment":     equipment,
 		"IsTech":        true,
 		"TechID":        techID,
 		"PageType":      "job_detail", // Hide main nav
@@ -419,16 +481,29 @@ func (h *TechHandler) ShowCompleteJob(e *core.RequestEvent) error {
 // SubmitCompleteJob processes the completion form with parts and invoice recalculation
 func (h *TechHandler) SubmitCompleteJob(e *core.RequestEvent) error {
 	jobID := e.Request.PathValue("id")
-	var err error
-	if h.BookingRepo != nil {
-		_, err = h.BookingRepo.GetByID(jobID)
-	} else {
-		_, err = h.App.FindRecordById("bookings", jobID)
-	}
-
+	booking, err := h.App.FindRecordById("bookings", jobID)
 	if err != nil {
 		return e.String(404, "Job không tồn tại")
 	}
+	if booking.GetString("technician_id") != e.Auth.Id {
+		return e.String(403, "Bạn không có quyền truy cập công việc này.")
+	}
+
+	// A resubmit adds another report and deducts the parts again: only while
+	// the job is still open and the tech has not signed its invoice. A deposit
+	// may number the invoice earlier, the signature is set by this form alone.
+	if status := booking.GetString("job_status"); status == domain.StatusCompleted || status == domain.StatusCancelled {
+		return e.String(409, "Công việc đã kết thúc, không thể nghiệm thu lại")
+	}
+	if invoice, _ := h.App.FindFirstRecordByFilter("invoices", "booking_id = {:booking}",
+		dbx.Params{"booking": jobID}); invoice != nil {
+		if !domain.InvoiceEditable(invoice.GetString("status"), invoice.GetString("einvoice_status")) {
+			return e.String(409, "Hóa đơn đã thanh toán hoặc đã xuất hóa đơn điện tử, không thể nghiệm thu lại")
+		}
+		if invoice.GetString("tech_signature") != "" {
+			return e.String(409, "Công việc đã được nghiệm thu và ký hóa đơn, vui lòng tiếp tục bước thanh toán")
+		}
+	}
 
 	// 1. Save Photo Evidence
 	files, _ := e.FindUploadedFiles("after_images")
@@ -436,6 +511,15 @@ func (h *TechHandler) SubmitCompleteJob(e *core.RequestEvent) error {
 		return e.String(400, "Bắt buộc phải có ảnh nghiệm thu")
 	}
 
+	// [NEW] A unit first seen today, registered with the report below
+	var newUnit *domain.Equipment
+	if raw := e.Request.FormValue("new_unit_json"); raw != "" && h.EquipmentService != nil {
+		newUnit = &domain.Equipment{}
+		if err := json.Unmarshal([]byte(raw), newUnit); err != nil {
+			return e.String(400, "Lỗi phân tích dữ liệu thiết bị mới: "+err.Error())
+		}
+	}
+
 	// Create Job Report
 	jobReports, _ := h.App.FindCollectionByNameOrId("job_reports")
 	report := core.NewRecord(jobReports)
@@ -443,6 +527,12 @@ func (h *TechHandler) SubmitCompleteJob(e *core.RequestEvent) error {
 	report.Set("tech_id", e.Auth.Id)
 	report.Set("photo_notes", e.Request.FormValue("notes"))
 
+	// [NEW] Extra labor hours, billed on the invoice at the brand's hourly rate
+	if hours, err := strconv.ParseFloat(e.Request.FormValue("extra_hours"), 64); err == nil && hours > 0 {
+		report.Set("extra_hours", hours)
+		report.Set("extra_hours_note", strings.TrimSpace(e.Request.FormValue("extra_hours_note")))
+	}
+
 	// Handle file upload
 	fileSlice := make([]any, len(files))
 	for i, f := range files {
@@ -454,6 +544,24 @@ func (h *TechHandler) SubmitCompleteJob(e *core.RequestEvent) error {
 		return e.String(500, "Lỗi lưu báo cáo: "+err.Error())
 	}
 
+	// [NEW] Link the report to the serviced units (optionally a unit first seen today)
+	var equipmentIDs []string
+	newUnitID := ""
+	if h.EquipmentService != nil {
+		equipmentIDs = e.Request.Form["equipment_ids"] // Form already parsed above
+		if newUnit != nil {
+			if err := h.EquipmentService.RegisterForBooking(jobID, newUnit); err == nil {
+				equipmentIDs = append(equipmentIDs, newUnit.ID)
+				newUnitID = newUnit.ID
+			} else {
+				fmt.Printf("⚠️ Equipment not registered for job %s: %v\n", jobID, err)
+			}
+		}
+		if err := h.EquipmentService.RecordService(jobID, report.Id, equipmentIDs); err != nil {
+			fmt.Printf("⚠️ Failed to link equipment to report %s: %v\n", report.Id, err)
+		}
+	}
+
 	// 2. Parse and Process Parts Usage
 	partsJSON := e.Request.FormValue("parts_json")
 	var jobParts []services.JobPart
@@ -465,12 +573,22 @@ func (h *TechHandler) SubmitCompleteJob(e *core.RequestEvent) error {
 			return e.String(400, "Lỗi phân tích dữ liệu vật tư: "+err.Error())
 		}
 
+		// [NEW] Serials go into the unit picked on the part ("new" = the unit
+		// registered above) or, when one unit was serviced, into that one
+		for i := range jobParts {
+			if len(jobParts[i].Lots) > 0 {
+				jobParts[i].EquipmentID = h.partEquipment(jobID, jobParts[i].EquipmentID, newUnitID, equipmentIDs)
+			} else {
+				jobParts[i].EquipmentID = ""
+			}
+		}
+
 		// [TRUCK STOCK] Record parts usage and deduct from TECH's inventory
 		techID := e.Auth.Id
 		totalPartsCost, err := h.Inventory.RecordPartsUsageFromTech(report.Id, techID, jobID, jobParts)
 		if err != nil {
 			fmt.Printf("Error recording parts usage: %v\n", err)
-			return e.String(400, "Lỗi xử lý vật tư: "+err.Error())
+			return e.String(400, "Lỗi xử lý vật tư: "+stockErrorMessage(err))
 		}
 		fmt.Printf("Parts recorded: %d items, total cost: %.2f đ\n", len(jobParts), totalPartsCost)
 	}
@@ -484,7 +602,7 @@ func (h *TechHandler) SubmitCompleteJob(e *core.RequestEvent) error {
 
 	// Ensure public hash for sharing
 	if invoice.GetString("public_hash") == "" {
-		invoice.Set("public_hash", fmt.Sprintf("%x", time.Now().UnixNano()))
+		invoice.Set("public_hash", services.NewInvoiceHash())
 	}
 
 	// [NEW] Save tech signature to invoice
@@ -495,8 +613,8 @@ func (h *TechHandler) SubmitCompleteJob(e *core.RequestEvent) error {
 		fmt.Printf("✅ Saved tech signature for invoice %s\n", invoice.Id)
 	}
 
-	// Save invoice with tech signature
-	if err := h.App.Save(invoice); err != nil {
+	// Save invoice with tech signature; signing issues it with its number
+	if err := h.InvoiceService.Issue(invoice); err != nil {
 		return e.String(500, "Lỗi lưu hóa đơn: "+err.Error())
 	}
 
@@ -590,9 +708,16 @@ func (h *TechHandler) ShowInvoicePayment(e *core.RequestEvent) error {
 	items, _ := h.App.FindRecordsByFilter("invoice_items", fmt.Sprintf("invoice_id='%s'", invoice.Id), "", 100, 0, nil)
 	fmt.Printf("📋 Invoice %s has %d items\n", invoice.Id, len(items))
 
+	// [NEW] Deposits already received reduce what the tech collects
+	due := invoice.GetFloat("total_amount") - invoice.GetFloat("amount_paid")
+	if due < 0 {
+		due = 0
+	}
+
 	data := map[string]interface{}{
 		"Job":      job,
 		"Invoice":  invoice,
+		"Due":      due,
 		"Items":    items,  // Pass items to view
 		"Report":   report, // [FIX] Pass report to view
 		"IsTech":   true,
@@ -701,20 +826,13 @@ func (h *TechHandler) ShowProfile(e *core.RequestEvent) error {
 	return RenderPage(h.Templates, e, "layouts/tech.html", "tech/profile.html", data)
 }
 
-func (h *TechHandler) ShowQuote(e *core.RequestEvent) error {
-	return e.String(200, "Quote form")
-}
-
-func (h *TechHandler) SubmitQuote(e *core.RequestEvent) error {
-	return e.String(200, "Quote submitted")
-}
-
+// ShowReport: the job report is part of the completion form
 func (h *TechHandler) ShowReport(e *core.RequestEvent) error {
-	return e.String(200, "Report form")
+	return e.Redirect(http.StatusSeeOther, "/tech/job/"+e.Request.PathValue("id")+"/complete")
 }
 
 func (h *TechHandler) SubmitReport(e *core.RequestEvent) error {
-	return e.String(200, "Report submitted")
+	return h.SubmitCompleteJob(e)
 }
 
 // TechStream provides SSE endpoint for tech real-time notifications
@@ -902,8 +1020,11 @@ func (h *TechHandler) HandleTechCheckIn(e *core.RequestEvent) error {
 	}
 
 	// Call Service
-	err = h.BookingService.TechCheckIn(bookingID, lat, long)
+	err = h.BookingService.TechCheckIn(bookingID, lat, long, techActor(e, "check_in"))
 	if err != nil {
+		if ok, rerr := renderTransitionError(e, err); ok {
+			return rerr
+		}
 		// Return friendly error message
 		return e.JSON(400, map[string]string{"error": err.Error()})
 	}
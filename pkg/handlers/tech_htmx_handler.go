@@ -2,15 +2,19 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	domain "hvac-system/internal/core"
 	"hvac-system/pkg/broker"
 	"hvac-system/pkg/notification"
+	"hvac-system/pkg/services"
 
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 )
 
@@ -43,120 +47,36 @@ func (h *TechHandler) UpdateJobStatusHTMX(e *core.RequestEvent) error {
 		}
 	}
 
-	// Validate status transition (Updated to match UI flow)
-	// Validate status transition (Updated to match UI flow)
-	currentStatus := job.JobStatus
-
 	// [FIX] Idempotency check: If already in target status, return success
-	if currentStatus == newStatus {
+	if job.JobStatus == newStatus {
 		return e.JSON(200, map[string]string{
 			"status":  newStatus,
 			"message": "Trạng thái đã được cập nhật",
 		})
 	}
 
-	validTransition := map[string][]string{
-		"pending":  {"moving", "cancelled"},
-		"assigned": {"accepted", "moving", "cancelled"},
-		"accepted": {"moving", "cancelled"},
-		"moving":   {"arrived", "working", "cancelled"},
-		"arrived":  {"working", "cancelled"},
-		"working":  {"completed", "cancelled"},
-	}
-
-	allowed := false
-	if next, ok := validTransition[currentStatus]; ok {
-		for _, s := range next {
-			if s == newStatus {
-				allowed = true
-				break
-			}
+	// Lifecycle validates the transition, stamps timestamps and publishes SSE/FCM
+	if err := h.BookingService.UpdateStatus(jobID, newStatus, techActor(e, "tech_app")); err != nil {
+		if ok, rerr := renderTransitionError(e, err); ok {
+			return rerr
 		}
+		return e.String(500, "Failed to update status: "+err.Error())
 	}
 
-	if !allowed {
-		if currentStatus == "cancelled" {
-			return e.JSON(409, map[string]interface{}{
-				"error":          "Đơn hàng này đã bị hủy hoặc thay đổi trạng thái bởi Admin.",
-				"current_status": currentStatus,
-			})
-		}
-		return e.JSON(409, map[string]interface{}{
-			"error":          fmt.Sprintf("Trạng thái không hợp lệ (Hiện tại: %s -> %s)", currentStatus, newStatus),
-			"current_status": currentStatus,
-		})
-	}
-
-	// Update job status
-	if h.BookingRepo != nil {
-		if err := h.BookingRepo.UpdateStatus(jobID, newStatus); err != nil {
-			return e.String(500, "Failed to update status: "+err.Error())
-		}
-	} else {
-		// Fallback direct update
-		rec, _ := h.App.FindRecordById("bookings", jobID) // Re-fetch or reuse if we had 'rec'
-		rec.Set("job_status", newStatus)
-		rec.Set(fmt.Sprintf("%s_start_at", newStatus), time.Now())
-		if err := h.App.Save(rec); err != nil {
-			return e.String(500, "Failed to update status: "+err.Error())
-		}
-	}
-
-	// Publish events
-	h.Broker.Publish(broker.ChannelCustomer, jobID, broker.Event{
-		Type:      "job.status_changed",
-		Timestamp: time.Now().Unix(),
-		Data: map[string]interface{}{
-			"status":  newStatus,
-			"tech_id": e.Auth.Id,
-		},
-	})
-
-	h.Broker.Publish(broker.ChannelAdmin, "", broker.Event{
-		Type:      "job.status_changed",
-		Timestamp: time.Now().Unix(),
-		Data: map[string]interface{}{
-			"booking_id": jobID,
-			"status":     newStatus,
-			"tech_id":    e.Auth.Id,
-		},
-	})
-
-	// [NEW] Send Push Notification
-	if h.FCMService != nil {
+	// Notify Admin on completion (cancel alerts are sent by BookingService.CancelBooking)
+	if h.FCMService != nil && newStatus == domain.StatusCompleted {
 		go func() {
-			// 1. Notify Technician (Self update confirmation, optional but good for multi-device)
-			tech, err := h.App.FindRecordById("technicians", e.Auth.Id)
-			if err == nil {
-				token := tech.GetString("fcm_token")
-				if token != "" {
-					h.FCMService.NotifyJobStatusChange(context.Background(), token, jobID, newStatus)
-				}
-			}
-
-			// 2. Notify Admin (Only for key events: Completed or Cancelled)
-			if newStatus == "completed" || newStatus == "cancelled" {
-				var title, body string
-				if newStatus == "completed" {
-					title = "✅ Đơn hàng hoàn thành"
-					body = fmt.Sprintf("KTV %s đã hoàn thành đơn %s", e.Auth.GetString("name"), job.CustomerName)
-				} else {
-					title = "⚠️ Đơn hàng bị hủy"
-					body = fmt.Sprintf("KTV %s đã hủy đơn %s", e.Auth.GetString("name"), job.CustomerName)
-				}
-
-				payload := &notification.NotificationPayload{
-					Title: title,
-					Body:  body,
-					Data: map[string]string{
-						"type":       "job_update",
-						"booking_id": jobID,
-						"status":     newStatus,
-					},
-				}
-				// Send to 'admin_alerts' topic
-				h.FCMService.SendToTopic(context.Background(), "admin_alerts", payload)
+			payload := &notification.NotificationPayload{
+				Title: "✅ Đơn hàng hoàn thành",
+				Body:  fmt.Sprintf("KTV %s đã hoàn thành đơn %s", e.Auth.GetString("name"), job.CustomerName),
+				Data: map[string]string{
+					"type":       "job_update",
+					"booking_id": jobID,
+					"status":     newStatus,
+				},
 			}
+			// Send to 'admin_alerts' topic
+			h.FCMService.SendToTopic(context.Background(), "admin_alerts", payload)
 		}()
 	}
 
@@ -227,7 +147,21 @@ func (h *TechHandler) GetJobInvoice(e *core.RequestEvent) error {
 func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 	jobID := e.Request.PathValue("id")
 	paymentMethod := e.Request.FormValue("payment_method")
-	transactionCode := e.Request.FormValue("transaction_code")
+
+	// Only the assigned tech collects the money and closes the job
+	booking, err := h.App.FindRecordById("bookings", jobID)
+	if err != nil {
+		return e.JSON(404, map[string]interface{}{
+			"success": false,
+			"error":   "Không tìm thấy đơn hàng",
+		})
+	}
+	if booking.GetString("technician_id") != e.Auth.Id {
+		return e.JSON(403, map[string]interface{}{
+			"success": false,
+			"error":   "Bạn không có quyền truy cập công việc này.",
+		})
+	}
 
 	// 1. Find invoice
 	invoices, _ := h.App.FindRecordsByFilter(
@@ -248,20 +182,12 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 
 	invoice := invoices[0]
 
-	// 2. Check invoice status FIRST - prevent duplicate payment
-	if invoice.GetString("status") == "paid" {
-		fmt.Printf("⚠️  PAYMENT: Invoice %s already paid, preventing duplicate\n", invoice.Id)
-		return e.JSON(200, map[string]interface{}{
-			"success":      true,
-			"invoice_hash": invoice.GetString("public_hash"),
-			"message":      "Hóa đơn này đã được thanh toán rồi",
-		})
-	}
+	// 2. A transfer may already be reconciled from the bank: the job still
+	// needs the customer signature, but the payment must not be recorded twice
+	alreadyPaid := domain.InvoiceSettled(invoice.GetString("status"))
 
-	// 3. Check job status
 	// 3. Check job status
 	var job *domain.Booking
-	var err error
 	if h.BookingRepo != nil {
 		job, err = h.BookingRepo.GetByID(jobID)
 	} else {
@@ -282,12 +208,25 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 		})
 	}
 
-	if job.JobStatus == "completed" {
+	if job.JobStatus == domain.StatusCompleted {
 		fmt.Printf("⚠️  PAYMENT: Job %s already completed\n", jobID)
+		message := "Đơn hàng đã hoàn thành"
+		if alreadyPaid {
+			message = "Hóa đơn này đã được thanh toán rồi"
+		}
 		return e.JSON(200, map[string]interface{}{
 			"success":      true,
 			"invoice_hash": invoice.GetString("public_hash"),
-			"message":      "Đơn hàng đã hoàn thành",
+			"message":      message,
+		})
+	}
+
+	// Payment completes the job, so the lifecycle must allow it before money is recorded
+	if !domain.BookingLifecycle.Can(job.JobStatus, domain.StatusCompleted) {
+		return e.JSON(409, map[string]interface{}{
+			"success":        false,
+			"error":          (&domain.TransitionError{BookingID: jobID, From: job.JobStatus, To: domain.StatusCompleted}).Message(),
+			"current_status": job.JobStatus,
 		})
 	}
 
@@ -301,15 +240,22 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 	}
 
 	// 5. Validate payment method requirements
-	if paymentMethod == "transfer" {
-		if transactionCode == "" || len(transactionCode) < 3 {
-			return e.JSON(400, map[string]interface{}{
-				"success": false,
-				"error":   "Vui lòng nhập mã giao dịch hợp lệ (tối thiểu 3 ký tự)",
-			})
-		}
+	cashAmount := 0.0
+	if alreadyPaid {
+		paymentMethod = invoice.GetString("payment_method")
+	} else if paymentMethod == "transfer" {
+		// Transfers are confirmed by the bank reconciliation only, never by a typed reference
+		return e.JSON(409, map[string]interface{}{
+			"success": false,
+			"error":   "Chưa nhận được chuyển khoản cho hóa đơn này. Vui lòng chờ ngân hàng xác nhận.",
+		})
 	} else if paymentMethod == "cash" {
-		// Cash confirmed on frontend, no extra validation needed
+		// Cash confirmed on frontend. The tech may collect less than the
+		// balance (deposit on a large installation); default is the full balance
+		cashAmount, _ = strconv.ParseFloat(e.Request.FormValue("amount"), 64)
+		if cashAmount <= 0 {
+			cashAmount = invoice.GetFloat("total_amount") - invoice.GetFloat("amount_paid")
+		}
 	} else {
 		return e.JSON(400, map[string]interface{}{
 			"success": false,
@@ -317,29 +263,13 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 		})
 	}
 
-	// 5. Update invoice
-	invoice.Set("payment_method", paymentMethod)
-	invoice.Set("status", "paid")
-
 	// Ensure Public Hash exists for external viewing
 	publicHash := invoice.GetString("public_hash")
 	if publicHash == "" {
-		publicHash = fmt.Sprintf("%x", time.Now().UnixNano())
+		publicHash = services.NewInvoiceHash()
 		invoice.Set("public_hash", publicHash)
 	}
 
-	// Save Transaction Code if provided (e.g., for Bank Transfer)
-	if transactionCode != "" {
-		// Append to existing notes or set new field if available
-		// Using 'payment_note' assuming it fits standard schema, fallback to description if needed
-		currentNote := invoice.GetString("payment_note")
-		if currentNote != "" {
-			invoice.Set("payment_note", fmt.Sprintf("%s | Ref: %s", currentNote, transactionCode))
-		} else {
-			invoice.Set("payment_note", fmt.Sprintf("Ref: %s", transactionCode))
-		}
-	}
-
 	// 6. Handle Customer Signature Upload
 	// This is the customer's signature confirming payment (tech signature was saved during completion)
 	files, _ := e.FindUploadedFiles("signature_file")
@@ -348,7 +278,8 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 		invoice.Set("customer_signed_at", time.Now())
 	}
 
-	if err := h.App.Save(invoice); err != nil {
+	// Issued on signing; numbered here if it was never signed (older invoices)
+	if err := h.InvoiceService.Issue(invoice); err != nil {
 		fmt.Printf("❌ Payment Save Error: %v\n", err)
 		return e.JSON(500, map[string]interface{}{
 			"success": false,
@@ -356,17 +287,49 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 		})
 	}
 
+	// Post the cash to the payments ledger; the invoice status follows the balance.
+	// The key allows one completion payment per invoice, so a double submit
+	// that got past the job status check above is not recorded twice.
+	invoiceStatus := invoice.GetString("status")
+	if cashAmount > 0 {
+		balance, err := h.PaymentService.Record(&domain.Payment{
+			InvoiceID:       invoice.Id,
+			Amount:          cashAmount,
+			Method:          domain.MethodCash,
+			IdempotencyKey:  "completion:" + invoice.Id,
+			CollectedBy:     e.Auth.Id,
+			CollectedByName: e.Auth.GetString("name"),
+		}, techActor(e, "payment"))
+		if errors.Is(err, domain.ErrDuplicatePayment) {
+			fmt.Printf("⚠️  PAYMENT: Cash for invoice %s already recorded\n", invoice.Id)
+			return e.JSON(200, map[string]interface{}{
+				"success":      true,
+				"invoice_hash": publicHash,
+				"message":      "Hóa đơn này đã được thanh toán rồi",
+			})
+		}
+		if err != nil {
+			fmt.Printf("❌ Payment Ledger Error: %v\n", err)
+			return e.JSON(500, map[string]interface{}{
+				"success": false,
+				"error":   "Lỗi ghi nhận thanh toán: " + err.Error(),
+			})
+		}
+		invoiceStatus = balance.Status
+	}
+
 	// 7. Update job status
-	// 7. Update job status
-	// Fallback direct update for payment_status (not in domain model yet)
+	// Direct update for payment_status (not in domain model yet)
 	if rec, err := h.App.FindRecordById("bookings", jobID); err == nil {
-		rec.Set("payment_status", "paid")
-		rec.Set("job_status", "completed")
-		rec.Set("completed_at", time.Now())
+		rec.Set("payment_status", invoiceStatus)
 		if err := h.App.Save(rec); err != nil {
 			fmt.Printf("❌ Job Update Error: %v\n", err)
 		}
 	}
+	// job_status + completed_at go through the lifecycle
+	if err := h.BookingService.UpdateStatus(jobID, domain.StatusCompleted, techActor(e, "payment")); err != nil {
+		fmt.Printf("❌ Job Update Error: %v\n", err)
+	}
 
 	// 8. Publish payment event for Customer
 	h.Broker.Publish(broker.ChannelCustomer, jobID, broker.Event{
@@ -375,7 +338,7 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 		Data: map[string]interface{}{
 			"amount": invoice.GetFloat("total_amount"),
 			"method": paymentMethod,
-			"status": "paid",
+			"status": invoiceStatus,
 		},
 	})
 
@@ -384,13 +347,12 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 		Type:      "job.completed",
 		Timestamp: time.Now().Unix(),
 		Data: map[string]interface{}{
-			"booking_id":      jobID,
-			"tech_id":         e.Auth.Id,
-			"tech_name":       e.Auth.Get("name"),
-			"invoice_amount":  invoice.GetFloat("total_amount"),
-			"payment_method":  paymentMethod,
-			"transaction_ref": transactionCode,
-			"status":          "completed",
+			"booking_id":     jobID,
+			"tech_id":        e.Auth.Id,
+			"tech_name":      e.Auth.Get("name"),
+			"invoice_amount": invoice.GetFloat("total_amount"),
+			"payment_method": paymentMethod,
+			"status":         "completed",
 		},
 	})
 
@@ -409,6 +371,9 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 		}()
 	}
 
+	// [NEW] Email the invoice PDF to the customer
+	go h.emailInvoice(job.CustomerID, invoice)
+
 	fmt.Printf("✅ PAYMENT: Successfully processed for job %s, invoice %s\n", jobID, invoice.Id)
 
 	// Check if this is an HTMX or API request
@@ -421,6 +386,26 @@ func (h *TechHandler) ProcessPayment(e *core.RequestEvent) error {
 	})
 }
 
+// GET /api/tech/job/{id}/payment-status - Polled by the payment page while
+// the customer transfers; the bank reconciliation marks the invoice paid
+func (h *TechHandler) GetPaymentStatus(e *core.RequestEvent) error {
+	jobID := e.Request.PathValue("id")
+	booking, err := h.App.FindRecordById("bookings", jobID)
+	if err != nil || booking.GetString("technician_id") != e.Auth.Id {
+		return e.JSON(404, map[string]interface{}{"paid": false})
+	}
+	invoice, err := h.App.FindFirstRecordByFilter("invoices", "booking_id = {:id}", dbx.Params{"id": jobID})
+	if err != nil {
+		return e.JSON(404, map[string]interface{}{"paid": false})
+	}
+	return e.JSON(200, map[string]interface{}{
+		"paid":   domain.InvoiceSettled(invoice.GetString("status")),
+		"status": invoice.GetString("status"),
+		"due":    max(invoice.GetFloat("total_amount")-invoice.GetFloat("amount_paid"), 0),
+		"method": invoice.GetString("payment_method"),
+	})
+}
+
 // GET /api/tech/jobs/list - Get refreshed job list (for HTMX pull)
 func (h *TechHandler) GetJobsListHTMX(e *core.RequestEvent) error {
 	authRecord := e.Auth
@@ -691,3 +676,23 @@ func (h *TechHandler) UploadJobEvidence(e *core.RequestEvent) error {
 
 	return h.renderPartial(e, "tech/partials/evidence_preview", data)
 }
+
+// emailInvoice sends the paid invoice with its PDF when the customer has an email address
+func (h *TechHandler) emailInvoice(customerID string, invoice *core.Record) {
+	if h.MailService == nil || h.PDFService == nil || h.CustomerRepo == nil || customerID == "" {
+		return
+	}
+	customer, err := h.CustomerRepo.GetByID(customerID)
+	if err != nil || customer.Email == "" {
+		return
+	}
+	pdf, err := h.PDFService.InvoicePDF(invoice)
+	if err != nil {
+		fmt.Printf("❌ Invoice PDF %s: %v\n", invoice.Id, err)
+		return
+	}
+	link := strings.TrimRight(h.App.Settings().Meta.AppURL, "/") + "/invoice/" + invoice.GetString("public_hash")
+	if err := h.MailService.SendInvoice(customer, invoice.GetString("invoice_code"), link, pdf); err != nil {
+		fmt.Printf("❌ Invoice email %s: %v\n", invoice.Id, err)
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	domain "hvac-system/internal/core"
+	"net/http"
+	"net/url"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ShowLeave lists the tech's leave requests and the request form
+// GET /tech/leave
+func (h *TechHandler) ShowLeave(e *core.RequestEvent) error {
+	offs, err := h.ScheduleService.TimeOffForTechnician(e.Auth.Id)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	data := h.getTechCommonData(e.Auth.Id)
+	data["TimeOff"] = offs
+	data["PageType"] = "profile"
+	data["Success"] = e.Request.URL.Query().Get("success") != ""
+	data["Error"] = e.Request.URL.Query().Get("error")
+	return RenderPage(h.Templates, e, "layouts/tech.html", "tech/leave.html", data)
+}
+
+// SubmitLeave creates a pending leave / sick day request for admin approval
+// POST /tech/leave
+func (h *TechHandler) SubmitLeave(e *core.RequestEvent) error {
+	off := &domain.TimeOff{
+		Kind:      e.Request.FormValue("kind"),
+		DateFrom:  e.Request.FormValue("date_from"),
+		DateTo:    e.Request.FormValue("date_to"),
+		StartTime: e.Request.FormValue("start_time"),
+		EndTime:   e.Request.FormValue("end_time"),
+		Reason:    e.Request.FormValue("reason"),
+	}
+
+	if err := h.ScheduleService.RequestTimeOff(off, techActor(e, "tech_app")); err != nil {
+		msg := "Không thể gửi yêu cầu, vui lòng thử lại"
+		if errors.Is(err, domain.ErrInvalidTimeOff) {
+			msg = "Ngày hoặc giờ nghỉ không hợp lệ"
+		}
+		return e.Redirect(http.StatusSeeOther, "/tech/leave?error="+url.QueryEscape(msg))
+	}
+
+	return e.Redirect(http.StatusSeeOther, "/tech/leave?success=1")
+}
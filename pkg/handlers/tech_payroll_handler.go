@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	domain "hvac-system/internal/core"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ShowPayroll shows the tech's own statement of a month. Drafts are shown as
+// an estimate until the office approves them.
+// GET /tech/payroll?period=YYYY-MM
+func (h *TechHandler) ShowPayroll(e *core.RequestEvent) error {
+	statements, err := h.PayrollService.ForTechnician(e.Auth.Id)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+
+	period := payrollPeriodParam(e)
+	var statement *domain.PayrollStatement
+	for _, s := range statements {
+		if s.Period == period {
+			statement = s
+			break
+		}
+	}
+	current := domain.CurrentPayrollPeriod(time.Now())
+	if (statement != nil && !statement.Locked()) || (statement == nil && period == current) {
+		// Drafts are refreshed so the tech sees today's figures
+		if generated, err := h.PayrollService.Generate(period, e.Auth.Id); err == nil && len(generated) > 0 {
+			statement = generated[0]
+		}
+	}
+
+	periods := make([]string, 0, len(statements)+1)
+	if len(statements) == 0 || statements[0].Period != current {
+		periods = append(periods, current)
+	}
+	for _, s := range statements {
+		periods = append(periods, s.Period)
+	}
+
+	data := h.getTechCommonData(e.Auth.Id)
+	data["Statement"] = statement
+	data["Period"] = period
+	data["Periods"] = periods
+	data["PageType"] = "profile"
+	return RenderPage(h.Templates, e, "layouts/tech.html", "tech/payroll.html", data)
+}
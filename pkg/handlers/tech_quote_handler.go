@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// quoteLink is the public page where the customer signs the quote
+func quoteLink(q *domain.Quote) string {
+	return "/quote/" + q.PublicHash
+}
+
+// ShowQuote lets the tech build a quote from the catalog and follow its status
+// GET /tech/job/{id}/quote
+func (h *TechHandler) ShowQuote(e *core.RequestEvent) error {
+	jobID := e.Request.PathValue("id")
+	job, err := h.BookingRepo.GetByID(jobID)
+	if err != nil || job.TechnicianID != e.Auth.Id {
+		return e.String(404, "Job không tồn tại")
+	}
+
+	quotes, err := h.QuoteService.ForBooking(jobID)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	var latest *domain.Quote
+	latestLink := ""
+	if len(quotes) > 0 {
+		latest = quotes[0]
+		latestLink = quoteLink(latest)
+	}
+
+	services, _ := h.App.FindRecordsByFilter("services", "active=true", "+name", 200, 0, nil)
+	catalog := []map[string]interface{}{}
+	for _, s := range services {
+		catalog = append(catalog, map[string]interface{}{
+			"kind": domain.QuoteItemService, "id": s.Id, "name": s.GetString("name"), "unit": "lần", "price": s.GetFloat("price"),
+		})
+	}
+	parts, _ := h.PartRepo.ListActive()
+	for _, p := range parts {
+		catalog = append(catalog, map[string]interface{}{
+			"kind": domain.QuoteItemPart, "id": p.ID, "name": p.Name, "unit": p.Unit, "price": p.Price,
+		})
+	}
+
+	data := h.getTechCommonData(e.Auth.Id)
+	data["Booking"] = job
+	data["Quotes"] = quotes
+	data["Latest"] = latest
+	data["LatestLink"] = latestLink
+	data["Catalog"] = catalog
+	data["DefaultServiceID"] = job.ServiceID
+	data["Error"] = e.Request.URL.Query().Get("error")
+	data["PageType"] = "job_detail"
+	return RenderPage(h.Templates, e, "layouts/tech.html", "tech/forms/quote.html", data)
+}
+
+// SubmitQuote prices the selected lines and waits for the customer's signature
+// POST /tech/job/{id}/quote (items_json: [{kind, ref_id, qty}], notes)
+func (h *TechHandler) SubmitQuote(e *core.RequestEvent) error {
+	jobID := e.Request.PathValue("id")
+	back := "/tech/job/" + jobID + "/quote"
+
+	var lines []domain.QuoteLine
+	if err := json.Unmarshal([]byte(e.Request.FormValue("items_json")), &lines); err != nil {
+		return e.Redirect(http.StatusSeeOther, back+"?error="+url.QueryEscape("Dữ liệu báo giá không hợp lệ"))
+	}
+
+	_, err := h.QuoteService.Create(jobID, lines, strings.TrimSpace(e.Request.FormValue("notes")), techActor(e, "tech_app"))
+	if err != nil {
+		msg := "Không thể lập báo giá, vui lòng thử lại"
+		var te *domain.TransitionError
+		switch {
+		case errors.As(err, &te):
+			msg = te.Message()
+		case errors.Is(err, domain.ErrInvalidQuote):
+			msg = "Báo giá cần ít nhất một hạng mục với số lượng hợp lệ"
+		}
+		return e.Redirect(http.StatusSeeOther, back+"?error="+url.QueryEscape(msg))
+	}
+	return e.Redirect(http.StatusSeeOther, back)
+}
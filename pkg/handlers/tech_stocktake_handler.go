@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	domain "hvac-system/internal/core"
+	"net/http"
+	"net/url"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ShowStocktake shows the count of the tech's truck in progress and the
+// variances of past stocktakes
+// GET /tech/stocktake
+func (h *TechHandler) ShowStocktake(e *core.RequestEvent) error {
+	open, err := h.Stocktakes.OpenStocktake(e.Auth.Id)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	past, err := h.Stocktakes.Stocktakes(e.Auth.Id, domain.StocktakeApproved)
+	if err != nil {
+		return e.String(500, err.Error())
+	}
+	if len(past) > 10 {
+		past = past[:10]
+	}
+
+	data := h.getTechCommonData(e.Auth.Id)
+	data["Stocktake"] = open
+	data["Past"] = past
+	data["PageType"] = "profile"
+	data["Success"] = e.Request.URL.Query().Get("success") != ""
+	data["Error"] = e.Request.URL.Query().Get("error")
+	return RenderPage(h.Templates, e, "layouts/tech.html", "tech/stocktake.html", data)
+}
+
+// SubmitStocktakeCounts saves the counts of the tech's own truck, and hands
+// them in for approval when asked
+// POST /tech/stocktake (Form: id, item_id[], counted[], submit)
+func (h *TechHandler) SubmitStocktakeCounts(e *core.RequestEvent) error {
+	st, err := h.Stocktakes.GetStocktake(e.Request.FormValue("id"))
+	if err != nil || st.Location != e.Auth.Id {
+		return e.Redirect(http.StatusSeeOther, "/tech/stocktake?error="+url.QueryEscape("Không tìm thấy phiếu kiểm kê"))
+	}
+	fail := func(err error) error {
+		return e.Redirect(http.StatusSeeOther, "/tech/stocktake?error="+url.QueryEscape(stocktakeErrorMessage(err)))
+	}
+	// Once handed in, only the office can correct the counts
+	if st.Status != domain.StocktakeCounting {
+		return fail(domain.ErrStocktakeState)
+	}
+	counts, err := stocktakeCounts(e)
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := h.Stocktakes.Count(st.ID, counts, nil, e.Auth.Id); err != nil {
+		return fail(err)
+	}
+	if e.Request.FormValue("submit") != "" {
+		if _, err := h.Stocktakes.Submit(st.ID, e.Auth.Id); err != nil {
+			return fail(err)
+		}
+	}
+	return e.Redirect(http.StatusSeeOther, "/tech/stocktake?success=1")
+}
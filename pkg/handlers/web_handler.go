@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 	"hvac-system/internal/adapter/repository"
 	domain "hvac-system/internal/core"
 	"hvac-system/pkg/broker"
+	"hvac-system/pkg/cache"
 	"hvac-system/pkg/models"
 	"hvac-system/pkg/notification"
 	"hvac-system/pkg/services"
@@ -24,6 +26,9 @@ type WebHandler struct {
 	FCMService     *notification.FCMService // [NEW]
 	BookingService domain.BookingService    // [NEW] Internal Service
 	SlotService    *services.TimeSlotService
+	BookingRepo    domain.BookingRepository    // [NEW] Customer portal lookup by token
+	TechRepo       domain.TechnicianRepository // [NEW]
+	LocationCache  *cache.LocationCache        // [NEW] Live tech position for portal map
 }
 
 // 1. Trang chủ - Landing Page
@@ -91,6 +96,9 @@ func (h *WebHandler) BookService(e *core.RequestEvent) error {
 		Files:          files,
 	})
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPhone) {
+			return e.String(400, "Số điện thoại không hợp lệ")
+		}
 		return e.String(500, "Lỗi tạo booking: "+err.Error())
 	}
 
@@ -105,14 +113,16 @@ func (h *WebHandler) BookService(e *core.RequestEvent) error {
 		}
 	}
 
-	return e.HTML(200, `
+	// [NEW] Customer keeps this link to track, reschedule or cancel
+	return e.HTML(200, fmt.Sprintf(`
         <div class="alert alert-success shadow-lg">
             <div>
 				<i class="fa-solid fa-check-circle"></i>
 				<span>Đã nhận yêu cầu! Kỹ thuật viên sẽ gọi lại trong 5 phút.</span>
 			</div>
+			<a href="%s" class="btn btn-sm btn-ghost underline">Theo dõi đơn hàng</a>
         </div>
-    `)
+    `, PortalLink(booking)))
 }
 
 // Booking Page Handler
@@ -210,6 +210,7 @@ func (s *FCMService) NotifyJobStatusChange(ctx context.Context, techToken string
 		"completed":   "✨ Hoàn thành",
 		"pending":     "⏳ Chờ duyệt",
 		"cancelled":   "❌ Đơn hàng đã hủy",
+		"rescheduled": "📅 Khách đã đổi lịch hẹn",
 	}
 
 	title, ok := statusMessage[status]
@@ -393,3 +394,28 @@ func (s *FCMService) NotifyAdminsBookingCancelled(ctx context.Context, tokens []
 	log.Printf("NotifyAdminsBookingCancelled: Success %d, Failure %d", response.SuccessCount, response.FailureCount)
 	return failedTokens, nil
 }
+
+// NotifyAdminsBookingRescheduled sends multicast reschedule notice to admins
+func (s *FCMService) NotifyAdminsBookingRescheduled(ctx context.Context, tokens []string, bookingID, customerName, newTime string) ([]string, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	payload := &NotificationPayload{
+		Title: "📅 Đơn hàng đổi lịch",
+		Body:  fmt.Sprintf("Đơn %s đã đổi sang %s", customerName, newTime),
+		Data: map[string]string{
+			"type":       "booking_rescheduled",
+			"booking_id": bookingID,
+		},
+		Icon:  "/assets/icons/icon-192x192.png",
+		Badge: "/assets/icons/icon-192x192.png",
+	}
+
+	response, failedTokens, err := s.SendMulticast(ctx, tokens, payload)
+	if err != nil {
+		return failedTokens, err
+	}
+	log.Printf("NotifyAdminsBookingRescheduled: Success %d, Failure %d", response.SuccessCount, response.FailureCount)
+	return failedTokens, nil
+}
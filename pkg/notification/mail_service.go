@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"net/mail"
+	"strings"
+
+	"hvac-system/internal/core"
+
+	pbCore "github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/mailer"
+)
+
+// MailService sends customer emails through the PocketBase mailer (SMTP settings)
+type MailService struct {
+	app pbCore.App
+}
+
+// NewMailService creates a mail sender using the app's SMTP settings
+func NewMailService(app pbCore.App) *MailService {
+	return &MailService{app: app}
+}
+
+// portalURL is the absolute customer portal link (/b/{token})
+func (s *MailService) portalURL(booking *core.Booking) string {
+	return strings.TrimRight(s.app.Settings().Meta.AppURL, "/") + "/b/" + booking.AccessToken
+}
+
+// send delivers one HTML email; attachments maps file names to their content
+func (s *MailService) send(to, name, subject, body string, attachments map[string][]byte) error {
+	meta := s.app.Settings().Meta
+	msg := &mailer.Message{
+		From:    mail.Address{Name: meta.SenderName, Address: meta.SenderAddress},
+		To:      []mail.Address{{Name: name, Address: to}},
+		Subject: subject,
+		HTML:    body,
+	}
+	if len(attachments) > 0 {
+		msg.Attachments = make(map[string]io.Reader, len(attachments))
+		for file, data := range attachments {
+			msg.Attachments[file] = bytes.NewReader(data)
+		}
+	}
+	return s.app.NewMailClient().Send(msg)
+}
+
+// SendMaintenanceReminder emails the upcoming contract visit with the portal link
+// so the customer can confirm, reschedule or cancel.
+func (s *MailService) SendMaintenanceReminder(customer *core.Customer, booking *core.Booking) error {
+	if customer.Email == "" {
+		return core.ErrNoContactChannel
+	}
+
+	link := s.portalURL(booking)
+	body := fmt.Sprintf(
+		`<p>Xin chào %s,</p>
+<p>Theo hợp đồng bảo trì, kỹ thuật viên sẽ đến bảo dưỡng thiết bị của bạn vào <strong>%s</strong>.</p>
+<p>Bạn có thể xem chi tiết, đổi lịch hoặc hủy hẹn tại: <a href="%s">%s</a></p>
+<p>Trân trọng,<br>%s</p>`,
+		html.EscapeString(customer.Name),
+		html.EscapeString(booking.BookingTime),
+		link, link,
+		html.EscapeString(s.app.Settings().Meta.AppName),
+	)
+	if err := s.send(customer.Email, customer.Name, "Nhắc lịch bảo trì định kỳ", body, nil); err != nil {
+		return fmt.Errorf("failed to send reminder email: %w", err)
+	}
+	return nil
+}
+
+// SendInvoice emails the issued invoice with its PDF attached and the link
+// to the online version.
+func (s *MailService) SendInvoice(customer *core.Customer, invoiceCode, link string, pdf []byte) error {
+	if customer.Email == "" {
+		return core.ErrNoContactChannel
+	}
+
+	body := fmt.Sprintf(
+		`<p>Xin chào %s,</p>
+<p>Cảm ơn bạn đã sử dụng dịch vụ. Hóa đơn <strong>%s</strong> được đính kèm trong email này.</p>
+<p>Bạn cũng có thể xem hóa đơn trực tuyến tại: <a href="%s">%s</a></p>
+<p>Trân trọng,<br>%s</p>`,
+		html.EscapeString(customer.Name),
+		html.EscapeString(invoiceCode),
+		link, link,
+		html.EscapeString(s.app.Settings().Meta.AppName),
+	)
+	attachments := map[string][]byte{invoiceCode + ".pdf": pdf}
+	if err := s.send(customer.Email, customer.Name, "Hóa đơn dịch vụ "+invoiceCode, body, attachments); err != nil {
+		return fmt.Errorf("failed to send invoice email: %w", err)
+	}
+	return nil
+}
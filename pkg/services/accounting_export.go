@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Column layout of the MISA SME "Chứng từ nghiệp vụ khác" import template.
+// (*) marks the columns MISA requires.
+var misaJournalHeader = []string{
+	"Ngày hạch toán (*)", "Ngày chứng từ (*)", "Số chứng từ (*)", "Diễn giải",
+	"TK Nợ (*)", "TK Có (*)", "Số tiền", "Đối tượng Nợ", "Đối tượng Có",
+}
+
+var journalCSVHeader = []string{
+	"Ngày", "Số chứng từ", "Loại", "Diễn giải", "TK Nợ", "TK Có", "Số tiền",
+	"Đối tượng", "Hóa đơn", "Hình thức TT",
+}
+
+// misaPartners puts the customer or technician on the side of the entry
+// that carries the receivable or payable account
+func misaPartners(e domain.JournalEntry) (debtor, creditor string) {
+	switch e.Kind {
+	case domain.JournalReceipt, domain.JournalCommission:
+		return "", e.Partner
+	default:
+		return e.Partner, ""
+	}
+}
+
+func misaJournalRow(e domain.JournalEntry) []interface{} {
+	date := e.LocalDate().Format("02/01/2006")
+	debtor, creditor := misaPartners(e)
+	return []interface{}{date, date, e.Voucher, e.Description, e.Debit, e.Credit, e.Amount, debtor, creditor}
+}
+
+// AccountingCSV writes one row per entry in a generic layout. The UTF-8 BOM
+// lets Excel read Vietnamese text correctly.
+func AccountingCSV(entries []domain.JournalEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(journalCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if err := w.Write([]string{
+			e.LocalDate().Format("2006-01-02"), e.Voucher, domain.JournalKindLabel(e.Kind), e.Description,
+			e.Debit, e.Credit, fmt.Sprintf("%.0f", e.Amount), e.Partner, e.InvoiceCode, e.Method,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// AccountingXLSX builds a workbook in the MISA import layout, with a summary
+// sheet the accountant can check the import against
+func AccountingXLSX(entries []domain.JournalEntry, from, to string) ([]byte, error) {
+	book := excelize.NewFile()
+	defer book.Close()
+
+	const journal, summary = "Chứng từ", "Tổng hợp"
+	book.SetSheetName(book.GetSheetName(0), journal)
+	if _, err := book.NewSheet(summary); err != nil {
+		return nil, err
+	}
+
+	money, err := book.NewStyle(&excelize.Style{CustomNumFmt: strPtr("#,##0")})
+	if err != nil {
+		return nil, err
+	}
+	bold, err := book.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]interface{}{toRow(misaJournalHeader)}
+	for _, e := range entries {
+		rows = append(rows, misaJournalRow(e))
+	}
+	if err := writeSheet(book, journal, rows); err != nil {
+		return nil, err
+	}
+	book.SetCellStyle(journal, "A1", "I1", bold)
+	book.SetCellStyle(journal, "G2", fmt.Sprintf("G%d", len(rows)), money)
+	book.SetColWidth(journal, "A", "B", 14)
+	book.SetColWidth(journal, "C", "C", 22)
+	book.SetColWidth(journal, "D", "D", 40)
+	book.SetColWidth(journal, "G", "G", 15)
+	book.SetColWidth(journal, "H", "I", 28)
+
+	var totals domain.JournalTotals
+	totals.Add(entries)
+	rows = [][]interface{}{
+		{"Từ ngày", from},
+		{"Đến ngày", to},
+		{"Doanh thu (chưa VAT)", totals.Revenue},
+		{"Thuế GTGT đầu ra", totals.VAT},
+		{"Giá vốn vật tư", totals.Cost},
+		{"Hoa hồng thợ", totals.Commission},
+		{"Tiền đã thu", totals.Receipts},
+		{"Tiền đã hoàn", totals.Refunds},
+	}
+	if err := writeSheet(book, summary, rows); err != nil {
+		return nil, err
+	}
+	book.SetCellStyle(summary, "A1", fmt.Sprintf("A%d", len(rows)), bold)
+	book.SetCellStyle(summary, "B3", fmt.Sprintf("B%d", len(rows)), money)
+	book.SetColWidth(summary, "A", "A", 24)
+	book.SetColWidth(summary, "B", "B", 18)
+
+	buf, err := book.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
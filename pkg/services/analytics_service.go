@@ -66,7 +66,7 @@ func (s *AnalyticsService) GetRevenueLast7Days() ([]RevenueStat, error) {
 		"SUM(total_amount) as total",
 	).
 		From("invoices").
-		Where(dbx.NewExp("status = 'paid' && created >= {:start} && created <= {:end}", dbx.Params{
+		Where(dbx.NewExp("status IN ('paid', 'overpaid') AND created >= {:start} && created <= {:end}", dbx.Params{
 			"start": startStr,
 			"end":   endStr,
 		})).
@@ -132,7 +132,7 @@ func (s *AnalyticsService) GetDashboardStats() (*DashboardStats, error) {
 	}
 	err := s.App.DB().Select("SUM(total_amount) as total").
 		From("invoices").
-		Where(dbx.HashExp{"status": "paid"}).
+		Where(dbx.In("status", "paid", "overpaid")).
 		One(&revenueResult)
 
 	if err == nil {
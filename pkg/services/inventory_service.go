@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
+
+	domain "hvac-system/internal/core"
 
 	"github.com/pocketbase/pocketbase/core"
 )
@@ -19,26 +22,16 @@ func NewInventoryService(app core.App) *InventoryService {
 	return &InventoryService{app: app}
 }
 
-// DeductStock reduces the stock of an item by quantity and returns the cost
-// This is a helper for atomic operations within a transaction context ideally
+// DeductStock takes quantity out of the main warehouse for a job and returns
+// the unit price
 func (s *InventoryService) DeductStock(itemID string, quantity float64) (float64, error) {
-	item, err := s.app.FindRecordById("inventory_items", itemID)
-	if err != nil {
-		return 0, err
-	}
-
-	currentStock := item.GetFloat("stock_quantity")
-	if currentStock < quantity {
-		return 0, fmt.Errorf("insufficient stock: have %.0f, need %.0f", currentStock, quantity)
-	}
-
-	// Deduct
-	item.Set("stock_quantity", currentStock-quantity)
-	if err := s.app.Save(item); err != nil {
-		return 0, err
-	}
-
-	return item.GetFloat("price"), nil
+	var price float64
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		var err error
+		price, _, _, err = consume(txApp, domain.StockMain, itemID, quantity, nil, "")
+		return err
+	})
+	return price, err
 }
 
 // InventoryItem represents a part in stock (legacy, for backward compatibility)
@@ -50,6 +43,8 @@ type InventoryItem struct {
 	Price         float64
 	StockQuantity int
 	Unit          string
+	VATRate       string // Empty = brand default
+	Tracking      string // "", domain.TrackSerial or domain.TrackLot
 	IsActive      bool
 }
 
@@ -65,6 +60,9 @@ type Product struct {
 	MinThreshold float64 `json:"min_threshold"` // Ngưỡng cảnh báo
 	MainStock    float64 `json:"main_stock"`    // Tồn kho tổng
 	IsActive     bool    `json:"is_active"`
+	Tracking     string  `json:"tracking"` // "", domain.TrackSerial or domain.TrackLot
+
+	PreferredSupplierID string `json:"preferred_supplier_id"` // Draft POs from low-stock alerts go to this supplier
 }
 
 // LowStockAlert represents a product below threshold
@@ -81,6 +79,9 @@ type JobPart struct {
 	Quantity     float64 `json:"qty"`
 	PricePerUnit float64 `json:"price"`
 	Total        float64 `json:"total"`
+
+	Lots        []domain.LotAllocation `json:"lots"`         // Serials / lots used, for tracked items
+	EquipmentID string                 `json:"equipment_id"` // Customer equipment the serials went into
 }
 
 // GetActiveItems returns all active inventory items
@@ -110,6 +111,8 @@ func (s *InventoryService) GetActiveItems() ([]InventoryItem, error) {
 			Price:         record.GetFloat("price"),
 			StockQuantity: int(record.GetFloat("stock_quantity")),
 			Unit:          record.GetString("unit"),
+			VATRate:       record.GetString("vat_rate"),
+			Tracking:      record.GetString("tracking"),
 			IsActive:      record.GetBool("is_active"),
 		}
 	}
@@ -149,100 +152,84 @@ func (s *InventoryService) GetItemsByCategory(category string) ([]InventoryItem,
 	return items, nil
 }
 
-// RecordPartsUsage saves parts used in a job and updates stock
+// RecordPartsUsage saves parts used in a job and takes them out of the main
+// warehouse, all in one transaction
 // Business rule: Atomic stock deduction to prevent overselling
 func (s *InventoryService) RecordPartsUsage(jobReportID string, parts []JobPart) (float64, error) {
+	return s.recordPartsUsage(domain.StockMain, jobReportID, "", parts)
+}
+
+// RecordPartsUsageFromTech saves parts used and deducts from TECHNICIAN'S inventory (Truck Stock)
+// This is the new workflow for Kho Trên Xe feature. Either every part is
+// recorded and deducted, or none is.
+func (s *InventoryService) RecordPartsUsageFromTech(jobReportID, techID, jobID string, parts []JobPart) (float64, error) {
+	return s.recordPartsUsage(techID, jobReportID, jobID, parts)
+}
+
+func (s *InventoryService) recordPartsUsage(location, jobReportID, jobID string, parts []JobPart) (float64, error) {
 	if len(parts) == 0 {
 		return 0, nil
 	}
 
-	collection, err := s.app.FindCollectionByNameOrId("job_parts")
-	if err != nil {
-		return 0, err
-	}
-
 	var totalPartsCost float64
-
-	for _, part := range parts {
-		// Verify item exists and has stock
-		item, err := s.app.FindRecordById("inventory_items", part.ItemID)
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		totalPartsCost = 0
+		collection, err := txApp.FindCollectionByNameOrId("job_parts")
 		if err != nil {
-			return 0, fmt.Errorf("item %s not found", part.ItemID)
-		}
-
-		currentStock := item.GetFloat("stock_quantity")
-		if currentStock < part.Quantity {
-			return 0, fmt.Errorf("insufficient stock for %s: need %.0f, have %.0f",
-				item.GetString("name"), part.Quantity, currentStock)
+			return err
 		}
 
-		// Get current price (for frozen pricing)
-		pricePerUnit := item.GetFloat("price")
-		total := part.Quantity * pricePerUnit
-
-		// Create job_parts record
-		record := core.NewRecord(collection)
-		record.Set("job_report_id", jobReportID)
-		record.Set("item_id", part.ItemID)
-		record.Set("quantity", part.Quantity)
-		record.Set("price_per_unit", pricePerUnit)
-		record.Set("total", total)
-
-		if err := s.app.Save(record); err != nil {
-			return 0, fmt.Errorf("failed to record part usage: %w", err)
-		}
+		for _, part := range parts {
+			// Frozen pricing: the sell price at the time of use
+			pricePerUnit, unitCost, lots, err := consume(txApp, location, part.ItemID, part.Quantity, part.Lots, jobID)
+			if err != nil {
+				return err
+			}
+			total := part.Quantity * pricePerUnit
+
+			record := core.NewRecord(collection)
+			record.Set("job_report_id", jobReportID)
+			record.Set("item_id", part.ItemID)
+			record.Set("quantity", part.Quantity)
+			record.Set("price_per_unit", pricePerUnit)
+			record.Set("total", total)
+			record.Set("unit_cost", unitCost) // Actual cost at consumption (cost layers)
+			record.Set("cost_total", part.Quantity*unitCost)
+			record.Set("lots", lots)
+			record.Set("equipment_id", part.EquipmentID)
+			if err := txApp.Save(record); err != nil {
+				return fmt.Errorf("failed to record part usage: %w", err)
+			}
+			if err := linkInstalledSerials(txApp, part.ItemID, jobID, lots, jobReportID, record.Id, part.EquipmentID); err != nil {
+				return err
+			}
 
-		// Deduct stock
-		item.Set("stock_quantity", currentStock-part.Quantity)
-		if err := s.app.Save(item); err != nil {
-			return 0, fmt.Errorf("failed to update stock: %w", err)
+			totalPartsCost += total
 		}
-
-		totalPartsCost += total
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-
 	return totalPartsCost, nil
 }
 
-// RecordPartsUsageFromTech saves parts used and deducts from TECHNICIAN'S inventory (Truck Stock)
-// This is the new workflow for Kho Trên Xe feature
-func (s *InventoryService) RecordPartsUsageFromTech(jobReportID, techID, jobID string, parts []JobPart) (float64, error) {
-	if len(parts) == 0 {
-		return 0, nil
-	}
-
-	collection, err := s.app.FindCollectionByNameOrId("job_parts")
+// consume takes qty of an item, with its serials or lots when tracked, out
+// of a location for a job. It returns the sell price, the unit cost drawn
+// from the cost layers and the numbers used. Must run inside a transaction.
+func consume(txApp core.App, location, itemID string, qty float64, lots []domain.LotAllocation, jobID string) (price, unitCost float64, used []domain.LotAllocation, err error) {
+	item, err := txApp.FindRecordById("inventory_items", itemID)
 	if err != nil {
-		return 0, err
+		return 0, 0, nil, fmt.Errorf("item %s not found", itemID)
 	}
-
-	var totalPartsCost float64
-
-	for _, part := range parts {
-		// Deduct from tech inventory (will error if insufficient stock)
-		pricePerUnit, err := s.DeductTechStock(techID, part.ItemID, part.Quantity, jobID)
-		if err != nil {
-			return 0, err
-		}
-
-		total := part.Quantity * pricePerUnit
-
-		// Create job_parts record
-		record := core.NewRecord(collection)
-		record.Set("job_report_id", jobReportID)
-		record.Set("item_id", part.ItemID)
-		record.Set("quantity", part.Quantity)
-		record.Set("price_per_unit", pricePerUnit)
-		record.Set("total", total)
-
-		if err := s.app.Save(record); err != nil {
-			return 0, fmt.Errorf("failed to record part usage: %w", err)
-		}
-
-		totalPartsCost += total
+	m := &domain.StockMovement{Type: domain.MoveTechToJob, FromID: location, ItemID: itemID, Quantity: qty, Lots: lots, JobID: jobID}
+	if location == domain.StockMain {
+		m.Type = domain.MoveMainToJob
 	}
-
-	return totalPartsCost, nil
+	if err := moveStock(txApp, m); err != nil {
+		return 0, 0, nil, err
+	}
+	return item.GetFloat("price"), m.UnitCost, m.Lots, nil
 }
 
 // CalculateJobCost calculates total cost including labor and parts
@@ -289,7 +276,9 @@ func (s *InventoryService) GetJobParts(jobReportID string) ([]JobPart, error) {
 			Quantity:     record.GetFloat("quantity"),
 			PricePerUnit: record.GetFloat("price_per_unit"),
 			Total:        record.GetFloat("total"),
+			EquipmentID:  record.GetString("equipment_id"),
 		}
+		_ = record.UnmarshalJSONField("lots", &parts[i].Lots)
 	}
 
 	return parts, nil
@@ -310,6 +299,7 @@ type TechStockItem struct {
 	Quantity  float64
 	Unit      string
 	MainStock float64 // Reference to main inventory stock
+	Tracking  string  // "", domain.TrackSerial or domain.TrackLot
 }
 
 // GetTechInventory returns all items in a technician's truck stock
@@ -343,6 +333,7 @@ func (s *InventoryService) GetTechInventory(techID string) ([]TechStockItem, err
 		price := 0.0
 		unit := ""
 		mainStock := 0.0
+		tracking := ""
 
 		if item != nil {
 			itemName = item.GetString("name")
@@ -350,6 +341,7 @@ func (s *InventoryService) GetTechInventory(techID string) ([]TechStockItem, err
 			price = item.GetFloat("price")
 			unit = item.GetString("unit")
 			mainStock = item.GetFloat("stock_quantity")
+			tracking = item.GetString("tracking")
 		}
 
 		items[i] = TechStockItem{
@@ -362,149 +354,65 @@ func (s *InventoryService) GetTechInventory(techID string) ([]TechStockItem, err
 			Quantity:  record.GetFloat("quantity"),
 			Unit:      unit,
 			MainStock: mainStock,
+			Tracking:  tracking,
 		}
 	}
 
 	return items, nil
 }
 
-// TransferToTech transfers stock from main inventory to a technician's truck
-func (s *InventoryService) TransferToTech(itemID, techID string, qty float64, adminID string) error {
-	// 1. Check main stock
-	item, err := s.app.FindRecordById("inventory_items", itemID)
-	if err != nil {
-		return fmt.Errorf("vật tư không tồn tại: %w", err)
-	}
-
-	mainStock := item.GetFloat("stock_quantity")
-	if mainStock < qty {
-		return fmt.Errorf("kho chính không đủ hàng: cần %.1f, còn %.1f", qty, mainStock)
-	}
-
-	// 2. Deduct from main stock
-	item.Set("stock_quantity", mainStock-qty)
-	if err := s.app.Save(item); err != nil {
-		return fmt.Errorf("lỗi trừ kho chính: %w", err)
-	}
-
-	// 3. Add to tech inventory (upsert)
-	filter := fmt.Sprintf("technician_id = '%s' && item_id = '%s'", techID, itemID)
-	existing, _ := s.app.FindFirstRecordByFilter("tech_inventory", filter)
-
-	if existing != nil {
-		// Update existing record
-		newQty := existing.GetFloat("quantity") + qty
-		existing.Set("quantity", newQty)
-		if err := s.app.Save(existing); err != nil {
-			return fmt.Errorf("lỗi cập nhật kho thợ: %w", err)
-		}
-	} else {
-		// Create new record
-		collection, err := s.app.FindCollectionByNameOrId("tech_inventory")
-		if err != nil {
-			return err
-		}
-		record := core.NewRecord(collection)
-		record.Set("technician_id", techID)
-		record.Set("item_id", itemID)
-		record.Set("quantity", qty)
-		if err := s.app.Save(record); err != nil {
-			return fmt.Errorf("lỗi tạo kho thợ: %w", err)
-		}
-	}
-
-	// 4. Log transfer
-	s.logTransfer("main_to_tech", "", techID, itemID, qty, adminID, "")
-
-	return nil
+// TransferToTech transfers stock from main inventory to a technician's truck,
+// naming the serials or lots moved when the item is tracked
+func (s *InventoryService) TransferToTech(itemID, techID string, qty float64, lots []domain.LotAllocation, adminID string) error {
+	return s.MoveStock(&domain.StockMovement{
+		Type: domain.MoveMainToTech, FromID: domain.StockMain, ToID: techID, ItemID: itemID, Quantity: qty, Lots: lots, CreatedBy: adminID,
+	})
 }
 
 // DeductTechStock deducts from technician's truck stock when completing a job
 func (s *InventoryService) DeductTechStock(techID, itemID string, qty float64, jobID string) (float64, error) {
-	// Find tech inventory record
-	filter := fmt.Sprintf("technician_id = '%s' && item_id = '%s'", techID, itemID)
-	record, err := s.app.FindFirstRecordByFilter("tech_inventory", filter)
-	if err != nil {
-		return 0, fmt.Errorf("vật tư không có trong kho xe")
-	}
-
-	currentQty := record.GetFloat("quantity")
-	if currentQty < qty {
-		return 0, fmt.Errorf("kho xe không đủ: cần %.1f, còn %.1f", qty, currentQty)
-	}
-
-	// Deduct
-	record.Set("quantity", currentQty-qty)
-	if err := s.app.Save(record); err != nil {
-		return 0, err
-	}
-
-	// Get price for return
-	item, _ := s.app.FindRecordById("inventory_items", itemID)
-	price := 0.0
-	if item != nil {
-		price = item.GetFloat("price")
-	}
-
-	// Log transfer
-	s.logTransfer("tech_to_job", techID, "", itemID, qty, "", jobID)
-
-	return price, nil
+	var price float64
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		var err error
+		price, _, _, err = consume(txApp, techID, itemID, qty, nil, jobID)
+		return err
+	})
+	return price, err
 }
 
 // ReturnToMain returns stock from technician's truck back to main inventory
-func (s *InventoryService) ReturnToMain(techID, itemID string, qty float64, adminID string) error {
-	// 1. Check tech stock
-	filter := fmt.Sprintf("technician_id = '%s' && item_id = '%s'", techID, itemID)
-	techRecord, err := s.app.FindFirstRecordByFilter("tech_inventory", filter)
-	if err != nil {
-		return fmt.Errorf("vật tư không có trong kho xe")
-	}
-
-	currentQty := techRecord.GetFloat("quantity")
-	if currentQty < qty {
-		return fmt.Errorf("kho xe không đủ để trả: có %.1f, muốn trả %.1f", currentQty, qty)
-	}
-
-	// 2. Deduct from tech
-	techRecord.Set("quantity", currentQty-qty)
-	if err := s.app.Save(techRecord); err != nil {
-		return err
-	}
-
-	// 3. Add back to main stock
-	item, err := s.app.FindRecordById("inventory_items", itemID)
-	if err != nil {
-		return err
-	}
-	item.Set("stock_quantity", item.GetFloat("stock_quantity")+qty)
-	if err := s.app.Save(item); err != nil {
-		return err
-	}
-
-	// 4. Log transfer
-	s.logTransfer("tech_to_main", techID, "", itemID, qty, adminID, "")
-
-	return nil
+func (s *InventoryService) ReturnToMain(techID, itemID string, qty float64, lots []domain.LotAllocation, adminID string) error {
+	return s.MoveStock(&domain.StockMovement{
+		Type: domain.MoveTechToMain, FromID: techID, ToID: domain.StockMain, ItemID: itemID, Quantity: qty, Lots: lots, CreatedBy: adminID,
+	})
 }
 
-// logTransfer creates an audit log entry for stock movements
-func (s *InventoryService) logTransfer(transferType, fromID, toID, itemID string, qty float64, createdBy, jobID string) {
-	collection, err := s.app.FindCollectionByNameOrId("stock_transfers")
-	if err != nil {
-		return // Silently fail logging - don't block main operation
-	}
-
-	record := core.NewRecord(collection)
-	record.Set("transfer_type", transferType)
-	record.Set("from_id", fromID)
-	record.Set("to_id", toID)
-	record.Set("item_id", itemID)
-	record.Set("quantity", qty)
-	record.Set("created_by", createdBy)
-	record.Set("job_id", jobID)
+// AdjustStock corrects the balance of an item at a location (domain.StockMain
+// or a technician ID) by a signed delta, e.g. after a count
+func (s *InventoryService) AdjustStock(itemID, location string, delta float64, note, adminID string) error {
+	return s.MoveStock(&domain.StockMovement{
+		Type: domain.MoveAdjustment, ToID: location, ItemID: itemID, Quantity: delta, Note: note, CreatedBy: adminID,
+	})
+}
 
-	s.app.Save(record) // Best effort
+// SetMainStock brings the main warehouse balance of an item to qty through
+// an adjustment, e.g. the initial stock of a new item
+func (s *InventoryService) SetMainStock(itemID string, qty float64, note, adminID string) error {
+	return s.app.RunInTransaction(func(txApp core.App) error {
+		item, err := txApp.FindRecordById("inventory_items", itemID)
+		if err != nil {
+			return fmt.Errorf("product not found: %w", err)
+		}
+		delta := qty - item.GetFloat("stock_quantity")
+		if math.Abs(delta) <= domain.StockEpsilon {
+			return nil
+		}
+		m := &domain.StockMovement{Type: domain.MoveAdjustment, ToID: domain.StockMain, ItemID: itemID, Quantity: delta, Note: note, CreatedBy: adminID}
+		if note == "" {
+			m.Note = "Nhập số tồn"
+		}
+		return moveStock(txApp, m)
+	})
 }
 
 // ============================================
@@ -536,6 +444,9 @@ func (s *InventoryService) GetProducts() ([]Product, error) {
 			MinThreshold: r.GetFloat("min_threshold"),
 			MainStock:    r.GetFloat("stock_quantity"), // Main warehouse stock
 			IsActive:     r.GetBool("is_active"),
+			Tracking:     r.GetString("tracking"),
+
+			PreferredSupplierID: r.GetString("preferred_supplier_id"),
 		}
 		products = append(products, p)
 	}
@@ -552,28 +463,17 @@ func (s *InventoryService) GetMainStock(productID string) (float64, error) {
 	return item.GetFloat("stock_quantity"), nil
 }
 
-// ImportToMain adds stock to main warehouse and logs it
-func (s *InventoryService) ImportToMain(productID string, qty float64, note, adminID string) error {
+// ImportToMain adds stock to main warehouse and logs it. Tracked items need
+// the serials or lots received.
+func (s *InventoryService) ImportToMain(productID string, qty, unitCost float64, lots []domain.LotAllocation, note, adminID string) error {
 	if qty <= 0 {
 		return errors.New("quantity must be positive")
 	}
-
-	item, err := s.app.FindRecordById("inventory_items", productID)
-	if err != nil {
-		return fmt.Errorf("product not found: %w", err)
-	}
-
-	currentStock := item.GetFloat("stock_quantity")
-	item.Set("stock_quantity", currentStock+qty)
-
-	if err := s.app.Save(item); err != nil {
-		return err
-	}
-
-	// Log the import
-	s.logTransfer("import", "", "main", productID, qty, adminID, "")
-
-	return nil
+	// unitCost 0 costs the receipt at the item's last purchase price
+	return s.MoveStock(&domain.StockMovement{
+		Type: domain.MoveImport, ToID: domain.StockMain, ItemID: productID, Quantity: qty, UnitCost: unitCost,
+		Lots: lots, Note: note, CreatedBy: adminID,
+	})
 }
 
 // GetLowStockAlerts returns products below their min_threshold
@@ -602,8 +502,11 @@ func (s *InventoryService) GetLowStockAlerts() ([]LowStockAlert, error) {
 				Category:     r.GetString("category"),
 				Unit:         r.GetString("unit"),
 				PriceSell:    r.GetFloat("price"),
+				PriceImport:  r.GetFloat("price_import"),
 				MinThreshold: threshold,
 				MainStock:    stock,
+
+				PreferredSupplierID: r.GetString("preferred_supplier_id"),
 			}
 
 			alerts = append(alerts, LowStockAlert{
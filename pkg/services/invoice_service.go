@@ -1,9 +1,14 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 )
 
@@ -15,7 +20,10 @@ func NewInvoiceService(app core.App) *InvoiceService {
 	return &InvoiceService{app: app}
 }
 
-// GenerateInvoice creates an invoice based on labor and parts used
+// GenerateInvoice prices the job (approved quote, or service + parts used),
+// adds the extra labor hours and applies the invoice's discounts and VAT.
+// Manual adjustments stored on an existing invoice are kept; a paid or
+// e-invoiced invoice is not re-priced (ErrInvoiceLocked).
 func (s *InvoiceService) GenerateInvoice(bookingID string) (*core.Record, error) {
 	// Fetch booking
 	booking, err := s.app.FindRecordById("bookings", bookingID)
@@ -23,11 +31,11 @@ func (s *InvoiceService) GenerateInvoice(bookingID string) (*core.Record, error)
 		return nil, fmt.Errorf("booking not found")
 	}
 
-	// Fetch job report
+	// Fetch the latest job report (a new one is created on each completion submit)
 	jobReports, err := s.app.FindRecordsByFilter(
 		"job_reports",
 		fmt.Sprintf("booking_id='%s'", bookingID),
-		"",
+		"-created",
 		1,
 		0,
 		nil,
@@ -39,41 +47,85 @@ func (s *InvoiceService) GenerateInvoice(bookingID string) (*core.Record, error)
 	}
 	report := jobReports[0]
 
-	// Calculate parts total from job_parts
-	partsTotal := 0.0
-	jobParts, _ := s.app.FindRecordsByFilter(
-		"job_parts",
-		fmt.Sprintf("job_report_id='%s'", report.Id),
-		"",
-		100,
-		0,
-		nil,
-	)
-	for _, part := range jobParts {
-		partsTotal += part.GetFloat("total")
-	}
+	defaultVAT, hourRate := s.pricingDefaults()
 
-	// Get base service price (labor)
+	// Get base service (labor)
 	serviceID := booking.GetString("service_id")
-	laborTotal := 0.0
-	serviceName := "Dịch vụ"
-
+	var service *core.Record
 	if serviceID == "" {
 		fmt.Printf("⚠️  INVOICEGEN: Booking %s has no service_id! Labor will be 0\n", bookingID)
+	} else if service, err = s.app.FindRecordById("services", serviceID); err != nil {
+		fmt.Printf("❌ INVOICEGEN: Service %s not found for booking %s: %v\n", serviceID, bookingID, err)
+		service = nil
+	}
+	serviceVAT := ""
+	if service != nil {
+		serviceVAT = service.GetString("vat_rate")
+	}
+
+	// [NEW] An approved quote fixes the price: the invoice copies its lines
+	// instead of re-pricing labor and parts (job_parts still track stock).
+	var lines []domain.InvoiceLine
+	quoteID, quoteItems := s.approvedQuote(bookingID)
+	if quoteID != "" {
+		for _, it := range quoteItems {
+			lines = append(lines, domain.InvoiceLine{
+				Key:       it.Kind + ":" + it.RefID,
+				Kind:      it.Kind,
+				Name:      it.Name,
+				Unit:      it.Unit,
+				Quantity:  it.Quantity,
+				UnitPrice: it.UnitPrice,
+				VATCode:   s.lineVAT(it.Kind, it.RefID),
+			})
+		}
+		fmt.Printf("✅ INVOICEGEN: Using approved quote %s for booking %s\n", quoteID, bookingID)
 	} else {
-		service, err := s.app.FindRecordById("services", serviceID)
-		if err != nil {
-			fmt.Printf("❌ INVOICEGEN: Service %s not found for booking %s: %v\n", serviceID, bookingID, err)
-		} else {
-			laborTotal = service.GetFloat("price")
-			serviceName = service.GetString("name")
-			fmt.Printf("✅ INVOICEGEN: Found service '%s' with price %.2f\n", serviceName, laborTotal)
+		if service != nil && service.GetFloat("price") > 0 {
+			lines = append(lines, domain.InvoiceLine{
+				Key:       domain.InvoiceLineService + ":" + service.Id,
+				Kind:      domain.InvoiceLineService,
+				Name:      service.GetString("name"),
+				Unit:      "lần",
+				Quantity:  1,
+				UnitPrice: service.GetFloat("price"),
+				VATCode:   serviceVAT,
+			})
 		}
-	}
 
-	totalAmount := partsTotal + laborTotal
-	fmt.Printf("DEBUG INVOICEGEN: Booking=%s, ServiceID=%s, Labor=%.2f, Parts=%.2f, Total=%.2f\n",
-		bookingID, serviceID, laborTotal, partsTotal, totalAmount)
+		jobParts, _ := s.app.FindRecordsByFilter(
+			"job_parts",
+			fmt.Sprintf("job_report_id='%s'", report.Id),
+			"",
+			100,
+			0,
+			nil,
+		)
+		seen := map[string]int{}
+		for _, part := range jobParts {
+			// Expand item to get name, unit and VAT
+			s.app.ExpandRecord(part, []string{"item_id"}, nil)
+			line := domain.InvoiceLine{
+				Kind:      domain.InvoiceLinePart,
+				Name:      "Vật tư không tên",
+				Quantity:  part.GetFloat("quantity"),
+				UnitPrice: part.GetFloat("price_per_unit"),
+			}
+			if inventoryItem := part.ExpandedOne("item_id"); inventoryItem != nil {
+				line.Name = inventoryItem.GetString("name")
+				line.Unit = inventoryItem.GetString("unit")
+				line.VATCode = inventoryItem.GetString("vat_rate")
+			}
+			// Keys must stay stable when the report is resubmitted: item ID, then #n for repeats
+			itemID := part.GetString("item_id")
+			line.Key = domain.InvoiceLinePart + ":" + itemID
+			if n := seen[itemID]; n > 0 {
+				line.Key = fmt.Sprintf("%s#%d", line.Key, n)
+			}
+			seen[itemID]++
+			lines = append(lines, line)
+		}
+	}
 
 	// Check for existing invoice
 	existingInvoices, _ := s.app.FindRecordsByFilter(
@@ -90,26 +142,47 @@ func (s *InvoiceService) GenerateInvoice(bookingID string) (*core.Record, error)
 
 	if len(existingInvoices) > 0 {
 		invoice = existingInvoices[0]
-		// Retain existing status if valid, or reset? Typically retain.
-		// Retain public_hash
-		fmt.Printf("DEBUG INVOICEGEN: Updating existing invoice %s\n", invoice.Id)
+		// A paid invoice or one with an e-invoice in force keeps its lines and
+		// totals; status and public_hash are retained otherwise
+		if !domain.InvoiceEditable(invoice.GetString("status"), invoice.GetString("einvoice_status")) {
+			return nil, domain.ErrInvoiceLocked
+		}
 	} else {
 		invoice = core.NewRecord(invoicesCollection)
 		invoice.Set("booking_id", bookingID)
 		invoice.Set("status", "unpaid")
-		invoice.Set("public_hash", fmt.Sprintf("%x", time.Now().UnixNano()))
-		fmt.Printf("DEBUG INVOICEGEN: Creating new invoice\n")
+		invoice.Set("public_hash", NewInvoiceHash())
 	}
 
-	invoice.Set("parts_total", partsTotal)
-	invoice.Set("labor_total", laborTotal)
-	invoice.Set("total_amount", totalAmount)
+	// [NEW] Discounts and extra labor survive regeneration; the tech's hours come from the report
+	var adj domain.InvoiceAdjustments
+	_ = invoice.UnmarshalJSONField("adjustments", &adj)
+	adj.SetTechHours(report.GetFloat("extra_hours"), hourRate, report.GetString("extra_hours_note"))
+	lines = append(lines, adj.LaborLines(serviceVAT)...)
 
-	// [NEW] Calculate Tech Commission
+	totals, err := domain.PriceInvoice(lines, adj, defaultVAT)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice.Set("parts_total", totals.PartsTotal)
+	invoice.Set("labor_total", totals.LaborTotal)
+	invoice.Set("subtotal", totals.Subtotal)
+	invoice.Set("discount", totals.Discount)
+	invoice.Set("tax_total", totals.Tax)
+	invoice.Set("total_amount", totals.Total)
+	invoice.Set("adjustments", adj)
+	// A deposit may already be on the ledger: the status follows the new total
+	if paid := invoice.GetFloat("amount_paid"); paid > 0 && domain.InvoiceOpen(invoice.GetString("status")) {
+		invoice.Set("status", domain.DeriveInvoiceStatus(totals.Total, paid, 0))
+	}
+	invoice.Set("quote_id", quoteID)
+
+	// [NEW] Calculate Tech Commission on labor after discounts (VAT is not revenue)
 	// Priority: Tech Rate > Service Rate > Default 10%
 	techID := booking.GetString("technician_id")
 	techCommission := 0.0
-	if techID != "" && laborTotal > 0 {
+	if techID != "" && totals.LaborNet > 0 {
 		commissionRate := 10.0 // Default 10%
 
 		// Check technician's personal rate
@@ -119,21 +192,18 @@ func (s *InvoiceService) GenerateInvoice(bookingID string) (*core.Record, error)
 			if techRate > 0 {
 				commissionRate = techRate
 				fmt.Printf("✅ COMMISSION: Using Tech rate %.1f%%\n", commissionRate)
-			} else if serviceID != "" {
+			} else if service != nil {
 				// Fallback to service rate
-				service, err := s.app.FindRecordById("services", serviceID)
-				if err == nil && service != nil {
-					serviceRate := service.GetFloat("commission_rate")
-					if serviceRate > 0 {
-						commissionRate = serviceRate
-						fmt.Printf("✅ COMMISSION: Using Service rate %.1f%%\n", commissionRate)
-					}
+				serviceRate := service.GetFloat("commission_rate")
+				if serviceRate > 0 {
+					commissionRate = serviceRate
+					fmt.Printf("✅ COMMISSION: Using Service rate %.1f%%\n", commissionRate)
 				}
 			}
 		}
 
-		techCommission = laborTotal * (commissionRate / 100)
-		fmt.Printf("✅ COMMISSION: Calculated %.2f (%.1f%% of %.2f labor)\n", techCommission, commissionRate, laborTotal)
+		techCommission = totals.LaborNet * (commissionRate / 100)
+		fmt.Printf("✅ COMMISSION: Calculated %.2f (%.1f%% of %.2f labor)\n", techCommission, commissionRate, totals.LaborNet)
 	}
 	invoice.Set("tech_commission", techCommission)
 
@@ -154,7 +224,6 @@ func (s *InvoiceService) GenerateInvoice(bookingID string) (*core.Record, error)
 	for _, item := range existingItems {
 		s.app.Delete(item)
 	}
-	fmt.Printf("DEBUG INVOICEGEN: Deleted %d old invoice items\n", len(existingItems))
 
 	// Create items collection reference
 	itemsCollection, err := s.app.FindCollectionByNameOrId("invoice_items")
@@ -164,41 +233,295 @@ func (s *InvoiceService) GenerateInvoice(bookingID string) (*core.Record, error)
 		return invoice, nil
 	}
 
-	// Create labor item
-	if laborTotal > 0 {
-		laborItem := core.NewRecord(itemsCollection)
-		laborItem.Set("invoice_id", invoice.Id)
-		laborItem.Set("item_name", serviceName)
-		laborItem.Set("quantity", 1)
-		laborItem.Set("unit_price", laborTotal)
-		laborItem.Set("total", laborTotal)
-		if err := s.app.Save(laborItem); err != nil {
-			fmt.Printf("WARNING: Failed to save labor item: %v\n", err)
+	for _, l := range lines {
+		item := core.NewRecord(itemsCollection)
+		item.Set("invoice_id", invoice.Id)
+		item.Set("line_key", l.Key)
+		item.Set("kind", l.Kind)
+		item.Set("item_name", l.Name)
+		item.Set("unit", l.Unit)
+		item.Set("quantity", l.Quantity)
+		item.Set("unit_price", l.UnitPrice)
+		item.Set("vat_rate", l.VATCode)
+		item.Set("subtotal", l.Subtotal)
+		item.Set("discount", l.Discount)
+		item.Set("tax", l.Tax)
+		item.Set("total", l.Total)
+		if err := s.app.Save(item); err != nil {
+			fmt.Printf("WARNING: Failed to save %s item: %v\n", l.Kind, err)
 		}
 	}
 
-	// Create items for each part
-	for _, part := range jobParts {
-		// Expand item to get name
-		s.app.ExpandRecord(part, []string{"item_id"}, nil)
-		inventoryItem := part.ExpandedOne("item_id")
-		itemName := "Vật tư không tên"
-		if inventoryItem != nil {
-			itemName = inventoryItem.GetString("name")
+	fmt.Printf("✅ INVOICEGEN: Created %d invoice items\n", len(lines))
+
+	return invoice, nil
+}
+
+// ApplyAdjustments replaces the discounts and admin labor lines of an unpaid
+// invoice and re-prices it. New or changed discounts are approved by approver;
+// the hours reported by the tech are kept.
+func (s *InvoiceService) ApplyAdjustments(invoiceID string, adj domain.InvoiceAdjustments, approver string) (*core.Record, error) {
+	invoice, err := s.app.FindRecordById("invoices", invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found")
+	}
+	if !domain.InvoiceEditable(invoice.GetString("status"), invoice.GetString("einvoice_status")) {
+		return nil, domain.ErrInvoiceLocked
+	}
+
+	var previous domain.InvoiceAdjustments
+	_ = invoice.UnmarshalJSONField("adjustments", &previous)
+
+	now := time.Now().UTC().Format(domain.DateTimeLayout)
+	approve := func(d *domain.Discount, old *domain.Discount) {
+		if old != nil && old.Type == d.Type && old.Value == d.Value && old.Reason == d.Reason && old.ApprovedBy != "" {
+			d.ApprovedBy, d.ApprovedAt = old.ApprovedBy, old.ApprovedAt
+			return
+		}
+		d.ApprovedBy, d.ApprovedAt = approver, now
+	}
+	if adj.Discount != nil {
+		approve(adj.Discount, previous.Discount)
+	}
+	for key, d := range adj.LineDiscounts {
+		var old *domain.Discount
+		if p, ok := previous.LineDiscounts[key]; ok {
+			old = &p
 		}
+		approve(&d, old)
+		adj.LineDiscounts[key] = d
+	}
 
-		partItem := core.NewRecord(itemsCollection)
-		partItem.Set("invoice_id", invoice.Id)
-		partItem.Set("item_name", itemName)
-		partItem.Set("quantity", part.GetFloat("quantity"))         // Use GetFloat for quantity as it can be decimal
-		partItem.Set("unit_price", part.GetFloat("price_per_unit")) // Fix: read price_per_unit
-		partItem.Set("total", part.GetFloat("total"))
-		if err := s.app.Save(partItem); err != nil {
-			fmt.Printf("WARNING: Failed to save part item: %v\n", err)
+	labor := make([]domain.LaborLine, 0, len(adj.ExtraLabor)+1)
+	for _, l := range adj.ExtraLabor {
+		l.Source = domain.LaborFromAdmin
+		labor = append(labor, l)
+	}
+	for _, l := range previous.ExtraLabor {
+		if l.Source == domain.LaborFromTech {
+			labor = append(labor, l)
 		}
 	}
+	adj.ExtraLabor = labor
 
-	fmt.Printf("✅ INVOICEGEN: Created %d invoice items (1 labor + %d parts)\n", 1+len(jobParts), len(jobParts))
+	if err := adj.Validate(); err != nil {
+		return nil, err
+	}
 
-	return invoice, nil
+	invoice.Set("adjustments", adj)
+	if err := s.app.Save(invoice); err != nil {
+		return nil, err
+	}
+	return s.GenerateInvoice(invoice.GetString("booking_id"))
+}
+
+// Issue gives the invoice its number (once) and saves it. The brand's yearly
+// sequence is bumped in the same transaction, so numbers stay gap-free: a
+// failed save rolls the counter back, and writes are serialized by SQLite.
+func (s *InvoiceService) Issue(invoice *core.Record) error {
+	if invoice.GetString("invoice_code") != "" {
+		return s.app.Save(invoice)
+	}
+
+	now := time.Now()
+	year := domain.FiscalYear(now)
+	brandID := s.brandID()
+
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		// A concurrent request may have issued it since the record was loaded
+		if !invoice.IsNew() {
+			if current, err := txApp.FindRecordById("invoices", invoice.Id); err == nil && current.GetString("invoice_code") != "" {
+				invoice.Set("invoice_code", current.GetString("invoice_code"))
+				invoice.Set("issued_at", current.GetString("issued_at"))
+				return txApp.Save(invoice)
+			}
+		}
+
+		seq, err := s.sequence(txApp, brandID, year)
+		if err != nil {
+			return err
+		}
+		next := seq.GetInt("last_number") + 1
+		seq.Set("last_number", next)
+		if err := txApp.Save(seq); err != nil {
+			return err
+		}
+
+		invoice.Set("invoice_code", domain.FormatInvoiceCode(year, next))
+		invoice.Set("issued_at", now.UTC().Format(domain.DateTimeLayout))
+		return txApp.Save(invoice)
+	})
+	if err != nil {
+		invoice.Set("invoice_code", "")
+		invoice.Set("issued_at", "")
+		return err
+	}
+	fmt.Printf("✅ INVOICE: Issued %s for booking %s\n", invoice.GetString("invoice_code"), invoice.GetString("booking_id"))
+	return nil
+}
+
+// sequence returns the brand's counter for the year, seeding a new one from
+// the numbers already issued that year
+func (s *InvoiceService) sequence(txApp core.App, brandID string, year int) (*core.Record, error) {
+	seq, err := txApp.FindFirstRecordByFilter(
+		"invoice_sequences",
+		"brand_id = {:brand} && year = {:year}",
+		dbx.Params{"brand": brandID, "year": year},
+	)
+	if err == nil {
+		return seq, nil
+	}
+
+	collection, err := txApp.FindCollectionByNameOrId("invoice_sequences")
+	if err != nil {
+		return nil, err
+	}
+	last := 0
+	issued, _ := txApp.FindRecordsByFilter(
+		"invoices",
+		"invoice_code ~ {:prefix}",
+		"",
+		0,
+		0,
+		dbx.Params{"prefix": fmt.Sprintf("%s-%d-", domain.InvoicePrefix, year)},
+	)
+	for _, inv := range issued {
+		if y, n, ok := domain.ParseInvoiceCode(inv.GetString("invoice_code")); ok && y == year && n > last {
+			last = n
+		}
+	}
+
+	seq = core.NewRecord(collection)
+	seq.Set("brand_id", brandID)
+	seq.Set("year", year)
+	seq.Set("last_number", last)
+	return seq, nil
+}
+
+// brandID is the settings record the invoice numbers belong to (single tenant)
+func (s *InvoiceService) brandID() string {
+	settings, err := s.app.FindRecordsByFilter("settings", "", "-created", 1, 0, nil)
+	if err != nil || len(settings) == 0 {
+		return "default"
+	}
+	return settings[0].Id
+}
+
+// OpenInvoices lists the invoices still expecting money, with their amount due
+func (s *InvoiceService) OpenInvoices() ([]domain.PayableInvoice, error) {
+	records, err := s.app.FindRecordsByFilter("invoices",
+		"status = '' || status = 'unpaid' || status = 'partially_paid'", "-created", 0, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	open := make([]domain.PayableInvoice, 0, len(records))
+	for _, r := range records {
+		open = append(open, payable(r))
+	}
+	return open, nil
+}
+
+// GetPayable returns the invoice with its amount due
+func (s *InvoiceService) GetPayable(invoiceID string) (*domain.PayableInvoice, error) {
+	invoice, err := s.app.FindRecordById("invoices", invoiceID)
+	if err != nil {
+		return nil, domain.ErrInvoiceNotPayable
+	}
+	p := payable(invoice)
+	return &p, nil
+}
+
+// ApplyBalance stores the status and net amount paid computed from the
+// payments ledger. The invoice is issued with the first money received.
+func (s *InvoiceService) ApplyBalance(invoiceID string, balance domain.InvoiceBalance) (*domain.PayableInvoice, error) {
+	invoice, err := s.app.FindRecordById("invoices", invoiceID)
+	if err != nil {
+		return nil, domain.ErrInvoiceNotPayable
+	}
+	if invoice.GetString("status") == domain.InvoiceCancelled {
+		return nil, domain.ErrInvoiceClosed
+	}
+	invoice.Set("status", balance.Status)
+	invoice.Set("amount_paid", balance.Net)
+	if balance.Method != "" {
+		invoice.Set("payment_method", balance.Method)
+	}
+	if invoice.GetString("public_hash") == "" {
+		invoice.Set("public_hash", NewInvoiceHash())
+	}
+
+	if balance.Paid > 0 {
+		err = s.Issue(invoice)
+	} else {
+		err = s.app.Save(invoice)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := payable(invoice)
+	return &p, nil
+}
+
+func payable(invoice *core.Record) domain.PayableInvoice {
+	total := invoice.GetFloat("total_amount")
+	due := total - invoice.GetFloat("amount_paid")
+	if due < 0 || !domain.InvoiceOpen(invoice.GetString("status")) {
+		due = 0
+	}
+	return domain.PayableInvoice{
+		ID:        invoice.Id,
+		BookingID: invoice.GetString("booking_id"),
+		Code:      invoice.GetString("invoice_code"),
+		Amount:    due,
+		Total:     total,
+		Status:    invoice.GetString("status"),
+	}
+}
+
+// NewInvoiceHash returns an unguessable token for the public invoice link
+func NewInvoiceHash() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// pricingDefaults reads the brand's default VAT code and extra labor hourly rate
+func (s *InvoiceService) pricingDefaults() (string, float64) {
+	settings, err := s.app.FindRecordsByFilter("settings", "", "-created", 1, 0, nil)
+	if err != nil || len(settings) == 0 {
+		return domain.DefaultVATCode, 0
+	}
+	return domain.ResolveVAT(settings[0].GetString("default_vat"), ""), settings[0].GetFloat("labor_hour_rate")
+}
+
+// lineVAT is the VAT code of a catalog service or inventory item ("" = brand default)
+func (s *InvoiceService) lineVAT(kind, refID string) string {
+	collection := "inventory_items"
+	if kind == domain.QuoteItemService {
+		collection = "services"
+	}
+	record, err := s.app.FindRecordById(collection, refID)
+	if err != nil {
+		return ""
+	}
+	return record.GetString("vat_rate")
+}
+
+// approvedQuote returns the latest approved quote of the booking (empty ID if none)
+func (s *InvoiceService) approvedQuote(bookingID string) (string, []domain.QuoteItem) {
+	record, err := s.app.FindFirstRecordByFilter(
+		"quotes",
+		"booking_id = {:booking} && status = {:status}",
+		dbx.Params{"booking": bookingID, "status": domain.QuoteApproved},
+	)
+	if err != nil {
+		return "", nil
+	}
+	var items []domain.QuoteItem
+	if err := record.UnmarshalJSONField("items", &items); err != nil || len(items) == 0 {
+		return "", nil
+	}
+	return record.Id, items
 }
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"fmt"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/pocketbase/core"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// InvoiceQR is the VietQR transfer request of an invoice: the brand's bank
+// account, the amount still due and the invoice code in the memo.
+func InvoiceQR(settings, invoice *core.Record) domain.VietQR {
+	return domain.VietQR{
+		BankBin: settings.GetString("bank_bin"),
+		Account: settings.GetString("bank_account"),
+		Amount:  max(invoice.GetFloat("total_amount")-invoice.GetFloat("amount_paid"), 0),
+		Memo:    domain.InvoiceTransferMemo(invoice.GetString("invoice_code")),
+	}
+}
+
+// VietQRPNG encodes the payload as a square PNG of size pixels
+func VietQRPNG(qr domain.VietQR, size int) ([]byte, error) {
+	payload, err := qr.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return qrcode.Encode(payload, qrcode.Medium, size)
+}
+
+// PaymentQR renders the VietQR PNG of an invoice from the brand bank settings
+func (s *InvoiceService) PaymentQR(invoice *core.Record, size int) ([]byte, error) {
+	settings, err := s.app.FindRecordsByFilter("settings", "", "-created", 1, 0, nil)
+	if err != nil || len(settings) == 0 {
+		return nil, fmt.Errorf("brand settings not found")
+	}
+	return VietQRPNG(InvoiceQR(settings[0], invoice), size)
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSXContentType is the MIME type of Excel workbooks
+const XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+var payrollSummaryHeader = []string{
+	"Kỳ lương", "Mã thợ", "Họ tên", "Lương cơ bản", "Hoa hồng", "Thưởng",
+	"Phạt", "Tạm ứng", "Thiếu tiền mặt", "Thực lĩnh", "Trạng thái",
+}
+
+var payrollLineHeader = []string{"Kỳ lương", "Họ tên", "Ngày", "Khoản", "Diễn giải", "Tham chiếu", "Số tiền"}
+
+func payrollSummaryRow(s *domain.PayrollStatement) []interface{} {
+	status := "Tạm tính"
+	if s.Locked() {
+		status = "Đã duyệt"
+	}
+	return []interface{}{
+		s.Period, s.TechnicianID, s.TechnicianName, s.BaseSalary, s.Commission, s.Bonus,
+		s.Penalty, s.Advance, s.Shortage, s.NetPay, status,
+	}
+}
+
+func payrollLineRow(s *domain.PayrollStatement, l domain.PayrollLine) []interface{} {
+	date := ""
+	if !l.Date.IsZero() {
+		date = l.LocalDate().Format("02/01/2006")
+	}
+	return []interface{}{s.Period, s.TechnicianName, date, domain.PayLineLabel(l.Kind), l.Description, l.Reference, l.Amount}
+}
+
+// PayrollCSV writes one row per statement. The UTF-8 BOM lets Excel read
+// Vietnamese names correctly.
+func PayrollCSV(statements []*domain.PayrollStatement) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(payrollSummaryHeader); err != nil {
+		return nil, err
+	}
+	for _, s := range statements {
+		row := payrollSummaryRow(s)
+		record := make([]string, len(row))
+		for i, v := range row {
+			if f, ok := v.(float64); ok {
+				record[i] = fmt.Sprintf("%.0f", f)
+			} else {
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// PayrollXLSX builds a workbook with the summary sheet and the detail lines
+func PayrollXLSX(statements []*domain.PayrollStatement) ([]byte, error) {
+	book := excelize.NewFile()
+	defer book.Close()
+
+	const summary, detail = "Bảng lương", "Chi tiết"
+	book.SetSheetName(book.GetSheetName(0), summary)
+	if _, err := book.NewSheet(detail); err != nil {
+		return nil, err
+	}
+
+	money, err := book.NewStyle(&excelize.Style{CustomNumFmt: strPtr("#,##0")})
+	if err != nil {
+		return nil, err
+	}
+	bold, err := book.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]interface{}{toRow(payrollSummaryHeader)}
+	for _, s := range statements {
+		rows = append(rows, payrollSummaryRow(s))
+	}
+	if err := writeSheet(book, summary, rows); err != nil {
+		return nil, err
+	}
+	book.SetCellStyle(summary, "A1", "K1", bold)
+	book.SetCellStyle(summary, "D2", fmt.Sprintf("J%d", len(rows)), money)
+	book.SetColWidth(summary, "C", "C", 28)
+	book.SetColWidth(summary, "D", "J", 15)
+
+	rows = [][]interface{}{toRow(payrollLineHeader)}
+	for _, s := range statements {
+		for _, l := range s.Lines {
+			rows = append(rows, payrollLineRow(s, l))
+		}
+	}
+	if err := writeSheet(book, detail, rows); err != nil {
+		return nil, err
+	}
+	book.SetCellStyle(detail, "A1", "G1", bold)
+	book.SetCellStyle(detail, "G2", fmt.Sprintf("G%d", len(rows)), money)
+	book.SetColWidth(detail, "B", "B", 28)
+	book.SetColWidth(detail, "E", "E", 40)
+	book.SetColWidth(detail, "F", "G", 18)
+
+	buf, err := book.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeSheet(book *excelize.File, sheet string, rows [][]interface{}) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := book.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toRow(values []string) []interface{} {
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		row[i] = v
+	}
+	return row
+}
+
+func strPtr(s string) *string { return &s }
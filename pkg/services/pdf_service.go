@@ -0,0 +1,454 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/dustin/go-humanize"
+	"github.com/go-pdf/fpdf"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	_ "golang.org/x/image/webp"
+)
+
+// pdfFont is a Unicode TTF family; the PDF core fonts have no Vietnamese glyphs
+const pdfFont = "dejavu"
+
+// PDFService renders invoices and job reports as PDF documents
+// (printing, email attachments) from the same records as the HTML pages.
+type PDFService struct {
+	app     core.App
+	fontDir string
+}
+
+// NewPDFService creates a renderer loading its fonts from fontDir (assets/fonts)
+func NewPDFService(app core.App, fontDir string) *PDFService {
+	return &PDFService{app: app, fontDir: fontDir}
+}
+
+// pdfDoc bundles the document with the open file store used for images
+type pdfDoc struct {
+	*fpdf.Fpdf
+	fs *filesystem.System
+}
+
+// InvoicePDF renders the customer invoice: brand header, line items, totals,
+// VietQR payment code, before/after photos and both signatures.
+func (s *PDFService) InvoicePDF(invoice *core.Record) ([]byte, error) {
+	booking, err := s.app.FindRecordById("bookings", invoice.GetString("booking_id"))
+	if err != nil {
+		return nil, fmt.Errorf("booking not found")
+	}
+	report := s.latestReport(booking.Id)
+	items, _ := s.app.FindRecordsByFilter("invoice_items", "invoice_id = {:id}", "", 0, 0, map[string]any{"id": invoice.Id})
+	brand := s.brand()
+
+	doc, err := s.newDoc()
+	if err != nil {
+		return nil, err
+	}
+	defer doc.fs.Close()
+
+	s.header(doc, brand, "HÓA ĐƠN DỊCH VỤ")
+
+	code := invoice.GetString("invoice_code")
+	if code == "" {
+		code = "Chưa phát hành"
+	}
+	doc.SetFont(pdfFont, "", 10)
+	doc.CellFormat(95, 6, "Số hóa đơn: "+code, "", 0, "L", false, 0, "")
+	doc.CellFormat(95, 6, "Ngày: "+pdfDate(invoice.GetString("issued_at"), invoice.GetString("created")), "", 1, "R", false, 0, "")
+	s.customerBlock(doc, booking)
+
+	// Line items
+	doc.Ln(4)
+	widths := []float64{76, 20, 30, 14, 20, 30}
+	doc.SetFont(pdfFont, "B", 9)
+	doc.SetFillColor(235, 240, 250)
+	for i, title := range []string{"Hạng mục", "SL", "Đơn giá", "VAT", "Giảm", "Thành tiền"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		doc.CellFormat(widths[i], 7, title, "1", 0, align, true, 0, "")
+	}
+	doc.Ln(-1)
+	doc.SetFont(pdfFont, "", 9)
+	for _, item := range items {
+		discount := ""
+		if d := item.GetFloat("discount"); d > 0 {
+			discount = "-" + humanize.Commaf(d)
+		}
+		qty := humanize.Ftoa(item.GetFloat("quantity"))
+		if unit := item.GetString("unit"); unit != "" {
+			qty += " " + unit
+		}
+		cells := []string{
+			item.GetString("item_name"),
+			qty,
+			humanize.Commaf(item.GetFloat("unit_price")),
+			domain.VATLabel(item.GetString("vat_rate")),
+			discount,
+			humanize.Commaf(item.GetFloat("total")),
+		}
+		for i, text := range cells {
+			align := "R"
+			if i == 0 {
+				align = "L"
+				text = fitText(doc, text, widths[i]-2)
+			}
+			doc.CellFormat(widths[i], 7, text, "1", 0, align, false, 0, "")
+		}
+		doc.Ln(-1)
+	}
+
+	// Totals
+	doc.Ln(2)
+	total := func(label string, amount float64, bold bool) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		doc.SetFont(pdfFont, style, 10)
+		doc.SetX(110)
+		doc.CellFormat(50, 6, label, "", 0, "L", false, 0, "")
+		doc.CellFormat(30, 6, humanize.Commaf(amount)+" đ", "", 1, "R", false, 0, "")
+	}
+	total("Cộng tiền hàng:", invoice.GetFloat("subtotal"), false)
+	if d := invoice.GetFloat("discount"); d > 0 {
+		total("Giảm giá:", -d, false)
+	}
+	total("Thuế GTGT:", invoice.GetFloat("tax_total"), false)
+	total("Tổng thanh toán:", invoice.GetFloat("total_amount"), true)
+	if paid := invoice.GetFloat("amount_paid"); paid > 0 {
+		total("Đã thanh toán:", paid, false)
+		total("Còn lại:", max(invoice.GetFloat("total_amount")-paid, 0), false)
+	}
+
+	if domain.InvoiceOpen(invoice.GetString("status")) {
+		s.paymentQR(doc, brand, invoice)
+	}
+	if report != nil {
+		s.photos(doc, report)
+	}
+	s.signatures(doc, invoice, report)
+
+	return doc.bytes()
+}
+
+// JobReportPDF renders the latest completion report of a booking
+// (work done, photos, customer signature) without prices.
+func (s *PDFService) JobReportPDF(bookingID string) ([]byte, error) {
+	booking, err := s.app.FindRecordById("bookings", bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found")
+	}
+	report := s.latestReport(bookingID)
+	if report == nil {
+		return nil, fmt.Errorf("job report not found")
+	}
+
+	doc, err := s.newDoc()
+	if err != nil {
+		return nil, err
+	}
+	defer doc.fs.Close()
+
+	s.header(doc, s.brand(), "BIÊN BẢN HOÀN THÀNH CÔNG VIỆC")
+	doc.SetFont(pdfFont, "", 10)
+	doc.CellFormat(0, 6, "Ngày: "+pdfDate(report.GetString("created"), ""), "", 1, "R", false, 0, "")
+	s.customerBlock(doc, booking)
+
+	if tech, err := s.app.FindRecordById("technicians", booking.GetString("technician_id")); err == nil {
+		doc.CellFormat(0, 6, "Kỹ thuật viên: "+tech.GetString("name"), "", 1, "L", false, 0, "")
+	}
+
+	if notes := report.GetString("photo_notes"); notes != "" {
+		doc.Ln(3)
+		doc.SetFont(pdfFont, "B", 10)
+		doc.CellFormat(0, 6, "Ghi chú kỹ thuật", "", 1, "L", false, 0, "")
+		doc.SetFont(pdfFont, "", 10)
+		doc.MultiCell(0, 5, notes, "", "L", false)
+	}
+
+	parts, _ := s.app.FindRecordsByFilter("job_parts", "job_report_id = {:id}", "", 0, 0, map[string]any{"id": report.Id})
+	if len(parts) > 0 {
+		doc.Ln(3)
+		doc.SetFont(pdfFont, "B", 10)
+		doc.CellFormat(0, 6, "Vật tư sử dụng", "", 1, "L", false, 0, "")
+		doc.SetFont(pdfFont, "", 10)
+		for _, part := range parts {
+			name, unit := "Vật tư không tên", ""
+			if item, err := s.app.FindRecordById("inventory_items", part.GetString("item_id")); err == nil {
+				name, unit = item.GetString("name"), item.GetString("unit")
+			}
+			doc.CellFormat(0, 5, fmt.Sprintf("- %s: %s %s", name, humanize.Ftoa(part.GetFloat("quantity")), unit), "", 1, "L", false, 0, "")
+		}
+	}
+
+	s.photos(doc, report)
+	s.signatures(doc, nil, report)
+
+	return doc.bytes()
+}
+
+func (s *PDFService) newDoc() (*pdfDoc, error) {
+	fs, err := s.app.NewFilesystem()
+	if err != nil {
+		return nil, err
+	}
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetFontLocation(s.fontDir)
+	pdf.AddUTF8Font(pdfFont, "", "DejaVuSansCondensed.ttf")
+	pdf.AddUTF8Font(pdfFont, "B", "DejaVuSansCondensed-Bold.ttf")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+	if err := pdf.Error(); err != nil {
+		fs.Close()
+		return nil, fmt.Errorf("pdf fonts: %w", err)
+	}
+	return &pdfDoc{Fpdf: pdf, fs: fs}, nil
+}
+
+func (d *pdfDoc) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// header prints the brand logo and contact lines followed by the document title
+func (s *PDFService) header(doc *pdfDoc, brand *core.Record, title string) {
+	top := doc.GetY()
+	textX := 10.0
+	if brand != nil {
+		if logo := brand.GetString("logo"); logo != "" && s.image(doc, brand, logo, 10, top, 0, 18) {
+			textX = 50
+		}
+		doc.SetXY(textX, top)
+		doc.SetFont(pdfFont, "B", 13)
+		doc.CellFormat(0, 7, brand.GetString("company_name"), "", 1, "L", false, 0, "")
+		doc.SetFont(pdfFont, "", 9)
+		for _, line := range []string{brand.GetString("address"), brand.GetString("hotline"), brand.GetString("email")} {
+			if line != "" {
+				doc.SetX(textX)
+				doc.CellFormat(0, 4.5, line, "", 1, "L", false, 0, "")
+			}
+		}
+	}
+	if doc.GetY() < top+20 {
+		doc.SetY(top + 20)
+	}
+	doc.Ln(3)
+	doc.SetFont(pdfFont, "B", 16)
+	doc.CellFormat(0, 9, title, "", 1, "C", false, 0, "")
+	doc.Ln(2)
+}
+
+func (s *PDFService) customerBlock(doc *pdfDoc, booking *core.Record) {
+	doc.SetFont(pdfFont, "", 10)
+	doc.CellFormat(0, 6, "Khách hàng: "+booking.GetString("customer_name")+" - "+booking.GetString("customer_phone"), "", 1, "L", false, 0, "")
+	if address := booking.GetString("address"); address != "" {
+		doc.MultiCell(0, 5, "Địa chỉ: "+address, "", "L", false)
+	}
+}
+
+// paymentQR prints the VietQR transfer code of the brand account next to
+// the bank details (the details alone when the QR cannot be built).
+func (s *PDFService) paymentQR(doc *pdfDoc, brand *core.Record, invoice *core.Record) {
+	if brand == nil || brand.GetString("bank_account") == "" {
+		return
+	}
+	if doc.GetY() > 230 {
+		doc.AddPage()
+	}
+	doc.Ln(4)
+	doc.SetFont(pdfFont, "B", 10)
+	doc.CellFormat(0, 6, "Thanh toán chuyển khoản", "", 1, "L", false, 0, "")
+	top := doc.GetY()
+
+	qr := InvoiceQR(brand, invoice)
+	textX := 10.0
+	if data, err := VietQRPNG(qr, 512); err == nil && doc.registerImage("vietqr", data) {
+		doc.ImageOptions("vietqr", 10, top, 40, 40, false, fpdf.ImageOptions{}, 0, "")
+		textX = 55
+	}
+	doc.SetFont(pdfFont, "", 10)
+	for _, line := range []string{
+		"Chủ tài khoản: " + brand.GetString("bank_owner"),
+		"Số tài khoản: " + brand.GetString("bank_account"),
+		"Nội dung: " + domain.VietQRMemo(qr.Memo),
+	} {
+		doc.SetX(textX)
+		doc.CellFormat(0, 6, line, "", 1, "L", false, 0, "")
+	}
+	if textX > 10 {
+		doc.SetY(top + 42)
+	}
+}
+
+// photos prints the before/after pictures of the report, four per row
+func (s *PDFService) photos(doc *pdfDoc, report *core.Record) {
+	for _, group := range []struct{ field, title string }{
+		{"before_images", "Ảnh trước khi làm"},
+		{"after_images", "Ảnh sau khi làm"},
+	} {
+		names := report.GetStringSlice(group.field)
+		if len(names) == 0 {
+			continue
+		}
+		if doc.GetY() > 230 {
+			doc.AddPage()
+		}
+		doc.Ln(4)
+		doc.SetFont(pdfFont, "B", 10)
+		doc.CellFormat(0, 6, group.title, "", 1, "L", false, 0, "")
+
+		const size, gap = 44.0, 3.5
+		col := 0
+		for _, name := range names {
+			if col == 0 && doc.GetY()+size > 280 {
+				doc.AddPage()
+			}
+			if s.image(doc, report, name, 10+float64(col)*(size+gap), doc.GetY(), size, size) {
+				col++
+			}
+			if col == 4 {
+				doc.SetY(doc.GetY() + size + gap)
+				col = 0
+			}
+		}
+		if col > 0 {
+			doc.SetY(doc.GetY() + size + gap)
+		}
+	}
+}
+
+// signatures prints the technician and customer signatures side by side
+func (s *PDFService) signatures(doc *pdfDoc, invoice, report *core.Record) {
+	if doc.GetY() > 240 {
+		doc.AddPage()
+	}
+	doc.Ln(6)
+	top := doc.GetY()
+	doc.SetFont(pdfFont, "B", 10)
+	doc.CellFormat(95, 6, "Kỹ thuật viên", "", 0, "C", false, 0, "")
+	doc.CellFormat(95, 6, "Khách hàng", "", 1, "C", false, 0, "")
+
+	signature := func(x float64, field string) {
+		for _, record := range []*core.Record{invoice, report} {
+			if record == nil || record.Collection().Fields.GetByName(field) == nil {
+				continue
+			}
+			if name := record.GetString(field); name != "" && s.image(doc, record, name, x, top+7, 0, 25) {
+				return
+			}
+		}
+	}
+	signature(30, "tech_signature")
+	signature(125, "customer_signature")
+	doc.SetY(top + 35)
+}
+
+// image draws a file of the record; w or h may be 0 to keep the aspect ratio.
+// Missing or undecodable files are skipped so one bad photo never fails the PDF.
+func (s *PDFService) image(doc *pdfDoc, record *core.Record, name string, x, y, w, h float64) bool {
+	key := record.BaseFilesPath() + "/" + name
+	reader, err := doc.fs.GetReader(key)
+	if err != nil {
+		fmt.Printf("⚠️ PDF: cannot open %s: %v\n", key, err)
+		return false
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || !doc.registerImage(key, data) {
+		return false
+	}
+	doc.ImageOptions(key, x, y, w, h, false, fpdf.ImageOptions{}, 0, "")
+	return true
+}
+
+// registerImage adds an image under name; formats fpdf cannot embed (webp)
+// are converted to PNG first.
+func (d *pdfDoc) registerImage(name string, data []byte) bool {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	switch format {
+	case "jpeg", "png", "gif":
+	default:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return false
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return false
+		}
+		data, format = buf.Bytes(), "png"
+	}
+	info := d.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: format}, bytes.NewReader(data))
+	if d.Err() {
+		// A broken image leaves fpdf in error state; drop it and keep going
+		fmt.Printf("⚠️ PDF: cannot embed %s: %v\n", filepath.Base(name), d.Error())
+		d.ClearError()
+		return false
+	}
+	return info != nil
+}
+
+func (s *PDFService) latestReport(bookingID string) *core.Record {
+	reports, err := s.app.FindRecordsByFilter("job_reports", "booking_id = {:id}", "-created", 1, 0, map[string]any{"id": bookingID})
+	if err != nil || len(reports) == 0 {
+		return nil
+	}
+	return reports[0]
+}
+
+func (s *PDFService) brand() *core.Record {
+	settings, err := s.app.FindRecordsByFilter("settings", "", "-created", 1, 0, nil)
+	if err != nil || len(settings) == 0 {
+		return nil
+	}
+	return settings[0]
+}
+
+// fitText shortens text with an ellipsis so it stays inside one table cell
+func fitText(doc *pdfDoc, text string, width float64) string {
+	if doc.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && doc.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// pdfDate formats a stored timestamp as dd/mm/yyyy in Vietnam time
+func pdfDate(values ...string) string {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.000Z", "2006-01-02 15:04:05Z"} {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t.In(time.FixedZone("ICT", 7*60*60)).Format("02/01/2006")
+			}
+		}
+	}
+	return time.Now().Format("02/01/2006")
+}
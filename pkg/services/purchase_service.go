@@ -0,0 +1,613 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// PurchaseService handles suppliers, purchase orders, goods receipts and
+// supplier payables. Goods receipts post into the main warehouse through the
+// stock ledger, in the same transaction as the receipt.
+type PurchaseService struct {
+	app core.App
+}
+
+// NewPurchaseService creates a new purchasing service
+func NewPurchaseService(app core.App) *PurchaseService {
+	return &PurchaseService{app: app}
+}
+
+// ============================================
+// SUPPLIERS
+// ============================================
+
+func supplierFromRecord(r *core.Record) *domain.Supplier {
+	return &domain.Supplier{
+		ID:           r.Id,
+		Name:         r.GetString("name"),
+		TaxCode:      r.GetString("tax_code"),
+		ContactName:  r.GetString("contact_name"),
+		Phone:        r.GetString("phone"),
+		Email:        r.GetString("email"),
+		Address:      r.GetString("address"),
+		PaymentTerms: r.GetInt("payment_terms"),
+		Note:         r.GetString("note"),
+		Active:       r.GetBool("active"),
+	}
+}
+
+// Suppliers lists the directory by name
+func (s *PurchaseService) Suppliers(activeOnly bool) ([]*domain.Supplier, error) {
+	filter := ""
+	if activeOnly {
+		filter = "active = true"
+	}
+	records, err := s.app.FindRecordsByFilter("suppliers", filter, "name", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	suppliers := make([]*domain.Supplier, 0, len(records))
+	for _, r := range records {
+		suppliers = append(suppliers, supplierFromRecord(r))
+	}
+	return suppliers, nil
+}
+
+// GetSupplier returns one supplier
+func (s *PurchaseService) GetSupplier(id string) (*domain.Supplier, error) {
+	r, err := s.app.FindRecordById("suppliers", id)
+	if err != nil {
+		return nil, err
+	}
+	return supplierFromRecord(r), nil
+}
+
+// SaveSupplier creates the supplier, or updates it when ID is set
+func (s *PurchaseService) SaveSupplier(supplier *domain.Supplier) error {
+	if err := supplier.Validate(); err != nil {
+		return err
+	}
+	var record *core.Record
+	if supplier.ID != "" {
+		var err error
+		if record, err = s.app.FindRecordById("suppliers", supplier.ID); err != nil {
+			return err
+		}
+	} else {
+		collection, err := s.app.FindCollectionByNameOrId("suppliers")
+		if err != nil {
+			return err
+		}
+		record = core.NewRecord(collection)
+	}
+	record.Set("name", supplier.Name)
+	record.Set("tax_code", supplier.TaxCode)
+	record.Set("contact_name", supplier.ContactName)
+	record.Set("phone", supplier.Phone)
+	record.Set("email", supplier.Email)
+	record.Set("address", supplier.Address)
+	record.Set("payment_terms", supplier.PaymentTerms)
+	record.Set("note", supplier.Note)
+	record.Set("active", supplier.Active)
+	if err := s.app.Save(record); err != nil {
+		return err
+	}
+	supplier.ID = record.Id
+	return nil
+}
+
+// SetPreferredSupplier picks the supplier low-stock drafts order the item from
+func (s *PurchaseService) SetPreferredSupplier(itemID, supplierID string) error {
+	item, err := s.app.FindRecordById("inventory_items", itemID)
+	if err != nil {
+		return err
+	}
+	if supplierID != "" {
+		if _, err := s.app.FindRecordById("suppliers", supplierID); err != nil {
+			return err
+		}
+	}
+	item.Set("preferred_supplier_id", supplierID)
+	return s.app.Save(item)
+}
+
+// supplierNames maps supplier IDs to names for display
+func (s *PurchaseService) supplierNames() map[string]string {
+	names := map[string]string{}
+	records, _ := s.app.FindAllRecords("suppliers")
+	for _, r := range records {
+		names[r.Id] = r.GetString("name")
+	}
+	return names
+}
+
+// ============================================
+// PURCHASE ORDERS
+// ============================================
+
+func purchaseOrderFromRecord(r *core.Record) *domain.PurchaseOrder {
+	po := &domain.PurchaseOrder{
+		ID:           r.Id,
+		Code:         r.GetString("code"),
+		SupplierID:   r.GetString("supplier_id"),
+		Status:       r.GetString("status"),
+		ExpectedDate: r.GetString("expected_date"),
+		Note:         r.GetString("note"),
+		CreatedBy:    r.GetString("created_by"),
+		OrderedAt:    r.GetString("ordered_at"),
+		Created:      r.GetString("created"),
+	}
+	_ = r.UnmarshalJSONField("lines", &po.Lines)
+	return po
+}
+
+// PurchaseOrders lists orders, newest first; status "" lists them all and
+// "open" those still waiting for goods
+func (s *PurchaseService) PurchaseOrders(status string) ([]*domain.PurchaseOrder, error) {
+	filter, params := "", dbx.Params{}
+	switch status {
+	case "":
+	case "open":
+		filter = "status = {:ordered} || status = {:partial}"
+		params = dbx.Params{"ordered": domain.POOrdered, "partial": domain.POPartial}
+	default:
+		filter = "status = {:status}"
+		params = dbx.Params{"status": status}
+	}
+	records, err := s.app.FindRecordsByFilter("purchase_orders", filter, "-created", 0, 0, params)
+	if err != nil {
+		return nil, err
+	}
+	names := s.supplierNames()
+	orders := make([]*domain.PurchaseOrder, 0, len(records))
+	for _, r := range records {
+		po := purchaseOrderFromRecord(r)
+		po.SupplierName = names[po.SupplierID]
+		orders = append(orders, po)
+	}
+	return orders, nil
+}
+
+// GetPurchaseOrder returns one order
+func (s *PurchaseService) GetPurchaseOrder(id string) (*domain.PurchaseOrder, error) {
+	r, err := s.app.FindRecordById("purchase_orders", id)
+	if err != nil {
+		return nil, err
+	}
+	po := purchaseOrderFromRecord(r)
+	if supplier, err := s.app.FindRecordById("suppliers", po.SupplierID); err == nil {
+		po.SupplierName = supplier.GetString("name")
+	}
+	return po, nil
+}
+
+// CreatePurchaseOrder saves a new draft. Item names and units are copied
+// from the catalog; a line without a price takes the last import price.
+func (s *PurchaseService) CreatePurchaseOrder(po *domain.PurchaseOrder, adminID string) error {
+	po.Status = domain.PODraft
+	po.CreatedBy = adminID
+	return s.app.RunInTransaction(func(txApp core.App) error {
+		return createPurchaseOrder(txApp, po)
+	})
+}
+
+func createPurchaseOrder(txApp core.App, po *domain.PurchaseOrder) error {
+	if _, err := txApp.FindRecordById("suppliers", po.SupplierID); err != nil {
+		return domain.ErrInvalidPurchaseOrder
+	}
+	for i := range po.Lines {
+		item, err := txApp.FindRecordById("inventory_items", po.Lines[i].ItemID)
+		if err != nil {
+			return domain.ErrInvalidPurchaseOrder
+		}
+		po.Lines[i].ItemName = item.GetString("name")
+		po.Lines[i].Unit = item.GetString("unit")
+		if po.Lines[i].UnitPrice == 0 {
+			po.Lines[i].UnitPrice = item.GetFloat("price_import")
+		}
+	}
+	if err := po.Validate(); err != nil {
+		return err
+	}
+
+	code, err := nextPurchaseCode(txApp, "purchase_orders", domain.PurchasePrefix)
+	if err != nil {
+		return err
+	}
+	collection, err := txApp.FindCollectionByNameOrId("purchase_orders")
+	if err != nil {
+		return err
+	}
+	record := core.NewRecord(collection)
+	po.Code = code
+	setPurchaseOrder(record, po)
+	record.Set("code", po.Code)
+	record.Set("supplier_id", po.SupplierID)
+	record.Set("created_by", po.CreatedBy)
+	if err := txApp.Save(record); err != nil {
+		return err
+	}
+	po.ID = record.Id
+	po.Created = record.GetString("created")
+	return nil
+}
+
+// setPurchaseOrder copies the mutable fields of the order onto its record
+func setPurchaseOrder(record *core.Record, po *domain.PurchaseOrder) {
+	record.Set("status", po.Status)
+	record.Set("expected_date", po.ExpectedDate)
+	record.Set("note", po.Note)
+	record.Set("lines", po.Lines)
+	record.Set("total", po.Total())
+	record.Set("ordered_at", po.OrderedAt)
+}
+
+// nextPurchaseCode numbers purchasing documents per year: PO-2026-00001...
+// Must run inside a transaction.
+func nextPurchaseCode(txApp core.App, collection, prefix string) (string, error) {
+	year := domain.FiscalYear(time.Now())
+	start := fmt.Sprintf("%s-%d-", prefix, year)
+	last, err := txApp.FindRecordsByFilter(collection, "code ~ {:prefix}", "-code", 1, 0, dbx.Params{"prefix": start + "%"})
+	if err != nil {
+		return "", err
+	}
+	seq := 1
+	if len(last) > 0 {
+		n, err := strconv.Atoi(strings.TrimPrefix(last[0].GetString("code"), start))
+		if err != nil {
+			return "", fmt.Errorf("unexpected document code %q", last[0].GetString("code"))
+		}
+		seq = n + 1
+	}
+	return domain.FormatPurchaseCode(prefix, year, seq), nil
+}
+
+// updatePurchaseOrder loads the order, applies change and saves it
+func (s *PurchaseService) updatePurchaseOrder(id string, change func(po *domain.PurchaseOrder) error) (*domain.PurchaseOrder, error) {
+	var po *domain.PurchaseOrder
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindRecordById("purchase_orders", id)
+		if err != nil {
+			return err
+		}
+		po = purchaseOrderFromRecord(record)
+		if err := change(po); err != nil {
+			return err
+		}
+		setPurchaseOrder(record, po)
+		return txApp.Save(record)
+	})
+	return po, err
+}
+
+// MarkOrdered records that the draft was sent to the supplier
+func (s *PurchaseService) MarkOrdered(id string) (*domain.PurchaseOrder, error) {
+	return s.updatePurchaseOrder(id, func(po *domain.PurchaseOrder) error {
+		return po.MarkOrdered(time.Now())
+	})
+}
+
+// CancelPurchaseOrder closes the order; deliveries already posted stay
+func (s *PurchaseService) CancelPurchaseOrder(id string) (*domain.PurchaseOrder, error) {
+	return s.updatePurchaseOrder(id, func(po *domain.PurchaseOrder) error {
+		return po.Cancel()
+	})
+}
+
+// ============================================
+// GOODS RECEIPTS
+// ============================================
+
+// ReceiveGoods posts a (partial) delivery of the order into the main
+// warehouse: the receipt, the order's received quantities, one import
+// movement per line and the items' import price change together or not at
+// all. A line without a price is received at the ordered price.
+func (s *PurchaseService) ReceiveGoods(poID string, lines []domain.ReceiptLine, note, adminID string) (*domain.GoodsReceipt, error) {
+	var receipt *domain.GoodsReceipt
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindRecordById("purchase_orders", poID)
+		if err != nil {
+			return err
+		}
+		po := purchaseOrderFromRecord(record)
+
+		ordered := make(map[string]domain.PurchaseLine, len(po.Lines))
+		for _, l := range po.Lines {
+			ordered[l.ItemID] = l
+		}
+		received := make([]domain.ReceiptLine, 0, len(lines))
+		for _, l := range lines {
+			if l.Quantity == 0 {
+				continue
+			}
+			if o, ok := ordered[l.ItemID]; ok {
+				l.ItemName, l.Unit = o.ItemName, o.Unit
+				if l.UnitPrice == 0 {
+					l.UnitPrice = o.UnitPrice
+				}
+			}
+			received = append(received, l)
+		}
+		if err := po.Receive(received); err != nil {
+			return err
+		}
+		setPurchaseOrder(record, po)
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+
+		code, err := nextPurchaseCode(txApp, "goods_receipts", domain.ReceiptPrefix)
+		if err != nil {
+			return err
+		}
+		receipt = &domain.GoodsReceipt{
+			Code: code, POID: po.ID, POCode: po.Code, SupplierID: po.SupplierID,
+			Lines: received, Note: note, ReceivedBy: adminID,
+		}
+		// Stock first: the receipt keeps the serials / lots as the ledger cleaned them
+		for i, l := range received {
+			m := &domain.StockMovement{
+				Type: domain.MoveImport, ToID: domain.StockMain, ItemID: l.ItemID, Quantity: l.Quantity,
+				UnitCost: l.UnitPrice, Lots: l.Lots, Note: fmt.Sprintf("%s (%s)", receipt.Code, po.Code), CreatedBy: adminID,
+			}
+			if err := moveStock(txApp, m); err != nil {
+				return err
+			}
+			received[i].Lots = m.Lots
+			if _, err := txApp.DB().Update("inventory_items",
+				dbx.Params{"price_import": l.UnitPrice}, dbx.HashExp{"id": l.ItemID}).Execute(); err != nil {
+				return err
+			}
+		}
+
+		collection, err := txApp.FindCollectionByNameOrId("goods_receipts")
+		if err != nil {
+			return err
+		}
+		rr := core.NewRecord(collection)
+		rr.Set("code", receipt.Code)
+		rr.Set("po_id", receipt.POID)
+		rr.Set("supplier_id", receipt.SupplierID)
+		rr.Set("lines", receipt.Lines)
+		rr.Set("total", receipt.Total())
+		rr.Set("note", receipt.Note)
+		rr.Set("received_by", receipt.ReceivedBy)
+		if err := txApp.Save(rr); err != nil {
+			return err
+		}
+		receipt.ID = rr.Id
+		receipt.Created = rr.GetString("created")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// Receipts lists the deliveries of an order, oldest first
+func (s *PurchaseService) Receipts(poID string) ([]*domain.GoodsReceipt, error) {
+	records, err := s.app.FindRecordsByFilter("goods_receipts", "po_id = {:po}", "created", 0, 0, dbx.Params{"po": poID})
+	if err != nil {
+		return nil, err
+	}
+	receipts := make([]*domain.GoodsReceipt, 0, len(records))
+	for _, r := range records {
+		receipt := &domain.GoodsReceipt{
+			ID:         r.Id,
+			Code:       r.GetString("code"),
+			POID:       r.GetString("po_id"),
+			SupplierID: r.GetString("supplier_id"),
+			Note:       r.GetString("note"),
+			ReceivedBy: r.GetString("received_by"),
+			Created:    r.GetString("created"),
+		}
+		_ = r.UnmarshalJSONField("lines", &receipt.Lines)
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+// ============================================
+// SUPPLIER INVOICES & PAYABLES
+// ============================================
+
+func supplierInvoiceFromRecord(r *core.Record) *domain.SupplierInvoice {
+	return &domain.SupplierInvoice{
+		ID:          r.Id,
+		SupplierID:  r.GetString("supplier_id"),
+		POID:        r.GetString("po_id"),
+		InvoiceNo:   r.GetString("invoice_no"),
+		InvoiceDate: r.GetString("invoice_date"),
+		DueDate:     r.GetString("due_date"),
+		Amount:      r.GetFloat("amount"),
+		Paid:        r.GetFloat("paid"),
+		Status:      r.GetString("status"),
+		Note:        r.GetString("note"),
+		CreatedBy:   r.GetString("created_by"),
+		Created:     r.GetString("created"),
+	}
+}
+
+// CreateSupplierInvoice records a bill from a supplier. Without a due date it
+// is due after the supplier's payment terms.
+func (s *PurchaseService) CreateSupplierInvoice(inv *domain.SupplierInvoice, adminID string) error {
+	if err := inv.Validate(); err != nil {
+		return err
+	}
+	supplier, err := s.GetSupplier(inv.SupplierID)
+	if err != nil {
+		return domain.ErrInvalidSupplierInvoice
+	}
+	if inv.DueDate == "" {
+		inv.DueDate = domain.DueDate(inv.InvoiceDate, supplier.PaymentTerms)
+	}
+	inv.SupplierName = supplier.Name
+	inv.Paid = 0
+	inv.Status = domain.PayableStatus(inv.Amount, 0)
+	inv.CreatedBy = adminID
+
+	collection, err := s.app.FindCollectionByNameOrId("supplier_invoices")
+	if err != nil {
+		return err
+	}
+	record := core.NewRecord(collection)
+	record.Set("supplier_id", inv.SupplierID)
+	record.Set("po_id", inv.POID)
+	record.Set("invoice_no", inv.InvoiceNo)
+	record.Set("invoice_date", inv.InvoiceDate)
+	record.Set("due_date", inv.DueDate)
+	record.Set("amount", inv.Amount)
+	record.Set("paid", inv.Paid)
+	record.Set("status", inv.Status)
+	record.Set("note", inv.Note)
+	record.Set("created_by", inv.CreatedBy)
+	if err := s.app.Save(record); err != nil {
+		return err
+	}
+	inv.ID = record.Id
+	return nil
+}
+
+// PaySupplierInvoice records a payment to the supplier against an invoice
+func (s *PurchaseService) PaySupplierInvoice(invoiceID string, amount float64, method, reference, note, adminID string) (*domain.SupplierInvoice, error) {
+	var inv *domain.SupplierInvoice
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindRecordById("supplier_invoices", invoiceID)
+		if err != nil {
+			return err
+		}
+		inv = supplierInvoiceFromRecord(record)
+		if err := inv.Pay(amount); err != nil {
+			return err
+		}
+		record.Set("paid", inv.Paid)
+		record.Set("status", inv.Status)
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+
+		collection, err := txApp.FindCollectionByNameOrId("supplier_payments")
+		if err != nil {
+			return err
+		}
+		payment := core.NewRecord(collection)
+		payment.Set("invoice_id", inv.ID)
+		payment.Set("supplier_id", inv.SupplierID)
+		payment.Set("amount", amount)
+		payment.Set("method", method)
+		payment.Set("reference", reference)
+		payment.Set("note", note)
+		payment.Set("created_by", adminID)
+		return txApp.Save(payment)
+	})
+	return inv, err
+}
+
+// SupplierInvoices lists invoices, newest first, optionally of one supplier
+// or of one order
+func (s *PurchaseService) SupplierInvoices(supplierID, poID string) ([]*domain.SupplierInvoice, error) {
+	var conditions []string
+	if supplierID != "" {
+		conditions = append(conditions, "supplier_id = {:supplier}")
+	}
+	if poID != "" {
+		conditions = append(conditions, "po_id = {:po}")
+	}
+	records, err := s.app.FindRecordsByFilter("supplier_invoices", strings.Join(conditions, " && "), "-invoice_date,-created", 0, 0,
+		dbx.Params{"supplier": supplierID, "po": poID})
+	if err != nil {
+		return nil, err
+	}
+	names := s.supplierNames()
+	invoices := make([]*domain.SupplierInvoice, 0, len(records))
+	for _, r := range records {
+		inv := supplierInvoiceFromRecord(r)
+		inv.SupplierName = names[inv.SupplierID]
+		invoices = append(invoices, inv)
+	}
+	return invoices, nil
+}
+
+// Payables totals what is owed per supplier
+func (s *PurchaseService) Payables() ([]*domain.Payable, error) {
+	invoices, err := s.SupplierInvoices("", "")
+	if err != nil {
+		return nil, err
+	}
+	return domain.SummarizePayables(invoices, time.Now()), nil
+}
+
+// ============================================
+// REORDERING
+// ============================================
+
+// onOrder sums what open orders still have to deliver, per item
+func (s *PurchaseService) onOrder() (map[string]float64, error) {
+	open, err := s.PurchaseOrders("open")
+	if err != nil {
+		return nil, err
+	}
+	drafts, err := s.PurchaseOrders(domain.PODraft)
+	if err != nil {
+		return nil, err
+	}
+	qty := map[string]float64{}
+	for _, po := range append(open, drafts...) {
+		for i := range po.Lines {
+			qty[po.Lines[i].ItemID] += po.Lines[i].Remaining()
+		}
+	}
+	return qty, nil
+}
+
+// DraftFromLowStock turns low-stock alerts into one draft order per preferred
+// supplier. Quantities already on draft or open orders are deducted, so
+// running it twice does not order twice. Items without a preferred supplier
+// are returned for the admin to handle.
+func (s *PurchaseService) DraftFromLowStock(alerts []LowStockAlert, adminID string) ([]*domain.PurchaseOrder, []domain.ReorderCandidate, error) {
+	onOrder, err := s.onOrder()
+	if err != nil {
+		return nil, nil, err
+	}
+	candidates := make([]domain.ReorderCandidate, 0, len(alerts))
+	for _, a := range alerts {
+		candidates = append(candidates, domain.ReorderCandidate{
+			ItemID:       a.Product.ID,
+			ItemName:     a.Product.Name,
+			Unit:         a.Product.Unit,
+			SupplierID:   a.Product.PreferredSupplierID,
+			Stock:        a.MainStock,
+			MinThreshold: a.Product.MinThreshold,
+			OnOrder:      onOrder[a.Product.ID],
+			UnitPrice:    a.Product.PriceImport,
+		})
+	}
+
+	orders, unassigned := domain.DraftPurchaseOrders(candidates)
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		for _, po := range orders {
+			po.CreatedBy = adminID
+			po.Note = "Tạo từ cảnh báo tồn kho thấp"
+			if err := createPurchaseOrder(txApp, po); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	names := s.supplierNames()
+	for _, po := range orders {
+		po.SupplierName = names[po.SupplierID]
+	}
+	return orders, unassigned, nil
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"time"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Cost layers (stock_cost_layers) hold what each receipt cost, per location.
+// Every stock movement draws from or opens layers and records the unit cost
+// it moved at on the ledger, so the ledger alone values the stock at any
+// date. inventory_items.price_import stays the last purchase price: the cost
+// of stock no layer covers.
+
+// costingMethod reads settings.inventory_costing
+func costingMethod(app core.App) string {
+	settings, err := app.FindRecordsByFilter("settings", "", "-created", 1, 0, nil)
+	if err != nil || len(settings) == 0 {
+		return domain.CostingAverage
+	}
+	return domain.NormalizeCostingMethod(settings[0].GetString("inventory_costing"))
+}
+
+// costMovement draws the outgoing side from its layers and opens layers on the
+// incoming side, then sets m.UnitCost. An incoming movement from outside the
+// stock keeps the UnitCost it was given (the receipt price), or takes the
+// item's last purchase price. Must run inside a transaction.
+func costMovement(txApp core.App, m *domain.StockMovement) error {
+	item, err := txApp.FindRecordById("inventory_items", m.ItemID)
+	if err != nil {
+		return err
+	}
+	method := costingMethod(txApp)
+	fallback := item.GetFloat("price_import")
+	now := time.Now().UTC().Format(domain.DateTimeLayout)
+
+	var draws []domain.LayerDraw
+	drawn := false
+	for _, d := range m.Deltas() {
+		if d.Quantity >= 0 {
+			continue
+		}
+		layers, err := loadCostLayers(txApp, d.Key)
+		if err != nil {
+			return err
+		}
+		draws = domain.DrawLayers(layers, -d.Quantity, method, fallback)
+		for _, draw := range draws {
+			if draw.Layer == nil {
+				continue
+			}
+			if _, err := txApp.DB().Update("stock_cost_layers",
+				dbx.Params{"remaining": draw.Layer.Remaining}, dbx.HashExp{"id": draw.Layer.ID}).Execute(); err != nil {
+				return err
+			}
+		}
+		m.UnitCost = domain.DrawCost(draws) / -d.Quantity
+		drawn = true
+	}
+
+	for _, d := range m.Deltas() {
+		if d.Quantity <= 0 {
+			continue
+		}
+		var incoming []domain.CostLayer
+		if drawn {
+			incoming = domain.TransferLayers(draws, method, now)
+		} else {
+			if m.UnitCost <= 0 {
+				m.UnitCost = fallback
+			}
+			incoming = []domain.CostLayer{{Quantity: d.Quantity, Remaining: d.Quantity, UnitCost: m.UnitCost, ReceivedAt: now}}
+		}
+		for _, layer := range incoming {
+			layer.ItemID, layer.Location = d.Key.ItemID, d.Key.Location
+			if err := saveCostLayer(txApp, &layer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadCostLayers returns the layers with stock left of an item at a location
+func loadCostLayers(txApp core.App, key domain.StockKey) ([]*domain.CostLayer, error) {
+	records, err := txApp.FindRecordsByFilter("stock_cost_layers",
+		"item_id = {:item} && location = {:location} && remaining > 0", "received_at,id", 0, 0,
+		dbx.Params{"item": key.ItemID, "location": key.Location})
+	if err != nil {
+		return nil, err
+	}
+	layers := make([]*domain.CostLayer, 0, len(records))
+	for _, r := range records {
+		layers = append(layers, &domain.CostLayer{
+			ID:         r.Id,
+			ItemID:     r.GetString("item_id"),
+			Location:   r.GetString("location"),
+			Quantity:   r.GetFloat("quantity"),
+			Remaining:  r.GetFloat("remaining"),
+			UnitCost:   r.GetFloat("unit_cost"),
+			ReceivedAt: r.GetString("received_at"),
+		})
+	}
+	return layers, nil
+}
+
+func saveCostLayer(txApp core.App, layer *domain.CostLayer) error {
+	collection, err := txApp.FindCollectionByNameOrId("stock_cost_layers")
+	if err != nil {
+		return err
+	}
+	record := core.NewRecord(collection)
+	record.Set("item_id", layer.ItemID)
+	record.Set("location", layer.Location)
+	record.Set("quantity", layer.Quantity)
+	record.Set("remaining", layer.Remaining)
+	record.Set("unit_cost", layer.UnitCost)
+	record.Set("received_at", layer.ReceivedAt)
+	if err := txApp.Save(record); err != nil {
+		return err
+	}
+	layer.ID = record.Id
+	return nil
+}
+
+// CostingMethod is the costing method in use (settings.inventory_costing)
+func (s *InventoryService) CostingMethod() string {
+	return costingMethod(s.app)
+}
+
+// CostLayers lists the open layers of an item, every location, oldest first
+func (s *InventoryService) CostLayers(itemID string) ([]*domain.CostLayer, error) {
+	records, err := s.app.FindRecordsByFilter("stock_cost_layers",
+		"item_id = {:item} && remaining > 0", "location,received_at,id", 0, 0, dbx.Params{"item": itemID})
+	if err != nil {
+		return nil, err
+	}
+	layers := make([]*domain.CostLayer, 0, len(records))
+	for _, r := range records {
+		layers = append(layers, &domain.CostLayer{
+			ID:         r.Id,
+			ItemID:     itemID,
+			Location:   r.GetString("location"),
+			Quantity:   r.GetFloat("quantity"),
+			Remaining:  r.GetFloat("remaining"),
+			UnitCost:   r.GetFloat("unit_cost"),
+			ReceivedAt: r.GetString("received_at"),
+		})
+	}
+	return layers, nil
+}
+
+// StockValuation values the main warehouse and every truck as of the end of
+// date (YYYY-MM-DD, Vietnam time) by replaying the ledger at cost
+func (s *InventoryService) StockValuation(date string) ([]domain.StockValue, error) {
+	cutoff, err := domain.ValuationCutoff(date)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.app.FindRecordsByFilter("stock_transfers", "created < {:cutoff}", "created,id", 0, 0,
+		dbx.Params{"cutoff": cutoff.UTC().Format(domain.DateTimeLayout)})
+	if err != nil {
+		return nil, err
+	}
+	movements := make([]*domain.StockMovement, 0, len(records))
+	for _, r := range records {
+		movements = append(movements, &domain.StockMovement{
+			Type:     r.GetString("transfer_type"),
+			FromID:   r.GetString("from_id"),
+			ToID:     r.GetString("to_id"),
+			ItemID:   r.GetString("item_id"),
+			Quantity: r.GetFloat("quantity"),
+			UnitCost: r.GetFloat("unit_cost"),
+		})
+	}
+	values := domain.ValueStock(movements)
+
+	items := map[string]*core.Record{}
+	if all, err := s.app.FindAllRecords("inventory_items"); err == nil {
+		for _, r := range all {
+			items[r.Id] = r
+		}
+	}
+	names := stockLocationNames(s.app)
+	for i := range values {
+		if item := items[values[i].ItemID]; item != nil {
+			values[i].ItemName = item.GetString("name")
+			values[i].Unit = item.GetString("unit")
+		}
+		values[i].LocationName = names[values[i].Location]
+	}
+	return values, nil
+}
+
+// stockLocationNames names the main warehouse and every truck by its technician
+func stockLocationNames(app core.App) map[string]string {
+	names := map[string]string{domain.StockMain: "Kho chính"}
+	if all, err := app.FindAllRecords("technicians"); err == nil {
+		for _, r := range all {
+			names[r.Id] = r.GetString("name")
+		}
+	}
+	return names
+}
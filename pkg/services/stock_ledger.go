@@ -0,0 +1,266 @@
+package services
+
+import (
+	"fmt"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// The stock ledger (stock_transfers) is the source of truth for quantities.
+// inventory_items.stock_quantity (main warehouse) and tech_inventory.quantity
+// (truck stock) are balances kept in step with it: every movement updates
+// them and appends to the ledger in the same transaction.
+
+// StockShortageError is returned when a movement would take a balance below zero
+type StockShortageError struct {
+	Location string // domain.StockMain or a technician ID
+	Need     float64
+	Have     float64
+}
+
+func (e *StockShortageError) Error() string {
+	if e.Location == domain.StockMain {
+		return fmt.Sprintf("kho chính không đủ hàng: cần %.1f, còn %.1f", e.Need, e.Have)
+	}
+	return fmt.Sprintf("kho xe không đủ: cần %.1f, còn %.1f", e.Need, e.Have)
+}
+
+func (e *StockShortageError) Unwrap() error { return domain.ErrInsufficientStock }
+
+// MoveStock executes the movements as one transaction: all of them or none
+func (s *InventoryService) MoveStock(movements ...*domain.StockMovement) error {
+	return s.app.RunInTransaction(func(txApp core.App) error {
+		for _, m := range movements {
+			if err := moveStock(txApp, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// moveStock updates the balances, cost layers and serial / lot numbers a
+// movement touches and appends it to the ledger. Must run inside a transaction.
+func moveStock(txApp core.App, m *domain.StockMovement) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	for _, d := range m.Deltas() {
+		if err := applyStockDelta(txApp, d); err != nil {
+			return err
+		}
+	}
+	if err := costMovement(txApp, m); err != nil {
+		return err
+	}
+	if err := trackMovement(txApp, m); err != nil {
+		return err
+	}
+
+	collection, err := txApp.FindCollectionByNameOrId("stock_transfers")
+	if err != nil {
+		return err
+	}
+	record := core.NewRecord(collection)
+	record.Set("transfer_type", m.Type)
+	record.Set("from_id", m.FromID)
+	record.Set("to_id", m.ToID)
+	record.Set("item_id", m.ItemID)
+	record.Set("quantity", m.Quantity)
+	record.Set("unit_cost", m.UnitCost)
+	record.Set("lots", m.Lots)
+	record.Set("note", m.Note)
+	record.Set("created_by", m.CreatedBy)
+	record.Set("job_id", m.JobID)
+	if err := txApp.Save(record); err != nil {
+		return fmt.Errorf("lỗi ghi sổ kho: %w", err)
+	}
+	m.ID = record.Id
+	m.Created = record.GetString("created")
+	return nil
+}
+
+// applyStockDelta changes one balance with a guarded UPDATE, so the check
+// "enough stock" and the write are a single statement on the row
+func applyStockDelta(txApp core.App, d domain.StockDelta) error {
+	change := dbx.Params{"delta": d.Quantity, "floor": -domain.StockEpsilon}
+
+	if d.Key.Location == domain.StockMain {
+		res, err := txApp.DB().Update("inventory_items",
+			dbx.Params{"stock_quantity": dbx.NewExp("stock_quantity + {:delta}", change)},
+			dbx.And(
+				dbx.HashExp{"id": d.Key.ItemID},
+				dbx.NewExp("stock_quantity + {:delta} >= {:floor}", change),
+			)).Execute()
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			return nil
+		}
+		item, err := txApp.FindRecordById("inventory_items", d.Key.ItemID)
+		if err != nil {
+			return fmt.Errorf("vật tư không tồn tại: %w", err)
+		}
+		return &StockShortageError{Location: domain.StockMain, Need: -d.Quantity, Have: item.GetFloat("stock_quantity")}
+	}
+
+	res, err := txApp.DB().Update("tech_inventory",
+		dbx.Params{"quantity": dbx.NewExp("quantity + {:delta}", change)},
+		dbx.And(
+			dbx.HashExp{"technician_id": d.Key.Location, "item_id": d.Key.ItemID},
+			dbx.NewExp("quantity + {:delta} >= {:floor}", change),
+		)).Execute()
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil
+	}
+
+	existing, _ := txApp.FindFirstRecordByFilter("tech_inventory", "technician_id = {:tech} && item_id = {:item}",
+		dbx.Params{"tech": d.Key.Location, "item": d.Key.ItemID})
+	if existing != nil || d.Quantity < 0 {
+		have := 0.0
+		if existing != nil {
+			have = existing.GetFloat("quantity")
+		}
+		return &StockShortageError{Location: d.Key.Location, Need: -d.Quantity, Have: have}
+	}
+
+	// First time this item goes on the truck
+	collection, err := txApp.FindCollectionByNameOrId("tech_inventory")
+	if err != nil {
+		return err
+	}
+	record := core.NewRecord(collection)
+	record.Set("technician_id", d.Key.Location)
+	record.Set("item_id", d.Key.ItemID)
+	record.Set("quantity", d.Quantity)
+	if err := txApp.Save(record); err != nil {
+		return fmt.Errorf("lỗi tạo kho thợ: %w", err)
+	}
+	return nil
+}
+
+// StockLedger returns every movement, oldest first
+func (s *InventoryService) StockLedger() ([]*domain.StockMovement, error) {
+	records, err := s.app.FindRecordsByFilter("stock_transfers", "", "created,id", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	movements := make([]*domain.StockMovement, 0, len(records))
+	for _, r := range records {
+		movements = append(movements, &domain.StockMovement{
+			ID:        r.Id,
+			Type:      r.GetString("transfer_type"),
+			FromID:    r.GetString("from_id"),
+			ToID:      r.GetString("to_id"),
+			ItemID:    r.GetString("item_id"),
+			Quantity:  r.GetFloat("quantity"),
+			UnitCost:  r.GetFloat("unit_cost"),
+			Note:      r.GetString("note"),
+			CreatedBy: r.GetString("created_by"),
+			JobID:     r.GetString("job_id"),
+			Created:   r.GetString("created"),
+		})
+	}
+	return movements, nil
+}
+
+// recordedStock reads the stored balances of the main warehouse and every truck
+func recordedStock(app core.App) (map[domain.StockKey]float64, map[string]string, error) {
+	items, err := app.FindAllRecords("inventory_items")
+	if err != nil {
+		return nil, nil, err
+	}
+	balances := make(map[domain.StockKey]float64, len(items))
+	names := make(map[string]string, len(items))
+	for _, item := range items {
+		names[item.Id] = item.GetString("name")
+		if qty := item.GetFloat("stock_quantity"); qty != 0 {
+			balances[domain.StockKey{ItemID: item.Id, Location: domain.StockMain}] = qty
+		}
+	}
+
+	trucks, err := app.FindAllRecords("tech_inventory")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, r := range trucks {
+		if qty := r.GetFloat("quantity"); qty != 0 {
+			balances[domain.StockKey{ItemID: r.GetString("item_id"), Location: r.GetString("technician_id")}] = qty
+		}
+	}
+	return balances, names, nil
+}
+
+// CheckStockLedger lists the stored balances that do not match the ledger
+func (s *InventoryService) CheckStockLedger() ([]domain.StockDiscrepancy, error) {
+	movements, err := s.StockLedger()
+	if err != nil {
+		return nil, err
+	}
+	recorded, names, err := recordedStock(s.app)
+	if err != nil {
+		return nil, err
+	}
+	diffs := domain.CompareStock(recorded, domain.StockBalances(movements))
+	for i := range diffs {
+		diffs[i].ItemName = names[diffs[i].ItemID]
+	}
+	return diffs, nil
+}
+
+// RebuildStockFromLedger overwrites the stored balances with the ledger
+// replay and returns how many were corrected
+func (s *InventoryService) RebuildStockFromLedger() (int, error) {
+	fixed := 0
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		fixed = 0
+		movements, err := (&InventoryService{app: txApp}).StockLedger()
+		if err != nil {
+			return err
+		}
+		recorded, _, err := recordedStock(txApp)
+		if err != nil {
+			return err
+		}
+		for _, d := range domain.CompareStock(recorded, domain.StockBalances(movements)) {
+			if err := setStockBalance(txApp, domain.StockKey{ItemID: d.ItemID, Location: d.Location}, d.Ledger); err != nil {
+				return err
+			}
+			fixed++
+		}
+		return nil
+	})
+	return fixed, err
+}
+
+func setStockBalance(txApp core.App, key domain.StockKey, qty float64) error {
+	if key.Location == domain.StockMain {
+		item, err := txApp.FindRecordById("inventory_items", key.ItemID)
+		if err != nil {
+			return err
+		}
+		item.Set("stock_quantity", qty)
+		return txApp.Save(item)
+	}
+
+	record, _ := txApp.FindFirstRecordByFilter("tech_inventory", "technician_id = {:tech} && item_id = {:item}",
+		dbx.Params{"tech": key.Location, "item": key.ItemID})
+	if record == nil {
+		collection, err := txApp.FindCollectionByNameOrId("tech_inventory")
+		if err != nil {
+			return err
+		}
+		record = core.NewRecord(collection)
+		record.Set("technician_id", key.Location)
+		record.Set("item_id", key.ItemID)
+	}
+	record.Set("quantity", qty)
+	return txApp.Save(record)
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Serial and lot numbers (stock_lots) follow the items whose
+// inventory_items.tracking is set. Receipts, transfers and consumption must
+// name the numbers they move; moveStock takes them out of the source
+// location and puts them into the destination in the same transaction as the
+// balances. A serial used on a job is marked installed and later linked to
+// the job part and the customer equipment it went into.
+
+// trackMovement checks the serial or lot numbers of a movement against the
+// item's tracking mode and moves them. Openings and manual corrections of a
+// tracked item may go without numbers; a stocktake names the numbers of its
+// variances (see Stocktake.CheckTracking) and the serials it finds missing
+// are marked lost. Must run inside a transaction.
+func trackMovement(txApp core.App, m *domain.StockMovement) error {
+	item, err := txApp.FindRecordById("inventory_items", m.ItemID)
+	if err != nil {
+		return err
+	}
+	mode := domain.NormalizeTrackingMode(item.GetString("tracking"))
+	if len(m.Lots) == 0 && (mode == "" || !m.RequiresTracking()) {
+		return nil
+	}
+	if m.Lots, err = domain.NormalizeLots(mode, m.Quantity, m.Lots); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(domain.DateTimeLayout)
+	taken := map[string]*core.Record{}
+	for _, d := range m.Deltas() {
+		if d.Quantity >= 0 {
+			continue
+		}
+		for _, lot := range m.Lots {
+			record, err := takeLot(txApp, d.Key, lot)
+			if err != nil {
+				return err
+			}
+			taken[lot.Number] = record
+		}
+	}
+
+	// Used on a job: the serials leave the stock as installed
+	if m.Type == domain.MoveTechToJob || m.Type == domain.MoveMainToJob {
+		if mode != domain.TrackSerial {
+			return nil
+		}
+		for _, record := range taken {
+			record.Set("location", "")
+			record.Set("status", domain.LotInstalled)
+			record.Set("job_id", m.JobID)
+			record.Set("installed_at", now)
+			if err := txApp.Save(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Missing at a count: the serials leave the stock as lost, where they were last
+	if m.Type == domain.MoveAdjustment && mode == domain.TrackSerial {
+		for _, record := range taken {
+			record.Set("status", domain.LotLost)
+			if err := txApp.Save(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, d := range m.Deltas() {
+		if d.Quantity <= 0 {
+			continue
+		}
+		for _, lot := range m.Lots {
+			if err := putLot(txApp, d.Key, mode, lot, taken[lot.Number], now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// takeLot takes the lot's quantity out of its entry at a location
+func takeLot(txApp core.App, key domain.StockKey, lot domain.LotAllocation) (*core.Record, error) {
+	record, err := txApp.FindFirstRecordByFilter("stock_lots",
+		"item_id = {:item} && number = {:number} && location = {:location} && status = {:status} && quantity >= {:qty}",
+		dbx.Params{"item": key.ItemID, "number": lot.Number, "location": key.Location, "status": domain.LotInStock,
+			"qty": lot.Quantity - domain.StockEpsilon})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrLotNotInStock, lot.Number)
+	}
+	record.Set("quantity", record.GetFloat("quantity")-lot.Quantity)
+	if err := txApp.Save(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// putLot adds the lot to a location. A serial moves its own entry (from is
+// where it was taken); a new serial must not be in stock already. A lot adds
+// to the entry of the same number at the location.
+func putLot(txApp core.App, key domain.StockKey, mode string, lot domain.LotAllocation, from *core.Record, now string) error {
+	params := dbx.Params{"item": key.ItemID, "number": lot.Number, "location": key.Location, "status": domain.LotInStock}
+	var record *core.Record
+	if mode == domain.TrackSerial {
+		if from == nil {
+			held, _ := txApp.FindFirstRecordByFilter("stock_lots",
+				"item_id = {:item} && number = {:number} && status = {:status} && quantity > 0", params)
+			if held != nil {
+				return fmt.Errorf("%w: %s", domain.ErrDuplicateSerial, lot.Number)
+			}
+		}
+		record = from
+	} else {
+		record, _ = txApp.FindFirstRecordByFilter("stock_lots",
+			"item_id = {:item} && number = {:number} && location = {:location} && status = {:status}", params)
+		if record != nil {
+			record.Set("quantity", record.GetFloat("quantity")+lot.Quantity)
+			return txApp.Save(record)
+		}
+	}
+
+	if record == nil {
+		collection, err := txApp.FindCollectionByNameOrId("stock_lots")
+		if err != nil {
+			return err
+		}
+		record = core.NewRecord(collection)
+		record.Set("item_id", key.ItemID)
+		record.Set("number", lot.Number)
+		record.Set("status", domain.LotInStock)
+		record.Set("received_at", now)
+		if from != nil {
+			record.Set("received_at", from.GetString("received_at"))
+		}
+	}
+	record.Set("location", key.Location)
+	record.Set("quantity", lot.Quantity)
+	return txApp.Save(record)
+}
+
+// linkInstalledSerials ties the serials installed on a job to the job part
+// they were used as and, when known, the customer equipment they went into.
+// Must run inside a transaction.
+func linkInstalledSerials(txApp core.App, itemID, jobID string, lots []domain.LotAllocation, reportID, partID, equipmentID string) error {
+	for _, lot := range lots {
+		if _, err := txApp.DB().Update("stock_lots",
+			dbx.Params{"job_report_id": reportID, "job_part_id": partID, "equipment_id": equipmentID},
+			dbx.HashExp{"item_id": itemID, "number": lot.Number, "status": domain.LotInstalled, "job_id": jobID},
+		).Execute(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func trackedLotFromRecord(r *core.Record) domain.TrackedLot {
+	return domain.TrackedLot{
+		ID:          r.Id,
+		ItemID:      r.GetString("item_id"),
+		Number:      r.GetString("number"),
+		Location:    r.GetString("location"),
+		Quantity:    r.GetFloat("quantity"),
+		Status:      r.GetString("status"),
+		ReceivedAt:  r.GetString("received_at"),
+		JobID:       r.GetString("job_id"),
+		JobReportID: r.GetString("job_report_id"),
+		EquipmentID: r.GetString("equipment_id"),
+		InstalledAt: r.GetString("installed_at"),
+	}
+}
+
+// StockLots lists the serials and lots in stock at a location, by item
+func (s *InventoryService) StockLots(location string) (map[string][]domain.TrackedLot, error) {
+	records, err := s.app.FindRecordsByFilter("stock_lots",
+		"location = {:location} && status = {:status} && quantity > 0", "received_at,number", 0, 0,
+		dbx.Params{"location": location, "status": domain.LotInStock})
+	if err != nil {
+		return nil, err
+	}
+	lots := map[string][]domain.TrackedLot{}
+	for _, r := range records {
+		lot := trackedLotFromRecord(r)
+		lots[lot.ItemID] = append(lots[lot.ItemID], lot)
+	}
+	return lots, nil
+}
+
+// TraceLots finds where a serial or lot number is now, or which customer
+// equipment it was installed in
+func (s *InventoryService) TraceLots(number string) ([]domain.TrackedLot, error) {
+	records, err := s.app.FindRecordsByFilter("stock_lots", "number ~ {:number}", "number,-updated", 100, 0,
+		dbx.Params{"number": number})
+	if err != nil {
+		return nil, err
+	}
+	return s.describeLots(records), nil
+}
+
+// EquipmentLots lists the serials installed in a customer's equipment
+func (s *InventoryService) EquipmentLots(equipmentID string) ([]domain.TrackedLot, error) {
+	records, err := s.app.FindRecordsByFilter("stock_lots", "equipment_id = {:equipment}", "-installed_at", 0, 0,
+		dbx.Params{"equipment": equipmentID})
+	if err != nil {
+		return nil, err
+	}
+	return s.describeLots(records), nil
+}
+
+// describeLots adds the item and location names, and the equipment and
+// customer of installed serials
+func (s *InventoryService) describeLots(records []*core.Record) []domain.TrackedLot {
+	names := stockLocationNames(s.app)
+	lots := make([]domain.TrackedLot, 0, len(records))
+	for _, r := range records {
+		lot := trackedLotFromRecord(r)
+		if item, err := s.app.FindRecordById("inventory_items", lot.ItemID); err == nil {
+			lot.ItemName = item.GetString("name")
+			lot.Tracking = item.GetString("tracking")
+		}
+		lot.LocationName = names[lot.Location]
+		if lot.EquipmentID != "" {
+			if eq, err := s.app.FindRecordById("equipment", lot.EquipmentID); err == nil {
+				unit := domain.Equipment{UnitType: eq.GetString("unit_type"), Brand: eq.GetString("brand"),
+					Model: eq.GetString("model"), CapacityHP: eq.GetFloat("capacity_hp"), Location: eq.GetString("location")}
+				lot.EquipmentName = unit.Label()
+				lot.CustomerID = eq.GetString("customer_id")
+			}
+			if customer, err := s.app.FindRecordById("customers", lot.CustomerID); err == nil {
+				lot.CustomerName = customer.GetString("name")
+			}
+		}
+		lots = append(lots, lot)
+	}
+	return lots
+}
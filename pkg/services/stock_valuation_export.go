@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var stockValueHeader = []string{"Ngày", "Kho", "Mã vật tư", "Tên vật tư", "ĐVT", "Số lượng", "Đơn giá", "Giá trị"}
+
+var locationValueHeader = []string{"Ngày", "Kho", "Số mặt hàng", "Giá trị"}
+
+func stockValueRow(date string, v domain.StockValue) []interface{} {
+	return []interface{}{date, v.LocationName, v.ItemID, v.ItemName, v.Unit, v.Quantity, v.UnitCost(), v.Value}
+}
+
+// StockValuationCSV writes one row per item and warehouse
+func StockValuationCSV(date string, lines []domain.StockValue) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(stockValueHeader); err != nil {
+		return nil, err
+	}
+	for _, v := range lines {
+		if err := w.Write([]string{
+			date, v.LocationName, v.ItemID, v.ItemName, v.Unit,
+			fmt.Sprintf("%g", v.Quantity), fmt.Sprintf("%.0f", v.UnitCost()), fmt.Sprintf("%.0f", v.Value),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// StockValuationXLSX builds a workbook with the totals per warehouse and the
+// item lines
+func StockValuationXLSX(date string, lines []domain.StockValue) ([]byte, error) {
+	book := excelize.NewFile()
+	defer book.Close()
+
+	const summary, detail = "Theo kho", "Chi tiết"
+	book.SetSheetName(book.GetSheetName(0), summary)
+	if _, err := book.NewSheet(detail); err != nil {
+		return nil, err
+	}
+
+	money, err := book.NewStyle(&excelize.Style{CustomNumFmt: strPtr("#,##0")})
+	if err != nil {
+		return nil, err
+	}
+	bold, err := book.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]interface{}{toRow(locationValueHeader)}
+	total := 0.0
+	for _, l := range domain.ValueByLocation(lines) {
+		rows = append(rows, []interface{}{date, l.Name, l.Items, l.Value})
+		total += l.Value
+	}
+	rows = append(rows, []interface{}{"", "Tổng", "", total})
+	if err := writeSheet(book, summary, rows); err != nil {
+		return nil, err
+	}
+	book.SetCellStyle(summary, "A1", "D1", bold)
+	book.SetCellStyle(summary, fmt.Sprintf("A%d", len(rows)), fmt.Sprintf("D%d", len(rows)), bold)
+	book.SetCellStyle(summary, "D2", fmt.Sprintf("D%d", len(rows)), money)
+	book.SetColWidth(summary, "B", "B", 28)
+	book.SetColWidth(summary, "D", "D", 18)
+
+	rows = [][]interface{}{toRow(stockValueHeader)}
+	for _, v := range lines {
+		rows = append(rows, stockValueRow(date, v))
+	}
+	if err := writeSheet(book, detail, rows); err != nil {
+		return nil, err
+	}
+	book.SetCellStyle(detail, "A1", "H1", bold)
+	book.SetCellStyle(detail, "G2", fmt.Sprintf("H%d", len(rows)), money)
+	book.SetColWidth(detail, "B", "B", 24)
+	book.SetColWidth(detail, "D", "D", 32)
+	book.SetColWidth(detail, "G", "H", 16)
+
+	buf, err := book.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
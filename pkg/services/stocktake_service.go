@@ -0,0 +1,371 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	domain "hvac-system/internal/core"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// StocktakeService runs stocktakes (kiểm kê) of the main warehouse and the
+// trucks. Starting one freezes the balances and their cost; the counts are
+// compared with that snapshot and the approved variances are posted to the
+// stock ledger as adjustments.
+type StocktakeService struct {
+	app core.App
+}
+
+// NewStocktakeService creates a new stocktake service
+func NewStocktakeService(app core.App) *StocktakeService {
+	return &StocktakeService{app: app}
+}
+
+func stocktakeFromRecord(r *core.Record) *domain.Stocktake {
+	s := &domain.Stocktake{
+		ID:          r.Id,
+		Code:        r.GetString("code"),
+		Location:    r.GetString("location"),
+		Status:      r.GetString("status"),
+		Note:        r.GetString("note"),
+		CreatedBy:   r.GetString("created_by"),
+		CountedBy:   r.GetString("counted_by"),
+		SubmittedAt: r.GetString("submitted_at"),
+		ApprovedBy:  r.GetString("approved_by"),
+		ApprovedAt:  r.GetString("approved_at"),
+		Created:     r.GetString("created"),
+	}
+	_ = r.UnmarshalJSONField("lines", &s.Lines)
+	return s
+}
+
+// setStocktake copies the mutable fields of the stocktake onto its record
+func setStocktake(record *core.Record, s *domain.Stocktake) {
+	record.Set("status", s.Status)
+	record.Set("note", s.Note)
+	record.Set("lines", s.Lines)
+	record.Set("shortage", s.Shortage())
+	record.Set("surplus", s.Surplus())
+	record.Set("counted_by", s.CountedBy)
+	record.Set("submitted_at", s.SubmittedAt)
+	record.Set("approved_by", s.ApprovedBy)
+	record.Set("approved_at", s.ApprovedAt)
+}
+
+// Stocktakes lists stocktakes, newest first. location "" lists every
+// location; status "" every status and "open" those not yet approved.
+func (s *StocktakeService) Stocktakes(location, status string) ([]*domain.Stocktake, error) {
+	var filters []string
+	params := dbx.Params{}
+	if location != "" {
+		filters = append(filters, "location = {:location}")
+		params["location"] = location
+	}
+	switch status {
+	case "":
+	case "open":
+		filters = append(filters, "(status = {:counting} || status = {:submitted})")
+		params["counting"], params["submitted"] = domain.StocktakeCounting, domain.StocktakeSubmitted
+	default:
+		filters = append(filters, "status = {:status}")
+		params["status"] = status
+	}
+	records, err := s.app.FindRecordsByFilter("stocktakes", strings.Join(filters, " && "), "-created", 0, 0, params)
+	if err != nil {
+		return nil, err
+	}
+	names := stockLocationNames(s.app)
+	takes := make([]*domain.Stocktake, 0, len(records))
+	for _, r := range records {
+		st := stocktakeFromRecord(r)
+		st.LocationName = names[st.Location]
+		takes = append(takes, st)
+	}
+	return takes, nil
+}
+
+// GetStocktake returns one stocktake. While open, its expected balances are
+// the book stock now, the variances approval would post, and its lines carry
+// the current tracking mode of their items.
+func (s *StocktakeService) GetStocktake(id string) (*domain.Stocktake, error) {
+	r, err := s.app.FindRecordById("stocktakes", id)
+	if err != nil {
+		return nil, err
+	}
+	st := stocktakeFromRecord(r)
+	if st.Open() {
+		moved, err := ledgerSince(s.app, st.Location, st.Created)
+		if err != nil {
+			return nil, err
+		}
+		st.Rebase(moved)
+		lineTracking(s.app, st)
+	}
+	st.LocationName = stockLocationNames(s.app)[st.Location]
+	return st, nil
+}
+
+// OpenStocktake returns the stocktake in progress at a location, or nil
+func (s *StocktakeService) OpenStocktake(location string) (*domain.Stocktake, error) {
+	takes, err := s.Stocktakes(location, "open")
+	if err != nil || len(takes) == 0 {
+		return nil, err
+	}
+	return takes[0], nil
+}
+
+// StartStocktake snapshots the balances of a location with their average
+// cost. Only one stocktake per location can be in progress.
+func (s *StocktakeService) StartStocktake(location, note, createdBy string) (*domain.Stocktake, error) {
+	st := &domain.Stocktake{Location: location, Status: domain.StocktakeCounting, Note: note, CreatedBy: createdBy}
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		open, err := txApp.FindRecordsByFilter("stocktakes",
+			"location = {:location} && (status = {:counting} || status = {:submitted})", "", 1, 0,
+			dbx.Params{"location": location, "counting": domain.StocktakeCounting, "submitted": domain.StocktakeSubmitted})
+		if err != nil {
+			return err
+		}
+		if len(open) > 0 {
+			return domain.ErrStocktakeOpen
+		}
+
+		if st.Lines, err = snapshotStock(txApp, location); err != nil {
+			return err
+		}
+		if err := st.Validate(); err != nil {
+			return err
+		}
+		if st.Code, err = nextPurchaseCode(txApp, "stocktakes", domain.StocktakePrefix); err != nil {
+			return err
+		}
+		collection, err := txApp.FindCollectionByNameOrId("stocktakes")
+		if err != nil {
+			return err
+		}
+		record := core.NewRecord(collection)
+		setStocktake(record, st)
+		record.Set("code", st.Code)
+		record.Set("location", st.Location)
+		record.Set("created_by", st.CreatedBy)
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+		st.ID = record.Id
+		st.Created = record.GetString("created")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	st.LocationName = stockLocationNames(s.app)[location]
+	return st, nil
+}
+
+// snapshotStock lists every active item of the main warehouse, or every
+// item on the truck, with its balance and average cost now
+func snapshotStock(txApp core.App, location string) ([]domain.StocktakeLine, error) {
+	balances := map[string]float64{}
+	var items []*core.Record
+	if location == domain.StockMain {
+		records, err := txApp.FindRecordsByFilter("inventory_items", "is_active = true", "name", 0, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			balances[r.Id] = r.GetFloat("stock_quantity")
+		}
+		items = records
+	} else {
+		if _, err := txApp.FindRecordById("technicians", location); err != nil {
+			return nil, domain.ErrInvalidStocktake
+		}
+		rows, err := txApp.FindRecordsByFilter("tech_inventory", "technician_id = {:tech}", "", 0, 0, dbx.Params{"tech": location})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			item, err := txApp.FindRecordById("inventory_items", r.GetString("item_id"))
+			if err != nil {
+				continue
+			}
+			balances[item.Id] = r.GetFloat("quantity")
+			items = append(items, item)
+		}
+	}
+
+	lines := make([]domain.StocktakeLine, 0, len(items))
+	for _, item := range items {
+		line, err := stocktakeLine(txApp, location, item, balances[item.Id])
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func stocktakeLine(txApp core.App, location string, item *core.Record, expected float64) (domain.StocktakeLine, error) {
+	layers, err := loadCostLayers(txApp, domain.StockKey{ItemID: item.Id, Location: location})
+	if err != nil {
+		return domain.StocktakeLine{}, err
+	}
+	return domain.StocktakeLine{
+		ItemID:   item.Id,
+		ItemName: item.GetString("name"),
+		Unit:     item.GetString("unit"),
+		Expected: expected,
+		UnitCost: domain.AverageCost(layers, item.GetFloat("price_import")),
+		Tracking: domain.NormalizeTrackingMode(item.GetString("tracking")),
+	}, nil
+}
+
+// lineTracking sets the lines to the tracking mode their items have now; an
+// item may have become tracked since the snapshot
+func lineTracking(app core.App, st *domain.Stocktake) {
+	for i := range st.Lines {
+		if item, err := app.FindRecordById("inventory_items", st.Lines[i].ItemID); err == nil {
+			st.Lines[i].Tracking = domain.NormalizeTrackingMode(item.GetString("tracking"))
+		}
+	}
+}
+
+// updateStocktake loads the stocktake, applies change and saves it
+func (s *StocktakeService) updateStocktake(id string, change func(txApp core.App, st *domain.Stocktake) error) (*domain.Stocktake, error) {
+	var st *domain.Stocktake
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindRecordById("stocktakes", id)
+		if err != nil {
+			return err
+		}
+		st = stocktakeFromRecord(record)
+		if err := change(txApp, st); err != nil {
+			return err
+		}
+		setStocktake(record, st)
+		return txApp.Save(record)
+	})
+	return st, err
+}
+
+// Count records counted quantities by item ID, and the serial / lot numbers
+// of the variances of tracked items (nil when the counter gives none)
+func (s *StocktakeService) Count(id string, counts map[string]float64, lots map[string][]domain.LotAllocation, by string) (*domain.Stocktake, error) {
+	return s.updateStocktake(id, func(txApp core.App, st *domain.Stocktake) error {
+		for itemID, qty := range counts {
+			if err := st.Count(itemID, qty, by); err != nil {
+				return err
+			}
+		}
+		for itemID, numbers := range lots {
+			if err := st.SetLots(itemID, numbers); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddItem lists an item the snapshot missed, expected at its balance at the
+// snapshot time like the other lines
+func (s *StocktakeService) AddItem(id, itemID string) (*domain.Stocktake, error) {
+	return s.updateStocktake(id, func(txApp core.App, st *domain.Stocktake) error {
+		item, err := txApp.FindRecordById("inventory_items", itemID)
+		if err != nil {
+			return domain.ErrInvalidCount
+		}
+		expected := item.GetFloat("stock_quantity")
+		if st.Location != domain.StockMain {
+			expected = 0
+			row, _ := txApp.FindFirstRecordByFilter("tech_inventory", "technician_id = {:tech} && item_id = {:item}",
+				dbx.Params{"tech": st.Location, "item": itemID})
+			if row != nil {
+				expected = row.GetFloat("quantity")
+			}
+		}
+		moved, err := ledgerSince(txApp, st.Location, st.Created)
+		if err != nil {
+			return err
+		}
+		line, err := stocktakeLine(txApp, st.Location, item, expected-moved[itemID])
+		if err != nil {
+			return err
+		}
+		return st.AddLine(line)
+	})
+}
+
+// Submit hands the counts in for approval
+func (s *StocktakeService) Submit(id, by string) (*domain.Stocktake, error) {
+	return s.updateStocktake(id, func(txApp core.App, st *domain.Stocktake) error {
+		st.CountedBy = by
+		return st.Submit(time.Now().UTC().Format(domain.DateTimeLayout))
+	})
+}
+
+// Approve posts one adjustment per variance to the stock ledger, in the same
+// transaction as the approval. The counts are compared with the snapshot
+// moved forward by the ledger since, so stock used or transferred during the
+// count is not taken out a second time. Variances of tracked items move the
+// serial / lot numbers recorded with the count.
+func (s *StocktakeService) Approve(id, adminID string) (*domain.Stocktake, error) {
+	return s.updateStocktake(id, func(txApp core.App, st *domain.Stocktake) error {
+		if err := st.Approve(adminID, time.Now().UTC().Format(domain.DateTimeLayout)); err != nil {
+			return err
+		}
+		moved, err := ledgerSince(txApp, st.Location, st.Created)
+		if err != nil {
+			return err
+		}
+		st.Rebase(moved)
+		lineTracking(txApp, st)
+		if err := st.CheckTracking(); err != nil {
+			return err
+		}
+		for _, m := range st.Adjustments(adminID) {
+			if err := moveStock(txApp, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ledgerSince nets the ledger movements of each item at a location from the
+// time since (a stocktake's snapshot) on
+func ledgerSince(txApp core.App, location, since string) (map[string]float64, error) {
+	records, err := txApp.FindRecordsByFilter("stock_transfers",
+		"created >= {:since} && (from_id = {:location} || to_id = {:location})", "", 0, 0,
+		dbx.Params{"since": since, "location": location})
+	if err != nil {
+		return nil, err
+	}
+	moved := map[string]float64{}
+	for _, r := range records {
+		m := &domain.StockMovement{FromID: r.GetString("from_id"), ToID: r.GetString("to_id"),
+			ItemID: r.GetString("item_id"), Quantity: r.GetFloat("quantity")}
+		for _, d := range m.Deltas() {
+			if d.Key.Location == location {
+				moved[d.Key.ItemID] += d.Quantity
+			}
+		}
+	}
+	return moved, nil
+}
+
+// Cancel drops a stocktake without touching the stock
+func (s *StocktakeService) Cancel(id string) (*domain.Stocktake, error) {
+	return s.updateStocktake(id, func(txApp core.App, st *domain.Stocktake) error {
+		return st.Cancel()
+	})
+}
+
+// History sums the approved stocktakes per location
+func (s *StocktakeService) History() ([]domain.StocktakeHistory, error) {
+	takes, err := s.Stocktakes("", domain.StocktakeApproved)
+	if err != nil {
+		return nil, err
+	}
+	return domain.SummarizeStocktakes(takes), nil
+}
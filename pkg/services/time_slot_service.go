@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -15,14 +16,20 @@ type TimeSlotService struct {
 	app         core.App
 	techRepo    domain.TechnicianRepository
 	bookingRepo domain.BookingRepository
+	serviceRepo domain.ServiceRepository   // [NEW] Job durations
+	brandRepo   domain.BrandRepository     // [NEW] Travel buffer per brand
+	schedule    domain.TechScheduleService // [NEW] Working hours & time off
 }
 
 // NewTimeSlotService creates a new time slot service
-func NewTimeSlotService(app core.App, techRepo domain.TechnicianRepository, bookingRepo domain.BookingRepository) *TimeSlotService {
+func NewTimeSlotService(app core.App, techRepo domain.TechnicianRepository, bookingRepo domain.BookingRepository, serviceRepo domain.ServiceRepository, brandRepo domain.BrandRepository, schedule domain.TechScheduleService) *TimeSlotService {
 	return &TimeSlotService{
 		app:         app,
 		techRepo:    techRepo,
 		bookingRepo: bookingRepo,
+		serviceRepo: serviceRepo,
+		brandRepo:   brandRepo,
+		schedule:    schedule,
 	}
 }
 
@@ -38,252 +45,39 @@ type TimeSlot struct {
 	Status          string // available, limited, waitlist, full
 }
 
-// TimeBlock represents a busy period for a technician
-type TimeBlock struct {
-	Start time.Time
-	End   time.Time
-}
-
 // GetAvailableSlots returns available time slots using Dynamic Availability
-// Formula: Available = TotalActiveTechs - (AssignedJobs + StuckJobs)
+// Formula: Available = FreeTechs - OverlappingUnassignedJobs
+// Without a requested service the default job duration is used.
 func (s *TimeSlotService) GetAvailableSlots(date string) ([]TimeSlot, error) {
-	// Standard slot duration (could be dynamic based on selected service in future)
-	const StandardSlotDuration = 120 * time.Minute // 2 hours
-	const TravelBuffer = 30 * time.Minute
-
-	// Validate date format
-	targetDate, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		return nil, fmt.Errorf("invalid date format: %w", err)
-	}
-
-	// Don't allow booking in the past
-	if targetDate.Before(time.Now().Truncate(24 * time.Hour)) {
-		return []TimeSlot{}, nil
-	}
-
-	// 1. Get Resources (Total Active Technicians)
-	dynamicCapacity, err := s.techRepo.CountActive()
+	activeTechs, err := s.techRepo.GetAvailable()
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch active technicians: %w", err)
 	}
-	if dynamicCapacity == 0 {
-		return []TimeSlot{}, nil // No techs = no slots
-	}
-
-	// 2. Get Constraints (All Bookings for the Date)
-	bookings, err := s.bookingRepo.FindAllByDate(date)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch bookings: %w", err)
-	}
-
-	// 3. Build Tech Timelines (Map[TechID] -> BusyBlocks)
-	techSchedules := make(map[string][]TimeBlock)
-	var unassignedBlocks []TimeBlock // [Fix] Track unassigned bookings
-
-	fmt.Printf("[DEBUG] Date: %s | Total Bookings Found: %d\n", date, len(bookings))
-
-	for _, b := range bookings {
-		techID := b.TechnicianID
-		// if techID == "" { continue } // REMOVED: Don't skip unassigned
-
-		startTimeStr := b.BookingTime // "2006-01-02 15:04"
-		// Try parsing with flexible formats
-		var startT time.Time
-		var err error
-
-		formats := []string{"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02 15:04:05.000Z", time.RFC3339}
-		parsed := false
-		for _, f := range formats {
-			startT, err = time.Parse(f, startTimeStr)
-			if err == nil {
-				parsed = true
-				break
-			}
-		}
-
-		if parsed {
-			// Estimate duration (default 60m + travel 30m)
-			// Ideally we fetch service specific duration, for now allow standard block
-			duration := 60 * time.Minute
-
-			// [TODO] If ServiceID is available, fetch duration
-			// For MVP, we use standard block or existing logic if available
-
-			endT := startT.Add(duration).Add(TravelBuffer)
-
-			// [NEW] Overrun Detection (Stuck Job)
-			if b.JobStatus == "working" && endT.Before(time.Now()) {
-				estimatedEnd := time.Now().Add(30 * time.Minute)
-				if estimatedEnd.After(endT) {
-					endT = estimatedEnd
-				}
-			}
-
-			if techID != "" {
-				techSchedules[techID] = append(techSchedules[techID], TimeBlock{
-					Start: startT,
-					End:   endT,
-				})
-			} else {
-				// Add to unassigned pool
-				unassignedBlocks = append(unassignedBlocks, TimeBlock{
-					Start: startT,
-					End:   endT,
-				})
-				fmt.Printf("[DEBUG] Unassigned Block Added: %s - %s\n", startT.Format("15:04"), endT.Format("15:04"))
-			}
-		} else {
-			fmt.Printf("[DEBUG] Failed to parse booking time: '%s'\n", startTimeStr)
-		}
-	}
-
-	// 4. Fetch Standard Slot Definitions (The "Grid")
-	// We still use the "time_slots" collection to define the grid (8-10, 10-12...)
-	filter := fmt.Sprintf("date = '%s'", date)
-	records, err := s.app.FindRecordsByFilter("time_slots", filter, "start_time", 100, 0, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch slot definitions: %w", err)
-	}
-
-	// [AUTO-GENERATE] If no slots exist for this date, create them on-the-fly
-	if len(records) == 0 {
-		// Only auto-generate for future dates (not past)
-		if !targetDate.Before(time.Now().Truncate(24 * time.Hour)) {
-			if err := s.GenerateDefaultSlots(date, dynamicCapacity); err == nil {
-				// Re-fetch after generation
-				records, _ = s.app.FindRecordsByFilter("time_slots", filter, "start_time", 100, 0, nil)
-			}
-		}
-	}
-
-	slots := make([]TimeSlot, 0, len(records))
-	now := time.Now()
-
-	for _, record := range records {
-		startTimeStr := record.GetString("start_time")
-		slotStart, _ := time.Parse("2006-01-02 15:04", date+" "+startTimeStr)
-		slotEnd := slotStart.Add(StandardSlotDuration)
-
-		// Filter past slots (2h advance notice)
-		if date == now.Format("2006-01-02") {
-			if slotStart.Sub(now).Hours() < 2 {
-				continue
-			}
-		}
-
-		// 5. Evaluate Availability: Count how many techs are free for this slot
-		availableTechsCount := 0
-
-		// Use CountActive() implicitly by assuming specific Tech IDs aren't needed here,
-		// but we do need to iterate actual Tech IDs if we built specific schedules.
-		// Wait, CountActive returns int. We need the list of Active Techs to check their specific schedules?
-		// YES.
-		// Retrying fetching active techs list for iteration
-		// Optimally this should be cached or passed from handler, but repo query is fast enough (sqlite/pb)
-		activeTechs, _ := s.techRepo.GetAvailable() // Re-using GetAvailable which returns []*Technician
-
-		for _, tech := range activeTechs {
-			techID := tech.ID
-			timeline := techSchedules[techID]
-			isFree := true
-
-			// Check intersection with any busy block
-			for _, block := range timeline {
-				// Slot [Start, End] overlaps Block [Start, End]?
-				// Allow TravelBuffer logic inside Block definition
-				if slotStart.Before(block.End) && slotEnd.After(block.Start) {
-					isFree = false
-					break
-				}
-			}
-
-			if isFree {
-				availableTechsCount++
-			}
-		}
-
-		// [Fix] Subtract unassigned bookings that overlap with this slot
-		unassignedOverlaps := 0
-		for _, block := range unassignedBlocks {
-			// Slot [Start, End] overlaps Block [Start, End]?
-			if slotStart.Before(block.End) && slotEnd.After(block.Start) {
-				unassignedOverlaps++
-			}
-		}
-
-		// Net Availability
-		availableTechsCount = availableTechsCount - unassignedOverlaps
-		if availableTechsCount < 0 {
-			availableTechsCount = 0
-		}
-
-		// 6. Determine Status & Availability
-		// available: > 2
-		// limited: 1-2
-		// waitlist: 0 (but allow +2 overbooking)
-		// full: 0 (and waitlist full)
-
-		status := "full"
-		isAvailable := false
-
-		// Occupied slots (approximate based on dynamic calculation)
-		currentOccupancy := dynamicCapacity - availableTechsCount
-
-		if availableTechsCount >= 2 {
-			status = "available"
-			isAvailable = true
-		} else if availableTechsCount > 0 {
-			status = "limited"
-			isAvailable = true
-		} else {
-			// Waitlist logic: Allow 2 extra bookings beyond capacity
-			// We need to check exact booking count for this slot to see if waitlist is full
-			// For now, let's approximate: Check strictly assigned jobs + 2
-			// But we don't track "Waitlist" in DB yet explicitly beyond booking records.
-			// Getting total bookings for this slot specifically from DB would be accurate.
-			// Using 'record.CurrentBookings' from 'time_slots' collection is the persistent counter.
-			persistentBookings := int(record.GetFloat("current_bookings"))
-
-			if persistentBookings < dynamicCapacity+2 {
-				status = "waitlist"
-				isAvailable = true
-			} else {
-				status = "full"
-				isAvailable = false
-			}
-		}
-
-		slots = append(slots, TimeSlot{
-			ID:              record.Id,
-			Date:            record.GetString("date"),
-			StartTime:       startTimeStr,
-			EndTime:         record.GetString("end_time"),
-			MaxCapacity:     dynamicCapacity,
-			CurrentBookings: currentOccupancy,
-			IsAvailable:     isAvailable,
-			Status:          status,
-		})
-	}
-
-	return slots, nil
+	return s.computeSlots(date, activeTechs, "", "")
 }
 
 // GetAvailableSlotsWithFilters returns available time slots filtered by customer zone and service skill
 // This enables "Smart Booking" - only show slots where qualified techs are available
 func (s *TimeSlotService) GetAvailableSlotsWithFilters(date string, customerZone string, serviceID string) ([]TimeSlot, error) {
-	const StandardSlotDuration = 120 * time.Minute
-	const TravelBuffer = 30 * time.Minute
-
-	// Validate date
-	targetDate, err := time.Parse("2006-01-02", date)
+	eligibleTechs, err := s.eligibleTechs(date, customerZone, serviceID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid date format: %w", err)
+		return nil, err
 	}
-	if targetDate.Before(time.Now().Truncate(24 * time.Hour)) {
-		return []TimeSlot{}, nil
+	return s.computeSlots(date, eligibleTechs, serviceID, "")
+}
+
+// GetAvailableSlotsForBooking returns the slots an existing booking can be
+// moved to (customer reschedule). The booking itself is not counted as busy.
+func (s *TimeSlotService) GetAvailableSlotsForBooking(date string, booking *domain.Booking) ([]TimeSlot, error) {
+	eligibleTechs, err := s.eligibleTechs(date, "", booking.ServiceID)
+	if err != nil {
+		return nil, err
 	}
+	return s.computeSlots(date, eligibleTechs, booking.ServiceID, booking.ID)
+}
 
+// eligibleTechs returns active techs having the service's required skill and covering the zone
+func (s *TimeSlotService) eligibleTechs(date string, customerZone string, serviceID string) ([]*domain.Technician, error) {
 	// Get required skill from service (if any)
 	requiredSkill := ""
 	if serviceID != "" {
@@ -317,103 +111,145 @@ func (s *TimeSlotService) GetAvailableSlotsWithFilters(date string, customerZone
 		eligibleTechs = append(eligibleTechs, tech)
 	}
 
-	dynamicCapacity := len(eligibleTechs)
 	fmt.Printf("[SMART_BOOKING] Date=%s | Eligible Techs: %d/%d (Zone=%s, Skill=%s)\n",
-		date, dynamicCapacity, len(allTechs), customerZone, requiredSkill)
+		date, len(eligibleTechs), len(allTechs), customerZone, requiredSkill)
+	return eligibleTechs, nil
+}
 
-	if dynamicCapacity == 0 {
+// computeSlots evaluates the slot grid of a date against the given technicians.
+// A tech counts as free for a slot only if the whole requested job
+// (service duration) fits inside their working hours, between their other
+// jobs including the brand's travel buffer, and before the last slot closes.
+func (s *TimeSlotService) computeSlots(date string, candidates []*domain.Technician, serviceID string, excludeBookingID string) ([]TimeSlot, error) {
+	// Validate date format
+	targetDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format: %w", err)
+	}
+
+	// Don't allow booking in the past
+	if targetDate.Before(time.Now().Truncate(24 * time.Hour)) {
 		return []TimeSlot{}, nil
 	}
 
-	// Build schedules for eligible techs only
-	bookings, _ := s.bookingRepo.FindAllByDate(date)
-	techSchedules := make(map[string][]TimeBlock)
-	var unassignedBlocks []TimeBlock
+	// Techs on leave / day off are not part of the capacity at all
+	techs := make([]*domain.Technician, 0, len(candidates))
+	daySchedules := make(map[string]*domain.DaySchedule, len(candidates))
+	for _, tech := range candidates {
+		if s.schedule != nil {
+			day, err := s.schedule.DaySchedule(tech.ID, date)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch technician schedule: %w", err)
+			}
+			if day.Off() {
+				continue
+			}
+			daySchedules[tech.ID] = day
+		}
+		techs = append(techs, tech)
+	}
+
+	dynamicCapacity := len(techs)
+	if dynamicCapacity == 0 {
+		return []TimeSlot{}, nil // No techs = no slots
+	}
+
+	durations := make(map[string]time.Duration)
+	jobDuration := s.serviceDuration(serviceID, durations)
+	buffer := s.travelBuffer()
+
+	// 1. Build Tech Timelines (Map[TechID] -> BusyBlocks incl. travel buffer)
+	bookings, err := s.bookingRepo.FindAllByDate(date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bookings: %w", err)
+	}
+
+	techSchedules := make(map[string][]domain.TimeBlock)
+	var unassignedBlocks []domain.TimeBlock
+	now := time.Now()
 
 	for _, b := range bookings {
-		startT, err := time.Parse("2006-01-02 15:04", b.BookingTime)
-		if err != nil {
+		if b.ID == excludeBookingID {
+			continue
+		}
+		startT, ok := domain.ParseBookingTime(b.BookingTime)
+		if !ok {
+			log.Printf("⚠️ [TIMESLOT] Booking %s has an unreadable booking_time %q, not counted on %s", b.ID, b.BookingTime, date)
 			continue
 		}
-		duration := 60 * time.Minute
-		endT := startT.Add(duration).Add(TravelBuffer)
+		duration := s.serviceDuration(b.ServiceID, durations)
 
+		// Overrun Detection (Stuck Job)
+		if b.JobStatus == domain.StatusWorking && startT.Add(duration).Before(now) {
+			duration = now.Add(30 * time.Minute).Sub(startT)
+		}
+
+		block := domain.BusyBlock(startT, duration, buffer)
 		if b.TechnicianID != "" {
-			techSchedules[b.TechnicianID] = append(techSchedules[b.TechnicianID], TimeBlock{Start: startT, End: endT})
+			techSchedules[b.TechnicianID] = append(techSchedules[b.TechnicianID], block)
 		} else {
-			unassignedBlocks = append(unassignedBlocks, TimeBlock{Start: startT, End: endT})
+			unassignedBlocks = append(unassignedBlocks, block)
 		}
 	}
 
-	// Fetch slot definitions
+	// 2. Fetch Standard Slot Definitions (The "Grid")
+	// We still use the "time_slots" collection to define the grid (8-10, 10-12...)
 	filter := fmt.Sprintf("date = '%s'", date)
 	records, err := s.app.FindRecordsByFilter("time_slots", filter, "start_time", 100, 0, nil)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to fetch slot definitions: %w", err)
 	}
 
-	// Auto-generate if needed
+	// [AUTO-GENERATE] If no slots exist for this date, create them on-the-fly
 	if len(records) == 0 {
 		if err := s.GenerateDefaultSlots(date, dynamicCapacity); err == nil {
 			records, _ = s.app.FindRecordsByFilter("time_slots", filter, "start_time", 100, 0, nil)
 		}
 	}
 
+	// Working day ends with the last window of the grid
+	var dayEnd time.Time
+	for _, record := range records {
+		if end, err := time.Parse("2006-01-02 15:04", date+" "+record.GetString("end_time")); err == nil && end.After(dayEnd) {
+			dayEnd = end
+		}
+	}
+
 	slots := make([]TimeSlot, 0, len(records))
-	now := time.Now()
 
 	for _, record := range records {
 		startTimeStr := record.GetString("start_time")
-		slotStart, _ := time.Parse("2006-01-02 15:04", date+" "+startTimeStr)
-		slotEnd := slotStart.Add(StandardSlotDuration)
+		slotStart, err := time.Parse("2006-01-02 15:04", date+" "+startTimeStr)
+		if err != nil {
+			continue
+		}
+		jobEnd := slotStart.Add(jobDuration)
 
-		// Skip past slots
+		// Filter past slots (2h advance notice)
 		if date == now.Format("2006-01-02") && slotStart.Sub(now).Hours() < 2 {
 			continue
 		}
 
-		// Count eligible free techs
+		// 3. Count techs whose timeline can absorb the whole job
+		fitsDay := dayEnd.IsZero() || !jobEnd.After(dayEnd)
 		availableTechsCount := 0
-		for _, tech := range eligibleTechs {
-			timeline := techSchedules[tech.ID]
-			isFree := true
-			for _, block := range timeline {
-				if slotStart.Before(block.End) && slotEnd.After(block.Start) {
-					isFree = false
-					break
+		if fitsDay {
+			for _, tech := range techs {
+				if day := daySchedules[tech.ID]; day != nil && !day.Covers(slotStart, jobEnd) {
+					continue // Outside working hours / partial leave
+				}
+				if !domain.OverlapsAny(slotStart, jobEnd, techSchedules[tech.ID]) {
+					availableTechsCount++
 				}
-			}
-			if isFree {
-				availableTechsCount++
 			}
 		}
 
-		// Subtract unassigned overlaps
-		for _, block := range unassignedBlocks {
-			if slotStart.Before(block.End) && slotEnd.After(block.Start) {
-				availableTechsCount--
-			}
-		}
-		if availableTechsCount < 0 {
-			availableTechsCount = 0
-		}
+		// Unassigned bookings will take one of those techs
+		availableTechsCount = domain.FreeCapacity(availableTechsCount, slotStart, jobEnd, unassignedBlocks)
 
-		// Determine status
-		status := "full"
-		isAvailable := false
-		if availableTechsCount >= 2 {
-			status = "available"
-			isAvailable = true
-		} else if availableTechsCount > 0 {
-			status = "limited"
-			isAvailable = true
-		} else {
-			persistentBookings := int(record.GetFloat("current_bookings"))
-			if persistentBookings < dynamicCapacity+2 {
-				status = "waitlist"
-				isAvailable = true
-			}
-		}
+		// 4. Determine Status & Availability ('current_bookings' on time_slots
+		// is the persistent counter used for the waitlist)
+		status, isAvailable := domain.ClassifySlot(availableTechsCount, int(record.GetFloat("current_bookings")), dynamicCapacity, fitsDay)
 
 		slots = append(slots, TimeSlot{
 			ID:              record.Id,
@@ -430,6 +266,30 @@ func (s *TimeSlotService) GetAvailableSlotsWithFilters(date string, customerZone
 	return slots, nil
 }
 
+// travelBuffer returns the brand's configured gap between two jobs
+func (s *TimeSlotService) travelBuffer() time.Duration {
+	var brand *domain.Brand
+	if s.brandRepo != nil {
+		brand, _ = s.brandRepo.GetDefault()
+	}
+	return brand.TravelBuffer()
+}
+
+// serviceDuration returns the estimated duration of a service (cached per call)
+func (s *TimeSlotService) serviceDuration(serviceID string, cache map[string]time.Duration) time.Duration {
+	if d, ok := cache[serviceID]; ok {
+		return d
+	}
+	d := time.Duration(domain.DefaultJobDurationMinutes) * time.Minute
+	if serviceID != "" && s.serviceRepo != nil {
+		if svc, err := s.serviceRepo.GetByID(serviceID); err == nil && svc.DurationMinutes > 0 {
+			d = time.Duration(svc.DurationMinutes) * time.Minute
+		}
+	}
+	cache[serviceID] = d
+	return d
+}
+
 // techHasSkill checks if technician has the required skill
 func techHasSkill(techSkills []string, requiredSkill string) bool {
 	for _, skill := range techSkills {
@@ -483,12 +343,18 @@ func (s *TimeSlotService) BookSlot(slotID, bookingID string) error {
 	// Double-check availability logic
 	date := slot.GetString("date")
 
-	// Re-run smart check just for this slot?
-	// For performance, we might trust the generic capacity check for now,
-	// or implement a lightweight "IsSlotAvailable(date, start, duration)" helper.
+	// Re-run the check with the booking's own service duration,
+	// ignoring the booking itself (it is already saved as unassigned).
+	serviceID := ""
+	if booking, err := s.app.FindRecordById("bookings", bookingID); err == nil {
+		serviceID = booking.GetString("service_id")
+	}
 
-	// MVP: Fetch availability for the whole day (cached?) and check this slot.
-	availableSlots, err := s.GetAvailableSlots(date)
+	activeTechs, err := s.techRepo.GetAvailable()
+	if err != nil {
+		return fmt.Errorf("failed to fetch active technicians: %w", err)
+	}
+	availableSlots, err := s.computeSlots(date, activeTechs, serviceID, bookingID)
 	if err != nil {
 		return err
 	}
@@ -517,12 +383,11 @@ func (s *TimeSlotService) BookSlot(slotID, bookingID string) error {
 }
 
 // CheckConflict validates if a specific scheduler slot conflicts with existing bookings for a technician
-// Rules:
-// 1. New Job Start Time >= Previous Job End Time + Travel Buffer (30m)
+// Rules (buffer = brand travel_buffer_minutes, default 30m):
+// 1. New Job Start Time >= Previous Job End Time + Travel Buffer
 // 2. New Job End Time + Travel Buffer <= Next Job Start Time
-// CheckConflict validates if a specific scheduler slot conflicts with existing bookings for a technician
 func (s *TimeSlotService) CheckConflict(techID string, date string, startTime string, newJobDuration int, newSlotID string, excludeBookingID string) error {
-	const TravelBufferMinutes = 30
+	buffer := s.travelBuffer()
 
 	// 1. Tính toán thời gian của Job MỚI đang định giao
 	newStart, err := time.Parse("2006-01-02 15:04", date+" "+startTime)
@@ -532,6 +397,18 @@ func (s *TimeSlotService) CheckConflict(techID string, date string, startTime st
 	// Thời gian kết thúc = Bắt đầu + Thời lượng dịch vụ
 	newEnd := newStart.Add(time.Duration(newJobDuration) * time.Minute)
 
+	// [NEW] Job phải nằm trong giờ làm việc của thợ (không trùng ngày nghỉ/phép)
+	if s.schedule != nil {
+		day, err := s.schedule.DaySchedule(techID, date)
+		if err != nil {
+			return fmt.Errorf("failed to fetch technician schedule: %w", err)
+		}
+		if !day.Covers(newStart, newEnd) {
+			return fmt.Errorf("Xung đột: Thợ không làm việc trong khung %s - %s ngày %s (nghỉ phép hoặc ngoài giờ làm)",
+				newStart.Format("15:04"), newEnd.Format("15:04"), date)
+		}
+	}
+
 	// 2. Lấy danh sách các Job ĐÃ CÓ của thợ trong ngày đó
 	// Lọc các job chưa huỷ (cancelled) và CHƯA HOÀN THÀNH (completed).
 	// Nếu job đã xong, thợ coi như rảnh (hoặc chấp nhận overlap vì đã xong việc).
@@ -553,13 +430,9 @@ func (s *TimeSlotService) CheckConflict(techID string, date string, startTime st
 			continue
 		}
 
-		jobTimeStr := job.GetString("booking_time") // "2006-01-02 15:04"
-		if len(jobTimeStr) < 16 {
-			continue
-		}
-
-		jobStart, err := time.Parse("2006-01-02 15:04", jobTimeStr)
-		if err != nil {
+		jobStart, ok := domain.ParseBookingTime(job.GetString("booking_time"))
+		if !ok {
+			log.Printf("⚠️ [TIMESLOT] Booking %s has an unreadable booking_time %q, not checked on %s", job.Id, job.GetString("booking_time"), date)
 			continue
 		}
 
@@ -578,7 +451,7 @@ func (s *TimeSlotService) CheckConflict(techID string, date string, startTime st
 
 		// 3. Xác định thời lượng của Job ĐÃ CÓ (Quan trọng)
 		// Mặc định 60 phút nếu không tìm thấy service
-		existingJobDuration := 60
+		existingJobDuration := domain.DefaultJobDurationMinutes
 
 		// Lấy thông tin service của job cũ để biết thời lượng chính xác
 		serviceID := job.GetString("service_id")
@@ -596,17 +469,14 @@ func (s *TimeSlotService) CheckConflict(techID string, date string, startTime st
 
 		// 4. Kiểm tra xung đột có tính Buffer di chuyển
 		// Quy tắc:
-		// - Job Mới phải bắt đầu SAU khi Job Cũ kết thúc + 30p
-		// - Job Mới phải kết thúc TRƯỚC khi Job Cũ bắt đầu - 30p (nếu chen vào trước)
+		// - Job Mới phải bắt đầu SAU khi Job Cũ kết thúc + buffer
+		// - Job Mới phải kết thúc TRƯỚC khi Job Cũ bắt đầu - buffer (nếu chen vào trước)
 
 		// Thời gian an toàn mà Job Cũ chiếm dụng (bao gồm cả di chuyển đến và đi)
-		// [Start - 30] ... [End + 30]
+		// [Start - buffer] ... [End + buffer]
 		// Nếu Job Mới chạm vào khoảng này thì là Conflict
 
-		bufferedStart := jobStart.Add(-time.Duration(TravelBufferMinutes) * time.Minute)
-		bufferedEnd := jobEnd.Add(time.Duration(TravelBufferMinutes) * time.Minute)
-
-		if newEnd.After(bufferedStart) && newStart.Before(bufferedEnd) {
+		if domain.BusyBlock(jobStart, jobEnd.Sub(jobStart), buffer).Overlaps(newStart, newEnd) {
 			return fmt.Errorf("Xung đột lịch trình: Thợ đã có việc từ %s đến %s (cộng thời gian di chuyển)",
 				jobStart.Format("15:04"), jobEnd.Format("15:04"))
 		}